@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"embed"
+	"encoding/base64"
 	"fmt"
 	"io/fs"
 	"kushkiv2/internal/db"
@@ -21,6 +22,7 @@ import (
 	goruntime "runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -46,10 +48,32 @@ type App struct {
 	taxService       *service.TaxService
 	productService   *service.ProductService
 	clientService    *service.ClientService
+	draftService     *service.DraftService
+	recurringService *service.RecurringService
+	batchService     *service.BatchService
+	inventoryService *service.InventoryService
+	purchaseService  *service.PurchaseService
+	receivedService  *service.ReceivedService
+	warehouseService *service.WarehouseService
+	lotService       *service.LotService
+	notificationService *service.NotificationService
+	reorderService   *service.ReorderService
+	countService     *service.CountService
+	unitService      *service.UnitService
+	priceListService *service.PriceListService
+	promotionService *service.PromotionService
+	catalogService   *service.CatalogService
+	kitService       *service.KitService
+	labelService     *service.LabelService
+	cashService      *service.CashService
 
 	// Satellite Server
 	satelliteToken string
 	serverPort     string
+
+	// Cliente de la venta en curso en el POS de escritorio, para cotizar los escaneos del móvil
+	posMu       sync.RWMutex
+	posClientID string
 }
 
 // NewApp creates a new App application struct
@@ -61,8 +85,12 @@ func NewApp() *App {
 		}
 	}
 
+	invoiceService := service.NewInvoiceService()
+	purchaseService := service.NewPurchaseService()
+	notificationService := service.NewNotificationService()
+
 	return &App{
-		invoiceService:   service.NewInvoiceService(),
+		invoiceService:   invoiceService,
 		reportService:    service.NewReportService(),
 		syncService:      service.NewSyncService(),
 		cloudService:     service.NewCloudService(),
@@ -73,6 +101,24 @@ func NewApp() *App {
 		taxService:       service.NewTaxService(),
 		productService:   service.NewProductService(),
 		clientService:    service.NewClientService(),
+		draftService:     service.NewDraftService(),
+		recurringService: service.NewRecurringService(invoiceService),
+		batchService:     service.NewBatchService(invoiceService),
+		inventoryService: service.NewInventoryService(),
+		purchaseService:  purchaseService,
+		receivedService:  service.NewReceivedService(purchaseService),
+		warehouseService: service.NewWarehouseService(),
+		lotService:       service.NewLotService(),
+		notificationService: notificationService,
+		reorderService:   service.NewReorderService(notificationService),
+		countService:     service.NewCountService(),
+		unitService:      service.NewUnitService(),
+		priceListService: service.NewPriceListService(),
+		promotionService: service.NewPromotionService(),
+		catalogService:   service.NewCatalogService(),
+		kitService:       service.NewKitService(),
+		labelService:     service.NewLabelService(),
+		cashService:      service.NewCashService(),
 		serverPort:       "8085", // Default port
 	}
 }
@@ -89,6 +135,16 @@ func (a *App) startup(ctx context.Context) {
 
 	a.startLicenseHeartbeat()
 	a.syncService.StartWorker()
+	a.draftService.StartPurgeWorker()
+	a.recurringService.StartScheduler(a.postProcesarFactura)
+	a.receivedService.StartQueueWorker()
+	a.lotService.StartExpiryWatcher(func(mensaje string) {
+		ref := "LOTES " + time.Now().Format("2006-01-02")
+		if n, _ := a.notificationService.Notificar(service.NotifVencimiento, ref, mensaje); n != nil {
+			a.NotifyFrontend("info", mensaje)
+		}
+	})
+	a.reorderService.StartStockWatcher(func(mensaje string) { a.NotifyFrontend("info", mensaje) })
 	
 	// Start Local API Server
 	go a.startLocalServer()
@@ -111,9 +167,13 @@ func (a *App) startLocalServer() {
 	api := e.Group("/api")
 	api.Use(a.authMiddlewareEcho)
 	api.GET("/inventory", a.handleGetInventoryEcho)
+	api.GET("/warehouses", a.handleGetWarehousesEcho)
 	api.POST("/stock", a.handleUpdateStockEcho)
 	api.POST("/product/create", a.handleCreateProductEcho)
+	api.GET("/product/image/:sku", a.handleProductImageEcho)
 	api.POST("/pos/scan", a.handlePOSScan)
+	api.GET("/count-sessions", a.handleGetCountSessionsEcho)
+	api.POST("/count", a.handleCountScanEcho)
 	api.GET("/status", func(c echo.Context) error { return c.String(http.StatusOK, "OK") })
 
 	// Static Assets
@@ -153,16 +213,54 @@ func (a *App) authMiddlewareEcho(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// handleGetInventoryEcho devuelve el catálogo. Con ?warehouse=CODIGO, Stock y Location son
+// los de esa bodega.
 func (a *App) handleGetInventoryEcho(c echo.Context) error {
 	products := a.GetProducts()
+	if bodega := c.QueryParam("warehouse"); bodega != "" {
+		stocks, err := a.warehouseService.GetInventarioBodega(bodega)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		for i := range products {
+			ps := stocks[products[i].SKU]
+			products[i].Stock = ps.Stock
+			products[i].Location = ps.Ubicacion
+		}
+	}
 	return c.JSON(http.StatusOK, products)
 }
 
+func (a *App) handleGetWarehousesEcho(c echo.Context) error {
+	bodegas, err := a.warehouseService.ListarBodegas(true)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Database error"})
+	}
+	return c.JSON(http.StatusOK, bodegas)
+}
+
+// handleProductImageEcho sirve la miniatura del producto (?size=full para la foto completa).
+func (a *App) handleProductImageEcho(c echo.Context) error {
+	ruta, err := a.productService.RutaImagen(c.Param("sku"), c.QueryParam("size") != "full")
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.File(ruta)
+}
+
+// CreateProductRequest es el alta desde el móvil, con la foto opcional tomada con la cámara
+// (base64, con o sin el prefijo data:image/...;base64,).
+type CreateProductRequest struct {
+	db.ProductDTO
+	Foto string `json:"Foto"`
+}
+
 func (a *App) handleCreateProductEcho(c echo.Context) error {
-	var req db.ProductDTO
-	if err := c.Bind(&req); err != nil {
+	var body CreateProductRequest
+	if err := c.Bind(&body); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Bad Request"})
 	}
+	req := body.ProductDTO
 
 	if req.SKU == "" && req.Barcode != "" {
 		req.SKU = req.Barcode
@@ -172,6 +270,18 @@ func (a *App) handleCreateProductEcho(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "SKU o Barcode es requerido"})
 	}
 
+	// Sin código de barras se asigna un EAN-13 interno para poder etiquetarlo
+	if req.Barcode == "" {
+		if codigo, err := a.labelService.CodigoInternoDisponible(); err == nil {
+			req.Barcode = codigo
+		}
+	}
+
+	// Los servicios no llevan inventario
+	if req.EsServicio {
+		req.Stock = 0
+	}
+
 	taxCodeInt, _ := strconv.Atoi(req.TaxCode)
 	if taxCodeInt == 0 {
 		taxCodeInt = 2 // Default IVA
@@ -182,28 +292,59 @@ func (a *App) handleCreateProductEcho(c echo.Context) error {
 		SKU:           req.SKU,
 		Name:          req.Name,
 		Price:         req.Price,
-		Stock:         req.Stock,
 		TaxCode:       taxCodeInt,
 		TaxPercentage: req.TaxPercentage,
 		Barcode:       req.Barcode,
 		Location:      req.Location,
+		EsServicio:    req.EsServicio,
 	}
 
 	if err := db.GetDB().Create(&product).Error; err != nil {
 		return c.JSON(http.StatusConflict, map[string]string{"error": "El SKU o Código ya existe"})
 	}
+	// El stock inicial entra por el kardex, igual que en SaveProduct
+	if req.Stock != 0 {
+		if _, err := a.inventoryService.AjustarStock(product.SKU, "", req.Stock, true, "Satélite", "Stock inicial"); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Error registrando stock inicial: " + err.Error()})
+		}
+		db.GetDB().First(&product, "sku = ?", product.SKU)
+	}
+
+	// Una foto inválida no impide el alta: se informa en imageError
+	resp := struct {
+		db.Product
+		ImageError string `json:"imageError,omitempty"`
+	}{}
+	if body.Foto != "" {
+		foto := body.Foto
+		if i := strings.Index(foto, ","); strings.HasPrefix(foto, "data:") && i >= 0 {
+			foto = foto[i+1:]
+		}
+		data, err := base64.StdEncoding.DecodeString(foto)
+		if err == nil {
+			_, err = a.productService.GuardarImagen(product.SKU, data, filepath.Join(a.assetsDir(), "productos"))
+		}
+		if err != nil {
+			logger.Error("Error guardando foto de %s: %v", product.SKU, err)
+			resp.ImageError = err.Error()
+		} else {
+			db.GetDB().First(&product, "sku = ?", product.SKU)
+		}
+	}
+	resp.Product = product
 
 	// Notify Frontend
 	runtime.EventsEmit(a.ctx, "inventory-updated", product)
 
-	return c.JSON(http.StatusCreated, product)
+	return c.JSON(http.StatusCreated, resp)
 }
 
 type StockUpdateRequest struct {
-	SKU      string `json:"sku"`
-	Quantity int    `json:"quantity"`
-	Location string `json:"location"` // New field
-	Type     string `json:"type"` 
+	SKU       string `json:"sku"`
+	Quantity  float64 `json:"quantity"`
+	Location  string `json:"location"` // New field
+	Type      string `json:"type"` 
+	Warehouse string `json:"warehouse"` // Bodega contada; vacío = principal
 }
 
 func (a *App) handleUpdateStockEcho(c echo.Context) error {
@@ -217,38 +358,46 @@ func (a *App) handleUpdateStockEcho(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Product not found"})
 	}
 
-	if req.Type == "set" {
-		product.Stock = req.Quantity
-	} else {
-		product.Stock += req.Quantity
-	}
-
-	// Actualizar ubicación si viene en el request
-	updates := map[string]interface{}{
-		"stock": product.Stock,
+	// El cambio de stock queda registrado en el kardex
+	nota := "Conteo desde satélite"
+	if req.Type != "set" {
+		nota = "Ajuste desde satélite"
 	}
-	if req.Location != "" {
-		updates["location"] = req.Location
-		product.Location = req.Location
+	mov, err := a.inventoryService.AjustarStock(product.SKU, req.Warehouse, req.Quantity, req.Type == "set", "Satélite", nota)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	// Use UpdateColumns to update multiple fields while avoiding other constraint triggers
-	if err := db.GetDB().Model(&product).Updates(updates).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Database error"})
+	// Actualizar ubicación si viene en el request: por bodega si se eligió una
+	if req.Location != "" {
+		if req.Warehouse != "" {
+			err = a.warehouseService.AsignarUbicacion(product.SKU, req.Warehouse, req.Location)
+		} else {
+			err = db.GetDB().Model(&product).UpdateColumn("location", req.Location).Error
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Database error"})
+		}
 	}
+	db.GetDB().First(&product, "sku = ?", req.SKU)
 
 	// Notify Frontend
 	runtime.EventsEmit(a.ctx, "inventory-updated", product)
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
+	resp := map[string]interface{}{
 		"success":  true,
 		"newStock": product.Stock,
-	})
+	}
+	if mov != nil {
+		resp["warehouseStock"] = mov.SaldoBodega
+	}
+	return c.JSON(http.StatusOK, resp)
 }
 
 type POSScanRequest struct {
 	SKU      string `json:"sku"`
-	Quantity int    `json:"quantity"` // Optional, defaults to 1 if 0
+	Quantity float64 `json:"quantity"` // Optional, defaults to 1 if 0
+	ClientID string  `json:"clientId"` // Optional, defaults to the client selected in the desktop POS
 }
 
 func (a *App) handlePOSScan(c echo.Context) error {
@@ -261,24 +410,89 @@ func (a *App) handlePOSScan(c echo.Context) error {
 		req.Quantity = 1
 	}
 
-	// Buscar el producto para enviar info completa (opcional, pero útil)
-	var product db.Product
-	if err := db.GetDB().First(&product, "sku = ? OR barcode = ?", req.SKU, req.SKU).Error; err != nil {
+	// Buscar el producto (o la presentación, si es el código de una caja) para enviar info completa
+	scan, err := a.unitService.BuscarPorCodigo(req.SKU)
+	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "Product not found"})
 	}
 
+	// Precio según la lista del cliente de la venta (y la escala de la cantidad leída)
+	clientID := req.ClientID
+	if clientID == "" {
+		a.posMu.RLock()
+		clientID = a.posClientID
+		a.posMu.RUnlock()
+	}
+	priceList := ""
+	if quote, err := a.priceListService.ResolverPrecio(clientID, scan.SKU, scan.Unidad, req.Quantity); err == nil {
+		scan.Precio = quote.Precio
+		priceList = quote.Lista
+	}
+
 	// Enviar evento a Desktop
-	// El frontend escuchará "pos-scan-event" y lo añadirá al carrito
+	// El frontend escuchará "pos-scan-event" y lo añadirá al carrito en la unidad y precio leídos
 	runtime.EventsEmit(a.ctx, "pos-scan-event", map[string]interface{}{
-		"sku":      product.SKU,
-		"quantity": req.Quantity,
-		"product":  product,
+		"sku":       scan.SKU,
+		"quantity":  req.Quantity,
+		"unit":      scan.Unidad,
+		"price":     scan.Precio,
+		"priceList": priceList,
+		"product":   scan.Product,
 	})
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": "Sent to POS",
+		"product": scan.Nombre,
+		"unit":    scan.Unidad,
+	})
+}
+
+// handleGetCountSessionsEcho lista las tomas físicas abiertas para elegir desde el móvil.
+func (a *App) handleGetCountSessionsEcho(c echo.Context) error {
+	list, err := a.countService.ListarConteos(service.ConteoAbierto)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+type CountScanRequest struct {
+	SessionID uint   `json:"sessionId"`
+	Code      string `json:"code"`
+	Quantity  float64 `json:"quantity"` // Optional, defaults to 1 if 0
+	Device    string `json:"device"`
+}
+
+// handleCountScanEcho suma una lectura del móvil a una toma física abierta.
+func (a *App) handleCountScanEcho(c echo.Context) error {
+	var req CountScanRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Bad Request"})
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Device == "" {
+		req.Device = "Móvil"
+	}
+
+	product, total, err := a.countService.RegistrarLectura(req.SessionID, req.Code, req.Quantity, req.Device)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	runtime.EventsEmit(a.ctx, "count-updated", map[string]interface{}{
+		"sessionId": req.SessionID,
+		"sku":       product.SKU,
+		"total":     total,
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"sku":     product.SKU,
 		"product": product.Name,
+		"total":   total,
 	})
 }
 
@@ -517,6 +731,85 @@ func (a *App) GetTopProducts() []service.TopProduct {
 	return products
 }
 
+// GetTopCategories devuelve las categorías más vendidas para gráficos.
+func (a *App) GetTopCategories() []service.TopGrupo {
+	list, err := a.reportService.GetTopCategories(5)
+	if err != nil {
+		logger.Error("Error obteniendo top categorías: %v", err)
+		return []service.TopGrupo{}
+	}
+	return list
+}
+
+// GetTopBrands devuelve las marcas más vendidas para gráficos.
+func (a *App) GetTopBrands() []service.TopGrupo {
+	list, err := a.reportService.GetTopBrands(5)
+	if err != nil {
+		logger.Error("Error obteniendo top marcas: %v", err)
+		return []service.TopGrupo{}
+	}
+	return list
+}
+
+// GetStockByClassification devuelve el inventario valorizado por categoría y marca.
+func (a *App) GetStockByClassification() *service.ReporteStockClasificacion {
+	reporte, err := a.reportService.GetStockByClassification()
+	if err != nil {
+		logger.Error("Error valorizando inventario: %v", err)
+		return &service.ReporteStockClasificacion{Categorias: []service.StockGrupo{}, Marcas: []service.StockGrupo{}}
+	}
+	return reporte
+}
+
+// GetInventoryValuation devuelve el inventario valorizado y su antigüedad al cierre de la fecha (YYYY-MM-DD, vacío = hoy).
+func (a *App) GetInventoryValuation(fechaStr string) *service.ReporteValorizacion {
+	_, fecha := rangoFechas("", fechaStr)
+	reporte, err := a.reportService.GetInventoryValuation(fecha)
+	if err != nil {
+		logger.Error("Error valorizando inventario: %v", err)
+		return &service.ReporteValorizacion{Productos: []service.ValorizacionProducto{}, Categorias: []service.StockGrupo{}, Bodegas: []service.StockGrupo{}, Antiguedad: []service.StockGrupo{}, Existencias: []service.ExistenciaBodega{}}
+	}
+	return reporte
+}
+
+// ExportInventoryValuation guarda en Excel la valorización del inventario a la fecha de corte.
+func (a *App) ExportInventoryValuation(fechaStr string) string {
+	_, fecha := rangoFechas("", fechaStr)
+	data, err := a.reportService.GenerateInventoryValuationExcel(fecha)
+	if err != nil {
+		return fmt.Sprintf("Error generando reporte: %v", err)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("Valorizacion_Inventario_%s.xlsx", fecha.Format("20060102")),
+		Title:           "Guardar Valorización de Inventario",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Archivos Excel", Pattern: "*.xlsx"},
+		},
+	})
+
+	if err != nil || path == "" {
+		return "Cancelado"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Sprintf("Error guardando archivo: %v", err)
+	}
+
+	return "Valorización exportada exitosamente"
+}
+
+// GetMarginReport devuelve la utilidad bruta por factura, producto, categoría, marca y mes en el rango (YYYY-MM-DD).
+func (a *App) GetMarginReport(startStr, endStr string) *service.ReporteMargenes {
+	start, end := rangoFechas(startStr, endStr)
+	reporte, err := a.reportService.GetMargins(start, end)
+	if err != nil {
+		logger.Error("Error calculando márgenes: %v", err)
+		return &service.ReporteMargenes{Facturas: []service.MargenFactura{}, Productos: []service.MargenProducto{}, Categorias: []service.MargenGrupo{}, Marcas: []service.MargenGrupo{}, Periodos: []service.MargenPeriodo{}}
+	}
+	return reporte
+}
+
 // GetDashboardStats calcula los KPIs para un rango de fechas específico.
 // Utiliza goroutines para realizar consultas a la base de datos en paralelo y mejorar la respuesta.
 func (a *App) GetDashboardStats(startStr, endStr string) DashboardStats {
@@ -625,7 +918,25 @@ func (a *App) CreateInvoice(data db.FacturaDTO) string {
 		return "Advertencia: Factura emitida pero no se pudo recuperar para guardar archivos."
 	}
 
-	// 3. Guardar Archivos Locales
+	// 3. Guardar Archivos Locales y 4. Enviar correo
+	a.postProcesarFactura(factura, data.ClienteEmail)
+
+	return fmt.Sprintf("Éxito: Factura %s emitida con clave %s", data.Secuencial, data.ClaveAcceso)
+}
+
+// PreviewInvoice calcula totales, advertencias, XML sin firmar y RIDE borrador sin emitir la factura.
+func (a *App) PreviewInvoice(data db.FacturaDTO) *service.InvoicePreview {
+	preview, err := a.invoiceService.PrevisualizarFactura(data)
+	if err != nil {
+		logger.Error("Error previsualizando factura: %v", err)
+		return &service.InvoicePreview{Valida: false, Error: err.Error(), Advertencias: []string{}}
+	}
+	return preview
+}
+
+// postProcesarFactura guarda los archivos locales de una factura emitida y envía el correo al cliente.
+func (a *App) postProcesarFactura(factura db.Factura, email string) {
+	// Guardar Archivos Locales
 	if errSave := a.saveDocument(factura.Secuencial, factura.FechaEmision, "xml", factura.XMLFirmado); errSave != nil {
 		fmt.Printf("Error guardando XML local: %v\n", errSave)
 	}
@@ -635,8 +946,8 @@ func (a *App) CreateInvoice(data db.FacturaDTO) string {
 		}
 	}
 
-	// 4. ENVIAR CORREO (SOLO SMTP LOCAL)
-	if data.ClienteEmail != "" && len(factura.PDFRIDE) > 0 {
+	// ENVIAR CORREO (SOLO SMTP LOCAL)
+	if email != "" && len(factura.PDFRIDE) > 0 {
 		config := a.GetEmisorConfig()
 
 		go func(email, sec string, pdf []byte, conf *db.EmisorConfigDTO) {
@@ -672,142 +983,483 @@ func (a *App) CreateInvoice(data db.FacturaDTO) string {
 				logEntry.Mensaje = "Enviado correctamente"
 			}
 			db.GetDB().Create(&logEntry)
-		}(data.ClienteEmail, factura.Secuencial, factura.PDFRIDE, config)
+		}(email, factura.Secuencial, factura.PDFRIDE, config)
 	}
-
-	return fmt.Sprintf("Éxito: Factura %s emitida con clave %s", data.Secuencial, data.ClaveAcceso)
 }
 
-// ResendInvoiceEmail reenvía una factura usando SMTP local.
-func (a *App) ResendInvoiceEmail(claveAcceso string) string {
-	var factura db.Factura
-	if err := db.GetDB().First(&factura, "clave_acceso = ?", claveAcceso).Error; err != nil {
-		return "Error: Factura no encontrada"
+// LoadRejectedInvoice carga una factura DEVUELTA o NO AUTORIZADO para corregirla.
+func (a *App) LoadRejectedInvoice(claveAcceso string) *db.FacturaDTO {
+	dto, err := a.invoiceService.CargarFacturaRechazada(claveAcceso)
+	if err != nil {
+		logger.Error("Error cargando factura rechazada: %v", err)
+		return nil
 	}
+	return dto
+}
 
-	var cliente db.Client
-	if err := db.GetDB().First(&cliente, "id = ?", factura.ClienteID).Error; err != nil || cliente.Email == "" {
-		return "Error: El cliente no tiene un correo electrónico configurado"
+// ResubmitInvoice reenvía al SRI una factura rechazada con los datos corregidos.
+func (a *App) ResubmitInvoice(claveAcceso string, data db.FacturaDTO) string {
+	if err := a.invoiceService.ReenviarFacturaCorregida(claveAcceso, &data); err != nil {
+		return fmt.Sprintf("Error: %v", err)
 	}
 
-	// Envío Asíncrono
-	config := a.GetEmisorConfig()
-
-	go func(email, sec string, pdf []byte, conf *db.EmisorConfigDTO) {
-		// Validar configuración SMTP
-		if conf == nil || conf.SMTPHost == "" || conf.SMTPPort == 0 || conf.SMTPUser == "" {
-			a.NotifyFrontend("error", fmt.Sprintf("No se reenvió a %s: Servidor SMTP no configurado.", email))
-			return
-		}
-
-		smtpConfig := service.SMTPConfig{
-			Host:     conf.SMTPHost,
-			Port:     conf.SMTPPort,
-			User:     conf.SMTPUser,
-			Password: conf.SMTPPassword,
-		}
-
-		err := a.mailService.SendInvoiceEmail(smtpConfig, email, conf.RazonSocial, pdf, sec)
-
-		logEntry := db.MailLog{
-			FacturaClave: claveAcceso,
-			Email:        email,
-			Fecha:        time.Now(),
-		}
+	var factura db.Factura
+	if err := db.GetDB().First(&factura, "clave_acceso = ?", data.ClaveAcceso).Error; err != nil {
+		return "Advertencia: Factura reenviada pero no se pudo recuperar para guardar archivos."
+	}
 
-		if err != nil {
-			a.NotifyFrontend("error", fmt.Sprintf("Error SMTP: %v", err))
-			logEntry.Estado = "FAILED"
-			logEntry.Mensaje = err.Error()
-		} else {
-			a.NotifyFrontend("success", fmt.Sprintf("Factura reenviada a %s", email))
-			logEntry.Estado = "SUCCESS"
-			logEntry.Mensaje = "Reenviado correctamente"
-		}
-		db.GetDB().Create(&logEntry)
-	}(cliente.Email, factura.Secuencial, factura.PDFRIDE, config)
+	a.postProcesarFactura(factura, data.ClienteEmail)
 
-	return "Procesando envío..."
+	return fmt.Sprintf("Éxito: Factura %s reenviada con clave %s (Estado: %s)", factura.Secuencial, factura.ClaveAcceso, factura.EstadoSRI)
 }
 
-// GetMailLogs devuelve el historial de correos.
-func (a *App) GetMailLogs() []db.MailLogDTO {
-	var logs []db.MailLog
-	db.GetDB().Order("fecha desc").Limit(50).Find(&logs)
+// VoidInvoice marca como anulada una factura autorizada y reingresa su stock.
+// La anulación ante el SRI debe solicitarse previamente en su portal.
+func (a *App) VoidInvoice(claveAcceso, motivo string) string {
+	if strings.TrimSpace(motivo) == "" {
+		return "Error: Debe indicar el motivo de la anulación"
+	}
+	if err := a.invoiceService.AnularFactura(claveAcceso, motivo); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	runtime.EventsEmit(a.ctx, "inventory-updated", claveAcceso)
+	return "Éxito: Factura anulada y stock reingresado"
+}
 
-	var dtos []db.MailLogDTO
-	for _, l := range logs {
-		dtos = append(dtos, db.MailLogDTO{
-			ID:           l.ID,
-			FacturaClave: l.FacturaClave,
-			Email:        l.Email,
-			Estado:       l.Estado,
-			Mensaje:      l.Mensaje,
-			Fecha:        l.Fecha.Format("02/01/2006 15:04:05"),
+// GetInvoiceHistory devuelve los intentos previos de envío de una factura.
+func (a *App) GetInvoiceHistory(claveAcceso string) []db.FacturaHistorialDTO {
+	historial, err := a.invoiceService.GetHistorial(claveAcceso)
+	if err != nil {
+		logger.Error("Error obteniendo historial: %v", err)
+		return []db.FacturaHistorialDTO{}
+	}
+
+	dtos := make([]db.FacturaHistorialDTO, 0, len(historial))
+	for _, h := range historial {
+		dtos = append(dtos, db.FacturaHistorialDTO{
+			ClaveAcceso:  h.ClaveAcceso,
+			Intento:      h.Intento,
+			Fecha:        h.FechaEmision.Format("02/01/2006 15:04"),
+			Total:        h.Total,
+			Estado:       h.EstadoSRI,
+			MensajeError: h.MensajeError,
 		})
 	}
 	return dtos
 }
 
-// SelectBackupPath abre diálogo para seleccionar carpeta.
-func (a *App) SelectBackupPath() string {
-	selection, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "Seleccionar Carpeta para Respaldos Automáticos",
-	})
+// --- CAJA ---
+
+// OpenCashSession abre el turno de caja del usuario en el punto de emisión (vacío = el del emisor).
+func (a *App) OpenCashSession(estab, ptoEmi, usuario string, fondo float64) *db.CashSessionDTO {
+	caja, err := a.cashService.AbrirCaja(estab, ptoEmi, usuario, fondo)
 	if err != nil {
-		return ""
+		logger.Error("Error abriendo caja: %v", err)
+		a.NotifyFrontend("error", err.Error())
+		return nil
 	}
-	return selection
+	return caja
 }
 
-// CreateBackup ejecuta el respaldo.
-func (a *App) CreateBackup() error {
-	config := a.GetEmisorConfig()
-	if config == nil {
-		return fmt.Errorf("no hay configuración")
+// GetOpenCashSession devuelve la caja abierta del punto de emisión, o nil si no hay.
+func (a *App) GetOpenCashSession(estab, ptoEmi string) *db.CashSessionDTO {
+	caja, err := a.cashService.CajaAbiertaEn(estab, ptoEmi)
+	if err != nil {
+		logger.Error("Error consultando caja abierta: %v", err)
+		return nil
 	}
+	return caja
+}
 
-	backupDir := config.StoragePath
-	if backupDir == "" {
-		backupDir, _ = os.UserHomeDir()
-	} else {
-		backupDir = filepath.Join(backupDir, "Backups")
-		_ = os.MkdirAll(backupDir, 0755)
+// GetCashSession devuelve la caja con sus ventas por forma de pago, movimientos y arqueo.
+func (a *App) GetCashSession(id uint) *db.CashSessionDTO {
+	caja, err := a.cashService.GetCaja(id)
+	if err != nil {
+		logger.Error("Error obteniendo caja %d: %v", id, err)
+		return nil
 	}
+	return caja
+}
 
-	filename := fmt.Sprintf("Backup_Kushki_%s.zip", time.Now().Format("20060102_150405"))
-	destPath := filepath.Join(backupDir, filename)
-
-	sources := make(map[string]string)
-	cwd, _ := os.Getwd()
-	dbPath := filepath.Join(cwd, "kushki.db")
-	sources[dbPath] = "DB"
-
-	if config.StoragePath != "" {
-		sources[config.StoragePath] = "Docs"
+// GetCashSessions lista las cajas abiertas en el rango (YYYY-MM-DD).
+func (a *App) GetCashSessions(startStr, endStr string) []db.CashSessionDTO {
+	start, end := rangoFechas(startStr, endStr)
+	list, err := a.cashService.ListarCajas(start, end)
+	if err != nil {
+		logger.Error("Error listando cajas: %v", err)
+		return []db.CashSessionDTO{}
 	}
-
-	return util.CreateBackupZip(destPath, sources)
+	return list
 }
 
-type BackupDTO struct {
-	Name string `json:"name"`
-	Size string `json:"size"`
-	Date string `json:"date"`
-	Path string `json:"path"`
+// GetCashDenominations devuelve los billetes y monedas para el arqueo.
+func (a *App) GetCashDenominations() []float64 {
+	return service.DenominacionesEfectivo
 }
 
-type DailySale struct {
-	Date  string  `json:"date"`
-	Total float64 `json:"total"`
+// AddCashMovement registra un ingreso o egreso de efectivo (INGRESO / EGRESO) en la caja abierta.
+func (a *App) AddCashMovement(id uint, tipo string, monto float64, motivo, usuario string) string {
+	if _, err := a.cashService.RegistrarMovimientoCaja(id, tipo, monto, motivo, usuario); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Movimiento registrado"
 }
 
-type DashboardStats struct {
-	TotalFacturas int64       `json:"totalFacturas"`
-	TotalVentas   float64     `json:"totalVentas"`
-	Pendientes    int         `json:"pendientes"`
-	SRIOnline     bool        `json:"sriOnline"`
-	SalesTrend    []DailySale `json:"salesTrend"`
+// CloseCashSession cierra la caja con el arqueo por denominación y guarda el cierre Z.
+func (a *App) CloseCashSession(id uint, conteo []db.CashCountDTO, usuario, observacion string) string {
+	caja, err := a.cashService.CerrarCaja(id, conteo, usuario, observacion)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	switch {
+	case caja.Diferencia > 0:
+		return fmt.Sprintf("Éxito: Caja cerrada con sobrante de $%.2f", caja.Diferencia)
+	case caja.Diferencia < 0:
+		return fmt.Sprintf("Éxito: Caja cerrada con faltante de $%.2f", -caja.Diferencia)
+	}
+	return "Éxito: Caja cerrada sin diferencias"
+}
+
+// ExportCashClosePDF guarda el cierre Z de una caja cerrada.
+func (a *App) ExportCashClosePDF(id uint) string {
+	data, err := a.cashService.GetCierrePDF(id)
+	if err != nil {
+		return fmt.Sprintf("Error generando cierre: %v", err)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("CierreCaja_%d.pdf", id),
+		Title:           "Guardar Cierre de Caja",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Archivos PDF", Pattern: "*.pdf"},
+		},
+	})
+	if err != nil || path == "" {
+		return "Cancelado"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Sprintf("Error guardando archivo: %v", err)
+	}
+	return "Cierre exportado exitosamente"
+}
+
+// --- BORRADORES DE FACTURA ---
+
+// SaveInvoiceDraft guarda (autoguardado) una factura en construcción. Devuelve el borrador con su ID.
+func (a *App) SaveInvoiceDraft(draft db.InvoiceDraftDTO) *db.InvoiceDraftDTO {
+	saved, err := a.draftService.GuardarBorrador(draft)
+	if err != nil {
+		logger.Error("Error guardando borrador: %v", err)
+		return nil
+	}
+	return saved
+}
+
+// GetInvoiceDrafts lista los borradores abiertos del usuario/terminal indicados.
+func (a *App) GetInvoiceDrafts(usuario, terminal string) []db.InvoiceDraftDTO {
+	drafts, err := a.draftService.ListarBorradores(usuario, terminal)
+	if err != nil {
+		logger.Error("Error listando borradores: %v", err)
+		return []db.InvoiceDraftDTO{}
+	}
+	return drafts
+}
+
+// GetInvoiceDraft recupera un borrador para seguir editándolo.
+func (a *App) GetInvoiceDraft(id uint) *db.InvoiceDraftDTO {
+	draft, err := a.draftService.ObtenerBorrador(id)
+	if err != nil {
+		logger.Error("Error cargando borrador: %v", err)
+		return nil
+	}
+	return draft
+}
+
+// DeleteInvoiceDraft descarta un borrador.
+func (a *App) DeleteInvoiceDraft(id uint) string {
+	if err := a.draftService.EliminarBorrador(id); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Borrador eliminado"
+}
+
+// EmitInvoiceDraft emite la factura contenida en un borrador y lo elimina si la emisión es exitosa.
+func (a *App) EmitInvoiceDraft(id uint) string {
+	data, err := a.draftService.EmitirBorrador(id, a.invoiceService)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+
+	var factura db.Factura
+	if err := db.GetDB().First(&factura, "clave_acceso = ?", data.ClaveAcceso).Error; err != nil {
+		return "Advertencia: Factura emitida pero no se pudo recuperar para guardar archivos."
+	}
+
+	a.postProcesarFactura(factura, data.ClienteEmail)
+
+	return fmt.Sprintf("Éxito: Factura %s emitida con clave %s", data.Secuencial, data.ClaveAcceso)
+}
+
+// --- FACTURACIÓN RECURRENTE ---
+
+// SaveRecurringInvoice crea o actualiza una plantilla de facturación recurrente.
+func (a *App) SaveRecurringInvoice(data db.RecurringInvoiceDTO) string {
+	saved, err := a.recurringService.GuardarPlantilla(data)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Éxito: Plantilla guardada. Próxima emisión: %s", saved.ProximaEmision)
+}
+
+// GetRecurringInvoices lista las plantillas de facturación recurrente.
+func (a *App) GetRecurringInvoices() []db.RecurringInvoiceDTO {
+	list, err := a.recurringService.ListarPlantillas()
+	if err != nil {
+		logger.Error("Error listando plantillas recurrentes: %v", err)
+		return []db.RecurringInvoiceDTO{}
+	}
+	return list
+}
+
+// DeleteRecurringInvoice elimina una plantilla recurrente.
+func (a *App) DeleteRecurringInvoice(id uint) string {
+	if err := a.recurringService.EliminarPlantilla(id); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Plantilla eliminada"
+}
+
+// PreviewRecurringRun muestra las facturas que se emitirán en los próximos `dias` días.
+func (a *App) PreviewRecurringRun(dias int) []service.RecurringPreview {
+	if dias <= 0 {
+		dias = 30
+	}
+	preview, err := a.recurringService.PrevisualizarEjecucion(time.Now().AddDate(0, 0, dias))
+	if err != nil {
+		logger.Error("Error previsualizando facturación recurrente: %v", err)
+		return []service.RecurringPreview{}
+	}
+	return preview
+}
+
+// RunRecurringNow emite de inmediato las facturas recurrentes vencidas.
+func (a *App) RunRecurringNow() string {
+	runs, err := a.recurringService.EjecutarPendientes(time.Now(), a.postProcesarFactura)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+
+	fallidas := 0
+	for _, r := range runs {
+		if r.Estado != "EXITO" {
+			fallidas++
+		}
+	}
+	if fallidas > 0 {
+		return fmt.Sprintf("Advertencia: %d facturas emitidas, %d con error. Revise el log de ejecuciones.", len(runs)-fallidas, fallidas)
+	}
+	return fmt.Sprintf("Éxito: %d facturas recurrentes emitidas", len(runs))
+}
+
+// GetRecurringRuns devuelve el log de ejecuciones (plantillaID 0 = todas).
+func (a *App) GetRecurringRuns(plantillaID uint) []db.RecurringRunDTO {
+	runs, err := a.recurringService.GetEjecuciones(plantillaID, 200)
+	if err != nil {
+		logger.Error("Error obteniendo ejecuciones recurrentes: %v", err)
+		return []db.RecurringRunDTO{}
+	}
+	return runs
+}
+
+// --- EMISIÓN MASIVA ---
+
+// ValidateInvoiceBatch abre un archivo XLSX, CSV o JSON y valida todas sus facturas sin emitir.
+// Devuelve nil si el usuario cancela.
+func (a *App) ValidateInvoiceBatch() *service.LoteValidado {
+	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Seleccionar Lote de Facturas",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Lotes (Excel, CSV, JSON)", Pattern: "*.xlsx;*.csv;*.json"},
+		},
+	})
+	if err != nil || selection == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(selection)
+	if err != nil {
+		return &service.LoteValidado{Errores: []service.ErrorLote{{Mensaje: fmt.Sprintf("Error abriendo archivo: %v", err)}}}
+	}
+
+	lote, err := a.batchService.ParsearLote(selection, data)
+	if err != nil {
+		return &service.LoteValidado{Archivo: filepath.Base(selection), Errores: []service.ErrorLote{{Mensaje: err.Error()}}}
+	}
+	return lote
+}
+
+// EmitInvoiceBatch emite las facturas de un lote ya validado con la concurrencia indicada.
+func (a *App) EmitInvoiceBatch(facturas []service.LoteFactura, concurrencia int) *service.ResumenLote {
+	resumen := a.batchService.EmitirLote(facturas, concurrencia, a.postProcesarFactura)
+	a.NotifyFrontend("info", fmt.Sprintf("Lote finalizado: %d emitidas, %d con error", resumen.Emitidas, resumen.Fallidas))
+	return resumen
+}
+
+// ExportBatchReport guarda en Excel el resultado de una emisión masiva.
+func (a *App) ExportBatchReport(resultados []service.ResultadoLote) string {
+	data, err := a.batchService.GenerarReporteLote(resultados)
+	if err != nil {
+		return fmt.Sprintf("Error generando reporte: %v", err)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("Resultado_Lote_%s.xlsx", time.Now().Format("20060102_1504")),
+		Title:           "Guardar Resultado del Lote",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Archivos Excel", Pattern: "*.xlsx"},
+		},
+	})
+	if err != nil || path == "" {
+		return "Cancelado"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Sprintf("Error guardando archivo: %v", err)
+	}
+	return "Éxito: Reporte del lote exportado"
+}
+
+// ResendInvoiceEmail reenvía una factura usando SMTP local.
+func (a *App) ResendInvoiceEmail(claveAcceso string) string {
+	var factura db.Factura
+	if err := db.GetDB().First(&factura, "clave_acceso = ?", claveAcceso).Error; err != nil {
+		return "Error: Factura no encontrada"
+	}
+
+	var cliente db.Client
+	if err := db.GetDB().First(&cliente, "id = ?", factura.ClienteID).Error; err != nil || cliente.Email == "" {
+		return "Error: El cliente no tiene un correo electrónico configurado"
+	}
+
+	// Envío Asíncrono
+	config := a.GetEmisorConfig()
+
+	go func(email, sec string, pdf []byte, conf *db.EmisorConfigDTO) {
+		// Validar configuración SMTP
+		if conf == nil || conf.SMTPHost == "" || conf.SMTPPort == 0 || conf.SMTPUser == "" {
+			a.NotifyFrontend("error", fmt.Sprintf("No se reenvió a %s: Servidor SMTP no configurado.", email))
+			return
+		}
+
+		smtpConfig := service.SMTPConfig{
+			Host:     conf.SMTPHost,
+			Port:     conf.SMTPPort,
+			User:     conf.SMTPUser,
+			Password: conf.SMTPPassword,
+		}
+
+		err := a.mailService.SendInvoiceEmail(smtpConfig, email, conf.RazonSocial, pdf, sec)
+
+		logEntry := db.MailLog{
+			FacturaClave: claveAcceso,
+			Email:        email,
+			Fecha:        time.Now(),
+		}
+
+		if err != nil {
+			a.NotifyFrontend("error", fmt.Sprintf("Error SMTP: %v", err))
+			logEntry.Estado = "FAILED"
+			logEntry.Mensaje = err.Error()
+		} else {
+			a.NotifyFrontend("success", fmt.Sprintf("Factura reenviada a %s", email))
+			logEntry.Estado = "SUCCESS"
+			logEntry.Mensaje = "Reenviado correctamente"
+		}
+		db.GetDB().Create(&logEntry)
+	}(cliente.Email, factura.Secuencial, factura.PDFRIDE, config)
+
+	return "Procesando envío..."
+}
+
+// GetMailLogs devuelve el historial de correos.
+func (a *App) GetMailLogs() []db.MailLogDTO {
+	var logs []db.MailLog
+	db.GetDB().Order("fecha desc").Limit(50).Find(&logs)
+
+	var dtos []db.MailLogDTO
+	for _, l := range logs {
+		dtos = append(dtos, db.MailLogDTO{
+			ID:           l.ID,
+			FacturaClave: l.FacturaClave,
+			Email:        l.Email,
+			Estado:       l.Estado,
+			Mensaje:      l.Mensaje,
+			Fecha:        l.Fecha.Format("02/01/2006 15:04:05"),
+		})
+	}
+	return dtos
+}
+
+// SelectBackupPath abre diálogo para seleccionar carpeta.
+func (a *App) SelectBackupPath() string {
+	selection, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Seleccionar Carpeta para Respaldos Automáticos",
+	})
+	if err != nil {
+		return ""
+	}
+	return selection
+}
+
+// CreateBackup ejecuta el respaldo.
+func (a *App) CreateBackup() error {
+	config := a.GetEmisorConfig()
+	if config == nil {
+		return fmt.Errorf("no hay configuración")
+	}
+
+	backupDir := config.StoragePath
+	if backupDir == "" {
+		backupDir, _ = os.UserHomeDir()
+	} else {
+		backupDir = filepath.Join(backupDir, "Backups")
+		_ = os.MkdirAll(backupDir, 0755)
+	}
+
+	filename := fmt.Sprintf("Backup_Kushki_%s.zip", time.Now().Format("20060102_150405"))
+	destPath := filepath.Join(backupDir, filename)
+
+	sources := make(map[string]string)
+	cwd, _ := os.Getwd()
+	dbPath := filepath.Join(cwd, "kushki.db")
+	sources[dbPath] = "DB"
+
+	if config.StoragePath != "" {
+		sources[config.StoragePath] = "Docs"
+	}
+
+	return util.CreateBackupZip(destPath, sources)
+}
+
+type BackupDTO struct {
+	Name string `json:"name"`
+	Size string `json:"size"`
+	Date string `json:"date"`
+	Path string `json:"path"`
+}
+
+type DailySale struct {
+	Date  string  `json:"date"`
+	Total float64 `json:"total"`
+}
+
+type DashboardStats struct {
+	TotalFacturas int64       `json:"totalFacturas"`
+	TotalVentas   float64     `json:"totalVentas"`
+	Pendientes    int         `json:"pendientes"`
+	SRIOnline     bool        `json:"sriOnline"`
+	SalesTrend    []DailySale `json:"salesTrend"`
 }
 
 type FacturasResponse struct {
@@ -1172,19 +1824,7 @@ func (a *App) SelectAndSaveLogo() string {
 		return ""
 	}
 
-	// Definir dónde guardar (Carpeta de usuario/.kushki/assets o local)
-	// Usaremos la StoragePath definida o un default
-	config := a.GetEmisorConfig()
-	targetDir := "assets"
-	if config != nil && config.StoragePath != "" {
-		targetDir = filepath.Join(config.StoragePath, "assets")
-	} else {
-		// Fallback local
-		cwd, _ := os.Getwd()
-		targetDir = filepath.Join(cwd, "assets")
-	}
-
-	finalPath, err := util.ProcessAndSaveLogo(selection, targetDir)
+	finalPath, err := util.ProcessAndSaveLogo(selection, a.assetsDir())
 	if err != nil {
 		logger.Error("Error procesando logo: %v", err)
 		return "Error procesando imagen"
@@ -1193,10 +1833,21 @@ func (a *App) SelectAndSaveLogo() string {
 	return finalPath
 }
 
-// TestSMTPConnection verifica si las credenciales de correo funcionan.
-func (a *App) TestSMTPConnection(dto db.EmisorConfigDTO) string {
-	smtpConfig := service.SMTPConfig{
-		Host:     dto.SMTPHost,
+// assetsDir devuelve la carpeta de imágenes: StoragePath/assets o, sin configurar, ./assets.
+func (a *App) assetsDir() string {
+	config := a.GetEmisorConfig()
+	if config != nil && config.StoragePath != "" {
+		return filepath.Join(config.StoragePath, "assets")
+	}
+	// Fallback local
+	cwd, _ := os.Getwd()
+	return filepath.Join(cwd, "assets")
+}
+
+// TestSMTPConnection verifica si las credenciales de correo funcionan.
+func (a *App) TestSMTPConnection(dto db.EmisorConfigDTO) string {
+	smtpConfig := service.SMTPConfig{
+		Host:     dto.SMTPHost,
 		Port:     dto.SMTPPort,
 		User:     dto.SMTPUser,
 		Password: dto.SMTPPassword,
@@ -1216,9 +1867,10 @@ func (a *App) TestSMTPConnection(dto db.EmisorConfigDTO) string {
 func (a *App) GetClients() []db.ClientDTO {
 	var clients []db.Client
 	db.GetDB().Find(&clients)
+	listas := a.nombresListasPrecio()
 	var dtos []db.ClientDTO
 	for _, c := range clients {
-		dtos = append(dtos, db.ClientDTO{ID: c.ID, TipoID: c.TipoID, Nombre: c.Nombre, Direccion: c.Direccion, Email: c.Email, Telefono: c.Telefono})
+		dtos = append(dtos, db.ClientDTO{ID: c.ID, TipoID: c.TipoID, Nombre: c.Nombre, Direccion: c.Direccion, Email: c.Email, Telefono: c.Telefono, ListaPrecioID: c.ListaPrecioID, ListaPrecio: listas[c.ListaPrecioID], Segmento: c.Segmento})
 	}
 	return dtos
 }
@@ -1228,13 +1880,27 @@ func (a *App) SearchClients(query string) []db.ClientDTO {
 	likeQuery := "%" + query + "%"
 	// OPTIMIZACIÓN: Limitamos a 50 resultados para evitar congelar la UI con grandes volúmenes de datos.
 	db.GetDB().Where("nombre LIKE ? OR id LIKE ?", likeQuery, likeQuery).Limit(50).Find(&clients)
+	listas := a.nombresListasPrecio()
 	var dtos []db.ClientDTO
 	for _, c := range clients {
-		dtos = append(dtos, db.ClientDTO{ID: c.ID, TipoID: c.TipoID, Nombre: c.Nombre, Direccion: c.Direccion, Email: c.Email, Telefono: c.Telefono})
+		dtos = append(dtos, db.ClientDTO{ID: c.ID, TipoID: c.TipoID, Nombre: c.Nombre, Direccion: c.Direccion, Email: c.Email, Telefono: c.Telefono, ListaPrecioID: c.ListaPrecioID, ListaPrecio: listas[c.ListaPrecioID], Segmento: c.Segmento})
 	}
 	return dtos
 }
 
+// nombresListasPrecio indexa por ID los nombres de las listas de precios, para los listados de clientes.
+func (a *App) nombresListasPrecio() map[uint]string {
+	nombres := map[uint]string{}
+	listas, err := a.priceListService.ListarListas()
+	if err != nil {
+		return nombres
+	}
+	for _, l := range listas {
+		nombres[l.ID] = l.Nombre
+	}
+	return nombres
+}
+
 func (a *App) SaveClient(dto db.ClientDTO) string {
 	var existing db.Client
 	result := db.GetDB().First(&existing, "id = ?", dto.ID)
@@ -1244,11 +1910,16 @@ func (a *App) SaveClient(dto db.ClientDTO) string {
 		existing.Direccion = dto.Direccion
 		existing.Email = dto.Email
 		existing.Telefono = dto.Telefono
+		existing.Segmento = strings.ToUpper(strings.TrimSpace(dto.Segmento))
+		// La lista se quita con AssignPriceList; un formulario sin lista no la borra
+		if dto.ListaPrecioID != 0 {
+			existing.ListaPrecioID = dto.ListaPrecioID
+		}
 		if err := db.GetDB().Save(&existing).Error; err != nil {
 			return fmt.Sprintf("Error actualizando cliente: %v", err)
 		}
 	} else {
-		newClient := db.Client{ID: dto.ID, TipoID: dto.TipoID, Nombre: dto.Nombre, Direccion: dto.Direccion, Email: dto.Email, Telefono: dto.Telefono}
+		newClient := db.Client{ID: dto.ID, TipoID: dto.TipoID, Nombre: dto.Nombre, Direccion: dto.Direccion, Email: dto.Email, Telefono: dto.Telefono, ListaPrecioID: dto.ListaPrecioID, Segmento: strings.ToUpper(strings.TrimSpace(dto.Segmento))}
 		if err := db.GetDB().Create(&newClient).Error; err != nil {
 			return fmt.Sprintf("Error creando cliente: %v", err)
 		}
@@ -1263,11 +1934,230 @@ func (a *App) DeleteClient(id string) string {
 	return "Cliente eliminado"
 }
 
+// --- LISTAS DE PRECIOS ---
+
+// GetPriceLists lista las listas de precios con sus clientes asignados.
+func (a *App) GetPriceLists() []db.PriceListDTO {
+	list, err := a.priceListService.ListarListas()
+	if err != nil {
+		logger.Error("Error listando listas de precios: %v", err)
+		return []db.PriceListDTO{}
+	}
+	return list
+}
+
+// GetPriceList devuelve una lista de precios con sus reglas por producto.
+func (a *App) GetPriceList(id uint) *db.PriceListDTO {
+	lista, err := a.priceListService.GetLista(id)
+	if err != nil {
+		logger.Error("Error consultando lista de precios: %v", err)
+		return nil
+	}
+	return lista
+}
+
+// SavePriceList crea o actualiza una lista de precios y sus reglas.
+func (a *App) SavePriceList(dto db.PriceListDTO) string {
+	lista, err := a.priceListService.GuardarLista(dto)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Éxito: Lista %s guardada con %d reglas", lista.Nombre, len(lista.Items))
+}
+
+// DeletePriceList elimina una lista; sus clientes vuelven al precio base.
+func (a *App) DeletePriceList(id uint) string {
+	if err := a.priceListService.EliminarLista(id); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Lista de precios eliminada"
+}
+
+// AssignPriceList asigna una lista de precios a un cliente (0 = precio base).
+func (a *App) AssignPriceList(clientID string, listID uint) string {
+	if err := a.priceListService.AsignarLista(clientID, listID); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Lista de precios asignada"
+}
+
+// ResolvePrice devuelve el precio de un producto para un cliente, unidad y cantidad.
+func (a *App) ResolvePrice(clientID, sku, unidad string, cantidad float64) *db.PriceQuoteDTO {
+	quote, err := a.priceListService.ResolverPrecio(clientID, sku, unidad, cantidad)
+	if err != nil {
+		logger.Error("Error resolviendo precio: %v", err)
+		return nil
+	}
+	return quote
+}
+
+// ResolveCartPrices recalcula los precios del carrito para el cliente (al elegirlo o cambiar cantidades).
+func (a *App) ResolveCartPrices(clientID string, items []db.InvoiceItem) []db.InvoiceItem {
+	resolved, err := a.priceListService.ResolverItems(clientID, items)
+	if err != nil {
+		logger.Error("Error resolviendo precios del carrito: %v", err)
+		return items
+	}
+	return resolved
+}
+
+// SetPOSClient informa el cliente de la venta en curso; los escaneos del POS móvil se cotizan con su lista.
+func (a *App) SetPOSClient(clientID string) {
+	a.posMu.Lock()
+	a.posClientID = clientID
+	a.posMu.Unlock()
+}
+
+// --- PROMOCIONES ---
+
+// GetPromotions lista las promociones configuradas.
+func (a *App) GetPromotions() []db.PromotionDTO {
+	list, err := a.promotionService.ListarPromociones()
+	if err != nil {
+		logger.Error("Error listando promociones: %v", err)
+		return []db.PromotionDTO{}
+	}
+	return list
+}
+
+// SavePromotion crea o actualiza una promoción (PORCENTAJE o NXM).
+func (a *App) SavePromotion(dto db.PromotionDTO) string {
+	promo, err := a.promotionService.GuardarPromocion(dto)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Éxito: Promoción %s guardada", promo.Nombre)
+}
+
+// DeletePromotion elimina una promoción.
+func (a *App) DeletePromotion(id uint) string {
+	if err := a.promotionService.EliminarPromocion(id); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Promoción eliminada"
+}
+
+// EvaluateCart devuelve el carrito con precios de lista y descuentos por promoción, tal como se emitirá.
+func (a *App) EvaluateCart(clientID string, items []db.InvoiceItem) []db.InvoiceItem {
+	evaluated, err := a.promotionService.EvaluarCarrito(clientID, items)
+	if err != nil {
+		logger.Error("Error evaluando promociones: %v", err)
+		return items
+	}
+	return evaluated
+}
+
+// GetPromotionImpact resume descuentos y ventas por promoción en el rango (YYYY-MM-DD).
+func (a *App) GetPromotionImpact(startStr, endStr string) []db.PromotionImpactDTO {
+	start, end := rangoFechas(startStr, endStr)
+	list, err := a.promotionService.GetImpacto(start, end)
+	if err != nil {
+		logger.Error("Error calculando impacto de promociones: %v", err)
+		return []db.PromotionImpactDTO{}
+	}
+	return list
+}
+
+// ExportPromotionImpactExcel guarda el reporte de impacto de promociones.
+func (a *App) ExportPromotionImpactExcel(startStr, endStr string) string {
+	start, end := rangoFechas(startStr, endStr)
+	data, err := a.promotionService.GenerarReporteImpactoExcel(start, end)
+	if err != nil {
+		return fmt.Sprintf("Error generando reporte: %v", err)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("Promociones_%s.xlsx", time.Now().Format("20060102")),
+		Title:           "Guardar Impacto de Promociones",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Archivos Excel", Pattern: "*.xlsx"},
+		},
+	})
+	if err != nil || path == "" {
+		return "Cancelado"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Sprintf("Error guardando archivo: %v", err)
+	}
+	return "Reporte de promociones exportado exitosamente"
+}
+
+// --- CATEGORÍAS Y MARCAS ---
+
+// GetCategories devuelve el árbol de categorías ordenado por ruta.
+func (a *App) GetCategories() []db.CategoryDTO {
+	list, err := a.catalogService.ListarCategorias()
+	if err != nil {
+		logger.Error("Error listando categorías: %v", err)
+		return []db.CategoryDTO{}
+	}
+	return list
+}
+
+// SaveCategory crea, renombra o mueve una categoría (parentId 0 = raíz).
+func (a *App) SaveCategory(dto db.CategoryDTO) string {
+	cat, err := a.catalogService.GuardarCategoria(dto)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Éxito: Categoría %s guardada", cat.Ruta)
+}
+
+// DeleteCategory elimina una categoría sin subcategorías; sus productos pasan a la categoría padre.
+func (a *App) DeleteCategory(id uint) string {
+	if err := a.catalogService.EliminarCategoria(id); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Categoría eliminada"
+}
+
+// GetBrands lista las marcas con su número de productos.
+func (a *App) GetBrands() []db.BrandDTO {
+	list, err := a.catalogService.ListarMarcas()
+	if err != nil {
+		logger.Error("Error listando marcas: %v", err)
+		return []db.BrandDTO{}
+	}
+	return list
+}
+
+// SaveBrand crea o renombra una marca.
+func (a *App) SaveBrand(dto db.BrandDTO) string {
+	marca, err := a.catalogService.GuardarMarca(dto)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Éxito: Marca %s guardada", marca.Nombre)
+}
+
+// DeleteBrand elimina una marca; sus productos quedan sin marca.
+func (a *App) DeleteBrand(id uint) string {
+	if err := a.catalogService.EliminarMarca(id); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Marca eliminada"
+}
+
+// GetProductTags devuelve las etiquetas en uso, para autocompletar.
+func (a *App) GetProductTags() []string {
+	return a.catalogService.ListarTags()
+}
+
+// SetProductClassification fija categoría, marca y etiquetas de un producto (0 = ninguna).
+func (a *App) SetProductClassification(sku string, categoryID, brandID uint, tags []string) string {
+	if err := a.catalogService.AsignarClasificacion(sku, categoryID, brandID, tags); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Producto clasificado"
+}
+
 // --- GESTIÓN DE PRODUCTOS ---
 
 func (a *App) GetProducts() []db.ProductDTO {
 	var products []db.Product
 	db.GetDB().Find(&products)
+	unidades := a.unitService.UnidadesPorProducto()
 	var dtos []db.ProductDTO
 	for _, p := range products {
 		expiryStr := ""
@@ -1284,15 +2174,38 @@ func (a *App) GetProducts() []db.ProductDTO {
 			Barcode:       p.Barcode,
 			AuxiliaryCode: p.AuxiliaryCode,
 			MinStock:      p.MinStock,
+			Cost:          p.Cost,
+			LastCost:      p.LastCost,
 			ExpiryDate:    expiryStr,
 			Location:      p.Location,
+			UnidadMedida:  p.UnidadMedida,
+			Unidades:      unidades[p.SKU],
+			CategoryID:    p.CategoryID,
+			BrandID:       p.BrandID,
+			EsKit:         p.EsKit,
+			DetallarKit:   p.DetallarKit,
+			EsServicio:    p.EsServicio,
+			TieneImagen:   p.Imagen != "",
 		})
 	}
+	a.catalogService.Clasificar(dtos)
+	// Los kits muestran como stock lo que se puede armar con sus componentes
+	disponibles := a.kitService.DisponibilidadKits()
+	for i := range dtos {
+		if dtos[i].EsKit {
+			dtos[i].Stock = disponibles[dtos[i].SKU]
+		}
+	}
 	return dtos
 }
 
 func (a *App) SearchProducts(query string) []db.ProductDTO {
-	res, err := a.searchService.FuzzySearchProducts(query)
+	return a.SearchProductsFiltered(query, db.ProductFilterDTO{})
+}
+
+// SearchProductsFiltered busca productos dentro de una categoría (con sus subcategorías), marca y etiquetas.
+func (a *App) SearchProductsFiltered(query string, filtro db.ProductFilterDTO) []db.ProductDTO {
+	res, err := a.searchService.FuzzySearchProducts(query, filtro)
 	if err != nil {
 		logger.Error("Error fuzzy product: %v", err)
 		return []db.ProductDTO{}
@@ -1319,7 +2232,7 @@ func (a *App) SaveProduct(dto db.ProductDTO) string {
 	if result.Error == nil {
 		existing.Name = dto.Name
 		existing.Price = dto.Price
-		existing.Stock = dto.Stock
+		existing.Cost = dto.Cost
 		existing.TaxCode = taxCodeInt
 		existing.TaxPercentage = dto.TaxPercentage
 		existing.Barcode = dto.Barcode
@@ -1327,16 +2240,43 @@ func (a *App) SaveProduct(dto db.ProductDTO) string {
 		existing.MinStock = dto.MinStock
 		existing.ExpiryDate = expiryDate
 		existing.Location = dto.Location
+		existing.UnidadMedida = strings.ToUpper(strings.TrimSpace(dto.UnidadMedida))
+		// Un formulario sin clasificación no borra la existente; para quitarla está SetProductClassification
+		if dto.CategoryID != 0 {
+			existing.CategoryID = dto.CategoryID
+		}
+		if dto.BrandID != 0 {
+			existing.BrandID = dto.BrandID
+		}
 
-		if err := db.GetDB().Save(&existing).Error; err != nil {
+		// El cambio entre bien y servicio se valida antes de guardar para no dejar la edición a medias
+		// (un producto con stock no puede pasar a servicio). Un servicio que pasa a ser bien recibe
+		// su stock en el mismo guardado.
+		if dto.EsServicio != existing.EsServicio {
+			if err := a.productService.MarcarServicio(dto.SKU, dto.EsServicio); err != nil {
+				return fmt.Sprintf("Error: %v", err)
+			}
+			existing.EsServicio = dto.EsServicio
+		}
+
+		// El stock no se reescribe aquí: pudo cambiar por ventas mientras el formulario estaba abierto
+		if err := db.GetDB().Omit("stock").Save(&existing).Error; err != nil {
 			return fmt.Sprintf("Error actualizando producto: %v", err)
 		}
+		// El stock solo cambia a través del kardex y solo si el usuario lo editó; la diferencia con el
+		// total va a la bodega principal. Los kits y los servicios no tienen stock propio.
+		stockEditado := dto.StockOriginal != nil && dto.Stock != *dto.StockOriginal
+		if stockEditado && !existing.EsKit && !existing.EsServicio {
+			if _, err := a.inventoryService.AjustarStock(dto.SKU, "", dto.Stock-existing.Stock, false, "Escritorio", "Edición de producto"); err != nil {
+				return fmt.Sprintf("Error ajustando stock: %v", err)
+			}
+		}
 	} else {
 		newProd := db.Product{
 			SKU:           dto.SKU,
 			Name:          dto.Name,
 			Price:         dto.Price,
-			Stock:         dto.Stock,
+			Cost:          dto.Cost,
 			TaxCode:       taxCodeInt,
 			TaxPercentage: dto.TaxPercentage,
 			Barcode:       dto.Barcode,
@@ -1344,21 +2284,89 @@ func (a *App) SaveProduct(dto db.ProductDTO) string {
 			MinStock:      dto.MinStock,
 			ExpiryDate:    expiryDate,
 			Location:      dto.Location,
+			UnidadMedida:  strings.ToUpper(strings.TrimSpace(dto.UnidadMedida)),
+			CategoryID:    dto.CategoryID,
+			BrandID:       dto.BrandID,
+			EsServicio:    dto.EsServicio,
 		}
 		if err := db.GetDB().Create(&newProd).Error; err != nil {
 			return fmt.Sprintf("Error creando producto: %v", err)
 		}
+		if len(dto.Componentes) == 0 && !dto.EsServicio {
+			if _, err := a.inventoryService.AjustarStock(dto.SKU, "", dto.Stock, true, "Escritorio", "Stock inicial"); err != nil {
+				return fmt.Sprintf("Error registrando stock inicial: %v", err)
+			}
+		}
+	}
+	// Las presentaciones solo se reemplazan si el formulario las envía
+	if dto.Unidades != nil {
+		if err := a.unitService.GuardarUnidades(dto.SKU, dto.Unidades); err != nil {
+			return fmt.Sprintf("Error guardando unidades: %v", err)
+		}
+	}
+	if dto.Componentes != nil {
+		if err := a.kitService.GuardarComponentes(dto.SKU, dto.DetallarKit, dto.Componentes); err != nil {
+			return fmt.Sprintf("Error guardando componentes del kit: %v", err)
+		}
+	}
+	if dto.Tags != nil {
+		if err := a.catalogService.GuardarTags(dto.SKU, dto.Tags); err != nil {
+			return fmt.Sprintf("Error guardando etiquetas: %v", err)
+		}
 	}
 	return "Producto guardado exitosamente"
 }
 
 func (a *App) DeleteProduct(sku string) string {
-	if err := db.GetDB().Delete(&db.Product{}, "sku = ?", sku).Error; err != nil {
+	if err := a.productService.EliminarProducto(sku); err != nil {
 		return fmt.Sprintf("Error eliminando producto: %v", err)
 	}
 	return "Producto eliminado"
 }
 
+// SelectProductImage abre el diálogo para elegir la foto del producto y la guarda normalizada.
+func (a *App) SelectProductImage(sku string) string {
+	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Seleccionar Foto del Producto",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Imágenes", Pattern: "*.png;*.jpg;*.jpeg"},
+		},
+	})
+	if err != nil || selection == "" {
+		return "Cancelado"
+	}
+
+	data, err := os.ReadFile(selection)
+	if err != nil {
+		return fmt.Sprintf("Error leyendo imagen: %v", err)
+	}
+	if _, err := a.productService.GuardarImagen(sku, data, filepath.Join(a.assetsDir(), "productos")); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Imagen guardada"
+}
+
+// RemoveProductImage quita la foto del producto.
+func (a *App) RemoveProductImage(sku string) string {
+	if err := a.productService.QuitarImagen(sku); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Imagen eliminada"
+}
+
+// GetProductImage devuelve la foto (o su miniatura) como data URL, vacío si no tiene.
+func (a *App) GetProductImage(sku string, miniatura bool) string {
+	ruta, err := a.productService.RutaImagen(sku, miniatura)
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(ruta)
+	if err != nil {
+		return ""
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data)
+}
+
 // ImportProductsCSV permite al usuario seleccionar un archivo CSV e importar productos masivamente.
 func (a *App) ImportProductsCSV() string {
 	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
@@ -1385,6 +2393,81 @@ func (a *App) ImportProductsCSV() string {
 	return fmt.Sprintf("Éxito: Se importaron/actualizaron %d productos", count)
 }
 
+// OpenProductImport abre un archivo XLSX o CSV de productos y devuelve sus encabezados con el
+// mapeo de columnas sugerido. Devuelve nil si el usuario cancela.
+func (a *App) OpenProductImport() *service.ArchivoImportacion {
+	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Seleccionar Archivo de Productos",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Productos (Excel, CSV)", Pattern: "*.xlsx;*.csv"},
+		},
+	})
+	if err != nil || selection == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(selection)
+	if err != nil {
+		logger.Error("Error abriendo archivo de productos: %v", err)
+		return nil
+	}
+	archivo, err := a.productService.LeerArchivoProductos(selection, data)
+	if err != nil {
+		logger.Error("Error leyendo archivo de productos: %v", err)
+		return nil
+	}
+	return archivo
+}
+
+// ImportProductsFile valida (simular = true) o importa el archivo abierto con OpenProductImport
+// usando el mapeo campo -> columna elegido por el usuario.
+func (a *App) ImportProductsFile(ruta string, mapeo map[string]int, simular bool) *service.ResultadoImportacionProductos {
+	errorGeneral := func(err error) *service.ResultadoImportacionProductos {
+		return &service.ResultadoImportacionProductos{
+			Archivo:    filepath.Base(ruta),
+			Filas:      []service.FilaImportacion{{Errores: []string{err.Error()}}},
+			ConError:   1,
+			Simulacion: simular,
+		}
+	}
+	data, err := os.ReadFile(ruta)
+	if err != nil {
+		return errorGeneral(fmt.Errorf("error abriendo archivo: %v", err))
+	}
+	resultado, err := a.productService.ImportarProductos(ruta, data, mapeo, simular)
+	if err != nil {
+		return errorGeneral(err)
+	}
+	if resultado.Aplicado {
+		a.NotifyFrontend("success", fmt.Sprintf("Importación: %d productos creados, %d actualizados", resultado.Creados, resultado.Actualizados))
+	}
+	return resultado
+}
+
+// ExportProductImportReport guarda en Excel el detalle por fila de una importación de productos.
+func (a *App) ExportProductImportReport(resultado service.ResultadoImportacionProductos) string {
+	data, err := a.productService.GenerarReporteImportacion(&resultado)
+	if err != nil {
+		return fmt.Sprintf("Error generando reporte: %v", err)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("Importacion_Productos_%s.xlsx", time.Now().Format("20060102_1504")),
+		Title:           "Guardar Reporte de Importación",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Archivos Excel", Pattern: "*.xlsx"},
+		},
+	})
+	if err != nil || path == "" {
+		return "Cancelado"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Sprintf("Error guardando archivo: %v", err)
+	}
+	return "Éxito: Reporte de importación exportado"
+}
+
 // ImportClientsCSV permite al usuario seleccionar un archivo CSV e importar clientes masivamente.
 func (a *App) ImportClientsCSV() string {
 	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
@@ -1411,6 +2494,638 @@ func (a *App) ImportClientsCSV() string {
 	return fmt.Sprintf("Éxito: Se importaron/actualizaron %d clientes", count)
 }
 
+// GetProductUnits devuelve las presentaciones alternativas de venta de un producto.
+func (a *App) GetProductUnits(sku string) []db.ProductUnitDTO {
+	list, err := a.unitService.ListarUnidades(sku)
+	if err != nil {
+		logger.Error("Error listando unidades de %s: %v", sku, err)
+		return []db.ProductUnitDTO{}
+	}
+	return list
+}
+
+// SaveProductUnits reemplaza las presentaciones de un producto (caja de 12, paquete de 6...).
+func (a *App) SaveProductUnits(sku string, unidades []db.ProductUnitDTO) string {
+	if err := a.unitService.GuardarUnidades(sku, unidades); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Unidades guardadas"
+}
+
+// GetKitComponents devuelve los componentes de un kit con su stock.
+func (a *App) GetKitComponents(sku string) []db.KitComponentDTO {
+	list, err := a.kitService.ListarComponentes(sku)
+	if err != nil {
+		logger.Error("Error listando componentes de %s: %v", sku, err)
+		return []db.KitComponentDTO{}
+	}
+	return list
+}
+
+// SaveKitComponents convierte un producto en kit (o deja de serlo con la lista vacía). detallar
+// imprime los componentes en los detalles adicionales de la factura.
+func (a *App) SaveKitComponents(sku string, detallar bool, componentes []db.KitComponentDTO) string {
+	if err := a.kitService.GuardarComponentes(sku, detallar, componentes); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Componentes guardados"
+}
+
+// GetKitAvailability calcula cuántos kits se pueden armar en una bodega ("" = todas).
+func (a *App) GetKitAvailability(sku, bodega string) *db.KitAvailabilityDTO {
+	disp, err := a.kitService.Disponibilidad(sku, bodega)
+	if err != nil {
+		logger.Error("Error calculando disponibilidad de %s: %v", sku, err)
+		return nil
+	}
+	return disp
+}
+
+// FindProductByCode busca por SKU, código de barras o código de una presentación, devolviendo
+// la unidad y el precio con que debe agregarse a la factura.
+func (a *App) FindProductByCode(codigo string) *db.ScanResultDTO {
+	scan, err := a.unitService.BuscarPorCodigo(codigo)
+	if err != nil {
+		return nil
+	}
+	return scan
+}
+
+// --- INVENTARIO (KARDEX) ---
+
+// AdjustStock registra un ajuste manual de inventario en una bodega ("" = principal).
+// Si fijar es true, cantidad es el stock contado en esa bodega.
+func (a *App) AdjustStock(sku, bodega string, cantidad float64, fijar bool, nota string) string {
+	mov, err := a.inventoryService.AjustarStock(sku, bodega, cantidad, fijar, "Escritorio", nota)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	if mov == nil {
+		return "Sin cambios: el stock ya coincide"
+	}
+	runtime.EventsEmit(a.ctx, "inventory-updated", sku)
+	return fmt.Sprintf("Éxito: Stock de %s en %s actualizado a %g", sku, mov.Bodega, mov.SaldoBodega)
+}
+
+// GetKardex devuelve los movimientos de stock de un producto en el rango (YYYY-MM-DD).
+// Con bodega vacía se consolidan todas las bodegas.
+func (a *App) GetKardex(sku, bodega, startStr, endStr string) *db.KardexDTO {
+	start, end := rangoFechas(startStr, endStr)
+	kardex, err := a.inventoryService.GetKardex(sku, bodega, start, end)
+	if err != nil {
+		logger.Error("Error obteniendo kardex: %v", err)
+		return nil
+	}
+	return kardex
+}
+
+// ExportKardexExcel guarda en Excel el kardex de un producto.
+func (a *App) ExportKardexExcel(sku, bodega, startStr, endStr string) string {
+	start, end := rangoFechas(startStr, endStr)
+	data, err := a.inventoryService.GenerarKardexExcel(sku, bodega, start, end)
+	if err != nil {
+		return fmt.Sprintf("Error generando kardex: %v", err)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("Kardex_%s_%s.xlsx", sku, time.Now().Format("20060102")),
+		Title:           "Guardar Kardex",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Archivos Excel", Pattern: "*.xlsx"},
+		},
+	})
+	if err != nil || path == "" {
+		return "Cancelado"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Sprintf("Error guardando archivo: %v", err)
+	}
+	return "Kardex exportado exitosamente"
+}
+
+// --- BODEGAS Y TRASLADOS ---
+
+// SaveWarehouse crea o actualiza una bodega.
+func (a *App) SaveWarehouse(dto db.WarehouseDTO) string {
+	if err := a.warehouseService.GuardarBodega(dto); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Bodega guardada"
+}
+
+// GetWarehouses lista las bodegas (todas o solo las activas).
+func (a *App) GetWarehouses(soloActivas bool) []db.WarehouseDTO {
+	list, err := a.warehouseService.ListarBodegas(soloActivas)
+	if err != nil {
+		logger.Error("Error listando bodegas: %v", err)
+		return []db.WarehouseDTO{}
+	}
+	return list
+}
+
+// DeleteWarehouse elimina una bodega sin movimientos.
+func (a *App) DeleteWarehouse(codigo string) string {
+	if err := a.warehouseService.EliminarBodega(codigo); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Bodega eliminada"
+}
+
+// GetProductStockByWarehouse devuelve el stock de un producto en cada bodega.
+func (a *App) GetProductStockByWarehouse(sku string) []db.ProductStockDTO {
+	list, err := a.warehouseService.GetStockPorBodega(sku)
+	if err != nil {
+		logger.Error("Error consultando stock por bodega: %v", err)
+		return []db.ProductStockDTO{}
+	}
+	return list
+}
+
+// SetProductBinLocation guarda la ubicación (percha, pasillo) de un producto en una bodega.
+func (a *App) SetProductBinLocation(sku, bodega, ubicacion string) string {
+	if err := a.warehouseService.AsignarUbicacion(sku, bodega, ubicacion); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Ubicación guardada"
+}
+
+// TransferStock registra un traslado de mercadería entre bodegas.
+func (a *App) TransferStock(dto db.TransferDTO) string {
+	traslado, err := a.warehouseService.Transferir(dto, "Escritorio")
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	runtime.EventsEmit(a.ctx, "inventory-updated", traslado.ID)
+	return fmt.Sprintf("Éxito: Traslado #%d registrado", traslado.ID)
+}
+
+// VoidTransfer anula un traslado y devuelve la mercadería al origen.
+func (a *App) VoidTransfer(id uint) string {
+	if err := a.warehouseService.AnularTraslado(id, "Escritorio"); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	runtime.EventsEmit(a.ctx, "inventory-updated", id)
+	return "Éxito: Traslado anulado"
+}
+
+// GetTransfers lista los traslados del rango (YYYY-MM-DD).
+func (a *App) GetTransfers(startStr, endStr string) []db.TransferDTO {
+	start, end := rangoFechas(startStr, endStr)
+	list, err := a.warehouseService.ListarTraslados(start, end)
+	if err != nil {
+		logger.Error("Error listando traslados: %v", err)
+		return []db.TransferDTO{}
+	}
+	return list
+}
+
+// GetTransfer devuelve un traslado con sus ítems.
+func (a *App) GetTransfer(id uint) *db.TransferDTO {
+	traslado, err := a.warehouseService.GetTraslado(id)
+	if err != nil {
+		logger.Error("Error obteniendo traslado: %v", err)
+		return nil
+	}
+	return traslado
+}
+
+// --- LOTES Y VENCIMIENTOS ---
+
+// RegisterLot registra un lote. Con moverStock la cantidad ingresa al inventario; sin él se
+// asigna a stock existente que aún no tiene lote.
+func (a *App) RegisterLot(dto db.LotDTO, moverStock bool) string {
+	if err := a.lotService.IngresarLote(dto, moverStock, "Escritorio"); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	runtime.EventsEmit(a.ctx, "inventory-updated", dto.SKU)
+	return fmt.Sprintf("Éxito: Lote %s registrado", dto.Numero)
+}
+
+// GetLots lista los lotes de un producto y bodega (vacíos = todos) en orden FEFO.
+func (a *App) GetLots(sku, bodega string, soloDisponibles bool) []db.LotDTO {
+	list, err := a.lotService.ListarLotes(sku, bodega, soloDisponibles)
+	if err != nil {
+		logger.Error("Error listando lotes: %v", err)
+		return []db.LotDTO{}
+	}
+	return list
+}
+
+// GetExpiringLots devuelve los lotes vencidos y los que vencen en los próximos días.
+func (a *App) GetExpiringLots(dias int, bodega string) []db.LotDTO {
+	if dias <= 0 {
+		dias = service.DiasAlertaVencimiento
+	}
+	list, err := a.lotService.GetLotesPorVencer(dias, bodega)
+	if err != nil {
+		logger.Error("Error consultando vencimientos: %v", err)
+		return []db.LotDTO{}
+	}
+	return list
+}
+
+// WriteOffLot da de baja el saldo de un lote (vencido o dañado).
+func (a *App) WriteOffLot(id uint, nota string) string {
+	if err := a.lotService.DarDeBajaLote(id, "Escritorio", nota); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	runtime.EventsEmit(a.ctx, "inventory-updated", id)
+	return "Éxito: Lote dado de baja"
+}
+
+// ExportExpiringLotsExcel exporta el reporte de vencimientos.
+func (a *App) ExportExpiringLotsExcel(dias int, bodega string) string {
+	if dias <= 0 {
+		dias = service.DiasAlertaVencimiento
+	}
+	data, err := a.lotService.GenerarReporteVencimientosExcel(dias, bodega)
+	if err != nil {
+		return fmt.Sprintf("Error generando reporte: %v", err)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("Vencimientos_%s.xlsx", time.Now().Format("20060102")),
+		Title:           "Guardar Reporte de Vencimientos",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Archivos Excel", Pattern: "*.xlsx"},
+		},
+	})
+	if err != nil || path == "" {
+		return "Cancelado"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Sprintf("Error guardando archivo: %v", err)
+	}
+	return "Reporte exportado exitosamente"
+}
+
+// --- TOMA FÍSICA ---
+
+// OpenCountSession abre una toma física para una bodega y, opcionalmente, una sección (prefijo de ubicación).
+func (a *App) OpenCountSession(bodega, seccion, nota string) *db.CountSessionDTO {
+	conteo, err := a.countService.AbrirConteo(bodega, seccion, nota, "Escritorio")
+	if err != nil {
+		logger.Error("Error abriendo toma física: %v", err)
+		a.NotifyFrontend("error", err.Error())
+		return nil
+	}
+	return conteo
+}
+
+// GetCountSessions lista las tomas físicas ("" = todas, o ABIERTA / CONTABILIZADA / CANCELADA).
+func (a *App) GetCountSessions(estado string) []db.CountSessionDTO {
+	list, err := a.countService.ListarConteos(estado)
+	if err != nil {
+		logger.Error("Error listando tomas físicas: %v", err)
+		return []db.CountSessionDTO{}
+	}
+	return list
+}
+
+// GetCountReview devuelve lo contado frente al stock del sistema, con las diferencias valorizadas.
+func (a *App) GetCountReview(id uint) *db.CountSessionDTO {
+	conteo, err := a.countService.GetRevision(id)
+	if err != nil {
+		logger.Error("Error obteniendo toma física %d: %v", id, err)
+		return nil
+	}
+	return conteo
+}
+
+// AddCountReading registra una lectura desde el escritorio (cantidad negativa para corregir).
+func (a *App) AddCountReading(id uint, codigo string, cantidad float64) string {
+	product, total, err := a.countService.RegistrarLectura(id, codigo, cantidad, "Escritorio")
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Éxito: %s contado %g", product.Name, total)
+}
+
+// PostCountSession contabiliza la toma física: genera los ajustes en el kardex y la cierra.
+// Con ceroNoContados, los productos del alcance sin lecturas quedan en cero.
+func (a *App) PostCountSession(id uint, ceroNoContados bool) string {
+	conteo, err := a.countService.ContabilizarConteo(id, ceroNoContados, "Escritorio")
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	runtime.EventsEmit(a.ctx, "inventory-updated", nil)
+	return fmt.Sprintf("Éxito: Toma física contabilizada (%d sobrantes, %d faltantes)", conteo.Sobrantes, conteo.Faltantes)
+}
+
+// CancelCountSession descarta una toma física abierta.
+func (a *App) CancelCountSession(id uint) string {
+	if err := a.countService.CancelarConteo(id); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Toma física cancelada"
+}
+
+// ExportCountReportPDF guarda el reporte de diferencias de la toma física.
+func (a *App) ExportCountReportPDF(id uint) string {
+	data, err := a.countService.GenerarReportePDF(id)
+	if err != nil {
+		return fmt.Sprintf("Error generando reporte: %v", err)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("TomaFisica_%d_%s.pdf", id, time.Now().Format("20060102")),
+		Title:           "Guardar Reporte de Toma Física",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Archivos PDF", Pattern: "*.pdf"},
+		},
+	})
+	if err != nil || path == "" {
+		return "Cancelado"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Sprintf("Error guardando archivo: %v", err)
+	}
+	return "Reporte exportado exitosamente"
+}
+
+// --- ETIQUETAS ---
+
+// ExportProductLabels genera el PDF de etiquetas de los productos seleccionados.
+func (a *App) ExportProductLabels(skus []string, opciones db.LabelOptionsDTO) string {
+	data, err := a.labelService.GenerarEtiquetasProductos(skus, opciones)
+	if err != nil {
+		return fmt.Sprintf("Error generando etiquetas: %v", err)
+	}
+	return a.guardarEtiquetas(data, fmt.Sprintf("Etiquetas_%s.pdf", time.Now().Format("20060102_1504")))
+}
+
+// ExportPurchaseLabels genera una etiqueta por cada unidad recibida en la compra.
+func (a *App) ExportPurchaseLabels(purchaseID uint, opciones db.LabelOptionsDTO) string {
+	data, err := a.labelService.GenerarEtiquetasCompra(purchaseID, opciones)
+	if err != nil {
+		return fmt.Sprintf("Error generando etiquetas: %v", err)
+	}
+	return a.guardarEtiquetas(data, fmt.Sprintf("Etiquetas_Compra_%d.pdf", purchaseID))
+}
+
+// AssignInternalBarcodes asigna EAN-13 internos a los productos sin código (vacío = todo el catálogo).
+func (a *App) AssignInternalBarcodes(skus []string) string {
+	n, err := a.labelService.AsignarCodigosInternos(skus)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Éxito: %d códigos internos asignados", n)
+}
+
+func (a *App) guardarEtiquetas(data []byte, nombre string) string {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: nombre,
+		Title:           "Guardar Etiquetas",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Archivos PDF", Pattern: "*.pdf"},
+		},
+	})
+	if err != nil || path == "" {
+		return "Cancelado"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Sprintf("Error guardando archivo: %v", err)
+	}
+	return "Etiquetas exportadas exitosamente"
+}
+
+// --- NOTIFICACIONES Y REPOSICIÓN ---
+
+// GetNotifications devuelve las notificaciones más recientes.
+func (a *App) GetNotifications(soloNoLeidas bool) []db.NotificationDTO {
+	list, err := a.notificationService.Listar(soloNoLeidas, 200)
+	if err != nil {
+		logger.Error("Error listando notificaciones: %v", err)
+		return []db.NotificationDTO{}
+	}
+	return list
+}
+
+// GetUnreadNotificationCount devuelve el número de notificaciones sin leer.
+func (a *App) GetUnreadNotificationCount() int64 {
+	return a.notificationService.ContarNoLeidas()
+}
+
+// MarkNotificationRead marca una notificación como leída (0 = todas).
+func (a *App) MarkNotificationRead(id uint) string {
+	if err := a.notificationService.MarcarLeida(id); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Notificaciones actualizadas"
+}
+
+// CheckLowStock ejecuta la revisión de stock mínimo sin esperar al ciclo automático.
+func (a *App) CheckLowStock() string {
+	nuevas, err := a.reorderService.RevisarStockMinimo()
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return fmt.Sprintf("Éxito: %d alertas nuevas de stock bajo", len(nuevas))
+}
+
+// GetReorderSuggestions calcula las cantidades sugeridas a pedir por producto.
+func (a *App) GetReorderSuggestions(params db.ReorderParamsDTO) []db.ReorderSuggestionDTO {
+	list, err := a.reorderService.GetSugerenciasReposicion(params)
+	if err != nil {
+		logger.Error("Error calculando reposición: %v", err)
+		return []db.ReorderSuggestionDTO{}
+	}
+	return list
+}
+
+// ExportReorderExcel exporta las sugerencias como borrador de orden de compra por proveedor.
+func (a *App) ExportReorderExcel(params db.ReorderParamsDTO) string {
+	data, err := a.reorderService.GenerarOrdenCompraExcel(params)
+	if err != nil {
+		return fmt.Sprintf("Error generando orden de compra: %v", err)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: fmt.Sprintf("OrdenCompra_%s.xlsx", time.Now().Format("20060102")),
+		Title:           "Guardar Orden de Compra",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Archivos Excel", Pattern: "*.xlsx"},
+		},
+	})
+	if err != nil || path == "" {
+		return "Cancelado"
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Sprintf("Error guardando archivo: %v", err)
+	}
+	return "Orden de compra exportada exitosamente"
+}
+
+// rangoFechas convierte fechas YYYY-MM-DD en un rango que incluye el día final completo.
+// Sin fecha de inicio se toma desde el primer día del mes actual.
+func rangoFechas(startStr, endStr string) (time.Time, time.Time) {
+	now := time.Now()
+	start, err := time.ParseInLocation("2006-01-02", startStr, time.Local)
+	if err != nil {
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	}
+	end, err := time.ParseInLocation("2006-01-02", endStr, time.Local)
+	if err != nil {
+		end = now
+	}
+	return start, time.Date(end.Year(), end.Month(), end.Day(), 23, 59, 59, 0, time.Local)
+}
+
+// --- PROVEEDORES Y COMPRAS ---
+
+// SaveSupplier crea o actualiza un proveedor.
+func (a *App) SaveSupplier(dto db.SupplierDTO) string {
+	if err := a.purchaseService.GuardarProveedor(dto); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Proveedor guardado"
+}
+
+// GetSuppliers lista los proveedores (query opcional por RUC o nombre).
+func (a *App) GetSuppliers(query string) []db.SupplierDTO {
+	list, err := a.purchaseService.ListarProveedores(query)
+	if err != nil {
+		logger.Error("Error listando proveedores: %v", err)
+		return []db.SupplierDTO{}
+	}
+	return list
+}
+
+// DeleteSupplier elimina un proveedor sin compras.
+func (a *App) DeleteSupplier(ruc string) string {
+	if err := a.purchaseService.EliminarProveedor(ruc); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	return "Éxito: Proveedor eliminado"
+}
+
+// RegisterPurchase registra una factura de compra e ingresa su mercadería al inventario.
+func (a *App) RegisterPurchase(dto db.PurchaseDTO) string {
+	compra, err := a.purchaseService.RegistrarCompra(dto)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	runtime.EventsEmit(a.ctx, "inventory-updated", compra.NumeroFactura)
+	return fmt.Sprintf("Éxito: Compra %s registrada por $%.2f", compra.NumeroFactura, compra.Total)
+}
+
+// GetPurchases lista las compras del rango (YYYY-MM-DD).
+func (a *App) GetPurchases(startStr, endStr string) []db.PurchaseDTO {
+	start, end := rangoFechas(startStr, endStr)
+	list, err := a.purchaseService.ListarCompras(start, end)
+	if err != nil {
+		logger.Error("Error listando compras: %v", err)
+		return []db.PurchaseDTO{}
+	}
+	return list
+}
+
+// GetPurchase devuelve el detalle de una compra.
+func (a *App) GetPurchase(id uint) *db.PurchaseDTO {
+	compra, err := a.purchaseService.GetCompra(id)
+	if err != nil {
+		logger.Error("Error cargando compra: %v", err)
+		return nil
+	}
+	return compra
+}
+
+// VoidPurchase anula una compra y retira su mercadería del inventario.
+func (a *App) VoidPurchase(id uint) string {
+	if err := a.purchaseService.AnularCompra(id); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	runtime.EventsEmit(a.ctx, "inventory-updated", id)
+	return "Éxito: Compra anulada"
+}
+
+// ImportPurchaseXML importa como compras las facturas electrónicas recibidas de proveedores
+// (XML sueltos, envoltorios de autorización o ZIP). Devuelve nil si el usuario cancela.
+func (a *App) ImportPurchaseXML(opciones service.OpcionesImportacionXML) *service.ResultadoImportacionXML {
+	selection, err := runtime.OpenMultipleFilesDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Seleccionar Facturas de Proveedores",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Comprobantes (XML, ZIP)", Pattern: "*.xml;*.zip"},
+		},
+	})
+	if err != nil || len(selection) == 0 {
+		return nil
+	}
+
+	resultado := &service.ResultadoImportacionXML{Comprobantes: []service.ComprobanteImportado{}}
+	for _, path := range selection {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			resultado.Errores++
+			resultado.Comprobantes = append(resultado.Comprobantes, service.ComprobanteImportado{
+				Archivo: filepath.Base(path), Estado: "ERROR", Mensaje: fmt.Sprintf("Error abriendo archivo: %v", err),
+			})
+			continue
+		}
+		a.purchaseService.ImportarComprobantesXML(resultado, path, data, opciones)
+	}
+
+	if resultado.Importadas > 0 && opciones.ActualizarInventario {
+		runtime.EventsEmit(a.ctx, "inventory-updated", resultado.Importadas)
+	}
+	a.NotifyFrontend("info", fmt.Sprintf("Importación: %d compras nuevas, %d duplicadas, %d con error", resultado.Importadas, resultado.Duplicadas, resultado.Errores))
+	return resultado
+}
+
+// ReconcileReceivedReport abre el TXT de comprobantes recibidos descargado del portal del SRI,
+// lo cruza con las compras y encola las facturas faltantes. Devuelve nil si el usuario cancela.
+func (a *App) ReconcileReceivedReport(opciones service.OpcionesImportacionXML) *service.ConciliacionRecibidos {
+	selection, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Seleccionar Reporte de Comprobantes Recibidos",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Reporte SRI (TXT)", Pattern: "*.txt;*.csv"},
+		},
+	})
+	if err != nil || selection == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(selection)
+	if err != nil {
+		a.NotifyFrontend("error", fmt.Sprintf("Error abriendo archivo: %v", err))
+		return nil
+	}
+	res, err := a.receivedService.ConciliarRecibidos(data, opciones)
+	if err != nil {
+		a.NotifyFrontend("error", err.Error())
+		return nil
+	}
+	a.NotifyFrontend("info", fmt.Sprintf("Conciliación: %d registradas, %d faltantes (%d en cola), %d con diferencias", res.Registradas, res.Faltantes, res.Encolados, res.Diferencias))
+	return res
+}
+
+// GetReceivedQueue lista la cola de descarga de comprobantes recibidos ("" = todos).
+func (a *App) GetReceivedQueue(estado string) []db.ComprobanteRecibidoDTO {
+	list, err := a.receivedService.ListarColaRecibidos(estado)
+	if err != nil {
+		logger.Error("Error listando cola de recibidos: %v", err)
+		return []db.ComprobanteRecibidoDTO{}
+	}
+	return list
+}
+
+// ProcessReceivedQueue descarga ahora del SRI los comprobantes en cola y los registra como compras.
+func (a *App) ProcessReceivedQueue() *service.ResultadoImportacionXML {
+	res, err := a.receivedService.ProcesarColaRecibidos(50)
+	if err != nil {
+		a.NotifyFrontend("error", err.Error())
+	}
+	if res != nil && res.Importadas > 0 {
+		runtime.EventsEmit(a.ctx, "inventory-updated", res.Importadas)
+	}
+	return res
+}
+
 // --- GESTIÓN DE COTIZACIONES ---
 
 func (a *App) GetNextQuotationSecuencial() string {
@@ -1501,15 +3216,18 @@ func (a *App) SearchInvoicesSmart(query string) []db.FacturaResumenDTO {
 }
 
 type ChartsDTO struct {
-	RevenueBar string `json:"revenueBar"`
-	ClientsPie string `json:"clientsPie"`
+	RevenueBar    string `json:"revenueBar"`
+	ClientsPie    string `json:"clientsPie"`
+	CategoriesPie string `json:"categoriesPie"`
 }
 
 func (a *App) GetStatisticsCharts() ChartsDTO {
 	bar, _ := a.chartService.GenerateRevenueChart()
 	pie, _ := a.chartService.GenerateClientsPie()
+	categorias, _ := a.chartService.GenerateCategoriesPie()
 	return ChartsDTO{
-		RevenueBar: bar,
-		ClientsPie: pie,
+		RevenueBar:    bar,
+		ClientsPie:    pie,
+		CategoriesPie: categorias,
 	}
 }
@@ -1,13 +1,29 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {db} from '../models';
-import {main} from '../models';
 import {service} from '../models';
+import {main} from '../models';
 
 export function ActivateLicense(arg1:string):Promise<string>;
 
+export function AddCashMovement(arg1:number,arg2:string,arg3:number,arg4:string,arg5:string):Promise<string>;
+
+export function AddCountReading(arg1:number,arg2:string,arg3:number):Promise<string>;
+
+export function AdjustStock(arg1:string,arg2:string,arg3:number,arg4:boolean,arg5:string):Promise<string>;
+
+export function AssignInternalBarcodes(arg1:Array<string>):Promise<string>;
+
+export function AssignPriceList(arg1:string,arg2:number):Promise<string>;
+
+export function CancelCountSession(arg1:number):Promise<string>;
+
 export function CheckLicense():Promise<boolean>;
 
+export function CheckLowStock():Promise<string>;
+
+export function CloseCashSession(arg1:number,arg2:Array<db.CashCountDTO>,arg3:string,arg4:string):Promise<string>;
+
 export function ConvertQuotationToInvoice(arg1:number):Promise<db.FacturaDTO>;
 
 export function CreateBackup():Promise<void>;
@@ -16,80 +32,288 @@ export function CreateInvoice(arg1:db.FacturaDTO):Promise<string>;
 
 export function CreateQuotation(arg1:db.QuotationDTO):Promise<string>;
 
+export function DeleteBrand(arg1:number):Promise<string>;
+
+export function DeleteCategory(arg1:number):Promise<string>;
+
 export function DeleteClient(arg1:string):Promise<string>;
 
+export function DeleteInvoiceDraft(arg1:number):Promise<string>;
+
+export function DeletePriceList(arg1:number):Promise<string>;
+
 export function DeleteProduct(arg1:string):Promise<string>;
 
+export function DeletePromotion(arg1:number):Promise<string>;
+
+export function DeleteRecurringInvoice(arg1:number):Promise<string>;
+
+export function DeleteSupplier(arg1:string):Promise<string>;
+
+export function DeleteWarehouse(arg1:string):Promise<string>;
+
+export function EmitInvoiceBatch(arg1:Array<service.LoteFactura>,arg2:number):Promise<service.ResumenLote>;
+
+export function EmitInvoiceDraft(arg1:number):Promise<string>;
+
+export function EvaluateCart(arg1:string,arg2:Array<db.InvoiceItem>):Promise<Array<db.InvoiceItem>>;
+
+export function ExportBatchReport(arg1:Array<service.ResultadoLote>):Promise<string>;
+
+export function ExportCashClosePDF(arg1:number):Promise<string>;
+
+export function ExportCountReportPDF(arg1:number):Promise<string>;
+
+export function ExportExpiringLotsExcel(arg1:number,arg2:string):Promise<string>;
+
+export function ExportInventoryValuation(arg1:string):Promise<string>;
+
+export function ExportKardexExcel(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
 export function ExportMasterReport():Promise<string>;
 
+export function ExportProductImportReport(arg1:service.ResultadoImportacionProductos):Promise<string>;
+
+export function ExportProductLabels(arg1:Array<string>,arg2:db.LabelOptionsDTO):Promise<string>;
+
+export function ExportPromotionImpactExcel(arg1:string,arg2:string):Promise<string>;
+
+export function ExportPurchaseLabels(arg1:number,arg2:db.LabelOptionsDTO):Promise<string>;
+
+export function ExportReorderExcel(arg1:db.ReorderParamsDTO):Promise<string>;
+
 export function ExportSalesExcel(arg1:string,arg2:string):Promise<string>;
 
+export function FindProductByCode(arg1:string):Promise<db.ScanResultDTO>;
+
 export function GetBackups():Promise<Array<main.BackupDTO>>;
 
+export function GetBrands():Promise<Array<db.BrandDTO>>;
+
+export function GetCashDenominations():Promise<Array<number>>;
+
+export function GetCashSession(arg1:number):Promise<db.CashSessionDTO>;
+
+export function GetCashSessions(arg1:string,arg2:string):Promise<Array<db.CashSessionDTO>>;
+
+export function GetCategories():Promise<Array<db.CategoryDTO>>;
+
 export function GetClients():Promise<Array<db.ClientDTO>>;
 
+export function GetCountReview(arg1:number):Promise<db.CountSessionDTO>;
+
+export function GetCountSessions(arg1:string):Promise<Array<db.CountSessionDTO>>;
+
 export function GetDashboardStats(arg1:string,arg2:string):Promise<main.DashboardStats>;
 
 export function GetEmisorConfig():Promise<db.EmisorConfigDTO>;
 
+export function GetExpiringLots(arg1:number,arg2:string):Promise<Array<db.LotDTO>>;
+
 export function GetFacturasPaginated(arg1:number,arg2:number):Promise<main.FacturasResponse>;
 
+export function GetInventoryValuation(arg1:string):Promise<service.ReporteValorizacion>;
+
+export function GetInvoiceDraft(arg1:number):Promise<db.InvoiceDraftDTO>;
+
+export function GetInvoiceDrafts(arg1:string,arg2:string):Promise<Array<db.InvoiceDraftDTO>>;
+
+export function GetInvoiceHistory(arg1:string):Promise<Array<db.FacturaHistorialDTO>>;
+
+export function GetKardex(arg1:string,arg2:string,arg3:string,arg4:string):Promise<db.KardexDTO>;
+
+export function GetKitAvailability(arg1:string,arg2:string):Promise<db.KitAvailabilityDTO>;
+
+export function GetKitComponents(arg1:string):Promise<Array<db.KitComponentDTO>>;
+
+export function GetLots(arg1:string,arg2:string,arg3:boolean):Promise<Array<db.LotDTO>>;
+
 export function GetMailLogs():Promise<Array<db.MailLogDTO>>;
 
+export function GetMarginReport(arg1:string,arg2:string):Promise<service.ReporteMargenes>;
+
 export function GetNextQuotationSecuencial():Promise<string>;
 
 export function GetNextSecuencial():Promise<string>;
 
+export function GetNotifications(arg1:boolean):Promise<Array<db.NotificationDTO>>;
+
+export function GetOpenCashSession(arg1:string,arg2:string):Promise<db.CashSessionDTO>;
+
+export function GetPriceList(arg1:number):Promise<db.PriceListDTO>;
+
+export function GetPriceLists():Promise<Array<db.PriceListDTO>>;
+
+export function GetProductImage(arg1:string,arg2:boolean):Promise<string>;
+
+export function GetProductStockByWarehouse(arg1:string):Promise<Array<db.ProductStockDTO>>;
+
+export function GetProductTags():Promise<Array<string>>;
+
+export function GetProductUnits(arg1:string):Promise<Array<db.ProductUnitDTO>>;
+
 export function GetProducts():Promise<Array<db.ProductDTO>>;
 
+export function GetPromotionImpact(arg1:string,arg2:string):Promise<Array<db.PromotionImpactDTO>>;
+
+export function GetPromotions():Promise<Array<db.PromotionDTO>>;
+
+export function GetPurchase(arg1:number):Promise<db.PurchaseDTO>;
+
+export function GetPurchases(arg1:string,arg2:string):Promise<Array<db.PurchaseDTO>>;
+
 export function GetQuotations(arg1:number,arg2:number):Promise<main.QuotationListResponse>;
 
+export function GetReceivedQueue(arg1:string):Promise<Array<db.ComprobanteRecibidoDTO>>;
+
+export function GetRecurringInvoices():Promise<Array<db.RecurringInvoiceDTO>>;
+
+export function GetRecurringRuns(arg1:number):Promise<Array<db.RecurringRunDTO>>;
+
+export function GetReorderSuggestions(arg1:db.ReorderParamsDTO):Promise<Array<db.ReorderSuggestionDTO>>;
+
 export function GetSatelliteConnectionInfo():Promise<main.SatelliteConnectionDTO>;
 
 export function GetStatisticsCharts():Promise<main.ChartsDTO>;
 
+export function GetStockByClassification():Promise<service.ReporteStockClasificacion>;
+
+export function GetSuppliers(arg1:string):Promise<Array<db.SupplierDTO>>;
+
 export function GetSyncLogs():Promise<Array<service.SyncLog>>;
 
+export function GetTopBrands():Promise<Array<service.TopGrupo>>;
+
+export function GetTopCategories():Promise<Array<service.TopGrupo>>;
+
 export function GetTopProducts():Promise<Array<service.TopProduct>>;
 
+export function GetTransfer(arg1:number):Promise<db.TransferDTO>;
+
+export function GetTransfers(arg1:string,arg2:string):Promise<Array<db.TransferDTO>>;
+
+export function GetUnreadNotificationCount():Promise<number>;
+
 export function GetVATSummary(arg1:string,arg2:string):Promise<service.TaxSummary>;
 
+export function GetWarehouses(arg1:boolean):Promise<Array<db.WarehouseDTO>>;
+
 export function ImportClientsCSV():Promise<string>;
 
 export function ImportProductsCSV():Promise<string>;
 
+export function ImportProductsFile(arg1:string,arg2:Record<string, number>,arg3:boolean):Promise<service.ResultadoImportacionProductos>;
+
+export function ImportPurchaseXML(arg1:service.OpcionesImportacionXML):Promise<service.ResultadoImportacionXML>;
+
+export function LoadRejectedInvoice(arg1:string):Promise<db.FacturaDTO>;
+
+export function MarkNotificationRead(arg1:number):Promise<string>;
+
 export function NotifyFrontend(arg1:string,arg2:string):Promise<void>;
 
+export function OpenCashSession(arg1:string,arg2:string,arg3:string,arg4:number):Promise<db.CashSessionDTO>;
+
+export function OpenCountSession(arg1:string,arg2:string,arg3:string):Promise<db.CountSessionDTO>;
+
 export function OpenFacturaPDF(arg1:string):Promise<string>;
 
 export function OpenInvoiceFolder(arg1:string):Promise<string>;
 
 export function OpenInvoiceXML(arg1:string):Promise<string>;
 
+export function OpenProductImport():Promise<service.ArchivoImportacion>;
+
 export function OpenQuotationPDF(arg1:number):Promise<string>;
 
+export function PostCountSession(arg1:number,arg2:boolean):Promise<string>;
+
+export function PreviewInvoice(arg1:db.FacturaDTO):Promise<service.InvoicePreview>;
+
+export function PreviewRecurringRun(arg1:number):Promise<Array<service.RecurringPreview>>;
+
+export function ProcessReceivedQueue():Promise<service.ResultadoImportacionXML>;
+
+export function ReconcileReceivedReport(arg1:service.OpcionesImportacionXML):Promise<service.ConciliacionRecibidos>;
+
+export function RegisterLot(arg1:db.LotDTO,arg2:boolean):Promise<string>;
+
+export function RegisterPurchase(arg1:db.PurchaseDTO):Promise<string>;
+
+export function RemoveProductImage(arg1:string):Promise<string>;
+
 export function ResendInvoiceEmail(arg1:string):Promise<string>;
 
+export function ResolveCartPrices(arg1:string,arg2:Array<db.InvoiceItem>):Promise<Array<db.InvoiceItem>>;
+
+export function ResolvePrice(arg1:string,arg2:string,arg3:string,arg4:number):Promise<db.PriceQuoteDTO>;
+
+export function ResubmitInvoice(arg1:string,arg2:db.FacturaDTO):Promise<string>;
+
+export function RunRecurringNow():Promise<string>;
+
+export function SaveBrand(arg1:db.BrandDTO):Promise<string>;
+
+export function SaveCategory(arg1:db.CategoryDTO):Promise<string>;
+
 export function SaveClient(arg1:db.ClientDTO):Promise<string>;
 
 export function SaveEmisorConfig(arg1:db.EmisorConfigDTO):Promise<string>;
 
+export function SaveInvoiceDraft(arg1:db.InvoiceDraftDTO):Promise<db.InvoiceDraftDTO>;
+
+export function SaveKitComponents(arg1:string,arg2:boolean,arg3:Array<db.KitComponentDTO>):Promise<string>;
+
+export function SavePriceList(arg1:db.PriceListDTO):Promise<string>;
+
 export function SaveProduct(arg1:db.ProductDTO):Promise<string>;
 
+export function SaveProductUnits(arg1:string,arg2:Array<db.ProductUnitDTO>):Promise<string>;
+
+export function SavePromotion(arg1:db.PromotionDTO):Promise<string>;
+
+export function SaveRecurringInvoice(arg1:db.RecurringInvoiceDTO):Promise<string>;
+
+export function SaveSupplier(arg1:db.SupplierDTO):Promise<string>;
+
+export function SaveWarehouse(arg1:db.WarehouseDTO):Promise<string>;
+
 export function SearchClients(arg1:string):Promise<Array<db.ClientDTO>>;
 
 export function SearchInvoicesSmart(arg1:string):Promise<Array<db.FacturaResumenDTO>>;
 
 export function SearchProducts(arg1:string):Promise<Array<db.ProductDTO>>;
 
+export function SearchProductsFiltered(arg1:string,arg2:db.ProductFilterDTO):Promise<Array<db.ProductDTO>>;
+
 export function SelectAndSaveLogo():Promise<string>;
 
 export function SelectBackupPath():Promise<string>;
 
 export function SelectCertificate():Promise<string>;
 
+export function SelectProductImage(arg1:string):Promise<string>;
+
 export function SelectStoragePath():Promise<string>;
 
+export function SetPOSClient(arg1:string):Promise<void>;
+
+export function SetProductBinLocation(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function SetProductClassification(arg1:string,arg2:number,arg3:number,arg4:Array<string>):Promise<string>;
+
 export function TestSMTPConnection(arg1:db.EmisorConfigDTO):Promise<string>;
 
+export function TransferStock(arg1:db.TransferDTO):Promise<string>;
+
 export function TriggerSyncManual():Promise<string>;
+
+export function ValidateInvoiceBatch():Promise<service.LoteValidado>;
+
+export function VoidInvoice(arg1:string,arg2:string):Promise<string>;
+
+export function VoidPurchase(arg1:number):Promise<string>;
+
+export function VoidTransfer(arg1:number):Promise<string>;
+
+export function WriteOffLot(arg1:number,arg2:string):Promise<string>;
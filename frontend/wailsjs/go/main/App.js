@@ -6,10 +6,42 @@ export function ActivateLicense(arg1) {
   return window['go']['main']['App']['ActivateLicense'](arg1);
 }
 
+export function AddCashMovement(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['AddCashMovement'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function AddCountReading(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddCountReading'](arg1, arg2, arg3);
+}
+
+export function AdjustStock(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['AdjustStock'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function AssignInternalBarcodes(arg1) {
+  return window['go']['main']['App']['AssignInternalBarcodes'](arg1);
+}
+
+export function AssignPriceList(arg1, arg2) {
+  return window['go']['main']['App']['AssignPriceList'](arg1, arg2);
+}
+
+export function CancelCountSession(arg1) {
+  return window['go']['main']['App']['CancelCountSession'](arg1);
+}
+
 export function CheckLicense() {
   return window['go']['main']['App']['CheckLicense']();
 }
 
+export function CheckLowStock() {
+  return window['go']['main']['App']['CheckLowStock']();
+}
+
+export function CloseCashSession(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['CloseCashSession'](arg1, arg2, arg3, arg4);
+}
+
 export function ConvertQuotationToInvoice(arg1) {
   return window['go']['main']['App']['ConvertQuotationToInvoice'](arg1);
 }
@@ -26,30 +58,150 @@ export function CreateQuotation(arg1) {
   return window['go']['main']['App']['CreateQuotation'](arg1);
 }
 
+export function DeleteBrand(arg1) {
+  return window['go']['main']['App']['DeleteBrand'](arg1);
+}
+
+export function DeleteCategory(arg1) {
+  return window['go']['main']['App']['DeleteCategory'](arg1);
+}
+
 export function DeleteClient(arg1) {
   return window['go']['main']['App']['DeleteClient'](arg1);
 }
 
+export function DeleteInvoiceDraft(arg1) {
+  return window['go']['main']['App']['DeleteInvoiceDraft'](arg1);
+}
+
+export function DeletePriceList(arg1) {
+  return window['go']['main']['App']['DeletePriceList'](arg1);
+}
+
 export function DeleteProduct(arg1) {
   return window['go']['main']['App']['DeleteProduct'](arg1);
 }
 
+export function DeletePromotion(arg1) {
+  return window['go']['main']['App']['DeletePromotion'](arg1);
+}
+
+export function DeleteRecurringInvoice(arg1) {
+  return window['go']['main']['App']['DeleteRecurringInvoice'](arg1);
+}
+
+export function DeleteSupplier(arg1) {
+  return window['go']['main']['App']['DeleteSupplier'](arg1);
+}
+
+export function DeleteWarehouse(arg1) {
+  return window['go']['main']['App']['DeleteWarehouse'](arg1);
+}
+
+export function EmitInvoiceBatch(arg1, arg2) {
+  return window['go']['main']['App']['EmitInvoiceBatch'](arg1, arg2);
+}
+
+export function EmitInvoiceDraft(arg1) {
+  return window['go']['main']['App']['EmitInvoiceDraft'](arg1);
+}
+
+export function EvaluateCart(arg1, arg2) {
+  return window['go']['main']['App']['EvaluateCart'](arg1, arg2);
+}
+
+export function ExportBatchReport(arg1) {
+  return window['go']['main']['App']['ExportBatchReport'](arg1);
+}
+
+export function ExportCashClosePDF(arg1) {
+  return window['go']['main']['App']['ExportCashClosePDF'](arg1);
+}
+
+export function ExportCountReportPDF(arg1) {
+  return window['go']['main']['App']['ExportCountReportPDF'](arg1);
+}
+
+export function ExportExpiringLotsExcel(arg1, arg2) {
+  return window['go']['main']['App']['ExportExpiringLotsExcel'](arg1, arg2);
+}
+
+export function ExportInventoryValuation(arg1) {
+  return window['go']['main']['App']['ExportInventoryValuation'](arg1);
+}
+
+export function ExportKardexExcel(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ExportKardexExcel'](arg1, arg2, arg3, arg4);
+}
+
 export function ExportMasterReport() {
   return window['go']['main']['App']['ExportMasterReport']();
 }
 
+export function ExportProductImportReport(arg1) {
+  return window['go']['main']['App']['ExportProductImportReport'](arg1);
+}
+
+export function ExportProductLabels(arg1, arg2) {
+  return window['go']['main']['App']['ExportProductLabels'](arg1, arg2);
+}
+
+export function ExportPromotionImpactExcel(arg1, arg2) {
+  return window['go']['main']['App']['ExportPromotionImpactExcel'](arg1, arg2);
+}
+
+export function ExportPurchaseLabels(arg1, arg2) {
+  return window['go']['main']['App']['ExportPurchaseLabels'](arg1, arg2);
+}
+
+export function ExportReorderExcel(arg1) {
+  return window['go']['main']['App']['ExportReorderExcel'](arg1);
+}
+
 export function ExportSalesExcel(arg1, arg2) {
   return window['go']['main']['App']['ExportSalesExcel'](arg1, arg2);
 }
 
+export function FindProductByCode(arg1) {
+  return window['go']['main']['App']['FindProductByCode'](arg1);
+}
+
 export function GetBackups() {
   return window['go']['main']['App']['GetBackups']();
 }
 
+export function GetBrands() {
+  return window['go']['main']['App']['GetBrands']();
+}
+
+export function GetCashDenominations() {
+  return window['go']['main']['App']['GetCashDenominations']();
+}
+
+export function GetCashSession(arg1) {
+  return window['go']['main']['App']['GetCashSession'](arg1);
+}
+
+export function GetCashSessions(arg1, arg2) {
+  return window['go']['main']['App']['GetCashSessions'](arg1, arg2);
+}
+
+export function GetCategories() {
+  return window['go']['main']['App']['GetCategories']();
+}
+
 export function GetClients() {
   return window['go']['main']['App']['GetClients']();
 }
 
+export function GetCountReview(arg1) {
+  return window['go']['main']['App']['GetCountReview'](arg1);
+}
+
+export function GetCountSessions(arg1) {
+  return window['go']['main']['App']['GetCountSessions'](arg1);
+}
+
 export function GetDashboardStats(arg1, arg2) {
   return window['go']['main']['App']['GetDashboardStats'](arg1, arg2);
 }
@@ -58,14 +210,54 @@ export function GetEmisorConfig() {
   return window['go']['main']['App']['GetEmisorConfig']();
 }
 
+export function GetExpiringLots(arg1, arg2) {
+  return window['go']['main']['App']['GetExpiringLots'](arg1, arg2);
+}
+
 export function GetFacturasPaginated(arg1, arg2) {
   return window['go']['main']['App']['GetFacturasPaginated'](arg1, arg2);
 }
 
+export function GetInventoryValuation(arg1) {
+  return window['go']['main']['App']['GetInventoryValuation'](arg1);
+}
+
+export function GetInvoiceDraft(arg1) {
+  return window['go']['main']['App']['GetInvoiceDraft'](arg1);
+}
+
+export function GetInvoiceDrafts(arg1, arg2) {
+  return window['go']['main']['App']['GetInvoiceDrafts'](arg1, arg2);
+}
+
+export function GetInvoiceHistory(arg1) {
+  return window['go']['main']['App']['GetInvoiceHistory'](arg1);
+}
+
+export function GetKardex(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['GetKardex'](arg1, arg2, arg3, arg4);
+}
+
+export function GetKitAvailability(arg1, arg2) {
+  return window['go']['main']['App']['GetKitAvailability'](arg1, arg2);
+}
+
+export function GetKitComponents(arg1) {
+  return window['go']['main']['App']['GetKitComponents'](arg1);
+}
+
+export function GetLots(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetLots'](arg1, arg2, arg3);
+}
+
 export function GetMailLogs() {
   return window['go']['main']['App']['GetMailLogs']();
 }
 
+export function GetMarginReport(arg1, arg2) {
+  return window['go']['main']['App']['GetMarginReport'](arg1, arg2);
+}
+
 export function GetNextQuotationSecuencial() {
   return window['go']['main']['App']['GetNextQuotationSecuencial']();
 }
@@ -74,14 +266,78 @@ export function GetNextSecuencial() {
   return window['go']['main']['App']['GetNextSecuencial']();
 }
 
+export function GetNotifications(arg1) {
+  return window['go']['main']['App']['GetNotifications'](arg1);
+}
+
+export function GetOpenCashSession(arg1, arg2) {
+  return window['go']['main']['App']['GetOpenCashSession'](arg1, arg2);
+}
+
+export function GetPriceList(arg1) {
+  return window['go']['main']['App']['GetPriceList'](arg1);
+}
+
+export function GetPriceLists() {
+  return window['go']['main']['App']['GetPriceLists']();
+}
+
+export function GetProductImage(arg1, arg2) {
+  return window['go']['main']['App']['GetProductImage'](arg1, arg2);
+}
+
+export function GetProductStockByWarehouse(arg1) {
+  return window['go']['main']['App']['GetProductStockByWarehouse'](arg1);
+}
+
+export function GetProductTags() {
+  return window['go']['main']['App']['GetProductTags']();
+}
+
+export function GetProductUnits(arg1) {
+  return window['go']['main']['App']['GetProductUnits'](arg1);
+}
+
 export function GetProducts() {
   return window['go']['main']['App']['GetProducts']();
 }
 
+export function GetPromotionImpact(arg1, arg2) {
+  return window['go']['main']['App']['GetPromotionImpact'](arg1, arg2);
+}
+
+export function GetPromotions() {
+  return window['go']['main']['App']['GetPromotions']();
+}
+
+export function GetPurchase(arg1) {
+  return window['go']['main']['App']['GetPurchase'](arg1);
+}
+
+export function GetPurchases(arg1, arg2) {
+  return window['go']['main']['App']['GetPurchases'](arg1, arg2);
+}
+
 export function GetQuotations(arg1, arg2) {
   return window['go']['main']['App']['GetQuotations'](arg1, arg2);
 }
 
+export function GetReceivedQueue(arg1) {
+  return window['go']['main']['App']['GetReceivedQueue'](arg1);
+}
+
+export function GetRecurringInvoices() {
+  return window['go']['main']['App']['GetRecurringInvoices']();
+}
+
+export function GetRecurringRuns(arg1) {
+  return window['go']['main']['App']['GetRecurringRuns'](arg1);
+}
+
+export function GetReorderSuggestions(arg1) {
+  return window['go']['main']['App']['GetReorderSuggestions'](arg1);
+}
+
 export function GetSatelliteConnectionInfo() {
   return window['go']['main']['App']['GetSatelliteConnectionInfo']();
 }
@@ -90,18 +346,50 @@ export function GetStatisticsCharts() {
   return window['go']['main']['App']['GetStatisticsCharts']();
 }
 
+export function GetStockByClassification() {
+  return window['go']['main']['App']['GetStockByClassification']();
+}
+
+export function GetSuppliers(arg1) {
+  return window['go']['main']['App']['GetSuppliers'](arg1);
+}
+
 export function GetSyncLogs() {
   return window['go']['main']['App']['GetSyncLogs']();
 }
 
+export function GetTopBrands() {
+  return window['go']['main']['App']['GetTopBrands']();
+}
+
+export function GetTopCategories() {
+  return window['go']['main']['App']['GetTopCategories']();
+}
+
 export function GetTopProducts() {
   return window['go']['main']['App']['GetTopProducts']();
 }
 
+export function GetTransfer(arg1) {
+  return window['go']['main']['App']['GetTransfer'](arg1);
+}
+
+export function GetTransfers(arg1, arg2) {
+  return window['go']['main']['App']['GetTransfers'](arg1, arg2);
+}
+
+export function GetUnreadNotificationCount() {
+  return window['go']['main']['App']['GetUnreadNotificationCount']();
+}
+
 export function GetVATSummary(arg1, arg2) {
   return window['go']['main']['App']['GetVATSummary'](arg1, arg2);
 }
 
+export function GetWarehouses(arg1) {
+  return window['go']['main']['App']['GetWarehouses'](arg1);
+}
+
 export function ImportClientsCSV() {
   return window['go']['main']['App']['ImportClientsCSV']();
 }
@@ -110,10 +398,34 @@ export function ImportProductsCSV() {
   return window['go']['main']['App']['ImportProductsCSV']();
 }
 
+export function ImportProductsFile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportProductsFile'](arg1, arg2, arg3);
+}
+
+export function ImportPurchaseXML(arg1) {
+  return window['go']['main']['App']['ImportPurchaseXML'](arg1);
+}
+
+export function LoadRejectedInvoice(arg1) {
+  return window['go']['main']['App']['LoadRejectedInvoice'](arg1);
+}
+
+export function MarkNotificationRead(arg1) {
+  return window['go']['main']['App']['MarkNotificationRead'](arg1);
+}
+
 export function NotifyFrontend(arg1, arg2) {
   return window['go']['main']['App']['NotifyFrontend'](arg1, arg2);
 }
 
+export function OpenCashSession(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['OpenCashSession'](arg1, arg2, arg3, arg4);
+}
+
+export function OpenCountSession(arg1, arg2, arg3) {
+  return window['go']['main']['App']['OpenCountSession'](arg1, arg2, arg3);
+}
+
 export function OpenFacturaPDF(arg1) {
   return window['go']['main']['App']['OpenFacturaPDF'](arg1);
 }
@@ -126,14 +438,74 @@ export function OpenInvoiceXML(arg1) {
   return window['go']['main']['App']['OpenInvoiceXML'](arg1);
 }
 
+export function OpenProductImport() {
+  return window['go']['main']['App']['OpenProductImport']();
+}
+
 export function OpenQuotationPDF(arg1) {
   return window['go']['main']['App']['OpenQuotationPDF'](arg1);
 }
 
+export function PostCountSession(arg1, arg2) {
+  return window['go']['main']['App']['PostCountSession'](arg1, arg2);
+}
+
+export function PreviewInvoice(arg1) {
+  return window['go']['main']['App']['PreviewInvoice'](arg1);
+}
+
+export function PreviewRecurringRun(arg1) {
+  return window['go']['main']['App']['PreviewRecurringRun'](arg1);
+}
+
+export function ProcessReceivedQueue() {
+  return window['go']['main']['App']['ProcessReceivedQueue']();
+}
+
+export function ReconcileReceivedReport(arg1) {
+  return window['go']['main']['App']['ReconcileReceivedReport'](arg1);
+}
+
+export function RegisterLot(arg1, arg2) {
+  return window['go']['main']['App']['RegisterLot'](arg1, arg2);
+}
+
+export function RegisterPurchase(arg1) {
+  return window['go']['main']['App']['RegisterPurchase'](arg1);
+}
+
+export function RemoveProductImage(arg1) {
+  return window['go']['main']['App']['RemoveProductImage'](arg1);
+}
+
 export function ResendInvoiceEmail(arg1) {
   return window['go']['main']['App']['ResendInvoiceEmail'](arg1);
 }
 
+export function ResolveCartPrices(arg1, arg2) {
+  return window['go']['main']['App']['ResolveCartPrices'](arg1, arg2);
+}
+
+export function ResolvePrice(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ResolvePrice'](arg1, arg2, arg3, arg4);
+}
+
+export function ResubmitInvoice(arg1, arg2) {
+  return window['go']['main']['App']['ResubmitInvoice'](arg1, arg2);
+}
+
+export function RunRecurringNow() {
+  return window['go']['main']['App']['RunRecurringNow']();
+}
+
+export function SaveBrand(arg1) {
+  return window['go']['main']['App']['SaveBrand'](arg1);
+}
+
+export function SaveCategory(arg1) {
+  return window['go']['main']['App']['SaveCategory'](arg1);
+}
+
 export function SaveClient(arg1) {
   return window['go']['main']['App']['SaveClient'](arg1);
 }
@@ -142,10 +514,42 @@ export function SaveEmisorConfig(arg1) {
   return window['go']['main']['App']['SaveEmisorConfig'](arg1);
 }
 
+export function SaveInvoiceDraft(arg1) {
+  return window['go']['main']['App']['SaveInvoiceDraft'](arg1);
+}
+
+export function SaveKitComponents(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SaveKitComponents'](arg1, arg2, arg3);
+}
+
+export function SavePriceList(arg1) {
+  return window['go']['main']['App']['SavePriceList'](arg1);
+}
+
 export function SaveProduct(arg1) {
   return window['go']['main']['App']['SaveProduct'](arg1);
 }
 
+export function SaveProductUnits(arg1, arg2) {
+  return window['go']['main']['App']['SaveProductUnits'](arg1, arg2);
+}
+
+export function SavePromotion(arg1) {
+  return window['go']['main']['App']['SavePromotion'](arg1);
+}
+
+export function SaveRecurringInvoice(arg1) {
+  return window['go']['main']['App']['SaveRecurringInvoice'](arg1);
+}
+
+export function SaveSupplier(arg1) {
+  return window['go']['main']['App']['SaveSupplier'](arg1);
+}
+
+export function SaveWarehouse(arg1) {
+  return window['go']['main']['App']['SaveWarehouse'](arg1);
+}
+
 export function SearchClients(arg1) {
   return window['go']['main']['App']['SearchClients'](arg1);
 }
@@ -158,6 +562,10 @@ export function SearchProducts(arg1) {
   return window['go']['main']['App']['SearchProducts'](arg1);
 }
 
+export function SearchProductsFiltered(arg1, arg2) {
+  return window['go']['main']['App']['SearchProductsFiltered'](arg1, arg2);
+}
+
 export function SelectAndSaveLogo() {
   return window['go']['main']['App']['SelectAndSaveLogo']();
 }
@@ -170,14 +578,54 @@ export function SelectCertificate() {
   return window['go']['main']['App']['SelectCertificate']();
 }
 
+export function SelectProductImage(arg1) {
+  return window['go']['main']['App']['SelectProductImage'](arg1);
+}
+
 export function SelectStoragePath() {
   return window['go']['main']['App']['SelectStoragePath']();
 }
 
+export function SetPOSClient(arg1) {
+  return window['go']['main']['App']['SetPOSClient'](arg1);
+}
+
+export function SetProductBinLocation(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetProductBinLocation'](arg1, arg2, arg3);
+}
+
+export function SetProductClassification(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SetProductClassification'](arg1, arg2, arg3, arg4);
+}
+
 export function TestSMTPConnection(arg1) {
   return window['go']['main']['App']['TestSMTPConnection'](arg1);
 }
 
+export function TransferStock(arg1) {
+  return window['go']['main']['App']['TransferStock'](arg1);
+}
+
 export function TriggerSyncManual() {
   return window['go']['main']['App']['TriggerSyncManual']();
 }
+
+export function ValidateInvoiceBatch() {
+  return window['go']['main']['App']['ValidateInvoiceBatch']();
+}
+
+export function VoidInvoice(arg1, arg2) {
+  return window['go']['main']['App']['VoidInvoice'](arg1, arg2);
+}
+
+export function VoidPurchase(arg1) {
+  return window['go']['main']['App']['VoidPurchase'](arg1);
+}
+
+export function VoidTransfer(arg1) {
+  return window['go']['main']['App']['VoidTransfer'](arg1);
+}
+
+export function WriteOffLot(arg1, arg2) {
+  return window['go']['main']['App']['WriteOffLot'](arg1, arg2);
+}
@@ -1,5 +1,167 @@
 export namespace db {
 	
+	export class BrandDTO {
+	    id: number;
+	    nombre: string;
+	    productos: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BrandDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.nombre = source["nombre"];
+	        this.productos = source["productos"];
+	    }
+	}
+	export class CashCountDTO {
+	    denominacion: number;
+	    cantidad: number;
+	    subtotal: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CashCountDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.denominacion = source["denominacion"];
+	        this.cantidad = source["cantidad"];
+	        this.subtotal = source["subtotal"];
+	    }
+	}
+	export class CashMovementDTO {
+	    id: number;
+	    tipo: string;
+	    monto: number;
+	    motivo: string;
+	    usuario: string;
+	    fecha: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CashMovementDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.tipo = source["tipo"];
+	        this.monto = source["monto"];
+	        this.motivo = source["motivo"];
+	        this.usuario = source["usuario"];
+	        this.fecha = source["fecha"];
+	    }
+	}
+	export class CashPaymentDTO {
+	    formaPago: string;
+	    descripcion: string;
+	    facturas: number;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CashPaymentDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.formaPago = source["formaPago"];
+	        this.descripcion = source["descripcion"];
+	        this.facturas = source["facturas"];
+	        this.total = source["total"];
+	    }
+	}
+	export class CashSessionDTO {
+	    id: number;
+	    estab: string;
+	    ptoEmi: string;
+	    usuario: string;
+	    estado: string;
+	    apertura: string;
+	    cierre: string;
+	    usuarioCierre: string;
+	    fondoInicial: number;
+	    ventas: CashPaymentDTO[];
+	    facturas: number;
+	    totalVentas: number;
+	    ventasEfectivo: number;
+	    ingresos: number;
+	    egresos: number;
+	    efectivoEsperado: number;
+	    efectivoContado: number;
+	    diferencia: number;
+	    observacion: string;
+	    movimientos: CashMovementDTO[];
+	    conteo: CashCountDTO[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CashSessionDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.estab = source["estab"];
+	        this.ptoEmi = source["ptoEmi"];
+	        this.usuario = source["usuario"];
+	        this.estado = source["estado"];
+	        this.apertura = source["apertura"];
+	        this.cierre = source["cierre"];
+	        this.usuarioCierre = source["usuarioCierre"];
+	        this.fondoInicial = source["fondoInicial"];
+	        this.ventas = this.convertValues(source["ventas"], CashPaymentDTO);
+	        this.facturas = source["facturas"];
+	        this.totalVentas = source["totalVentas"];
+	        this.ventasEfectivo = source["ventasEfectivo"];
+	        this.ingresos = source["ingresos"];
+	        this.egresos = source["egresos"];
+	        this.efectivoEsperado = source["efectivoEsperado"];
+	        this.efectivoContado = source["efectivoContado"];
+	        this.diferencia = source["diferencia"];
+	        this.observacion = source["observacion"];
+	        this.movimientos = this.convertValues(source["movimientos"], CashMovementDTO);
+	        this.conteo = this.convertValues(source["conteo"], CashCountDTO);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CategoryDTO {
+	    id: number;
+	    nombre: string;
+	    parentId: number;
+	    ruta: string;
+	    productos: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CategoryDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.nombre = source["nombre"];
+	        this.parentId = source["parentId"];
+	        this.ruta = source["ruta"];
+	        this.productos = source["productos"];
+	    }
+	}
 	export class ClientDTO {
 	    ID: string;
 	    TipoID: string;
@@ -7,6 +169,9 @@ export namespace db {
 	    Direccion: string;
 	    Email: string;
 	    Telefono: string;
+	    ListaPrecioID: number;
+	    ListaPrecio: string;
+	    Segmento: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new ClientDTO(source);
@@ -20,7 +185,130 @@ export namespace db {
 	        this.Direccion = source["Direccion"];
 	        this.Email = source["Email"];
 	        this.Telefono = source["Telefono"];
+	        this.ListaPrecioID = source["ListaPrecioID"];
+	        this.ListaPrecio = source["ListaPrecio"];
+	        this.Segmento = source["Segmento"];
+	    }
+	}
+	export class ComprobanteRecibidoDTO {
+	    claveAcceso: string;
+	    tipo: string;
+	    rucEmisor: string;
+	    razonSocialEmisor: string;
+	    serie: string;
+	    fechaEmision: string;
+	    importeTotal: number;
+	    estado: string;
+	    intentos: number;
+	    mensaje: string;
+	    purchaseId: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ComprobanteRecibidoDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.claveAcceso = source["claveAcceso"];
+	        this.tipo = source["tipo"];
+	        this.rucEmisor = source["rucEmisor"];
+	        this.razonSocialEmisor = source["razonSocialEmisor"];
+	        this.serie = source["serie"];
+	        this.fechaEmision = source["fechaEmision"];
+	        this.importeTotal = source["importeTotal"];
+	        this.estado = source["estado"];
+	        this.intentos = source["intentos"];
+	        this.mensaje = source["mensaje"];
+	        this.purchaseId = source["purchaseId"];
+	    }
+	}
+	export class CountLineDTO {
+	    sku: string;
+	    nombre: string;
+	    ubicacion: string;
+	    sistema: number;
+	    contado: number;
+	    diferencia: number;
+	    noContado: boolean;
+	    lecturas: number;
+	    costoUnitario: number;
+	    valorDiferencia: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CountLineDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.ubicacion = source["ubicacion"];
+	        this.sistema = source["sistema"];
+	        this.contado = source["contado"];
+	        this.diferencia = source["diferencia"];
+	        this.noContado = source["noContado"];
+	        this.lecturas = source["lecturas"];
+	        this.costoUnitario = source["costoUnitario"];
+	        this.valorDiferencia = source["valorDiferencia"];
+	    }
+	}
+	export class CountSessionDTO {
+	    id: number;
+	    bodega: string;
+	    bodegaNombre: string;
+	    seccion: string;
+	    estado: string;
+	    nota: string;
+	    usuario: string;
+	    fecha: string;
+	    fechaCierre: string;
+	    contados: number;
+	    lineas: CountLineDTO[];
+	    sobrantes: number;
+	    faltantes: number;
+	    valorSobrante: number;
+	    valorFaltante: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CountSessionDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.bodega = source["bodega"];
+	        this.bodegaNombre = source["bodegaNombre"];
+	        this.seccion = source["seccion"];
+	        this.estado = source["estado"];
+	        this.nota = source["nota"];
+	        this.usuario = source["usuario"];
+	        this.fecha = source["fecha"];
+	        this.fechaCierre = source["fechaCierre"];
+	        this.contados = source["contados"];
+	        this.lineas = this.convertValues(source["lineas"], CountLineDTO);
+	        this.sobrantes = source["sobrantes"];
+	        this.faltantes = source["faltantes"];
+	        this.valorSobrante = source["valorSobrante"];
+	        this.valorFaltante = source["valorFaltante"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class EmisorConfigDTO {
 	    RUC: string;
@@ -74,7 +362,14 @@ export namespace db {
 	    codigo: string;
 	    nombre: string;
 	    cantidad: number;
+	    unidad: string;
+	    factor: number;
 	    precio: number;
+	    precioManual: boolean;
+	    descuento: number;
+	    promocionId: number;
+	    promocion: string;
+	    detalle: string;
 	    codigoIVA: string;
 	    porcentajeIVA: number;
 	
@@ -87,7 +382,14 @@ export namespace db {
 	        this.codigo = source["codigo"];
 	        this.nombre = source["nombre"];
 	        this.cantidad = source["cantidad"];
+	        this.unidad = source["unidad"];
+	        this.factor = source["factor"];
 	        this.precio = source["precio"];
+	        this.precioManual = source["precioManual"];
+	        this.descuento = source["descuento"];
+	        this.promocionId = source["promocionId"];
+	        this.promocion = source["promocion"];
+	        this.detalle = source["detalle"];
 	        this.codigoIVA = source["codigoIVA"];
 	        this.porcentajeIVA = source["porcentajeIVA"];
 	    }
@@ -144,17 +446,1707 @@ export namespace db {
 		    return a;
 		}
 	}
-	export class FacturaResumenDTO {
+	export class FacturaHistorialDTO {
+	    claveAcceso: string;
+	    intento: number;
+	    fecha: string;
+	    total: number;
+	    estado: string;
+	    mensajeError: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FacturaHistorialDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.claveAcceso = source["claveAcceso"];
+	        this.intento = source["intento"];
+	        this.fecha = source["fecha"];
+	        this.total = source["total"];
+	        this.estado = source["estado"];
+	        this.mensajeError = source["mensajeError"];
+	    }
+	}
+	export class FacturaResumenDTO {
+	    claveAcceso: string;
+	    secuencial: string;
+	    fecha: string;
+	    cliente: string;
+	    total: number;
+	    estado: string;
+	    tienePDF: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FacturaResumenDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.claveAcceso = source["claveAcceso"];
+	        this.secuencial = source["secuencial"];
+	        this.fecha = source["fecha"];
+	        this.cliente = source["cliente"];
+	        this.total = source["total"];
+	        this.estado = source["estado"];
+	        this.tienePDF = source["tienePDF"];
+	    }
+	}
+	export class InvoiceDraftDTO {
+	    id: number;
+	    usuario: string;
+	    terminal: string;
+	    ptoEmi: string;
+	    factura: FacturaDTO;
+	    clienteNombre: string;
+	    total: number;
+	    numItems: number;
+	    actualizado: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new InvoiceDraftDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.usuario = source["usuario"];
+	        this.terminal = source["terminal"];
+	        this.ptoEmi = source["ptoEmi"];
+	        this.factura = this.convertValues(source["factura"], FacturaDTO);
+	        this.clienteNombre = source["clienteNombre"];
+	        this.total = source["total"];
+	        this.numItems = source["numItems"];
+	        this.actualizado = source["actualizado"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class StockMovementDTO {
+	    id: number;
+	    fecha: string;
+	    tipo: string;
+	    entrada: number;
+	    salida: number;
+	    saldo: number;
+	    costo: number;
+	    documento: string;
+	    usuario: string;
+	    nota: string;
+	    bodega: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new StockMovementDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.fecha = source["fecha"];
+	        this.tipo = source["tipo"];
+	        this.entrada = source["entrada"];
+	        this.salida = source["salida"];
+	        this.saldo = source["saldo"];
+	        this.costo = source["costo"];
+	        this.documento = source["documento"];
+	        this.usuario = source["usuario"];
+	        this.nota = source["nota"];
+	        this.bodega = source["bodega"];
+	    }
+	}
+	export class KardexDTO {
+	    sku: string;
+	    nombre: string;
+	    bodega: string;
+	    desde: string;
+	    hasta: string;
+	    saldoInicial: number;
+	    entradas: number;
+	    salidas: number;
+	    saldoFinal: number;
+	    movimientos: StockMovementDTO[];
+	
+	    static createFrom(source: any = {}) {
+	        return new KardexDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.bodega = source["bodega"];
+	        this.desde = source["desde"];
+	        this.hasta = source["hasta"];
+	        this.saldoInicial = source["saldoInicial"];
+	        this.entradas = source["entradas"];
+	        this.salidas = source["salidas"];
+	        this.saldoFinal = source["saldoFinal"];
+	        this.movimientos = this.convertValues(source["movimientos"], StockMovementDTO);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class KitComponentDTO {
+	    sku: string;
+	    nombre: string;
+	    cantidad: number;
+	    stock: number;
+	    alcanza: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new KitComponentDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.cantidad = source["cantidad"];
+	        this.stock = source["stock"];
+	        this.alcanza = source["alcanza"];
+	    }
+	}
+	export class KitAvailabilityDTO {
+	    sku: string;
+	    bodega: string;
+	    disponible: number;
+	    limitante: string;
+	    componentes: KitComponentDTO[];
+	
+	    static createFrom(source: any = {}) {
+	        return new KitAvailabilityDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sku = source["sku"];
+	        this.bodega = source["bodega"];
+	        this.disponible = source["disponible"];
+	        this.limitante = source["limitante"];
+	        this.componentes = this.convertValues(source["componentes"], KitComponentDTO);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class LabelOptionsDTO {
+	    formato: string;
+	    simbologia: string;
+	    mostrarPrecio: boolean;
+	    mostrarUbicacion: boolean;
+	    copias: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LabelOptionsDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.formato = source["formato"];
+	        this.simbologia = source["simbologia"];
+	        this.mostrarPrecio = source["mostrarPrecio"];
+	        this.mostrarUbicacion = source["mostrarUbicacion"];
+	        this.copias = source["copias"];
+	    }
+	}
+	export class LotDTO {
+	    id: number;
+	    sku: string;
+	    nombre: string;
+	    bodega: string;
+	    numero: string;
+	    fechaVencimiento: string;
+	    diasParaVencer: number;
+	    estado: string;
+	    cantidad: number;
+	    cantidadInicial: number;
+	    valorCosto: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LotDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.bodega = source["bodega"];
+	        this.numero = source["numero"];
+	        this.fechaVencimiento = source["fechaVencimiento"];
+	        this.diasParaVencer = source["diasParaVencer"];
+	        this.estado = source["estado"];
+	        this.cantidad = source["cantidad"];
+	        this.cantidadInicial = source["cantidadInicial"];
+	        this.valorCosto = source["valorCosto"];
+	    }
+	}
+	export class MailLogDTO {
+	    id: number;
+	    facturaClave: string;
+	    email: string;
+	    estado: string;
+	    mensaje: string;
+	    fecha: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MailLogDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.facturaClave = source["facturaClave"];
+	        this.email = source["email"];
+	        this.estado = source["estado"];
+	        this.mensaje = source["mensaje"];
+	        this.fecha = source["fecha"];
+	    }
+	}
+	export class NotificationDTO {
+	    id: number;
+	    tipo: string;
+	    referencia: string;
+	    mensaje: string;
+	    leida: boolean;
+	    resuelta: boolean;
+	    fecha: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NotificationDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.tipo = source["tipo"];
+	        this.referencia = source["referencia"];
+	        this.mensaje = source["mensaje"];
+	        this.leida = source["leida"];
+	        this.resuelta = source["resuelta"];
+	        this.fecha = source["fecha"];
+	    }
+	}
+	export class PriceListItemDTO {
+	    id: number;
+	    sku: string;
+	    nombre: string;
+	    unidad: string;
+	    cantidadMinima: number;
+	    precio: number;
+	    porcentaje: number;
+	    vigenteDesde: string;
+	    vigenteHasta: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PriceListItemDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.unidad = source["unidad"];
+	        this.cantidadMinima = source["cantidadMinima"];
+	        this.precio = source["precio"];
+	        this.porcentaje = source["porcentaje"];
+	        this.vigenteDesde = source["vigenteDesde"];
+	        this.vigenteHasta = source["vigenteHasta"];
+	    }
+	}
+	export class PriceListDTO {
+	    id: number;
+	    nombre: string;
+	    descripcion: string;
+	    porcentaje: number;
+	    vigenteDesde: string;
+	    vigenteHasta: string;
+	    activa: boolean;
+	    clientes: number;
+	    items: PriceListItemDTO[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PriceListDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.nombre = source["nombre"];
+	        this.descripcion = source["descripcion"];
+	        this.porcentaje = source["porcentaje"];
+	        this.vigenteDesde = source["vigenteDesde"];
+	        this.vigenteHasta = source["vigenteHasta"];
+	        this.activa = source["activa"];
+	        this.clientes = source["clientes"];
+	        this.items = this.convertValues(source["items"], PriceListItemDTO);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class PriceQuoteDTO {
+	    sku: string;
+	    unidad: string;
+	    cantidad: number;
+	    precioBase: number;
+	    precio: number;
+	    listaId: number;
+	    lista: string;
+	    regla: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PriceQuoteDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sku = source["sku"];
+	        this.unidad = source["unidad"];
+	        this.cantidad = source["cantidad"];
+	        this.precioBase = source["precioBase"];
+	        this.precio = source["precio"];
+	        this.listaId = source["listaId"];
+	        this.lista = source["lista"];
+	        this.regla = source["regla"];
+	    }
+	}
+	export class Product {
+	    SKU: string;
+	    Name: string;
+	    Price: number;
+	    Stock: number;
+	    TaxCode: number;
+	    TaxPercentage: number;
+	    Barcode: string;
+	    AuxiliaryCode: string;
+	    MinStock: number;
+	    UnidadMedida: string;
+	    // Go type: time
+	    ExpiryDate?: any;
+	    Location: string;
+	    Cost: number;
+	    LastCost: number;
+	    CategoryID: number;
+	    BrandID: number;
+	    EsKit: boolean;
+	    DetallarKit: boolean;
+	    EsServicio: boolean;
+	    Imagen: string;
+	    // Go type: time
+	    CreatedAt: any;
+	    // Go type: time
+	    UpdatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Product(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.SKU = source["SKU"];
+	        this.Name = source["Name"];
+	        this.Price = source["Price"];
+	        this.Stock = source["Stock"];
+	        this.TaxCode = source["TaxCode"];
+	        this.TaxPercentage = source["TaxPercentage"];
+	        this.Barcode = source["Barcode"];
+	        this.AuxiliaryCode = source["AuxiliaryCode"];
+	        this.MinStock = source["MinStock"];
+	        this.UnidadMedida = source["UnidadMedida"];
+	        this.ExpiryDate = this.convertValues(source["ExpiryDate"], null);
+	        this.Location = source["Location"];
+	        this.Cost = source["Cost"];
+	        this.LastCost = source["LastCost"];
+	        this.CategoryID = source["CategoryID"];
+	        this.BrandID = source["BrandID"];
+	        this.EsKit = source["EsKit"];
+	        this.DetallarKit = source["DetallarKit"];
+	        this.EsServicio = source["EsServicio"];
+	        this.Imagen = source["Imagen"];
+	        this.CreatedAt = this.convertValues(source["CreatedAt"], null);
+	        this.UpdatedAt = this.convertValues(source["UpdatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProductUnitDTO {
+	    id: number;
+	    nombre: string;
+	    factor: number;
+	    barcode: string;
+	    price: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProductUnitDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.nombre = source["nombre"];
+	        this.factor = source["factor"];
+	        this.barcode = source["barcode"];
+	        this.price = source["price"];
+	    }
+	}
+	export class ProductDTO {
+	    SKU: string;
+	    Name: string;
+	    Price: number;
+	    Stock: number;
+	    StockOriginal?: number;
+	    TaxCode: string;
+	    TaxPercentage: number;
+	    Barcode: string;
+	    AuxiliaryCode: string;
+	    MinStock: number;
+	    ExpiryDate: string;
+	    Location: string;
+	    Cost: number;
+	    LastCost: number;
+	    UnidadMedida: string;
+	    Unidades: ProductUnitDTO[];
+	    CategoryID: number;
+	    Categoria: string;
+	    BrandID: number;
+	    Marca: string;
+	    Tags: string[];
+	    EsKit: boolean;
+	    DetallarKit: boolean;
+	    Componentes: KitComponentDTO[];
+	    EsServicio: boolean;
+	    TieneImagen: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProductDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.SKU = source["SKU"];
+	        this.Name = source["Name"];
+	        this.Price = source["Price"];
+	        this.Stock = source["Stock"];
+	        this.StockOriginal = source["StockOriginal"];
+	        this.TaxCode = source["TaxCode"];
+	        this.TaxPercentage = source["TaxPercentage"];
+	        this.Barcode = source["Barcode"];
+	        this.AuxiliaryCode = source["AuxiliaryCode"];
+	        this.MinStock = source["MinStock"];
+	        this.ExpiryDate = source["ExpiryDate"];
+	        this.Location = source["Location"];
+	        this.Cost = source["Cost"];
+	        this.LastCost = source["LastCost"];
+	        this.UnidadMedida = source["UnidadMedida"];
+	        this.Unidades = this.convertValues(source["Unidades"], ProductUnitDTO);
+	        this.CategoryID = source["CategoryID"];
+	        this.Categoria = source["Categoria"];
+	        this.BrandID = source["BrandID"];
+	        this.Marca = source["Marca"];
+	        this.Tags = source["Tags"];
+	        this.EsKit = source["EsKit"];
+	        this.DetallarKit = source["DetallarKit"];
+	        this.Componentes = this.convertValues(source["Componentes"], KitComponentDTO);
+	        this.EsServicio = source["EsServicio"];
+	        this.TieneImagen = source["TieneImagen"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProductFilterDTO {
+	    categoryId: number;
+	    brandId: number;
+	    tags: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProductFilterDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.categoryId = source["categoryId"];
+	        this.brandId = source["brandId"];
+	        this.tags = source["tags"];
+	    }
+	}
+	export class ProductStockDTO {
+	    sku: string;
+	    nombre: string;
+	    bodega: string;
+	    bodegaNombre: string;
+	    stock: number;
+	    ubicacion: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProductStockDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.bodega = source["bodega"];
+	        this.bodegaNombre = source["bodegaNombre"];
+	        this.stock = source["stock"];
+	        this.ubicacion = source["ubicacion"];
+	    }
+	}
+	
+	export class PromotionDTO {
+	    id: number;
+	    nombre: string;
+	    tipo: string;
+	    porcentaje: number;
+	    llevaN: number;
+	    pagaM: number;
+	    cantidadMinima: number;
+	    skus: string[];
+	    categorias: number[];
+	    marcas: number[];
+	    segmentos: string[];
+	    vigenteDesde: string;
+	    vigenteHasta: string;
+	    diasSemana: number[];
+	    horaDesde: string;
+	    horaHasta: string;
+	    activa: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PromotionDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.nombre = source["nombre"];
+	        this.tipo = source["tipo"];
+	        this.porcentaje = source["porcentaje"];
+	        this.llevaN = source["llevaN"];
+	        this.pagaM = source["pagaM"];
+	        this.cantidadMinima = source["cantidadMinima"];
+	        this.skus = source["skus"];
+	        this.categorias = source["categorias"];
+	        this.marcas = source["marcas"];
+	        this.segmentos = source["segmentos"];
+	        this.vigenteDesde = source["vigenteDesde"];
+	        this.vigenteHasta = source["vigenteHasta"];
+	        this.diasSemana = source["diasSemana"];
+	        this.horaDesde = source["horaDesde"];
+	        this.horaHasta = source["horaHasta"];
+	        this.activa = source["activa"];
+	    }
+	}
+	export class PromotionImpactDTO {
+	    promocionId: number;
+	    nombre: string;
+	    facturas: number;
+	    lineas: number;
+	    unidades: number;
+	    venta: number;
+	    descuento: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PromotionImpactDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.promocionId = source["promocionId"];
+	        this.nombre = source["nombre"];
+	        this.facturas = source["facturas"];
+	        this.lineas = source["lineas"];
+	        this.unidades = source["unidades"];
+	        this.venta = source["venta"];
+	        this.descuento = source["descuento"];
+	    }
+	}
+	export class PurchaseItemDTO {
+	    productoSku: string;
+	    nombre: string;
+	    cantidad: number;
+	    costoUnitario: number;
+	    porcentajeIva: number;
+	    subtotal: number;
+	    lote: string;
+	    fechaVencimiento: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PurchaseItemDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.productoSku = source["productoSku"];
+	        this.nombre = source["nombre"];
+	        this.cantidad = source["cantidad"];
+	        this.costoUnitario = source["costoUnitario"];
+	        this.porcentajeIva = source["porcentajeIva"];
+	        this.subtotal = source["subtotal"];
+	        this.lote = source["lote"];
+	        this.fechaVencimiento = source["fechaVencimiento"];
+	    }
+	}
+	export class PurchaseDTO {
+	    id: number;
+	    supplierRuc: string;
+	    supplierNombre: string;
+	    numeroFactura: string;
+	    autorizacion: string;
+	    fechaEmision: string;
+	    bodega: string;
+	    items: PurchaseItemDTO[];
+	    subtotal15: number;
+	    subtotal5: number;
+	    subtotal8: number;
+	    subtotal0: number;
+	    iva: number;
+	    total: number;
+	    estado: string;
+	    observacion: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PurchaseDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.supplierRuc = source["supplierRuc"];
+	        this.supplierNombre = source["supplierNombre"];
+	        this.numeroFactura = source["numeroFactura"];
+	        this.autorizacion = source["autorizacion"];
+	        this.fechaEmision = source["fechaEmision"];
+	        this.bodega = source["bodega"];
+	        this.items = this.convertValues(source["items"], PurchaseItemDTO);
+	        this.subtotal15 = source["subtotal15"];
+	        this.subtotal5 = source["subtotal5"];
+	        this.subtotal8 = source["subtotal8"];
+	        this.subtotal0 = source["subtotal0"];
+	        this.iva = source["iva"];
+	        this.total = source["total"];
+	        this.estado = source["estado"];
+	        this.observacion = source["observacion"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class QuotationItemDTO {
+	    codigo: string;
+	    nombre: string;
+	    cantidad: number;
+	    precio: number;
+	    precioManual: boolean;
+	    descuento: number;
+	    promocionId: number;
+	    promocion: string;
+	    codigoIVA: string;
+	    porcentajeIVA: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QuotationItemDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.codigo = source["codigo"];
+	        this.nombre = source["nombre"];
+	        this.cantidad = source["cantidad"];
+	        this.precio = source["precio"];
+	        this.precioManual = source["precioManual"];
+	        this.descuento = source["descuento"];
+	        this.promocionId = source["promocionId"];
+	        this.promocion = source["promocion"];
+	        this.codigoIVA = source["codigoIVA"];
+	        this.porcentajeIVA = source["porcentajeIVA"];
+	    }
+	}
+	export class QuotationDTO {
+	    id: number;
+	    secuencial: string;
+	    fechaEmision: string;
+	    clienteID: string;
+	    clienteNombre: string;
+	    clienteDireccion: string;
+	    clienteEmail: string;
+	    clienteTelefono: string;
+	    observacion: string;
+	    total: number;
+	    items: QuotationItemDTO[];
+	    estado: string;
+	    mostrarImagenes: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new QuotationDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.secuencial = source["secuencial"];
+	        this.fechaEmision = source["fechaEmision"];
+	        this.clienteID = source["clienteID"];
+	        this.clienteNombre = source["clienteNombre"];
+	        this.clienteDireccion = source["clienteDireccion"];
+	        this.clienteEmail = source["clienteEmail"];
+	        this.clienteTelefono = source["clienteTelefono"];
+	        this.observacion = source["observacion"];
+	        this.total = source["total"];
+	        this.items = this.convertValues(source["items"], QuotationItemDTO);
+	        this.estado = source["estado"];
+	        this.mostrarImagenes = source["mostrarImagenes"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class RecurringInvoiceDTO {
+	    id: number;
+	    nombre: string;
+	    factura: FacturaDTO;
+	    periodicidad: string;
+	    diaMes: number;
+	    fechaInicio: string;
+	    fechaFin: string;
+	    enviarCorreo: boolean;
+	    activa: boolean;
+	    proximaEmision: string;
+	    ultimaEmision: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecurringInvoiceDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.nombre = source["nombre"];
+	        this.factura = this.convertValues(source["factura"], FacturaDTO);
+	        this.periodicidad = source["periodicidad"];
+	        this.diaMes = source["diaMes"];
+	        this.fechaInicio = source["fechaInicio"];
+	        this.fechaFin = source["fechaFin"];
+	        this.enviarCorreo = source["enviarCorreo"];
+	        this.activa = source["activa"];
+	        this.proximaEmision = source["proximaEmision"];
+	        this.ultimaEmision = source["ultimaEmision"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecurringRunDTO {
+	    id: number;
+	    recurringId: number;
+	    plantilla: string;
+	    periodo: string;
+	    fecha: string;
+	    estado: string;
+	    claveAcceso: string;
+	    secuencial: string;
+	    total: number;
+	    mensaje: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecurringRunDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.recurringId = source["recurringId"];
+	        this.plantilla = source["plantilla"];
+	        this.periodo = source["periodo"];
+	        this.fecha = source["fecha"];
+	        this.estado = source["estado"];
+	        this.claveAcceso = source["claveAcceso"];
+	        this.secuencial = source["secuencial"];
+	        this.total = source["total"];
+	        this.mensaje = source["mensaje"];
+	    }
+	}
+	export class ReorderParamsDTO {
+	    diasHistorial: number;
+	    diasCobertura: number;
+	    diasEntregaDefecto: number;
+	    supplierRuc: string;
+	    soloBajoMinimo: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReorderParamsDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.diasHistorial = source["diasHistorial"];
+	        this.diasCobertura = source["diasCobertura"];
+	        this.diasEntregaDefecto = source["diasEntregaDefecto"];
+	        this.supplierRuc = source["supplierRuc"];
+	        this.soloBajoMinimo = source["soloBajoMinimo"];
+	    }
+	}
+	export class ReorderSuggestionDTO {
+	    sku: string;
+	    nombre: string;
+	    stock: number;
+	    minStock: number;
+	    ventaDiaria: number;
+	    diasRestantes: number;
+	    diasEntrega: number;
+	    sugerido: number;
+	    supplierRuc: string;
+	    supplierNombre: string;
+	    ultimoCosto: number;
+	    costoEstimado: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReorderSuggestionDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.stock = source["stock"];
+	        this.minStock = source["minStock"];
+	        this.ventaDiaria = source["ventaDiaria"];
+	        this.diasRestantes = source["diasRestantes"];
+	        this.diasEntrega = source["diasEntrega"];
+	        this.sugerido = source["sugerido"];
+	        this.supplierRuc = source["supplierRuc"];
+	        this.supplierNombre = source["supplierNombre"];
+	        this.ultimoCosto = source["ultimoCosto"];
+	        this.costoEstimado = source["costoEstimado"];
+	    }
+	}
+	export class ScanResultDTO {
+	    sku: string;
+	    nombre: string;
+	    unidad: string;
+	    factor: number;
+	    precio: number;
+	    stock: number;
+	    product: Product;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScanResultDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.unidad = source["unidad"];
+	        this.factor = source["factor"];
+	        this.precio = source["precio"];
+	        this.stock = source["stock"];
+	        this.product = this.convertValues(source["product"], Product);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SupplierDTO {
+	    ruc: string;
+	    razonSocial: string;
+	    nombreComercial: string;
+	    direccion: string;
+	    email: string;
+	    telefono: string;
+	    contacto: string;
+	    plazoPago: number;
+	    diasEntrega: number;
+	    notas: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SupplierDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ruc = source["ruc"];
+	        this.razonSocial = source["razonSocial"];
+	        this.nombreComercial = source["nombreComercial"];
+	        this.direccion = source["direccion"];
+	        this.email = source["email"];
+	        this.telefono = source["telefono"];
+	        this.contacto = source["contacto"];
+	        this.plazoPago = source["plazoPago"];
+	        this.diasEntrega = source["diasEntrega"];
+	        this.notas = source["notas"];
+	    }
+	}
+	export class TransferItemDTO {
+	    sku: string;
+	    nombre: string;
+	    cantidad: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TransferItemDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.cantidad = source["cantidad"];
+	    }
+	}
+	export class TransferDTO {
+	    id: number;
+	    origen: string;
+	    destino: string;
+	    guiaRemision: string;
+	    estado: string;
+	    usuario: string;
+	    nota: string;
+	    fecha: string;
+	    items: TransferItemDTO[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TransferDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.origen = source["origen"];
+	        this.destino = source["destino"];
+	        this.guiaRemision = source["guiaRemision"];
+	        this.estado = source["estado"];
+	        this.usuario = source["usuario"];
+	        this.nota = source["nota"];
+	        this.fecha = source["fecha"];
+	        this.items = this.convertValues(source["items"], TransferItemDTO);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class WarehouseDTO {
+	    codigo: string;
+	    nombre: string;
+	    direccion: string;
+	    estab: string;
+	    ptoEmi: string;
+	    principal: boolean;
+	    activa: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new WarehouseDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.codigo = source["codigo"];
+	        this.nombre = source["nombre"];
+	        this.direccion = source["direccion"];
+	        this.estab = source["estab"];
+	        this.ptoEmi = source["ptoEmi"];
+	        this.principal = source["principal"];
+	        this.activa = source["activa"];
+	    }
+	}
+
+}
+
+export namespace main {
+	
+	export class BackupDTO {
+	    name: string;
+	    size: string;
+	    date: string;
+	    path: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.size = source["size"];
+	        this.date = source["date"];
+	        this.path = source["path"];
+	    }
+	}
+	export class ChartsDTO {
+	    revenueBar: string;
+	    clientsPie: string;
+	    categoriesPie: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChartsDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.revenueBar = source["revenueBar"];
+	        this.clientsPie = source["clientsPie"];
+	        this.categoriesPie = source["categoriesPie"];
+	    }
+	}
+	export class DailySale {
+	    date: string;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DailySale(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.date = source["date"];
+	        this.total = source["total"];
+	    }
+	}
+	export class DashboardStats {
+	    totalFacturas: number;
+	    totalVentas: number;
+	    pendientes: number;
+	    sriOnline: boolean;
+	    salesTrend: DailySale[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DashboardStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.totalFacturas = source["totalFacturas"];
+	        this.totalVentas = source["totalVentas"];
+	        this.pendientes = source["pendientes"];
+	        this.sriOnline = source["sriOnline"];
+	        this.salesTrend = this.convertValues(source["salesTrend"], DailySale);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FacturasResponse {
+	    total: number;
+	    data: db.FacturaResumenDTO[];
+	
+	    static createFrom(source: any = {}) {
+	        return new FacturasResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total = source["total"];
+	        this.data = this.convertValues(source["data"], db.FacturaResumenDTO);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QuotationListResponse {
+	    total: number;
+	    data: db.QuotationDTO[];
+	
+	    static createFrom(source: any = {}) {
+	        return new QuotationListResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total = source["total"];
+	        this.data = this.convertValues(source["data"], db.QuotationDTO);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SatelliteConnectionDTO {
+	    ip: string;
+	    port: string;
+	    token: string;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SatelliteConnectionDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ip = source["ip"];
+	        this.port = source["port"];
+	        this.token = source["token"];
+	        this.url = source["url"];
+	    }
+	}
+
+}
+
+export namespace service {
+	
+	export class ArchivoImportacion {
+	    archivo: string;
+	    ruta: string;
+	    encabezados: string[];
+	    campos: string[];
+	    mapeo: Record<string, number>;
+	    muestra: string[][];
+	    filas: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ArchivoImportacion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.archivo = source["archivo"];
+	        this.ruta = source["ruta"];
+	        this.encabezados = source["encabezados"];
+	        this.campos = source["campos"];
+	        this.mapeo = source["mapeo"];
+	        this.muestra = source["muestra"];
+	        this.filas = source["filas"];
+	    }
+	}
+	export class ComprobanteImportado {
+	    archivo: string;
+	    claveAcceso: string;
+	    proveedor: string;
+	    numeroFactura: string;
+	    total: number;
+	    firma: string;
+	    estado: string;
+	    mensaje: string;
+	    compraId: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ComprobanteImportado(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.archivo = source["archivo"];
+	        this.claveAcceso = source["claveAcceso"];
+	        this.proveedor = source["proveedor"];
+	        this.numeroFactura = source["numeroFactura"];
+	        this.total = source["total"];
+	        this.firma = source["firma"];
+	        this.estado = source["estado"];
+	        this.mensaje = source["mensaje"];
+	        this.compraId = source["compraId"];
+	    }
+	}
+	export class ConciliacionItem {
+	    tipo: string;
+	    serie: string;
+	    rucEmisor: string;
+	    razonSocial: string;
+	    fechaEmision: string;
+	    claveAcceso: string;
+	    total: number;
+	    estado: string;
+	    compraId: number;
+	    totalRegistrado: number;
+	    mensaje: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConciliacionItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tipo = source["tipo"];
+	        this.serie = source["serie"];
+	        this.rucEmisor = source["rucEmisor"];
+	        this.razonSocial = source["razonSocial"];
+	        this.fechaEmision = source["fechaEmision"];
+	        this.claveAcceso = source["claveAcceso"];
+	        this.total = source["total"];
+	        this.estado = source["estado"];
+	        this.compraId = source["compraId"];
+	        this.totalRegistrado = source["totalRegistrado"];
+	        this.mensaje = source["mensaje"];
+	    }
+	}
+	export class ConciliacionRecibidos {
+	    desde: string;
+	    hasta: string;
+	    items: ConciliacionItem[];
+	    noEnSri: db.PurchaseDTO[];
+	    registradas: number;
+	    diferencias: number;
+	    faltantes: number;
+	    noSoportados: number;
+	    encolados: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConciliacionRecibidos(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.desde = source["desde"];
+	        this.hasta = source["hasta"];
+	        this.items = this.convertValues(source["items"], ConciliacionItem);
+	        this.noEnSri = this.convertValues(source["noEnSri"], db.PurchaseDTO);
+	        this.registradas = source["registradas"];
+	        this.diferencias = source["diferencias"];
+	        this.faltantes = source["faltantes"];
+	        this.noSoportados = source["noSoportados"];
+	        this.encolados = source["encolados"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ErrorLote {
+	    fila: number;
+	    referencia: string;
+	    mensaje: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ErrorLote(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fila = source["fila"];
+	        this.referencia = source["referencia"];
+	        this.mensaje = source["mensaje"];
+	    }
+	}
+	export class ExistenciaBodega {
+	    bodega: string;
+	    sku: string;
+	    nombre: string;
+	    cantidad: number;
+	    valor: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExistenciaBodega(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.bodega = source["bodega"];
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.cantidad = source["cantidad"];
+	        this.valor = source["valor"];
+	    }
+	}
+	export class FilaImportacion {
+	    fila: number;
+	    sku: string;
+	    nombre: string;
+	    accion: string;
+	    errores: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new FilaImportacion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fila = source["fila"];
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.accion = source["accion"];
+	        this.errores = source["errores"];
+	    }
+	}
+	export class ImpuestoPreview {
+	    codigo: string;
+	    codigoPorcentaje: string;
+	    baseImponible: number;
+	    valor: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImpuestoPreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.codigo = source["codigo"];
+	        this.codigoPorcentaje = source["codigoPorcentaje"];
+	        this.baseImponible = source["baseImponible"];
+	        this.valor = source["valor"];
+	    }
+	}
+	export class InvoicePreview {
+	    valida: boolean;
+	    error: string;
+	    advertencias: string[];
+	    secuencial: string;
+	    totalSinImpuestos: number;
+	    subtotalGravado: number;
+	    subtotalCero: number;
+	    totalIVA: number;
+	    importeTotal: number;
+	    totalConImpuestos: ImpuestoPreview[];
+	    xml: string;
+	    pdfBase64: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new InvoicePreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.valida = source["valida"];
+	        this.error = source["error"];
+	        this.advertencias = source["advertencias"];
+	        this.secuencial = source["secuencial"];
+	        this.totalSinImpuestos = source["totalSinImpuestos"];
+	        this.subtotalGravado = source["subtotalGravado"];
+	        this.subtotalCero = source["subtotalCero"];
+	        this.totalIVA = source["totalIVA"];
+	        this.importeTotal = source["importeTotal"];
+	        this.totalConImpuestos = this.convertValues(source["totalConImpuestos"], ImpuestoPreview);
+	        this.xml = source["xml"];
+	        this.pdfBase64 = source["pdfBase64"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LoteFactura {
+	    referencia: string;
+	    filas: number[];
+	    factura: db.FacturaDTO;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LoteFactura(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.referencia = source["referencia"];
+	        this.filas = source["filas"];
+	        this.factura = this.convertValues(source["factura"], db.FacturaDTO);
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LoteValidado {
+	    archivo: string;
+	    facturas: LoteFactura[];
+	    errores: ErrorLote[];
+	    valido: boolean;
+	    totalGeneral: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LoteValidado(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.archivo = source["archivo"];
+	        this.facturas = this.convertValues(source["facturas"], LoteFactura);
+	        this.errores = this.convertValues(source["errores"], ErrorLote);
+	        this.valido = source["valido"];
+	        this.totalGeneral = source["totalGeneral"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MargenFactura {
 	    claveAcceso: string;
 	    secuencial: string;
 	    fecha: string;
-	    cliente: string;
-	    total: number;
-	    estado: string;
-	    tienePDF: boolean;
+	    clienteId: string;
+	    venta: number;
+	    costo: number;
+	    margen: number;
+	    margenPct: number;
+	    bienes: number;
+	    servicios: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new FacturaResumenDTO(source);
+	        return new MargenFactura(source);
 	    }
 	
 	    constructor(source: any = {}) {
@@ -162,121 +2154,147 @@ export namespace db {
 	        this.claveAcceso = source["claveAcceso"];
 	        this.secuencial = source["secuencial"];
 	        this.fecha = source["fecha"];
-	        this.cliente = source["cliente"];
-	        this.total = source["total"];
-	        this.estado = source["estado"];
-	        this.tienePDF = source["tienePDF"];
+	        this.clienteId = source["clienteId"];
+	        this.venta = source["venta"];
+	        this.costo = source["costo"];
+	        this.margen = source["margen"];
+	        this.margenPct = source["margenPct"];
+	        this.bienes = source["bienes"];
+	        this.servicios = source["servicios"];
 	    }
 	}
-	
-	export class MailLogDTO {
-	    id: number;
-	    facturaClave: string;
-	    email: string;
-	    estado: string;
-	    mensaje: string;
-	    fecha: string;
+	export class MargenGrupo {
+	    grupo: string;
+	    cantidad: number;
+	    venta: number;
+	    costo: number;
+	    margen: number;
+	    margenPct: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new MailLogDTO(source);
+	        return new MargenGrupo(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.id = source["id"];
-	        this.facturaClave = source["facturaClave"];
-	        this.email = source["email"];
-	        this.estado = source["estado"];
-	        this.mensaje = source["mensaje"];
-	        this.fecha = source["fecha"];
+	        this.grupo = source["grupo"];
+	        this.cantidad = source["cantidad"];
+	        this.venta = source["venta"];
+	        this.costo = source["costo"];
+	        this.margen = source["margen"];
+	        this.margenPct = source["margenPct"];
 	    }
 	}
-	export class ProductDTO {
-	    SKU: string;
-	    Name: string;
-	    Price: number;
-	    Stock: number;
-	    TaxCode: string;
-	    TaxPercentage: number;
-	    Barcode: string;
-	    AuxiliaryCode: string;
-	    MinStock: number;
-	    ExpiryDate: string;
-	    Location: string;
+	export class MargenPeriodo {
+	    periodo: string;
+	    venta: number;
+	    costo: number;
+	    margen: number;
+	    margenPct: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new ProductDTO(source);
+	        return new MargenPeriodo(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.SKU = source["SKU"];
-	        this.Name = source["Name"];
-	        this.Price = source["Price"];
-	        this.Stock = source["Stock"];
-	        this.TaxCode = source["TaxCode"];
-	        this.TaxPercentage = source["TaxPercentage"];
-	        this.Barcode = source["Barcode"];
-	        this.AuxiliaryCode = source["AuxiliaryCode"];
-	        this.MinStock = source["MinStock"];
-	        this.ExpiryDate = source["ExpiryDate"];
-	        this.Location = source["Location"];
+	        this.periodo = source["periodo"];
+	        this.venta = source["venta"];
+	        this.costo = source["costo"];
+	        this.margen = source["margen"];
+	        this.margenPct = source["margenPct"];
 	    }
 	}
-	export class QuotationItemDTO {
-	    codigo: string;
+	export class MargenProducto {
+	    sku: string;
 	    nombre: string;
 	    cantidad: number;
-	    precio: number;
-	    codigoIVA: string;
-	    porcentajeIVA: number;
+	    venta: number;
+	    costo: number;
+	    margen: number;
+	    margenPct: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new QuotationItemDTO(source);
+	        return new MargenProducto(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.codigo = source["codigo"];
+	        this.sku = source["sku"];
 	        this.nombre = source["nombre"];
 	        this.cantidad = source["cantidad"];
-	        this.precio = source["precio"];
-	        this.codigoIVA = source["codigoIVA"];
-	        this.porcentajeIVA = source["porcentajeIVA"];
+	        this.venta = source["venta"];
+	        this.costo = source["costo"];
+	        this.margen = source["margen"];
+	        this.margenPct = source["margenPct"];
 	    }
 	}
-	export class QuotationDTO {
-	    id: number;
-	    secuencial: string;
-	    fechaEmision: string;
-	    clienteID: string;
-	    clienteNombre: string;
-	    clienteDireccion: string;
-	    clienteEmail: string;
-	    clienteTelefono: string;
-	    observacion: string;
+	export class OpcionesImportacionXML {
+	    actualizarInventario: boolean;
+	    crearProductos: boolean;
+	    aceptarSinFirma: boolean;
+	    bodega: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OpcionesImportacionXML(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.actualizarInventario = source["actualizarInventario"];
+	        this.crearProductos = source["crearProductos"];
+	        this.aceptarSinFirma = source["aceptarSinFirma"];
+	        this.bodega = source["bodega"];
+	    }
+	}
+	export class RecurringPreview {
+	    plantillaId: number;
+	    nombre: string;
+	    cliente: string;
+	    fecha: string;
 	    total: number;
-	    items: QuotationItemDTO[];
-	    estado: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new QuotationDTO(source);
+	        return new RecurringPreview(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.id = source["id"];
-	        this.secuencial = source["secuencial"];
-	        this.fechaEmision = source["fechaEmision"];
-	        this.clienteID = source["clienteID"];
-	        this.clienteNombre = source["clienteNombre"];
-	        this.clienteDireccion = source["clienteDireccion"];
-	        this.clienteEmail = source["clienteEmail"];
-	        this.clienteTelefono = source["clienteTelefono"];
-	        this.observacion = source["observacion"];
+	        this.plantillaId = source["plantillaId"];
+	        this.nombre = source["nombre"];
+	        this.cliente = source["cliente"];
+	        this.fecha = source["fecha"];
 	        this.total = source["total"];
-	        this.items = this.convertValues(source["items"], QuotationItemDTO);
-	        this.estado = source["estado"];
+	    }
+	}
+	export class ReporteMargenes {
+	    facturas: MargenFactura[];
+	    productos: MargenProducto[];
+	    tipos: MargenGrupo[];
+	    categorias: MargenGrupo[];
+	    marcas: MargenGrupo[];
+	    periodos: MargenPeriodo[];
+	    venta: number;
+	    costo: number;
+	    margen: number;
+	    margenPct: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReporteMargenes(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.facturas = this.convertValues(source["facturas"], MargenFactura);
+	        this.productos = this.convertValues(source["productos"], MargenProducto);
+	        this.tipos = this.convertValues(source["tipos"], MargenGrupo);
+	        this.categorias = this.convertValues(source["categorias"], MargenGrupo);
+	        this.marcas = this.convertValues(source["marcas"], MargenGrupo);
+	        this.periodos = this.convertValues(source["periodos"], MargenPeriodo);
+	        this.venta = source["venta"];
+	        this.costo = source["costo"];
+	        this.margen = source["margen"];
+	        this.margenPct = source["margenPct"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -297,75 +2315,108 @@ export namespace db {
 		    return a;
 		}
 	}
-
-}
-
-export namespace main {
-	
-	export class BackupDTO {
-	    name: string;
-	    size: string;
-	    date: string;
-	    path: string;
+	export class StockGrupo {
+	    grupo: string;
+	    productos: number;
+	    unidades: number;
+	    valor: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new BackupDTO(source);
+	        return new StockGrupo(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.name = source["name"];
-	        this.size = source["size"];
-	        this.date = source["date"];
-	        this.path = source["path"];
+	        this.grupo = source["grupo"];
+	        this.productos = source["productos"];
+	        this.unidades = source["unidades"];
+	        this.valor = source["valor"];
 	    }
 	}
-	export class ChartsDTO {
-	    revenueBar: string;
-	    clientsPie: string;
+	export class ReporteStockClasificacion {
+	    categorias: StockGrupo[];
+	    marcas: StockGrupo[];
+	    valor: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new ChartsDTO(source);
+	        return new ReporteStockClasificacion(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.revenueBar = source["revenueBar"];
-	        this.clientsPie = source["clientsPie"];
+	        this.categorias = this.convertValues(source["categorias"], StockGrupo);
+	        this.marcas = this.convertValues(source["marcas"], StockGrupo);
+	        this.valor = source["valor"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
-	export class DailySale {
-	    date: string;
-	    total: number;
+	export class ValorizacionProducto {
+	    sku: string;
+	    nombre: string;
+	    categoria: string;
+	    cantidad: number;
+	    costoUnitario: number;
+	    valor: number;
+	    ultimoMovimiento: string;
+	    diasSinMovimiento: number;
+	    antiguedad: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new DailySale(source);
+	        return new ValorizacionProducto(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.date = source["date"];
-	        this.total = source["total"];
+	        this.sku = source["sku"];
+	        this.nombre = source["nombre"];
+	        this.categoria = source["categoria"];
+	        this.cantidad = source["cantidad"];
+	        this.costoUnitario = source["costoUnitario"];
+	        this.valor = source["valor"];
+	        this.ultimoMovimiento = source["ultimoMovimiento"];
+	        this.diasSinMovimiento = source["diasSinMovimiento"];
+	        this.antiguedad = source["antiguedad"];
 	    }
 	}
-	export class DashboardStats {
-	    totalFacturas: number;
-	    totalVentas: number;
-	    pendientes: number;
-	    sriOnline: boolean;
-	    salesTrend: DailySale[];
+	export class ReporteValorizacion {
+	    fecha: string;
+	    productos: ValorizacionProducto[];
+	    categorias: StockGrupo[];
+	    bodegas: StockGrupo[];
+	    antiguedad: StockGrupo[];
+	    existencias: ExistenciaBodega[];
+	    valor: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new DashboardStats(source);
+	        return new ReporteValorizacion(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.totalFacturas = source["totalFacturas"];
-	        this.totalVentas = source["totalVentas"];
-	        this.pendientes = source["pendientes"];
-	        this.sriOnline = source["sriOnline"];
-	        this.salesTrend = this.convertValues(source["salesTrend"], DailySale);
+	        this.fecha = source["fecha"];
+	        this.productos = this.convertValues(source["productos"], ValorizacionProducto);
+	        this.categorias = this.convertValues(source["categorias"], StockGrupo);
+	        this.bodegas = this.convertValues(source["bodegas"], StockGrupo);
+	        this.antiguedad = this.convertValues(source["antiguedad"], StockGrupo);
+	        this.existencias = this.convertValues(source["existencias"], ExistenciaBodega);
+	        this.valor = source["valor"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -386,18 +2437,28 @@ export namespace main {
 		    return a;
 		}
 	}
-	export class FacturasResponse {
-	    total: number;
-	    data: db.FacturaResumenDTO[];
+	export class ResultadoImportacionProductos {
+	    archivo: string;
+	    filas: FilaImportacion[];
+	    creados: number;
+	    actualizados: number;
+	    conError: number;
+	    simulacion: boolean;
+	    aplicado: boolean;
 	
 	    static createFrom(source: any = {}) {
-	        return new FacturasResponse(source);
+	        return new ResultadoImportacionProductos(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.total = source["total"];
-	        this.data = this.convertValues(source["data"], db.FacturaResumenDTO);
+	        this.archivo = source["archivo"];
+	        this.filas = this.convertValues(source["filas"], FilaImportacion);
+	        this.creados = source["creados"];
+	        this.actualizados = source["actualizados"];
+	        this.conError = source["conError"];
+	        this.simulacion = source["simulacion"];
+	        this.aplicado = source["aplicado"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -418,18 +2479,24 @@ export namespace main {
 		    return a;
 		}
 	}
-	export class QuotationListResponse {
-	    total: number;
-	    data: db.QuotationDTO[];
+	export class ResultadoImportacionXML {
+	    comprobantes: ComprobanteImportado[];
+	    importadas: number;
+	    duplicadas: number;
+	    omitidas: number;
+	    errores: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new QuotationListResponse(source);
+	        return new ResultadoImportacionXML(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.total = source["total"];
-	        this.data = this.convertValues(source["data"], db.QuotationDTO);
+	        this.comprobantes = this.convertValues(source["comprobantes"], ComprobanteImportado);
+	        this.importadas = source["importadas"];
+	        this.duplicadas = source["duplicadas"];
+	        this.omitidas = source["omitidas"];
+	        this.errores = source["errores"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -450,28 +2517,64 @@ export namespace main {
 		    return a;
 		}
 	}
-	export class SatelliteConnectionDTO {
-	    ip: string;
-	    port: string;
-	    token: string;
-	    url: string;
+	export class ResultadoLote {
+	    referencia: string;
+	    cliente: string;
+	    secuencial: string;
+	    claveAcceso: string;
+	    estado: string;
+	    total: number;
+	    mensaje: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new SatelliteConnectionDTO(source);
+	        return new ResultadoLote(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.ip = source["ip"];
-	        this.port = source["port"];
-	        this.token = source["token"];
-	        this.url = source["url"];
+	        this.referencia = source["referencia"];
+	        this.cliente = source["cliente"];
+	        this.secuencial = source["secuencial"];
+	        this.claveAcceso = source["claveAcceso"];
+	        this.estado = source["estado"];
+	        this.total = source["total"];
+	        this.mensaje = source["mensaje"];
 	    }
 	}
-
-}
-
-export namespace service {
+	export class ResumenLote {
+	    resultados: ResultadoLote[];
+	    emitidas: number;
+	    fallidas: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ResumenLote(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.resultados = this.convertValues(source["resultados"], ResultadoLote);
+	        this.emitidas = source["emitidas"];
+	        this.fallidas = source["fallidas"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	
 	export class SyncLog {
 	    id: string;
@@ -501,6 +2604,12 @@ export namespace service {
 	    ventas15: number;
 	    ventas0: number;
 	    ivaGenerado: number;
+	    compras15: number;
+	    compras5: number;
+	    compras8: number;
+	    compras0: number;
+	    ivaCompras: number;
+	    creditoTributario: number;
 	    retencionesIva: number;
 	    factorProporcion: number;
 	    impuestoSugerido: number;
@@ -514,11 +2623,33 @@ export namespace service {
 	        this.ventas15 = source["ventas15"];
 	        this.ventas0 = source["ventas0"];
 	        this.ivaGenerado = source["ivaGenerado"];
+	        this.compras15 = source["compras15"];
+	        this.compras5 = source["compras5"];
+	        this.compras8 = source["compras8"];
+	        this.compras0 = source["compras0"];
+	        this.ivaCompras = source["ivaCompras"];
+	        this.creditoTributario = source["creditoTributario"];
 	        this.retencionesIva = source["retencionesIva"];
 	        this.factorProporcion = source["factorProporcion"];
 	        this.impuestoSugerido = source["impuestoSugerido"];
 	    }
 	}
+	export class TopGrupo {
+	    grupo: string;
+	    quantity: number;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TopGrupo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.grupo = source["grupo"];
+	        this.quantity = source["quantity"];
+	        this.total = source["total"];
+	    }
+	}
 	export class TopProduct {
 	    sku: string;
 	    name: string;
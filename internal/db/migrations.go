@@ -8,10 +8,20 @@ import (
 
 // RunMigrations ejecuta el AutoMigrate de GORM para asegurar que las tablas existan.
 func Migrate(db *gorm.DB) {
+	comprasPorTarifa := db.Migrator().HasTable(&Purchase{}) && !db.Migrator().HasColumn(&Purchase{}, "subtotal5")
+
 	db.AutoMigrate(
 		&EmisorConfig{},
 		&Factura{},
 		&Product{},
+		&ProductUnit{},
+		&KitComponent{},
+		&Category{},
+		&Brand{},
+		&ProductTag{},
+		&PriceList{},
+		&PriceListItem{},
+		&Promotion{},
 		&Client{},
 		&EmailQueue{},
 		&FacturaItem{},
@@ -19,6 +29,30 @@ func Migrate(db *gorm.DB) {
 		&Quotation{},
 		&QuotationItem{},
 		&RetencionRecibida{},
+		&FacturaHistorial{},
+		&InvoiceDraft{},
+		&RecurringInvoice{},
+		&RecurringRun{},
+		&StockMovement{},
+		&Supplier{},
+		&Purchase{},
+		&PurchaseItem{},
+		&ComprobanteRecibido{},
+		&Warehouse{},
+		&ProductStock{},
+		&Transfer{},
+		&TransferItem{},
+		&Lot{},
+		&LotConsumption{},
+		&Notification{},
+		&CountSession{},
+		&CountEntry{},
+		&CountSnapshot{},
+		&CountLine{},
+		&CashSession{},
+		&CashMovement{},
+		&CashSessionPayment{},
+		&CashCount{},
 	)
 	
 	// OPTIMIZACIÓN: Índices manuales para el Dashboard y Buscador
@@ -34,6 +68,10 @@ func Migrate(db *gorm.DB) {
 	}
 
 	seedEmisor(db)
+	seedBodegas(db)
+	if comprasPorTarifa {
+		separarTarifasCompras(db)
+	}
 }
 
 func seedEmisor(db *gorm.DB) {
@@ -54,3 +92,26 @@ func seedEmisor(db *gorm.DB) {
 		log.Println("Se ha creado un Emisor de prueba por defecto.")
 	}
 }
+
+// seedBodegas crea la bodega principal y le asigna el stock existente (instalaciones previas
+// a multibodega, donde Product.Stock era el único saldo).
+func seedBodegas(db *gorm.DB) {
+	var count int64
+	db.Model(&Warehouse{}).Count(&count)
+	if count == 0 {
+		db.Create(&Warehouse{Codigo: "PRINCIPAL", Nombre: "Bodega Principal", Principal: true, Activa: true})
+		db.Exec("INSERT INTO product_stocks (product_sku, bodega, stock, ubicacion, updated_at) SELECT sku, 'PRINCIPAL', stock, location, CURRENT_TIMESTAMP FROM products WHERE stock <> 0")
+		db.Exec("UPDATE stock_movements SET bodega = 'PRINCIPAL', saldo_bodega = saldo WHERE bodega IS NULL OR bodega = ''")
+		log.Println("Se ha creado la bodega principal.")
+	}
+}
+
+// separarTarifasCompras saca de Subtotal15 las bases al 5% y 8% de las compras registradas antes de
+// que se guardaran por separado.
+func separarTarifasCompras(db *gorm.DB) {
+	db.Exec(`UPDATE purchases SET
+		subtotal5 = (SELECT COALESCE(SUM(subtotal), 0) FROM purchase_items WHERE purchase_id = purchases.id AND porcentaje_iva = 5),
+		subtotal8 = (SELECT COALESCE(SUM(subtotal), 0) FROM purchase_items WHERE purchase_id = purchases.id AND porcentaje_iva = 8)`)
+	db.Exec("UPDATE purchases SET subtotal15 = ROUND(subtotal15 - subtotal5 - subtotal8, 2) WHERE subtotal5 <> 0 OR subtotal8 <> 0")
+	log.Println("Se separaron las bases de compras al 5% y 8%.")
+}
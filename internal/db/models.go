@@ -43,14 +43,16 @@ type EmisorConfig struct {
 
 // Client representa a los clientes/compradores.
 type Client struct {
-	ID        string `gorm:"primaryKey"`
-	TipoID    string
-	Nombre    string `gorm:"index"`
-	Direccion string
-	Email     string
-	Telefono  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID            string `gorm:"primaryKey"`
+	TipoID        string
+	Nombre        string `gorm:"index"`
+	Direccion     string
+	Email         string
+	Telefono      string
+	ListaPrecioID uint   `gorm:"index"` // Lista de precios asignada; 0 = precio base del producto
+	Segmento      string `gorm:"index"` // Segmento comercial (VIP, MAYORISTA...) para promociones
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 // Factura representa un comprobante electrónico en la base de datos.
@@ -67,10 +69,28 @@ type Factura struct {
 	Subtotal15      float64
 	Subtotal0       float64
 	IVA             float64
+	FormaPago       string `gorm:"size:2"` // Código SRI del pago (01 = efectivo); vacío en facturas anteriores, ver el XML
+	StockPendiente  bool   // La salida de inventario falló después del envío al SRI y debe registrarse a mano
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 }
 
+// FacturaHistorial guarda los intentos previos de un comprobante rechazado por el SRI
+// (DEVUELTA / NO AUTORIZADO) que fue corregido y reenviado.
+type FacturaHistorial struct {
+	ID           uint   `gorm:"primaryKey"`
+	FacturaClave string `gorm:"index"` // Clave de acceso del documento vigente
+	ClaveAcceso  string `gorm:"size:49"` // Clave usada en este intento
+	Secuencial   string `gorm:"size:9;index"`
+	Intento      int
+	FechaEmision time.Time
+	Total        float64
+	EstadoSRI    string
+	MensajeError string
+	XMLFirmado   []byte `gorm:"type:blob"`
+	CreatedAt    time.Time
+}
+
 // FacturaItem almacena el detalle de cada producto.
 type FacturaItem struct {
 	ID               uint    `gorm:"primaryKey"`
@@ -78,8 +98,15 @@ type FacturaItem struct {
 	ProductoSKU      string  `gorm:"index"`
 	Nombre           string
 	Cantidad         float64
+	Unidad           string  // Unidad de venta (vacío = unidad base del producto)
+	Factor           float64 `gorm:"default:1"` // Unidades base por unidad de venta
 	PrecioUnitario   float64
 	Subtotal         float64
+	CostoUnitario    float64 // Costo promedio del producto al momento de la venta, por unidad de venta
+	Descuento        float64 // Descuento de la línea (promoción o manual), ya restado del Subtotal
+	PromocionID      uint    `gorm:"index"`
+	Promocion        string  // Nombre de la promoción aplicada
+	Detalle          string  // Texto libre de la línea
 		PorcentajeIVA   float64
 		CreatedAt       time.Time
 	}
@@ -100,19 +127,133 @@ type Product struct {
 	SKU           string `gorm:"primaryKey"`
 	Name          string `gorm:"index"`
 	Price         float64
-	Stock         int
+	Stock         float64
 	TaxCode       int
 	TaxPercentage int
 	// Nuevos campos POS
 	Barcode       string `gorm:"uniqueIndex"`
 	AuxiliaryCode string
-	MinStock      int
+	MinStock      float64
+	UnidadMedida  string // Unidad base del stock (UNIDAD, KG, M, LT...), se imprime en el detalle del XML
 	ExpiryDate    *time.Time
 	Location      string
+	Cost          float64 // Costo promedio ponderado, se recalcula en cada entrada con costo
+	LastCost      float64 // Último costo de compra
+	CategoryID    uint    `gorm:"index"` // 0 = sin categoría
+	BrandID       uint    `gorm:"index"` // 0 = sin marca
+	EsKit         bool    `gorm:"index"` // Sin stock propio: su venta descuenta los componentes (KitComponent)
+	DetallarKit   bool    // Lista los componentes del kit en detallesAdicionales de la factura
+	EsServicio    bool    `gorm:"index"` // Sin inventario: no mueve stock ni entra en alertas ni tomas físicas
+	Imagen        string  // Ruta de la foto normalizada (JPEG); la miniatura va al lado con sufijo _thumb
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 }
 
+// KitComponent es un producto que forma parte de un kit (canasta, combo). Cantidad está en la
+// unidad base del componente, por cada unidad del kit.
+type KitComponent struct {
+	ID           uint   `gorm:"primaryKey"`
+	KitSKU       string `gorm:"uniqueIndex:idx_componente_kit"`
+	ComponentSKU string `gorm:"uniqueIndex:idx_componente_kit;index"`
+	Cantidad     float64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// ProductUnit es una presentación alternativa de venta de un producto (caja de 12, paquete de 6).
+// Factor es cuántas unidades base contiene; el stock siempre se lleva en la unidad base.
+type ProductUnit struct {
+	ID         uint   `gorm:"primaryKey"`
+	ProductSKU string `gorm:"uniqueIndex:idx_unidad_producto"`
+	Nombre     string `gorm:"uniqueIndex:idx_unidad_producto"` // CAJA, PAQUETE, DOCENA...
+	Factor     float64
+	Barcode    string `gorm:"index"` // Código de barras propio de la presentación
+	Price      float64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Category es una categoría de productos; ParentID forma el árbol (nil = raíz). Ruta guarda el
+// camino completo ("Bebidas > Gaseosas") para listados y reportes.
+type Category struct {
+	ID        uint   `gorm:"primaryKey"`
+	Nombre    string
+	ParentID  *uint  `gorm:"index"`
+	Ruta      string `gorm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Brand es la marca comercial de un producto.
+type Brand struct {
+	ID        uint   `gorm:"primaryKey"`
+	Nombre    string `gorm:"uniqueIndex"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ProductTag es una etiqueta libre de un producto ("importado", "temporada").
+type ProductTag struct {
+	ID         uint   `gorm:"primaryKey"`
+	ProductSKU string `gorm:"uniqueIndex:idx_tag_producto"`
+	Tag        string `gorm:"uniqueIndex:idx_tag_producto;index"`
+}
+
+// PriceList es una lista de precios (mayorista, distribuidor...). Porcentaje es la regla general
+// sobre el precio base (-10 = 10% de descuento) para productos sin precio propio en la lista.
+type PriceList struct {
+	ID           uint   `gorm:"primaryKey"`
+	Nombre       string `gorm:"uniqueIndex"`
+	Descripcion  string
+	Porcentaje   float64
+	VigenteDesde *time.Time
+	VigenteHasta *time.Time
+	Activa       bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// PriceListItem es el precio de un producto en una lista desde CantidadMinima (escalas por
+// cantidad). Con Precio en cero se aplica Porcentaje sobre el precio base.
+type PriceListItem struct {
+	ID             uint   `gorm:"primaryKey"`
+	PriceListID    uint   `gorm:"index"`
+	ProductSKU     string `gorm:"index"`
+	Unidad         string // Presentación; vacío = unidad base
+	CantidadMinima float64
+	Precio         float64
+	Porcentaje     float64
+	VigenteDesde   *time.Time
+	VigenteHasta   *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Promotion es una regla de descuento automática sobre el carrito. Tipo PORCENTAJE descuenta
+// Porcentaje de las líneas que califican (desde CantidadMinima); NXM cobra PagaM de cada LlevaN
+// unidades ("2x1", "3x2"). Las listas separadas por coma vacías no restringen.
+type Promotion struct {
+	ID             uint   `gorm:"primaryKey"`
+	Nombre         string `gorm:"uniqueIndex"`
+	Tipo           string // PORCENTAJE, NXM
+	Porcentaje     float64
+	LlevaN         int
+	PagaM          int
+	CantidadMinima float64
+	SKUs           string // Productos que participan
+	Categorias     string // IDs de categorías que participan (incluye sus subcategorías)
+	Marcas         string // IDs de marcas que participan
+	Segmentos      string // Segmentos de cliente que participan
+	VigenteDesde   *time.Time
+	VigenteHasta   *time.Time
+	DiasSemana     string // 1 = lunes ... 7 = domingo
+	HoraDesde      string // HH:MM
+	HoraHasta      string // HH:MM, exclusiva
+	Activa         bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
 // MailLog registra el historial de envíos de correo.
 type MailLog struct {
 	ID           uint      `gorm:"primaryKey"`
@@ -177,11 +318,329 @@ type QuotationItem struct {
 	Nombre          string
 	Cantidad        float64
 	PrecioUnitario  float64
+	Descuento       float64
+	PromocionID     uint
+	Promocion       string
 	Subtotal        float64
 	PorcentajeIVA   float64
 	CreatedAt       time.Time
 }
 
+// InvoiceDraft guarda una factura en construcción para que sobreviva a reinicios de la app.
+// El contenido completo (cliente, ítems, pago, observación) se serializa en Data como JSON de FacturaDTO.
+type InvoiceDraft struct {
+	ID            uint   `gorm:"primaryKey"`
+	Usuario       string `gorm:"index"`
+	Terminal      string `gorm:"index"`
+	PtoEmi        string
+	ClienteID     string
+	ClienteNombre string
+	Total         float64
+	NumItems      int
+	Data          string `gorm:"type:text"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time `gorm:"index"`
+}
+
+// RecurringInvoice es una plantilla de facturación periódica (contratos de mantenimiento, suscripciones).
+// La factura modelo se guarda en Data como JSON de FacturaDTO.
+type RecurringInvoice struct {
+	ID             uint `gorm:"primaryKey"`
+	Nombre         string
+	ClienteID      string `gorm:"index"`
+	ClienteNombre  string
+	Data           string `gorm:"type:text"`
+	Periodicidad   string // MENSUAL, BIMESTRAL, TRIMESTRAL, SEMESTRAL, ANUAL
+	DiaMes         int
+	FechaInicio    time.Time
+	FechaFin       *time.Time
+	EnviarCorreo   bool
+	Activa         bool      `gorm:"index"`
+	ProximaEmision time.Time `gorm:"index"`
+	UltimaEmision  *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// RecurringRun registra cada intento de emisión de una plantilla recurrente.
+type RecurringRun struct {
+	ID          uint `gorm:"primaryKey"`
+	RecurringID uint `gorm:"index"`
+	Periodo     time.Time
+	Estado      string // EXITO, ERROR
+	ClaveAcceso string
+	Secuencial  string
+	Total       float64
+	Mensaje     string
+	CreatedAt   time.Time
+}
+
+// StockMovement es una línea del kardex: todo cambio de stock queda registrado con su saldo resultante.
+type StockMovement struct {
+	ID            uint    `gorm:"primaryKey"`
+	ProductSKU    string  `gorm:"index"`
+	Tipo          string  `gorm:"index"` // VENTA, COMPRA, AJUSTE, DEVOLUCION, TRANSFERENCIA
+	Cantidad      float64 // Con signo: positivo entra, negativo sale
+	Saldo         float64 // Stock del producto después del movimiento
+	CostoUnitario float64 // Costo de la entrada, o costo promedio vigente en las salidas
+	Documento     string  `gorm:"index"` // Clave de acceso u otra referencia
+	Usuario       string
+	Nota          string
+	Bodega        string    `gorm:"index"`
+	SaldoBodega   float64   // Stock del producto en la bodega después del movimiento
+	CreatedAt     time.Time `gorm:"index"`
+}
+
+
+// Warehouse es una bodega o local con stock propio.
+type Warehouse struct {
+	Codigo    string `gorm:"primaryKey"`
+	Nombre    string
+	Direccion string
+	Estab     string // Establecimiento SRI cuyas ventas descuentan de esta bodega
+	PtoEmi    string // Opcional: punto de emisión específico dentro del establecimiento
+	Principal bool   // Bodega por defecto de compras, ajustes y ventas sin bodega asignada
+	Activa    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ProductStock es el stock de un producto en una bodega. Product.Stock guarda la suma de todas.
+type ProductStock struct {
+	ProductSKU string `gorm:"primaryKey"`
+	Bodega     string `gorm:"primaryKey"`
+	Stock      float64
+	Ubicacion  string // Percha, pasillo o casillero dentro de la bodega
+	UpdatedAt  time.Time
+}
+
+
+// Transfer es un traslado de mercadería entre bodegas.
+type Transfer struct {
+	ID           uint   `gorm:"primaryKey"`
+	Origen       string `gorm:"index"`
+	Destino      string `gorm:"index"`
+	GuiaRemision string // Número de la guía de remisión que respalda el traslado (opcional)
+	Estado       string // REALIZADA, ANULADA
+	Usuario      string
+	Nota         string
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+// TransferItem es una línea de un traslado.
+type TransferItem struct {
+	ID         uint   `gorm:"primaryKey"`
+	TransferID uint   `gorm:"index"`
+	ProductSKU string `gorm:"index"`
+	Cantidad   float64
+}
+
+
+// Lot es un lote de un producto en una bodega. Cantidad es el saldo disponible del lote; un
+// producto pasa a controlarse por lotes desde que se registra el primero.
+type Lot struct {
+	ID               uint       `gorm:"primaryKey"`
+	ProductSKU       string     `gorm:"uniqueIndex:idx_lote"`
+	Bodega           string     `gorm:"uniqueIndex:idx_lote"`
+	Numero           string     `gorm:"uniqueIndex:idx_lote"`
+	FechaVencimiento *time.Time `gorm:"index"`
+	Cantidad         float64
+	CantidadInicial  float64
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+
+// LotConsumption registra cuánto movió un documento de cada lote (positivo = salida,
+// negativo = entrada) para poder revertirlo en anulaciones.
+type LotConsumption struct {
+	ID        uint   `gorm:"primaryKey"`
+	LotID     uint   `gorm:"index"`
+	Documento string `gorm:"index"`
+	Cantidad  float64
+	CreatedAt time.Time
+}
+
+
+// CountSession es una toma física de inventario de una bodega, completa o de una sección
+// (prefijo de ubicación). Mientras está ABIERTA recibe lecturas de varios dispositivos.
+type CountSession struct {
+	ID        uint   `gorm:"primaryKey"`
+	Bodega    string `gorm:"index"`
+	Seccion   string // Prefijo de ubicación a contar; vacío = toda la bodega
+	Estado    string `gorm:"index"` // ABIERTA, CONTABILIZADA, CANCELADA
+	Nota      string
+	Usuario   string
+	CreatedAt time.Time
+	CerradaAt *time.Time
+}
+
+// CountEntry es una lectura de un dispositivo. El conteo de un producto es la suma de sus lecturas.
+type CountEntry struct {
+	ID          uint   `gorm:"primaryKey"`
+	SessionID   uint   `gorm:"index"`
+	ProductSKU  string `gorm:"index"`
+	Cantidad    float64
+	Dispositivo string
+	CreatedAt   time.Time
+}
+
+// CountSnapshot es el stock del sistema de un producto al registrar su primera lectura: las ventas y
+// compras posteriores ya están en el kardex y no forman parte de la diferencia del conteo.
+type CountSnapshot struct {
+	ID         uint   `gorm:"primaryKey"`
+	SessionID  uint   `gorm:"uniqueIndex:idx_conteo_producto"`
+	ProductSKU string `gorm:"uniqueIndex:idx_conteo_producto"`
+	Sistema    float64
+}
+
+// CountLine es el resultado contabilizado de un producto en una toma física.
+type CountLine struct {
+	ID            uint `gorm:"primaryKey"`
+	SessionID     uint `gorm:"index"`
+	ProductSKU    string
+	Sistema       float64
+	Contado       float64
+	Diferencia    float64
+	NoContado     bool
+	CostoUnitario float64
+}
+
+// CashSession es un turno de caja de un usuario en un punto de emisión. Al cierre guarda el arqueo:
+// efectivo esperado (fondo + ventas en efectivo + ingresos - egresos), lo contado y la diferencia,
+// junto con el PDF del cierre Z para auditoría.
+type CashSession struct {
+	ID               uint   `gorm:"primaryKey"`
+	Estab            string `gorm:"size:3;index:idx_caja_punto"`
+	PtoEmi           string `gorm:"size:3;index:idx_caja_punto"`
+	Usuario          string
+	Estado           string `gorm:"index"` // ABIERTA, CERRADA
+	FondoInicial     float64
+	EfectivoEsperado float64 // Se fija al cierre
+	EfectivoContado  float64
+	Diferencia       float64 // Contado - esperado: negativo = faltante
+	UsuarioCierre    string
+	Observacion      string
+	CierrePDF        []byte `gorm:"type:blob"` // Cierre Z
+	CreatedAt        time.Time // Apertura
+	CerradaAt        *time.Time
+}
+
+// CashMovement es una entrada o salida de efectivo del turno que no es una venta (cambio, retiro,
+// pago a un proveedor).
+type CashMovement struct {
+	ID        uint   `gorm:"primaryKey"`
+	SessionID uint   `gorm:"index"`
+	Tipo      string // INGRESO, EGRESO
+	Monto     float64
+	Motivo    string
+	Usuario   string
+	CreatedAt time.Time
+}
+
+// CashSessionPayment son las ventas del turno por forma de pago, congeladas al cierre.
+type CashSessionPayment struct {
+	ID        uint   `gorm:"primaryKey"`
+	SessionID uint   `gorm:"index"`
+	FormaPago string `gorm:"size:2"`
+	Facturas  int
+	Total     float64
+}
+
+// CashCount es el arqueo de una denominación (billete o moneda) al cierre.
+type CashCount struct {
+	ID           uint `gorm:"primaryKey"`
+	SessionID    uint `gorm:"index"`
+	Denominacion float64
+	Cantidad     int
+}
+
+
+// Notification es un aviso persistente para el usuario (stock bajo, vencimientos). Mientras no se
+// resuelva, no se vuelve a crear otra con la misma referencia.
+type Notification struct {
+	ID         uint   `gorm:"primaryKey"`
+	Tipo       string `gorm:"index"` // STOCK_BAJO, VENCIMIENTO
+	Referencia string `gorm:"index"` // SKU u otra clave del origen del aviso
+	Mensaje    string
+	Leida      bool      `gorm:"index"`
+	Resuelta   bool      `gorm:"index"`
+	CreatedAt  time.Time `gorm:"index"`
+	UpdatedAt  time.Time
+}
+
+// Supplier representa un proveedor.
+type Supplier struct {
+	RUC             string `gorm:"primaryKey"`
+	RazonSocial     string `gorm:"index"`
+	NombreComercial string
+	Direccion       string
+	Email           string
+	Telefono        string
+	Contacto        string
+	PlazoPago       int // Días de crédito
+	DiasEntrega     int // Tiempo de entrega en días, para sugerir reposiciones
+	Notas           string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Purchase es una factura de compra recibida de un proveedor.
+type Purchase struct {
+	ID            uint      `gorm:"primaryKey"`
+	SupplierRUC   string    `gorm:"uniqueIndex:idx_purchase_doc"`
+	NumeroFactura string    `gorm:"uniqueIndex:idx_purchase_doc"` // 001-001-000000123
+	Autorizacion  string    `gorm:"index"`                        // En comprobantes electrónicos, la clave de acceso
+	Bodega        string    // Bodega donde ingresó la mercadería
+	FechaEmision  time.Time `gorm:"index"`
+	Subtotal15    float64   // Base de la tarifa general (15%, o 12-14% en compras antiguas)
+	Subtotal5     float64   // Base de la tarifa reducida del 5%
+	Subtotal8     float64   // Base de la tarifa del 8% (turismo en feriados)
+	Subtotal0     float64
+	IVA           float64
+	Total         float64
+	Estado        string // REGISTRADA, ANULADA
+	Observacion   string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// PurchaseItem es una línea de una compra.
+type PurchaseItem struct {
+	ID            uint   `gorm:"primaryKey"`
+	PurchaseID    uint   `gorm:"index"`
+	ProductoSKU   string `gorm:"index"`
+	Nombre        string
+	Cantidad      float64
+	CostoUnitario float64
+	Subtotal      float64
+	PorcentajeIVA float64
+	Lote             string // Número de lote del proveedor (opcional)
+	FechaVencimiento *time.Time
+}
+
+// ComprobanteRecibido es un documento del reporte de recibidos del SRI que aún no consta
+// como compra. Funciona como cola de descarga: se consulta por clave de acceso y se registra.
+type ComprobanteRecibido struct {
+	ClaveAcceso          string    `gorm:"primaryKey"`
+	Tipo                 string
+	RucEmisor            string    `gorm:"index"`
+	RazonSocialEmisor    string
+	Serie                string    // 001-001-000000123
+	FechaEmision         time.Time `gorm:"index"`
+	ImporteTotal         float64
+	Estado               string    `gorm:"index"` // PENDIENTE, REGISTRADO, OMITIDO, ERROR
+	Intentos             int
+	Mensaje              string
+	PurchaseID           uint
+	ActualizarInventario bool
+	CrearProductos       bool
+	AceptarSinFirma      bool
+	Bodega               string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
 // --- DTOs ---
 
 type EmisorConfigDTO struct {
@@ -210,28 +669,47 @@ type EmisorConfigDTO struct {
 }
 
 type ClientDTO struct {
-	ID        string `json:"ID"`
-	TipoID    string `json:"TipoID"`
-	Nombre    string `json:"Nombre"`
-	Direccion string `json:"Direccion"`
-	Email     string `json:"Email"`
-	Telefono  string `json:"Telefono"`
+	ID            string `json:"ID"`
+	TipoID        string `json:"TipoID"`
+	Nombre        string `json:"Nombre"`
+	Direccion     string `json:"Direccion"`
+	Email         string `json:"Email"`
+	Telefono      string `json:"Telefono"`
+	ListaPrecioID uint   `json:"ListaPrecioID"`
+	ListaPrecio   string `json:"ListaPrecio"`
+	Segmento      string `json:"Segmento"`
 }
 
 type ProductDTO struct {
-	SKU           string  `json:"SKU"`
-	Name          string  `json:"Name"`
-	Price         float64 `json:"Price"`
-	Stock         int     `json:"Stock"`
-	TaxCode       string  `json:"TaxCode"`
-	TaxPercentage int     `json:"TaxPercentage"`
-	Barcode       string  `json:"Barcode"`
-	AuxiliaryCode string  `json:"AuxiliaryCode"`
-	MinStock      int     `json:"MinStock"`
-	ExpiryDate    string  `json:"ExpiryDate"` // Format: 2006-01-02
-	Location      string  `json:"Location"`
+	SKU           string            `json:"SKU"`
+	Name          string            `json:"Name"`
+	Price         float64           `json:"Price"`
+	Stock         float64           `json:"Stock"`
+	StockOriginal *float64          `json:"StockOriginal,omitempty"` // Stock con el que se abrió el formulario; nil = el stock no se edita
+	TaxCode       string            `json:"TaxCode"`
+	TaxPercentage int               `json:"TaxPercentage"`
+	Barcode       string            `json:"Barcode"`
+	AuxiliaryCode string            `json:"AuxiliaryCode"`
+	MinStock      float64           `json:"MinStock"`
+	ExpiryDate    string            `json:"ExpiryDate"` // Format: 2006-01-02
+	Location      string            `json:"Location"`
+	Cost          float64           `json:"Cost"`
+	LastCost      float64           `json:"LastCost"`
+	UnidadMedida  string            `json:"UnidadMedida"`
+	Unidades      []ProductUnitDTO  `json:"Unidades"`
+	CategoryID    uint              `json:"CategoryID"`
+	Categoria     string            `json:"Categoria"` // Ruta completa de la categoría
+	BrandID       uint              `json:"BrandID"`
+	Marca         string            `json:"Marca"`
+	Tags          []string          `json:"Tags"`
+	EsKit         bool              `json:"EsKit"` // En un kit, Stock es lo que se puede armar con los componentes
+	DetallarKit   bool              `json:"DetallarKit"`
+	Componentes   []KitComponentDTO `json:"Componentes"` // nil deja los componentes actuales al guardar
+	EsServicio    bool              `json:"EsServicio"`
+	TieneImagen   bool              `json:"TieneImagen"` // La foto se obtiene con GetProductImage o /api/product/image/:sku
 }
 
+
 type FacturaDTO struct {
 	Secuencial       string        `json:"secuencial"`
 	ClienteID        string        `json:"clienteID"`
@@ -247,6 +725,15 @@ type FacturaDTO struct {
 	ClaveAcceso      string
 }
 
+type FacturaHistorialDTO struct {
+	ClaveAcceso  string  `json:"claveAcceso"`
+	Intento      int     `json:"intento"`
+	Fecha        string  `json:"fecha"`
+	Total        float64 `json:"total"`
+	Estado       string  `json:"estado"`
+	MensajeError string  `json:"mensajeError"`
+}
+
 type FacturaResumenDTO struct {
 	ClaveAcceso   string  `json:"claveAcceso"`
 	Secuencial    string  `json:"secuencial"`
@@ -261,11 +748,19 @@ type InvoiceItem struct {
 	Codigo        string  `json:"codigo"`
 	Nombre        string  `json:"nombre"`
 	Cantidad      float64 `json:"cantidad"`
+	Unidad        string  `json:"unidad"` // Presentación de venta; vacío = unidad base del producto
+	Factor        float64 `json:"factor"` // Lo completa el servicio a partir de la unidad
 	Precio        float64 `json:"precio"`
+	PrecioManual  bool    `json:"precioManual"` // Precio fijado a mano: no se aplica la lista del cliente
+	Descuento     float64 `json:"descuento"`    // Descuento de la línea; lo calcula el motor de promociones
+	PromocionID   uint    `json:"promocionId"`
+	Promocion     string  `json:"promocion"`
+	Detalle       string  `json:"detalle"` // Texto libre de la línea (habitual en servicios), va como detalle adicional
 	CodigoIVA     string  `json:"codigoIVA"`
 	PorcentajeIVA float64 `json:"porcentajeIVA"`
 }
 
+
 type QuotationDTO struct {
 	ID              uint           `json:"id"`
 	Secuencial      string         `json:"secuencial"`
@@ -279,6 +774,7 @@ type QuotationDTO struct {
 	Total           float64        `json:"total"`
 	Items           []QuotationItemDTO `json:"items"`
 	Estado          string         `json:"estado"`
+	MostrarImagenes bool           `json:"mostrarImagenes"` // Incluye la foto de cada producto en el PDF
 }
 
 type QuotationItemDTO struct {
@@ -286,6 +782,452 @@ type QuotationItemDTO struct {
 	Nombre        string  `json:"nombre"`
 	Cantidad      float64 `json:"cantidad"`
 	Precio        float64 `json:"precio"`
+	PrecioManual  bool    `json:"precioManual"`
+	Descuento     float64 `json:"descuento"`
+	PromocionID   uint    `json:"promocionId"`
+	Promocion     string  `json:"promocion"`
 	CodigoIVA     string  `json:"codigoIVA"`
 	PorcentajeIVA float64 `json:"porcentajeIVA"`
 }
+
+type InvoiceDraftDTO struct {
+	ID            uint       `json:"id"`
+	Usuario       string     `json:"usuario"`
+	Terminal      string     `json:"terminal"`
+	PtoEmi        string     `json:"ptoEmi"`
+	Factura       FacturaDTO `json:"factura"`
+	ClienteNombre string     `json:"clienteNombre"`
+	Total         float64    `json:"total"`
+	NumItems      int        `json:"numItems"`
+	Actualizado   string     `json:"actualizado"`
+}
+
+type RecurringInvoiceDTO struct {
+	ID             uint       `json:"id"`
+	Nombre         string     `json:"nombre"`
+	Factura        FacturaDTO `json:"factura"`
+	Periodicidad   string     `json:"periodicidad"`
+	DiaMes         int        `json:"diaMes"`
+	FechaInicio    string     `json:"fechaInicio"` // Format: 2006-01-02
+	FechaFin       string     `json:"fechaFin"`    // Vacío = sin fecha de fin
+	EnviarCorreo   bool       `json:"enviarCorreo"`
+	Activa         bool       `json:"activa"`
+	ProximaEmision string     `json:"proximaEmision"`
+	UltimaEmision  string     `json:"ultimaEmision"`
+}
+
+type RecurringRunDTO struct {
+	ID          uint    `json:"id"`
+	RecurringID uint    `json:"recurringId"`
+	Plantilla   string  `json:"plantilla"`
+	Periodo     string  `json:"periodo"`
+	Fecha       string  `json:"fecha"`
+	Estado      string  `json:"estado"`
+	ClaveAcceso string  `json:"claveAcceso"`
+	Secuencial  string  `json:"secuencial"`
+	Total       float64 `json:"total"`
+	Mensaje     string  `json:"mensaje"`
+}
+
+type StockMovementDTO struct {
+	ID        uint    `json:"id"`
+	Fecha     string  `json:"fecha"`
+	Tipo      string  `json:"tipo"`
+	Entrada   float64 `json:"entrada"`
+	Salida    float64 `json:"salida"`
+	Saldo     float64 `json:"saldo"`
+	Costo     float64 `json:"costo"`
+	Documento string  `json:"documento"`
+	Usuario   string  `json:"usuario"`
+	Nota      string  `json:"nota"`
+	Bodega    string  `json:"bodega"`
+}
+
+
+type KardexDTO struct {
+	SKU          string             `json:"sku"`
+	Nombre       string             `json:"nombre"`
+	Bodega       string             `json:"bodega"` // Vacío = todas las bodegas
+	Desde        string             `json:"desde"`
+	Hasta        string             `json:"hasta"`
+	SaldoInicial float64            `json:"saldoInicial"`
+	Entradas     float64            `json:"entradas"`
+	Salidas      float64            `json:"salidas"`
+	SaldoFinal   float64            `json:"saldoFinal"`
+	Movimientos  []StockMovementDTO `json:"movimientos"`
+}
+
+
+type SupplierDTO struct {
+	RUC             string `json:"ruc"`
+	RazonSocial     string `json:"razonSocial"`
+	NombreComercial string `json:"nombreComercial"`
+	Direccion       string `json:"direccion"`
+	Email           string `json:"email"`
+	Telefono        string `json:"telefono"`
+	Contacto        string `json:"contacto"`
+	PlazoPago       int    `json:"plazoPago"`
+	DiasEntrega     int    `json:"diasEntrega"`
+	Notas           string `json:"notas"`
+}
+
+type PurchaseItemDTO struct {
+	ProductoSKU   string  `json:"productoSku"`
+	Nombre        string  `json:"nombre"`
+	Cantidad      float64 `json:"cantidad"`
+	CostoUnitario float64 `json:"costoUnitario"`
+	PorcentajeIVA float64 `json:"porcentajeIva"`
+	Subtotal      float64 `json:"subtotal"`
+	Lote             string `json:"lote"`
+	FechaVencimiento string `json:"fechaVencimiento"` // YYYY-MM-DD
+}
+
+type PurchaseDTO struct {
+	ID             uint              `json:"id"`
+	SupplierRUC    string            `json:"supplierRuc"`
+	SupplierNombre string            `json:"supplierNombre"`
+	NumeroFactura  string            `json:"numeroFactura"`
+	Autorizacion   string            `json:"autorizacion"`
+	FechaEmision   string            `json:"fechaEmision"` // Format: 2006-01-02
+	Bodega         string            `json:"bodega"`       // Vacío = bodega principal
+	Items          []PurchaseItemDTO `json:"items"`
+	Subtotal15     float64           `json:"subtotal15"`
+	Subtotal5      float64           `json:"subtotal5"`
+	Subtotal8      float64           `json:"subtotal8"`
+	Subtotal0      float64           `json:"subtotal0"`
+	IVA            float64           `json:"iva"`
+	Total          float64           `json:"total"`
+	Estado         string            `json:"estado"`
+	Observacion    string            `json:"observacion"`
+}
+
+type ComprobanteRecibidoDTO struct {
+	ClaveAcceso       string  `json:"claveAcceso"`
+	Tipo              string  `json:"tipo"`
+	RucEmisor         string  `json:"rucEmisor"`
+	RazonSocialEmisor string  `json:"razonSocialEmisor"`
+	Serie             string  `json:"serie"`
+	FechaEmision      string  `json:"fechaEmision"` // Format: 2006-01-02
+	ImporteTotal      float64 `json:"importeTotal"`
+	Estado            string  `json:"estado"`
+	Intentos          int     `json:"intentos"`
+	Mensaje           string  `json:"mensaje"`
+	PurchaseID        uint    `json:"purchaseId"`
+}
+
+type WarehouseDTO struct {
+	Codigo    string `json:"codigo"`
+	Nombre    string `json:"nombre"`
+	Direccion string `json:"direccion"`
+	Estab     string `json:"estab"`
+	PtoEmi    string `json:"ptoEmi"`
+	Principal bool   `json:"principal"`
+	Activa    bool   `json:"activa"`
+}
+
+type ProductStockDTO struct {
+	SKU          string  `json:"sku"`
+	Nombre       string  `json:"nombre"`
+	Bodega       string  `json:"bodega"`
+	BodegaNombre string  `json:"bodegaNombre"`
+	Stock        float64 `json:"stock"`
+	Ubicacion    string  `json:"ubicacion"`
+}
+
+
+type TransferItemDTO struct {
+	SKU      string  `json:"sku"`
+	Nombre   string  `json:"nombre"`
+	Cantidad float64 `json:"cantidad"`
+}
+
+
+type TransferDTO struct {
+	ID           uint              `json:"id"`
+	Origen       string            `json:"origen"`
+	Destino      string            `json:"destino"`
+	GuiaRemision string            `json:"guiaRemision"`
+	Estado       string            `json:"estado"`
+	Usuario      string            `json:"usuario"`
+	Nota         string            `json:"nota"`
+	Fecha        string            `json:"fecha"`
+	Items        []TransferItemDTO `json:"items"`
+}
+
+type LotDTO struct {
+	ID               uint    `json:"id"`
+	SKU              string  `json:"sku"`
+	Nombre           string  `json:"nombre"`
+	Bodega           string  `json:"bodega"`
+	Numero           string  `json:"numero"`
+	FechaVencimiento string  `json:"fechaVencimiento"` // YYYY-MM-DD, vacío = sin vencimiento
+	DiasParaVencer   int     `json:"diasParaVencer"`
+	Estado           string  `json:"estado"` // VIGENTE, POR_VENCER, VENCIDO, AGOTADO
+	Cantidad         float64 `json:"cantidad"`
+	CantidadInicial  float64 `json:"cantidadInicial"`
+	ValorCosto       float64 `json:"valorCosto"` // Cantidad al costo promedio vigente
+}
+
+
+type NotificationDTO struct {
+	ID         uint   `json:"id"`
+	Tipo       string `json:"tipo"`
+	Referencia string `json:"referencia"`
+	Mensaje    string `json:"mensaje"`
+	Leida      bool   `json:"leida"`
+	Resuelta   bool   `json:"resuelta"`
+	Fecha      string `json:"fecha"`
+}
+
+type ReorderParamsDTO struct {
+	DiasHistorial      int    `json:"diasHistorial"`      // Ventana para el promedio de ventas (por defecto 30)
+	DiasCobertura      int    `json:"diasCobertura"`      // Días de venta que debe cubrir el pedido (por defecto 15)
+	DiasEntregaDefecto int    `json:"diasEntregaDefecto"` // Para proveedores sin tiempo de entrega (por defecto 7)
+	SupplierRUC        string `json:"supplierRuc"`        // Filtrar por proveedor
+	SoloBajoMinimo     bool   `json:"soloBajoMinimo"`
+}
+
+type ReorderSuggestionDTO struct {
+	SKU            string  `json:"sku"`
+	Nombre         string  `json:"nombre"`
+	Stock          float64 `json:"stock"`
+	MinStock       float64 `json:"minStock"`
+	VentaDiaria    float64 `json:"ventaDiaria"`
+	DiasRestantes  float64 `json:"diasRestantes"` // Días que alcanza el stock actual (-1 = sin ventas)
+	DiasEntrega    int     `json:"diasEntrega"`
+	Sugerido       float64 `json:"sugerido"`
+	SupplierRUC    string  `json:"supplierRuc"`
+	SupplierNombre string  `json:"supplierNombre"`
+	UltimoCosto    float64 `json:"ultimoCosto"`
+	CostoEstimado  float64 `json:"costoEstimado"`
+}
+
+
+type CountLineDTO struct {
+	SKU             string  `json:"sku"`
+	Nombre          string  `json:"nombre"`
+	Ubicacion       string  `json:"ubicacion"`
+	Sistema         float64 `json:"sistema"`
+	Contado         float64 `json:"contado"`
+	Diferencia      float64 `json:"diferencia"`
+	NoContado       bool    `json:"noContado"` // En el alcance de la sesión pero sin lecturas
+	Lecturas        int     `json:"lecturas"`
+	CostoUnitario   float64 `json:"costoUnitario"`
+	ValorDiferencia float64 `json:"valorDiferencia"`
+}
+
+
+type CountSessionDTO struct {
+	ID            uint           `json:"id"`
+	Bodega        string         `json:"bodega"`
+	BodegaNombre  string         `json:"bodegaNombre"`
+	Seccion       string         `json:"seccion"`
+	Estado        string         `json:"estado"`
+	Nota          string         `json:"nota"`
+	Usuario       string         `json:"usuario"`
+	Fecha         string         `json:"fecha"`
+	FechaCierre   string         `json:"fechaCierre"`
+	Contados      int            `json:"contados"` // Productos con al menos una lectura
+	Lineas        []CountLineDTO `json:"lineas"`
+	Sobrantes     int            `json:"sobrantes"`
+	Faltantes     int            `json:"faltantes"`
+	ValorSobrante float64        `json:"valorSobrante"`
+	ValorFaltante float64        `json:"valorFaltante"`
+}
+
+type CashSessionDTO struct {
+	ID               uint              `json:"id"`
+	Estab            string            `json:"estab"`
+	PtoEmi           string            `json:"ptoEmi"`
+	Usuario          string            `json:"usuario"`
+	Estado           string            `json:"estado"`
+	Apertura         string            `json:"apertura"`
+	Cierre           string            `json:"cierre"`
+	UsuarioCierre    string            `json:"usuarioCierre"`
+	FondoInicial     float64           `json:"fondoInicial"`
+	Ventas           []CashPaymentDTO  `json:"ventas"` // Por forma de pago
+	Facturas         int               `json:"facturas"`
+	TotalVentas      float64           `json:"totalVentas"`
+	VentasEfectivo   float64           `json:"ventasEfectivo"`
+	Ingresos         float64           `json:"ingresos"`
+	Egresos          float64           `json:"egresos"`
+	EfectivoEsperado float64           `json:"efectivoEsperado"`
+	EfectivoContado  float64           `json:"efectivoContado"`
+	Diferencia       float64           `json:"diferencia"`
+	Observacion      string            `json:"observacion"`
+	Movimientos      []CashMovementDTO `json:"movimientos"`
+	Conteo           []CashCountDTO    `json:"conteo"`
+}
+
+type CashPaymentDTO struct {
+	FormaPago   string  `json:"formaPago"`
+	Descripcion string  `json:"descripcion"`
+	Facturas    int     `json:"facturas"`
+	Total       float64 `json:"total"`
+}
+
+type CashMovementDTO struct {
+	ID      uint    `json:"id"`
+	Tipo    string  `json:"tipo"`
+	Monto   float64 `json:"monto"`
+	Motivo  string  `json:"motivo"`
+	Usuario string  `json:"usuario"`
+	Fecha   string  `json:"fecha"`
+}
+
+type CashCountDTO struct {
+	Denominacion float64 `json:"denominacion"`
+	Cantidad     int     `json:"cantidad"`
+	Subtotal     float64 `json:"subtotal"`
+}
+
+type ProductUnitDTO struct {
+	ID      uint    `json:"id"`
+	Nombre  string  `json:"nombre"`
+	Factor  float64 `json:"factor"`
+	Barcode string  `json:"barcode"`
+	Price   float64 `json:"price"`
+}
+
+// ScanResultDTO es el producto encontrado por un código; Unidad es la presentación cuando el
+// código corresponde a una unidad alternativa (Factor unidades base, a su propio precio).
+type ScanResultDTO struct {
+	SKU     string  `json:"sku"`
+	Nombre  string  `json:"nombre"`
+	Unidad  string  `json:"unidad"`
+	Factor  float64 `json:"factor"`
+	Precio  float64 `json:"precio"`
+	Stock   float64 `json:"stock"`
+	Product Product `json:"product"`
+}
+
+type PriceListItemDTO struct {
+	ID             uint    `json:"id"`
+	SKU            string  `json:"sku"`
+	Nombre         string  `json:"nombre"`
+	Unidad         string  `json:"unidad"`
+	CantidadMinima float64 `json:"cantidadMinima"`
+	Precio         float64 `json:"precio"`
+	Porcentaje     float64 `json:"porcentaje"`
+	VigenteDesde   string  `json:"vigenteDesde"` // YYYY-MM-DD, vacío = sin límite
+	VigenteHasta   string  `json:"vigenteHasta"`
+}
+
+type PriceListDTO struct {
+	ID           uint               `json:"id"`
+	Nombre       string             `json:"nombre"`
+	Descripcion  string             `json:"descripcion"`
+	Porcentaje   float64            `json:"porcentaje"`
+	VigenteDesde string             `json:"vigenteDesde"`
+	VigenteHasta string             `json:"vigenteHasta"`
+	Activa       bool               `json:"activa"`
+	Clientes     int64              `json:"clientes"`
+	Items        []PriceListItemDTO `json:"items"`
+}
+
+// PriceQuoteDTO es el precio resuelto para un cliente; Regla explica de dónde sale
+// (BASE, PRECIO, PORCENTAJE_ITEM o PORCENTAJE_LISTA).
+type PriceQuoteDTO struct {
+	SKU        string  `json:"sku"`
+	Unidad     string  `json:"unidad"`
+	Cantidad   float64 `json:"cantidad"`
+	PrecioBase float64 `json:"precioBase"`
+	Precio     float64 `json:"precio"`
+	ListaID    uint    `json:"listaId"`
+	Lista      string  `json:"lista"`
+	Regla      string  `json:"regla"`
+}
+
+type PromotionDTO struct {
+	ID             uint     `json:"id"`
+	Nombre         string   `json:"nombre"`
+	Tipo           string   `json:"tipo"`
+	Porcentaje     float64  `json:"porcentaje"`
+	LlevaN         int      `json:"llevaN"`
+	PagaM          int      `json:"pagaM"`
+	CantidadMinima float64  `json:"cantidadMinima"`
+	SKUs           []string `json:"skus"`
+	Categorias     []uint   `json:"categorias"`
+	Marcas         []uint   `json:"marcas"`
+	Segmentos      []string `json:"segmentos"`
+	VigenteDesde   string   `json:"vigenteDesde"` // YYYY-MM-DD
+	VigenteHasta   string   `json:"vigenteHasta"`
+	DiasSemana     []int    `json:"diasSemana"` // 1 = lunes ... 7 = domingo
+	HoraDesde      string   `json:"horaDesde"`  // HH:MM
+	HoraHasta      string   `json:"horaHasta"`
+	Activa         bool     `json:"activa"`
+}
+
+// PromotionImpactDTO resume lo que una promoción movió en el periodo: Venta es el neto de las
+// líneas con la promoción, ya descontado.
+type PromotionImpactDTO struct {
+	PromocionID uint    `json:"promocionId"`
+	Nombre      string  `json:"nombre"`
+	Facturas    int64   `json:"facturas"`
+	Lineas      int64   `json:"lineas"`
+	Unidades    float64 `json:"unidades"`
+	Venta       float64 `json:"venta"`
+	Descuento   float64 `json:"descuento"`
+}
+
+type CategoryDTO struct {
+	ID        uint   `json:"id"`
+	Nombre    string `json:"nombre"`
+	ParentID  uint   `json:"parentId"` // 0 = raíz
+	Ruta      string `json:"ruta"`
+	Productos int64  `json:"productos"`
+}
+
+type BrandDTO struct {
+	ID        uint   `json:"id"`
+	Nombre    string `json:"nombre"`
+	Productos int64  `json:"productos"`
+}
+
+// ProductFilterDTO restringe búsquedas de productos; los campos vacíos no filtran. La categoría
+// incluye sus subcategorías y todas las etiquetas deben estar presentes.
+type ProductFilterDTO struct {
+	CategoryID uint     `json:"categoryId"`
+	BrandID    uint     `json:"brandId"`
+	Tags       []string `json:"tags"`
+}
+
+// KitComponentDTO es un componente de un kit con su stock; Alcanza es cuántos kits cubre.
+type KitComponentDTO struct {
+	SKU      string  `json:"sku"`
+	Nombre   string  `json:"nombre"`
+	Cantidad float64 `json:"cantidad"`
+	Stock    float64 `json:"stock"`
+	Alcanza  float64 `json:"alcanza"`
+}
+
+// KitAvailabilityDTO es cuántos kits se pueden armar en una bodega y qué componente lo limita.
+type KitAvailabilityDTO struct {
+	SKU         string            `json:"sku"`
+	Bodega      string            `json:"bodega"`
+	Disponible  float64           `json:"disponible"`
+	Limitante   string            `json:"limitante"`
+	Componentes []KitComponentDTO `json:"componentes"`
+}
+
+// LabelDTO es una etiqueta de producto; Codigo es lo que se codifica con la Simbologia indicada.
+// Precio es el PVP con IVA.
+type LabelDTO struct {
+	SKU        string  `json:"sku"`
+	Nombre     string  `json:"nombre"`
+	Precio     float64 `json:"precio"`
+	Ubicacion  string  `json:"ubicacion"`
+	Codigo     string  `json:"codigo"`
+	Simbologia string  `json:"simbologia"` // EAN13, CODE128, QR
+	Copias     int     `json:"copias"`
+}
+
+// LabelOptionsDTO configura la impresión de etiquetas. Copias aplica a cada producto de una
+// selección; en una compra se imprime una etiqueta por unidad recibida.
+type LabelOptionsDTO struct {
+	Formato          string `json:"formato"`    // A4 (hoja de 3x8) o TERMICA (rollo 50x25 mm)
+	Simbologia       string `json:"simbologia"` // EAN13, CODE128, QR
+	MostrarPrecio    bool   `json:"mostrarPrecio"`
+	MostrarUbicacion bool   `json:"mostrarUbicacion"`
+	Copias           int    `json:"copias"`
+}
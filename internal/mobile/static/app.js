@@ -5,6 +5,7 @@ const state = {
     editingProduct: null,
     tempStock: 0,
     isPosMode: false,
+    isCountMode: false,
     scanner: null
 };
 
@@ -20,6 +21,7 @@ const dom = {
     pinInput: document.getElementById('pin-input'),
     btnLogin: document.getElementById('btn-login'),
     searchInput: document.getElementById('search-input'),
+    warehouseSelect: document.getElementById('warehouse-select'),
     list: document.getElementById('product-list'),
     
     // Header
@@ -29,6 +31,9 @@ const dom = {
     btnScan: document.getElementById('btn-scan'),
     btnTogglePos: document.getElementById('btn-toggle-pos'),
     posLabel: document.getElementById('pos-label'),
+    btnToggleCount: document.getElementById('btn-toggle-count'),
+    countLabel: document.getElementById('count-label'),
+    countSessionSelect: document.getElementById('count-session-select'),
     
     // Modal
     modal: document.getElementById('edit-modal'),
@@ -40,10 +45,13 @@ const dom = {
     posControls: document.getElementById('pos-controls'),
     invControls: document.getElementById('inventory-controls'),
     posQtyDisplay: document.getElementById('pos-qty-display'), // New element
+    countControls: document.getElementById('count-controls'),
+    countQtyDisplay: document.getElementById('count-qty-display'),
     
     btnCloseModal: document.getElementById('btn-close-modal'),
     btnSaveStock: document.getElementById('btn-save-stock'),
     btnSendPos: document.getElementById('btn-send-pos'),
+    btnSendCount: document.getElementById('btn-send-count'),
     
     // Scanner
     btnStopScan: document.getElementById('btn-stop-scan'),
@@ -59,12 +67,16 @@ const dom = {
     createPrice: document.getElementById('create-price'),
     createStock: document.getElementById('create-stock'),
     createLocation: document.getElementById('create-location'),
+    createPhoto: document.getElementById('create-photo'),
+    createPhotoPreview: document.getElementById('create-photo-preview'),
     
     toast: document.getElementById('toast')
 };
 
 // State extensions
 state.isCreating = false;
+state.createPhoto = null; // data URL de la foto tomada al crear
+state.thumbs = {};        // SKU -> object URL de la miniatura ya descargada
 
 // Init
 window.addEventListener('DOMContentLoaded', () => {
@@ -89,14 +101,55 @@ dom.btnLogin.addEventListener('click', () => {
 // Search
 dom.searchInput.addEventListener('input', (e) => filterProducts(e.target.value));
 
+// Bodega: el conteo y la ubicación se registran en la bodega elegida
+dom.warehouseSelect.addEventListener('change', async () => {
+    localStorage.setItem('kushki_warehouse', dom.warehouseSelect.value);
+    try {
+        await loadInventory();
+        filterProducts(dom.searchInput.value);
+    } catch (e) {
+        showToast('Error de conexión');
+    }
+});
+
 // Toggle POS Mode
 dom.btnTogglePos.addEventListener('click', () => {
     state.isPosMode = !state.isPosMode;
+    if (state.isPosMode) state.isCountMode = false;
+    updateModeUI();
+});
+
+// Toggle Count Mode (toma física abierta desde el escritorio)
+dom.btnToggleCount.addEventListener('click', async () => {
+    if (state.isCountMode) {
+        state.isCountMode = false;
+        updateModeUI();
+        return;
+    }
+    try {
+        const sessions = await loadCountSessions();
+        if (sessions.length === 0) {
+            return showToast("No hay tomas físicas abiertas");
+        }
+    } catch (e) {
+        return showToast('Error de conexión');
+    }
+    state.isCountMode = true;
+    state.isPosMode = false;
     updateModeUI();
 });
 
 function updateModeUI() {
-    if (state.isPosMode) {
+    dom.countSessionSelect.classList.toggle('hidden', !state.isCountMode);
+    dom.btnToggleCount.classList.toggle('active', state.isCountMode);
+    dom.countLabel.innerText = state.isCountMode ? "Salir Conteo" : "Conteo";
+    if (state.isCountMode) {
+        dom.modeBadge.innerText = "TOMA FÍSICA";
+        dom.modeBadge.style.color = "#FBBF24"; // Amber
+        dom.posLabel.innerText = "Modo POS";
+        dom.btnTogglePos.classList.remove('active');
+        showToast("Modo Conteo Activo: Escanee para contar");
+    } else if (state.isPosMode) {
         dom.modeBadge.innerText = "MODO CAJA";
         dom.modeBadge.style.color = "#34D399"; // Mint
         dom.posLabel.innerText = "Salir POS";
@@ -124,9 +177,32 @@ dom.btnOpenCreate.addEventListener('click', () => {
     dom.createPrice.value = "";
     dom.createStock.value = "";
     dom.createLocation.value = "";
+    dom.createPhoto.value = "";
+    state.createPhoto = null;
+    dom.createPhotoPreview.classList.add('hidden');
     dom.modalCreate.classList.remove('hidden');
 });
 
+// La foto se reduce en el teléfono antes de enviarla; el servidor la normaliza al guardarla
+dom.createPhoto.addEventListener('change', () => {
+    const file = dom.createPhoto.files[0];
+    if (!file) return;
+    const img = new Image();
+    img.onload = () => {
+        const scale = Math.min(1, 1600 / Math.max(img.width, img.height));
+        const canvas = document.createElement('canvas');
+        canvas.width = Math.round(img.width * scale);
+        canvas.height = Math.round(img.height * scale);
+        canvas.getContext('2d').drawImage(img, 0, 0, canvas.width, canvas.height);
+        state.createPhoto = canvas.toDataURL('image/jpeg', 0.85);
+        dom.createPhotoPreview.src = state.createPhoto;
+        dom.createPhotoPreview.classList.remove('hidden');
+        URL.revokeObjectURL(img.src);
+    };
+    img.onerror = () => showToast("No se pudo leer la foto");
+    img.src = URL.createObjectURL(file);
+});
+
 dom.btnCloseCreate.addEventListener('click', () => dom.modalCreate.classList.add('hidden'));
 
 dom.btnScanCreate.addEventListener('click', () => {
@@ -140,10 +216,11 @@ dom.btnSaveCreate.addEventListener('click', async () => {
         Barcode: dom.createSku.value.trim(),
         Name: dom.createName.value.trim(),
         Price: parseFloat(dom.createPrice.value) || 0,
-        Stock: parseInt(dom.createStock.value) || 0,
+        Stock: parseFloat(dom.createStock.value) || 0,
         Location: dom.createLocation.value.trim(),
         TaxCode: "2",
-        TaxPercentage: 15
+        TaxPercentage: 15,
+        Foto: state.createPhoto || ""
     };
 
     if (!data.SKU || !data.Name) {
@@ -161,7 +238,8 @@ dom.btnSaveCreate.addEventListener('click', async () => {
         });
 
         if (res.status === 201) {
-            showToast("Producto creado exitosamente");
+            const created = await res.json();
+            showToast(created.imageError ? "Producto creado, pero la foto no se guardó" : "Producto creado exitosamente");
             dom.modalCreate.classList.add('hidden');
             await loadInventory(); // Refresh list
         } else {
@@ -214,6 +292,14 @@ function onScanSuccess(decodedText, decodedResult) {
         return;
     }
 
+    // En toma física cada lectura suma 1 al conteo
+    if (state.isCountMode) {
+        sendToCount(decodedText);
+        state.scanner.pause();
+        setTimeout(() => state.scanner.resume(), 1500);
+        return;
+    }
+
     // Si estamos en modo POS, enviar directo
     if (state.isPosMode) {
         sendToPos(decodedText);
@@ -224,7 +310,7 @@ function onScanSuccess(decodedText, decodedResult) {
     } else {
         // Modo Inventario: Buscar y abrir modal
         stopScanner();
-        const product = state.products.find(p => p.Barcode === decodedText || p.SKU === decodedText);
+        const product = findByCode(decodedText);
         if (product) {
             openModal(product.SKU);
         } else {
@@ -249,20 +335,18 @@ function openModal(sku) {
     state.editingProduct = p;
     state.tempStock = p.Stock;
     state.posQty = 1; // Reset POS Qty
+    state.countQty = 1;
 
     dom.modalTitle.innerText = p.Name;
     dom.modalSku.innerText = p.SKU;
     dom.modalStock.innerText = state.tempStock;
     dom.modalLocation.value = p.Location || "";
     if (dom.posQtyDisplay) dom.posQtyDisplay.innerText = "1";
+    dom.countQtyDisplay.innerText = "1";
     
-    if (state.isPosMode) {
-        dom.posControls.classList.remove('hidden');
-        dom.invControls.classList.add('hidden');
-    } else {
-        dom.posControls.classList.add('hidden');
-        dom.invControls.classList.remove('hidden');
-    }
+    dom.posControls.classList.toggle('hidden', !state.isPosMode);
+    dom.countControls.classList.toggle('hidden', !state.isCountMode);
+    dom.invControls.classList.toggle('hidden', state.isPosMode || state.isCountMode);
 
     dom.modal.classList.remove('hidden');
 }
@@ -289,6 +373,15 @@ document.querySelectorAll('.btn-pos-qty').forEach(btn => {
     });
 });
 
+// Count Qty Logic (permite negativos para corregir)
+document.querySelectorAll('.btn-count-qty').forEach(btn => {
+    btn.addEventListener('click', () => {
+        state.countQty = (state.countQty || 1) + parseInt(btn.dataset.delta);
+        if (state.countQty === 0) state.countQty = parseInt(btn.dataset.delta) > 0 ? 1 : -1;
+        dom.countQtyDisplay.innerText = state.countQty;
+    });
+});
+
 dom.btnSaveStock.addEventListener('click', async () => {
     if (!state.editingProduct) return;
     
@@ -303,7 +396,8 @@ dom.btnSaveStock.addEventListener('click', async () => {
                 sku: state.editingProduct.SKU,
                 quantity: state.tempStock,
                 location: dom.modalLocation.value.trim(),
-                type: 'set'
+                type: 'set',
+                warehouse: dom.warehouseSelect.value
             })
         });
         
@@ -335,6 +429,70 @@ dom.btnSendPos.addEventListener('click', () => {
     }
 });
 
+// Count Logic
+dom.btnSendCount.addEventListener('click', async () => {
+    if (!state.editingProduct) return;
+    if (await sendToCount(state.editingProduct.SKU, state.countQty || 1)) {
+        dom.modal.classList.add('hidden');
+    }
+});
+
+async function sendToCount(code, qty = 1) {
+    try {
+        const res = await fetch('/api/count', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'X-Kushki-Token': state.token
+            },
+            body: JSON.stringify({
+                sessionId: parseInt(dom.countSessionSelect.value),
+                code: code,
+                quantity: qty,
+                device: deviceId()
+            })
+        });
+        const data = await res.json();
+        if (!res.ok) {
+            showToast("Error: " + (data.error || "No se registró"));
+            return false;
+        }
+        showToast(`${data.product}: ${data.total} contados`);
+        return true;
+    } catch (e) {
+        showToast("Error enviando conteo");
+        return false;
+    }
+}
+
+async function loadCountSessions() {
+    const res = await fetch('/api/count-sessions', {
+        headers: { 'X-Kushki-Token': state.token }
+    });
+    if (!res.ok) throw new Error();
+    const sessions = await res.json();
+    const current = dom.countSessionSelect.value;
+    dom.countSessionSelect.innerHTML = '';
+    sessions.forEach(s => {
+        const opt = document.createElement('option');
+        opt.value = s.id;
+        opt.textContent = `Conteo #${s.id} - ${s.bodega}${s.seccion ? ' / ' + s.seccion : ''}`;
+        if (String(s.id) === current) opt.selected = true;
+        dom.countSessionSelect.appendChild(opt);
+    });
+    return sessions;
+}
+
+// Identificador del dispositivo para distinguir las lecturas de cada contador
+function deviceId() {
+    let id = localStorage.getItem('kushki_device');
+    if (!id) {
+        id = 'Móvil-' + Math.random().toString(36).slice(2, 6).toUpperCase();
+        localStorage.setItem('kushki_device', id);
+    }
+    return id;
+}
+
 async function sendToPos(sku, qty = 1) {
     try {
         await fetch('/api/pos/scan', {
@@ -355,6 +513,7 @@ async function login(token) {
     state.token = token;
     localStorage.setItem('kushki_token', token);
     try {
+        await loadWarehouses();
         await loadInventory();
         views.login.classList.add('hidden');
         views.app.classList.remove('hidden');
@@ -365,8 +524,29 @@ async function login(token) {
     }
 }
 
+async function loadWarehouses() {
+    const res = await fetch('/api/warehouses', {
+        headers: { 'X-Kushki-Token': state.token }
+    });
+    if (!res.ok) throw new Error();
+    const warehouses = await res.json();
+    const saved = localStorage.getItem('kushki_warehouse');
+    dom.warehouseSelect.innerHTML = '';
+    warehouses.forEach(w => {
+        const opt = document.createElement('option');
+        opt.value = w.codigo;
+        opt.textContent = w.nombre;
+        if (w.codigo === saved || (!saved && w.principal)) opt.selected = true;
+        dom.warehouseSelect.appendChild(opt);
+    });
+    // Con una sola bodega el selector no aporta nada
+    dom.warehouseSelect.style.display = warehouses.length > 1 ? '' : 'none';
+}
+
 async function loadInventory() {
-    const res = await fetch('/api/inventory', {
+    const warehouse = dom.warehouseSelect.value;
+    const url = warehouse ? `/api/inventory?warehouse=${encodeURIComponent(warehouse)}` : '/api/inventory';
+    const res = await fetch(url, {
         headers: { 'X-Kushki-Token': state.token }
     });
     if (!res.ok) throw new Error();
@@ -382,17 +562,43 @@ function renderList() {
         el.className = `product-card ${p.Stock <= (p.MinStock || 0) ? 'low-stock' : ''}`;
         el.dataset.sku = p.SKU;
         el.innerHTML = `
+            ${p.TieneImagen ? '<img class="p-thumb" alt="">' : ''}
             <div class="p-info">
                 <h3>${p.Name}</h3>
                 <p>${p.SKU} | $${p.Price.toFixed(2)}</p>
                 <p style="font-size: 11px; color: #999;">${p.Location || 'Sin ubicación'}</p>
             </div>
-            <div class="p-stock">${p.Stock}</div>
+            <div class="p-stock">${p.Stock}${p.UnidadMedida ? ' <small>' + p.UnidadMedida + '</small>' : ''}</div>
         `;
         dom.list.appendChild(el);
+        if (p.TieneImagen) loadThumb(el.querySelector('.p-thumb'), p.SKU);
     });
 }
 
+// Las miniaturas requieren el token, por eso se descargan con fetch y se guardan en caché
+async function loadThumb(img, sku) {
+    if (!state.thumbs[sku]) {
+        try {
+            const res = await fetch(`/api/product/image/${encodeURIComponent(sku)}`, {
+                headers: { 'X-Kushki-Token': state.token }
+            });
+            if (!res.ok) return img.remove();
+            state.thumbs[sku] = URL.createObjectURL(await res.blob());
+        } catch (e) {
+            return;
+        }
+    }
+    img.src = state.thumbs[sku];
+}
+
+// Busca por SKU, código de barras o código de una presentación (caja, paquete)
+function findByCode(code) {
+    return state.products.find(p =>
+        p.Barcode === code || p.SKU === code ||
+        (p.Unidades || []).some(u => u.barcode === code)
+    );
+}
+
 function filterProducts(query) {
     const q = query.toLowerCase();
     state.filtered = state.products.filter(p => 
@@ -0,0 +1,547 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/logger"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Máximo de emisiones simultáneas contra el SRI en un lote.
+const maxConcurrenciaLote = 8
+
+// LoteFactura es una factura del lote armada a partir de una o varias filas del archivo.
+type LoteFactura struct {
+	Referencia string        `json:"referencia"`
+	Filas      []int         `json:"filas"`
+	Factura    db.FacturaDTO `json:"factura"`
+	Total      float64       `json:"total"`
+}
+
+// ErrorLote es un error de validación ubicado en una fila del archivo (0 = error general).
+type ErrorLote struct {
+	Fila       int    `json:"fila"`
+	Referencia string `json:"referencia"`
+	Mensaje    string `json:"mensaje"`
+}
+
+// LoteValidado es el resultado de leer y validar un archivo de lote antes de emitir.
+type LoteValidado struct {
+	Archivo      string        `json:"archivo"`
+	Facturas     []LoteFactura `json:"facturas"`
+	Errores      []ErrorLote   `json:"errores"`
+	Valido       bool          `json:"valido"`
+	TotalGeneral float64       `json:"totalGeneral"`
+}
+
+// ResultadoLote es el resultado de emitir una factura del lote.
+type ResultadoLote struct {
+	Referencia  string  `json:"referencia"`
+	Cliente     string  `json:"cliente"`
+	Secuencial  string  `json:"secuencial"`
+	ClaveAcceso string  `json:"claveAcceso"`
+	Estado      string  `json:"estado"`
+	Total       float64 `json:"total"`
+	Mensaje     string  `json:"mensaje"`
+}
+
+// fallida indica que la factura no quedó emitida: falló antes del envío o el SRI la rechazó.
+func (r ResultadoLote) fallida() bool {
+	return r.Estado == "ERROR" || estadosCorregibles[r.Estado]
+}
+
+// ResumenLote agrupa los resultados de una emisión masiva.
+type ResumenLote struct {
+	Resultados []ResultadoLote `json:"resultados"`
+	Emitidas   int             `json:"emitidas"`
+	Fallidas   int             `json:"fallidas"`
+}
+
+// columnasLote mapea los encabezados aceptados (normalizados) al campo interno.
+var columnasLote = map[string]string{
+	"referencia":        "referencia",
+	"ref":               "referencia",
+	"cliente_id":        "cliente_id",
+	"identificacion":    "cliente_id",
+	"ruc":               "cliente_id",
+	"cedula":            "cliente_id",
+	"cliente_nombre":    "cliente_nombre",
+	"razon_social":      "cliente_nombre",
+	"cliente":           "cliente_nombre",
+	"cliente_direccion": "cliente_direccion",
+	"direccion":         "cliente_direccion",
+	"cliente_email":     "cliente_email",
+	"email":             "cliente_email",
+	"correo":            "cliente_email",
+	"cliente_telefono":  "cliente_telefono",
+	"telefono":          "cliente_telefono",
+	"forma_pago":        "forma_pago",
+	"plazo":             "plazo",
+	"unidad_tiempo":     "unidad_tiempo",
+	"observacion":       "observacion",
+	"codigo":            "codigo",
+	"sku":               "codigo",
+	"nombre":            "nombre",
+	"descripcion":       "nombre",
+	"producto":          "nombre",
+	"cantidad":          "cantidad",
+	"precio":            "precio",
+	"precio_unitario":   "precio",
+	"porcentaje_iva":    "porcentaje_iva",
+	"iva":               "porcentaje_iva",
+}
+
+var columnasLoteRequeridas = []string{"cliente_id", "cliente_nombre", "codigo", "cantidad", "precio"}
+
+type BatchService struct {
+	invoiceService *InvoiceService
+}
+
+func NewBatchService(invoiceService *InvoiceService) *BatchService {
+	return &BatchService{invoiceService: invoiceService}
+}
+
+// ParsearLote lee un archivo XLSX, CSV o JSON y valida todas sus facturas antes de emitir.
+// En XLSX/CSV cada fila es un ítem; las filas con la misma referencia forman una factura
+// (sin columna referencia, cada fila es una factura). En JSON se espera un arreglo de FacturaDTO.
+func (s *BatchService) ParsearLote(nombreArchivo string, data []byte) (*LoteValidado, error) {
+	lote := &LoteValidado{Archivo: filepath.Base(nombreArchivo), Facturas: []LoteFactura{}, Errores: []ErrorLote{}}
+
+	switch strings.ToLower(filepath.Ext(nombreArchivo)) {
+	case ".xlsx", ".csv":
+		rows, lineas, err := leerTabla(nombreArchivo, data)
+		if err != nil {
+			return nil, err
+		}
+		s.agruparFilas(lote, rows, lineas)
+	case ".json":
+		var facturas []db.FacturaDTO
+		if err := json.Unmarshal(data, &facturas); err != nil {
+			return nil, fmt.Errorf("error leyendo JSON: %v", err)
+		}
+		for i, f := range facturas {
+			lote.Facturas = append(lote.Facturas, LoteFactura{
+				Referencia: strconv.Itoa(i + 1),
+				Filas:      []int{i + 1},
+				Factura:    f,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("formato no soportado: use .xlsx, .csv o .json")
+	}
+
+	if len(lote.Facturas) == 0 && len(lote.Errores) == 0 {
+		lote.Errores = append(lote.Errores, ErrorLote{Mensaje: "El archivo no contiene facturas"})
+	}
+
+	s.validarLote(lote)
+	return lote, nil
+}
+
+// agruparFilas convierte filas tabulares (con encabezado) en facturas agrupadas por referencia.
+// lineas indica el número de fila real de cada registro; si es nil se usa la posición.
+func (s *BatchService) agruparFilas(lote *LoteValidado, rows [][]string, lineas []int) {
+	if len(rows) == 0 {
+		return
+	}
+
+	indices := map[string]int{}
+	for i, h := range rows[0] {
+		if campo, ok := columnasLote[normalizarEncabezado(h)]; ok {
+			if _, existe := indices[campo]; !existe {
+				indices[campo] = i
+			}
+		}
+	}
+	for _, req := range columnasLoteRequeridas {
+		if _, ok := indices[req]; !ok {
+			lote.Errores = append(lote.Errores, ErrorLote{Fila: 1, Mensaje: fmt.Sprintf("Falta la columna requerida '%s'", req)})
+		}
+	}
+	if len(lote.Errores) > 0 {
+		return
+	}
+
+	valor := func(row []string, campo string) string {
+		if i, ok := indices[campo]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	porReferencia := map[string]int{}
+	for n, row := range rows[1:] {
+		fila := n + 2 // 1-based y saltando el encabezado
+		if lineas != nil {
+			fila = lineas[n+1]
+		}
+		if filaVacia(row) {
+			continue
+		}
+
+		ref := valor(row, "referencia")
+		if ref == "" {
+			ref = fmt.Sprintf("FILA-%d", fila)
+		}
+
+		item, errItem := parsearItemLote(valor(row, "codigo"), valor(row, "nombre"), valor(row, "cantidad"), valor(row, "precio"), valor(row, "porcentaje_iva"))
+		if errItem != "" {
+			lote.Errores = append(lote.Errores, ErrorLote{Fila: fila, Referencia: ref, Mensaje: errItem})
+		}
+
+		idx, existe := porReferencia[ref]
+		if !existe {
+			lote.Facturas = append(lote.Facturas, LoteFactura{
+				Referencia: ref,
+				Factura: db.FacturaDTO{
+					ClienteID:        valor(row, "cliente_id"),
+					ClienteNombre:    valor(row, "cliente_nombre"),
+					ClienteDireccion: valor(row, "cliente_direccion"),
+					ClienteEmail:     valor(row, "cliente_email"),
+					ClienteTelefono:  valor(row, "cliente_telefono"),
+					FormaPago:        valor(row, "forma_pago"),
+					Plazo:            valor(row, "plazo"),
+					UnidadTiempo:     valor(row, "unidad_tiempo"),
+					Observacion:      valor(row, "observacion"),
+				},
+			})
+			idx = len(lote.Facturas) - 1
+			porReferencia[ref] = idx
+		} else if id := valor(row, "cliente_id"); id != "" && id != lote.Facturas[idx].Factura.ClienteID {
+			lote.Errores = append(lote.Errores, ErrorLote{Fila: fila, Referencia: ref, Mensaje: "Cliente distinto al de las filas anteriores con la misma referencia"})
+		}
+
+		lote.Facturas[idx].Filas = append(lote.Facturas[idx].Filas, fila)
+		if errItem == "" {
+			lote.Facturas[idx].Factura.Items = append(lote.Facturas[idx].Factura.Items, item)
+		}
+	}
+}
+
+// validarLote aplica las mismas reglas de EmitirFactura a cada factura del lote.
+func (s *BatchService) validarLote(lote *LoteValidado) {
+	config, err := s.invoiceService.cargarEmisor()
+	if err != nil {
+		lote.Errores = append(lote.Errores, ErrorLote{Mensaje: err.Error()})
+	}
+
+	for i := range lote.Facturas {
+		lf := &lote.Facturas[i]
+		fila := 0
+		if len(lf.Filas) > 0 {
+			fila = lf.Filas[0]
+		}
+
+		if lf.Factura.ClienteID == "" || lf.Factura.ClienteNombre == "" {
+			lote.Errores = append(lote.Errores, ErrorLote{Fila: fila, Referencia: lf.Referencia, Mensaje: "Identificación y nombre del cliente son obligatorios"})
+		}
+
+		completarIVALote(&lf.Factura)
+		lf.Total = calcularFactura(&lf.Factura).ImporteTotal
+		lote.TotalGeneral += lf.Total
+
+		if config != nil {
+			if err := s.invoiceService.validarFactura(config, &lf.Factura); err != nil {
+				lote.Errores = append(lote.Errores, ErrorLote{Fila: fila, Referencia: lf.Referencia, Mensaje: err.Error()})
+			}
+		}
+	}
+
+	lote.Valido = len(lote.Errores) == 0
+}
+
+// EmitirLote emite las facturas del lote con concurrencia controlada.
+// Cada factura se emite de forma independiente: un fallo no detiene al resto.
+func (s *BatchService) EmitirLote(facturas []LoteFactura, concurrencia int, onEmitida FacturaEmitidaFunc) *ResumenLote {
+	if concurrencia < 1 {
+		concurrencia = 1
+	}
+	if concurrencia > maxConcurrenciaLote {
+		concurrencia = maxConcurrenciaLote
+	}
+
+	resultados := make([]ResultadoLote, len(facturas))
+	sem := make(chan struct{}, concurrencia)
+	var wg sync.WaitGroup
+
+	for i := range facturas {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			lf := facturas[i]
+			dto := lf.Factura
+			res := ResultadoLote{Referencia: lf.Referencia, Cliente: dto.ClienteNombre, Total: lf.Total}
+
+			if err := s.invoiceService.EmitirFactura(&dto); err != nil {
+				res.Estado = "ERROR"
+				res.Mensaje = err.Error()
+				logger.Error("Lote: factura %s falló: %v", lf.Referencia, err)
+				resultados[i] = res
+				return
+			}
+
+			res.Secuencial = dto.Secuencial
+			res.ClaveAcceso = dto.ClaveAcceso
+
+			var factura db.Factura
+			if err := db.GetDB().First(&factura, "clave_acceso = ?", dto.ClaveAcceso).Error; err == nil {
+				res.Estado = factura.EstadoSRI
+				res.Mensaje = factura.MensajeError
+				res.Total = factura.Total
+				if res.fallida() {
+					logger.Error("Lote: factura %s %s: %s", lf.Referencia, factura.EstadoSRI, factura.MensajeError)
+				} else if onEmitida != nil {
+					onEmitida(factura, dto.ClienteEmail)
+				}
+			} else {
+				res.Estado = "EMITIDA"
+			}
+			resultados[i] = res
+		}(i)
+	}
+	wg.Wait()
+
+	return resumirLote(resultados)
+}
+
+// resumirLote cuenta emitidas y fallidas; las rechazadas por el SRI cuentan como fallidas.
+func resumirLote(resultados []ResultadoLote) *ResumenLote {
+	resumen := &ResumenLote{Resultados: resultados}
+	for _, r := range resultados {
+		if r.fallida() {
+			resumen.Fallidas++
+		} else {
+			resumen.Emitidas++
+		}
+	}
+	return resumen
+}
+
+// GenerarReporteLote crea el Excel descargable con el resultado de cada factura del lote.
+func (s *BatchService) GenerarReporteLote(resultados []ResultadoLote) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Resultados"
+	f.SetSheetName("Sheet1", sheet)
+
+	headers := []string{"Referencia", "Cliente", "Secuencial", "Clave de Acceso", "Estado", "Total", "Mensaje", "Resultado"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+	}
+	style, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	f.SetRowStyle(sheet, 1, 1, style)
+
+	for i, r := range resultados {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), r.Referencia)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), r.Cliente)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), r.Secuencial)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), r.ClaveAcceso)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), r.Estado)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), r.Total)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), r.Mensaje)
+		resultado := "EMITIDA"
+		if r.fallida() {
+			resultado = "FALLIDA"
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), resultado)
+	}
+	f.SetColWidth(sheet, "B", "B", 30)
+	f.SetColWidth(sheet, "D", "D", 52)
+	f.SetColWidth(sheet, "G", "G", 40)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error generando reporte: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// parsearItemLote convierte las celdas de una fila en un ítem. Devuelve un mensaje si la fila es inválida.
+func parsearItemLote(codigo, nombre, cantidadStr, precioStr, ivaStr string) (db.InvoiceItem, string) {
+	item := db.InvoiceItem{Codigo: codigo, Nombre: nombre}
+	if codigo == "" {
+		return item, "Código de producto vacío"
+	}
+
+	cantidad, err := parsearDecimal(cantidadStr)
+	if err != nil || cantidad <= 0 {
+		return item, fmt.Sprintf("Cantidad inválida: '%s'", cantidadStr)
+	}
+	precio, err := parsearDecimal(precioStr)
+	if err != nil || precio < 0 {
+		return item, fmt.Sprintf("Precio inválido: '%s'", precioStr)
+	}
+	item.Cantidad = cantidad
+	// El precio del archivo manda sobre la lista de precios del cliente
+	item.Precio = precio
+	item.PrecioManual = true
+
+	if ivaStr != "" {
+		iva, err := parsearDecimal(strings.TrimSuffix(ivaStr, "%"))
+		if err != nil || iva < 0 {
+			return item, fmt.Sprintf("Porcentaje de IVA inválido: '%s'", ivaStr)
+		}
+		item.PorcentajeIVA = iva
+		item.CodigoIVA = codigoIVADesdePorcentaje(iva)
+	}
+	return item, ""
+}
+
+// completarIVALote toma el IVA y nombre del catálogo de productos para los ítems que no lo indican.
+func completarIVALote(dto *db.FacturaDTO) {
+	for i := range dto.Items {
+		item := &dto.Items[i]
+		if item.CodigoIVA == "" && item.PorcentajeIVA > 0 {
+			item.CodigoIVA = codigoIVADesdePorcentaje(item.PorcentajeIVA)
+		}
+		if item.CodigoIVA != "" && item.Nombre != "" {
+			continue
+		}
+		var productos []db.Product
+		db.GetDB().Where("sku = ?", item.Codigo).Limit(1).Find(&productos)
+		if len(productos) > 0 {
+			p := productos[0]
+			if item.Nombre == "" {
+				item.Nombre = p.Name
+			}
+			if item.CodigoIVA == "" {
+				item.CodigoIVA = strconv.Itoa(p.TaxCode)
+				item.PorcentajeIVA = float64(p.TaxPercentage)
+			}
+		}
+		if item.CodigoIVA == "" {
+			// Tarifa general vigente
+			item.CodigoIVA = "4"
+			item.PorcentajeIVA = 15
+		}
+		if item.Nombre == "" {
+			item.Nombre = item.Codigo
+		}
+	}
+}
+
+var (
+	// Un único separador seguido de tres dígitos puede ser de miles o decimal ("1,234").
+	decimalAmbiguo = regexp.MustCompile(`^[+-]?[1-9][0-9]{0,2}[.,][0-9]{3}$`)
+	gruposMiles    = map[string]*regexp.Regexp{
+		".": regexp.MustCompile(`^[+-]?[0-9]{1,3}(\.[0-9]{3})+$`),
+		",": regexp.MustCompile(`^[+-]?[0-9]{1,3}(,[0-9]{3})+$`),
+	}
+)
+
+// parsearDecimal acepta coma o punto como separador decimal ("10,50" o "10.50") y separadores de
+// miles. Con ambos separadores el último es el decimal ("1.234,56" o "1,234.56"); uno repetido es
+// de miles ("1.234.567"). Un único separador seguido de tres dígitos ("1,234") es ambiguo y se
+// rechaza para no importar precios mil veces menores.
+func parsearDecimal(v string) (float64, error) {
+	v = strings.TrimSpace(v)
+	coma, punto := strings.LastIndex(v, ","), strings.LastIndex(v, ".")
+	decimal, miles := ".", ","
+	if coma > punto {
+		decimal, miles = ",", "."
+	}
+	if coma < 0 || punto < 0 {
+		switch {
+		case strings.Count(v, decimal) > 1:
+			decimal, miles = "", decimal
+		case decimalAmbiguo.MatchString(v):
+			return 0, fmt.Errorf("número ambiguo '%s': no se distingue el separador de miles del decimal", v)
+		}
+	}
+
+	entero, fraccion := v, ""
+	if i := strings.LastIndex(v, decimal); decimal != "" && i >= 0 {
+		entero, fraccion = v[:i], "."+v[i+1:]
+	}
+	if strings.Contains(entero, miles) {
+		if !gruposMiles[miles].MatchString(entero) {
+			return 0, fmt.Errorf("separador de miles inválido en '%s'", v)
+		}
+		entero = strings.ReplaceAll(entero, miles, "")
+	}
+	return strconv.ParseFloat(entero+fraccion, 64)
+}
+
+// leerTabla lee la primera hoja de un XLSX o un CSV (con ',' o ';'). En CSV devuelve además la
+// línea real de cada registro, porque el lector omite las líneas vacías; en XLSX lineas es nil
+// y la fila es la posición.
+func leerTabla(nombreArchivo string, data []byte) ([][]string, []int, error) {
+	switch strings.ToLower(filepath.Ext(nombreArchivo)) {
+	case ".xlsx":
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error abriendo Excel: %v", err)
+		}
+		defer f.Close()
+		rows, err := f.GetRows(f.GetSheetName(0))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error leyendo hoja: %v", err)
+		}
+		return rows, nil, nil
+	case ".csv":
+		r := csv.NewReader(bytes.NewReader(data))
+		r.Comma = detectarSeparador(data)
+		r.FieldsPerRecord = -1
+		var rows [][]string
+		var lineas []int
+		for {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("error leyendo CSV: %v", err)
+			}
+			line, _ := r.FieldPos(0)
+			rows = append(rows, record)
+			lineas = append(lineas, line)
+		}
+		return rows, lineas, nil
+	default:
+		return nil, nil, fmt.Errorf("formato no soportado: use .xlsx o .csv")
+	}
+}
+
+// detectarSeparador elige ';' si la primera línea lo usa (Excel en español), si no ','.
+func detectarSeparador(data []byte) rune {
+	linea := string(data)
+	if i := strings.IndexByte(linea, '\n'); i >= 0 {
+		linea = linea[:i]
+	}
+	if strings.Count(linea, ";") > strings.Count(linea, ",") {
+		return ';'
+	}
+	return ','
+}
+
+func normalizarEncabezado(h string) string {
+	h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+	h = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ñ", "n", " ", "_", "-", "_").Replace(h)
+	return h
+}
+
+func filaVacia(row []string) bool {
+	for _, c := range row {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+
+	"kushkiv2/internal/db"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParsearLote(t *testing.T) {
+	database := setupTestDB()
+	database.Create(&db.EmisorConfig{RUC: "1790011223001", RazonSocial: "Emisor", Estab: "001", PtoEmi: "001", Ambiente: 1})
+	database.Create(&db.Product{SKU: "SERV0", Name: "Servicio Exento", Barcode: "SERV0", TaxCode: 0, TaxPercentage: 0})
+	s := NewBatchService(NewInvoiceService())
+
+	t.Run("CSV Agrupado por Referencia", func(t *testing.T) {
+		csv := "Referencia;Identificación;Razón Social;Email;Código;Descripción;Cantidad;Precio;IVA\n" +
+			"A1;0102030405;Juan Perez;juan@mail.com;P1;Producto 1;2;10,50;15\n" +
+			"A1;0102030405;Juan Perez;;SERV0;;1;5;\n" +
+			"\n" +
+			"A2;1790000000001;Empresa SA;;P2;Producto 2;1;100;15\n"
+
+		lote, err := s.ParsearLote("lote.csv", []byte(csv))
+		if err != nil {
+			t.Fatalf("Error parseando: %v", err)
+		}
+		if !lote.Valido {
+			t.Fatalf("Lote debería ser válido: %+v", lote.Errores)
+		}
+		if len(lote.Facturas) != 2 {
+			t.Fatalf("Esperaba 2 facturas, obtuve %d", len(lote.Facturas))
+		}
+
+		a1 := lote.Facturas[0]
+		if len(a1.Factura.Items) != 2 || a1.Factura.Items[0].Precio != 10.5 {
+			t.Errorf("Ítems de A1 incorrectos: %+v", a1.Factura.Items)
+		}
+		// El ítem sin IVA toma los datos del catálogo
+		if a1.Factura.Items[1].CodigoIVA != "0" || a1.Factura.Items[1].Nombre != "Servicio Exento" {
+			t.Errorf("IVA/nombre del catálogo no aplicado: %+v", a1.Factura.Items[1])
+		}
+		if a1.Total != 29.15 || lote.TotalGeneral != 144.15 {
+			t.Errorf("Totales incorrectos: factura %.2f, lote %.2f", a1.Total, lote.TotalGeneral)
+		}
+		if lote.Facturas[1].Filas[0] != 5 {
+			t.Errorf("Número de fila incorrecto: %v", lote.Facturas[1].Filas)
+		}
+	})
+
+	t.Run("Errores por Fila", func(t *testing.T) {
+		csv := "referencia,cliente_id,cliente_nombre,codigo,cantidad,precio\n" +
+			"B1,9999999999999,Consumidor Final,P1,1,80\n" +
+			"B2,0102030405,Juan,,1,10\n" +
+			"B3,0102030405,Juan,P3,abc,10\n" +
+			"B3,1790000000001,Otro,P4,1,10\n"
+
+		lote, err := s.ParsearLote("lote.csv", []byte(csv))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lote.Valido {
+			t.Fatal("El lote no debería ser válido")
+		}
+
+		filas := map[int]bool{}
+		for _, e := range lote.Errores {
+			filas[e.Fila] = true
+		}
+		for _, f := range []int{2, 3, 4, 5} {
+			if !filas[f] {
+				t.Errorf("Falta error para la fila %d: %+v", f, lote.Errores)
+			}
+		}
+	})
+
+	t.Run("Columnas Faltantes", func(t *testing.T) {
+		lote, _ := s.ParsearLote("lote.csv", []byte("cliente_id,codigo\n0102030405,P1\n"))
+		if lote.Valido || len(lote.Errores) != 3 {
+			t.Errorf("Esperaba 3 errores de columnas faltantes: %+v", lote.Errores)
+		}
+	})
+
+	t.Run("XLSX y JSON", func(t *testing.T) {
+		f := excelize.NewFile()
+		f.SetSheetRow("Sheet1", "A1", &[]string{"cliente_id", "cliente_nombre", "codigo", "cantidad", "precio"})
+		f.SetSheetRow("Sheet1", "A2", &[]interface{}{"0102030405", "Juan", "P1", 3, 2.5})
+		buf, _ := f.WriteToBuffer()
+
+		lote, err := s.ParsearLote("lote.xlsx", buf.Bytes())
+		if err != nil || !lote.Valido || len(lote.Facturas) != 1 || lote.Facturas[0].Factura.Items[0].Cantidad != 3 {
+			t.Errorf("Lote XLSX incorrecto: %v %+v", err, lote)
+		}
+
+		json := `[{"clienteID":"0102030405","clienteNombre":"Juan","items":[{"Codigo":"P1","Nombre":"X","Cantidad":1,"Precio":10,"PorcentajeIVA":15}]}]`
+		lote, err = s.ParsearLote("lote.json", []byte(json))
+		if err != nil || !lote.Valido || lote.Facturas[0].Factura.Items[0].CodigoIVA != "4" {
+			t.Errorf("Lote JSON incorrecto: %v %+v", err, lote)
+		}
+
+		if _, err := s.ParsearLote("lote.txt", nil); err == nil {
+			t.Error("Formato desconocido debería fallar")
+		}
+	})
+}
+
+func TestParsearDecimal(t *testing.T) {
+	casos := []struct {
+		valor    string
+		esperado float64
+		valido   bool
+	}{
+		{"10.50", 10.5, true},
+		{"10,50", 10.5, true},
+		{" 3 ", 3, true},
+		{"0.125", 0.125, true},
+		{"1234,567", 1234.567, true},
+		{"1.234,56", 1234.56, true},
+		{"1,234.56", 1234.56, true},
+		{"1.234.567", 1234567, true},
+		{"1,234,567.89", 1234567.89, true},
+		{"-1.234,5", -1234.5, true},
+		{"1,234", 0, false}, // Ambiguo: miles o tres decimales
+		{"12.500", 0, false},
+		{"12,5.3", 0, false},
+		{"1.23.4", 0, false},
+		{"abc", 0, false},
+		{"", 0, false},
+	}
+	for _, c := range casos {
+		n, err := parsearDecimal(c.valor)
+		if c.valido && (err != nil || n != c.esperado) {
+			t.Errorf("'%s': esperado %g, obtuve %g (%v)", c.valor, c.esperado, n, err)
+		}
+		if !c.valido && err == nil {
+			t.Errorf("'%s' debió rechazarse, obtuve %g", c.valor, n)
+		}
+	}
+}
+
+func TestGenerarReporteLote(t *testing.T) {
+	s := NewBatchService(NewInvoiceService())
+	data, err := s.GenerarReporteLote([]ResultadoLote{
+		{Referencia: "A1", Cliente: "Juan", Secuencial: "000000001", ClaveAcceso: "123", Estado: "AUTORIZADO", Total: 10},
+		{Referencia: "A2", Cliente: "Ana", Secuencial: "000000002", ClaveAcceso: "124", Estado: "DEVUELTA", Total: 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := f.GetCellValue("Resultados", "E2"); v != "AUTORIZADO" {
+		t.Errorf("Estado esperado AUTORIZADO, obtuve %s", v)
+	}
+	if v, _ := f.GetCellValue("Resultados", "H3"); v != "FALLIDA" {
+		t.Errorf("Una factura DEVUELTA debe figurar como fallida, obtuve %s", v)
+	}
+}
+
+func TestResumirLote(t *testing.T) {
+	resumen := resumirLote([]ResultadoLote{
+		{Estado: "AUTORIZADO"},
+		{Estado: "PENDIENTE_ENVIO"},
+		{Estado: "ERROR"},
+		{Estado: "DEVUELTA"},
+		{Estado: "NO AUTORIZADO"},
+	})
+	if resumen.Emitidas != 2 || resumen.Fallidas != 3 {
+		t.Errorf("Esperado 2 emitidas y 3 fallidas, obtuve %d y %d", resumen.Emitidas, resumen.Fallidas)
+	}
+}
@@ -0,0 +1,442 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/logger"
+	"kushkiv2/pkg/pdf"
+	"kushkiv2/pkg/util"
+	"kushkiv2/pkg/xml"
+
+	"gorm.io/gorm"
+)
+
+// Estados de una caja y tipos de movimiento de efectivo.
+const (
+	CajaAbierta    = "ABIERTA"
+	CajaCerrada    = "CERRADA"
+	MovCajaIngreso = "INGRESO"
+	MovCajaEgreso  = "EGRESO"
+)
+
+// FormaPagoEfectivo es el código SRI "Sin utilización del sistema financiero": lo cobrado en caja.
+const FormaPagoEfectivo = "01"
+
+// formasPago es la tabla de formas de pago del SRI.
+var formasPago = map[string]string{
+	"01": "Sin utilización del sistema financiero",
+	"15": "Compensación de deudas",
+	"16": "Tarjeta de débito",
+	"17": "Dinero electrónico",
+	"18": "Tarjeta prepago",
+	"19": "Tarjeta de crédito",
+	"20": "Otros con utilización del sistema financiero",
+	"21": "Endoso de títulos",
+}
+
+// DenominacionesEfectivo son los billetes y monedas en circulación (dólar y centavos).
+var DenominacionesEfectivo = []float64{100, 50, 20, 10, 5, 2, 1, 0.5, 0.25, 0.1, 0.05, 0.01}
+
+type CashService struct{}
+
+func NewCashService() *CashService {
+	return &CashService{}
+}
+
+// AbrirCaja inicia el turno de un usuario en un punto de emisión (vacío = el configurado en el
+// emisor) con el fondo de cambio. Solo puede haber una caja abierta por punto de emisión.
+func (s *CashService) AbrirCaja(estab, ptoEmi, usuario string, fondo float64) (*db.CashSessionDTO, error) {
+	usuario = strings.TrimSpace(usuario)
+	if usuario == "" {
+		return nil, fmt.Errorf("indique el usuario que abre la caja")
+	}
+	if fondo < 0 {
+		return nil, fmt.Errorf("el fondo inicial no puede ser negativo")
+	}
+	estab, ptoEmi, err := puntoCaja(estab, ptoEmi)
+	if err != nil {
+		return nil, err
+	}
+
+	var sesion db.CashSession
+	err = db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var abiertas []db.CashSession
+		tx.Where("estab = ? AND pto_emi = ? AND estado = ?", estab, ptoEmi, CajaAbierta).Limit(1).Find(&abiertas)
+		if len(abiertas) > 0 {
+			return fmt.Errorf("ya hay una caja abierta en el punto %s-%s (%s)", estab, ptoEmi, abiertas[0].Usuario)
+		}
+		sesion = db.CashSession{Estab: estab, PtoEmi: ptoEmi, Usuario: usuario, Estado: CajaAbierta, FondoInicial: util.Round(fondo, 2)}
+		if err := tx.Create(&sesion).Error; err != nil {
+			return fmt.Errorf("error abriendo caja: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCaja(sesion.ID)
+}
+
+// CajaAbiertaEn devuelve la caja abierta del punto de emisión (vacío = el del emisor), o nil.
+func (s *CashService) CajaAbiertaEn(estab, ptoEmi string) (*db.CashSessionDTO, error) {
+	estab, ptoEmi, err := puntoCaja(estab, ptoEmi)
+	if err != nil {
+		return nil, err
+	}
+	var abiertas []db.CashSession
+	db.GetDB().Where("estab = ? AND pto_emi = ? AND estado = ?", estab, ptoEmi, CajaAbierta).Limit(1).Find(&abiertas)
+	if len(abiertas) == 0 {
+		return nil, nil
+	}
+	return s.GetCaja(abiertas[0].ID)
+}
+
+// RegistrarMovimientoCaja anota un ingreso o egreso de efectivo en la caja abierta. Un egreso no
+// puede superar el efectivo que debería haber en la caja.
+func (s *CashService) RegistrarMovimientoCaja(id uint, tipo string, monto float64, motivo, usuario string) (*db.CashSessionDTO, error) {
+	tipo = strings.ToUpper(strings.TrimSpace(tipo))
+	if tipo != MovCajaIngreso && tipo != MovCajaEgreso {
+		return nil, fmt.Errorf("tipo de movimiento inválido: %s", tipo)
+	}
+	monto = util.Round(monto, 2)
+	if monto <= 0 {
+		return nil, fmt.Errorf("el monto debe ser mayor a cero")
+	}
+	motivo = strings.TrimSpace(motivo)
+	if motivo == "" {
+		return nil, fmt.Errorf("indique el motivo del movimiento")
+	}
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		sesion, err := cajaAbierta(tx, id)
+		if err != nil {
+			return err
+		}
+		if tipo == MovCajaEgreso {
+			resumen := resumirCaja(tx, sesion, time.Now())
+			if monto > resumen.EfectivoEsperado {
+				return fmt.Errorf("el egreso supera el efectivo en caja (%.2f)", resumen.EfectivoEsperado)
+			}
+		}
+		mov := db.CashMovement{SessionID: id, Tipo: tipo, Monto: monto, Motivo: motivo, Usuario: usuario}
+		if err := tx.Create(&mov).Error; err != nil {
+			return fmt.Errorf("error registrando movimiento de caja: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCaja(id)
+}
+
+// GetCaja devuelve la caja con sus ventas por forma de pago, movimientos y arqueo. Una caja abierta
+// se calcula al momento; una cerrada muestra lo congelado al cierre.
+func (s *CashService) GetCaja(id uint) (*db.CashSessionDTO, error) {
+	var sesion db.CashSession
+	if err := db.GetDB().First(&sesion, id).Error; err != nil {
+		return nil, fmt.Errorf("caja no encontrada")
+	}
+	var dto db.CashSessionDTO
+	if sesion.Estado == CajaAbierta {
+		dto = resumirCaja(db.GetDB(), &sesion, time.Now())
+	} else {
+		dto = resumenCajaCerrada(db.GetDB(), &sesion)
+	}
+
+	var movs []db.CashMovement
+	db.GetDB().Where("session_id = ?", id).Order("id").Find(&movs)
+	for _, m := range movs {
+		dto.Movimientos = append(dto.Movimientos, db.CashMovementDTO{
+			ID:      m.ID,
+			Tipo:    m.Tipo,
+			Monto:   m.Monto,
+			Motivo:  m.Motivo,
+			Usuario: m.Usuario,
+			Fecha:   m.CreatedAt.Format("2006-01-02 15:04"),
+		})
+	}
+	var conteo []db.CashCount
+	db.GetDB().Where("session_id = ?", id).Order("denominacion desc").Find(&conteo)
+	for _, c := range conteo {
+		dto.Conteo = append(dto.Conteo, db.CashCountDTO{Denominacion: c.Denominacion, Cantidad: c.Cantidad, Subtotal: util.Round(c.Denominacion*float64(c.Cantidad), 2)})
+	}
+	return &dto, nil
+}
+
+// ListarCajas devuelve las cajas abiertas en el rango, sin movimientos ni arqueo.
+func (s *CashService) ListarCajas(desde, hasta time.Time) ([]db.CashSessionDTO, error) {
+	var sesiones []db.CashSession
+	if err := db.GetDB().Omit("cierre_pdf").Where("created_at BETWEEN ? AND ?", desde, hasta).
+		Order("created_at desc").Find(&sesiones).Error; err != nil {
+		return nil, fmt.Errorf("error listando cajas: %v", err)
+	}
+	result := make([]db.CashSessionDTO, 0, len(sesiones))
+	for i := range sesiones {
+		if sesiones[i].Estado == CajaAbierta {
+			result = append(result, resumirCaja(db.GetDB(), &sesiones[i], time.Now()))
+		} else {
+			result = append(result, resumenCajaCerrada(db.GetDB(), &sesiones[i]))
+		}
+	}
+	return result, nil
+}
+
+// CerrarCaja hace el arqueo con el efectivo contado por denominación, congela las ventas del turno
+// y guarda el cierre Z. La diferencia es contado - esperado (negativa = faltante).
+func (s *CashService) CerrarCaja(id uint, conteo []db.CashCountDTO, usuario, observacion string) (*db.CashSessionDTO, error) {
+	cantidades := map[float64]int{}
+	for _, c := range conteo {
+		if c.Cantidad < 0 {
+			return nil, fmt.Errorf("cantidad negativa para la denominación %.2f", c.Denominacion)
+		}
+		d, ok := denominacionValida(c.Denominacion)
+		if !ok {
+			return nil, fmt.Errorf("denominación no válida: %.2f", c.Denominacion)
+		}
+		cantidades[d] += c.Cantidad
+	}
+
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		sesion, err := cajaAbierta(tx, id)
+		if err != nil {
+			return err
+		}
+		ahora := time.Now()
+		resumen := resumirCaja(tx, sesion, ahora)
+
+		// En centavos para que la diferencia (que puede ser negativa) no arrastre error de redondeo
+		var centavos int64
+		for d, n := range cantidades {
+			if n == 0 {
+				continue
+			}
+			if err := tx.Create(&db.CashCount{SessionID: id, Denominacion: d, Cantidad: n}).Error; err != nil {
+				return fmt.Errorf("error guardando arqueo: %v", err)
+			}
+			centavos += int64(math.Round(d*100)) * int64(n)
+		}
+		for _, v := range resumen.Ventas {
+			pago := db.CashSessionPayment{SessionID: id, FormaPago: v.FormaPago, Facturas: v.Facturas, Total: v.Total}
+			if err := tx.Create(&pago).Error; err != nil {
+				return fmt.Errorf("error guardando ventas de la caja: %v", err)
+			}
+		}
+
+		diferencia := centavos - int64(math.Round(resumen.EfectivoEsperado*100))
+		return tx.Model(sesion).Updates(map[string]interface{}{
+			"estado":            CajaCerrada,
+			"efectivo_esperado": resumen.EfectivoEsperado,
+			"efectivo_contado":  float64(centavos) / 100,
+			"diferencia":        float64(diferencia) / 100,
+			"usuario_cierre":    usuario,
+			"observacion":       strings.TrimSpace(observacion),
+			"cerrada_at":        &ahora,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// El cierre Z queda guardado; si falla se puede regenerar con GetCierrePDF
+	if _, err := s.GetCierrePDF(id); err != nil {
+		logger.Error("Error generando cierre Z de la caja %d: %v", id, err)
+	}
+	return s.GetCaja(id)
+}
+
+// GetCierrePDF devuelve el cierre Z guardado de una caja cerrada (lo genera si aún no existe).
+func (s *CashService) GetCierrePDF(id uint) ([]byte, error) {
+	var sesion db.CashSession
+	if err := db.GetDB().First(&sesion, id).Error; err != nil {
+		return nil, fmt.Errorf("caja no encontrada")
+	}
+	if sesion.Estado != CajaCerrada {
+		return nil, fmt.Errorf("la caja aún está abierta")
+	}
+	if len(sesion.CierrePDF) > 0 {
+		return sesion.CierrePDF, nil
+	}
+
+	caja, err := s.GetCaja(id)
+	if err != nil {
+		return nil, err
+	}
+	var config db.EmisorConfig
+	if err := db.GetDB().First(&config).Error; err != nil {
+		return nil, fmt.Errorf("error obteniendo configuración: %v", err)
+	}
+	data, err := pdf.GenerarCierreCajaPDF(*caja, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.GetDB().Model(&sesion).Update("cierre_pdf", data).Error; err != nil {
+		return nil, fmt.Errorf("error guardando cierre Z: %v", err)
+	}
+	return data, nil
+}
+
+// puntoCaja normaliza el punto de emisión a 3 dígitos; vacío toma el configurado en el emisor.
+func puntoCaja(estab, ptoEmi string) (string, string, error) {
+	if strings.TrimSpace(estab) == "" && strings.TrimSpace(ptoEmi) == "" {
+		var config db.EmisorConfig
+		if err := db.GetDB().First(&config).Error; err != nil {
+			return "", "", fmt.Errorf("configure el emisor antes de abrir la caja")
+		}
+		estab, ptoEmi = serieEmisor(&config)
+		return estab, ptoEmi, nil
+	}
+	var nEstab, nPtoEmi int
+	if _, err := fmt.Sscanf(estab, "%d", &nEstab); err != nil || nEstab < 1 || nEstab > 999 {
+		return "", "", fmt.Errorf("establecimiento inválido: %s", estab)
+	}
+	if _, err := fmt.Sscanf(ptoEmi, "%d", &nPtoEmi); err != nil || nPtoEmi < 1 || nPtoEmi > 999 {
+		return "", "", fmt.Errorf("punto de emisión inválido: %s", ptoEmi)
+	}
+	return fmt.Sprintf("%03d", nEstab), fmt.Sprintf("%03d", nPtoEmi), nil
+}
+
+func cajaAbierta(tx *gorm.DB, id uint) (*db.CashSession, error) {
+	var sesion db.CashSession
+	if err := tx.Omit("cierre_pdf").First(&sesion, id).Error; err != nil {
+		return nil, fmt.Errorf("caja no encontrada")
+	}
+	if sesion.Estado != CajaAbierta {
+		return nil, fmt.Errorf("la caja ya está cerrada")
+	}
+	return &sesion, nil
+}
+
+func denominacionValida(valor float64) (float64, bool) {
+	for _, d := range DenominacionesEfectivo {
+		if math.Round(d*100) == math.Round(valor*100) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// resumirCaja calcula las ventas del turno hasta el corte: facturas que cuentan como venta (ver
+// estadosVenta; no las anuladas ni las rechazadas por el SRI) del punto de emisión (serie de la
+// clave de acceso) emitidas desde la apertura, agrupadas por forma de pago.
+func resumirCaja(tx *gorm.DB, sesion *db.CashSession, hasta time.Time) db.CashSessionDTO {
+	dto := mapCashSessionToDTO(sesion)
+
+	var facturas []db.Factura
+	tx.Select("clave_acceso, total, forma_pago, xml_firmado").
+		Where("substr(clave_acceso, 25, 6) = ? AND fecha_emision BETWEEN ? AND ? AND estado_sri IN ?",
+			sesion.Estab+sesion.PtoEmi, sesion.CreatedAt, hasta, listaEstadosVenta()).
+		Find(&facturas)
+	porForma := map[string]*db.CashPaymentDTO{}
+	for _, f := range facturas {
+		forma := formaPagoFactura(f)
+		v, ok := porForma[forma]
+		if !ok {
+			v = &db.CashPaymentDTO{FormaPago: forma, Descripcion: descripcionFormaPago(forma)}
+			porForma[forma] = v
+		}
+		v.Facturas++
+		v.Total += f.Total
+	}
+	for _, v := range porForma {
+		v.Total = util.Round(v.Total, 2)
+		dto.Ventas = append(dto.Ventas, *v)
+	}
+	sort.Slice(dto.Ventas, func(a, b int) bool { return dto.Ventas[a].FormaPago < dto.Ventas[b].FormaPago })
+
+	var movs []db.CashMovement
+	tx.Where("session_id = ?", sesion.ID).Find(&movs)
+	for _, m := range movs {
+		if m.Tipo == MovCajaIngreso {
+			dto.Ingresos += m.Monto
+		} else {
+			dto.Egresos += m.Monto
+		}
+	}
+
+	totalizarCaja(&dto)
+	dto.EfectivoEsperado = util.Round(dto.FondoInicial+dto.VentasEfectivo+dto.Ingresos-dto.Egresos, 2)
+	return dto
+}
+
+// resumenCajaCerrada arma el resumen con lo congelado al cierre.
+func resumenCajaCerrada(tx *gorm.DB, sesion *db.CashSession) db.CashSessionDTO {
+	dto := mapCashSessionToDTO(sesion)
+	var pagos []db.CashSessionPayment
+	tx.Where("session_id = ?", sesion.ID).Order("forma_pago").Find(&pagos)
+	for _, p := range pagos {
+		dto.Ventas = append(dto.Ventas, db.CashPaymentDTO{FormaPago: p.FormaPago, Descripcion: descripcionFormaPago(p.FormaPago), Facturas: p.Facturas, Total: p.Total})
+	}
+	var movs []db.CashMovement
+	tx.Where("session_id = ?", sesion.ID).Find(&movs)
+	for _, m := range movs {
+		if m.Tipo == MovCajaIngreso {
+			dto.Ingresos += m.Monto
+		} else {
+			dto.Egresos += m.Monto
+		}
+	}
+	totalizarCaja(&dto)
+	return dto
+}
+
+func totalizarCaja(dto *db.CashSessionDTO) {
+	for _, v := range dto.Ventas {
+		dto.Facturas += v.Facturas
+		dto.TotalVentas += v.Total
+		if v.FormaPago == FormaPagoEfectivo {
+			dto.VentasEfectivo += v.Total
+		}
+	}
+	dto.TotalVentas = util.Round(dto.TotalVentas, 2)
+	dto.VentasEfectivo = util.Round(dto.VentasEfectivo, 2)
+	dto.Ingresos = util.Round(dto.Ingresos, 2)
+	dto.Egresos = util.Round(dto.Egresos, 2)
+}
+
+// formaPagoFactura toma la forma de pago guardada o, en facturas anteriores, la del XML.
+func formaPagoFactura(f db.Factura) string {
+	if f.FormaPago != "" {
+		return f.FormaPago
+	}
+	if facturaXML, err := xml.ParseFacturaXML(f.XMLFirmado); err == nil && len(facturaXML.InfoFactura.Pagos) > 0 {
+		return facturaXML.InfoFactura.Pagos[0].FormaPago
+	}
+	return FormaPagoEfectivo
+}
+
+func descripcionFormaPago(codigo string) string {
+	if d, ok := formasPago[codigo]; ok {
+		return d
+	}
+	return "Forma de pago " + codigo
+}
+
+func mapCashSessionToDTO(s *db.CashSession) db.CashSessionDTO {
+	dto := db.CashSessionDTO{
+		ID:               s.ID,
+		Estab:            s.Estab,
+		PtoEmi:           s.PtoEmi,
+		Usuario:          s.Usuario,
+		Estado:           s.Estado,
+		Apertura:         s.CreatedAt.Format("2006-01-02 15:04"),
+		UsuarioCierre:    s.UsuarioCierre,
+		FondoInicial:     s.FondoInicial,
+		Ventas:           []db.CashPaymentDTO{},
+		EfectivoEsperado: s.EfectivoEsperado,
+		EfectivoContado:  s.EfectivoContado,
+		Diferencia:       s.Diferencia,
+		Observacion:      s.Observacion,
+		Movimientos:      []db.CashMovementDTO{},
+		Conteo:           []db.CashCountDTO{},
+	}
+	if s.CerradaAt != nil {
+		dto.Cierre = s.CerradaAt.Format("2006-01-02 15:04")
+	}
+	return dto
+}
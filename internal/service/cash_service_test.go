@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+)
+
+// claveSerie arma una clave de acceso de prueba con la serie (estab + ptoEmi) en su posición.
+func claveSerie(serie string, n int) string {
+	return fmt.Sprintf("%024d%s%019d", 0, serie, n)
+}
+
+func TestCashService_AperturaYCierre(t *testing.T) {
+	database := setupTestDB()
+	svc := NewCashService()
+	database.Model(&db.EmisorConfig{}).Where("1 = 1").Updates(map[string]interface{}{"estab": "1", "pto_emi": "1", "razon_social": "Empresa Test"})
+
+	caja, err := svc.AbrirCaja("", "", "ana", 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caja.Estab != "001" || caja.PtoEmi != "001" || caja.Estado != CajaAbierta {
+		t.Fatalf("Caja mal abierta: %+v", caja)
+	}
+	if _, err := svc.AbrirCaja("001", "1", "luis", 0); err == nil {
+		t.Error("No debe haber dos cajas abiertas en el mismo punto de emisión")
+	}
+
+	ahora := time.Now()
+	database.Create(&[]db.Factura{
+		{ClaveAcceso: claveSerie("001001", 1), FechaEmision: ahora, Total: 30, FormaPago: "01", EstadoSRI: "AUTORIZADO"},
+		{ClaveAcceso: claveSerie("001001", 2), FechaEmision: ahora, Total: 20, FormaPago: "19", EstadoSRI: "AUTORIZADO"},
+		{ClaveAcceso: claveSerie("001001", 3), FechaEmision: ahora, Total: 100, FormaPago: "01", EstadoSRI: "ANULADO"},
+		{ClaveAcceso: claveSerie("001001", 7), FechaEmision: ahora, Total: 60, FormaPago: "01", EstadoSRI: "DEVUELTA"},
+		{ClaveAcceso: claveSerie("002001", 4), FechaEmision: ahora, Total: 40, FormaPago: "01", EstadoSRI: "AUTORIZADO"},
+		{ClaveAcceso: claveSerie("001001", 5), FechaEmision: ahora.Add(-2 * time.Hour), Total: 10, FormaPago: "01", EstadoSRI: "AUTORIZADO"},
+		// Anterior a guardar la forma de pago y sin XML: se asume efectivo
+		{ClaveAcceso: claveSerie("001001", 6), FechaEmision: ahora, Total: 5, EstadoSRI: "PENDIENTE_ENVIO"},
+	})
+
+	if _, err := svc.RegistrarMovimientoCaja(caja.ID, MovCajaIngreso, 10, "Cambio", "ana"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.RegistrarMovimientoCaja(caja.ID, MovCajaEgreso, 1000, "Depósito", "ana"); err == nil {
+		t.Error("Un egreso no puede superar el efectivo en caja")
+	}
+	caja, err = svc.RegistrarMovimientoCaja(caja.ID, MovCajaEgreso, 15, "Pago de flete", "ana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caja.VentasEfectivo != 35 || caja.TotalVentas != 55 || caja.Facturas != 3 || caja.EfectivoEsperado != 80 {
+		t.Fatalf("Resumen de caja incorrecto: %+v", caja)
+	}
+	if len(caja.Ventas) != 2 || caja.Ventas[1].FormaPago != "19" || caja.Ventas[1].Total != 20 {
+		t.Errorf("Ventas por forma de pago incorrectas: %+v", caja.Ventas)
+	}
+
+	if _, err := svc.CerrarCaja(caja.ID, []db.CashCountDTO{{Denominacion: 3, Cantidad: 1}}, "ana", ""); err == nil {
+		t.Error("Debe rechazar denominaciones inexistentes")
+	}
+	conteo := []db.CashCountDTO{
+		{Denominacion: 20, Cantidad: 3},
+		{Denominacion: 10, Cantidad: 1},
+		{Denominacion: 5, Cantidad: 1},
+		{Denominacion: 1, Cantidad: 4},
+		{Denominacion: 0.25, Cantidad: 2},
+		{Denominacion: 0.01, Cantidad: 0},
+	}
+	caja, err = svc.CerrarCaja(caja.ID, conteo, "supervisor", "Faltan 50 centavos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caja.Estado != CajaCerrada || caja.EfectivoContado != 79.5 || caja.Diferencia != -0.5 || len(caja.Conteo) != 5 {
+		t.Fatalf("Arqueo incorrecto: %+v", caja)
+	}
+	if data, err := svc.GetCierrePDF(caja.ID); err != nil || len(data) == 0 {
+		t.Errorf("Cierre Z no guardado: %v", err)
+	}
+
+	// Lo cerrado queda congelado aunque luego se anule una factura
+	database.Model(&db.Factura{}).Where("clave_acceso = ?", claveSerie("001001", 1)).Update("estado_sri", "ANULADO")
+	caja, _ = svc.GetCaja(caja.ID)
+	if caja.VentasEfectivo != 35 || caja.EfectivoEsperado != 80 {
+		t.Errorf("El cierre no debe recalcularse: %+v", caja)
+	}
+	if _, err := svc.RegistrarMovimientoCaja(caja.ID, MovCajaIngreso, 1, "Tarde", "ana"); err == nil {
+		t.Error("Una caja cerrada no admite movimientos")
+	}
+	if abierta, _ := svc.CajaAbiertaEn("", ""); abierta != nil {
+		t.Error("No debería quedar caja abierta")
+	}
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"kushkiv2/internal/db"
+
+	"gorm.io/gorm"
+)
+
+// separadorRuta une los niveles de una categoría ("Bebidas > Gaseosas").
+const separadorRuta = " > "
+
+// Nombres de grupo para productos sin clasificar en los reportes
+const (
+	SinCategoria = "Sin categoría"
+	SinMarca     = "Sin marca"
+)
+
+type CatalogService struct{}
+
+func NewCatalogService() *CatalogService {
+	return &CatalogService{}
+}
+
+// ListarCategorias devuelve el árbol de categorías aplanado y ordenado por ruta.
+func (s *CatalogService) ListarCategorias() ([]db.CategoryDTO, error) {
+	var categorias []db.Category
+	if err := db.GetDB().Order("ruta").Find(&categorias).Error; err != nil {
+		return nil, fmt.Errorf("error listando categorías: %v", err)
+	}
+	conteo := contarProductosPor("category_id")
+	result := make([]db.CategoryDTO, 0, len(categorias))
+	for _, c := range categorias {
+		dto := db.CategoryDTO{ID: c.ID, Nombre: c.Nombre, Ruta: c.Ruta, Productos: conteo[c.ID]}
+		if c.ParentID != nil {
+			dto.ParentID = *c.ParentID
+		}
+		result = append(result, dto)
+	}
+	return result, nil
+}
+
+// GuardarCategoria crea, renombra o mueve una categoría. No puede quedar dentro de sí misma ni
+// repetir el nombre de una hermana; al cambiar, se recalcula la ruta de sus subcategorías.
+func (s *CatalogService) GuardarCategoria(dto db.CategoryDTO) (*db.CategoryDTO, error) {
+	nombre := strings.TrimSpace(dto.Nombre)
+	if nombre == "" || strings.ContainsAny(nombre, ">/") {
+		return nil, fmt.Errorf("nombre de categoría inválido: %q", dto.Nombre)
+	}
+
+	var categoria db.Category
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if dto.ID != 0 {
+			if err := tx.First(&categoria, dto.ID).Error; err != nil {
+				return fmt.Errorf("categoría no encontrada")
+			}
+		}
+		var parentID *uint
+		rutaPadre := ""
+		if dto.ParentID != 0 {
+			if dto.ID != 0 {
+				for _, id := range subcategorias(tx, dto.ID) {
+					if id == dto.ParentID {
+						return fmt.Errorf("una categoría no puede quedar dentro de sí misma")
+					}
+				}
+			}
+			var padre db.Category
+			if err := tx.First(&padre, dto.ParentID).Error; err != nil {
+				return fmt.Errorf("categoría padre no encontrada")
+			}
+			parentID = &padre.ID
+			rutaPadre = padre.Ruta + separadorRuta
+		}
+
+		if id := buscarCategoriaHija(tx, parentID, nombre); id != 0 && id != dto.ID {
+			return fmt.Errorf("ya existe la categoría %s", rutaPadre+nombre)
+		}
+
+		categoria.Nombre = nombre
+		categoria.ParentID = parentID
+		categoria.Ruta = rutaPadre + nombre
+		if err := tx.Save(&categoria).Error; err != nil {
+			return fmt.Errorf("error guardando categoría: %v", err)
+		}
+		return actualizarRutas(tx, categoria)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &db.CategoryDTO{ID: categoria.ID, Nombre: categoria.Nombre, ParentID: dto.ParentID, Ruta: categoria.Ruta}, nil
+}
+
+// EliminarCategoria borra una categoría sin subcategorías; sus productos pasan a la categoría padre.
+func (s *CatalogService) EliminarCategoria(id uint) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var categoria db.Category
+		if err := tx.First(&categoria, id).Error; err != nil {
+			return fmt.Errorf("categoría no encontrada")
+		}
+		var hijas int64
+		tx.Model(&db.Category{}).Where("parent_id = ?", id).Count(&hijas)
+		if hijas > 0 {
+			return fmt.Errorf("la categoría %s tiene subcategorías", categoria.Ruta)
+		}
+		destino := uint(0)
+		if categoria.ParentID != nil {
+			destino = *categoria.ParentID
+		}
+		if err := tx.Model(&db.Product{}).Where("category_id = ?", id).Update("category_id", destino).Error; err != nil {
+			return fmt.Errorf("error reasignando productos: %v", err)
+		}
+		if err := tx.Delete(&categoria).Error; err != nil {
+			return fmt.Errorf("error eliminando categoría: %v", err)
+		}
+		return nil
+	})
+}
+
+// ListarMarcas devuelve las marcas con su número de productos.
+func (s *CatalogService) ListarMarcas() ([]db.BrandDTO, error) {
+	var marcas []db.Brand
+	if err := db.GetDB().Order("nombre").Find(&marcas).Error; err != nil {
+		return nil, fmt.Errorf("error listando marcas: %v", err)
+	}
+	conteo := contarProductosPor("brand_id")
+	result := make([]db.BrandDTO, 0, len(marcas))
+	for _, m := range marcas {
+		result = append(result, db.BrandDTO{ID: m.ID, Nombre: m.Nombre, Productos: conteo[m.ID]})
+	}
+	return result, nil
+}
+
+// GuardarMarca crea o renombra una marca.
+func (s *CatalogService) GuardarMarca(dto db.BrandDTO) (*db.BrandDTO, error) {
+	nombre := strings.TrimSpace(dto.Nombre)
+	if nombre == "" {
+		return nil, fmt.Errorf("la marca necesita un nombre")
+	}
+	var repetida []db.Brand
+	db.GetDB().Where("LOWER(nombre) = LOWER(?) AND id <> ?", nombre, dto.ID).Limit(1).Find(&repetida)
+	if len(repetida) > 0 {
+		return nil, fmt.Errorf("ya existe la marca %s", repetida[0].Nombre)
+	}
+	marca := db.Brand{ID: dto.ID, Nombre: nombre}
+	if dto.ID != 0 {
+		var actual db.Brand
+		if err := db.GetDB().First(&actual, dto.ID).Error; err != nil {
+			return nil, fmt.Errorf("marca no encontrada")
+		}
+		marca.CreatedAt = actual.CreatedAt
+	}
+	if err := db.GetDB().Save(&marca).Error; err != nil {
+		return nil, fmt.Errorf("error guardando marca: %v", err)
+	}
+	return &db.BrandDTO{ID: marca.ID, Nombre: marca.Nombre}, nil
+}
+
+// EliminarMarca borra una marca; sus productos quedan sin marca.
+func (s *CatalogService) EliminarMarca(id uint) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&db.Product{}).Where("brand_id = ?", id).Update("brand_id", 0).Error; err != nil {
+			return fmt.Errorf("error liberando productos: %v", err)
+		}
+		if err := tx.Delete(&db.Brand{}, id).Error; err != nil {
+			return fmt.Errorf("error eliminando marca: %v", err)
+		}
+		return nil
+	})
+}
+
+// ListarTags devuelve las etiquetas en uso, en orden alfabético.
+func (s *CatalogService) ListarTags() []string {
+	tags := []string{}
+	db.GetDB().Model(&db.ProductTag{}).Distinct("tag").Order("tag").Pluck("tag", &tags)
+	return tags
+}
+
+// AsignarClasificacion fija categoría, marca y etiquetas de un producto (0 = sin categoría o
+// marca). Con tags nil las etiquetas no cambian.
+func (s *CatalogService) AsignarClasificacion(sku string, categoryID, brandID uint, tags []string) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var product db.Product
+		if err := tx.First(&product, "sku = ?", sku).Error; err != nil {
+			return fmt.Errorf("producto no encontrado: %s", sku)
+		}
+		if categoryID != 0 {
+			if err := tx.First(&db.Category{}, categoryID).Error; err != nil {
+				return fmt.Errorf("categoría no encontrada")
+			}
+		}
+		if brandID != 0 {
+			if err := tx.First(&db.Brand{}, brandID).Error; err != nil {
+				return fmt.Errorf("marca no encontrada")
+			}
+		}
+		if err := tx.Model(&product).Updates(map[string]interface{}{"category_id": categoryID, "brand_id": brandID}).Error; err != nil {
+			return fmt.Errorf("error clasificando producto: %v", err)
+		}
+		if tags != nil {
+			return guardarTags(tx, sku, tags)
+		}
+		return nil
+	})
+}
+
+// GuardarTags reemplaza las etiquetas de un producto.
+func (s *CatalogService) GuardarTags(sku string, tags []string) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		return guardarTags(tx, sku, tags)
+	})
+}
+
+// categoriaPorRuta devuelve la categoría de una ruta ("Bebidas > Gaseosas" o "Bebidas/Gaseosas"),
+// creando los niveles que falten. Una ruta vacía es 0.
+func categoriaPorRuta(tx *gorm.DB, ruta string) (uint, error) {
+	ruta = strings.ReplaceAll(ruta, "/", ">")
+	var parentID *uint
+	rutaActual := ""
+	for _, parte := range strings.Split(ruta, ">") {
+		nombre := strings.TrimSpace(parte)
+		if nombre == "" {
+			continue
+		}
+		if rutaActual != "" {
+			rutaActual += separadorRuta
+		}
+		rutaActual += nombre
+		id := buscarCategoriaHija(tx, parentID, nombre)
+		if id == 0 {
+			nueva := db.Category{Nombre: nombre, ParentID: parentID, Ruta: rutaActual}
+			if err := tx.Create(&nueva).Error; err != nil {
+				return 0, fmt.Errorf("error creando categoría %s: %v", rutaActual, err)
+			}
+			id = nueva.ID
+		}
+		parentID = &id
+	}
+	if parentID == nil {
+		return 0, nil
+	}
+	return *parentID, nil
+}
+
+// marcaPorNombre devuelve la marca con ese nombre (sin distinguir mayúsculas), creándola si no existe.
+func marcaPorNombre(tx *gorm.DB, nombre string) (uint, error) {
+	nombre = strings.TrimSpace(nombre)
+	if nombre == "" {
+		return 0, nil
+	}
+	var marcas []db.Brand
+	tx.Where("LOWER(nombre) = LOWER(?)", nombre).Limit(1).Find(&marcas)
+	if len(marcas) > 0 {
+		return marcas[0].ID, nil
+	}
+	marca := db.Brand{Nombre: nombre}
+	if err := tx.Create(&marca).Error; err != nil {
+		return 0, fmt.Errorf("error creando marca %s: %v", nombre, err)
+	}
+	return marca.ID, nil
+}
+
+// guardarTags reemplaza las etiquetas de un producto.
+func guardarTags(tx *gorm.DB, sku string, tags []string) error {
+	if err := tx.Where("product_sku = ?", sku).Delete(&db.ProductTag{}).Error; err != nil {
+		return fmt.Errorf("error actualizando etiquetas: %v", err)
+	}
+	for _, tag := range normalizarTags(tags) {
+		if err := tx.Create(&db.ProductTag{ProductSKU: sku, Tag: tag}).Error; err != nil {
+			return fmt.Errorf("error guardando etiqueta %s: %v", tag, err)
+		}
+	}
+	return nil
+}
+
+// normalizarTags pasa las etiquetas a minúsculas sin espacios sobrantes ni repetidas.
+func normalizarTags(tags []string) []string {
+	vistas := map[string]bool{}
+	result := []string{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !vistas[t] {
+			vistas[t] = true
+			result = append(result, t)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func buscarCategoriaHija(tx *gorm.DB, parentID *uint, nombre string) uint {
+	q := tx.Model(&db.Category{}).Where("LOWER(nombre) = LOWER(?)", nombre)
+	if parentID == nil {
+		q = q.Where("parent_id IS NULL")
+	} else {
+		q = q.Where("parent_id = ?", *parentID)
+	}
+	var categorias []db.Category
+	q.Limit(1).Find(&categorias)
+	if len(categorias) == 0 {
+		return 0
+	}
+	return categorias[0].ID
+}
+
+// actualizarRutas recalcula la ruta de las subcategorías después de renombrar o mover una.
+func actualizarRutas(tx *gorm.DB, padre db.Category) error {
+	var hijas []db.Category
+	tx.Where("parent_id = ?", padre.ID).Find(&hijas)
+	for _, h := range hijas {
+		h.Ruta = padre.Ruta + separadorRuta + h.Nombre
+		if err := tx.Model(&h).Update("ruta", h.Ruta).Error; err != nil {
+			return fmt.Errorf("error actualizando ruta de %s: %v", h.Nombre, err)
+		}
+		if err := actualizarRutas(tx, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subcategorias devuelve la categoría y todas sus descendientes.
+func subcategorias(tx *gorm.DB, id uint) []uint {
+	result := []uint{id}
+	pendientes := []uint{id}
+	for len(pendientes) > 0 {
+		var hijas []uint
+		tx.Model(&db.Category{}).Where("parent_id IN ?", pendientes).Pluck("id", &hijas)
+		result = append(result, hijas...)
+		pendientes = hijas
+	}
+	return result
+}
+
+// ancestrosCategoria devuelve la categoría y sus ancestros hasta la raíz.
+func ancestrosCategoria(tx *gorm.DB, id uint) []uint {
+	result := []uint{}
+	for id != 0 && len(result) < 50 {
+		result = append(result, id)
+		var categorias []db.Category
+		tx.Where("id = ?", id).Limit(1).Find(&categorias)
+		if len(categorias) == 0 || categorias[0].ParentID == nil {
+			break
+		}
+		id = *categorias[0].ParentID
+	}
+	return result
+}
+
+// rutasCategorias y nombresMarcas indexan por ID para armar DTOs y reportes sin joins.
+func rutasCategorias(tx *gorm.DB) map[uint]string {
+	var categorias []db.Category
+	tx.Find(&categorias)
+	rutas := make(map[uint]string, len(categorias))
+	for _, c := range categorias {
+		rutas[c.ID] = c.Ruta
+	}
+	return rutas
+}
+
+func nombresMarcas(tx *gorm.DB) map[uint]string {
+	var marcas []db.Brand
+	tx.Find(&marcas)
+	nombres := make(map[uint]string, len(marcas))
+	for _, m := range marcas {
+		nombres[m.ID] = m.Nombre
+	}
+	return nombres
+}
+
+// TagsPorProducto agrupa las etiquetas por SKU, para listados.
+func (s *CatalogService) TagsPorProducto() map[string][]string {
+	var tags []db.ProductTag
+	db.GetDB().Order("product_sku, tag").Find(&tags)
+	result := map[string][]string{}
+	for _, t := range tags {
+		result[t.ProductSKU] = append(result[t.ProductSKU], t.Tag)
+	}
+	return result
+}
+
+// Clasificar completa categoría, marca y etiquetas de los DTOs de productos.
+func (s *CatalogService) Clasificar(dtos []db.ProductDTO) {
+	tx := db.GetDB()
+	rutas, marcas, tags := rutasCategorias(tx), nombresMarcas(tx), s.TagsPorProducto()
+	for i := range dtos {
+		dtos[i].Categoria = rutas[dtos[i].CategoryID]
+		dtos[i].Marca = marcas[dtos[i].BrandID]
+		dtos[i].Tags = tags[dtos[i].SKU]
+		if dtos[i].Tags == nil {
+			dtos[i].Tags = []string{}
+		}
+	}
+}
+
+func contarProductosPor(columna string) map[uint]int64 {
+	type fila struct {
+		ID    uint
+		Total int64
+	}
+	var filas []fila
+	db.GetDB().Model(&db.Product{}).Select(columna + " as id, COUNT(*) as total").Group(columna).Scan(&filas)
+	conteo := make(map[uint]int64, len(filas))
+	for _, f := range filas {
+		conteo[f.ID] = f.Total
+	}
+	return conteo
+}
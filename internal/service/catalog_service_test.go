@@ -0,0 +1,141 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+)
+
+func TestCatalogService_ArbolYBusqueda(t *testing.T) {
+	database := setupTestDB()
+	svc := NewCatalogService()
+
+	csv := "SKU,Nombre,Precio,Stock,CodImp,IVA,Barcode,Aux,Min,Vence,Ubicacion,Unidad,Categoria,Marca,Tags\n" +
+		"COLA,Cola 1L,1,10,2,15,C1,,,,,,Bebidas > Gaseosas,Fizz,retornable;Fria\n" +
+		"AGUA,Agua 500ml,0.5,20,2,15,A1,,,,,,Bebidas/Aguas,Fizz,fria\n" +
+		"PAN,Pan,0.2,50,2,0,P1,,,,,,Panadería,,\n"
+	if _, err := NewProductService().ImportProductsFromCSV(strings.NewReader(csv)); err != nil {
+		t.Fatal(err)
+	}
+
+	categorias, _ := svc.ListarCategorias()
+	if len(categorias) != 4 || categorias[0].Ruta != "Bebidas" || categorias[1].Ruta != "Bebidas > Aguas" {
+		t.Fatalf("Árbol mal importado: %+v", categorias)
+	}
+	marcas, _ := svc.ListarMarcas()
+	if len(marcas) != 1 || marcas[0].Productos != 2 {
+		t.Errorf("Marcas mal importadas: %+v", marcas)
+	}
+	if tags := svc.ListarTags(); len(tags) != 2 || tags[0] != "fria" {
+		t.Errorf("Etiquetas no normalizadas: %v", tags)
+	}
+
+	bebidas := categorias[0]
+	gaseosas := categorias[2]
+	if _, err := svc.GuardarCategoria(db.CategoryDTO{ID: bebidas.ID, Nombre: "Bebidas", ParentID: gaseosas.ID}); err == nil {
+		t.Error("Una categoría no puede moverse dentro de su descendiente")
+	}
+	if _, err := svc.GuardarCategoria(db.CategoryDTO{Nombre: "aguas", ParentID: bebidas.ID}); err == nil {
+		t.Error("No debe repetir el nombre de una hermana")
+	}
+	if _, err := svc.GuardarCategoria(db.CategoryDTO{ID: bebidas.ID, Nombre: "Líquidos"}); err != nil {
+		t.Fatal(err)
+	}
+	var gas db.Category
+	database.First(&gas, gaseosas.ID)
+	if gas.Ruta != "Líquidos > Gaseosas" {
+		t.Errorf("Ruta de la subcategoría no actualizada: %s", gas.Ruta)
+	}
+	if err := svc.EliminarCategoria(bebidas.ID); err == nil {
+		t.Error("No debe eliminar una categoría con subcategorías")
+	}
+
+	// El filtro por categoría incluye las subcategorías y las etiquetas deben estar todas
+	search := NewSearchService()
+	res, _ := search.FuzzySearchProducts("", db.ProductFilterDTO{CategoryID: bebidas.ID})
+	if len(res) != 2 {
+		t.Errorf("Esperaba 2 bebidas, obtuve %+v", res)
+	}
+	res, _ = search.FuzzySearchProducts("", db.ProductFilterDTO{Tags: []string{"FRIA", "retornable"}})
+	if len(res) != 1 || res[0].SKU != "COLA" || res[0].Marca != "Fizz" || res[0].Categoria != "Líquidos > Gaseosas" {
+		t.Errorf("Filtro por etiquetas incorrecto: %+v", res)
+	}
+	res, _ = search.FuzzySearchProducts("fizz", db.ProductFilterDTO{})
+	if len(res) != 2 {
+		t.Errorf("La búsqueda debe considerar la marca: %+v", res)
+	}
+
+	// Al eliminar una hoja, sus productos suben a la categoría padre
+	if err := svc.EliminarCategoria(gaseosas.ID); err != nil {
+		t.Fatal(err)
+	}
+	var cola db.Product
+	database.First(&cola, "sku = ?", "COLA")
+	if cola.CategoryID != bebidas.ID {
+		t.Errorf("El producto debió pasar a la categoría padre: %d", cola.CategoryID)
+	}
+}
+
+func TestCatalogService_ReportesYPromociones(t *testing.T) {
+	database := setupTestDB()
+	svc := NewCatalogService()
+	database.Create(&db.Product{SKU: "COLA", Name: "Cola", Barcode: "COLA", Price: 1, Cost: 0.5, Stock: 10})
+	database.Create(&db.Product{SKU: "PAN", Name: "Pan", Barcode: "PAN", Price: 2, Cost: 1, Stock: 4})
+	bebidas, _ := categoriaPorRuta(database, "Bebidas")
+	gaseosas, _ := categoriaPorRuta(database, "Bebidas > Gaseosas")
+	fizz, _ := marcaPorNombre(database, "Fizz")
+	if err := svc.AsignarClasificacion("COLA", gaseosas, fizz, []string{"fria"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Una promoción por categoría padre alcanza a las subcategorías
+	promo, err := NewPromotionService().GuardarPromocion(db.PromotionDTO{Nombre: "Bebidas 10%", Tipo: PromoPorcentaje, Porcentaje: 10, Categorias: []uint{bebidas}, Marcas: []uint{fizz}, Activa: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(promo.Categorias) != 1 || promo.Categorias[0] != bebidas {
+		t.Errorf("Categorías de la promoción no guardadas: %+v", promo)
+	}
+	items := []db.InvoiceItem{{Codigo: "COLA", Cantidad: 2, Precio: 1}, {Codigo: "PAN", Cantidad: 1, Precio: 2}}
+	aplicarPromociones(database, "9999999999999", items, time.Now())
+	if items[0].Descuento != 0.2 || items[1].Descuento != 0 {
+		t.Errorf("Promoción por categoría mal aplicada: %+v", items)
+	}
+
+	database.Create(&db.Factura{ClaveAcceso: "CLAVE_CAT", Secuencial: "000000001", FechaEmision: time.Now(), EstadoSRI: "AUTORIZADO", Total: 4})
+	database.Create(&[]db.FacturaItem{
+		{FacturaClave: "CLAVE_CAT", ProductoSKU: "COLA", Nombre: "Cola", Cantidad: 2, PrecioUnitario: 1, Subtotal: 2, CostoUnitario: 0.5},
+		{FacturaClave: "CLAVE_CAT", ProductoSKU: "PAN", Nombre: "Pan", Cantidad: 1, PrecioUnitario: 2, Subtotal: 2, CostoUnitario: 1},
+	})
+
+	reports := NewReportService()
+	margenes, err := reports.GetMargins(time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	grupos := map[string]MargenGrupo{}
+	for _, g := range append(margenes.Categorias, margenes.Marcas...) {
+		grupos[g.Grupo] = g
+	}
+	if grupos["Bebidas > Gaseosas"].Margen != 1 || grupos[SinCategoria].Venta != 2 || grupos["Fizz"].Cantidad != 2 || grupos[SinMarca].Costo != 1 {
+		t.Errorf("Márgenes por clasificación incorrectos: %+v", grupos)
+	}
+
+	top, _ := reports.GetTopCategories(5)
+	if len(top) != 2 {
+		t.Errorf("Top de categorías incorrecto: %+v", top)
+	}
+
+	stock, err := reports.GetStockByClassification()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stock.Valor != 9 || stock.Categorias[0].Grupo != "Bebidas > Gaseosas" || stock.Categorias[0].Valor != 5 {
+		t.Errorf("Stock por clasificación incorrecto: %+v", stock)
+	}
+	if _, err := reports.GenerateMasterReportExcel(); err != nil {
+		t.Error(err)
+	}
+}
@@ -148,3 +148,48 @@ func (s *ChartService) GenerateClientsPie() (string, error) {
 	pie.Render(&buf)
 	return buf.String(), nil
 }
+
+// GenerateCategoriesPie genera una gráfica de pastel con las categorías más vendidas
+func (s *ChartService) GenerateCategoriesPie() (string, error) {
+	results, err := NewReportService().GetTopCategories(6)
+	if err != nil || len(results) == 0 {
+		return "", err
+	}
+
+	pie := charts.NewPie()
+	pie.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:      "Ventas por Categoría",
+			Left:       "center",
+			TitleStyle: &opts.TextStyle{Color: "#eee", FontSize: 16},
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Theme:           types.ThemeMacarons,
+			Height:          "300px",
+			BackgroundColor: "transparent",
+		}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+	)
+
+	items := make([]opts.PieData, 0)
+	for _, r := range results {
+		items = append(items, opts.PieData{Name: r.Grupo, Value: r.Total})
+	}
+
+	pie.AddSeries("Ventas", items).
+		SetSeriesOptions(
+			charts.WithLabelOpts(opts.Label{
+				Show:      opts.Bool(true),
+				Formatter: "{b}: {d}%",
+				Color:     "#fff",
+			}),
+			charts.WithPieChartOpts(opts.PieChart{
+				Radius: []string{"30%", "60%"},
+				Center: []string{"50%", "55%"},
+			}),
+		)
+
+	var buf bytes.Buffer
+	pie.Render(&buf)
+	return buf.String(), nil
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/pdf"
+	"kushkiv2/pkg/util"
+
+	"gorm.io/gorm"
+)
+
+// Estados de una toma física.
+const (
+	ConteoAbierto       = "ABIERTA"
+	ConteoContabilizado = "CONTABILIZADA"
+	ConteoCancelado     = "CANCELADA"
+)
+
+type CountService struct{}
+
+func NewCountService() *CountService {
+	return &CountService{}
+}
+
+// AbrirConteo inicia una toma física en una bodega ("" = principal). Con seccion solo se
+// consideran los productos cuya ubicación en la bodega empieza con ese texto.
+func (s *CountService) AbrirConteo(bodega, seccion, nota, usuario string) (*db.CountSessionDTO, error) {
+	seccion = strings.TrimSpace(seccion)
+	var sesion db.CountSession
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		codigo, err := resolverBodega(tx, bodega)
+		if err != nil {
+			return err
+		}
+		var abiertas int64
+		tx.Model(&db.CountSession{}).Where("bodega = ? AND seccion = ? AND estado = ?", codigo, seccion, ConteoAbierto).Count(&abiertas)
+		if abiertas > 0 {
+			return fmt.Errorf("ya hay una toma física abierta para esa bodega y sección")
+		}
+
+		// Los productos sin desglose por bodega deben tenerlo para calcular el alcance
+		var products []db.Product
+		tx.Where("stock <> 0 AND sku NOT IN (?)", tx.Model(&db.ProductStock{}).Select("product_sku")).Find(&products)
+		for i := range products {
+			if err := inicializarStockBodegas(tx, &products[i]); err != nil {
+				return err
+			}
+		}
+
+		sesion = db.CountSession{Bodega: codigo, Seccion: seccion, Estado: ConteoAbierto, Nota: nota, Usuario: usuario}
+		if err := tx.Create(&sesion).Error; err != nil {
+			return fmt.Errorf("error abriendo toma física: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRevision(sesion.ID)
+}
+
+// ListarConteos devuelve las tomas físicas ("" = todos los estados), sin el detalle de líneas.
+func (s *CountService) ListarConteos(estado string) ([]db.CountSessionDTO, error) {
+	q := db.GetDB().Order("created_at desc")
+	if estado != "" {
+		q = q.Where("estado = ?", estado)
+	}
+	var sesiones []db.CountSession
+	if err := q.Find(&sesiones).Error; err != nil {
+		return nil, fmt.Errorf("error listando tomas físicas: %v", err)
+	}
+	result := make([]db.CountSessionDTO, 0, len(sesiones))
+	for _, ses := range sesiones {
+		dto := mapCountSessionToDTO(ses)
+		db.GetDB().Model(&db.CountEntry{}).Where("session_id = ?", ses.ID).
+			Select("COUNT(DISTINCT product_sku)").Scan(&dto.Contados)
+		result = append(result, dto)
+	}
+	return result, nil
+}
+
+// RegistrarLectura suma una lectura (código o código de barras) a la toma física. Varios dispositivos
+// pueden contar en paralelo: cada lectura se guarda por separado y se acumula. Una cantidad negativa
+// corrige lecturas anteriores; el código de una presentación (caja) cuenta su factor en unidades
+// base. Devuelve el producto y su total contado.
+func (s *CountService) RegistrarLectura(sessionID uint, codigo string, cantidad float64, dispositivo string) (*db.Product, float64, error) {
+	if cantidad == 0 {
+		return nil, 0, fmt.Errorf("la cantidad no puede ser cero")
+	}
+	var sesion db.CountSession
+	if err := db.GetDB().First(&sesion, sessionID).Error; err != nil {
+		return nil, 0, fmt.Errorf("toma física no encontrada")
+	}
+	if sesion.Estado != ConteoAbierto {
+		return nil, 0, fmt.Errorf("la toma física está %s", strings.ToLower(sesion.Estado))
+	}
+	product, unidad, err := buscarPorCodigo(db.GetDB(), codigo)
+	if err != nil {
+		return nil, 0, err
+	}
+	if product.EsKit {
+		return nil, 0, fmt.Errorf("%s es un kit: cuente sus componentes", product.SKU)
+	}
+	if product.EsServicio {
+		return nil, 0, fmt.Errorf("%s es un servicio: no se cuenta", product.SKU)
+	}
+	if unidad != nil {
+		cantidad *= unidad.Factor
+	}
+	cantidad = redondearCantidad(cantidad)
+
+	var total float64
+	err = db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var actual float64
+		tx.Model(&db.CountEntry{}).Where("session_id = ? AND product_sku = ?", sessionID, product.SKU).
+			Select("COALESCE(SUM(cantidad), 0)").Scan(&actual)
+		actual = redondearCantidad(actual)
+		if actual+cantidad < 0 {
+			return fmt.Errorf("el conteo de %s no puede quedar negativo (actual %g)", product.SKU, actual)
+		}
+		// La primera lectura fija el stock del sistema contra el que se compara lo contado
+		var fotos int64
+		tx.Model(&db.CountSnapshot{}).Where("session_id = ? AND product_sku = ?", sessionID, product.SKU).Count(&fotos)
+		if fotos == 0 {
+			sistema, err := stockEnBodega(tx, product, sesion.Bodega)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&db.CountSnapshot{SessionID: sessionID, ProductSKU: product.SKU, Sistema: sistema}).Error; err != nil {
+				return fmt.Errorf("error registrando lectura: %v", err)
+			}
+		}
+		entrada := db.CountEntry{SessionID: sessionID, ProductSKU: product.SKU, Cantidad: cantidad, Dispositivo: dispositivo}
+		if err := tx.Create(&entrada).Error; err != nil {
+			return fmt.Errorf("error registrando lectura: %v", err)
+		}
+		total = redondearCantidad(actual + cantidad)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return product, total, nil
+}
+
+// GetRevision devuelve la toma física con la diferencia de cada producto contra el stock de la bodega.
+// En una toma abierta el stock del sistema es el de la primera lectura del producto (el actual si aún
+// no se cuenta); en una contabilizada, el que se usó al cierre.
+func (s *CountService) GetRevision(id uint) (*db.CountSessionDTO, error) {
+	var sesion db.CountSession
+	if err := db.GetDB().First(&sesion, id).Error; err != nil {
+		return nil, fmt.Errorf("toma física no encontrada")
+	}
+	dto := mapCountSessionToDTO(sesion)
+
+	if sesion.Estado == ConteoContabilizado {
+		var lineas []db.CountLine
+		db.GetDB().Where("session_id = ?", id).Find(&lineas)
+		lecturas := lecturasConteo(db.GetDB(), id)
+		for _, l := range lineas {
+			dto.Lineas = append(dto.Lineas, db.CountLineDTO{
+				SKU:           l.ProductSKU,
+				Sistema:       l.Sistema,
+				Contado:       l.Contado,
+				Diferencia:    l.Diferencia,
+				NoContado:     l.NoContado,
+				Lecturas:      lecturas[l.ProductSKU].Lecturas,
+				CostoUnitario: l.CostoUnitario,
+			})
+		}
+		completarLineasConteo(db.GetDB(), sesion.Bodega, dto.Lineas)
+	} else {
+		dto.Lineas = lineasConteo(db.GetDB(), &sesion)
+	}
+	totalizarConteo(&dto)
+
+	var bodega db.Warehouse
+	if db.GetDB().Limit(1).Find(&bodega, "codigo = ?", sesion.Bodega); bodega.Nombre != "" {
+		dto.BodegaNombre = bodega.Nombre
+	}
+	return &dto, nil
+}
+
+// ContabilizarConteo cierra la toma física y registra en el kardex un AJUSTE por cada diferencia.
+// Lo contado se compara con el stock de la primera lectura, así las ventas y compras hechas durante
+// la toma no se ajustan dos veces. Los productos del alcance sin lecturas se ajustan a cero solo con
+// ceroNoContados; si no, quedan sin cambios. Las faltantes de productos con lotes se descuentan de
+// sus lotes (FEFO).
+func (s *CountService) ContabilizarConteo(id uint, ceroNoContados bool, usuario string) (*db.CountSessionDTO, error) {
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var sesion db.CountSession
+		if err := tx.First(&sesion, id).Error; err != nil {
+			return fmt.Errorf("toma física no encontrada")
+		}
+		if sesion.Estado != ConteoAbierto {
+			return fmt.Errorf("la toma física ya está %s", strings.ToLower(sesion.Estado))
+		}
+
+		documento := documentoConteo(id)
+		fotos := fotosConteo(tx, id)
+		for _, l := range lineasConteo(tx, &sesion) {
+			var product db.Product
+			if err := tx.First(&product, "sku = ?", l.SKU).Error; err != nil {
+				continue
+			}
+			sistema, ok := fotos[l.SKU]
+			if !ok {
+				var err error
+				if sistema, err = stockEnBodega(tx, &product, sesion.Bodega); err != nil {
+					return err
+				}
+			}
+			linea := db.CountLine{SessionID: id, ProductSKU: l.SKU, Sistema: sistema, Contado: l.Contado, NoContado: l.NoContado, CostoUnitario: product.Cost}
+			if !l.NoContado || ceroNoContados {
+				linea.Diferencia = redondearCantidad(linea.Contado - sistema)
+			}
+
+			if linea.Diferencia != 0 {
+				mov := &db.StockMovement{
+					ProductSKU: l.SKU,
+					Tipo:       MovAjuste,
+					Cantidad:   linea.Diferencia,
+					Documento:  documento,
+					Usuario:    usuario,
+					Nota:       "Toma física",
+					Bodega:     sesion.Bodega,
+				}
+				if err := registrarMovimiento(tx, mov); err != nil {
+					return err
+				}
+				if linea.Diferencia < 0 {
+					if _, err := consumirLotes(tx, documento, l.SKU, sesion.Bodega, -linea.Diferencia, true); err != nil {
+						return err
+					}
+				}
+			}
+			if err := tx.Create(&linea).Error; err != nil {
+				return fmt.Errorf("error guardando resultado del conteo: %v", err)
+			}
+		}
+
+		ahora := time.Now()
+		return tx.Model(&sesion).Updates(map[string]interface{}{"estado": ConteoContabilizado, "cerrada_at": &ahora}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRevision(id)
+}
+
+// CancelarConteo descarta una toma física abierta sin mover inventario.
+func (s *CountService) CancelarConteo(id uint) error {
+	res := db.GetDB().Model(&db.CountSession{}).Where("id = ? AND estado = ?", id, ConteoAbierto).
+		Updates(map[string]interface{}{"estado": ConteoCancelado, "cerrada_at": time.Now()})
+	if res.Error != nil {
+		return fmt.Errorf("error cancelando toma física: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("la toma física no existe o ya fue cerrada")
+	}
+	return nil
+}
+
+// GenerarReportePDF genera el reporte de diferencias de la toma física para firmar.
+func (s *CountService) GenerarReportePDF(id uint) ([]byte, error) {
+	conteo, err := s.GetRevision(id)
+	if err != nil {
+		return nil, err
+	}
+	var config db.EmisorConfig
+	if err := db.GetDB().First(&config).Error; err != nil {
+		return nil, fmt.Errorf("error obteniendo configuración: %v", err)
+	}
+	return pdf.GenerarReporteConteoPDF(*conteo, config)
+}
+
+// documentoConteo es la referencia de la toma física en el kardex.
+func documentoConteo(id uint) string {
+	return fmt.Sprintf("CONTEO %d", id)
+}
+
+type lecturaConteo struct {
+	ProductSKU string
+	Total      float64
+	Lecturas   int
+}
+
+func lecturasConteo(tx *gorm.DB, id uint) map[string]lecturaConteo {
+	var filas []lecturaConteo
+	tx.Model(&db.CountEntry{}).Select("product_sku, SUM(cantidad) as total, COUNT(*) as lecturas").
+		Where("session_id = ?", id).Group("product_sku").Scan(&filas)
+	result := make(map[string]lecturaConteo, len(filas))
+	for _, f := range filas {
+		result[f.ProductSKU] = f
+	}
+	return result
+}
+
+// fotosConteo devuelve el stock del sistema de cada producto al momento de su primera lectura.
+func fotosConteo(tx *gorm.DB, id uint) map[string]float64 {
+	var fotos []db.CountSnapshot
+	tx.Where("session_id = ?", id).Find(&fotos)
+	result := make(map[string]float64, len(fotos))
+	for _, f := range fotos {
+		result[f.ProductSKU] = f.Sistema
+	}
+	return result
+}
+
+// lineasConteo cruza las lecturas con el stock de la bodega: incluye los productos contados y los
+// del alcance (bodega o sección) con stock distinto de cero que nadie contó.
+func lineasConteo(tx *gorm.DB, sesion *db.CountSession) []db.CountLineDTO {
+	lecturas := lecturasConteo(tx, sesion.ID)
+
+	q := tx.Where("bodega = ? AND stock <> 0", sesion.Bodega)
+	if sesion.Seccion != "" {
+		q = q.Where("LOWER(ubicacion) LIKE ?", strings.ToLower(sesion.Seccion)+"%")
+	}
+	var enAlcance []db.ProductStock
+	q.Find(&enAlcance)
+
+	contados := make([]string, 0, len(lecturas))
+	for sku := range lecturas {
+		contados = append(contados, sku)
+	}
+	var deContados []db.ProductStock
+	if len(contados) > 0 {
+		tx.Where("bodega = ? AND product_sku IN ?", sesion.Bodega, contados).Find(&deContados)
+	}
+	stocks := map[string]db.ProductStock{}
+	for _, ps := range append(enAlcance, deContados...) {
+		stocks[ps.ProductSKU] = ps
+	}
+
+	lineas := make([]db.CountLineDTO, 0, len(stocks)+len(lecturas))
+	vistos := map[string]bool{}
+	for _, ps := range enAlcance {
+		vistos[ps.ProductSKU] = true
+		l := lecturas[ps.ProductSKU]
+		lineas = append(lineas, db.CountLineDTO{SKU: ps.ProductSKU, Sistema: ps.Stock, Contado: l.Total, Lecturas: l.Lecturas, NoContado: l.Lecturas == 0})
+	}
+	for _, sku := range contados {
+		if vistos[sku] {
+			continue
+		}
+		l := lecturas[sku]
+		lineas = append(lineas, db.CountLineDTO{SKU: sku, Sistema: stocks[sku].Stock, Contado: l.Total, Lecturas: l.Lecturas})
+	}
+	fotos := fotosConteo(tx, sesion.ID)
+	for i := range lineas {
+		if sistema, ok := fotos[lineas[i].SKU]; ok {
+			lineas[i].Sistema = sistema
+		}
+		if !lineas[i].NoContado {
+			lineas[i].Diferencia = redondearCantidad(lineas[i].Contado - lineas[i].Sistema)
+		}
+	}
+	completarLineasConteo(tx, sesion.Bodega, lineas)
+	return lineas
+}
+
+// completarLineasConteo agrega nombre, ubicación y costo, valoriza las diferencias y ordena por ubicación.
+func completarLineasConteo(tx *gorm.DB, bodega string, lineas []db.CountLineDTO) {
+	skus := make([]string, 0, len(lineas))
+	for _, l := range lineas {
+		skus = append(skus, l.SKU)
+	}
+	var products []db.Product
+	var stocks []db.ProductStock
+	if len(skus) > 0 {
+		tx.Where("sku IN ?", skus).Find(&products)
+		tx.Where("bodega = ? AND product_sku IN ?", bodega, skus).Find(&stocks)
+	}
+	porSKU := map[string]db.Product{}
+	for _, p := range products {
+		porSKU[p.SKU] = p
+	}
+	ubicaciones := map[string]string{}
+	for _, ps := range stocks {
+		ubicaciones[ps.ProductSKU] = ps.Ubicacion
+	}
+
+	for i := range lineas {
+		l := &lineas[i]
+		p := porSKU[l.SKU]
+		l.Nombre = p.Name
+		l.Ubicacion = ubicaciones[l.SKU]
+		if l.CostoUnitario == 0 {
+			l.CostoUnitario = p.Cost
+		}
+		// util.Round solo redondea bien valores positivos
+		valor := util.Round(math.Abs(l.Diferencia)*l.CostoUnitario, 2)
+		if l.Diferencia < 0 {
+			valor = -valor
+		}
+		l.ValorDiferencia = valor
+	}
+	sort.SliceStable(lineas, func(i, j int) bool {
+		if lineas[i].Ubicacion != lineas[j].Ubicacion {
+			return lineas[i].Ubicacion < lineas[j].Ubicacion
+		}
+		return lineas[i].Nombre < lineas[j].Nombre
+	})
+}
+
+func totalizarConteo(dto *db.CountSessionDTO) {
+	for _, l := range dto.Lineas {
+		if !l.NoContado {
+			dto.Contados++
+		}
+		switch {
+		case l.Diferencia > 0:
+			dto.Sobrantes++
+			dto.ValorSobrante += l.ValorDiferencia
+		case l.Diferencia < 0:
+			dto.Faltantes++
+			dto.ValorFaltante += -l.ValorDiferencia
+		}
+	}
+	dto.ValorSobrante = util.Round(dto.ValorSobrante, 2)
+	dto.ValorFaltante = util.Round(dto.ValorFaltante, 2)
+}
+
+func mapCountSessionToDTO(s db.CountSession) db.CountSessionDTO {
+	dto := db.CountSessionDTO{
+		ID:      s.ID,
+		Bodega:  s.Bodega,
+		Seccion: s.Seccion,
+		Estado:  s.Estado,
+		Nota:    s.Nota,
+		Usuario: s.Usuario,
+		Fecha:   s.CreatedAt.Format("2006-01-02 15:04"),
+		Lineas:  []db.CountLineDTO{},
+	}
+	if s.CerradaAt != nil {
+		dto.FechaCierre = s.CerradaAt.Format("2006-01-02 15:04")
+	}
+	return dto
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"testing"
+
+	"kushkiv2/internal/db"
+)
+
+func TestCountService_TomaFisica(t *testing.T) {
+	database := setupTestDB()
+	svc := NewCountService()
+	database.Create(&db.Product{SKU: "A", Name: "Producto A", Barcode: "7861", Stock: 10, Cost: 2})
+	database.Create(&db.Product{SKU: "B", Name: "Producto B", Barcode: "7862", Stock: 5, Cost: 1})
+	database.Create(&db.Product{SKU: "C", Name: "Producto C", Barcode: "7863", Stock: 4, Cost: 3})
+
+	sesion, err := svc.AbrirConteo("", "", "Cierre anual", "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sesion.Bodega != "PRINCIPAL" || len(sesion.Lineas) != 3 {
+		t.Fatalf("Toma física mal abierta: %+v", sesion)
+	}
+	if _, err := svc.AbrirConteo("", "", "", "tester"); err == nil {
+		t.Error("No debe permitir dos tomas abiertas de la misma bodega y sección")
+	}
+
+	// Dos dispositivos cuentan en paralelo; las lecturas se acumulan
+	svc.RegistrarLectura(sesion.ID, "7861", 5, "movil-1")
+	svc.RegistrarLectura(sesion.ID, "A", 3, "movil-2")
+	if _, total, err := svc.RegistrarLectura(sesion.ID, "7862", 7, "movil-2"); err != nil || total != 7 {
+		t.Fatalf("Lectura de B incorrecta: %g %v", total, err)
+	}
+	if _, _, err := svc.RegistrarLectura(sesion.ID, "B", -8, "movil-1"); err == nil {
+		t.Error("El conteo no debe quedar negativo")
+	}
+	if _, _, err := svc.RegistrarLectura(sesion.ID, "NOEXISTE", 1, "movil-1"); err == nil {
+		t.Error("Debe rechazar productos desconocidos")
+	}
+
+	revision, err := svc.GetRevision(sesion.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	porSKU := map[string]db.CountLineDTO{}
+	for _, l := range revision.Lineas {
+		porSKU[l.SKU] = l
+	}
+	if a := porSKU["A"]; a.Contado != 8 || a.Diferencia != -2 || a.Lecturas != 2 || a.ValorDiferencia != -4 {
+		t.Errorf("Línea A incorrecta: %+v", a)
+	}
+	if c := porSKU["C"]; !c.NoContado || c.Diferencia != 0 {
+		t.Errorf("C debería figurar sin contar: %+v", c)
+	}
+	if revision.Sobrantes != 1 || revision.Faltantes != 1 || revision.ValorSobrante != 2 || revision.ValorFaltante != 4 {
+		t.Errorf("Totales incorrectos: %+v", revision)
+	}
+
+	posted, err := svc.ContabilizarConteo(sesion.ID, true, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posted.Estado != ConteoContabilizado || posted.FechaCierre == "" || posted.Faltantes != 2 {
+		t.Errorf("Toma mal contabilizada: %+v", posted)
+	}
+	for sku, esperado := range map[string]float64{"A": 8, "B": 7, "C": 0} {
+		if got := stockDe(t, sku); got != esperado {
+			t.Errorf("Stock de %s esperado %g, obtuve %g", sku, esperado, got)
+		}
+	}
+	var movs []db.StockMovement
+	database.Where("documento = ?", documentoConteo(sesion.ID)).Find(&movs)
+	if len(movs) != 3 {
+		t.Errorf("Se esperaban 3 ajustes en el kardex, obtuve %d", len(movs))
+	}
+
+	if _, _, err := svc.RegistrarLectura(sesion.ID, "A", 1, "movil-1"); err == nil {
+		t.Error("No debe aceptar lecturas en una toma cerrada")
+	}
+	if _, err := svc.ContabilizarConteo(sesion.ID, true, "tester"); err == nil {
+		t.Error("No debe contabilizar dos veces")
+	}
+}
+
+func TestCountService_SeccionSinCeroNoContados(t *testing.T) {
+	database := setupTestDB()
+	svc := NewCountService()
+	database.Create(&db.Product{SKU: "A", Name: "Producto A", Barcode: "A", Stock: 10})
+	database.Create(&db.Product{SKU: "B", Name: "Producto B", Barcode: "B", Stock: 5})
+	ws := NewWarehouseService()
+	ws.AsignarUbicacion("A", "", "PASILLO 1-A")
+	ws.AsignarUbicacion("B", "", "PASILLO 2-C")
+
+	sesion, err := svc.AbrirConteo("", "pasillo 1", "", "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sesion.Lineas) != 1 || sesion.Lineas[0].SKU != "A" {
+		t.Fatalf("La sección debería incluir solo A: %+v", sesion.Lineas)
+	}
+	if _, err := svc.ContabilizarConteo(sesion.ID, false, "tester"); err != nil {
+		t.Fatal(err)
+	}
+	if got := stockDe(t, "A"); got != 10 {
+		t.Errorf("Sin lecturas y sin ceroNoContados el stock no debe cambiar: %g", got)
+	}
+
+	otra, _ := svc.AbrirConteo("", "pasillo 2", "", "tester")
+	if err := svc.CancelarConteo(otra.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.RegistrarLectura(otra.ID, "B", 1, "movil-1"); err == nil {
+		t.Error("No debe aceptar lecturas en una toma cancelada")
+	}
+}
+
+func TestCountService_MovimientosDuranteLaToma(t *testing.T) {
+	database := setupTestDB()
+	svc := NewCountService()
+	inv := NewInventoryService()
+	database.Create(&db.Product{SKU: "A", Name: "Producto A", Barcode: "A", Stock: 10})
+	database.Create(&db.Product{SKU: "B", Name: "Producto B", Barcode: "B", Stock: 5})
+
+	sesion, err := svc.AbrirConteo("", "", "", "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.RegistrarLectura(sesion.ID, "A", 10, "movil-1")
+	svc.RegistrarLectura(sesion.ID, "B", 4, "movil-1")
+
+	// Una venta y una compra entre la lectura y la contabilización ya están en el kardex
+	if _, err := inv.AjustarStock("A", "", -3, false, "caja", "Venta"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inv.AjustarStock("B", "", 2, false, "bodega", "Compra"); err != nil {
+		t.Fatal(err)
+	}
+
+	posted, err := svc.ContabilizarConteo(sesion.ID, false, "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posted.Sobrantes != 0 || posted.Faltantes != 1 {
+		t.Errorf("Solo B tenía diferencia al contarse: %+v", posted.Lineas)
+	}
+	for sku, esperado := range map[string]float64{"A": 7, "B": 6} {
+		if got := stockDe(t, sku); got != esperado {
+			t.Errorf("Stock de %s esperado %g, obtuve %g", sku, esperado, got)
+		}
+	}
+	var ajustes int64
+	database.Model(&db.StockMovement{}).Where("documento = ?", documentoConteo(sesion.ID)).Count(&ajustes)
+	if ajustes != 1 {
+		t.Errorf("Se esperaba un solo ajuste (B), obtuve %d", ajustes)
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/logger"
+)
+
+// DraftMaxAge es el tiempo sin modificaciones tras el cual un borrador se considera abandonado.
+const DraftMaxAge = 30 * 24 * time.Hour
+
+type DraftService struct{}
+
+func NewDraftService() *DraftService {
+	return &DraftService{}
+}
+
+// terminalLocal identifica el equipo actual cuando el frontend no envía terminal.
+func terminalLocal() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "local"
+	}
+	return host
+}
+
+// GuardarBorrador crea o actualiza (si trae ID) un borrador de factura.
+// Está pensado para el autoguardado del frontend: no valida la factura, solo la persiste.
+func (s *DraftService) GuardarBorrador(dto db.InvoiceDraftDTO) (*db.InvoiceDraftDTO, error) {
+	data, err := json.Marshal(dto.Factura)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando borrador: %v", err)
+	}
+
+	if dto.Terminal == "" {
+		dto.Terminal = terminalLocal()
+	}
+
+	var total float64
+	for _, item := range dto.Factura.Items {
+		subtotal := item.Cantidad*item.Precio - item.Descuento
+		total += subtotal + subtotal*item.PorcentajeIVA/100
+	}
+
+	draft := db.InvoiceDraft{}
+	if dto.ID != 0 {
+		if err := db.GetDB().First(&draft, dto.ID).Error; err != nil {
+			return nil, fmt.Errorf("borrador no encontrado: %v", err)
+		}
+	}
+
+	draft.Usuario = dto.Usuario
+	draft.Terminal = dto.Terminal
+	draft.PtoEmi = dto.PtoEmi
+	draft.ClienteID = dto.Factura.ClienteID
+	draft.ClienteNombre = dto.Factura.ClienteNombre
+	draft.Total = total
+	draft.NumItems = len(dto.Factura.Items)
+	draft.Data = string(data)
+
+	if err := db.GetDB().Save(&draft).Error; err != nil {
+		return nil, fmt.Errorf("error guardando borrador: %v", err)
+	}
+
+	return mapDraftToDTO(draft)
+}
+
+// ListarBorradores devuelve los borradores abiertos de un usuario/terminal, del más reciente al más antiguo.
+// Filtros vacíos no se aplican.
+func (s *DraftService) ListarBorradores(usuario, terminal string) ([]db.InvoiceDraftDTO, error) {
+	query := db.GetDB().Order("updated_at desc")
+	if usuario != "" {
+		query = query.Where("usuario = ?", usuario)
+	}
+	if terminal != "" {
+		query = query.Where("terminal = ?", terminal)
+	}
+
+	var drafts []db.InvoiceDraft
+	if err := query.Find(&drafts).Error; err != nil {
+		return nil, fmt.Errorf("error listando borradores: %v", err)
+	}
+
+	result := make([]db.InvoiceDraftDTO, 0, len(drafts))
+	for _, d := range drafts {
+		dto, err := mapDraftToDTO(d)
+		if err != nil {
+			logger.Error("Borrador %d corrupto: %v", d.ID, err)
+			continue
+		}
+		result = append(result, *dto)
+	}
+	return result, nil
+}
+
+// ObtenerBorrador carga un borrador para continuar editándolo.
+func (s *DraftService) ObtenerBorrador(id uint) (*db.InvoiceDraftDTO, error) {
+	var draft db.InvoiceDraft
+	if err := db.GetDB().First(&draft, id).Error; err != nil {
+		return nil, fmt.Errorf("borrador no encontrado: %v", err)
+	}
+	return mapDraftToDTO(draft)
+}
+
+// EliminarBorrador descarta un borrador.
+func (s *DraftService) EliminarBorrador(id uint) error {
+	res := db.GetDB().Delete(&db.InvoiceDraft{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("error eliminando borrador: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("borrador no encontrado")
+	}
+	return nil
+}
+
+// EmitirBorrador convierte el borrador en factura usando el flujo normal de emisión.
+// Si la emisión es exitosa el borrador se elimina; si falla, se conserva para corregirlo.
+// La emisión usa la serie configurada, así que un borrador guardado en otro punto de emisión se rechaza.
+func (s *DraftService) EmitirBorrador(id uint, invoiceService *InvoiceService) (*db.FacturaDTO, error) {
+	draft, err := s.ObtenerBorrador(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if draft.PtoEmi != "" {
+		config, err := invoiceService.cargarEmisor()
+		if err != nil {
+			return nil, err
+		}
+		var nPtoEmi int
+		fmt.Sscanf(draft.PtoEmi, "%d", &nPtoEmi)
+		if _, ptoEmi := serieEmisor(config); fmt.Sprintf("%03d", nPtoEmi) != ptoEmi {
+			return nil, fmt.Errorf("el borrador se guardó en el punto de emisión %s y este equipo emite en %s", draft.PtoEmi, ptoEmi)
+		}
+	}
+
+	factura := draft.Factura
+	if err := invoiceService.EmitirFactura(&factura); err != nil {
+		return nil, err
+	}
+
+	if err := db.GetDB().Delete(&db.InvoiceDraft{}, id).Error; err != nil {
+		logger.Error("Factura %s emitida pero no se pudo eliminar el borrador %d: %v", factura.Secuencial, id, err)
+	}
+	return &factura, nil
+}
+
+// PurgarBorradores elimina los borradores sin modificaciones desde hace más de maxAge.
+func (s *DraftService) PurgarBorradores(maxAge time.Duration) (int64, error) {
+	limite := time.Now().Add(-maxAge)
+	res := db.GetDB().Where("updated_at < ?", limite).Delete(&db.InvoiceDraft{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("error purgando borradores: %v", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// StartPurgeWorker purga los borradores abandonados al iniciar y luego una vez al día.
+func (s *DraftService) StartPurgeWorker() {
+	go func() {
+		for {
+			if n, err := s.PurgarBorradores(DraftMaxAge); err != nil {
+				logger.Error("%v", err)
+			} else if n > 0 {
+				logger.Info("Borradores abandonados eliminados: %d", n)
+			}
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+}
+
+func mapDraftToDTO(d db.InvoiceDraft) (*db.InvoiceDraftDTO, error) {
+	var factura db.FacturaDTO
+	if d.Data != "" {
+		if err := json.Unmarshal([]byte(d.Data), &factura); err != nil {
+			return nil, fmt.Errorf("error leyendo borrador: %v", err)
+		}
+	}
+	return &db.InvoiceDraftDTO{
+		ID:            d.ID,
+		Usuario:       d.Usuario,
+		Terminal:      d.Terminal,
+		PtoEmi:        d.PtoEmi,
+		Factura:       factura,
+		ClienteNombre: d.ClienteNombre,
+		Total:         d.Total,
+		NumItems:      d.NumItems,
+		Actualizado:   d.UpdatedAt.Format("02/01/2006 15:04"),
+	}, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+)
+
+func TestDraftService(t *testing.T) {
+	database := setupTestDB()
+	s := NewDraftService()
+
+	draft := db.InvoiceDraftDTO{
+		Usuario:  "caja1",
+		Terminal: "PC-01",
+		PtoEmi:   "001",
+		Factura: db.FacturaDTO{
+			ClienteID:     "0102030405",
+			ClienteNombre: "Juan Perez",
+			Observacion:   "Entrega a domicilio",
+			FormaPago:     "20",
+			Items: []db.InvoiceItem{
+				{Codigo: "P1", Nombre: "Producto 1", Cantidad: 2, Precio: 10, CodigoIVA: "4", PorcentajeIVA: 15},
+			},
+		},
+	}
+
+	t.Run("Crear y Actualizar", func(t *testing.T) {
+		saved, err := s.GuardarBorrador(draft)
+		if err != nil {
+			t.Fatalf("Error guardando borrador: %v", err)
+		}
+		if saved.ID == 0 || saved.Total != 23 || saved.NumItems != 1 {
+			t.Errorf("Borrador inesperado: %+v", saved)
+		}
+
+		saved.Factura.Items = append(saved.Factura.Items, db.InvoiceItem{Codigo: "P2", Nombre: "Producto 2", Cantidad: 1, Precio: 5, CodigoIVA: "0"})
+		updated, err := s.GuardarBorrador(*saved)
+		if err != nil {
+			t.Fatalf("Error actualizando borrador: %v", err)
+		}
+		if updated.ID != saved.ID || updated.NumItems != 2 {
+			t.Errorf("La actualización debió reutilizar el borrador: %+v", updated)
+		}
+
+		loaded, err := s.ObtenerBorrador(saved.ID)
+		if err != nil {
+			t.Fatalf("Error cargando borrador: %v", err)
+		}
+		if loaded.Factura.Observacion != "Entrega a domicilio" || loaded.Factura.FormaPago != "20" || len(loaded.Factura.Items) != 2 {
+			t.Errorf("Contenido del borrador no se preservó: %+v", loaded.Factura)
+		}
+	})
+
+	t.Run("Listar por Usuario y Terminal", func(t *testing.T) {
+		otro := draft
+		otro.Usuario = "caja2"
+		if _, err := s.GuardarBorrador(otro); err != nil {
+			t.Fatal(err)
+		}
+
+		list, _ := s.ListarBorradores("caja1", "PC-01")
+		if len(list) != 1 {
+			t.Errorf("Esperaba 1 borrador de caja1, obtuve %d", len(list))
+		}
+		all, _ := s.ListarBorradores("", "")
+		if len(all) != 2 {
+			t.Errorf("Esperaba 2 borradores en total, obtuve %d", len(all))
+		}
+	})
+
+	t.Run("Purgar Abandonados", func(t *testing.T) {
+		viejo := time.Now().Add(-2 * DraftMaxAge)
+		database.Model(&db.InvoiceDraft{}).Where("usuario = ?", "caja2").UpdateColumn("updated_at", viejo)
+
+		n, err := s.PurgarBorradores(DraftMaxAge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("Esperaba purgar 1 borrador, se purgaron %d", n)
+		}
+		all, _ := s.ListarBorradores("", "")
+		if len(all) != 1 || all[0].Usuario != "caja1" {
+			t.Errorf("Quedaron borradores incorrectos: %+v", all)
+		}
+	})
+
+	t.Run("Otro Punto de Emisión", func(t *testing.T) {
+		database.Model(&db.EmisorConfig{}).Where("1 = 1").Update("pto_emi", "002")
+		all, _ := s.ListarBorradores("", "")
+		_, err := s.EmitirBorrador(all[0].ID, NewInvoiceService())
+		if err == nil || !strings.Contains(err.Error(), "punto de emisión 001") {
+			t.Errorf("Debe rechazar un borrador de otro punto de emisión: %v", err)
+		}
+		if _, err := s.ObtenerBorrador(all[0].ID); err != nil {
+			t.Error("El borrador rechazado debe conservarse")
+		}
+	})
+
+	t.Run("Eliminar", func(t *testing.T) {
+		all, _ := s.ListarBorradores("", "")
+		if err := s.EliminarBorrador(all[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.EliminarBorrador(all[0].ID); err == nil {
+			t.Error("Eliminar un borrador inexistente debería fallar")
+		}
+	})
+}
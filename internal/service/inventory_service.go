@@ -0,0 +1,369 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/util"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Tipos de movimiento del kardex.
+const (
+	MovVenta         = "VENTA"
+	MovCompra        = "COMPRA"
+	MovAjuste        = "AJUSTE"
+	MovDevolucion    = "DEVOLUCION"
+	MovTransferencia = "TRANSFERENCIA"
+)
+
+// UsuarioSistema identifica movimientos generados automáticamente (emisión de facturas).
+const UsuarioSistema = "Sistema"
+
+type InventoryService struct{}
+
+func NewInventoryService() *InventoryService {
+	return &InventoryService{}
+}
+
+// AjustarStock registra un ajuste manual en una bodega ("" = principal). Si fijar es true,
+// cantidad es el stock final deseado en esa bodega (conteo físico); si no, es la variación.
+func (s *InventoryService) AjustarStock(sku, bodega string, cantidad float64, fijar bool, usuario, nota string) (*db.StockMovement, error) {
+	var mov *db.StockMovement
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var product db.Product
+		if err := tx.First(&product, "sku = ?", sku).Error; err != nil {
+			return fmt.Errorf("producto no encontrado: %s", sku)
+		}
+		bodega, err := resolverBodega(tx, bodega)
+		if err != nil {
+			return err
+		}
+
+		delta := cantidad
+		if fijar {
+			actual, err := stockEnBodega(tx, &product, bodega)
+			if err != nil {
+				return err
+			}
+			delta = cantidad - actual
+		}
+		delta = redondearCantidad(delta)
+		if delta == 0 {
+			return nil
+		}
+
+		mov = &db.StockMovement{
+			ProductSKU: sku,
+			Tipo:       MovAjuste,
+			Cantidad:   delta,
+			Usuario:    usuario,
+			Nota:       nota,
+			Bodega:     bodega,
+		}
+		return registrarMovimiento(tx, mov)
+	})
+	return mov, err
+}
+
+// GetKardex devuelve los movimientos de un producto en el rango con saldos inicial y final.
+// Con bodega vacía se muestra el kardex consolidado de todas las bodegas.
+func (s *InventoryService) GetKardex(sku, bodega string, desde, hasta time.Time) (*db.KardexDTO, error) {
+	var product db.Product
+	if err := db.GetDB().First(&product, "sku = ?", sku).Error; err != nil {
+		return nil, fmt.Errorf("producto no encontrado: %s", sku)
+	}
+
+	q := db.GetDB().Where("product_sku = ?", sku)
+	saldo := func(m db.StockMovement) float64 { return m.Saldo }
+	if bodega != "" {
+		q = q.Where("bodega = ?", bodega)
+		saldo = func(m db.StockMovement) float64 { return m.SaldoBodega }
+	}
+	q = q.Session(&gorm.Session{})
+
+	var movs []db.StockMovement
+	if err := q.Where("created_at >= ? AND created_at <= ?", desde, hasta).
+		Order("created_at asc, id asc").Find(&movs).Error; err != nil {
+		return nil, fmt.Errorf("error consultando kardex: %v", err)
+	}
+
+	kardex := &db.KardexDTO{
+		SKU:         product.SKU,
+		Nombre:      product.Name,
+		Bodega:      bodega,
+		Desde:       desde.Format("2006-01-02"),
+		Hasta:       hasta.Format("2006-01-02"),
+		Movimientos: make([]db.StockMovementDTO, 0, len(movs)),
+	}
+
+	// Saldo inicial: el stock justo antes del primer movimiento desde la fecha de inicio.
+	// Si no hay movimientos posteriores, el stock actual es el saldo.
+	var siguientes []db.StockMovement
+	q.Where("created_at >= ?", desde).Order("created_at asc, id asc").Limit(1).Find(&siguientes)
+	if len(siguientes) > 0 {
+		kardex.SaldoInicial = redondearCantidad(saldo(siguientes[0]) - siguientes[0].Cantidad)
+	} else if bodega != "" {
+		kardex.SaldoInicial, _ = stockEnBodega(db.GetDB(), &product, bodega)
+	} else {
+		kardex.SaldoInicial = product.Stock
+	}
+
+	kardex.SaldoFinal = kardex.SaldoInicial
+	for _, m := range movs {
+		dto := db.StockMovementDTO{
+			ID:        m.ID,
+			Fecha:     m.CreatedAt.Format("02/01/2006 15:04"),
+			Tipo:      m.Tipo,
+			Saldo:     saldo(m),
+			Costo:     m.CostoUnitario,
+			Documento: m.Documento,
+			Usuario:   m.Usuario,
+			Nota:      m.Nota,
+			Bodega:    m.Bodega,
+		}
+		if m.Cantidad >= 0 {
+			dto.Entrada = m.Cantidad
+			kardex.Entradas += m.Cantidad
+		} else {
+			dto.Salida = -m.Cantidad
+			kardex.Salidas += -m.Cantidad
+		}
+		kardex.SaldoFinal = saldo(m)
+		kardex.Movimientos = append(kardex.Movimientos, dto)
+	}
+	kardex.Entradas = redondearCantidad(kardex.Entradas)
+	kardex.Salidas = redondearCantidad(kardex.Salidas)
+	return kardex, nil
+}
+
+// GenerarKardexExcel exporta el kardex de un producto.
+func (s *InventoryService) GenerarKardexExcel(sku, bodega string, desde, hasta time.Time) ([]byte, error) {
+	kardex, err := s.GetKardex(sku, bodega, desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Kardex"
+	f.SetSheetName("Sheet1", sheet)
+	f.SetCellValue(sheet, "A1", fmt.Sprintf("Kardex: %s - %s", kardex.SKU, kardex.Nombre))
+	alcance := "Todas las bodegas"
+	if kardex.Bodega != "" {
+		alcance = "Bodega " + kardex.Bodega
+	}
+	f.SetCellValue(sheet, "A2", fmt.Sprintf("Del %s al %s - %s", kardex.Desde, kardex.Hasta, alcance))
+
+	headers := []string{"Fecha", "Tipo", "Documento", "Entrada", "Salida", "Saldo", "Usuario", "Nota", "Costo Unit.", "Bodega"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 4)
+		f.SetCellValue(sheet, cell, h)
+	}
+	style, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	f.SetRowStyle(sheet, 4, 4, style)
+
+	f.SetCellValue(sheet, "A5", "Saldo inicial")
+	f.SetCellValue(sheet, "F5", kardex.SaldoInicial)
+
+	row := 6
+	for _, m := range kardex.Movimientos {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), m.Fecha)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), m.Tipo)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), m.Documento)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), m.Entrada)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), m.Salida)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), m.Saldo)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), m.Usuario)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), m.Nota)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), m.Costo)
+		f.SetCellValue(sheet, fmt.Sprintf("J%d", row), m.Bodega)
+		row++
+	}
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "Totales")
+	f.SetCellValue(sheet, fmt.Sprintf("D%d", row), kardex.Entradas)
+	f.SetCellValue(sheet, fmt.Sprintf("E%d", row), kardex.Salidas)
+	f.SetCellValue(sheet, fmt.Sprintf("F%d", row), kardex.SaldoFinal)
+	f.SetRowStyle(sheet, row, row, style)
+	f.SetColWidth(sheet, "A", "A", 18)
+	f.SetColWidth(sheet, "C", "C", 52)
+	f.SetColWidth(sheet, "H", "H", 40)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error generando kardex: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// registrarMovimiento aplica mov.Cantidad al stock del producto y guarda la línea del kardex
+// con el saldo resultante. Las entradas con costo recalculan el costo promedio ponderado;
+// las salidas (y entradas sin costo) se valoran al costo promedio vigente. El costo es único
+// para todas las bodegas; el stock se lleva por bodega (mov.Bodega, vacío = principal) y en total.
+// Debe llamarse dentro de una transacción.
+func registrarMovimiento(tx *gorm.DB, mov *db.StockMovement) error {
+	var product db.Product
+	if err := tx.First(&product, "sku = ?", mov.ProductSKU).Error; err != nil {
+		return fmt.Errorf("producto no encontrado: %s", mov.ProductSKU)
+	}
+	if product.EsKit {
+		return fmt.Errorf("%s es un kit: no lleva stock propio, se mueven sus componentes", mov.ProductSKU)
+	}
+	if product.EsServicio {
+		return fmt.Errorf("%s es un servicio: no lleva inventario", mov.ProductSKU)
+	}
+	bodega, err := resolverBodega(tx, mov.Bodega)
+	if err != nil {
+		return err
+	}
+	mov.Bodega = bodega
+	if err := inicializarStockBodegas(tx, &product); err != nil {
+		return err
+	}
+
+	mov.Cantidad = redondearCantidad(mov.Cantidad)
+	updates := map[string]interface{}{"stock": gorm.Expr("ROUND(stock + ?, 6)", mov.Cantidad)}
+	if mov.Cantidad > 0 && mov.CostoUnitario > 0 {
+		updates["cost"] = costoPromedio(product.Stock, product.Cost, mov.Cantidad, mov.CostoUnitario)
+		if mov.Tipo == MovCompra {
+			updates["last_cost"] = mov.CostoUnitario
+		}
+	} else {
+		mov.CostoUnitario = product.Cost
+	}
+
+	if err := tx.Model(&db.Product{}).Where("sku = ?", mov.ProductSKU).UpdateColumns(updates).Error; err != nil {
+		return fmt.Errorf("error actualizando stock de %s: %v", mov.ProductSKU, err)
+	}
+
+	if err := sumarStockBodega(tx, mov.ProductSKU, bodega, mov.Cantidad); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&db.Product{}).Where("sku = ?", mov.ProductSKU).Select("stock").Scan(&mov.Saldo).Error; err != nil {
+		return fmt.Errorf("error leyendo stock de %s: %v", mov.ProductSKU, err)
+	}
+	if err := tx.Model(&db.ProductStock{}).Where("product_sku = ? AND bodega = ?", mov.ProductSKU, bodega).
+		Select("stock").Scan(&mov.SaldoBodega).Error; err != nil {
+		return fmt.Errorf("error leyendo stock de %s en %s: %v", mov.ProductSKU, bodega, err)
+	}
+	if mov.Usuario == "" {
+		mov.Usuario = UsuarioSistema
+	}
+	if err := tx.Create(mov).Error; err != nil {
+		return fmt.Errorf("error registrando movimiento: %v", err)
+	}
+	return nil
+}
+
+// costoPromedio calcula el nuevo costo promedio ponderado tras una entrada.
+// Con stock en cero o negativo el costo anterior no es representativo y se toma el de la entrada.
+func costoPromedio(stock, costo, cantidad, costoEntrada float64) float64 {
+	if stock <= 0 {
+		return costoEntrada
+	}
+	total := stock*costo + cantidad*costoEntrada
+	return util.Round(total/(stock+cantidad), 4)
+}
+
+// redondearCantidad elimina el error de punto flotante en cantidades de stock (hasta 6 decimales,
+// la precisión que admite el SRI en la cantidad del detalle).
+func redondearCantidad(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+// descontarVenta registra la salida de inventario de los ítems de una factura desde la bodega
+// indicada y guarda en cada ítem el costo unitario vigente, por lo que debe llamarse antes de
+// persistir los ítems. Los ítems vendidos en una presentación descuentan Cantidad × Factor
+// unidades base y su costo se expresa por unidad de venta. Los ítems cuyo código no existe en el catálogo (servicios, códigos libres)
+// no mueven stock, como tampoco los servicios, que se valoran a su costo configurado. Los productos
+// con lotes descuentan además de sus lotes en orden FEFO. Los kits descuentan sus componentes y su
+// costo es la suma del de ellos.
+func descontarVenta(tx *gorm.DB, documento, bodega string, items []db.FacturaItem) error {
+	for i := range items {
+		item := &items[i]
+		var products []db.Product
+		tx.Where("sku = ?", item.ProductoSKU).Limit(1).Find(&products)
+		if len(products) == 0 {
+			continue
+		}
+
+		factor := item.Factor
+		if factor <= 0 {
+			factor = 1
+		}
+		if products[0].EsServicio {
+			item.CostoUnitario = util.Round(products[0].Cost*factor, 4)
+			continue
+		}
+		if products[0].EsKit {
+			costo, err := descontarKit(tx, documento, bodega, item.ProductoSKU, item.Cantidad*factor)
+			if err != nil {
+				return err
+			}
+			item.CostoUnitario = util.Round(costo*factor, 4)
+			continue
+		}
+		mov := &db.StockMovement{
+			ProductSKU: item.ProductoSKU,
+			Tipo:       MovVenta,
+			Cantidad:   -redondearCantidad(item.Cantidad * factor),
+			Documento:  documento,
+			Bodega:     bodega,
+		}
+		if mov.Cantidad == 0 {
+			continue
+		}
+		if err := registrarMovimiento(tx, mov); err != nil {
+			return err
+		}
+		if _, err := consumirLotes(tx, documento, item.ProductoSKU, mov.Bodega, -mov.Cantidad, false); err != nil {
+			return err
+		}
+		item.CostoUnitario = util.Round(mov.CostoUnitario*factor, 4)
+	}
+	return nil
+}
+
+// revertirMovimientos deja en cero el efecto neto de un documento sobre el inventario
+// (anulaciones, reemplazos) en cada bodega afectada, incluidos sus lotes. Es idempotente:
+// revertir dos veces no vuelve a mover stock.
+func revertirMovimientos(tx *gorm.DB, documento, tipo, nota string) error {
+	if err := revertirLotes(tx, documento); err != nil {
+		return err
+	}
+
+	type neto struct {
+		ProductSKU string
+		Bodega     string
+		Total      float64
+	}
+	var netos []neto
+	if err := tx.Model(&db.StockMovement{}).Select("product_sku, bodega, SUM(cantidad) as total").
+		Where("documento = ?", documento).Group("product_sku, bodega").Scan(&netos).Error; err != nil {
+		return fmt.Errorf("error consultando movimientos de %s: %v", documento, err)
+	}
+
+	for _, n := range netos {
+		if redondearCantidad(n.Total) == 0 {
+			continue
+		}
+		mov := &db.StockMovement{
+			ProductSKU: n.ProductSKU,
+			Tipo:       tipo,
+			Cantidad:   -n.Total,
+			Documento:  documento,
+			Nota:       nota,
+			Bodega:     n.Bodega,
+		}
+		if err := registrarMovimiento(tx, mov); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+
+	"gorm.io/gorm"
+)
+
+func stockDe(t *testing.T, sku string) float64 {
+	t.Helper()
+	var p db.Product
+	if err := db.GetDB().First(&p, "sku = ?", sku).Error; err != nil {
+		t.Fatalf("Producto %s no encontrado: %v", sku, err)
+	}
+	return p.Stock
+}
+
+func TestInventoryService_Kardex(t *testing.T) {
+	database := setupTestDB()
+	svc := NewInventoryService()
+	database.Create(&db.Product{SKU: "P1", Name: "Producto 1", Barcode: "P1", Stock: 10})
+
+	t.Run("Ajustes", func(t *testing.T) {
+		mov, err := svc.AjustarStock("P1", "", 5, false, "tester", "Ingreso")
+		if err != nil || mov.Saldo != 15 {
+			t.Fatalf("Ajuste relativo incorrecto: %v %+v", err, mov)
+		}
+		mov, err = svc.AjustarStock("P1", "", 12, true, "tester", "Conteo")
+		if err != nil || mov.Cantidad != -3 || mov.Saldo != 12 {
+			t.Fatalf("Ajuste por conteo incorrecto: %v %+v", err, mov)
+		}
+		if mov, _ := svc.AjustarStock("P1", "", 12, true, "tester", ""); mov != nil {
+			t.Error("Un conteo igual al stock no debe generar movimiento")
+		}
+		if _, err := svc.AjustarStock("NOEXISTE", "", 1, false, "", ""); err == nil {
+			t.Error("Debería fallar con producto inexistente")
+		}
+	})
+
+	t.Run("Venta y Reversión", func(t *testing.T) {
+		items := []db.FacturaItem{
+			{FacturaClave: "CLAVE1", ProductoSKU: "P1", Cantidad: 4},
+			{FacturaClave: "CLAVE1", ProductoSKU: "SERVICIO", Cantidad: 1}, // Sin catálogo: no mueve stock
+		}
+		err := database.Transaction(func(tx *gorm.DB) error {
+			return descontarVenta(tx, "CLAVE1", "", items)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := stockDe(t, "P1"); got != 8 {
+			t.Errorf("Stock esperado 8 tras la venta, obtuve %g", got)
+		}
+
+		for i := 0; i < 2; i++ {
+			database.Transaction(func(tx *gorm.DB) error {
+				return revertirMovimientos(tx, "CLAVE1", MovDevolucion, "Anulación")
+			})
+		}
+		if got := stockDe(t, "P1"); got != 12 {
+			t.Errorf("La reversión debe ser idempotente: esperado 12, obtuve %g", got)
+		}
+	})
+
+	t.Run("Reporte", func(t *testing.T) {
+		kardex, err := svc.GetKardex("P1", "", time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kardex.SaldoInicial != 10 || kardex.SaldoFinal != 12 || len(kardex.Movimientos) != 4 {
+			t.Errorf("Kardex inesperado: %+v", kardex)
+		}
+		if kardex.Entradas-kardex.Salidas != kardex.SaldoFinal-kardex.SaldoInicial {
+			t.Errorf("Entradas/Salidas no cuadran: %+v", kardex)
+		}
+
+		// Un rango futuro sin movimientos reporta el stock actual
+		kardex, _ = svc.GetKardex("P1", "", time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))
+		if kardex.SaldoInicial != 12 || len(kardex.Movimientos) != 0 {
+			t.Errorf("Kardex vacío inesperado: %+v", kardex)
+		}
+
+		if _, err := svc.GenerarKardexExcel("P1", "", time.Now().Add(-time.Hour), time.Now()); err != nil {
+			t.Errorf("Error exportando kardex: %v", err)
+		}
+	})
+}
+
+func TestAnularFactura(t *testing.T) {
+	database := setupTestDB()
+	svc := NewInvoiceService()
+	database.Create(&db.Product{SKU: "P1", Name: "Producto 1", Barcode: "P1", Stock: 10})
+	database.Create(&db.Factura{ClaveAcceso: "CLAVE_AUT", Secuencial: "000000001", EstadoSRI: "AUTORIZADO", Total: 10})
+	database.Create(&db.Factura{ClaveAcceso: "CLAVE_DEV", Secuencial: "000000002", EstadoSRI: "DEVUELTA", Total: 10})
+	database.Transaction(func(tx *gorm.DB) error {
+		return descontarVenta(tx, "CLAVE_AUT", "", []db.FacturaItem{{ProductoSKU: "P1", Cantidad: 3}})
+	})
+
+	if err := svc.AnularFactura("CLAVE_DEV", "Error"); err == nil {
+		t.Error("No se debe anular una factura no autorizada")
+	}
+	if err := svc.AnularFactura("CLAVE_AUT", "Cliente desistió"); err != nil {
+		t.Fatalf("Error anulando: %v", err)
+	}
+
+	var f db.Factura
+	database.First(&f, "clave_acceso = ?", "CLAVE_AUT")
+	if f.EstadoSRI != "ANULADO" {
+		t.Errorf("Estado esperado ANULADO, obtuve %s", f.EstadoSRI)
+	}
+	if got := stockDe(t, "P1"); got != 10 {
+		t.Errorf("El stock debió reingresar: esperado 10, obtuve %g", got)
+	}
+}
+
+func TestCostoPromedioPonderado(t *testing.T) {
+	database := setupTestDB()
+	database.Create(&db.Product{SKU: "C1", Name: "Costeado", Barcode: "C1", Stock: 10, Cost: 2})
+
+	database.Transaction(func(tx *gorm.DB) error {
+		return registrarMovimiento(tx, &db.StockMovement{ProductSKU: "C1", Tipo: MovCompra, Cantidad: 10, CostoUnitario: 4})
+	})
+
+	var p db.Product
+	database.First(&p, "sku = ?", "C1")
+	if p.Cost != 3 || p.LastCost != 4 || p.Stock != 20 {
+		t.Errorf("Costo promedio esperado 3 (último 4), obtuve %+v", p)
+	}
+
+	// La venta toma el costo vigente y no lo altera
+	items := []db.FacturaItem{{ProductoSKU: "C1", Cantidad: 5}}
+	database.Transaction(func(tx *gorm.DB) error {
+		return descontarVenta(tx, "CLAVE_COSTO", "", items)
+	})
+	if items[0].CostoUnitario != 3 {
+		t.Errorf("El ítem debió guardar costo 3, obtuve %.2f", items[0].CostoUnitario)
+	}
+	database.First(&p, "sku = ?", "C1")
+	if p.Cost != 3 {
+		t.Errorf("Las salidas no deben cambiar el costo promedio: %.2f", p.Cost)
+	}
+
+	if got := costoPromedio(-2, 5, 4, 7); got != 7 {
+		t.Errorf("Con stock negativo el costo debe ser el de la entrada, obtuve %.2f", got)
+	}
+}
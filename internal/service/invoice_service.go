@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/base64"
 	"fmt"
 	"kushkiv2/internal/db"
 	"kushkiv2/pkg/crypto"
@@ -9,12 +10,46 @@ import (
 	"kushkiv2/pkg/sri"
 	"kushkiv2/pkg/util"
 	"kushkiv2/pkg/xml"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
 )
 
 type InvoiceService struct {
 	db        *db.EmisorConfig // Cache de configuración (opcional)
 	sriClient *sri.SRIClient
+
+	secMu         sync.Mutex   // Serializa la reserva de secuenciales (emisión concurrente en lote)
+	ultimoReservo int          // Último secuencial entregado en esta sesión
+	liberados     map[int]bool // Secuenciales devueltos que aún no son el último entregado
+}
+
+// ImpuestoPreview resume una base imponible agrupada por código de porcentaje.
+type ImpuestoPreview struct {
+	Codigo           string  `json:"codigo"`
+	CodigoPorcentaje string  `json:"codigoPorcentaje"`
+	BaseImponible    float64 `json:"baseImponible"`
+	Valor            float64 `json:"valor"`
+}
+
+// InvoicePreview es el resultado de una emisión en seco (dry-run).
+type InvoicePreview struct {
+	Valida            bool              `json:"valida"`
+	Error             string            `json:"error"` // Regla que impediría emitir
+	Advertencias      []string          `json:"advertencias"`
+	Secuencial        string            `json:"secuencial"` // Tentativo, no se reserva
+	TotalSinImpuestos float64           `json:"totalSinImpuestos"`
+	SubtotalGravado   float64           `json:"subtotalGravado"`
+	SubtotalCero      float64           `json:"subtotalCero"`
+	TotalIVA          float64           `json:"totalIVA"`
+	ImporteTotal      float64           `json:"importeTotal"`
+	TotalConImpuestos []ImpuestoPreview `json:"totalConImpuestos"`
+	XML               string            `json:"xml"` // XML sin firmar
+	PDFBase64         string            `json:"pdfBase64"` // RIDE con marca BORRADOR
 }
 
 func NewInvoiceService() *InvoiceService {
@@ -23,11 +58,17 @@ func NewInvoiceService() *InvoiceService {
 	}
 }
 
+// Estados SRI que permiten corregir y reenviar el comprobante.
+var estadosCorregibles = map[string]bool{
+	"DEVUELTA":      true,
+	"NO AUTORIZADO": true,
+}
+
 func (s *InvoiceService) GetNextSecuencial() (string, error) {
 	var lastFacturas []db.Factura
 	// Usamos Find con Limit 1 para evitar el error "record not found" si está vacía
 	db.GetDB().Order("created_at desc").Limit(1).Find(&lastFacturas)
-	
+
 	if len(lastFacturas) == 0 {
 		// Si no hay facturas, empezamos en 1
 		return "000000001", nil
@@ -39,21 +80,533 @@ func (s *InvoiceService) GetNextSecuencial() (string, error) {
 	return fmt.Sprintf("%09d", currentSec+1), nil
 }
 
+// reservarSecuencial entrega un secuencial único aunque varias emisiones corran en paralelo:
+// el siguiente de la base de datos puede no reflejar aún facturas en curso, por eso se recuerda
+// el último entregado. Devuelve también la fecha de emisión tomada bajo el mismo bloqueo.
+func (s *InvoiceService) reservarSecuencial() (string, time.Time) {
+	s.secMu.Lock()
+	defer s.secMu.Unlock()
+
+	next, _ := s.GetNextSecuencial()
+	var nSec int
+	fmt.Sscanf(next, "%d", &nSec)
+	if nSec <= s.ultimoReservo {
+		nSec = s.ultimoReservo + 1
+	}
+	s.ultimoReservo = nSec
+
+	return fmt.Sprintf("%09d", nSec), time.Now()
+}
+
+// liberarSecuencial devuelve un secuencial que no llegó a usarse para no dejar un salto en la
+// numeración. Solo se reutiliza si nadie tomó uno mayor (o si esos también se liberan): el
+// siguiente se calcula a partir del último guardado, así que un hueco anterior no se puede llenar.
+func (s *InvoiceService) liberarSecuencial(secuencial string) {
+	s.secMu.Lock()
+	defer s.secMu.Unlock()
+
+	var nSec int
+	fmt.Sscanf(secuencial, "%d", &nSec)
+	if s.liberados == nil {
+		s.liberados = map[int]bool{}
+	}
+	s.liberados[nSec] = true
+	for s.ultimoReservo > 0 && s.liberados[s.ultimoReservo] {
+		delete(s.liberados, s.ultimoReservo)
+		s.ultimoReservo--
+	}
+}
+
+// estadosVenta son los estados en que un comprobante cuenta como venta: aceptado por el SRI o aún
+// sin respuesta definitiva. RECIBIDA y ERROR_AUTH ya están en el SRI con la autorización por
+// confirmar, así que tampoco pueden tratarse como fallidos (reemitirlos duplicaría la venta).
+var estadosVenta = map[string]bool{
+	"AUTORIZADO":      true,
+	"PENDIENTE_ENVIO": true,
+	"ERROR_TECNICO":   true,
+	"RECIBIDA":        true,
+	"ERROR_AUTH":      true,
+}
+
+// listaEstadosVenta devuelve estadosVenta como lista para filtros SQL.
+func listaEstadosVenta() []string {
+	estados := make([]string, 0, len(estadosVenta))
+	for estado := range estadosVenta {
+		estados = append(estados, estado)
+	}
+	return estados
+}
+
+// sinEnvioSRI indica que el comprobante no llegó al SRI, por lo que su secuencial sigue libre.
+func sinEnvioSRI(estado string) bool {
+	return estado == "PENDIENTE_ENVIO" || estado == "ERROR_TECNICO"
+}
+
 // EmitirFactura coordina el flujo completo de facturación.
+// Se divide en una fase pura (cálculo y validación, ver PrevisualizarFactura) y una fase de emisión
+// (secuencial, firma, envío al SRI y persistencia).
 func (s *InvoiceService) EmitirFactura(dto *db.FacturaDTO) error {
 	// 1. Obtener Configuración del Emisor
+	config, err := s.cargarEmisor()
+	if err != nil {
+		return err
+	}
+
+	// 2. Fase pura: Validaciones normativa SRI 2025/2026 y cálculos
+	calculo, err := s.calcularYValidar(config, dto)
+	if err != nil {
+		return err
+	}
+
+	// 3. Fase de emisión
+	return s.emitir(config, dto, calculo)
+}
+
+// emitir consume un secuencial, firma, envía al SRI y persiste la factura ya calculada.
+func (s *InvoiceService) emitir(config *db.EmisorConfig, dto *db.FacturaDTO, calculo *calculoFactura) error {
+	// RECALCULAR SECUENCIAL: Ignoramos el del DTO por ser inseguro (concurrencia)
+	// y reservamos el verdadero siguiente disponible.
+	secuencialStr, fechaEmision := s.reservarSecuencial()
+
+	// Actualizar DTO para reflejar el real usado
+	dto.Secuencial = secuencialStr
+
+	// Generar Clave de Acceso (49 dígitos)
+	claveAcceso := generarClaveAcceso(config, fechaEmision, secuencialStr, codigoNumerico(0))
+
+	// Construir XML, firmar y enviar al SRI. Sus errores ocurren antes del envío: el secuencial se libera
+	facturaXML, facturaDB, err := s.procesarComprobante(config, dto, calculo, secuencialStr, claveAcceso, fechaEmision)
+	if err != nil {
+		s.liberarSecuencial(secuencialStr)
+		return err
+	}
+
+	// Auto-Guardar Cliente (Upsert)
+	upsertClienteFactura(dto)
+
+	// Guardar Factura e ítems en una sola transacción. CreatedAt se fija al momento de la reserva
+	// para que el orden por created_at (GetNextSecuencial) coincida con el secuencial aunque las
+	// respuestas del SRI lleguen desordenadas.
+	facturaDB.CreatedAt = fechaEmision
+	items := itemsFactura(claveAcceso, dto)
+	err = db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(facturaDB).Error; err != nil {
+			return fmt.Errorf("error guardando factura en DB: %v", err)
+		}
+		// Guardar Items de Factura para Reportería
+		for i := range items {
+			if err := tx.Create(&items[i]).Error; err != nil {
+				return fmt.Errorf("error guardando ítems: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Un comprobante que el SRI ya recibió consumió su secuencial aunque no se haya guardado
+		if sinEnvioSRI(facturaDB.EstadoSRI) {
+			s.liberarSecuencial(secuencialStr)
+		}
+		return err
+	}
+
+	// La salida de inventario va aparte: su fallo no puede deshacer un comprobante ya enviado al SRI
+	actualizarInventarioFactura(facturaDB, items, "")
+
+	// Actualizar DTO de retorno con la clave generada
+	dto.ClaveAcceso = facturaXML.InfoTributaria.ClaveAcceso
+
+	return nil
+}
+
+// PrevisualizarFactura ejecuta solo la fase pura de la emisión: calcula totales, evalúa reglas SRI
+// y genera el XML sin firmar y un RIDE marcado como BORRADOR.
+// No consume secuencial ni escribe en la base de datos.
+func (s *InvoiceService) PrevisualizarFactura(dto db.FacturaDTO) (*InvoicePreview, error) {
+	config, err := s.cargarEmisor()
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &InvoicePreview{Valida: true}
+	errUnidades := resolverUnidades(db.GetDB(), dto.Items)
+	aplicarListaPrecios(db.GetDB(), dto.ClienteID, dto.Items, time.Now())
+	aplicarPromociones(db.GetDB(), dto.ClienteID, dto.Items, time.Now())
+	if errValidacion := s.validarFactura(config, &dto); errValidacion != nil {
+		preview.Valida = false
+		preview.Error = errValidacion.Error()
+	}
+	if errUnidades != nil && preview.Valida {
+		preview.Valida = false
+		preview.Error = errUnidades.Error()
+	}
+	if dto.FormaPago == "" {
+		dto.FormaPago = "01"
+	}
+
+	calculo := calcularFactura(&dto)
+	preview.Advertencias = advertenciasFactura(&dto, calculo)
+	preview.TotalSinImpuestos = calculo.TotalSinImpuestos
+	preview.ImporteTotal = calculo.ImporteTotal
+	preview.SubtotalGravado = calculo.SubtotalGravado
+	preview.SubtotalCero = calculo.SubtotalCero
+	preview.TotalIVA = calculo.TotalIVA
+	for _, t := range calculo.TotalConImpuestos {
+		preview.TotalConImpuestos = append(preview.TotalConImpuestos, ImpuestoPreview{
+			Codigo:           t.Codigo,
+			CodigoPorcentaje: t.CodigoPorcentaje,
+			BaseImponible:    t.BaseImponible,
+			Valor:            t.Valor,
+		})
+	}
+
+	// Secuencial tentativo (solo lectura)
+	secuencial, _ := s.GetNextSecuencial()
+	preview.Secuencial = secuencial
+	fechaEmision := time.Now()
+	claveAcceso := generarClaveAcceso(config, fechaEmision, secuencial, codigoNumerico(0))
+
+	facturaXML, _ := construirFacturaXML(config, &dto, calculo, secuencial, claveAcceso, fechaEmision)
+	xmlData, err := xml.GenerateXML(facturaXML)
+	if err != nil {
+		return nil, err
+	}
+	preview.XML = string(xmlData)
+
+	pdfBytes, err := pdf.GenerarRIDEBorrador(*facturaXML, config.LogoPath, config.PDFTheme)
+	if err != nil {
+		logger.Error("Error generando RIDE borrador: %v", err)
+	} else {
+		preview.PDFBase64 = base64.StdEncoding.EncodeToString(pdfBytes)
+	}
+
+	return preview, nil
+}
+
+// CargarFacturaRechazada reconstruye el FacturaDTO de un comprobante DEVUELTO o NO AUTORIZADO
+// a partir de su XML, para que el usuario pueda corregirlo y reenviarlo.
+func (s *InvoiceService) CargarFacturaRechazada(claveAcceso string) (*db.FacturaDTO, error) {
+	var factura db.Factura
+	if err := db.GetDB().First(&factura, "clave_acceso = ?", claveAcceso).Error; err != nil {
+		return nil, fmt.Errorf("factura no encontrada: %v", err)
+	}
+	if !estadosCorregibles[factura.EstadoSRI] {
+		return nil, fmt.Errorf("solo se pueden corregir facturas DEVUELTA o NO AUTORIZADO (estado actual: %s)", factura.EstadoSRI)
+	}
+
+	dto := &db.FacturaDTO{
+		Secuencial:  factura.Secuencial,
+		ClienteID:   factura.ClienteID,
+		ClaveAcceso: factura.ClaveAcceso,
+	}
+
+	// Fuente principal: el XML firmado (contiene pagos e información adicional)
+	facturaXML, err := xml.ParseFacturaXML(factura.XMLFirmado)
+	if err == nil {
+		dto.ClienteNombre = facturaXML.InfoFactura.RazonSocialComprador
+		dto.ClienteDireccion = facturaXML.InfoFactura.DireccionComprador
+		if len(facturaXML.InfoFactura.Pagos) > 0 {
+			pago := facturaXML.InfoFactura.Pagos[0]
+			dto.FormaPago = pago.FormaPago
+			dto.Plazo = pago.Plazo
+			dto.UnidadTiempo = pago.UnidadTiempo
+		}
+		for _, campo := range facturaXML.InfoAdicional {
+			switch campo.Nombre {
+			case "Email":
+				dto.ClienteEmail = campo.Value
+			case "Telefono":
+				dto.ClienteTelefono = campo.Value
+			case "Observacion":
+				dto.Observacion = campo.Value
+			}
+		}
+		for _, det := range facturaXML.Detalles {
+			item := db.InvoiceItem{
+				Codigo:   det.CodigoPrincipal,
+				Nombre:   det.Descripcion,
+				Cantidad: det.Cantidad,
+				Unidad:   det.UnidadMedida,
+				Precio:   det.PrecioUnitario,
+				// La corrección conserva los precios y descuentos ya facturados
+				PrecioManual: true,
+				Descuento:    det.Descuento,
+			}
+			if len(det.Impuestos) > 0 {
+				item.CodigoIVA = det.Impuestos[0].CodigoPorcentaje
+				item.PorcentajeIVA = det.Impuestos[0].Tarifa
+			}
+			if det.DetallesAdicionales != nil {
+				for _, ad := range det.DetallesAdicionales.DetAdicional {
+					if ad.Nombre == "Detalle" {
+						item.Detalle = ad.Valor
+					}
+				}
+			}
+			dto.Items = append(dto.Items, item)
+		}
+		return dto, nil
+	}
+
+	// Fallback: XML ilegible, usamos los items guardados y los datos del cliente
+	logger.Error("No se pudo leer el XML de %s, se usan datos de la base: %v", claveAcceso, err)
+	var cliente db.Client
+	if db.GetDB().First(&cliente, "id = ?", factura.ClienteID).Error == nil {
+		dto.ClienteNombre = cliente.Nombre
+		dto.ClienteDireccion = cliente.Direccion
+		dto.ClienteEmail = cliente.Email
+		dto.ClienteTelefono = cliente.Telefono
+	}
+	var items []db.FacturaItem
+	db.GetDB().Where("factura_clave = ?", claveAcceso).Find(&items)
+	for _, it := range items {
+		dto.Items = append(dto.Items, db.InvoiceItem{
+			Codigo:        it.ProductoSKU,
+			Nombre:        it.Nombre,
+			Cantidad:      it.Cantidad,
+			Unidad:        it.Unidad,
+			Precio:        it.PrecioUnitario,
+			PrecioManual:  true,
+			Descuento:     it.Descuento,
+			PromocionID:   it.PromocionID,
+			Promocion:     it.Promocion,
+			Detalle:       it.Detalle,
+			CodigoIVA:     codigoIVADesdePorcentaje(it.PorcentajeIVA),
+			PorcentajeIVA: it.PorcentajeIVA,
+		})
+	}
+	return dto, nil
+}
+
+// ReenviarFacturaCorregida vuelve a construir, firmar y enviar una factura rechazada
+// conservando su secuencial. La clave de acceso se reutiliza cuando el SRI lo permite
+// (DEVUELTA el mismo día); en otro caso se genera una nueva con el mismo secuencial.
+// El intento previo queda registrado en el historial del documento.
+func (s *InvoiceService) ReenviarFacturaCorregida(claveOriginal string, dto *db.FacturaDTO) error {
+	var original db.Factura
+	if err := db.GetDB().First(&original, "clave_acceso = ?", claveOriginal).Error; err != nil {
+		return fmt.Errorf("factura no encontrada: %v", err)
+	}
+	if !estadosCorregibles[original.EstadoSRI] {
+		return fmt.Errorf("solo se pueden reenviar facturas DEVUELTA o NO AUTORIZADO (estado actual: %s)", original.EstadoSRI)
+	}
+
+	config, err := s.cargarEmisor()
+	if err != nil {
+		return err
+	}
+	calculo, err := s.calcularYValidar(config, dto)
+	if err != nil {
+		return err
+	}
+
+	var intentos int64
+	db.GetDB().Model(&db.FacturaHistorial{}).Where("secuencial = ?", original.Secuencial).Count(&intentos)
+
+	fechaEmision := time.Now()
+	claveAcceso := original.ClaveAcceso
+	mismoDia := original.FechaEmision.Format("02012006") == fechaEmision.Format("02012006")
+	if original.EstadoSRI != "DEVUELTA" || !mismoDia {
+		// NO AUTORIZADO: la clave quedó registrada en el SRI y no puede reutilizarse
+		claveAcceso = generarClaveAcceso(config, fechaEmision, original.Secuencial, codigoNumerico(int(intentos)+1))
+	}
+
+	dto.Secuencial = original.Secuencial
+	_, facturaDB, err := s.procesarComprobante(config, dto, calculo, original.Secuencial, claveAcceso, fechaEmision)
+	if err != nil {
+		return err
+	}
+
+	upsertClienteFactura(dto)
+
+	items := itemsFactura(claveAcceso, dto)
+	if err := reemplazarFacturaRechazada(&original, facturaDB, items); err != nil {
+		return err
+	}
+	actualizarInventarioFactura(facturaDB, items, original.ClaveAcceso)
+
+	dto.ClaveAcceso = claveAcceso
+	return nil
+}
+
+// GetHistorial devuelve los intentos previos registrados para una factura.
+func (s *InvoiceService) GetHistorial(claveAcceso string) ([]db.FacturaHistorial, error) {
+	var historial []db.FacturaHistorial
+	err := db.GetDB().Where("factura_clave = ?", claveAcceso).Order("intento asc").Find(&historial).Error
+	return historial, err
+}
+
+// AnularFactura marca como ANULADO un comprobante autorizado (la anulación ante el SRI se
+// solicita en su portal) y reingresa al inventario lo vendido.
+func (s *InvoiceService) AnularFactura(claveAcceso, motivo string) error {
+	var factura db.Factura
+	if err := db.GetDB().First(&factura, "clave_acceso = ?", claveAcceso).Error; err != nil {
+		return fmt.Errorf("factura no encontrada: %v", err)
+	}
+	if factura.EstadoSRI != "AUTORIZADO" {
+		return fmt.Errorf("solo se pueden anular facturas AUTORIZADAS (estado actual: %s)", factura.EstadoSRI)
+	}
+
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&db.Factura{}).Where("clave_acceso = ?", claveAcceso).
+			Updates(map[string]interface{}{"estado_sri": "ANULADO", "mensaje_error": motivo}).Error; err != nil {
+			return fmt.Errorf("error anulando factura: %v", err)
+		}
+		return revertirMovimientos(tx, claveAcceso, MovDevolucion, "Anulación: "+motivo)
+	})
+}
+
+// reemplazarFacturaRechazada archiva el intento previo en el historial y guarda el nuevo
+// intento en una sola transacción. Conserva CreatedAt para no alterar el cálculo del secuencial.
+func reemplazarFacturaRechazada(original *db.Factura, nueva *db.Factura, items []db.FacturaItem) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var intentos int64
+		tx.Model(&db.FacturaHistorial{}).Where("secuencial = ?", original.Secuencial).Count(&intentos)
+
+		historial := db.FacturaHistorial{
+			FacturaClave: nueva.ClaveAcceso,
+			ClaveAcceso:  original.ClaveAcceso,
+			Secuencial:   original.Secuencial,
+			Intento:      int(intentos) + 1,
+			FechaEmision: original.FechaEmision,
+			Total:        original.Total,
+			EstadoSRI:    original.EstadoSRI,
+			MensajeError: original.MensajeError,
+			XMLFirmado:   original.XMLFirmado,
+		}
+		if err := tx.Create(&historial).Error; err != nil {
+			return fmt.Errorf("error guardando historial: %v", err)
+		}
+
+		// Re-apuntar intentos anteriores al documento vigente
+		if original.ClaveAcceso != nueva.ClaveAcceso {
+			if err := tx.Model(&db.FacturaHistorial{}).
+				Where("factura_clave = ?", original.ClaveAcceso).
+				Update("factura_clave", nueva.ClaveAcceso).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("factura_clave = ?", original.ClaveAcceso).Delete(&db.FacturaItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&db.Factura{}, "clave_acceso = ?", original.ClaveAcceso).Error; err != nil {
+			return err
+		}
+
+		nueva.CreatedAt = original.CreatedAt
+		if err := tx.Create(nueva).Error; err != nil {
+			return fmt.Errorf("error guardando factura en DB: %v", err)
+		}
+		for i := range items {
+			if err := tx.Create(&items[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// actualizarInventarioFactura deja el inventario acorde con una factura ya guardada: revierte los
+// movimientos del documento que reemplaza (si lo hay) y, si el comprobante cuenta como venta,
+// registra su salida y fija el costo de cada ítem. Corre en su propia transacción porque el
+// comprobante ya está en el SRI: si falla, la factura se conserva marcada con StockPendiente.
+func actualizarInventarioFactura(factura *db.Factura, items []db.FacturaItem, reemplaza string) {
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if reemplaza != "" {
+			if err := revertirMovimientos(tx, reemplaza, MovDevolucion, "Reemplazada por factura corregida"); err != nil {
+				return err
+			}
+		}
+		if !estadosVenta[factura.EstadoSRI] {
+			return nil
+		}
+		if err := descontarVenta(tx, factura.ClaveAcceso, bodegaVentaClave(tx, factura.ClaveAcceso), items); err != nil {
+			return err
+		}
+		for i := range items {
+			if err := tx.Model(&items[i]).Update("costo_unitario", items[i].CostoUnitario).Error; err != nil {
+				return fmt.Errorf("error guardando costo de ítems: %v", err)
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return
+	}
+
+	logger.Error("Factura %s guardada sin descontar inventario: %v", factura.Secuencial, err)
+	factura.StockPendiente = true
+	factura.MensajeError = strings.TrimSpace(factura.MensajeError + " Inventario no descontado: " + err.Error())
+	db.GetDB().Model(&db.Factura{}).Where("clave_acceso = ?", factura.ClaveAcceso).
+		Updates(map[string]interface{}{"stock_pendiente": true, "mensaje_error": factura.MensajeError})
+}
+
+// revertirVentaRechazada devuelve al inventario lo que descontó una factura que el SRI terminó
+// rechazando (envío diferido): un comprobante DEVUELTA o NO AUTORIZADO no es una venta.
+func revertirVentaRechazada(factura *db.Factura) {
+	if !estadosCorregibles[factura.EstadoSRI] {
+		return
+	}
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		return revertirMovimientos(tx, factura.ClaveAcceso, MovDevolucion, "Rechazada por el SRI: "+factura.EstadoSRI)
+	})
+	if err != nil {
+		logger.Error("Error revirtiendo inventario de factura %s: %v", factura.Secuencial, err)
+	}
+}
+
+// cargarEmisor obtiene y valida la configuración del emisor.
+func (s *InvoiceService) cargarEmisor() (*db.EmisorConfig, error) {
 	var config db.EmisorConfig
 	if err := db.GetDB().First(&config).Error; err != nil {
-		return fmt.Errorf("emisor no configurado: %v", err)
+		return nil, fmt.Errorf("emisor no configurado: %v", err)
 	}
-	
+
 	// Validar RUC del Emisor (CRÍTICO: debe tener 13 dígitos)
 	if len(config.RUC) != 13 {
-		return fmt.Errorf("configuración inválida: El RUC del emisor tiene %d dígitos, debe tener 13", len(config.RUC))
+		return nil, fmt.Errorf("configuración inválida: El RUC del emisor tiene %d dígitos, debe tener 13", len(config.RUC))
+	}
+	return &config, nil
+}
+
+// calcularYValidar es la fase pura de la emisión: aplica las reglas SRI y calcula los totales.
+func (s *InvoiceService) calcularYValidar(config *db.EmisorConfig, dto *db.FacturaDTO) (*calculoFactura, error) {
+	// Unidad de venta de cada ítem y su equivalencia en unidades base del stock
+	if err := resolverUnidades(db.GetDB(), dto.Items); err != nil {
+		return nil, err
+	}
+	// Precios de la lista del cliente y promociones, antes de validar los montos
+	aplicarListaPrecios(db.GetDB(), dto.ClienteID, dto.Items, time.Now())
+	aplicarPromociones(db.GetDB(), dto.ClienteID, dto.Items, time.Now())
+	if err := s.validarFactura(config, dto); err != nil {
+		return nil, err
 	}
 
-	// VALIDACIONES NORMATIVA SRI 2025/2026
+	// Si la forma de pago viene vacía, asignamos "01" por defecto (si cumple reglas)
+	if dto.FormaPago == "" {
+		dto.FormaPago = "01"
+	}
 
+	return calcularFactura(dto), nil
+}
+
+// advertenciasFactura devuelve avisos no bloqueantes para mostrar al cajero antes de emitir.
+func advertenciasFactura(dto *db.FacturaDTO, calculo *calculoFactura) []string {
+	advertencias := []string{}
+	if dto.ClienteID == "9999999999999" && calculo.ImporteTotal > 50.00 {
+		advertencias = append(advertencias, "Consumidor final no permitido para montos mayores a $50: registre los datos del cliente.")
+	}
+	if calculo.ImporteTotal >= 1000.00 && (dto.FormaPago == "01" || dto.FormaPago == "") {
+		advertencias = append(advertencias, "Montos desde $1,000 requieren uso del sistema financiero (forma de pago distinta de '01').")
+	}
+	if dto.ClienteEmail == "" {
+		advertencias = append(advertencias, "El cliente no tiene correo electrónico: el RIDE no se enviará automáticamente.")
+	}
+	return advertencias
+}
+
+// validarFactura aplica las reglas de la normativa SRI 2025/2026 sobre el DTO.
+func (s *InvoiceService) validarFactura(config *db.EmisorConfig, dto *db.FacturaDTO) error {
 	// Regla 6: Validación de productos
 	if len(dto.Items) == 0 {
 		return fmt.Errorf("error validación: la factura debe tener al menos un ítem")
@@ -62,13 +615,16 @@ func (s *InvoiceService) EmitirFactura(dto *db.FacturaDTO) error {
 		if len(item.Codigo) == 0 {
 			return fmt.Errorf("normativa 2025: todos los ítems deben tener código principal (SKU)")
 		}
+		if utf8.RuneCountInString(strings.TrimSpace(item.Detalle)) > maxValorDetAdicional {
+			return fmt.Errorf("error validación: el detalle de %s supera los %d caracteres", item.Codigo, maxValorDetAdicional)
+		}
 	}
 
 	// Regla 2: Uso sistema financiero > $1000
 	// Calculamos el total preliminar para validar
 	var totalValidacion float64
 	for _, item := range dto.Items {
-		base := util.Round(item.Cantidad*item.Precio, 2)
+		base := util.Round(item.Cantidad*item.Precio, 2) - util.Round(item.Descuento, 2)
 		impuesto := util.Round(base*(item.PorcentajeIVA/100), 2)
 		totalValidacion += base + impuesto
 	}
@@ -83,17 +639,110 @@ func (s *InvoiceService) EmitirFactura(dto *db.FacturaDTO) error {
 			return fmt.Errorf("normativa SRI: consumidor final no permitido para montos mayores a $50 (se requieren datos reales)")
 		}
 	}
-	
-	// Si la forma de pago viene vacía, asignamos "01" por defecto (si cumple reglas)
-	if dto.FormaPago == "" {
-		dto.FormaPago = "01" 
+	return nil
+}
+
+// generarClaveAcceso arma la clave de 49 dígitos.
+// Algoritmo estándar del SRI: Fecha + Tipo + RUC + Ambiente + Serie + Secuencial + Código + TipoEmisión + DigitoVerificador
+func generarClaveAcceso(config *db.EmisorConfig, fechaEmision time.Time, secuencial string, codigoNum string) string {
+	estabStr, ptoEmiStr := serieEmisor(config)
+	fechaStr := fechaEmision.Format("02012006")
+	tipoDoc := "01" // Factura
+	ambiente := fmt.Sprintf("%d", config.Ambiente)
+	emision := "1" // Normal
+
+	clavePrevia := fechaStr + tipoDoc + config.RUC + ambiente + estabStr + ptoEmiStr + secuencial + codigoNum + emision
+	digito := util.CalcularDigitoModulo11(clavePrevia)
+	return fmt.Sprintf("%s%d", clavePrevia, digito)
+}
+
+// codigoNumerico devuelve el código de seguridad de la clave de acceso.
+// Cada reintento usa un código distinto para no colisionar con claves ya registradas en el SRI.
+func codigoNumerico(intento int) string {
+	return fmt.Sprintf("%08d", 12345678+intento)
+}
+
+// serieEmisor devuelve establecimiento y punto de emisión con padding de 3 dígitos.
+func serieEmisor(config *db.EmisorConfig) (string, string) {
+	var nEstab, nPtoEmi int
+	fmt.Sscanf(config.Estab, "%d", &nEstab)
+	fmt.Sscanf(config.PtoEmi, "%d", &nPtoEmi)
+	return fmt.Sprintf("%03d", nEstab), fmt.Sprintf("%03d", nPtoEmi)
+}
+
+// procesarComprobante construye el XML, lo firma, lo envía al SRI y genera el RIDE.
+// Devuelve el registro de factura listo para persistir (no toca la base de datos).
+func (s *InvoiceService) procesarComprobante(config *db.EmisorConfig, dto *db.FacturaDTO, calculo *calculoFactura, secuencialStr, claveAcceso string, fechaEmision time.Time) (*xml.FacturaXML, *db.Factura, error) {
+	facturaXML, facturaDB := construirFacturaXML(config, dto, calculo, secuencialStr, claveAcceso, fechaEmision)
+
+	xmlData, err := xml.GenerateXML(facturaXML)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 6. Firmar XML
+	// Descifrar contraseña
+	p12Pass, err := crypto.Decrypt(config.P12Password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error descifrando contraseña de firma: %v", err)
 	}
 
+	signer, err := crypto.NewSignerFromFile(config.P12Path, p12Pass)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error cargando firma: %v", err)
+	}
+
+	xmlFirmado, err := signer.SignXML(xmlData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error firmando xml: %v", err)
+	}
+
+	facturaDB.XMLFirmado = xmlFirmado
+
+	// 7. Enviar al SRI (Recepción y Autorización)
+	s.enviarYAutorizar(facturaDB)
+
+	// 9. Generar RIDE (PDF) si no hubo error fatal técnico (Offline sí genera PDF)
+	if facturaDB.EstadoSRI != "ERROR_TECNICO" {
+		pdfBytes, errPdf := pdf.GenerarRIDE(*facturaXML, config.LogoPath, config.PDFTheme)
+		if errPdf != nil {
+			logger.Error("Error generando RIDE: %v", errPdf)
+		} else {
+			facturaDB.PDFRIDE = pdfBytes
+		}
+	}
+
+	return facturaXML, facturaDB, nil
+}
+
+// calculoFactura agrupa el resultado de la fase pura de cálculo de una factura.
+type calculoFactura struct {
+	Detalles          []xml.Detalle
+	TotalConImpuestos []xml.TotalImpuesto
+	TotalSinImpuestos float64
+	ImporteTotal      float64
+	SubtotalGravado   float64
+	SubtotalCero      float64
+	TotalIVA          float64
+	TotalDescuento    float64
+}
 
-	// 2. Preparar Datos y Cálculos (Regla 1: IVA Dinámico)
+// maxValorDetAdicional es el máximo de caracteres que el SRI admite en el valor de un detalle adicional.
+const maxValorDetAdicional = 300
+
+// detAdicional arma un detalle adicional recortando el valor al máximo que admite el SRI.
+func detAdicional(nombre, valor string) xml.DetAdicional {
+	if r := []rune(valor); len(r) > maxValorDetAdicional {
+		valor = string(r[:maxValorDetAdicional-3]) + "..."
+	}
+	return xml.DetAdicional{Nombre: nombre, Valor: valor}
+}
+
+// calcularFactura calcula detalles y totales (Regla 1: IVA Dinámico). No tiene efectos secundarios.
+func calcularFactura(dto *db.FacturaDTO) *calculoFactura {
 	var detallesXML []xml.Detalle
 	var totalConImpuestos []xml.TotalImpuesto
-	
+
 	// Mapa para agrupar bases imponibles por código de impuesto
 	// Key: CodigoPorcentaje ("0", "2", "4", "5"), Value: BaseImponible
 	basesImponibles := make(map[string]struct {
@@ -102,19 +751,34 @@ func (s *InvoiceService) EmitirFactura(dto *db.FacturaDTO) error {
 		Tarifa float64
 	})
 
+	var totalDescuento float64
 	for _, item := range dto.Items {
-		precioTotalSinImpuesto := util.Round(item.Cantidad*item.Precio, 2)
+		// El descuento de la línea (promoción o manual) se resta antes de impuestos
+		descuento := util.Round(item.Descuento, 2)
+		precioTotalSinImpuesto := util.Round(util.Round(item.Cantidad*item.Precio, 2)-descuento, 2)
+		totalDescuento += descuento
 
 		// Crear detalle XML
 		detalle := xml.Detalle{
 			CodigoPrincipal:        item.Codigo,
 			Descripcion:            item.Nombre,
+			UnidadMedida:           item.Unidad,
 			Cantidad:               item.Cantidad, // Cantidad permitimos hasta 6, no redondeamos agresivamente aquí
 			PrecioUnitario:         item.Precio,   // Unitario hasta 6
-			Descuento:              0.00,
+			Descuento:              descuento,
 			PrecioTotalSinImpuesto: precioTotalSinImpuesto,
 			Impuestos:              []xml.Impuesto{},
 		}
+		adicionales := []xml.DetAdicional{}
+		if item.Promocion != "" {
+			adicionales = append(adicionales, detAdicional("Promoción", item.Promocion))
+		}
+		if detalleLinea := strings.TrimSpace(item.Detalle); detalleLinea != "" {
+			adicionales = append(adicionales, detAdicional("Detalle", detalleLinea))
+		}
+		if len(adicionales) > 0 {
+			detalle.DetallesAdicionales = &xml.DetallesAdicionales{DetAdicional: adicionales}
+		}
 
 		// Cálculo Impuesto Dinámico
 		valorIVA := util.Round(precioTotalSinImpuesto*(item.PorcentajeIVA/100), 2)
@@ -137,10 +801,16 @@ func (s *InvoiceService) EmitirFactura(dto *db.FacturaDTO) error {
 		detallesXML = append(detallesXML, detalle)
 	}
 
-	// Construir resumen de impuestos (TotalConImpuestos)
+	// Construir resumen de impuestos (TotalConImpuestos) en orden estable
 	var importeTotal, totalSinImpuestos float64
-	
-	for codigo, datos := range basesImponibles {
+	codigos := make([]string, 0, len(basesImponibles))
+	for codigo := range basesImponibles {
+		codigos = append(codigos, codigo)
+	}
+	sort.Strings(codigos)
+
+	for _, codigo := range codigos {
+		datos := basesImponibles[codigo]
 		totalConImpuestos = append(totalConImpuestos, xml.TotalImpuesto{
 			Codigo:           "2",
 			CodigoPorcentaje: codigo,
@@ -150,41 +820,42 @@ func (s *InvoiceService) EmitirFactura(dto *db.FacturaDTO) error {
 		importeTotal += datos.Base + datos.Valor
 		totalSinImpuestos += datos.Base
 	}
-	
+
 	// Redondeo final de totales globales
 	importeTotal = util.Round(importeTotal, 2)
 	totalSinImpuestos = util.Round(totalSinImpuestos, 2)
 
-	// 3. Formateo Estricto SRI (Padding)
-	// RECALCULAR SECUENCIAL: Ignoramos el del DTO por ser inseguro (concurrencia)
-	// y obtenemos el verdadero siguiente disponible.
-	realSec, _ := s.GetNextSecuencial()
-	var nEstab, nPtoEmi, nSec int
-	fmt.Sscanf(config.Estab, "%d", &nEstab)
-	fmt.Sscanf(config.PtoEmi, "%d", &nPtoEmi)
-	fmt.Sscanf(realSec, "%d", &nSec)
+	// Calcular subtotales para DB
+	var subtotalGravado, subtotalCero, totalIVA float64
 
-	estabStr := fmt.Sprintf("%03d", nEstab)
-	ptoEmiStr := fmt.Sprintf("%03d", nPtoEmi)
-	secuencialStr := fmt.Sprintf("%09d", nSec)
+	if datos, ok := basesImponibles["0"]; ok {
+		subtotalCero = datos.Base
+	}
 
-	// Actualizar DTO para reflejar el real usado
-	dto.Secuencial = secuencialStr
+	// Sumar cualquier otra base gravada (2, 4, 5, etc)
+	for codigo, datos := range basesImponibles {
+		if codigo != "0" {
+			subtotalGravado += datos.Base
+			totalIVA += datos.Valor
+		}
+	}
 
-	// 4. Generar Clave de Acceso (49 dígitos)
-	// Algoritmo estándar del SRI: Fecha + Tipo + RUC + Ambiente + Serie + Secuencial + Código + TipoEmisión + DigitoVerificador
-	fechaEmision := time.Now()
-	fechaStr := fechaEmision.Format("02012006")
-	tipoDoc := "01" // Factura
-	ruc := config.RUC
-	ambiente := fmt.Sprintf("%d", config.Ambiente)
-	serie := estabStr + ptoEmiStr
-	emision := "1"          // Normal
-	codigoNum := "12345678" // Código de seguridad fijo (puede ser aleatorio)
+	return &calculoFactura{
+		Detalles:          detallesXML,
+		TotalConImpuestos: totalConImpuestos,
+		TotalSinImpuestos: totalSinImpuestos,
+		ImporteTotal:      importeTotal,
+		SubtotalGravado:   subtotalGravado,
+		SubtotalCero:      subtotalCero,
+		TotalIVA:          totalIVA,
+		TotalDescuento:    util.Round(totalDescuento, 2),
+	}
+}
 
-	clavePrevia := fechaStr + tipoDoc + ruc + ambiente + serie + secuencialStr + codigoNum + emision
-	digito := util.CalcularDigitoModulo11(clavePrevia)
-	claveAcceso := fmt.Sprintf("%s%d", clavePrevia, digito)
+// construirFacturaXML arma la estructura XML a partir de un cálculo ya realizado,
+// junto con el registro de base de datos correspondiente.
+func construirFacturaXML(config *db.EmisorConfig, dto *db.FacturaDTO, calculo *calculoFactura, secuencialStr, claveAcceso string, fechaEmision time.Time) (*xml.FacturaXML, *db.Factura) {
+	estabStr, ptoEmiStr := serieEmisor(config)
 
 	// Determinar dirección matriz (fallback a Razon Social si vacía)
 	dirMatriz := config.Direccion
@@ -192,81 +863,50 @@ func (s *InvoiceService) EmitirFactura(dto *db.FacturaDTO) error {
 		dirMatriz = config.RazonSocial
 	}
 
-		// 5. Construir XML Completo con campos formateados (REFACTORIZADO: 100% DINÁMICO)
-
-		facturaXML := &xml.FacturaXML{
-
-			Version: "1.1.0",
-
-			ID:      "comprobante",
-
-			InfoTributaria: xml.InfoTributaria{
-
-				Ambiente:        ambiente,
-
-				TipoEmision:     emision,
-
-				RazonSocial:     config.RazonSocial,
-
-				NombreComercial: config.NombreComercial, // Ahora dinámico
-
-				Ruc:             ruc,                     // Variable unificada con ClaveAcceso
-
-				ClaveAcceso:     claveAcceso,
-
-				CodDoc:          tipoDoc,
-
-				Estab:           estabStr,
-
-				PtoEmi:          ptoEmiStr,
-
-				Secuencial:      secuencialStr,
-
-				DirMatriz:       dirMatriz,
-				ContribuyenteRimpe: config.ContribuyenteRimpe,
-				AgenteRetencion:    config.AgenteRetencion,
-			},
-
-			InfoFactura: xml.InfoFactura{
-
-				FechaEmision:                fechaEmision.Format("02/01/2006"),
-
-				DirEstablecimiento:          dirMatriz, // Usamos DirMatriz también aquí por defecto
-
-				ObligadoContabilidad:        "NO",
-
-				TipoIdentificacionComprador: "05", // Cédula por defecto
-
-				RazonSocialComprador:        dto.ClienteNombre,
-
-				IdentificacionComprador:     dto.ClienteID,
-
-				DireccionComprador:          dto.ClienteDireccion,
-
-				TotalSinImpuestos:           totalSinImpuestos,
-
-				TotalDescuento:              0.00,
-
-				TotalConImpuestos:           totalConImpuestos,
-
-				ImporteTotal:                importeTotal,
-
-				Moneda:                      "DOLAR",
-
-				Pagos: []xml.Pago{
-					{
-						FormaPago:    dto.FormaPago,
-						Total:        importeTotal,
-						Plazo:        dto.Plazo,
-						UnidadTiempo: dto.UnidadTiempo,
-					},
+	// Construir XML Completo con campos formateados (100% DINÁMICO)
+	facturaXML := &xml.FacturaXML{
+		Version: "1.1.0",
+		ID:      "comprobante",
+		InfoTributaria: xml.InfoTributaria{
+			Ambiente:           fmt.Sprintf("%d", config.Ambiente),
+			TipoEmision:        "1",
+			RazonSocial:        config.RazonSocial,
+			NombreComercial:    config.NombreComercial,
+			Ruc:                config.RUC, // Variable unificada con ClaveAcceso
+			ClaveAcceso:        claveAcceso,
+			CodDoc:             "01",
+			Estab:              estabStr,
+			PtoEmi:             ptoEmiStr,
+			Secuencial:         secuencialStr,
+			DirMatriz:          dirMatriz,
+			ContribuyenteRimpe: config.ContribuyenteRimpe,
+			AgenteRetencion:    config.AgenteRetencion,
+		},
+		InfoFactura: xml.InfoFactura{
+			FechaEmision:                fechaEmision.Format("02/01/2006"),
+			DirEstablecimiento:          dirMatriz, // Usamos DirMatriz también aquí por defecto
+			ObligadoContabilidad:        "NO",
+			TipoIdentificacionComprador: "05", // Cédula por defecto
+			RazonSocialComprador:        dto.ClienteNombre,
+			IdentificacionComprador:     dto.ClienteID,
+			DireccionComprador:          dto.ClienteDireccion,
+			TotalSinImpuestos:           calculo.TotalSinImpuestos,
+			TotalDescuento:              calculo.TotalDescuento,
+			TotalConImpuestos:           calculo.TotalConImpuestos,
+			ImporteTotal:                calculo.ImporteTotal,
+			Moneda:                      "DOLAR",
+			Pagos: []xml.Pago{
+				{
+					FormaPago:    dto.FormaPago,
+					Total:        calculo.ImporteTotal,
+					Plazo:        dto.Plazo,
+					UnidadTiempo: dto.UnidadTiempo,
 				},
-
 			},
-
-			Detalles: detallesXML,
-
-		}
+		},
+		// Lotes FEFO que saldrán de la bodega del punto de emisión
+		Detalles: anotarLotes(db.GetDB(), bodegaVentaClave(db.GetDB(), claveAcceso), anotarComponentes(db.GetDB(), calculo.Detalles)),
+	}
 
 	if config.Obligado {
 		facturaXML.InfoFactura.ObligadoContabilidad = "SI"
@@ -287,144 +927,102 @@ func (s *InvoiceService) EmitirFactura(dto *db.FacturaDTO) error {
 		facturaXML.InfoAdicional = append(facturaXML.InfoAdicional, xml.CampoAdicional{Nombre: "Direccion", Value: dto.ClienteDireccion})
 	}
 
-	xmlData, err := xml.GenerateXML(facturaXML)
-
-	if err != nil {
-
-		return err
-
-	}
-
-	// Calcular subtotales para DB
-	var subtotalGravado, subtotalCero, totalIVA float64
-	
-	if datos, ok := basesImponibles["0"]; ok {
-		subtotalCero = datos.Base
-	}
-	
-	// Sumar cualquier otra base gravada (2, 4, 5, etc)
-	for codigo, datos := range basesImponibles {
-		if codigo != "0" {
-			subtotalGravado += datos.Base
-			totalIVA += datos.Valor
-		}
-	}
-
-	// 6. Crear Registro en DB (Factura)
+	// Registro en DB (Factura)
 	facturaDB := &db.Factura{
 		ClaveAcceso:  claveAcceso,
 		Secuencial:   secuencialStr,
 		FechaEmision: fechaEmision,
 		ClienteID:    dto.ClienteID,
-		Total:        importeTotal,
-		Subtotal15:   subtotalGravado, // Reutilizamos campo para Base Gravada
-		Subtotal0:    subtotalCero,
-		IVA:          totalIVA,
+		Total:        calculo.ImporteTotal,
+		Subtotal15:   calculo.SubtotalGravado, // Reutilizamos campo para Base Gravada
+		Subtotal0:    calculo.SubtotalCero,
+		IVA:          calculo.TotalIVA,
+		FormaPago:    dto.FormaPago,
 		EstadoSRI:    "PENDIENTE",
 	}
 
-	// 6. Firmar XML
-	// Descifrar contraseña
-	p12Pass, err := crypto.Decrypt(config.P12Password)
-	if err != nil {
-		return fmt.Errorf("error descifrando contraseña de firma: %v", err)
-	}
-
-	signer, err := crypto.NewSignerFromFile(config.P12Path, p12Pass)
-	if err != nil {
-		return fmt.Errorf("error cargando firma: %v", err)
-	}
-
-	xmlFirmado, err := signer.SignXML(xmlData)
-	if err != nil {
-		return fmt.Errorf("error firmando xml: %v", err)
-	}
+	return facturaXML, facturaDB
+}
 
-	facturaDB.XMLFirmado = xmlFirmado
+// enviarYAutorizar envía el XML firmado al SRI y actualiza el estado de la factura.
+func (s *InvoiceService) enviarYAutorizar(facturaDB *db.Factura) {
+	respRecepcion, err := s.sriClient.EnviarComprobante(facturaDB.XMLFirmado)
 
-	// 7. Enviar al SRI (Recepción)
-	respRecepcion, err := s.sriClient.EnviarComprobante(xmlFirmado)
-	
 	// Manejo de Errores de Red (Contingencia Offline)
 	if _, isNetworkError := err.(*sri.NetworkError); isNetworkError {
 		facturaDB.EstadoSRI = "PENDIENTE_ENVIO"
 		facturaDB.MensajeError = "SRI Offline: Documento guardado para envío posterior."
 		// No retornamos error, continuamos para generar PDF y guardar en DB
+		return
 	} else if err != nil {
 		// Error técnico fatal (ej. XML mal formado localmente)
 		facturaDB.EstadoSRI = "ERROR_TECNICO"
 		facturaDB.MensajeError = err.Error()
-	} else {
-		// Conexión exitosa, procesar respuesta SRI
-		if respRecepcion.Estado == "RECIBIDA" {
-			facturaDB.EstadoSRI = "RECIBIDA"
-			
-			// Esperar un momento antes de pedir autorización (latencia del SRI)
-			time.Sleep(2 * time.Second)
-
-			// 8. Solicitar Autorización
-			respAuth, errAuth := s.sriClient.AutorizarComprobante(claveAcceso)
-			if _, isNetErr := errAuth.(*sri.NetworkError); isNetErr {
-				// Recibida pero falló la consulta de autorización
-				facturaDB.EstadoSRI = "RECIBIDA" // Se queda así, el worker verificará luego
-				facturaDB.MensajeError = "Documento recibido. Verificación de autorización pendiente por red."
-			} else if errAuth != nil {
-				facturaDB.EstadoSRI = "ERROR_AUTH"
-				facturaDB.MensajeError = errAuth.Error()
-			} else {
-				// Buscar autorización válida
-				autorizado := false
-				for _, auth := range respAuth.Autorizaciones.Autorizacion {
-					if auth.Estado == "AUTORIZADO" {
-						facturaDB.EstadoSRI = "AUTORIZADO"
-						facturaDB.MensajeError = "" // Limpiar errores previos
-						// Aquí podríamos guardar el XML autorizado que devuelve el SRI (tiene fecha y número)
-						autorizado = true
-						break
-					} else {
-						// Concatenar mensajes de rechazo
-						msg := fmt.Sprintf("[%s]", auth.Estado)
-						for _, m := range auth.Mensajes.Mensaje {
-							msg += fmt.Sprintf(" %s: %s;", m.Identificador, m.Mensaje)
-						}
-						facturaDB.MensajeError = msg
-						facturaDB.EstadoSRI = auth.Estado
-					}
-				}
-				if !autorizado && facturaDB.EstadoSRI == "RECIBIDA" {
-					// Caso raro: Recibida pero sin respuesta clara de autorización
-					facturaDB.MensajeError = "Documento recibido pero no se obtuvo respuesta de autorización."
-				}
-			}
+		return
+	}
 
-		} else {
-			// DEVUELTA
-			facturaDB.EstadoSRI = respRecepcion.Estado
-			msg := ""
-			for _, comp := range respRecepcion.Comprobantes.Comprobante {
-				for _, m := range comp.Mensajes.Mensaje {
-					msg += fmt.Sprintf("%s: %s (%s); ", m.Identificador, m.Mensaje, m.InformacionAdicional)
-				}
+	// Conexión exitosa, procesar respuesta SRI
+	if respRecepcion.Estado != "RECIBIDA" {
+		// DEVUELTA
+		facturaDB.EstadoSRI = respRecepcion.Estado
+		msg := ""
+		for _, comp := range respRecepcion.Comprobantes.Comprobante {
+			for _, m := range comp.Mensajes.Mensaje {
+				msg += fmt.Sprintf("%s: %s (%s); ", m.Identificador, m.Mensaje, m.InformacionAdicional)
 			}
-			facturaDB.MensajeError = msg
 		}
+		facturaDB.MensajeError = msg
+		return
 	}
 
-	// 9. Generar RIDE (PDF) si no hubo error fatal técnico (Offline sí genera PDF)
-	if facturaDB.EstadoSRI != "ERROR_TECNICO" {
-		pdfBytes, errPdf := pdf.GenerarRIDE(*facturaXML, config.LogoPath, config.PDFTheme)
-		if errPdf != nil {
-			logger.Error("Error generando RIDE: %v", errPdf)
+	facturaDB.EstadoSRI = "RECIBIDA"
+
+	// Esperar un momento antes de pedir autorización (latencia del SRI)
+	time.Sleep(2 * time.Second)
+
+	// 8. Solicitar Autorización
+	respAuth, errAuth := s.sriClient.AutorizarComprobante(facturaDB.ClaveAcceso)
+	if _, isNetErr := errAuth.(*sri.NetworkError); isNetErr {
+		// Recibida pero falló la consulta de autorización
+		facturaDB.EstadoSRI = "RECIBIDA" // Se queda así, el worker verificará luego
+		facturaDB.MensajeError = "Documento recibido. Verificación de autorización pendiente por red."
+		return
+	} else if errAuth != nil {
+		facturaDB.EstadoSRI = "ERROR_AUTH"
+		facturaDB.MensajeError = errAuth.Error()
+		return
+	}
+
+	// Buscar autorización válida
+	autorizado := false
+	for _, auth := range respAuth.Autorizaciones.Autorizacion {
+		if auth.Estado == "AUTORIZADO" {
+			facturaDB.EstadoSRI = "AUTORIZADO"
+			facturaDB.MensajeError = "" // Limpiar errores previos
+			// Aquí podríamos guardar el XML autorizado que devuelve el SRI (tiene fecha y número)
+			autorizado = true
+			break
 		} else {
-			facturaDB.PDFRIDE = pdfBytes
+			// Concatenar mensajes de rechazo
+			msg := fmt.Sprintf("[%s]", auth.Estado)
+			for _, m := range auth.Mensajes.Mensaje {
+				msg += fmt.Sprintf(" %s: %s;", m.Identificador, m.Mensaje)
+			}
+			facturaDB.MensajeError = msg
+			facturaDB.EstadoSRI = auth.Estado
 		}
 	}
+	if !autorizado && facturaDB.EstadoSRI == "RECIBIDA" {
+		// Caso raro: Recibida pero sin respuesta clara de autorización
+		facturaDB.MensajeError = "Documento recibido pero no se obtuvo respuesta de autorización."
+	}
+}
 
-	// 10. Auto-Guardar Cliente (Upsert)
-	// Si el cliente no existe, lo creamos. Si existe, actualizamos nombre/dirección.
+// upsertClienteFactura crea el cliente si no existe o actualiza sus datos básicos.
+func upsertClienteFactura(dto *db.FacturaDTO) {
 	var cliente db.Client
 	var tipoID string
-	
+
 	// Inferencia simple de TipoID
 	if dto.ClienteID == "9999999999999" {
 		tipoID = "07" // Consumidor Final
@@ -460,30 +1058,45 @@ func (s *InvoiceService) EmitirFactura(dto *db.FacturaDTO) error {
 		}
 		db.GetDB().Save(&cliente)
 	}
+}
 
-	// 11. Guardar Factura en Base de Datos
-	if err := db.GetDB().Create(facturaDB).Error; err != nil {
-		return fmt.Errorf("error guardando factura en DB: %v", err)
-	}
-
-	// 12. Guardar Items de Factura para Reportería
+// itemsFactura convierte los ítems del DTO en registros para reportería.
+func itemsFactura(claveAcceso string, dto *db.FacturaDTO) []db.FacturaItem {
+	items := make([]db.FacturaItem, 0, len(dto.Items))
 	for _, item := range dto.Items {
-		facturaItem := db.FacturaItem{
+		items = append(items, db.FacturaItem{
 			FacturaClave:   claveAcceso,
 			ProductoSKU:    item.Codigo,
 			Nombre:         item.Nombre,
 			Cantidad:       item.Cantidad,
+			Unidad:         item.Unidad,
+			Factor:         item.Factor,
 			PrecioUnitario: item.Precio,
-			Subtotal:       item.Cantidad * item.Precio,
+			Descuento:      item.Descuento,
+			PromocionID:    item.PromocionID,
+			Promocion:      item.Promocion,
+			Detalle:        strings.TrimSpace(item.Detalle),
+			Subtotal:       item.Cantidad*item.Precio - item.Descuento,
 			PorcentajeIVA:  item.PorcentajeIVA,
-		}
-		db.GetDB().Create(&facturaItem)
+		})
 	}
+	return items
+}
 
-	// Actualizar DTO de retorno con la clave generada
-
-	dto.ClaveAcceso = claveAcceso
-
-	return nil
-
+// codigoIVADesdePorcentaje infiere el código de porcentaje SRI a partir de la tarifa.
+func codigoIVADesdePorcentaje(porcentaje float64) string {
+	switch porcentaje {
+	case 0:
+		return "0"
+	case 12:
+		return "2"
+	case 14:
+		return "3"
+	case 15:
+		return "4"
+	case 5:
+		return "5"
+	default:
+		return "4"
+	}
 }
@@ -2,6 +2,9 @@ package service
 
 import (
 	"kushkiv2/internal/db"
+	"kushkiv2/pkg/xml"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -66,3 +69,257 @@ func TestEmitirFactura_Validations(t *testing.T) {
 		t.Error("Se esperaba error por monto > $1000 sin sistema financiero")
 	}
 }
+
+func TestCargarFacturaRechazada(t *testing.T) {
+	database := setupTestDB()
+	svc := NewInvoiceService()
+
+	config := &db.EmisorConfig{RUC: "1790011223001", RazonSocial: "Emisor", Estab: "001", PtoEmi: "001", Ambiente: 1}
+	dto := &db.FacturaDTO{
+		ClienteID:     "1712345678",
+		ClienteNombre: "Juan Perez",
+		ClienteEmail:  "juan@test.com",
+		Observacion:   "Pedido 45",
+		FormaPago:     "20",
+		Items: []db.InvoiceItem{
+			{Codigo: "P1", Nombre: "Prod 1", Cantidad: 2, Precio: 10, CodigoIVA: "4", PorcentajeIVA: 15},
+		},
+	}
+	clave := generarClaveAcceso(config, time.Now(), "000000007", codigoNumerico(0))
+	facturaXML, facturaDB := construirFacturaXML(config, dto, calcularFactura(dto), "000000007", clave, time.Now())
+	xmlData, _ := xml.GenerateXML(facturaXML)
+	facturaDB.XMLFirmado = xmlData
+	facturaDB.EstadoSRI = "DEVUELTA"
+	database.Create(facturaDB)
+
+	cargada, err := svc.CargarFacturaRechazada(clave)
+	if err != nil {
+		t.Fatalf("Error cargando factura rechazada: %v", err)
+	}
+	if cargada.ClienteNombre != "Juan Perez" || cargada.ClienteEmail != "juan@test.com" || cargada.Observacion != "Pedido 45" {
+		t.Errorf("Datos de cliente no reconstruidos: %+v", cargada)
+	}
+	if cargada.FormaPago != "20" || cargada.Secuencial != "000000007" {
+		t.Errorf("Forma de pago o secuencial incorrectos: %+v", cargada)
+	}
+	if len(cargada.Items) != 1 || cargada.Items[0].CodigoIVA != "4" || cargada.Items[0].Cantidad != 2 {
+		t.Errorf("Items no reconstruidos: %+v", cargada.Items)
+	}
+
+	// Una factura autorizada no puede corregirse
+	database.Model(&db.Factura{}).Where("clave_acceso = ?", clave).Update("estado_sri", "AUTORIZADO")
+	if _, err := svc.CargarFacturaRechazada(clave); err == nil {
+		t.Error("Se esperaba error al cargar una factura AUTORIZADA")
+	}
+	if err := svc.ReenviarFacturaCorregida(clave, dto); err == nil {
+		t.Error("Se esperaba error al reenviar una factura AUTORIZADA")
+	}
+}
+
+func TestReemplazarFacturaRechazada(t *testing.T) {
+	database := setupTestDB()
+	svc := NewInvoiceService()
+
+	creada := time.Now().Add(-time.Hour)
+	original := db.Factura{ClaveAcceso: "CLAVE_VIEJA", Secuencial: "000000003", Total: 10, EstadoSRI: "NO AUTORIZADO", MensajeError: "RUC inválido", CreatedAt: creada}
+	database.Create(&original)
+	database.Create(&db.FacturaItem{FacturaClave: "CLAVE_VIEJA", ProductoSKU: "P1", Cantidad: 1})
+
+	nueva := &db.Factura{ClaveAcceso: "CLAVE_NUEVA", Secuencial: "000000003", Total: 12, EstadoSRI: "AUTORIZADO"}
+	items := []db.FacturaItem{{FacturaClave: "CLAVE_NUEVA", ProductoSKU: "P2", Cantidad: 2}}
+
+	if err := reemplazarFacturaRechazada(&original, nueva, items); err != nil {
+		t.Fatalf("Error reemplazando factura: %v", err)
+	}
+
+	var count int64
+	database.Model(&db.Factura{}).Where("clave_acceso = ?", "CLAVE_VIEJA").Count(&count)
+	if count != 0 {
+		t.Error("La factura original debió ser reemplazada")
+	}
+	database.Model(&db.FacturaItem{}).Where("factura_clave = ?", "CLAVE_NUEVA").Count(&count)
+	if count != 1 {
+		t.Errorf("Esperado 1 item para la nueva clave, obtenido %d", count)
+	}
+
+	historial, _ := svc.GetHistorial("CLAVE_NUEVA")
+	if len(historial) != 1 || historial[0].ClaveAcceso != "CLAVE_VIEJA" || historial[0].EstadoSRI != "NO AUTORIZADO" {
+		t.Errorf("Historial incorrecto: %+v", historial)
+	}
+
+	// El secuencial no debe avanzar por el reenvío
+	sec, _ := svc.GetNextSecuencial()
+	if sec != "000000004" {
+		t.Errorf("Esperado 000000004, obtenido %s", sec)
+	}
+}
+
+func TestActualizarInventarioFactura(t *testing.T) {
+	database := setupTestDB()
+	database.Create(&db.Product{SKU: "P1", Name: "Producto 1", Barcode: "P1", Stock: 10, Cost: 2})
+
+	guardar := func(clave, estado string, items []db.FacturaItem) *db.Factura {
+		factura := &db.Factura{ClaveAcceso: clave, Secuencial: clave, EstadoSRI: estado}
+		database.Create(factura)
+		for i := range items {
+			items[i].FacturaClave = clave
+			database.Create(&items[i])
+		}
+		return factura
+	}
+
+	t.Run("Autorizada", func(t *testing.T) {
+		items := []db.FacturaItem{{ProductoSKU: "P1", Cantidad: 3}}
+		actualizarInventarioFactura(guardar("CLAVE_AUT", "AUTORIZADO", items), items, "")
+		if got := stockDe(t, "P1"); got != 7 {
+			t.Errorf("Stock esperado 7, obtuve %g", got)
+		}
+		var item db.FacturaItem
+		database.First(&item, items[0].ID)
+		if item.CostoUnitario != 2 {
+			t.Errorf("Costo del ítem no guardado: %g", item.CostoUnitario)
+		}
+	})
+
+	t.Run("Rechazada", func(t *testing.T) {
+		items := []db.FacturaItem{{ProductoSKU: "P1", Cantidad: 3}}
+		actualizarInventarioFactura(guardar("CLAVE_DEV", "DEVUELTA", items), items, "")
+		if got := stockDe(t, "P1"); got != 7 {
+			t.Errorf("Una factura DEVUELTA no debe descontar stock, obtuve %g", got)
+		}
+	})
+
+	t.Run("Rechazo Diferido", func(t *testing.T) {
+		items := []db.FacturaItem{{ProductoSKU: "P1", Cantidad: 2}}
+		factura := guardar("CLAVE_OFF", "PENDIENTE_ENVIO", items)
+		actualizarInventarioFactura(factura, items, "")
+		if got := stockDe(t, "P1"); got != 5 {
+			t.Fatalf("Stock esperado 5, obtuve %g", got)
+		}
+		factura.EstadoSRI = "NO AUTORIZADO"
+		revertirVentaRechazada(factura)
+		if got := stockDe(t, "P1"); got != 7 {
+			t.Errorf("El rechazo del SRI debe devolver el stock, obtuve %g", got)
+		}
+	})
+
+	t.Run("Fallo Posterior al Envío", func(t *testing.T) {
+		// Kit cuyo componente ya no existe: la salida falla, la factura se conserva
+		database.Create(&db.Product{SKU: "KIT", Name: "Kit", Barcode: "KIT", EsKit: true})
+		database.Create(&db.KitComponent{KitSKU: "KIT", ComponentSKU: "BORRADO", Cantidad: 1})
+		items := []db.FacturaItem{{ProductoSKU: "KIT", Cantidad: 1}}
+		factura := guardar("CLAVE_KIT", "AUTORIZADO", items)
+		actualizarInventarioFactura(factura, items, "")
+
+		var guardada db.Factura
+		if err := database.First(&guardada, "clave_acceso = ?", "CLAVE_KIT").Error; err != nil {
+			t.Fatalf("La factura debe conservarse: %v", err)
+		}
+		if !guardada.StockPendiente || guardada.MensajeError == "" {
+			t.Errorf("Fallo de inventario no registrado: %+v", guardada)
+		}
+	})
+}
+
+func TestPrevisualizarFactura(t *testing.T) {
+	database := setupTestDB()
+	svc := NewInvoiceService()
+
+	dto := db.FacturaDTO{
+		ClienteID:     "9999999999999",
+		ClienteNombre: "CONSUMIDOR FINAL",
+		Items: []db.InvoiceItem{
+			{Codigo: "P1", Nombre: "Prod 1", Cantidad: 1, Precio: 100, CodigoIVA: "4", PorcentajeIVA: 15},
+			{Codigo: "P2", Nombre: "Prod 2", Cantidad: 2, Precio: 10, CodigoIVA: "0", PorcentajeIVA: 0},
+		},
+	}
+
+	preview, err := svc.PrevisualizarFactura(dto)
+	if err != nil {
+		t.Fatalf("Error en previsualización: %v", err)
+	}
+
+	if preview.ImporteTotal != 135.00 || preview.TotalIVA != 15.00 || preview.SubtotalCero != 20.00 {
+		t.Errorf("Totales incorrectos: %+v", preview)
+	}
+	if len(preview.TotalConImpuestos) != 2 || preview.TotalConImpuestos[0].CodigoPorcentaje != "0" {
+		t.Errorf("Desglose de impuestos incorrecto: %+v", preview.TotalConImpuestos)
+	}
+	// Consumidor final > $50 bloquea la emisión y además se advierte
+	if preview.Valida || preview.Error == "" {
+		t.Error("Se esperaba que la previsualización marque la factura como no válida")
+	}
+	if len(preview.Advertencias) != 2 {
+		t.Errorf("Esperadas 2 advertencias (consumidor final y correo), obtenidas %v", preview.Advertencias)
+	}
+	if !strings.Contains(preview.XML, "<importeTotal>135</importeTotal>") || strings.Contains(preview.XML, "Signature") {
+		t.Errorf("XML sin firmar inesperado: %s", preview.XML)
+	}
+	if preview.PDFBase64 == "" {
+		t.Error("No se generó el RIDE borrador")
+	}
+
+	// No consume secuencial ni persiste datos
+	var count int64
+	database.Model(&db.Factura{}).Count(&count)
+	if count != 0 || preview.Secuencial != "000000001" {
+		t.Errorf("La previsualización no debe tocar la base de datos (facturas: %d)", count)
+	}
+	database.Model(&db.Client{}).Count(&count)
+	if count != 0 {
+		t.Error("La previsualización no debe crear clientes")
+	}
+}
+
+func TestReservarSecuencialConcurrente(t *testing.T) {
+	database := setupTestDB()
+	database.Create(&db.Factura{Secuencial: "000000010", ClaveAcceso: "previa"})
+	svc := NewInvoiceService()
+
+	var mu sync.Mutex
+	vistos := map[string]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sec, _ := svc.reservarSecuencial()
+			mu.Lock()
+			vistos[sec] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(vistos) != 20 || !vistos["000000011"] || !vistos["000000030"] {
+		t.Errorf("Secuenciales duplicados o fuera de rango: %v", vistos)
+	}
+}
+
+func TestLiberarSecuencial(t *testing.T) {
+	database := setupTestDB()
+	database.Model(&db.EmisorConfig{}).Where("1 = 1").Update("ruc", "1790011223001")
+	database.Create(&db.Factura{Secuencial: "000000010", ClaveAcceso: "previa"})
+	svc := NewInvoiceService()
+
+	// Sin firma configurada la emisión falla antes de enviar al SRI: el secuencial no se pierde
+	dto := &db.FacturaDTO{ClienteID: "9999999999999", ClienteNombre: "CONSUMIDOR FINAL", Items: []db.InvoiceItem{{Codigo: "S1", Nombre: "Servicio", Cantidad: 1, Precio: 10, CodigoIVA: "0"}}}
+	if err := svc.EmitirFactura(dto); err == nil {
+		t.Fatal("Se esperaba error de firma")
+	}
+	if sec, _ := svc.reservarSecuencial(); sec != "000000011" {
+		t.Errorf("El secuencial de la emisión fallida debió reutilizarse, obtuve %s", sec)
+	}
+
+	// Con otro secuencial mayor en curso, el liberado solo vuelve cuando ese también se libera
+	svc.reservarSecuencial() // 12
+	svc.liberarSecuencial("000000011")
+	if sec, _ := svc.reservarSecuencial(); sec != "000000013" {
+		t.Errorf("Esperado 000000013, obtuve %s", sec)
+	}
+	svc.liberarSecuencial("000000013")
+	svc.liberarSecuencial("000000012")
+	if sec, _ := svc.reservarSecuencial(); sec != "000000011" {
+		t.Errorf("Esperado 000000011 tras liberar los mayores, obtuve %s", sec)
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/xml"
+
+	"gorm.io/gorm"
+)
+
+type KitService struct{}
+
+func NewKitService() *KitService {
+	return &KitService{}
+}
+
+// ListarComponentes devuelve los componentes de un kit con su stock total.
+func (s *KitService) ListarComponentes(sku string) ([]db.KitComponentDTO, error) {
+	disp, err := disponibilidadKit(db.GetDB(), sku, "")
+	if err != nil {
+		return nil, err
+	}
+	return disp.Componentes, nil
+}
+
+// GuardarComponentes reemplaza los componentes de un kit y si se detallan en la factura. Con la
+// lista vacía el producto deja de ser kit. Un producto con stock propio no puede convertirse en
+// kit, y un kit no puede ser componente de otro.
+func (s *KitService) GuardarComponentes(sku string, detallar bool, componentes []db.KitComponentDTO) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var kit db.Product
+		if err := tx.First(&kit, "sku = ?", sku).Error; err != nil {
+			return fmt.Errorf("producto no encontrado: %s", sku)
+		}
+		if len(componentes) > 0 && kit.EsServicio {
+			return fmt.Errorf("%s es un servicio y no puede ser kit", sku)
+		}
+		if len(componentes) > 0 && !kit.EsKit && kit.Stock != 0 {
+			return fmt.Errorf("%s tiene stock propio (%g); ajústelo a cero antes de convertirlo en kit", sku, kit.Stock)
+		}
+		if len(componentes) > 0 {
+			var usos int64
+			tx.Model(&db.KitComponent{}).Where("component_sku = ?", sku).Count(&usos)
+			if usos > 0 {
+				return fmt.Errorf("%s es componente de otro kit", sku)
+			}
+		}
+
+		vistos := map[string]bool{}
+		nuevos := make([]db.KitComponent, 0, len(componentes))
+		for _, c := range componentes {
+			componente := strings.TrimSpace(c.SKU)
+			if componente == sku {
+				return fmt.Errorf("un kit no puede contenerse a sí mismo")
+			}
+			if vistos[componente] {
+				return fmt.Errorf("el componente %s está repetido", componente)
+			}
+			if c.Cantidad <= 0 {
+				return fmt.Errorf("la cantidad de %s debe ser mayor que cero", componente)
+			}
+			var product db.Product
+			if err := tx.First(&product, "sku = ?", componente).Error; err != nil {
+				return fmt.Errorf("componente no encontrado: %s", componente)
+			}
+			if product.EsKit {
+				return fmt.Errorf("%s es un kit y no puede ser componente", componente)
+			}
+			if product.EsServicio {
+				return fmt.Errorf("%s es un servicio y no puede ser componente", componente)
+			}
+			vistos[componente] = true
+			nuevos = append(nuevos, db.KitComponent{KitSKU: sku, ComponentSKU: componente, Cantidad: redondearCantidad(c.Cantidad)})
+		}
+
+		if err := tx.Where("kit_sku = ?", sku).Delete(&db.KitComponent{}).Error; err != nil {
+			return fmt.Errorf("error actualizando componentes: %v", err)
+		}
+		for i := range nuevos {
+			if err := tx.Create(&nuevos[i]).Error; err != nil {
+				return fmt.Errorf("error guardando componente %s: %v", nuevos[i].ComponentSKU, err)
+			}
+		}
+		updates := map[string]interface{}{"es_kit": len(nuevos) > 0, "detallar_kit": detallar && len(nuevos) > 0}
+		if err := tx.Model(&db.Product{}).Where("sku = ?", sku).Updates(updates).Error; err != nil {
+			return fmt.Errorf("error actualizando kit: %v", err)
+		}
+		return nil
+	})
+}
+
+// Disponibilidad calcula cuántos kits se pueden armar con el stock de los componentes en la
+// bodega (vacío = stock total de todas las bodegas).
+func (s *KitService) Disponibilidad(sku, bodega string) (*db.KitAvailabilityDTO, error) {
+	return disponibilidadKit(db.GetDB(), sku, bodega)
+}
+
+// DisponibilidadKits devuelve la disponibilidad total de todos los kits, para listados.
+func (s *KitService) DisponibilidadKits() map[string]float64 {
+	var kits []string
+	db.GetDB().Model(&db.Product{}).Where("es_kit = ?", true).Pluck("sku", &kits)
+	result := make(map[string]float64, len(kits))
+	for _, sku := range kits {
+		if disp, err := disponibilidadKit(db.GetDB(), sku, ""); err == nil {
+			result[sku] = disp.Disponible
+		}
+	}
+	return result
+}
+
+func disponibilidadKit(tx *gorm.DB, sku, bodega string) (*db.KitAvailabilityDTO, error) {
+	var kit db.Product
+	if err := tx.First(&kit, "sku = ?", sku).Error; err != nil {
+		return nil, fmt.Errorf("producto no encontrado: %s", sku)
+	}
+	if bodega != "" {
+		resuelta, err := resolverBodega(tx, bodega)
+		if err != nil {
+			return nil, err
+		}
+		bodega = resuelta
+	}
+	result := &db.KitAvailabilityDTO{SKU: sku, Bodega: bodega, Componentes: []db.KitComponentDTO{}}
+	componentes := componentesKit(tx, sku)
+	if len(componentes) == 0 {
+		return result, nil
+	}
+
+	disponible := math.Inf(1)
+	for _, c := range componentes {
+		var product db.Product
+		if err := tx.First(&product, "sku = ?", c.ComponentSKU).Error; err != nil {
+			return nil, fmt.Errorf("componente no encontrado: %s", c.ComponentSKU)
+		}
+		stock := product.Stock
+		if bodega != "" {
+			enBodega, err := stockEnBodega(tx, &product, bodega)
+			if err != nil {
+				return nil, err
+			}
+			stock = enBodega
+		}
+		// Los kits se arman completos: se redondea hacia abajo
+		alcanza := math.Max(0, math.Floor(redondearCantidad(stock/c.Cantidad)))
+		result.Componentes = append(result.Componentes, db.KitComponentDTO{
+			SKU:      c.ComponentSKU,
+			Nombre:   product.Name,
+			Cantidad: c.Cantidad,
+			Stock:    stock,
+			Alcanza:  alcanza,
+		})
+		if alcanza < disponible {
+			disponible = alcanza
+			result.Limitante = c.ComponentSKU
+		}
+	}
+	result.Disponible = disponible
+	return result, nil
+}
+
+func componentesKit(tx *gorm.DB, sku string) []db.KitComponent {
+	var componentes []db.KitComponent
+	tx.Where("kit_sku = ?", sku).Order("id").Find(&componentes)
+	return componentes
+}
+
+// descontarKit registra la salida de los componentes de cantidadKits kits vendidos y devuelve el
+// costo de un kit (suma del costo promedio de sus componentes).
+func descontarKit(tx *gorm.DB, documento, bodega, sku string, cantidadKits float64) (float64, error) {
+	costo := 0.0
+	for _, c := range componentesKit(tx, sku) {
+		mov := &db.StockMovement{
+			ProductSKU: c.ComponentSKU,
+			Tipo:       MovVenta,
+			Cantidad:   -redondearCantidad(cantidadKits * c.Cantidad),
+			Documento:  documento,
+			Bodega:     bodega,
+			Nota:       "Kit " + sku,
+		}
+		if mov.Cantidad == 0 {
+			continue
+		}
+		if err := registrarMovimiento(tx, mov); err != nil {
+			return 0, err
+		}
+		if _, err := consumirLotes(tx, documento, c.ComponentSKU, mov.Bodega, -mov.Cantidad, false); err != nil {
+			return 0, err
+		}
+		costo += mov.CostoUnitario * c.Cantidad
+	}
+	return costo, nil
+}
+
+// anotarComponentes agrega "Contiene" a los detalles de kits marcados con DetallarKit.
+// No modifica la base de datos.
+func anotarComponentes(tx *gorm.DB, detalles []xml.Detalle) []xml.Detalle {
+	result := make([]xml.Detalle, len(detalles))
+	copy(result, detalles)
+	for i := range result {
+		det := &result[i]
+		var kits []db.Product
+		tx.Where("sku = ? AND es_kit = ? AND detallar_kit = ?", det.CodigoPrincipal, true, true).Limit(1).Find(&kits)
+		if len(kits) == 0 {
+			continue
+		}
+		partes := []string{}
+		for _, c := range componentesKit(tx, det.CodigoPrincipal) {
+			nombre := c.ComponentSKU
+			var products []db.Product
+			tx.Where("sku = ?", c.ComponentSKU).Limit(1).Find(&products)
+			if len(products) > 0 {
+				nombre = products[0].Name
+			}
+			partes = append(partes, fmt.Sprintf("%g x %s", c.Cantidad, nombre))
+		}
+		if len(partes) == 0 {
+			continue
+		}
+		adicionales := []xml.DetAdicional{}
+		if det.DetallesAdicionales != nil {
+			adicionales = append(adicionales, det.DetallesAdicionales.DetAdicional...)
+		}
+		det.DetallesAdicionales = &xml.DetallesAdicionales{
+			DetAdicional: append(adicionales, detAdicional("Contiene", strings.Join(partes, ", "))),
+		}
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/xml"
+
+	"gorm.io/gorm"
+)
+
+func TestKitService_VentaDescuentaComponentes(t *testing.T) {
+	database := setupTestDB()
+	svc := NewKitService()
+	database.Create(&db.Product{SKU: "VINO", Name: "Vino", Barcode: "VINO", Stock: 10, Cost: 5, Price: 9})
+	database.Create(&db.Product{SKU: "QUESO", Name: "Queso", Barcode: "QUESO", Stock: 3, Cost: 2, Price: 4})
+	database.Create(&db.Product{SKU: "CANASTA", Name: "Canasta Navideña", Barcode: "CANASTA", Price: 30})
+	database.Create(&db.Product{SKU: "USADO", Name: "Con stock", Barcode: "USADO", Stock: 5})
+
+	if err := svc.GuardarComponentes("CANASTA", true, []db.KitComponentDTO{{SKU: "CANASTA", Cantidad: 1}}); err == nil {
+		t.Error("Un kit no puede contenerse a sí mismo")
+	}
+	if err := svc.GuardarComponentes("USADO", false, []db.KitComponentDTO{{SKU: "VINO", Cantidad: 1}}); err == nil {
+		t.Error("Un producto con stock no debe convertirse en kit")
+	}
+	if err := svc.GuardarComponentes("CANASTA", true, []db.KitComponentDTO{{SKU: "VINO", Cantidad: 2}, {SKU: "QUESO", Cantidad: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.GuardarComponentes("USADO", false, []db.KitComponentDTO{{SKU: "CANASTA", Cantidad: 1}}); err == nil {
+		t.Error("Un kit no puede ser componente de otro")
+	}
+
+	disp, err := svc.Disponibilidad("CANASTA", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disp.Disponible != 3 || disp.Limitante != "QUESO" || len(disp.Componentes) != 2 {
+		t.Errorf("Disponibilidad incorrecta: %+v", disp)
+	}
+	if _, err := NewInventoryService().AjustarStock("CANASTA", "", 5, false, "test", ""); err == nil {
+		t.Error("Un kit no debe aceptar stock propio")
+	}
+
+	// Vender 2 canastas descuenta 4 vinos y 2 quesos; el costo es el de los componentes
+	venta := []db.FacturaItem{{ProductoSKU: "CANASTA", Cantidad: 2, Factor: 1}}
+	if err := database.Transaction(func(tx *gorm.DB) error {
+		return descontarVenta(tx, "CLAVE_KIT", "", venta)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if stockDe(t, "VINO") != 6 || stockDe(t, "QUESO") != 1 || stockDe(t, "CANASTA") != 0 {
+		t.Errorf("Stock tras la venta: vino %g, queso %g", stockDe(t, "VINO"), stockDe(t, "QUESO"))
+	}
+	if venta[0].CostoUnitario != 12 {
+		t.Errorf("Costo del kit esperado 12, obtuve %g", venta[0].CostoUnitario)
+	}
+
+	// Anular la venta devuelve los componentes
+	database.Transaction(func(tx *gorm.DB) error {
+		return revertirMovimientos(tx, "CLAVE_KIT", MovDevolucion, "Anulación")
+	})
+	if stockDe(t, "VINO") != 10 || stockDe(t, "QUESO") != 3 {
+		t.Error("La anulación debió devolver los componentes")
+	}
+
+	detalles := anotarComponentes(database, []xml.Detalle{{CodigoPrincipal: "CANASTA", Cantidad: 1}, {CodigoPrincipal: "VINO", Cantidad: 1}})
+	if detalles[0].DetallesAdicionales == nil || detalles[0].DetallesAdicionales.DetAdicional[0].Valor != "2 x Vino, 1 x Queso" {
+		t.Errorf("Componentes no detallados: %+v", detalles[0].DetallesAdicionales)
+	}
+	if detalles[1].DetallesAdicionales != nil {
+		t.Error("Un producto normal no lleva componentes")
+	}
+
+	// Con la lista vacía deja de ser kit
+	if err := svc.GuardarComponentes("CANASTA", true, []db.KitComponentDTO{}); err != nil {
+		t.Fatal(err)
+	}
+	var canasta db.Product
+	database.First(&canasta, "sku = ?", "CANASTA")
+	if canasta.EsKit || canasta.DetallarKit {
+		t.Error("El producto debió dejar de ser kit")
+	}
+}
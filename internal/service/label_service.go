@@ -0,0 +1,172 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/pdf"
+	"kushkiv2/pkg/util"
+
+	"gorm.io/gorm"
+)
+
+// prefijoEANInterno es el rango GS1 de circulación restringida (uso interno de la tienda).
+const prefijoEANInterno = "20"
+
+type LabelService struct{}
+
+func NewLabelService() *LabelService {
+	return &LabelService{}
+}
+
+// GenerarEtiquetasProductos imprime opciones.Copias etiquetas de cada producto seleccionado.
+func (s *LabelService) GenerarEtiquetasProductos(skus []string, opciones db.LabelOptionsDTO) ([]byte, error) {
+	opciones = normalizarOpcionesEtiqueta(opciones)
+	var products []db.Product
+	if err := db.GetDB().Where("sku IN ?", skus).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("error cargando productos: %v", err)
+	}
+	porSKU := make(map[string]db.Product, len(products))
+	for _, p := range products {
+		porSKU[p.SKU] = p
+	}
+	// Se respeta el orden de la selección
+	etiquetas := []db.LabelDTO{}
+	for _, sku := range skus {
+		if p, ok := porSKU[sku]; ok {
+			etiquetas = append(etiquetas, etiquetaProducto(p, opciones.Simbologia, opciones.Copias))
+		}
+	}
+	if len(etiquetas) == 0 {
+		return nil, fmt.Errorf("no se encontraron los productos seleccionados")
+	}
+	return pdf.GenerarEtiquetasPDF(etiquetas, opciones)
+}
+
+// GenerarEtiquetasCompra imprime una etiqueta por cada unidad recibida en una compra
+// (las cantidades fraccionarias se redondean hacia arriba).
+func (s *LabelService) GenerarEtiquetasCompra(purchaseID uint, opciones db.LabelOptionsDTO) ([]byte, error) {
+	opciones = normalizarOpcionesEtiqueta(opciones)
+	var compra db.Purchase
+	if err := db.GetDB().First(&compra, purchaseID).Error; err != nil {
+		return nil, fmt.Errorf("compra no encontrada")
+	}
+	var items []db.PurchaseItem
+	db.GetDB().Where("purchase_id = ?", purchaseID).Order("id").Find(&items)
+
+	etiquetas := []db.LabelDTO{}
+	for _, it := range items {
+		var products []db.Product
+		db.GetDB().Where("sku = ?", it.ProductoSKU).Limit(1).Find(&products)
+		if len(products) == 0 || it.Cantidad <= 0 {
+			continue
+		}
+		copias := int(math.Ceil(redondearCantidad(it.Cantidad)))
+		etiquetas = append(etiquetas, etiquetaProducto(products[0], opciones.Simbologia, copias))
+	}
+	if len(etiquetas) == 0 {
+		return nil, fmt.Errorf("la compra %s no tiene productos del catálogo", compra.NumeroFactura)
+	}
+	return pdf.GenerarEtiquetasPDF(etiquetas, opciones)
+}
+
+// AsignarCodigosInternos genera un EAN-13 interno para los productos sin código de barras
+// (vacío, o el SKU copiado como código sin ser un EAN-13). Sin selección revisa todo el catálogo.
+// Devuelve cuántos productos recibieron código.
+func (s *LabelService) AsignarCodigosInternos(skus []string) (int, error) {
+	asignados := 0
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		q := tx.Order("sku")
+		if len(skus) > 0 {
+			q = q.Where("sku IN ?", skus)
+		}
+		var products []db.Product
+		if err := q.Find(&products).Error; err != nil {
+			return fmt.Errorf("error cargando productos: %v", err)
+		}
+		for _, p := range products {
+			if p.Barcode != "" && (p.Barcode != p.SKU || util.EsEAN13(p.Barcode)) {
+				continue
+			}
+			codigo, err := siguienteEANInterno(tx)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&db.Product{}).Where("sku = ?", p.SKU).Update("barcode", codigo).Error; err != nil {
+				return fmt.Errorf("error asignando código a %s: %v", p.SKU, err)
+			}
+			asignados++
+		}
+		return nil
+	})
+	return asignados, err
+}
+
+// CodigoInternoDisponible devuelve el siguiente EAN-13 interno sin asignarlo.
+func (s *LabelService) CodigoInternoDisponible() (string, error) {
+	return siguienteEANInterno(db.GetDB())
+}
+
+// siguienteEANInterno continúa la secuencia de los EAN-13 internos ya usados por productos y
+// presentaciones: prefijo 20, diez dígitos de secuencia y el dígito de control.
+func siguienteEANInterno(tx *gorm.DB) (string, error) {
+	patron := prefijoEANInterno + strings.Repeat("_", 11)
+	var codigos []string
+	tx.Model(&db.Product{}).Where("barcode LIKE ?", patron).Pluck("barcode", &codigos)
+	var deUnidades []string
+	tx.Model(&db.ProductUnit{}).Where("barcode LIKE ?", patron).Pluck("barcode", &deUnidades)
+
+	ultimo := int64(0)
+	for _, c := range append(codigos, deUnidades...) {
+		if !util.EsEAN13(c) {
+			continue
+		}
+		if n, err := strconv.ParseInt(c[2:12], 10, 64); err == nil && n > ultimo {
+			ultimo = n
+		}
+	}
+	if ultimo >= 9999999999 {
+		return "", fmt.Errorf("se agotaron los códigos internos")
+	}
+	base := fmt.Sprintf("%s%010d", prefijoEANInterno, ultimo+1)
+	return base + strconv.Itoa(util.DigitoEAN13(base)), nil
+}
+
+// etiquetaProducto arma la etiqueta con el código de barras del producto (o su SKU). Un código
+// que no es EAN-13 se imprime en Code128.
+func etiquetaProducto(p db.Product, simbologia string, copias int) db.LabelDTO {
+	codigo := p.Barcode
+	if codigo == "" {
+		codigo = p.SKU
+	}
+	if simbologia == pdf.SimbologiaEAN13 && !util.EsEAN13(codigo) {
+		simbologia = pdf.SimbologiaCode128
+	}
+	return db.LabelDTO{
+		SKU:        p.SKU,
+		Nombre:     p.Name,
+		Precio:     util.Round(p.Price*(1+float64(p.TaxPercentage)/100), 2),
+		Ubicacion:  p.Location,
+		Codigo:     codigo,
+		Simbologia: simbologia,
+		Copias:     copias,
+	}
+}
+
+func normalizarOpcionesEtiqueta(opciones db.LabelOptionsDTO) db.LabelOptionsDTO {
+	opciones.Formato = strings.ToUpper(strings.TrimSpace(opciones.Formato))
+	if opciones.Formato != pdf.EtiquetaTermica {
+		opciones.Formato = pdf.EtiquetaA4
+	}
+	opciones.Simbologia = strings.ToUpper(strings.TrimSpace(opciones.Simbologia))
+	if opciones.Simbologia != pdf.SimbologiaCode128 && opciones.Simbologia != pdf.SimbologiaQR {
+		opciones.Simbologia = pdf.SimbologiaEAN13
+	}
+	if opciones.Copias < 1 {
+		opciones.Copias = 1
+	}
+	return opciones
+}
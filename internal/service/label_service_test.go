@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/pdf"
+	"kushkiv2/pkg/util"
+)
+
+func TestLabelService_CodigosYEtiquetas(t *testing.T) {
+	database := setupTestDB()
+	svc := NewLabelService()
+	database.Create(&db.Product{SKU: "LECHE", Name: "Leche 1L", Barcode: "7861234567898", Price: 1, TaxPercentage: 15, Location: "P1-A"})
+	database.Create(&db.Product{SKU: "GEN-001", Name: "Tornillo", Barcode: "GEN-001", Price: 0.1})
+	database.Create(&db.Product{SKU: "GEN-002", Name: "Tuerca", Price: 0.05})
+	database.Create(&db.ProductUnit{ProductSKU: "LECHE", Nombre: "CAJA", Factor: 12, Barcode: "2000000000053"})
+
+	n, err := svc.AsignarCodigosInternos(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Esperaba 2 códigos asignados, obtuve %d", n)
+	}
+	var tornillo, tuerca, leche db.Product
+	database.First(&tornillo, "sku = ?", "GEN-001")
+	database.First(&tuerca, "sku = ?", "GEN-002")
+	database.First(&leche, "sku = ?", "LECHE")
+	// La secuencia continúa después del código interno de la presentación
+	if tornillo.Barcode != "2000000000060" || !util.EsEAN13(tuerca.Barcode) || tuerca.Barcode == tornillo.Barcode {
+		t.Errorf("Códigos internos incorrectos: %s, %s", tornillo.Barcode, tuerca.Barcode)
+	}
+	if leche.Barcode != "7861234567898" {
+		t.Error("No debe reemplazar un código existente")
+	}
+
+	// Un código que no es EAN-13 se imprime en Code128
+	e := etiquetaProducto(db.Product{SKU: "X", Barcode: "ABC-1", Price: 10, TaxPercentage: 15}, pdf.SimbologiaEAN13, 1)
+	if e.Simbologia != pdf.SimbologiaCode128 || e.Precio != 11.5 {
+		t.Errorf("Etiqueta incorrecta: %+v", e)
+	}
+
+	database.Create(&db.Purchase{ID: 1, SupplierRUC: "1790000000001", NumeroFactura: "001-001-000000001", Estado: "REGISTRADA"})
+	database.Create(&[]db.PurchaseItem{
+		{PurchaseID: 1, ProductoSKU: "LECHE", Cantidad: 2.5},
+		{PurchaseID: 1, ProductoSKU: "NO-EXISTE", Cantidad: 4},
+	})
+	for _, formato := range []string{pdf.EtiquetaA4, pdf.EtiquetaTermica} {
+		data, err := svc.GenerarEtiquetasCompra(1, db.LabelOptionsDTO{Formato: formato, MostrarPrecio: true, MostrarUbicacion: true})
+		if err != nil || len(data) == 0 {
+			t.Errorf("PDF %s no generado: %v", formato, err)
+		}
+	}
+	if _, err := svc.GenerarEtiquetasProductos([]string{"GEN-001", "GEN-002"}, db.LabelOptionsDTO{Simbologia: pdf.SimbologiaQR, Copias: 30}); err != nil {
+		t.Error(err)
+	}
+	if _, err := svc.GenerarEtiquetasProductos([]string{"NADA"}, db.LabelOptionsDTO{}); err == nil {
+		t.Error("Sin productos no debe generar etiquetas")
+	}
+}
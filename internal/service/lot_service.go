@@ -0,0 +1,525 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/logger"
+	"kushkiv2/pkg/util"
+	"kushkiv2/pkg/xml"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// DiasAlertaVencimiento es la anticipación con la que un lote se considera por vencer.
+const DiasAlertaVencimiento = 30
+
+type LotService struct{}
+
+func NewLotService() *LotService {
+	return &LotService{}
+}
+
+// IngresarLote registra un lote en una bodega ("" = principal). Con moverStock, la cantidad entra
+// al inventario como un ajuste; sin él, el lote se asigna a stock que ya existe sin lote
+// (por ejemplo, al empezar a controlar lotes de un producto).
+func (s *LotService) IngresarLote(dto db.LotDTO, moverStock bool, usuario string) error {
+	numero := strings.TrimSpace(dto.Numero)
+	if numero == "" {
+		return fmt.Errorf("el número de lote es obligatorio")
+	}
+	if dto.Cantidad <= 0 {
+		return fmt.Errorf("la cantidad del lote debe ser mayor a cero")
+	}
+	vence, err := parseFechaVencimiento(dto.FechaVencimiento)
+	if err != nil {
+		return err
+	}
+
+	err = db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var product db.Product
+		if err := tx.First(&product, "sku = ?", dto.SKU).Error; err != nil {
+			return fmt.Errorf("producto no encontrado: %s", dto.SKU)
+		}
+		bodega, err := resolverBodega(tx, dto.Bodega)
+		if err != nil {
+			return err
+		}
+
+		if moverStock {
+			mov := &db.StockMovement{
+				ProductSKU: dto.SKU,
+				Tipo:       MovAjuste,
+				Cantidad:   dto.Cantidad,
+				Usuario:    usuario,
+				Nota:       "Ingreso de lote " + numero,
+				Bodega:     bodega,
+			}
+			if err := registrarMovimiento(tx, mov); err != nil {
+				return err
+			}
+		} else {
+			stock, err := stockEnBodega(tx, &product, bodega)
+			if err != nil {
+				return err
+			}
+			var enLotes float64
+			tx.Model(&db.Lot{}).Where("product_sku = ? AND bodega = ? AND cantidad > 0", dto.SKU, bodega).
+				Select("COALESCE(SUM(cantidad), 0)").Scan(&enLotes)
+			if libre := stock - enLotes; dto.Cantidad > libre {
+				return fmt.Errorf("el stock sin lote de %s en %s es %g", dto.SKU, bodega, max(redondearCantidad(libre), 0))
+			}
+		}
+		_, err = ingresarLote(tx, "", dto.SKU, bodega, numero, vence, dto.Cantidad)
+		return err
+	})
+	return err
+}
+
+// ListarLotes devuelve los lotes de un producto ("" = todos) en una bodega ("" = todas), en orden FEFO.
+func (s *LotService) ListarLotes(sku, bodega string, soloDisponibles bool) ([]db.LotDTO, error) {
+	q := db.GetDB().Model(&db.Lot{})
+	if sku != "" {
+		q = q.Where("product_sku = ?", sku)
+	}
+	if bodega != "" {
+		q = q.Where("bodega = ?", bodega)
+	}
+	if soloDisponibles {
+		q = q.Where("cantidad > 0")
+	}
+	var lotes []db.Lot
+	if err := q.Order("product_sku, fecha_vencimiento IS NULL, fecha_vencimiento, id").Find(&lotes).Error; err != nil {
+		return nil, fmt.Errorf("error listando lotes: %v", err)
+	}
+	return mapLotes(lotes, time.Now()), nil
+}
+
+// GetLotesPorVencer devuelve los lotes con saldo que vencen dentro de los próximos días
+// (incluye los ya vencidos), del más urgente al menos urgente.
+func (s *LotService) GetLotesPorVencer(dias int, bodega string) ([]db.LotDTO, error) {
+	ahora := time.Now()
+	limite := inicioDelDia(ahora).AddDate(0, 0, dias+1)
+	q := db.GetDB().Where("cantidad > 0 AND fecha_vencimiento IS NOT NULL AND fecha_vencimiento < ?", limite)
+	if bodega != "" {
+		q = q.Where("bodega = ?", bodega)
+	}
+	var lotes []db.Lot
+	if err := q.Order("fecha_vencimiento, product_sku").Find(&lotes).Error; err != nil {
+		return nil, fmt.Errorf("error consultando vencimientos: %v", err)
+	}
+	return mapLotes(lotes, ahora), nil
+}
+
+// DarDeBajaLote retira del inventario el saldo de un lote (vencido, dañado) con un ajuste.
+func (s *LotService) DarDeBajaLote(id uint, usuario, nota string) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var lote db.Lot
+		if err := tx.First(&lote, id).Error; err != nil {
+			return fmt.Errorf("lote no encontrado")
+		}
+		if lote.Cantidad <= 0 {
+			return fmt.Errorf("el lote %s no tiene saldo", lote.Numero)
+		}
+		if nota == "" {
+			nota = "Baja de lote " + lote.Numero
+		}
+		mov := &db.StockMovement{
+			ProductSKU: lote.ProductSKU,
+			Tipo:       MovAjuste,
+			Cantidad:   -lote.Cantidad,
+			Usuario:    usuario,
+			Nota:       nota,
+			Bodega:     lote.Bodega,
+		}
+		if err := registrarMovimiento(tx, mov); err != nil {
+			return err
+		}
+		if err := tx.Model(&lote).UpdateColumn("cantidad", 0).Error; err != nil {
+			return fmt.Errorf("error actualizando lote: %v", err)
+		}
+		return actualizarVencimientoProducto(tx, lote.ProductSKU)
+	})
+}
+
+// GenerarReporteVencimientosExcel exporta los lotes vencidos y por vencer.
+func (s *LotService) GenerarReporteVencimientosExcel(dias int, bodega string) ([]byte, error) {
+	lotes, err := s.GetLotesPorVencer(dias, bodega)
+	if err != nil {
+		return nil, err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Vencimientos"
+	f.SetSheetName("Sheet1", sheet)
+	alcance := "Todas las bodegas"
+	if bodega != "" {
+		alcance = "Bodega " + bodega
+	}
+	f.SetCellValue(sheet, "A1", fmt.Sprintf("Lotes vencidos y por vencer en %d días - %s", dias, alcance))
+	f.SetCellValue(sheet, "A2", "Generado: "+time.Now().Format("2006-01-02 15:04"))
+
+	headers := []string{"SKU", "Producto", "Bodega", "Lote", "Vence", "Días", "Estado", "Cantidad", "Valor al costo"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 4)
+		f.SetCellValue(sheet, cell, h)
+	}
+	style, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	f.SetRowStyle(sheet, 4, 4, style)
+
+	row := 5
+	total := 0.0
+	for _, l := range lotes {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), l.SKU)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), l.Nombre)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), l.Bodega)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), l.Numero)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), l.FechaVencimiento)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), l.DiasParaVencer)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), l.Estado)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), l.Cantidad)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), l.ValorCosto)
+		total += l.ValorCosto
+		row++
+	}
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "Total")
+	f.SetCellValue(sheet, fmt.Sprintf("I%d", row), util.Round(total, 2))
+	f.SetRowStyle(sheet, row, row, style)
+	f.SetColWidth(sheet, "B", "B", 40)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error generando reporte de vencimientos: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// StartExpiryWatcher revisa una vez al día los lotes vencidos y por vencer y avisa con notificar.
+func (s *LotService) StartExpiryWatcher(notificar func(mensaje string)) {
+	go func() {
+		for {
+			time.Sleep(2 * time.Minute) // Dar tiempo a que el frontend termine de cargar
+			if mensaje := s.alertaVencimientos(); mensaje != "" {
+				notificar(mensaje)
+			}
+			time.Sleep(24*time.Hour - 2*time.Minute)
+		}
+	}()
+}
+
+// alertaVencimientos arma el texto de la alerta diaria; vacío si no hay nada que avisar.
+func (s *LotService) alertaVencimientos() string {
+	lotes, err := s.GetLotesPorVencer(DiasAlertaVencimiento, "")
+	if err != nil {
+		logger.Error("%v", err)
+		return ""
+	}
+	vencidos, porVencer := 0, 0
+	for _, l := range lotes {
+		if l.Estado == "VENCIDO" {
+			vencidos++
+		} else {
+			porVencer++
+		}
+	}
+	var partes []string
+	if vencidos > 0 {
+		partes = append(partes, fmt.Sprintf("%d lotes vencidos con saldo", vencidos))
+	}
+	if porVencer > 0 {
+		partes = append(partes, fmt.Sprintf("%d lotes vencen en los próximos %d días", porVencer, DiasAlertaVencimiento))
+	}
+	return strings.Join(partes, " y ")
+}
+
+// --- Movimiento de lotes (compartido con ventas, compras y traslados) ---
+
+// consumoLote es la cantidad que un documento toma de un lote.
+type consumoLote struct {
+	Lote     db.Lot
+	Cantidad float64
+}
+
+// planificarLotes elige, sin modificar nada, de qué lotes sale una cantidad en orden FEFO: primero
+// el que vence antes y al final los lotes sin fecha. Los vencidos se omiten salvo con
+// incluirVencidos. reservados descuenta lo ya asignado en el mismo documento (puede ser nil).
+// Si los lotes no alcanzan, el resto sale sin lote.
+func planificarLotes(tx *gorm.DB, sku, bodega string, cantidad float64, incluirVencidos bool, reservados map[uint]float64) []consumoLote {
+	if cantidad <= 0 {
+		return nil
+	}
+	q := tx.Where("product_sku = ? AND bodega = ? AND cantidad > 0", sku, bodega)
+	if !incluirVencidos {
+		q = q.Where("(fecha_vencimiento IS NULL OR fecha_vencimiento >= ?)", inicioDelDia(time.Now()))
+	}
+	var lotes []db.Lot
+	q.Order("fecha_vencimiento IS NULL, fecha_vencimiento, id").Find(&lotes)
+
+	var plan []consumoLote
+	for _, l := range lotes {
+		disponible := redondearCantidad(l.Cantidad - reservados[l.ID])
+		if disponible <= 0 {
+			continue
+		}
+		toma := math.Min(disponible, cantidad)
+		plan = append(plan, consumoLote{Lote: l, Cantidad: toma})
+		cantidad = redondearCantidad(cantidad - toma)
+		if cantidad <= 0 {
+			break
+		}
+	}
+	return plan
+}
+
+// consumirLotes descuenta una salida de los lotes de la bodega en orden FEFO y la registra a nombre
+// del documento. Los productos sin lotes no se ven afectados.
+func consumirLotes(tx *gorm.DB, documento, sku, bodega string, cantidad float64, incluirVencidos bool) ([]consumoLote, error) {
+	plan := planificarLotes(tx, sku, bodega, cantidad, incluirVencidos, nil)
+	if len(plan) == 0 {
+		return nil, nil
+	}
+	for _, c := range plan {
+		if err := tx.Model(&db.Lot{}).Where("id = ?", c.Lote.ID).
+			UpdateColumn("cantidad", gorm.Expr("ROUND(cantidad - ?, 6)", c.Cantidad)).Error; err != nil {
+			return nil, fmt.Errorf("error descontando lote %s: %v", c.Lote.Numero, err)
+		}
+		if err := tx.Create(&db.LotConsumption{LotID: c.Lote.ID, Documento: documento, Cantidad: c.Cantidad}).Error; err != nil {
+			return nil, fmt.Errorf("error registrando consumo de lote: %v", err)
+		}
+	}
+	return plan, actualizarVencimientoProducto(tx, sku)
+}
+
+// ingresarLote suma una entrada al lote (lo crea si no existe en la bodega). Si el lote ya existe
+// se conserva su fecha de vencimiento original.
+func ingresarLote(tx *gorm.DB, documento, sku, bodega, numero string, vence *time.Time, cantidad float64) (*db.Lot, error) {
+	lote := db.Lot{ProductSKU: sku, Bodega: bodega, Numero: numero}
+	var existentes []db.Lot
+	tx.Where(&lote).Limit(1).Find(&existentes)
+	if len(existentes) > 0 {
+		lote = existentes[0]
+		updates := map[string]interface{}{
+			"cantidad":         gorm.Expr("ROUND(cantidad + ?, 6)", cantidad),
+			"cantidad_inicial": gorm.Expr("ROUND(cantidad_inicial + ?, 6)", cantidad),
+		}
+		if lote.FechaVencimiento == nil && vence != nil {
+			updates["fecha_vencimiento"] = vence
+		}
+		if err := tx.Model(&lote).UpdateColumns(updates).Error; err != nil {
+			return nil, fmt.Errorf("error actualizando lote %s: %v", numero, err)
+		}
+	} else {
+		lote.FechaVencimiento = vence
+		lote.Cantidad = cantidad
+		lote.CantidadInicial = cantidad
+		if err := tx.Create(&lote).Error; err != nil {
+			return nil, fmt.Errorf("error creando lote %s: %v", numero, err)
+		}
+	}
+	if documento != "" {
+		if err := tx.Create(&db.LotConsumption{LotID: lote.ID, Documento: documento, Cantidad: -cantidad}).Error; err != nil {
+			return nil, fmt.Errorf("error registrando ingreso de lote: %v", err)
+		}
+	}
+	return &lote, actualizarVencimientoProducto(tx, sku)
+}
+
+// trasladarLotes mueve entre bodegas los lotes de una cantidad trasladada (FEFO en el origen,
+// incluidos los vencidos) conservando número y vencimiento.
+func trasladarLotes(tx *gorm.DB, documento, sku, origen, destino string, cantidad float64) error {
+	consumos, err := consumirLotes(tx, documento, sku, origen, cantidad, true)
+	if err != nil {
+		return err
+	}
+	for _, c := range consumos {
+		if _, err := ingresarLote(tx, documento, sku, destino, c.Lote.Numero, c.Lote.FechaVencimiento, c.Cantidad); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revertirLotes deshace lo que un documento movió en los lotes. Es idempotente.
+func revertirLotes(tx *gorm.DB, documento string) error {
+	var consumos []db.LotConsumption
+	tx.Where("documento = ?", documento).Find(&consumos)
+	if len(consumos) == 0 {
+		return nil
+	}
+	skus := map[string]bool{}
+	for _, c := range consumos {
+		var lote db.Lot
+		if err := tx.First(&lote, c.LotID).Error; err != nil {
+			continue
+		}
+		if err := tx.Model(&lote).UpdateColumn("cantidad", gorm.Expr("ROUND(cantidad + ?, 6)", c.Cantidad)).Error; err != nil {
+			return fmt.Errorf("error revirtiendo lote %s: %v", lote.Numero, err)
+		}
+		skus[lote.ProductSKU] = true
+	}
+	if err := tx.Where("documento = ?", documento).Delete(&db.LotConsumption{}).Error; err != nil {
+		return fmt.Errorf("error revirtiendo lotes de %s: %v", documento, err)
+	}
+	for sku := range skus {
+		if err := actualizarVencimientoProducto(tx, sku); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// actualizarVencimientoProducto mantiene Product.ExpiryDate como el vencimiento más próximo entre
+// los lotes con saldo, para las vistas que solo conocen una fecha por producto.
+func actualizarVencimientoProducto(tx *gorm.DB, sku string) error {
+	var lotes []db.Lot
+	tx.Where("product_sku = ? AND cantidad > 0 AND fecha_vencimiento IS NOT NULL", sku).
+		Order("fecha_vencimiento").Limit(1).Find(&lotes)
+	var proxima *time.Time
+	if len(lotes) > 0 {
+		proxima = lotes[0].FechaVencimiento
+	}
+	if err := tx.Model(&db.Product{}).Where("sku = ?", sku).UpdateColumn("expiry_date", proxima).Error; err != nil {
+		return fmt.Errorf("error actualizando vencimiento de %s: %v", sku, err)
+	}
+	return nil
+}
+
+// anotarLotes agrega a cada detalle de la factura los lotes que saldrán de la bodega de venta,
+// según el mismo orden FEFO que aplicará descontarVenta. No modifica la base de datos.
+func anotarLotes(tx *gorm.DB, bodega string, detalles []xml.Detalle) []xml.Detalle {
+	bodega, err := resolverBodega(tx, bodega)
+	if err != nil {
+		return detalles
+	}
+	result := make([]xml.Detalle, len(detalles))
+	copy(result, detalles)
+	reservados := map[uint]float64{}
+	for i := range result {
+		det := &result[i]
+		cantidad := redondearCantidad(det.Cantidad * factorUnidad(tx, det.CodigoPrincipal, det.UnidadMedida))
+		plan := planificarLotes(tx, det.CodigoPrincipal, bodega, cantidad, false, reservados)
+		if len(plan) == 0 {
+			continue
+		}
+		for _, c := range plan {
+			reservados[c.Lote.ID] += c.Cantidad
+		}
+		// Se agrega a los detalles que ya traiga la línea (p. ej. la promoción aplicada)
+		adicionales := []xml.DetAdicional{}
+		if det.DetallesAdicionales != nil {
+			adicionales = append(adicionales, det.DetallesAdicionales.DetAdicional...)
+		}
+		det.DetallesAdicionales = &xml.DetallesAdicionales{
+			DetAdicional: append(adicionales, detAdicional("Lote", textoLotes(plan))),
+		}
+	}
+	return result
+}
+
+// textoLotes describe los lotes de un detalle.
+func textoLotes(plan []consumoLote) string {
+	partes := make([]string, 0, len(plan))
+	for _, c := range plan {
+		p := c.Lote.Numero
+		if c.Lote.FechaVencimiento != nil {
+			p += " Vence " + c.Lote.FechaVencimiento.Format("02/01/2006")
+		}
+		if len(plan) > 1 {
+			p += fmt.Sprintf(" (%g)", c.Cantidad)
+		}
+		partes = append(partes, p)
+	}
+	return strings.Join(partes, "; ")
+}
+
+// loteDeDetalle extrae lote y vencimiento de los detalles adicionales de una factura de proveedor.
+// Los nombres varían entre emisores ("Lote", "No. Lote", "F. Vencimiento", "Caducidad"...).
+func loteDeDetalle(det xml.Detalle) (string, *time.Time) {
+	if det.DetallesAdicionales == nil {
+		return "", nil
+	}
+	var numero string
+	var vence *time.Time
+	for _, d := range det.DetallesAdicionales.DetAdicional {
+		nombre := strings.ToLower(d.Nombre)
+		valor := strings.TrimSpace(d.Valor)
+		switch {
+		case strings.Contains(nombre, "venc") || strings.Contains(nombre, "cad") || strings.Contains(nombre, "exp"):
+			for _, layout := range []string{"02/01/2006", "2006-01-02", "02-01-2006", "2006/01/02"} {
+				if t, err := time.ParseInLocation(layout, valor, time.Local); err == nil {
+					vence = &t
+					break
+				}
+			}
+		case strings.Contains(nombre, "lote"):
+			numero = valor
+		}
+	}
+	return numero, vence
+}
+
+func parseFechaVencimiento(fecha string) (*time.Time, error) {
+	if strings.TrimSpace(fecha) == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(fecha), time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("fecha de vencimiento inválida: %s", fecha)
+	}
+	return &t, nil
+}
+
+func inicioDelDia(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func mapLotes(lotes []db.Lot, ahora time.Time) []db.LotDTO {
+	skus := make([]string, 0, len(lotes))
+	for _, l := range lotes {
+		skus = append(skus, l.ProductSKU)
+	}
+	var products []db.Product
+	db.GetDB().Where("sku IN ?", skus).Find(&products)
+	porSKU := map[string]db.Product{}
+	for _, p := range products {
+		porSKU[p.SKU] = p
+	}
+
+	hoy := inicioDelDia(ahora)
+	result := make([]db.LotDTO, 0, len(lotes))
+	for _, l := range lotes {
+		p := porSKU[l.ProductSKU]
+		dto := db.LotDTO{
+			ID:              l.ID,
+			SKU:             l.ProductSKU,
+			Nombre:          p.Name,
+			Bodega:          l.Bodega,
+			Numero:          l.Numero,
+			Estado:          "VIGENTE",
+			Cantidad:        l.Cantidad,
+			CantidadInicial: l.CantidadInicial,
+			ValorCosto:      util.Round(float64(l.Cantidad)*p.Cost, 2),
+		}
+		if l.FechaVencimiento != nil {
+			dto.FechaVencimiento = l.FechaVencimiento.Format("2006-01-02")
+			dto.DiasParaVencer = int(math.Round(inicioDelDia(l.FechaVencimiento.In(hoy.Location())).Sub(hoy).Hours() / 24))
+			switch {
+			case dto.DiasParaVencer < 0:
+				dto.Estado = "VENCIDO"
+			case dto.DiasParaVencer <= DiasAlertaVencimiento:
+				dto.Estado = "POR_VENCER"
+			}
+		}
+		if l.Cantidad <= 0 {
+			dto.Estado = "AGOTADO"
+		}
+		result = append(result, dto)
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/xml"
+
+	"gorm.io/gorm"
+)
+
+func loteDe(t *testing.T, bodega, numero string) db.Lot {
+	t.Helper()
+	var lotes []db.Lot
+	db.GetDB().Where("product_sku = ? AND bodega = ? AND numero = ?", "P1", bodega, numero).Limit(1).Find(&lotes)
+	if len(lotes) == 0 {
+		t.Fatalf("Lote %s no encontrado en %s", numero, bodega)
+	}
+	return lotes[0]
+}
+
+func TestLotService_FEFO(t *testing.T) {
+	database := setupTestDB()
+	svc := NewLotService()
+	database.Create(&db.Product{SKU: "P1", Name: "Amoxicilina", Barcode: "P1"})
+
+	hoy := time.Now()
+	lotes := []db.LotDTO{
+		{SKU: "P1", Numero: "L1", FechaVencimiento: hoy.AddDate(0, 0, 60).Format("2006-01-02"), Cantidad: 5},
+		{SKU: "P1", Numero: "L2", FechaVencimiento: hoy.AddDate(0, 0, 10).Format("2006-01-02"), Cantidad: 5},
+		{SKU: "P1", Numero: "L3", FechaVencimiento: hoy.AddDate(0, 0, -1).Format("2006-01-02"), Cantidad: 3},
+	}
+	for _, l := range lotes {
+		if err := svc.IngresarLote(l, true, "tester"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := stockDe(t, "P1"); got != 13 {
+		t.Fatalf("Stock esperado 13, obtuve %g", got)
+	}
+	if err := svc.IngresarLote(db.LotDTO{SKU: "P1", Numero: "L4", Cantidad: 1}, false, "tester"); err == nil {
+		t.Error("No debe asignar un lote a stock que ya tiene lote")
+	}
+
+	t.Run("Por vencer", func(t *testing.T) {
+		porVencer, err := svc.GetLotesPorVencer(30, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(porVencer) != 2 || porVencer[0].Numero != "L3" || porVencer[0].Estado != "VENCIDO" || porVencer[1].Estado != "POR_VENCER" {
+			t.Errorf("Reporte de vencimientos incorrecto: %+v", porVencer)
+		}
+		if msg := svc.alertaVencimientos(); !strings.Contains(msg, "1 lotes vencidos") {
+			t.Errorf("Alerta inesperada: %q", msg)
+		}
+		if _, err := svc.GenerarReporteVencimientosExcel(30, ""); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Detalle de factura", func(t *testing.T) {
+		detalles := anotarLotes(database, "", []xml.Detalle{{CodigoPrincipal: "P1", Cantidad: 7}})
+		if detalles[0].DetallesAdicionales == nil {
+			t.Fatal("El detalle debería incluir los lotes")
+		}
+		texto := detalles[0].DetallesAdicionales.DetAdicional[0].Valor
+		if !strings.HasPrefix(texto, "L2") || !strings.Contains(texto, "L1") || strings.Contains(texto, "L3") {
+			t.Errorf("Lotes FEFO incorrectos en el detalle: %q", texto)
+		}
+	})
+
+	t.Run("Venta y anulación", func(t *testing.T) {
+		err := database.Transaction(func(tx *gorm.DB) error {
+			return descontarVenta(tx, "CLAVE_LOTE", "", []db.FacturaItem{{ProductoSKU: "P1", Cantidad: 7}})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l2, l1, l3 := loteDe(t, "PRINCIPAL", "L2"), loteDe(t, "PRINCIPAL", "L1"), loteDe(t, "PRINCIPAL", "L3"); l2.Cantidad != 0 || l1.Cantidad != 3 || l3.Cantidad != 3 {
+			t.Errorf("FEFO incorrecto: L2=%g L1=%g L3=%g", l2.Cantidad, l1.Cantidad, l3.Cantidad)
+		}
+
+		database.Transaction(func(tx *gorm.DB) error {
+			return revertirMovimientos(tx, "CLAVE_LOTE", MovDevolucion, "Anulación")
+		})
+		if l2, l1 := loteDe(t, "PRINCIPAL", "L2"), loteDe(t, "PRINCIPAL", "L1"); l2.Cantidad != 5 || l1.Cantidad != 5 {
+			t.Errorf("La anulación debió devolver los lotes: L2=%g L1=%g", l2.Cantidad, l1.Cantidad)
+		}
+	})
+
+	t.Run("Baja y traslado", func(t *testing.T) {
+		if err := svc.DarDeBajaLote(loteDe(t, "PRINCIPAL", "L3").ID, "tester", ""); err != nil {
+			t.Fatal(err)
+		}
+		if got := stockDe(t, "P1"); got != 10 {
+			t.Errorf("Stock esperado 10 tras la baja, obtuve %g", got)
+		}
+
+		var p db.Product
+		database.First(&p, "sku = ?", "P1")
+		if p.ExpiryDate == nil || p.ExpiryDate.Format("2006-01-02") != lotes[1].FechaVencimiento {
+			t.Errorf("El vencimiento del producto debería ser el del lote L2: %v", p.ExpiryDate)
+		}
+
+		NewWarehouseService().GuardarBodega(db.WarehouseDTO{Codigo: "SUC2", Nombre: "Sucursal", Activa: true})
+		_, err := NewWarehouseService().Transferir(db.TransferDTO{Origen: "PRINCIPAL", Destino: "SUC2", Items: []db.TransferItemDTO{{SKU: "P1", Cantidad: 6}}}, "tester")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l2, l1 := loteDe(t, "SUC2", "L2"), loteDe(t, "SUC2", "L1"); l2.Cantidad != 5 || l1.Cantidad != 1 || l2.FechaVencimiento == nil {
+			t.Errorf("Los lotes no se trasladaron: L2=%g L1=%g", l2.Cantidad, l1.Cantidad)
+		}
+	})
+}
+
+func TestLoteDeDetalle(t *testing.T) {
+	det := xml.Detalle{DetallesAdicionales: &xml.DetallesAdicionales{DetAdicional: []xml.DetAdicional{
+		{Nombre: "No. Lote", Valor: " AB123 "},
+		{Nombre: "F. Vencimiento", Valor: "31/12/2027"},
+	}}}
+	numero, vence := loteDeDetalle(det)
+	if numero != "AB123" || vence == nil || vence.Format("2006-01-02") != "2027-12-31" {
+		t.Errorf("Lote mal extraído: %q %v", numero, vence)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"fmt"
+
+	"kushkiv2/internal/db"
+)
+
+// Tipos de notificación.
+const (
+	NotifStockBajo   = "STOCK_BAJO"
+	NotifVencimiento = "VENCIMIENTO"
+)
+
+type NotificationService struct{}
+
+func NewNotificationService() *NotificationService {
+	return &NotificationService{}
+}
+
+// Notificar guarda una notificación. Si ya hay una pendiente (no resuelta) con el mismo tipo y
+// referencia no se duplica y devuelve nil.
+func (s *NotificationService) Notificar(tipo, referencia, mensaje string) (*db.Notification, error) {
+	var existentes []db.Notification
+	db.GetDB().Where("tipo = ? AND referencia = ? AND resuelta = ?", tipo, referencia, false).Limit(1).Find(&existentes)
+	if len(existentes) > 0 {
+		return nil, nil
+	}
+	n := db.Notification{Tipo: tipo, Referencia: referencia, Mensaje: mensaje}
+	if err := db.GetDB().Create(&n).Error; err != nil {
+		return nil, fmt.Errorf("error guardando notificación: %v", err)
+	}
+	return &n, nil
+}
+
+// Resolver cierra las notificaciones pendientes de una referencia (por ejemplo, cuando el stock
+// vuelve a estar sobre el mínimo), permitiendo que se avise de nuevo más adelante.
+func (s *NotificationService) Resolver(tipo, referencia string) error {
+	return db.GetDB().Model(&db.Notification{}).
+		Where("tipo = ? AND referencia = ? AND resuelta = ?", tipo, referencia, false).
+		Update("resuelta", true).Error
+}
+
+// Listar devuelve las notificaciones más recientes primero.
+func (s *NotificationService) Listar(soloNoLeidas bool, limite int) ([]db.NotificationDTO, error) {
+	q := db.GetDB().Order("created_at desc, id desc")
+	if soloNoLeidas {
+		q = q.Where("leida = ?", false)
+	}
+	if limite > 0 {
+		q = q.Limit(limite)
+	}
+	var list []db.Notification
+	if err := q.Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("error listando notificaciones: %v", err)
+	}
+	result := make([]db.NotificationDTO, 0, len(list))
+	for _, n := range list {
+		result = append(result, db.NotificationDTO{
+			ID:         n.ID,
+			Tipo:       n.Tipo,
+			Referencia: n.Referencia,
+			Mensaje:    n.Mensaje,
+			Leida:      n.Leida,
+			Resuelta:   n.Resuelta,
+			Fecha:      n.CreatedAt.Format("2006-01-02 15:04"),
+		})
+	}
+	return result, nil
+}
+
+// ContarNoLeidas devuelve cuántas notificaciones no se han leído.
+func (s *NotificationService) ContarNoLeidas() int64 {
+	var count int64
+	db.GetDB().Model(&db.Notification{}).Where("leida = ?", false).Count(&count)
+	return count
+}
+
+// MarcarLeida marca una notificación como leída (id 0 = todas).
+func (s *NotificationService) MarcarLeida(id uint) error {
+	q := db.GetDB().Model(&db.Notification{}).Where("leida = ?", false)
+	if id != 0 {
+		q = q.Where("id = ?", id)
+	}
+	if err := q.Update("leida", true).Error; err != nil {
+		return fmt.Errorf("error actualizando notificaciones: %v", err)
+	}
+	return nil
+}
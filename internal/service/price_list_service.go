@@ -0,0 +1,458 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/util"
+
+	"gorm.io/gorm"
+)
+
+// Origen del precio resuelto para un cliente
+const (
+	ReglaBase            = "BASE"
+	ReglaPrecio          = "PRECIO"
+	ReglaPorcentajeItem  = "PORCENTAJE_ITEM"
+	ReglaPorcentajeLista = "PORCENTAJE_LISTA"
+)
+
+type PriceListService struct{}
+
+func NewPriceListService() *PriceListService {
+	return &PriceListService{}
+}
+
+// ListarListas devuelve las listas de precios con el número de clientes asignados (sin ítems).
+func (s *PriceListService) ListarListas() ([]db.PriceListDTO, error) {
+	var listas []db.PriceList
+	if err := db.GetDB().Order("nombre").Find(&listas).Error; err != nil {
+		return nil, fmt.Errorf("error listando listas de precios: %v", err)
+	}
+	result := make([]db.PriceListDTO, 0, len(listas))
+	for _, l := range listas {
+		dto := mapPriceList(l)
+		db.GetDB().Model(&db.Client{}).Where("lista_precio_id = ?", l.ID).Count(&dto.Clientes)
+		result = append(result, dto)
+	}
+	return result, nil
+}
+
+// GetLista devuelve una lista de precios con sus reglas por producto.
+func (s *PriceListService) GetLista(id uint) (*db.PriceListDTO, error) {
+	var lista db.PriceList
+	if err := db.GetDB().First(&lista, id).Error; err != nil {
+		return nil, fmt.Errorf("lista de precios no encontrada")
+	}
+	dto := mapPriceList(lista)
+	db.GetDB().Model(&db.Client{}).Where("lista_precio_id = ?", id).Count(&dto.Clientes)
+
+	var items []db.PriceListItem
+	db.GetDB().Where("price_list_id = ?", id).Order("product_sku, unidad, cantidad_minima").Find(&items)
+	nombres := nombresProductos(items)
+	dto.Items = make([]db.PriceListItemDTO, 0, len(items))
+	for _, it := range items {
+		dto.Items = append(dto.Items, db.PriceListItemDTO{
+			ID:             it.ID,
+			SKU:            it.ProductSKU,
+			Nombre:         nombres[it.ProductSKU],
+			Unidad:         it.Unidad,
+			CantidadMinima: it.CantidadMinima,
+			Precio:         it.Precio,
+			Porcentaje:     it.Porcentaje,
+			VigenteDesde:   formatearFechaOpcional(it.VigenteDesde),
+			VigenteHasta:   formatearFechaOpcional(it.VigenteHasta),
+		})
+	}
+	return &dto, nil
+}
+
+// GuardarLista crea o actualiza una lista de precios y reemplaza sus reglas. Cada regla necesita
+// un producto existente, una presentación definida (o la unidad base) y un precio o porcentaje;
+// no puede repetirse la misma escala con la misma vigencia.
+func (s *PriceListService) GuardarLista(dto db.PriceListDTO) (*db.PriceListDTO, error) {
+	nombre := strings.TrimSpace(dto.Nombre)
+	if nombre == "" {
+		return nil, fmt.Errorf("la lista necesita un nombre")
+	}
+	if dto.Porcentaje <= -100 {
+		return nil, fmt.Errorf("el porcentaje de la lista debe ser mayor que -100")
+	}
+	desde, hasta, err := parsearVigencia(dto.VigenteDesde, dto.VigenteHasta)
+	if err != nil {
+		return nil, err
+	}
+
+	var id uint
+	err = db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var repetida int64
+		tx.Model(&db.PriceList{}).Where("nombre = ? AND id <> ?", nombre, dto.ID).Count(&repetida)
+		if repetida > 0 {
+			return fmt.Errorf("ya existe una lista llamada %s", nombre)
+		}
+
+		items := make([]db.PriceListItem, 0, len(dto.Items))
+		claves := map[string]bool{}
+		for _, it := range dto.Items {
+			item, err := validarReglaPrecio(tx, it)
+			if err != nil {
+				return err
+			}
+			clave := fmt.Sprintf("%s|%s|%g|%s|%s", item.ProductSKU, item.Unidad, item.CantidadMinima, it.VigenteDesde, it.VigenteHasta)
+			if claves[clave] {
+				return fmt.Errorf("la regla de %s desde %g está repetida", item.ProductSKU, item.CantidadMinima)
+			}
+			claves[clave] = true
+			items = append(items, item)
+		}
+
+		lista := db.PriceList{
+			ID:           dto.ID,
+			Nombre:       nombre,
+			Descripcion:  strings.TrimSpace(dto.Descripcion),
+			Porcentaje:   dto.Porcentaje,
+			VigenteDesde: desde,
+			VigenteHasta: hasta,
+			Activa:       dto.Activa,
+		}
+		if dto.ID != 0 {
+			var actual db.PriceList
+			if err := tx.First(&actual, dto.ID).Error; err != nil {
+				return fmt.Errorf("lista de precios no encontrada")
+			}
+			lista.CreatedAt = actual.CreatedAt
+		}
+		if err := tx.Save(&lista).Error; err != nil {
+			return fmt.Errorf("error guardando lista de precios: %v", err)
+		}
+
+		if err := tx.Where("price_list_id = ?", lista.ID).Delete(&db.PriceListItem{}).Error; err != nil {
+			return fmt.Errorf("error actualizando reglas: %v", err)
+		}
+		for i := range items {
+			items[i].PriceListID = lista.ID
+			if err := tx.Create(&items[i]).Error; err != nil {
+				return fmt.Errorf("error guardando regla de %s: %v", items[i].ProductSKU, err)
+			}
+		}
+		id = lista.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLista(id)
+}
+
+// EliminarLista borra una lista y sus reglas; los clientes que la tenían vuelven al precio base.
+func (s *PriceListService) EliminarLista(id uint) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&db.Client{}).Where("lista_precio_id = ?", id).Update("lista_precio_id", 0).Error; err != nil {
+			return fmt.Errorf("error liberando clientes: %v", err)
+		}
+		if err := tx.Where("price_list_id = ?", id).Delete(&db.PriceListItem{}).Error; err != nil {
+			return fmt.Errorf("error eliminando reglas: %v", err)
+		}
+		if err := tx.Delete(&db.PriceList{}, id).Error; err != nil {
+			return fmt.Errorf("error eliminando lista de precios: %v", err)
+		}
+		return nil
+	})
+}
+
+// AsignarLista asigna una lista de precios a un cliente (0 lo devuelve al precio base).
+func (s *PriceListService) AsignarLista(clienteID string, listaID uint) error {
+	var cliente db.Client
+	if err := db.GetDB().First(&cliente, "id = ?", clienteID).Error; err != nil {
+		return fmt.Errorf("cliente no encontrado: %s", clienteID)
+	}
+	if listaID != 0 {
+		var lista db.PriceList
+		if err := db.GetDB().First(&lista, listaID).Error; err != nil {
+			return fmt.Errorf("lista de precios no encontrada")
+		}
+	}
+	if err := db.GetDB().Model(&cliente).Update("lista_precio_id", listaID).Error; err != nil {
+		return fmt.Errorf("error asignando lista: %v", err)
+	}
+	return nil
+}
+
+// ResolverPrecio calcula el precio de un producto para un cliente en la unidad y cantidad
+// indicadas. Sin lista vigente, o sin regla para el producto, devuelve el precio base.
+func (s *PriceListService) ResolverPrecio(clienteID, sku, unidad string, cantidad float64) (*db.PriceQuoteDTO, error) {
+	tx := db.GetDB()
+	var product db.Product
+	if err := tx.First(&product, "sku = ?", sku).Error; err != nil {
+		return nil, fmt.Errorf("producto no encontrado: %s", sku)
+	}
+	return resolverPrecio(tx, listaCliente(tx, clienteID, time.Now()), &product, unidad, cantidad, time.Now()), nil
+}
+
+// ResolverItems recalcula los precios de un carrito o factura en construcción para el cliente,
+// por ejemplo al cambiarlo: los productos del catálogo toman el precio de su lista o, sin regla,
+// el precio base. Los ítems con PrecioManual no cambian.
+func (s *PriceListService) ResolverItems(clienteID string, items []db.InvoiceItem) ([]db.InvoiceItem, error) {
+	tx := db.GetDB()
+	if err := resolverUnidades(tx, items); err != nil {
+		return nil, err
+	}
+	ahora := time.Now()
+	lista := listaCliente(tx, clienteID, ahora)
+	for i := range items {
+		if items[i].PrecioManual {
+			continue
+		}
+		var products []db.Product
+		tx.Where("sku = ?", items[i].Codigo).Limit(1).Find(&products)
+		if len(products) > 0 {
+			items[i].Precio = resolverPrecio(tx, lista, &products[0], items[i].Unidad, items[i].Cantidad, ahora).Precio
+		}
+	}
+	return items, nil
+}
+
+// aplicarListaPrecios reemplaza el precio de los ítems de productos del catálogo por el de la
+// lista del cliente, salvo los marcados con PrecioManual. Sin regla aplicable el precio no cambia.
+// Un precio distinto del base y del de la lista lo escribió el cajero: se respeta y la línea queda
+// como manual. Las unidades deben estar resueltas (resolverUnidades).
+func aplicarListaPrecios(tx *gorm.DB, clienteID string, items []db.InvoiceItem, fecha time.Time) {
+	lista := listaCliente(tx, clienteID, fecha)
+	if lista == nil {
+		return
+	}
+	for i := range items {
+		if items[i].PrecioManual {
+			continue
+		}
+		var products []db.Product
+		tx.Where("sku = ?", items[i].Codigo).Limit(1).Find(&products)
+		if len(products) == 0 {
+			continue
+		}
+		cotizacion := resolverPrecio(tx, lista, &products[0], items[i].Unidad, items[i].Cantidad, fecha)
+		if precioEditado(items[i].Precio, cotizacion) {
+			items[i].PrecioManual = true
+			continue
+		}
+		if cotizacion.Regla != ReglaBase {
+			items[i].Precio = cotizacion.Precio
+		}
+	}
+}
+
+// precioEditado indica si el precio de la línea no es ni el base ni el de la lista (el carrito
+// pudo traer ya el precio de lista del escaneo). Sin precio se toma el de la lista.
+func precioEditado(precio float64, cotizacion *db.PriceQuoteDTO) bool {
+	precio = util.Round(precio, 4)
+	return precio > 0 && precio != util.Round(cotizacion.PrecioBase, 4) && precio != util.Round(cotizacion.Precio, 4)
+}
+
+// listaCliente devuelve la lista activa y vigente asignada al cliente, o nil.
+func listaCliente(tx *gorm.DB, clienteID string, fecha time.Time) *db.PriceList {
+	if clienteID == "" {
+		return nil
+	}
+	var clientes []db.Client
+	tx.Where("id = ?", clienteID).Limit(1).Find(&clientes)
+	if len(clientes) == 0 || clientes[0].ListaPrecioID == 0 {
+		return nil
+	}
+	var listas []db.PriceList
+	tx.Where("id = ? AND activa = ?", clientes[0].ListaPrecioID, true).Limit(1).Find(&listas)
+	if len(listas) == 0 || !enVigencia(listas[0].VigenteDesde, listas[0].VigenteHasta, fecha) {
+		return nil
+	}
+	return &listas[0]
+}
+
+// resolverPrecio busca, entre las reglas vigentes del producto en la unidad pedida, la de mayor
+// cantidad mínima alcanzada; si no hay, aplica el porcentaje general de la lista.
+func resolverPrecio(tx *gorm.DB, lista *db.PriceList, product *db.Product, unidad string, cantidad float64, fecha time.Time) *db.PriceQuoteDTO {
+	unidad = normalizarUnidad(unidad)
+	if unidad == normalizarUnidad(product.UnidadMedida) {
+		unidad = ""
+	}
+	base := precioBase(tx, product, unidad)
+	cotizacion := &db.PriceQuoteDTO{
+		SKU:        product.SKU,
+		Unidad:     unidad,
+		Cantidad:   cantidad,
+		PrecioBase: base,
+		Precio:     base,
+		Regla:      ReglaBase,
+	}
+	if unidad == "" {
+		cotizacion.Unidad = product.UnidadMedida
+	}
+	if lista == nil {
+		return cotizacion
+	}
+
+	var reglas []db.PriceListItem
+	tx.Where("price_list_id = ? AND product_sku = ? AND unidad = ? AND cantidad_minima <= ?", lista.ID, product.SKU, unidad, cantidad).Find(&reglas)
+	validas := reglas[:0]
+	for _, r := range reglas {
+		if enVigencia(r.VigenteDesde, r.VigenteHasta, fecha) {
+			validas = append(validas, r)
+		}
+	}
+	// Gana la escala más alta; a igual escala, la regla con vigencia más reciente
+	sort.SliceStable(validas, func(i, j int) bool {
+		if validas[i].CantidadMinima != validas[j].CantidadMinima {
+			return validas[i].CantidadMinima > validas[j].CantidadMinima
+		}
+		return inicioVigencia(validas[i]).After(inicioVigencia(validas[j]))
+	})
+
+	switch {
+	case len(validas) > 0 && validas[0].Precio > 0:
+		cotizacion.Precio = validas[0].Precio
+		cotizacion.Regla = ReglaPrecio
+	case len(validas) > 0:
+		cotizacion.Precio = util.Round(base*(1+validas[0].Porcentaje/100), 4)
+		cotizacion.Regla = ReglaPorcentajeItem
+	case lista.Porcentaje != 0:
+		cotizacion.Precio = util.Round(base*(1+lista.Porcentaje/100), 4)
+		cotizacion.Regla = ReglaPorcentajeLista
+	default:
+		return cotizacion
+	}
+	cotizacion.ListaID = lista.ID
+	cotizacion.Lista = lista.Nombre
+	return cotizacion
+}
+
+// precioBase es el precio del producto en la unidad pedida: el de la presentación o, si no lo
+// tiene, el precio base por el factor.
+func precioBase(tx *gorm.DB, product *db.Product, unidad string) float64 {
+	if unidad == "" {
+		return product.Price
+	}
+	var unidades []db.ProductUnit
+	tx.Where("product_sku = ? AND nombre = ?", product.SKU, unidad).Limit(1).Find(&unidades)
+	if len(unidades) == 0 {
+		return product.Price
+	}
+	if unidades[0].Price > 0 {
+		return unidades[0].Price
+	}
+	return util.Round(product.Price*unidades[0].Factor, 4)
+}
+
+func validarReglaPrecio(tx *gorm.DB, it db.PriceListItemDTO) (db.PriceListItem, error) {
+	sku := strings.TrimSpace(it.SKU)
+	var product db.Product
+	if err := tx.First(&product, "sku = ?", sku).Error; err != nil {
+		return db.PriceListItem{}, fmt.Errorf("producto no encontrado: %s", sku)
+	}
+	unidad := normalizarUnidad(it.Unidad)
+	if unidad == normalizarUnidad(product.UnidadMedida) {
+		unidad = ""
+	}
+	if unidad != "" {
+		var existe int64
+		tx.Model(&db.ProductUnit{}).Where("product_sku = ? AND nombre = ?", sku, unidad).Count(&existe)
+		if existe == 0 {
+			return db.PriceListItem{}, fmt.Errorf("la unidad %s no está definida para el producto %s", unidad, sku)
+		}
+	}
+	if it.CantidadMinima < 0 || it.Precio < 0 {
+		return db.PriceListItem{}, fmt.Errorf("la regla de %s tiene valores negativos", sku)
+	}
+	if it.Precio == 0 && it.Porcentaje == 0 {
+		return db.PriceListItem{}, fmt.Errorf("la regla de %s necesita un precio o un porcentaje", sku)
+	}
+	if it.Porcentaje <= -100 {
+		return db.PriceListItem{}, fmt.Errorf("el porcentaje de %s debe ser mayor que -100", sku)
+	}
+	desde, hasta, err := parsearVigencia(it.VigenteDesde, it.VigenteHasta)
+	if err != nil {
+		return db.PriceListItem{}, err
+	}
+	return db.PriceListItem{
+		ProductSKU:     sku,
+		Unidad:         unidad,
+		CantidadMinima: redondearCantidad(it.CantidadMinima),
+		Precio:         it.Precio,
+		Porcentaje:     it.Porcentaje,
+		VigenteDesde:   desde,
+		VigenteHasta:   hasta,
+	}, nil
+}
+
+// parsearVigencia lee un rango de fechas YYYY-MM-DD; cualquiera de los extremos puede ir vacío.
+func parsearVigencia(desdeStr, hastaStr string) (*time.Time, *time.Time, error) {
+	var desde, hasta *time.Time
+	for _, f := range []struct {
+		valor   string
+		destino **time.Time
+	}{{desdeStr, &desde}, {hastaStr, &hasta}} {
+		if strings.TrimSpace(f.valor) == "" {
+			continue
+		}
+		t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(f.valor), time.Local)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fecha de vigencia inválida: %s", f.valor)
+		}
+		*f.destino = &t
+	}
+	if desde != nil && hasta != nil && hasta.Before(*desde) {
+		return nil, nil, fmt.Errorf("la vigencia termina antes de empezar")
+	}
+	return desde, hasta, nil
+}
+
+// enVigencia indica si la fecha cae en el rango; el día de fin se incluye completo.
+func enVigencia(desde, hasta *time.Time, fecha time.Time) bool {
+	if desde != nil && fecha.Before(*desde) {
+		return false
+	}
+	if hasta != nil && !fecha.Before(hasta.AddDate(0, 0, 1)) {
+		return false
+	}
+	return true
+}
+
+func inicioVigencia(r db.PriceListItem) time.Time {
+	if r.VigenteDesde == nil {
+		return time.Time{}
+	}
+	return *r.VigenteDesde
+}
+
+func formatearFechaOpcional(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func nombresProductos(items []db.PriceListItem) map[string]string {
+	skus := make([]string, 0, len(items))
+	for _, it := range items {
+		skus = append(skus, it.ProductSKU)
+	}
+	var products []db.Product
+	db.GetDB().Select("sku, name").Where("sku IN ?", skus).Find(&products)
+	nombres := make(map[string]string, len(products))
+	for _, p := range products {
+		nombres[p.SKU] = p.Name
+	}
+	return nombres
+}
+
+func mapPriceList(l db.PriceList) db.PriceListDTO {
+	return db.PriceListDTO{
+		ID:           l.ID,
+		Nombre:       l.Nombre,
+		Descripcion:  l.Descripcion,
+		Porcentaje:   l.Porcentaje,
+		VigenteDesde: formatearFechaOpcional(l.VigenteDesde),
+		VigenteHasta: formatearFechaOpcional(l.VigenteHasta),
+		Activa:       l.Activa,
+	}
+}
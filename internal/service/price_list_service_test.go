@@ -0,0 +1,166 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+)
+
+func TestPriceListService_Resolucion(t *testing.T) {
+	database := setupTestDB()
+	svc := NewPriceListService()
+	database.Create(&db.Product{SKU: "ACEITE", Name: "Aceite 1L", Barcode: "ACEITE", Price: 4, UnidadMedida: "UND"})
+	database.Create(&db.Product{SKU: "ARROZ", Name: "Arroz 1kg", Barcode: "ARROZ", Price: 1.2})
+	NewUnitService().GuardarUnidades("ACEITE", []db.ProductUnitDTO{{Nombre: "CAJA", Factor: 12, Price: 45}})
+	database.Create(&db.Client{ID: "1790011223001", Nombre: "Distribuidora"})
+	database.Create(&db.Client{ID: "0102030405", Nombre: "Minorista"})
+
+	ayer := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	if _, err := svc.GuardarLista(db.PriceListDTO{Nombre: "X", Activa: true, Items: []db.PriceListItemDTO{{SKU: "ACEITE"}}}); err == nil {
+		t.Error("Una regla sin precio ni porcentaje debe rechazarse")
+	}
+	if _, err := svc.GuardarLista(db.PriceListDTO{Nombre: "X", Activa: true, Items: []db.PriceListItemDTO{{SKU: "ACEITE", Unidad: "PALLET", Precio: 1}}}); err == nil {
+		t.Error("Debe rechazar unidades no definidas")
+	}
+
+	lista, err := svc.GuardarLista(db.PriceListDTO{
+		Nombre:     "MAYORISTA",
+		Porcentaje: -5,
+		Activa:     true,
+		Items: []db.PriceListItemDTO{
+			{SKU: "ACEITE", Precio: 3.8},
+			{SKU: "ACEITE", CantidadMinima: 10, Precio: 3.5},
+			{SKU: "ACEITE", Unidad: "caja", Porcentaje: -10},
+			{SKU: "ACEITE", CantidadMinima: 10, Precio: 2, VigenteHasta: ayer},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lista.Items) != 4 {
+		t.Fatalf("Reglas no guardadas: %+v", lista)
+	}
+	if err := svc.AsignarLista("1790011223001", lista.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []struct {
+		cliente, sku, unidad string
+		cantidad, precio     float64
+		regla                string
+	}{
+		{"1790011223001", "ACEITE", "", 1, 3.8, ReglaPrecio},
+		{"1790011223001", "ACEITE", "UND", 12, 3.5, ReglaPrecio}, // Escala vigente; la vencida no aplica
+		{"1790011223001", "ACEITE", "CAJA", 1, 40.5, ReglaPorcentajeItem},
+		{"1790011223001", "ARROZ", "", 1, 1.14, ReglaPorcentajeLista},
+		{"0102030405", "ACEITE", "", 20, 4, ReglaBase},
+	}
+	for _, c := range casos {
+		quote, err := svc.ResolverPrecio(c.cliente, c.sku, c.unidad, c.cantidad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if quote.Precio != c.precio || quote.Regla != c.regla {
+			t.Errorf("%s %s x%g: esperado %g (%s), obtuve %+v", c.cliente, c.sku, c.cantidad, c.precio, c.regla, quote)
+		}
+	}
+
+	// Una lista inactiva o fuera de vigencia no se aplica
+	lista.Activa = false
+	svc.GuardarLista(*lista)
+	if quote, _ := svc.ResolverPrecio("1790011223001", "ACEITE", "", 1); quote.Regla != ReglaBase {
+		t.Errorf("Lista inactiva aplicada: %+v", quote)
+	}
+
+	if err := svc.EliminarLista(lista.ID); err != nil {
+		t.Fatal(err)
+	}
+	var cliente db.Client
+	database.First(&cliente, "id = ?", "1790011223001")
+	if cliente.ListaPrecioID != 0 {
+		t.Error("El cliente debió quedar sin lista")
+	}
+}
+
+func TestPriceListService_FacturaYCotizacion(t *testing.T) {
+	database := setupTestDB()
+	svc := NewPriceListService()
+	database.Create(&db.Product{SKU: "P1", Name: "Prod 1", Barcode: "P1", Price: 10})
+	database.Create(&db.Client{ID: "1790011223001", Nombre: "Distribuidora", Email: "d@x.com"})
+	lista, _ := svc.GuardarLista(db.PriceListDTO{Nombre: "DISTRIBUIDOR", Activa: true, Items: []db.PriceListItemDTO{{SKU: "P1", Precio: 8}}})
+	svc.AsignarLista("1790011223001", lista.ID)
+
+	dto := db.FacturaDTO{
+		ClienteID:     "1790011223001",
+		ClienteNombre: "Distribuidora",
+		ClienteEmail:  "d@x.com",
+		Items: []db.InvoiceItem{
+			{Codigo: "P1", Nombre: "Prod 1", Cantidad: 2, Precio: 10, CodigoIVA: "0"},
+			{Codigo: "P1", Nombre: "Prod 1", Cantidad: 1, Precio: 9, CodigoIVA: "0", PrecioManual: true},
+		},
+	}
+	preview, err := NewInvoiceService().PrevisualizarFactura(dto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.ImporteTotal != 25 {
+		t.Errorf("La factura debió usar la lista (2x8) y respetar el precio manual (9): total %g", preview.ImporteTotal)
+	}
+
+	// Al cambiar a un cliente sin lista, el carrito vuelve al precio base
+	items, err := svc.ResolverItems("9999999999999", []db.InvoiceItem{{Codigo: "P1", Cantidad: 1, Precio: 8}})
+	if err != nil || items[0].Precio != 10 {
+		t.Errorf("Carrito mal recalculado: %+v %v", items, err)
+	}
+
+	qs := NewQuotationService()
+	if err := qs.CreateQuotation(&db.QuotationDTO{
+		Secuencial:    "000000001",
+		ClienteID:     "1790011223001",
+		ClienteNombre: "Distribuidora",
+		Items:         []db.QuotationItemDTO{{Codigo: "P1", Nombre: "Prod 1", Cantidad: 3, Precio: 10}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var q db.Quotation
+	database.First(&q)
+	if q.Total != 24 {
+		t.Errorf("La cotización debió usar la lista: total %g", q.Total)
+	}
+	var cliente db.Client
+	database.First(&cliente, "id = ?", "1790011223001")
+	if cliente.ListaPrecioID != lista.ID {
+		t.Error("Guardar la cotización no debe quitar la lista del cliente")
+	}
+}
+
+func TestPriceListService_PrecioEditadoEnFactura(t *testing.T) {
+	database := setupTestDB()
+	svc := NewPriceListService()
+	database.Model(&db.EmisorConfig{}).Where("1 = 1").Update("ruc", "1790011223001")
+	database.Create(&db.Product{SKU: "P1", Name: "Prod 1", Barcode: "P1", Price: 10})
+	database.Create(&db.Client{ID: "0912345678", Nombre: "Cliente", Email: "c@x.com"})
+	lista, _ := svc.GuardarLista(db.PriceListDTO{Nombre: "MAYORISTA", Activa: true, Items: []db.PriceListItemDTO{{SKU: "P1", Precio: 8}}})
+	svc.AsignarLista("0912345678", lista.ID)
+
+	dto := &db.FacturaDTO{
+		ClienteID:     "0912345678",
+		ClienteNombre: "Cliente",
+		ClienteEmail:  "c@x.com",
+		Items: []db.InvoiceItem{
+			{Codigo: "P1", Nombre: "Prod 1", Cantidad: 1, Precio: 10, CodigoIVA: "0"},
+			{Codigo: "P1", Nombre: "Prod 1", Cantidad: 1, Precio: 8, CodigoIVA: "0"},
+			// El cajero cambió el precio sin marcar la línea como manual
+			{Codigo: "P1", Nombre: "Prod 1", Cantidad: 1, Precio: 9.5, CodigoIVA: "0"},
+		},
+	}
+	// Sin firma configurada la emisión falla después de calcular los precios
+	NewInvoiceService().EmitirFactura(dto)
+	if dto.Items[0].Precio != 8 || dto.Items[1].Precio != 8 {
+		t.Errorf("Las líneas al precio base o de lista deben tomar la lista: %+v", dto.Items)
+	}
+	if dto.Items[2].Precio != 9.5 || !dto.Items[2].PrecioManual {
+		t.Errorf("El precio editado debe respetarse: %+v", dto.Items[2])
+	}
+}
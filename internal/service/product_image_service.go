@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/util"
+)
+
+// maxBytesImagen limita el tamaño del archivo original (las fotos de celular rondan los 3-5 MB).
+const maxBytesImagen = 15 << 20
+
+var caracteresNoSeguros = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// GuardarImagen normaliza la foto (JPG o PNG) del producto en dir y reemplaza la anterior.
+// El nombre del archivo cambia en cada reemplazo para que el satélite no muestre una copia en caché.
+func (s *ProductService) GuardarImagen(sku string, data []byte, dir string) (string, error) {
+	var product db.Product
+	if err := db.GetDB().First(&product, "sku = ?", sku).Error; err != nil {
+		return "", fmt.Errorf("producto no encontrado: %s", sku)
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("la imagen está vacía")
+	}
+	if len(data) > maxBytesImagen {
+		return "", fmt.Errorf("la imagen supera los %d MB", maxBytesImagen>>20)
+	}
+
+	nombre := fmt.Sprintf("%s_%d", caracteresNoSeguros.ReplaceAllString(sku, "_"), time.Now().UnixNano())
+	ruta, err := util.ProcessAndSaveProductImage(data, dir, nombre)
+	if err != nil {
+		return "", fmt.Errorf("imagen no válida (use JPG o PNG): %v", err)
+	}
+	if err := db.GetDB().Model(&db.Product{}).Where("sku = ?", sku).Update("imagen", ruta).Error; err != nil {
+		eliminarArchivosImagen(ruta)
+		return "", fmt.Errorf("error guardando imagen de %s: %v", sku, err)
+	}
+	eliminarArchivosImagen(product.Imagen)
+	return ruta, nil
+}
+
+// QuitarImagen elimina la foto del producto y sus archivos.
+func (s *ProductService) QuitarImagen(sku string) error {
+	var product db.Product
+	if err := db.GetDB().First(&product, "sku = ?", sku).Error; err != nil {
+		return fmt.Errorf("producto no encontrado: %s", sku)
+	}
+	if err := db.GetDB().Model(&db.Product{}).Where("sku = ?", sku).Update("imagen", "").Error; err != nil {
+		return fmt.Errorf("error quitando imagen de %s: %v", sku, err)
+	}
+	eliminarArchivosImagen(product.Imagen)
+	return nil
+}
+
+// RutaImagen devuelve el archivo de la foto del producto (o de su miniatura).
+func (s *ProductService) RutaImagen(sku string, miniatura bool) (string, error) {
+	var products []db.Product
+	db.GetDB().Where("sku = ?", sku).Limit(1).Find(&products)
+	if len(products) == 0 || products[0].Imagen == "" {
+		return "", fmt.Errorf("el producto %s no tiene imagen", sku)
+	}
+	ruta := products[0].Imagen
+	if miniatura {
+		ruta = util.ThumbnailPath(ruta)
+	}
+	if _, err := os.Stat(ruta); err != nil {
+		return "", fmt.Errorf("no se encontró la imagen de %s", sku)
+	}
+	return ruta, nil
+}
+
+// miniaturasProductos devuelve la miniatura existente de cada SKU que tenga foto.
+func miniaturasProductos(skus []string) map[string]string {
+	var products []db.Product
+	db.GetDB().Select("sku, imagen").Where("sku IN ? AND imagen <> ''", skus).Find(&products)
+	miniaturas := make(map[string]string, len(products))
+	for _, p := range products {
+		ruta := util.ThumbnailPath(p.Imagen)
+		if _, err := os.Stat(ruta); err == nil {
+			miniaturas[p.SKU] = ruta
+		}
+	}
+	return miniaturas
+}
+
+func eliminarArchivosImagen(ruta string) {
+	if ruta == "" {
+		return
+	}
+	os.Remove(ruta)
+	os.Remove(util.ThumbnailPath(ruta))
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/util"
+)
+
+func pngDePrueba(t *testing.T, ancho, alto int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, ancho, alto))
+	for x := 0; x < ancho; x++ {
+		img.Set(x, alto/2, color.NRGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func dimensionesJPEG(t *testing.T, ruta string) (int, int) {
+	t.Helper()
+	f, err := os.Open(ruta)
+	if err != nil {
+		t.Fatalf("Falta el archivo %s: %v", ruta, err)
+	}
+	defer f.Close()
+	cfg, err := jpeg.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("%s no es JPEG: %v", ruta, err)
+	}
+	return cfg.Width, cfg.Height
+}
+
+func TestProductService_Imagenes(t *testing.T) {
+	database := setupTestDB()
+	svc := NewProductService()
+	dir := t.TempDir()
+	database.Create(&db.Product{SKU: "CAF/500", Name: "Café 500g", Barcode: "CAF500", Price: 4.5, TaxPercentage: 15})
+
+	ruta, err := svc.GuardarImagen("CAF/500", pngDePrueba(t, 1600, 400), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, h := dimensionesJPEG(t, ruta); w != util.ProductImageMaxSize || h != 200 {
+		t.Errorf("Imagen no normalizada: %dx%d", w, h)
+	}
+	if w, _ := dimensionesJPEG(t, util.ThumbnailPath(ruta)); w != util.ProductThumbSize {
+		t.Errorf("Miniatura de %dpx", w)
+	}
+	if miniatura, err := svc.RutaImagen("CAF/500", true); err != nil || miniatura != util.ThumbnailPath(ruta) {
+		t.Errorf("Ruta de miniatura incorrecta: %s (%v)", miniatura, err)
+	}
+
+	// Reemplazar borra los archivos anteriores
+	nueva, err := svc.GuardarImagen("CAF/500", pngDePrueba(t, 100, 300), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ruta); !os.IsNotExist(err) {
+		t.Error("La foto anterior debió eliminarse")
+	}
+	if w, h := dimensionesJPEG(t, nueva); w != 100 || h != 300 {
+		t.Errorf("Una imagen pequeña no se amplía: %dx%d", w, h)
+	}
+
+	if _, err := svc.GuardarImagen("CAF/500", []byte("no es imagen"), dir); err == nil {
+		t.Error("Debe rechazar archivos que no son imágenes")
+	}
+	if _, err := svc.GuardarImagen("NADA", pngDePrueba(t, 10, 10), dir); err == nil {
+		t.Error("Debe rechazar productos inexistentes")
+	}
+
+	// Cotización con fotos
+	database.Create(&db.EmisorConfig{RUC: "1234567890001", RazonSocial: "Empresa Test"})
+	dto := &db.QuotationDTO{
+		Secuencial:      "000000001",
+		ClienteID:       "1712345678",
+		ClienteNombre:   "Juan Perez",
+		MostrarImagenes: true,
+		Items: []db.QuotationItemDTO{
+			{Codigo: "CAF/500", Nombre: "Café 500g", Cantidad: 2, Precio: 4.5, PorcentajeIVA: 15},
+			{Codigo: "LIBRE", Nombre: "Flete", Cantidad: 1, Precio: 3},
+		},
+	}
+	if err := NewQuotationService().CreateQuotation(dto); err != nil {
+		t.Fatal(err)
+	}
+	var q db.Quotation
+	database.First(&q)
+	if !bytes.Contains(q.PDFBytes, []byte("/Subtype /Image")) {
+		t.Error("El PDF de la cotización no incluye la foto")
+	}
+
+	if err := svc.QuitarImagen("CAF/500"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(nueva); !os.IsNotExist(err) {
+		t.Error("QuitarImagen debe borrar el archivo")
+	}
+	if _, err := svc.RutaImagen("CAF/500", false); err == nil {
+		t.Error("Sin foto RutaImagen debe fallar")
+	}
+
+	// Al eliminar el producto se borran los archivos de su foto
+	ruta, err = svc.GuardarImagen("CAF/500", pngDePrueba(t, 50, 50), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.EliminarProducto("CAF/500"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(util.ThumbnailPath(ruta)); !os.IsNotExist(err) {
+		t.Error("EliminarProducto debe borrar la foto")
+	}
+}
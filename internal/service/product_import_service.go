@@ -0,0 +1,529 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"kushkiv2/internal/db"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Acciones de una fila importada
+const (
+	ImportCrear      = "CREAR"
+	ImportActualizar = "ACTUALIZAR"
+)
+
+// camposProducto son los campos que se pueden mapear desde las columnas del archivo, en el
+// orden en que se presentan al usuario.
+var camposProducto = []string{
+	"sku", "nombre", "precio", "costo", "stock", "codigo_impuesto", "porcentaje_iva", "barcode",
+	"codigo_auxiliar", "stock_minimo", "vence", "ubicacion", "unidad", "categoria", "marca", "tags", "servicio",
+}
+
+// camposPosicionales es el formato fijo del CSV clásico (ImportProductsFromCSV).
+var camposPosicionales = []string{
+	"sku", "nombre", "precio", "stock", "codigo_impuesto", "porcentaje_iva", "barcode", "codigo_auxiliar",
+	"stock_minimo", "vence", "ubicacion", "unidad", "categoria", "marca", "tags",
+}
+
+// columnasProducto mapea los encabezados aceptados (normalizados) al campo interno.
+var columnasProducto = map[string]string{
+	"sku":              "sku",
+	"codigo":           "sku",
+	"nombre":           "nombre",
+	"name":             "nombre",
+	"descripcion":      "nombre",
+	"producto":         "nombre",
+	"precio":           "precio",
+	"price":            "precio",
+	"pvp":              "precio",
+	"precio_venta":     "precio",
+	"costo":            "costo",
+	"cost":             "costo",
+	"stock":            "stock",
+	"existencia":       "stock",
+	"codigo_impuesto":  "codigo_impuesto",
+	"codimp":           "codigo_impuesto",
+	"taxcode":          "codigo_impuesto",
+	"porcentaje_iva":   "porcentaje_iva",
+	"iva":              "porcentaje_iva",
+	"tarifa":           "porcentaje_iva",
+	"taxpercentage":    "porcentaje_iva",
+	"barcode":          "barcode",
+	"codigo_barras":    "barcode",
+	"codigo_de_barras": "barcode",
+	"ean":              "barcode",
+	"codigo_auxiliar":  "codigo_auxiliar",
+	"aux":              "codigo_auxiliar",
+	"auxiliarycode":    "codigo_auxiliar",
+	"stock_minimo":     "stock_minimo",
+	"min":              "stock_minimo",
+	"minstock":         "stock_minimo",
+	"minimo":           "stock_minimo",
+	"vence":            "vence",
+	"vencimiento":      "vence",
+	"expirydate":       "vence",
+	"ubicacion":        "ubicacion",
+	"location":         "ubicacion",
+	"percha":           "ubicacion",
+	"unidad":           "unidad",
+	"unidad_medida":    "unidad",
+	"unidadmedida":     "unidad",
+	"categoria":        "categoria",
+	"category":         "categoria",
+	"marca":            "marca",
+	"brand":            "marca",
+	"tags":             "tags",
+	"etiquetas":        "tags",
+	"servicio":         "servicio",
+	"es_servicio":      "servicio",
+	"tipo":             "servicio",
+}
+
+// Códigos de porcentaje de IVA del SRI y tarifas admitidas
+var (
+	codigosIVAValidos  = map[int]bool{0: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 10: true}
+	tarifasIVAValidas  = map[float64]bool{0: true, 5: true, 8: true, 12: true, 13: true, 14: true, 15: true}
+	formatosFechaVence = []string{"2006-01-02", "02/01/2006", "2/1/2006", "2006/01/02", "01-02-06"}
+)
+
+var errRevertirImportacion = errors.New("importación revertida")
+
+// ArchivoImportacion es la vista previa de un archivo de productos para mapear sus columnas.
+type ArchivoImportacion struct {
+	Archivo     string         `json:"archivo"`
+	Ruta        string         `json:"ruta"` // Para volver a leerlo al importar
+	Encabezados []string       `json:"encabezados"`
+	Campos      []string       `json:"campos"`  // Campos disponibles para el mapeo
+	Mapeo       map[string]int `json:"mapeo"`   // Campo -> columna sugerida según el encabezado
+	Muestra     [][]string     `json:"muestra"` // Primeras filas de datos
+	Filas       int            `json:"filas"`
+}
+
+// FilaImportacion es el resultado de validar (y aplicar) una fila del archivo (0 = error general).
+type FilaImportacion struct {
+	Fila    int      `json:"fila"`
+	SKU     string   `json:"sku"`
+	Nombre  string   `json:"nombre"`
+	Accion  string   `json:"accion"` // CREAR, ACTUALIZAR
+	Errores []string `json:"errores"`
+}
+
+// ResultadoImportacionProductos resume una importación o su simulación. Si alguna fila tiene
+// errores no se aplica ninguna.
+type ResultadoImportacionProductos struct {
+	Archivo      string            `json:"archivo"`
+	Filas        []FilaImportacion `json:"filas"`
+	Creados      int               `json:"creados"`
+	Actualizados int               `json:"actualizados"`
+	ConError     int               `json:"conError"`
+	Simulacion   bool              `json:"simulacion"`
+	Aplicado     bool              `json:"aplicado"`
+}
+
+// LeerArchivoProductos lee el encabezado y las primeras filas de un XLSX o CSV y sugiere el mapeo
+// de columnas según sus encabezados.
+func (s *ProductService) LeerArchivoProductos(nombreArchivo string, data []byte) (*ArchivoImportacion, error) {
+	rows, _, err := leerTabla(nombreArchivo, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("el archivo está vacío")
+	}
+	archivo := &ArchivoImportacion{
+		Archivo:     filepath.Base(nombreArchivo),
+		Ruta:        nombreArchivo,
+		Encabezados: rows[0],
+		Campos:      camposProducto,
+		Mapeo:       map[string]int{},
+		Muestra:     [][]string{},
+	}
+	for i, h := range rows[0] {
+		if campo, ok := columnasProducto[normalizarEncabezado(h)]; ok {
+			if _, existe := archivo.Mapeo[campo]; !existe {
+				archivo.Mapeo[campo] = i
+			}
+		}
+	}
+	for _, row := range rows[1:] {
+		if filaVacia(row) {
+			continue
+		}
+		archivo.Filas++
+		if len(archivo.Muestra) < 5 {
+			archivo.Muestra = append(archivo.Muestra, row)
+		}
+	}
+	return archivo, nil
+}
+
+// ImportarProductos valida cada fila del archivo con el mapeo indicado (campo -> columna) y crea o
+// actualiza los productos en una sola transacción: si alguna fila tiene errores, o si es una
+// simulación, no se guarda nada. En las actualizaciones las celdas vacías conservan el valor
+// actual; el stock se fija con un ajuste en el kardex.
+func (s *ProductService) ImportarProductos(nombreArchivo string, data []byte, mapeo map[string]int, simular bool) (*ResultadoImportacionProductos, error) {
+	rows, lineas, err := leerTabla(nombreArchivo, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("el archivo no tiene filas de datos")
+	}
+	numeros := make([]int, len(rows)-1)
+	for i := range numeros {
+		numeros[i] = i + 2 // 1-based y saltando el encabezado
+		if lineas != nil {
+			numeros[i] = lineas[i+1]
+		}
+	}
+	return importarFilasProductos(filepath.Base(nombreArchivo), rows[1:], numeros, mapeo, simular)
+}
+
+// GenerarReporteImportacion exporta a Excel el resultado fila por fila de una importación.
+func (s *ProductService) GenerarReporteImportacion(resultado *ResultadoImportacionProductos) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Importación"
+	f.SetSheetName("Sheet1", sheet)
+
+	headers := []string{"Fila", "SKU", "Nombre", "Acción", "Estado", "Errores"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+	}
+	style, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	f.SetRowStyle(sheet, 1, 1, style)
+
+	for i, fila := range resultado.Filas {
+		row := i + 2
+		estado := "OK"
+		if len(fila.Errores) > 0 {
+			estado = "ERROR"
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), fila.Fila)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), fila.SKU)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), fila.Nombre)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), fila.Accion)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), estado)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), strings.Join(fila.Errores, "; "))
+	}
+	f.SetColWidth(sheet, "C", "C", 30)
+	f.SetColWidth(sheet, "F", "F", 60)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error generando reporte: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func importarFilasProductos(archivo string, filas [][]string, numeros []int, mapeo map[string]int, simular bool) (*ResultadoImportacionProductos, error) {
+	if i, ok := mapeo["sku"]; !ok || i < 0 {
+		return nil, fmt.Errorf("falta mapear la columna del SKU")
+	}
+	columnas := map[int]string{}
+	for campo, i := range mapeo {
+		if columnasProducto[campo] != campo {
+			return nil, fmt.Errorf("campo desconocido: %s", campo)
+		}
+		if i < 0 {
+			continue
+		}
+		if otro, ok := columnas[i]; ok {
+			return nil, fmt.Errorf("la columna %d está asignada a %s y a %s", i+1, otro, campo)
+		}
+		columnas[i] = campo
+	}
+
+	resultado := &ResultadoImportacionProductos{Archivo: archivo, Filas: []FilaImportacion{}, Simulacion: simular}
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		skus, barcodes := map[string]int{}, map[string]int{}
+		for i, row := range filas {
+			if filaVacia(row) {
+				continue
+			}
+			fila := importarFilaProducto(tx, row, numeros[i], mapeo, skus, barcodes)
+			resultado.Filas = append(resultado.Filas, fila)
+			switch {
+			case len(fila.Errores) > 0:
+				resultado.ConError++
+			case fila.Accion == ImportCrear:
+				resultado.Creados++
+			default:
+				resultado.Actualizados++
+			}
+		}
+		// La simulación recorre el mismo camino y deshace todo al final
+		if simular || resultado.ConError > 0 {
+			return errRevertirImportacion
+		}
+		return nil
+	})
+	if err != nil && err != errRevertirImportacion {
+		return nil, fmt.Errorf("error aplicando importación: %v", err)
+	}
+	resultado.Aplicado = err == nil
+	return resultado, nil
+}
+
+// importarFilaProducto valida una fila y, si no tiene errores, crea o actualiza el producto dentro
+// de la transacción. skus y barcodes guardan la fila donde apareció cada código en el archivo.
+func importarFilaProducto(tx *gorm.DB, row []string, numero int, mapeo map[string]int, skus, barcodes map[string]int) FilaImportacion {
+	valor := func(campo string) string {
+		if i, ok := mapeo[campo]; ok && i >= 0 && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	fila := FilaImportacion{Fila: numero, SKU: valor("sku"), Nombre: valor("nombre"), Errores: []string{}}
+	errorf := func(format string, args ...interface{}) {
+		fila.Errores = append(fila.Errores, fmt.Sprintf(format, args...))
+	}
+
+	if fila.SKU == "" {
+		errorf("SKU vacío")
+		return fila
+	}
+	if previa, ok := skus[fila.SKU]; ok {
+		errorf("SKU repetido en el archivo (fila %d)", previa)
+		return fila
+	}
+	skus[fila.SKU] = numero
+
+	var existentes []db.Product
+	tx.Where("sku = ?", fila.SKU).Limit(1).Find(&existentes)
+	nuevo := len(existentes) == 0
+	product := db.Product{SKU: fila.SKU, TaxCode: 2, TaxPercentage: 15}
+	fila.Accion = ImportCrear
+	if !nuevo {
+		product = existentes[0]
+		fila.Accion = ImportActualizar
+	}
+
+	if fila.Nombre != "" {
+		product.Name = fila.Nombre
+	} else if nuevo {
+		errorf("Nombre obligatorio para un producto nuevo")
+	} else {
+		fila.Nombre = product.Name
+	}
+
+	decimal := func(campo, etiqueta string, destino *float64) bool {
+		v := valor(campo)
+		if v == "" {
+			return false
+		}
+		n, err := parsearDecimal(v)
+		if err != nil || n < 0 {
+			errorf("%s inválido: '%s'", etiqueta, v)
+			return false
+		}
+		*destino = n
+		return true
+	}
+	if !decimal("precio", "Precio", &product.Price) && nuevo && valor("precio") == "" {
+		errorf("Precio obligatorio para un producto nuevo")
+	}
+	decimal("costo", "Costo", &product.Cost)
+	decimal("stock_minimo", "Stock mínimo", &product.MinStock)
+	var stock float64
+	fijarStock := decimal("stock", "Stock", &stock)
+
+	if v := valor("codigo_impuesto"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || !codigosIVAValidos[n] {
+			errorf("Código de impuesto inválido: '%s'", v)
+		} else {
+			product.TaxCode = n
+		}
+	}
+	if v := valor("porcentaje_iva"); v != "" {
+		n, err := parsearDecimal(strings.TrimSuffix(v, "%"))
+		n = redondearTarifa(n)
+		if err != nil || !tarifasIVAValidas[n] {
+			errorf("Porcentaje de IVA inválido: '%s'", v)
+		} else {
+			product.TaxPercentage = int(n)
+			if valor("codigo_impuesto") == "" {
+				product.TaxCode, _ = strconv.Atoi(codigoIVADesdePorcentaje(n))
+			}
+		}
+	}
+
+	if v := valor("barcode"); v != "" {
+		if previa, ok := barcodes[v]; ok {
+			errorf("Código de barras %s repetido en el archivo (fila %d)", v, previa)
+		} else {
+			barcodes[v] = numero
+			var otros []db.Product
+			tx.Where("barcode = ? AND sku <> ?", v, fila.SKU).Limit(1).Find(&otros)
+			var unidades []db.ProductUnit
+			tx.Where("barcode = ?", v).Limit(1).Find(&unidades)
+			switch {
+			case len(otros) > 0:
+				errorf("El código de barras %s ya pertenece a %s", v, otros[0].SKU)
+			case len(unidades) > 0:
+				errorf("El código de barras %s ya pertenece a la presentación %s de %s", v, unidades[0].Nombre, unidades[0].ProductSKU)
+			default:
+				product.Barcode = v
+			}
+		}
+	}
+
+	if v := valor("vence"); v != "" {
+		if vence, ok := parsearFechaImportacion(v); ok {
+			product.ExpiryDate = &vence
+		} else {
+			errorf("Fecha de vencimiento inválida: '%s' (use AAAA-MM-DD o DD/MM/AAAA)", v)
+		}
+	}
+	if v := valor("codigo_auxiliar"); v != "" {
+		product.AuxiliaryCode = v
+	}
+	if v := valor("ubicacion"); v != "" {
+		product.Location = v
+	}
+	if v := valor("unidad"); v != "" {
+		product.UnidadMedida = normalizarUnidad(v)
+	}
+
+	servicio := product.EsServicio
+	if v := valor("servicio"); v != "" {
+		if b, ok := parsearSiNo(v); ok {
+			servicio = b
+		} else {
+			errorf("Tipo inválido: '%s' (use SI/NO o SERVICIO/BIEN)", v)
+		}
+	}
+	stockFinal := product.Stock
+	if fijarStock {
+		stockFinal = stock
+	}
+	if product.EsKit && fijarStock {
+		errorf("Es un kit: su stock depende de los componentes")
+	}
+	if servicio && stockFinal != 0 {
+		errorf("Un servicio no lleva stock")
+	}
+	if servicio && !product.EsServicio && !nuevo {
+		var usos int64
+		tx.Model(&db.KitComponent{}).Where("component_sku = ?", fila.SKU).Count(&usos)
+		if product.EsKit || usos > 0 {
+			errorf("Un kit o un componente de kit no puede ser servicio")
+		}
+	}
+
+	var categoryID, brandID uint
+	if v := valor("categoria"); v != "" {
+		id, err := categoriaPorRuta(tx, v)
+		if err != nil {
+			errorf("Categoría inválida: %v", err)
+		}
+		categoryID = id
+	}
+	if v := valor("marca"); v != "" {
+		id, err := marcaPorNombre(tx, v)
+		if err != nil {
+			errorf("Marca inválida: %v", err)
+		}
+		brandID = id
+	}
+	if len(fila.Errores) > 0 {
+		return fila
+	}
+
+	if categoryID != 0 {
+		product.CategoryID = categoryID
+	}
+	if brandID != 0 {
+		product.BrandID = brandID
+	}
+	if nuevo {
+		// Sin código de barras se asigna un EAN-13 interno (el código es único en el catálogo)
+		if product.Barcode == "" {
+			codigo, err := siguienteEANInterno(tx)
+			if err != nil {
+				errorf("%v", err)
+				return fila
+			}
+			product.Barcode = codigo
+		}
+		product.EsServicio = servicio
+		if err := tx.Create(&product).Error; err != nil {
+			errorf("Error creando producto: %v", err)
+			return fila
+		}
+	} else if err := tx.Save(&product).Error; err != nil {
+		errorf("Error actualizando producto: %v", err)
+		return fila
+	}
+
+	// El stock cambia por el kardex: un servicio que pasa a bien se marca antes de recibir su
+	// stock y un bien que pasa a servicio, después de quedar en cero
+	if !nuevo && !servicio && product.EsServicio {
+		if err := tx.Model(&db.Product{}).Where("sku = ?", fila.SKU).Update("es_servicio", false).Error; err != nil {
+			errorf("Error actualizando tipo: %v", err)
+			return fila
+		}
+		product.EsServicio = false
+	}
+	if fijarStock && !product.EsServicio {
+		if diferencia := redondearCantidad(stock - product.Stock); diferencia != 0 {
+			mov := &db.StockMovement{ProductSKU: fila.SKU, Tipo: MovAjuste, Cantidad: diferencia, Nota: "Importación de productos"}
+			if nuevo {
+				mov.CostoUnitario = product.Cost
+			}
+			if err := registrarMovimiento(tx, mov); err != nil {
+				errorf("Error ajustando stock: %v", err)
+				return fila
+			}
+		}
+	}
+	if !nuevo && servicio && !product.EsServicio {
+		if err := tx.Model(&db.Product{}).Where("sku = ?", fila.SKU).Update("es_servicio", servicio).Error; err != nil {
+			errorf("Error actualizando tipo: %v", err)
+			return fila
+		}
+	}
+	if v := valor("tags"); v != "" {
+		if err := guardarTags(tx, fila.SKU, strings.FieldsFunc(v, func(r rune) bool { return r == ';' || r == '|' })); err != nil {
+			errorf("Error guardando etiquetas: %v", err)
+		}
+	}
+	return fila
+}
+
+// parsearFechaImportacion acepta AAAA-MM-DD, DD/MM/AAAA y el formato corto con que Excel muestra
+// las celdas de fecha.
+func parsearFechaImportacion(v string) (time.Time, bool) {
+	for _, formato := range formatosFechaVence {
+		if t, err := time.ParseInLocation(formato, v, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parsearSiNo(v string) (bool, bool) {
+	switch normalizarEncabezado(v) {
+	case "si", "s", "x", "1", "true", "verdadero", "servicio":
+		return true, true
+	case "no", "n", "0", "false", "falso", "bien", "producto":
+		return false, true
+	}
+	return false, false
+}
+
+// redondearTarifa evita que "15.0000001" de una hoja de cálculo no coincida con la tarifa.
+func redondearTarifa(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"kushkiv2/internal/db"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestImportarProductos_ValidacionYSimulacion(t *testing.T) {
+	database := setupTestDB()
+	svc := NewProductService()
+	database.Create(&db.Product{SKU: "EXISTE", Name: "Existente", Barcode: "7800000000017", Price: 1})
+
+	f := excelize.NewFile()
+	filas := [][]interface{}{
+		{"Código", "Descripción", "PVP", "Stock", "IVA", "Código de barras", "Vencimiento", "Categoría"},
+		{"A1", "Arroz", "1,25", "10", "15", "111", "31/12/2030", "Granos"},
+		{"A2", "Azúcar", "abc", "5", "15", "111", "2030-13-01", "Granos"},
+		{"A3", "Sal", "0.5", "", "16", "7800000000017", "", ""},
+		{"A1", "Arroz repetido", "1", "", "", "", "", ""},
+	}
+	for i, fila := range filas {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		f.SetSheetRow("Sheet1", cell, &fila)
+	}
+	buf, _ := f.WriteToBuffer()
+
+	archivo, err := svc.LeerArchivoProductos("catalogo.xlsx", buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archivo.Filas != 4 || archivo.Mapeo["sku"] != 0 || archivo.Mapeo["precio"] != 2 || archivo.Mapeo["barcode"] != 5 || archivo.Mapeo["vence"] != 6 {
+		t.Fatalf("Mapeo sugerido incorrecto: %+v", archivo)
+	}
+
+	res, err := svc.ImportarProductos("catalogo.xlsx", buf.Bytes(), archivo.Mapeo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Aplicado || res.ConError != 3 || res.Creados != 1 {
+		t.Fatalf("Resultado inesperado: %+v", res)
+	}
+	errores := strings.Join(res.Filas[1].Errores, " | ")
+	for _, esperado := range []string{"Precio inválido", "repetido en el archivo (fila 2)", "Fecha de vencimiento inválida"} {
+		if !strings.Contains(errores, esperado) {
+			t.Errorf("Falta el error '%s' en la fila 3: %s", esperado, errores)
+		}
+	}
+	errores = strings.Join(res.Filas[2].Errores, " | ")
+	if !strings.Contains(errores, "Porcentaje de IVA inválido") || !strings.Contains(errores, "ya pertenece a EXISTE") {
+		t.Errorf("Errores de la fila 4: %s", errores)
+	}
+	if res.Filas[3].Errores[0] != "SKU repetido en el archivo (fila 2)" {
+		t.Errorf("Errores de la fila 5: %v", res.Filas[3].Errores)
+	}
+
+	// Con errores no se guarda nada, ni siquiera las filas válidas ni las categorías nuevas
+	var productos, categorias int64
+	database.Model(&db.Product{}).Count(&productos)
+	database.Model(&db.Category{}).Count(&categorias)
+	if productos != 1 || categorias != 0 {
+		t.Errorf("La importación fallida dejó cambios: %d productos, %d categorías", productos, categorias)
+	}
+	if data, err := svc.GenerarReporteImportacion(res); err != nil || len(data) == 0 {
+		t.Errorf("Reporte no generado: %v", err)
+	}
+}
+
+func TestImportarProductos_Aplicar(t *testing.T) {
+	database := setupTestDB()
+	svc := NewProductService()
+	database.Create(&db.Product{SKU: "EXISTE", Name: "Existente", Barcode: "EXISTE", Price: 1, Stock: 4, Location: "P1"})
+
+	csv := "sku;nombre;precio;costo;stock;tipo;etiquetas\n" +
+		"EXISTE;;2,50;;10;;oferta\n" +
+		"NUEVO;Nuevo;3;1,5;6;;\n" +
+		"MO;Mano de obra;20;;;servicio;\n"
+	mapeo := map[string]int{"sku": 0, "nombre": 1, "precio": 2, "costo": 3, "stock": 4, "servicio": 5, "tags": 6}
+
+	res, err := svc.ImportarProductos("productos.csv", []byte(csv), mapeo, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Simulacion || res.Aplicado || res.Creados != 2 || res.Actualizados != 1 || res.ConError != 0 {
+		t.Fatalf("Simulación inesperada: %+v", res)
+	}
+	if stockDe(t, "EXISTE") != 4 {
+		t.Error("La simulación no debe modificar el catálogo")
+	}
+
+	res, err = svc.ImportarProductos("productos.csv", []byte(csv), mapeo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Aplicado {
+		t.Fatalf("La importación debió aplicarse: %+v", res)
+	}
+	var existe, nuevo, mo db.Product
+	database.First(&existe, "sku = ?", "EXISTE")
+	database.First(&nuevo, "sku = ?", "NUEVO")
+	database.First(&mo, "sku = ?", "MO")
+	if existe.Name != "Existente" || existe.Location != "P1" || existe.Price != 2.5 || existe.Stock != 10 {
+		t.Errorf("Actualización incorrecta (las celdas vacías deben conservar el valor): %+v", existe)
+	}
+	if nuevo.Stock != 6 || nuevo.Cost != 1.5 || !strings.HasPrefix(nuevo.Barcode, prefijoEANInterno) {
+		t.Errorf("Alta incorrecta: %+v", nuevo)
+	}
+	if !mo.EsServicio || mo.Stock != 0 {
+		t.Errorf("El servicio no se marcó: %+v", mo)
+	}
+	var movs int64
+	database.Model(&db.StockMovement{}).Where("product_sku IN ? AND tipo = ?", []string{"EXISTE", "NUEVO"}, MovAjuste).Count(&movs)
+	if movs != 2 {
+		t.Errorf("El stock debe pasar por el kardex, movimientos: %d", movs)
+	}
+
+	if _, err := svc.ImportarProductos("productos.csv", []byte(csv), map[string]int{"nombre": 1}, true); err == nil {
+		t.Error("Sin la columna del SKU la importación debe fallar")
+	}
+}
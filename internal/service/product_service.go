@@ -1,13 +1,12 @@
 package service
 
 import (
-	"encoding/csv"
 	"fmt"
 	"io"
 	"kushkiv2/internal/db"
-	"strconv"
 	"strings"
-	"time"
+
+	"gorm.io/gorm"
 )
 
 type ProductService struct{}
@@ -17,118 +16,115 @@ func NewProductService() *ProductService {
 }
 
 // ImportProductsFromCSV lee un CSV e inserta/actualiza productos en la base de datos.
-// Formato esperado: SKU, Nombre, Precio, Stock, CodigoImpuesto, PorcentajeIVA, Barcode, AuxiliaryCode, MinStock, ExpiryDate, Location
+// Formato esperado: SKU, Nombre, Precio, Stock, CodigoImpuesto, PorcentajeIVA, Barcode, AuxiliaryCode, MinStock, ExpiryDate, Location, UnidadMedida,
+// Categoria ("Bebidas > Gaseosas"), Marca, Tags (separados por ";"). Las categorías y marcas que no existan se crean.
+// Usa las mismas validaciones que ImportarProductos: si alguna fila es inválida no se importa ninguna.
 func (s *ProductService) ImportProductsFromCSV(reader io.Reader) (int, error) {
-	csvReader := csv.NewReader(reader)
-	// Saltar cabecera si existe (asumimos que la primera fila es cabecera si contiene "sku" o "nombre")
-	firstRow, err := csvReader.Read()
+	data, err := io.ReadAll(reader)
 	if err != nil {
 		return 0, fmt.Errorf("error leyendo CSV: %v", err)
 	}
+	rows, lineas, err := leerTabla("productos.csv", data)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, fmt.Errorf("error leyendo CSV: archivo vacío")
+	}
 
-	isHeader := false
-	for _, cell := range firstRow {
-		lower := strings.ToLower(cell)
+	// Saltar cabecera si existe (asumimos que la primera fila es cabecera si contiene "sku" o "nombre")
+	inicio := 0
+	for _, cell := range rows[0] {
+		lower := strings.ToLower(strings.TrimSpace(cell))
 		if lower == "sku" || lower == "nombre" || lower == "name" {
-			isHeader = true
+			inicio = 1
 			break
 		}
 	}
 
-	rowsToProcess := [][]string{}
-	if !isHeader {
-		rowsToProcess = append(rowsToProcess, firstRow)
+	mapeo := make(map[string]int, len(camposPosicionales))
+	for i, campo := range camposPosicionales {
+		mapeo[campo] = i
 	}
-
-	importedCount := 0
-	for {
-		record, err := csvReader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return importedCount, fmt.Errorf("error leyendo fila: %v", err)
-		}
-		rowsToProcess = append(rowsToProcess, record)
+	resultado, err := importarFilasProductos("productos.csv", rows[inicio:], lineas[inicio:], mapeo, false)
+	if err != nil {
+		return 0, err
 	}
-
-	for _, record := range rowsToProcess {
-		if len(record) < 3 {
-			continue // Fila inválida
-		}
-
-		sku := strings.TrimSpace(record[0])
-		name := strings.TrimSpace(record[1])
-		price, _ := strconv.ParseFloat(strings.ReplaceAll(record[2], ",", "."), 64)
-		
-		stock := 0
-		if len(record) > 3 {
-			stock, _ = strconv.Atoi(record[3])
-		}
-
-		taxCode := 2 // Default IVA
-		if len(record) > 4 {
-			taxCode, _ = strconv.Atoi(record[4])
-		}
-
-		taxPercentage := 15 // Default 15%
-		if len(record) > 5 {
-			taxPercentage, _ = strconv.Atoi(record[5])
+	if resultado.ConError > 0 {
+		for _, fila := range resultado.Filas {
+			if len(fila.Errores) > 0 {
+				return 0, fmt.Errorf("%d filas con errores, no se importó ninguna; fila %d: %s",
+					resultado.ConError, fila.Fila, strings.Join(fila.Errores, "; "))
+			}
 		}
+	}
+	return resultado.Creados + resultado.Actualizados, nil
+}
 
-		var barcode, auxCode, location string
-		var minStock int
-		var expiryDate *time.Time
-
-		if len(record) > 6 {
-			barcode = strings.TrimSpace(record[6])
-		}
-		if len(record) > 7 {
-			auxCode = strings.TrimSpace(record[7])
-		}
-		if len(record) > 8 {
-			minStock, _ = strconv.Atoi(record[8])
+// MarcarServicio cambia el tipo del producto entre bien y servicio. Un servicio no lleva inventario,
+// por lo que solo puede marcarse así un producto sin stock que no sea kit ni componente de uno.
+func (s *ProductService) MarcarServicio(sku string, servicio bool) error {
+	var product db.Product
+	if err := db.GetDB().First(&product, "sku = ?", sku).Error; err != nil {
+		return fmt.Errorf("producto no encontrado: %s", sku)
+	}
+	if product.EsServicio == servicio {
+		return nil
+	}
+	if servicio {
+		if product.EsKit {
+			return fmt.Errorf("%s es un kit y no puede ser servicio", sku)
 		}
-		if len(record) > 9 {
-			expiryStr := strings.TrimSpace(record[9])
-			if expiryStr != "" {
-				parsed, err := time.Parse("2006-01-02", expiryStr)
-				if err == nil {
-					expiryDate = &parsed
-				}
-			}
+		if product.Stock != 0 {
+			return fmt.Errorf("%s tiene stock (%g); ajústelo a cero antes de convertirlo en servicio", sku, product.Stock)
 		}
-		if len(record) > 10 {
-			location = strings.TrimSpace(record[10])
+		var usos int64
+		db.GetDB().Model(&db.KitComponent{}).Where("component_sku = ?", sku).Count(&usos)
+		if usos > 0 {
+			return fmt.Errorf("%s es componente de un kit y no puede ser servicio", sku)
 		}
+	}
+	if err := db.GetDB().Model(&db.Product{}).Where("sku = ?", sku).Update("es_servicio", servicio).Error; err != nil {
+		return fmt.Errorf("error actualizando tipo de %s: %v", sku, err)
+	}
+	return nil
+}
 
-		if sku == "" || name == "" {
-			continue
+// EliminarProducto borra el producto junto con la composición si es kit y su stock por bodega y
+// lotes, en una sola transacción. Un producto con movimientos en el kardex no se elimina (como
+// las bodegas): su historia sostiene la valoración del inventario y, si el SKU se volviera a crear,
+// heredaría ese stock. Los archivos de la foto se borran solo después de eliminar el registro,
+// para no dejar un producto apuntando a una foto inexistente.
+func (s *ProductService) EliminarProducto(sku string) error {
+	var kits int64
+	db.GetDB().Model(&db.KitComponent{}).Where("component_sku = ?", sku).Count(&kits)
+	if kits > 0 {
+		return fmt.Errorf("%s es componente de %d kit(s)", sku, kits)
+	}
+	var movimientos int64
+	db.GetDB().Model(&db.StockMovement{}).Where("product_sku = ?", sku).Count(&movimientos)
+	if movimientos > 0 {
+		return fmt.Errorf("%s tiene %d movimientos de inventario y no puede eliminarse", sku, movimientos)
+	}
+	var products []db.Product
+	db.GetDB().Where("sku = ?", sku).Limit(1).Find(&products)
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&db.Product{}, "sku = ?", sku).Error; err != nil {
+			return err
 		}
-
-		product := db.Product{
-			SKU:           sku,
-			Name:          name,
-			Price:         price,
-			Stock:         stock,
-			TaxCode:       taxCode,
-			TaxPercentage: taxPercentage,
-			Barcode:       barcode,
-			AuxiliaryCode: auxCode,
-			MinStock:      minStock,
-			ExpiryDate:    expiryDate,
-			Location:      location,
+		if err := tx.Where("kit_sku = ?", sku).Delete(&db.KitComponent{}).Error; err != nil {
+			return err
 		}
-
-		// Upsert logic
-		var existing db.Product
-		if err := db.GetDB().Where("sku = ?", sku).First(&existing).Error; err == nil {
-			db.GetDB().Model(&existing).Updates(product)
-		} else {
-			db.GetDB().Create(&product)
+		if err := tx.Delete(&db.ProductStock{}, "product_sku = ?", sku).Error; err != nil {
+			return err
 		}
-		importedCount++
+		return tx.Delete(&db.Lot{}, "product_sku = ?", sku).Error
+	})
+	if err != nil {
+		return err
 	}
-
-	return importedCount, nil
+	if len(products) > 0 {
+		eliminarArchivosImagen(products[0].Imagen)
+	}
+	return nil
 }
@@ -4,6 +4,9 @@ import (
 	"kushkiv2/internal/db"
 	"strings"
 	"testing"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 func TestImportProductsFromCSV(t *testing.T) {
@@ -35,7 +38,7 @@ P200,Producto Test 2,20.00,50,2,12
 	}
 
 	if p1.Stock != 100 {
-		t.Errorf("Stock incorrecto: %d", p1.Stock)
+		t.Errorf("Stock incorrecto: %g", p1.Stock)
 	}
 
 	// Probar actualización (Upsert)
@@ -54,3 +57,110 @@ P100,Producto Test 1 Actualizado,15.00,200,2,15
 		t.Errorf("Actualización fallida: %+v", p1)
 	}
 }
+
+func TestProductService_Servicios(t *testing.T) {
+	database := setupTestDB()
+	svc := NewProductService()
+	database.Create(&db.Product{SKU: "MANO-OBRA", Name: "Mano de obra", Barcode: "MANO-OBRA", Price: 20, Cost: 8, MinStock: 5})
+	database.Create(&db.Product{SKU: "FILTRO", Name: "Filtro", Barcode: "FILTRO", Price: 10, Cost: 6, Stock: 4, MinStock: 5})
+
+	if err := svc.MarcarServicio("FILTRO", true); err == nil {
+		t.Error("Un producto con stock no debe convertirse en servicio")
+	}
+	if err := svc.MarcarServicio("MANO-OBRA", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewInventoryService().AjustarStock("MANO-OBRA", "", 3, false, "test", ""); err == nil {
+		t.Error("Un servicio no debe aceptar stock")
+	}
+	if err := NewKitService().GuardarComponentes("FILTRO", false, []db.KitComponentDTO{{SKU: "MANO-OBRA", Cantidad: 1}}); err == nil {
+		t.Error("Un servicio no puede ser componente de un kit")
+	}
+
+	// El servicio no entra en las alertas de stock mínimo
+	nuevas, err := NewReorderService(NewNotificationService()).RevisarStockMinimo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nuevas) != 1 || !strings.Contains(nuevas[0].Mensaje, "FILTRO") {
+		t.Errorf("Solo el filtro debía alertar: %+v", nuevas)
+	}
+
+	// La venta del servicio no mueve stock y se valora a su costo
+	venta := []db.FacturaItem{{ProductoSKU: "MANO-OBRA", Cantidad: 2, Factor: 1}, {ProductoSKU: "FILTRO", Cantidad: 1, Factor: 1}}
+	if err := database.Transaction(func(tx *gorm.DB) error {
+		return descontarVenta(tx, "CLAVE_SERV", "", venta)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if venta[0].CostoUnitario != 8 || stockDe(t, "FILTRO") != 3 {
+		t.Errorf("Venta mal registrada: costo %g, stock filtro %g", venta[0].CostoUnitario, stockDe(t, "FILTRO"))
+	}
+	var movs int64
+	database.Model(&db.StockMovement{}).Where("product_sku = ?", "MANO-OBRA").Count(&movs)
+	if movs != 0 {
+		t.Error("El servicio no debe tener movimientos de kardex")
+	}
+
+	// El texto libre de la línea va como detalle adicional
+	dto := &db.FacturaDTO{Items: []db.InvoiceItem{{Codigo: "MANO-OBRA", Nombre: "Mano de obra", Cantidad: 2, Precio: 20, Detalle: " Cambio de aceite, placa PBA-1234 ", CodigoIVA: "4", PorcentajeIVA: 15}}}
+	calculo := calcularFactura(dto)
+	adicionales := calculo.Detalles[0].DetallesAdicionales
+	if adicionales == nil || adicionales.DetAdicional[0].Nombre != "Detalle" || adicionales.DetAdicional[0].Valor != "Cambio de aceite, placa PBA-1234" {
+		t.Errorf("Detalle de la línea no incluido: %+v", adicionales)
+	}
+	dto.Items[0].Detalle = strings.Repeat("x", 301)
+	if err := NewInvoiceService().validarFactura(&db.EmisorConfig{}, dto); err == nil {
+		t.Error("Un detalle de más de 300 caracteres debe rechazarse")
+	}
+
+	database.Create(&db.Factura{ClaveAcceso: "CLAVE_SERV", Secuencial: "000000001", FechaEmision: time.Now(), EstadoSRI: "AUTORIZADO", Total: 50})
+	for i := range venta {
+		venta[i].FacturaClave = "CLAVE_SERV"
+		venta[i].Subtotal = map[string]float64{"MANO-OBRA": 40, "FILTRO": 10}[venta[i].ProductoSKU]
+	}
+	database.Create(&venta)
+	margenes, err := NewReportService().GetMargins(time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tipos := map[string]MargenGrupo{}
+	for _, g := range margenes.Tipos {
+		tipos[g.Grupo] = g
+	}
+	if tipos[GrupoServicios].Venta != 40 || tipos[GrupoServicios].Costo != 16 || tipos[GrupoBienes].Venta != 10 {
+		t.Errorf("Ventas por tipo incorrectas: %+v", margenes.Tipos)
+	}
+	if margenes.Facturas[0].Servicios != 40 || margenes.Facturas[0].Bienes != 10 {
+		t.Errorf("Desglose de la factura incorrecto: %+v", margenes.Facturas[0])
+	}
+}
+
+func TestProductService_EliminarProducto(t *testing.T) {
+	database := setupTestDB()
+	svc := NewProductService()
+	database.Create(&db.Product{SKU: "VENDIDO", Name: "Vendido", Barcode: "VENDIDO"})
+	database.Create(&db.Product{SKU: "NUEVO", Name: "Nuevo", Barcode: "NUEVO", Stock: 5})
+	if _, err := NewInventoryService().AjustarStock("VENDIDO", "", 4, false, "test", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.EliminarProducto("VENDIDO"); err == nil {
+		t.Error("Un producto con movimientos no debe eliminarse")
+	}
+
+	// Sin movimientos se elimina junto con su stock por bodega y lotes
+	if _, err := NewWarehouseService().GetStockPorBodega("NUEVO"); err != nil {
+		t.Fatal(err)
+	}
+	database.Create(&db.Lot{ProductSKU: "NUEVO", Bodega: "PRINCIPAL", Numero: "L1", Cantidad: 5})
+	if err := svc.EliminarProducto("NUEVO"); err != nil {
+		t.Fatal(err)
+	}
+	var stocks, lotes int64
+	database.Model(&db.ProductStock{}).Where("product_sku = ?", "NUEVO").Count(&stocks)
+	database.Model(&db.Lot{}).Where("product_sku = ?", "NUEVO").Count(&lotes)
+	if stocks != 0 || lotes != 0 {
+		t.Errorf("Quedaron %d registros de stock y %d lotes del producto eliminado", stocks, lotes)
+	}
+}
@@ -0,0 +1,490 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/util"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Tipos de promoción
+const (
+	PromoPorcentaje = "PORCENTAJE"
+	PromoNxM        = "NXM"
+)
+
+var horaRe = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+type PromotionService struct{}
+
+func NewPromotionService() *PromotionService {
+	return &PromotionService{}
+}
+
+// ListarPromociones devuelve todas las promociones, las activas primero.
+func (s *PromotionService) ListarPromociones() ([]db.PromotionDTO, error) {
+	var promos []db.Promotion
+	if err := db.GetDB().Order("activa desc, nombre").Find(&promos).Error; err != nil {
+		return nil, fmt.Errorf("error listando promociones: %v", err)
+	}
+	result := make([]db.PromotionDTO, 0, len(promos))
+	for _, p := range promos {
+		result = append(result, mapPromotion(p))
+	}
+	return result, nil
+}
+
+// GuardarPromocion crea o actualiza una promoción validando su tipo, ventana horaria y alcance.
+func (s *PromotionService) GuardarPromocion(dto db.PromotionDTO) (*db.PromotionDTO, error) {
+	promo := db.Promotion{
+		ID:             dto.ID,
+		Nombre:         strings.TrimSpace(dto.Nombre),
+		Tipo:           strings.ToUpper(strings.TrimSpace(dto.Tipo)),
+		CantidadMinima: dto.CantidadMinima,
+		Activa:         dto.Activa,
+	}
+	if promo.Nombre == "" {
+		return nil, fmt.Errorf("la promoción necesita un nombre")
+	}
+	switch promo.Tipo {
+	case PromoPorcentaje:
+		if dto.Porcentaje <= 0 || dto.Porcentaje > 100 {
+			return nil, fmt.Errorf("el porcentaje debe estar entre 0 y 100")
+		}
+		promo.Porcentaje = dto.Porcentaje
+	case PromoNxM:
+		if dto.PagaM < 1 || dto.LlevaN <= dto.PagaM {
+			return nil, fmt.Errorf("en una promoción NxM se lleva más de lo que se paga (ej. 2x1)")
+		}
+		promo.LlevaN = dto.LlevaN
+		promo.PagaM = dto.PagaM
+	default:
+		return nil, fmt.Errorf("tipo de promoción inválido: %s", dto.Tipo)
+	}
+	if dto.CantidadMinima < 0 {
+		return nil, fmt.Errorf("la cantidad mínima no puede ser negativa")
+	}
+
+	desde, hasta, err := parsearVigencia(dto.VigenteDesde, dto.VigenteHasta)
+	if err != nil {
+		return nil, err
+	}
+	promo.VigenteDesde, promo.VigenteHasta = desde, hasta
+
+	for _, h := range []string{dto.HoraDesde, dto.HoraHasta} {
+		if h != "" && !horaRe.MatchString(h) {
+			return nil, fmt.Errorf("hora inválida: %s (use HH:MM)", h)
+		}
+	}
+	if (dto.HoraDesde == "") != (dto.HoraHasta == "") {
+		return nil, fmt.Errorf("indique la hora de inicio y de fin")
+	}
+	promo.HoraDesde, promo.HoraHasta = dto.HoraDesde, dto.HoraHasta
+
+	dias := make([]string, 0, len(dto.DiasSemana))
+	for _, d := range dto.DiasSemana {
+		if d < 1 || d > 7 {
+			return nil, fmt.Errorf("día de la semana inválido: %d", d)
+		}
+		dias = append(dias, strconv.Itoa(d))
+	}
+	promo.DiasSemana = strings.Join(dias, ",")
+	promo.Segmentos = unirLista(dto.Segmentos, true)
+	promo.SKUs = unirLista(dto.SKUs, false)
+	promo.Categorias = unirIDs(dto.Categorias)
+	promo.Marcas = unirIDs(dto.Marcas)
+
+	err = db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var repetida int64
+		tx.Model(&db.Promotion{}).Where("nombre = ? AND id <> ?", promo.Nombre, promo.ID).Count(&repetida)
+		if repetida > 0 {
+			return fmt.Errorf("ya existe una promoción llamada %s", promo.Nombre)
+		}
+		for _, sku := range separarLista(promo.SKUs) {
+			var existe int64
+			tx.Model(&db.Product{}).Where("sku = ?", sku).Count(&existe)
+			if existe == 0 {
+				return fmt.Errorf("producto no encontrado: %s", sku)
+			}
+		}
+		for _, id := range separarIDs(promo.Categorias) {
+			if err := tx.First(&db.Category{}, id).Error; err != nil {
+				return fmt.Errorf("categoría no encontrada: %d", id)
+			}
+		}
+		for _, id := range separarIDs(promo.Marcas) {
+			if err := tx.First(&db.Brand{}, id).Error; err != nil {
+				return fmt.Errorf("marca no encontrada: %d", id)
+			}
+		}
+		if promo.ID != 0 {
+			var actual db.Promotion
+			if err := tx.First(&actual, promo.ID).Error; err != nil {
+				return fmt.Errorf("promoción no encontrada")
+			}
+			promo.CreatedAt = actual.CreatedAt
+		}
+		if err := tx.Save(&promo).Error; err != nil {
+			return fmt.Errorf("error guardando promoción: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := mapPromotion(promo)
+	return &result, nil
+}
+
+// EliminarPromocion borra una promoción; las facturas emitidas conservan su nombre en cada línea.
+func (s *PromotionService) EliminarPromocion(id uint) error {
+	if err := db.GetDB().Delete(&db.Promotion{}, id).Error; err != nil {
+		return fmt.Errorf("error eliminando promoción: %v", err)
+	}
+	return nil
+}
+
+// EvaluarCarrito aplica las promociones vigentes a un carrito para mostrar los descuentos antes
+// de emitir. Resuelve unidades y precios de lista igual que la emisión.
+func (s *PromotionService) EvaluarCarrito(clienteID string, items []db.InvoiceItem) ([]db.InvoiceItem, error) {
+	tx := db.GetDB()
+	if err := resolverUnidades(tx, items); err != nil {
+		return nil, err
+	}
+	ahora := time.Now()
+	aplicarListaPrecios(tx, clienteID, items, ahora)
+	aplicarPromociones(tx, clienteID, items, ahora)
+	return items, nil
+}
+
+// GetImpacto resume por promoción las líneas facturadas en el rango (sin anuladas).
+func (s *PromotionService) GetImpacto(startDate, endDate time.Time) ([]db.PromotionImpactDTO, error) {
+	var result []db.PromotionImpactDTO
+	err := db.GetDB().Table("factura_items").
+		Select("factura_items.promocion_id, MAX(factura_items.promocion) as nombre, "+
+			"COUNT(DISTINCT factura_items.factura_clave) as facturas, COUNT(*) as lineas, "+
+			"SUM(factura_items.cantidad) as unidades, SUM(factura_items.subtotal) as venta, "+
+			"SUM(factura_items.descuento) as descuento").
+		Joins("JOIN facturas ON facturas.clave_acceso = factura_items.factura_clave").
+		Where("factura_items.promocion_id <> 0 AND facturas.fecha_emision BETWEEN ? AND ? AND facturas.estado_sri <> ?", startDate, endDate, "ANULADO").
+		Group("factura_items.promocion_id").
+		Order("descuento desc").
+		Scan(&result).Error
+	if err != nil {
+		return nil, fmt.Errorf("error calculando impacto de promociones: %v", err)
+	}
+	for i := range result {
+		result[i].Venta = util.Round(result[i].Venta, 2)
+		result[i].Descuento = util.Round(result[i].Descuento, 2)
+	}
+	if result == nil {
+		result = []db.PromotionImpactDTO{}
+	}
+	return result, nil
+}
+
+// GenerarReporteImpactoExcel exporta el impacto de las promociones del rango.
+func (s *PromotionService) GenerarReporteImpactoExcel(startDate, endDate time.Time) ([]byte, error) {
+	filas, err := s.GetImpacto(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Promociones"
+	f.SetSheetName("Sheet1", sheet)
+
+	f.SetCellValue(sheet, "A1", fmt.Sprintf("Impacto de promociones del %s al %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02")))
+	headers := []string{"Promoción", "Facturas", "Líneas", "Unidades", "Venta neta", "Descuento otorgado", "% sobre venta bruta"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 3)
+		f.SetCellValue(sheet, cell, h)
+	}
+	style, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	f.SetCellStyle(sheet, "A3", "G3", style)
+
+	for i, r := range filas {
+		row := i + 4
+		pct := 0.0
+		if bruto := r.Venta + r.Descuento; bruto > 0 {
+			pct = util.Round(r.Descuento/bruto*100, 2)
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), r.Nombre)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), r.Facturas)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), r.Lineas)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), r.Unidades)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), r.Venta)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), r.Descuento)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), pct)
+	}
+	f.SetColWidth(sheet, "A", "A", 30)
+	f.SetColWidth(sheet, "B", "G", 16)
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// aplicarPromociones calcula el descuento de cada línea con las promociones vigentes para el
+// cliente. Las líneas del mismo producto y unidad se agrupan (2x1 en dos líneas de 1 también
+// aplica) y cada grupo recibe la promoción que más descuenta. Las líneas con PrecioManual o con
+// un descuento manual (sin PromocionID) no se tocan.
+func aplicarPromociones(tx *gorm.DB, clienteID string, items []db.InvoiceItem, ahora time.Time) {
+	type grupo struct {
+		sku      string
+		cantidad float64
+		lineas   []int
+	}
+	grupos := []*grupo{}
+	idx := map[string]*grupo{}
+	for i := range items {
+		item := &items[i]
+		if item.PrecioManual || (item.Descuento > 0 && item.PromocionID == 0) {
+			continue
+		}
+		item.Descuento, item.PromocionID, item.Promocion = 0, 0, ""
+		clave := item.Codigo + "|" + item.Unidad
+		g, ok := idx[clave]
+		if !ok {
+			g = &grupo{sku: item.Codigo}
+			idx[clave] = g
+			grupos = append(grupos, g)
+		}
+		g.cantidad += item.Cantidad
+		g.lineas = append(g.lineas, i)
+	}
+	if len(grupos) == 0 {
+		return
+	}
+
+	promos := promocionesVigentes(tx, segmentoCliente(tx, clienteID), ahora)
+	if len(promos) == 0 {
+		return
+	}
+	skus := make([]string, 0, len(grupos))
+	for _, g := range grupos {
+		skus = append(skus, g.sku)
+	}
+	var products []db.Product
+	tx.Where("sku IN ?", skus).Find(&products)
+	clasificacion := map[string]db.Product{}
+	for _, p := range products {
+		clasificacion[p.SKU] = p
+	}
+	ancestros := map[uint][]uint{}
+
+	for _, g := range grupos {
+		producto := clasificacion[g.sku]
+		if _, ok := ancestros[producto.CategoryID]; !ok {
+			ancestros[producto.CategoryID] = ancestrosCategoria(tx, producto.CategoryID)
+		}
+		var mejor *db.Promotion
+		var mejorDescuentos []float64
+		mejorTotal := 0.0
+		for i := range promos {
+			p := &promos[i]
+			if !enLista(p.SKUs, g.sku) || !enAlgunaCategoria(p.Categorias, ancestros[producto.CategoryID]) ||
+				!enLista(p.Marcas, strconv.FormatUint(uint64(producto.BrandID), 10)) || g.cantidad < p.CantidadMinima {
+				continue
+			}
+			descuentos, total := descuentosPromocion(p, items, g.lineas, g.cantidad)
+			if total > mejorTotal {
+				mejor, mejorDescuentos, mejorTotal = p, descuentos, total
+			}
+		}
+		if mejor == nil {
+			continue
+		}
+		for j, i := range g.lineas {
+			if mejorDescuentos[j] > 0 {
+				items[i].Descuento = mejorDescuentos[j]
+				items[i].PromocionID = mejor.ID
+				items[i].Promocion = mejor.Nombre
+			}
+		}
+	}
+}
+
+// descuentosPromocion devuelve el descuento de cada línea del grupo y su suma. En NxM las
+// unidades gratis se reparten en el orden de las líneas.
+func descuentosPromocion(p *db.Promotion, items []db.InvoiceItem, lineas []int, cantidad float64) ([]float64, float64) {
+	descuentos := make([]float64, len(lineas))
+	total := 0.0
+	switch p.Tipo {
+	case PromoPorcentaje:
+		for j, i := range lineas {
+			bruto := items[i].Cantidad * items[i].Precio
+			descuentos[j] = util.Round(bruto*p.Porcentaje/100, 2)
+			total += descuentos[j]
+		}
+	case PromoNxM:
+		gratis := math.Floor(cantidad/float64(p.LlevaN)) * float64(p.LlevaN-p.PagaM)
+		for j, i := range lineas {
+			if gratis <= 0 {
+				break
+			}
+			unidades := math.Min(gratis, items[i].Cantidad)
+			descuentos[j] = util.Round(unidades*items[i].Precio, 2)
+			gratis -= unidades
+			total += descuentos[j]
+		}
+	}
+	return descuentos, total
+}
+
+// promocionesVigentes filtra las promociones activas por fecha, día, hora y segmento del cliente.
+func promocionesVigentes(tx *gorm.DB, segmento string, ahora time.Time) []db.Promotion {
+	var promos []db.Promotion
+	tx.Where("activa = ?", true).Order("id").Find(&promos)
+	dia := strconv.Itoa(diaSemanaISO(ahora))
+	hora := ahora.Format("15:04")
+	vigentes := promos[:0]
+	for _, p := range promos {
+		if !enVigencia(p.VigenteDesde, p.VigenteHasta, ahora) || !enLista(p.DiasSemana, dia) {
+			continue
+		}
+		if p.HoraDesde != "" && !enVentanaHoraria(p.HoraDesde, p.HoraHasta, hora) {
+			continue
+		}
+		if p.Segmentos != "" && (segmento == "" || !enLista(p.Segmentos, segmento)) {
+			continue
+		}
+		vigentes = append(vigentes, p)
+	}
+	return vigentes
+}
+
+// enVentanaHoraria admite ventanas que cruzan la medianoche (22:00 a 02:00).
+func enVentanaHoraria(desde, hasta, hora string) bool {
+	if desde <= hasta {
+		return hora >= desde && hora < hasta
+	}
+	return hora >= desde || hora < hasta
+}
+
+func segmentoCliente(tx *gorm.DB, clienteID string) string {
+	var clientes []db.Client
+	tx.Where("id = ?", clienteID).Limit(1).Find(&clientes)
+	if len(clientes) == 0 {
+		return ""
+	}
+	return strings.ToUpper(strings.TrimSpace(clientes[0].Segmento))
+}
+
+func diaSemanaISO(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// enLista indica si valor está en una lista separada por comas; la lista vacía admite todo.
+func enLista(lista, valor string) bool {
+	if lista == "" {
+		return true
+	}
+	for _, v := range separarLista(lista) {
+		if v == valor {
+			return true
+		}
+	}
+	return false
+}
+
+// enAlgunaCategoria indica si la categoría del producto o alguno de sus ancestros está en la lista.
+func enAlgunaCategoria(lista string, categorias []uint) bool {
+	if lista == "" {
+		return true
+	}
+	for _, id := range categorias {
+		if enLista(lista, strconv.FormatUint(uint64(id), 10)) {
+			return true
+		}
+	}
+	return false
+}
+
+func separarLista(lista string) []string {
+	if lista == "" {
+		return []string{}
+	}
+	return strings.Split(lista, ",")
+}
+
+// unirLista limpia, deduplica y ordena los valores para guardarlos separados por comas.
+func unirLista(valores []string, mayusculas bool) string {
+	vistos := map[string]bool{}
+	limpios := []string{}
+	for _, v := range valores {
+		v = strings.TrimSpace(strings.ReplaceAll(v, ",", ""))
+		if mayusculas {
+			v = strings.ToUpper(v)
+		}
+		if v != "" && !vistos[v] {
+			vistos[v] = true
+			limpios = append(limpios, v)
+		}
+	}
+	sort.Strings(limpios)
+	return strings.Join(limpios, ",")
+}
+
+func unirIDs(ids []uint) string {
+	valores := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 {
+			valores = append(valores, strconv.FormatUint(uint64(id), 10))
+		}
+	}
+	return unirLista(valores, false)
+}
+
+func separarIDs(lista string) []uint {
+	ids := []uint{}
+	for _, v := range separarLista(lista) {
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			ids = append(ids, uint(n))
+		}
+	}
+	return ids
+}
+
+func mapPromotion(p db.Promotion) db.PromotionDTO {
+	dias := []int{}
+	for _, d := range separarLista(p.DiasSemana) {
+		n, _ := strconv.Atoi(d)
+		dias = append(dias, n)
+	}
+	return db.PromotionDTO{
+		ID:             p.ID,
+		Nombre:         p.Nombre,
+		Tipo:           p.Tipo,
+		Porcentaje:     p.Porcentaje,
+		LlevaN:         p.LlevaN,
+		PagaM:          p.PagaM,
+		CantidadMinima: p.CantidadMinima,
+		SKUs:           separarLista(p.SKUs),
+		Categorias:     separarIDs(p.Categorias),
+		Marcas:         separarIDs(p.Marcas),
+		Segmentos:      separarLista(p.Segmentos),
+		VigenteDesde:   formatearFechaOpcional(p.VigenteDesde),
+		VigenteHasta:   formatearFechaOpcional(p.VigenteHasta),
+		DiasSemana:     dias,
+		HoraDesde:      p.HoraDesde,
+		HoraHasta:      p.HoraHasta,
+		Activa:         p.Activa,
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+)
+
+func TestPromotionService_Reglas(t *testing.T) {
+	database := setupTestDB()
+	svc := NewPromotionService()
+	database.Create(&db.Product{SKU: "COLA", Name: "Cola", Barcode: "COLA", Price: 1})
+	database.Create(&db.Product{SKU: "PAN", Name: "Pan", Barcode: "PAN", Price: 2})
+	database.Create(&db.Client{ID: "0102030405", Nombre: "Cliente VIP", Segmento: "VIP"})
+
+	if _, err := svc.GuardarPromocion(db.PromotionDTO{Nombre: "Mal", Tipo: PromoNxM, LlevaN: 2, PagaM: 2}); err == nil {
+		t.Error("Un NxM que no regala unidades debe rechazarse")
+	}
+	if _, err := svc.GuardarPromocion(db.PromotionDTO{Nombre: "Mal", Tipo: PromoPorcentaje, Porcentaje: 10, HoraDesde: "25:00", HoraHasta: "26:00"}); err == nil {
+		t.Error("Debe validar el formato de hora")
+	}
+	if _, err := svc.GuardarPromocion(db.PromotionDTO{Nombre: "2x1 Cola", Tipo: PromoNxM, LlevaN: 2, PagaM: 1, SKUs: []string{"COLA"}, Activa: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GuardarPromocion(db.PromotionDTO{Nombre: "3x2 Pan", Tipo: PromoNxM, LlevaN: 3, PagaM: 2, SKUs: []string{"PAN"}, Activa: true}); err != nil {
+		t.Fatal(err)
+	}
+	// 10% para VIP los fines de semana: en el pan gana el 3x2 si descuenta más
+	finde, err := svc.GuardarPromocion(db.PromotionDTO{Nombre: "VIP finde", Tipo: PromoPorcentaje, Porcentaje: 10, Segmentos: []string{"vip"}, DiasSemana: []int{6, 7}, HoraDesde: "08:00", HoraHasta: "20:00", Activa: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finde.Segmentos[0] != "VIP" {
+		t.Errorf("Segmentos no normalizados: %+v", finde)
+	}
+
+	sabado := time.Date(2026, 3, 7, 10, 0, 0, 0, time.Local)
+	lunes := time.Date(2026, 3, 9, 10, 0, 0, 0, time.Local)
+
+	items := []db.InvoiceItem{
+		{Codigo: "COLA", Cantidad: 1, Precio: 1},
+		{Codigo: "COLA", Cantidad: 2, Precio: 1},
+		{Codigo: "PAN", Cantidad: 7, Precio: 2},
+		{Codigo: "PAN", Unidad: "X", Cantidad: 1, Precio: 2, PrecioManual: true},
+	}
+	aplicarPromociones(database, "9999999999999", items, lunes)
+	// 3 colas = un par gratis; 7 panes = 2 gratis
+	if items[0].Descuento != 1 || items[0].Promocion != "2x1 Cola" || items[1].Descuento != 0 {
+		t.Errorf("2x1 mal repartido: %+v", items[:2])
+	}
+	if items[2].Descuento != 4 || items[2].Promocion != "3x2 Pan" {
+		t.Errorf("3x2 mal aplicado: %+v", items[2])
+	}
+	if items[3].Descuento != 0 {
+		t.Error("Las líneas con precio manual no reciben promociones")
+	}
+
+	// Sábado, cliente VIP, 2 panes: el 3x2 no aplica y gana el 10%
+	items = []db.InvoiceItem{{Codigo: "PAN", Cantidad: 2, Precio: 2}, {Codigo: "COLA", Cantidad: 1, Precio: 1}}
+	aplicarPromociones(database, "0102030405", items, sabado)
+	if items[0].Descuento != 0.4 || items[0].Promocion != "VIP finde" || items[1].Descuento != 0.1 {
+		t.Errorf("Promoción de segmento mal aplicada: %+v", items)
+	}
+	aplicarPromociones(database, "0102030405", items, sabado.Add(11*time.Hour))
+	if items[0].Descuento != 0 {
+		t.Error("Fuera del horario la promoción no debe aplicar")
+	}
+	aplicarPromociones(database, "9999999999999", items, sabado)
+	if items[0].Descuento != 0 {
+		t.Error("Un cliente fuera del segmento no debe recibir la promoción")
+	}
+}
+
+func TestPromotionService_FacturaEImpacto(t *testing.T) {
+	database := setupTestDB()
+	svc := NewPromotionService()
+	database.Create(&db.Product{SKU: "COLA", Name: "Cola", Barcode: "COLA", Price: 1, Stock: 10})
+	svc.GuardarPromocion(db.PromotionDTO{Nombre: "2x1 Cola", Tipo: PromoNxM, LlevaN: 2, PagaM: 1, Activa: true})
+
+	dto := db.FacturaDTO{
+		ClienteID:     "9999999999999",
+		ClienteNombre: "CONSUMIDOR FINAL",
+		Items:         []db.InvoiceItem{{Codigo: "COLA", Nombre: "Cola", Cantidad: 4, Precio: 1, CodigoIVA: "0"}},
+	}
+	preview, err := NewInvoiceService().PrevisualizarFactura(dto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.ImporteTotal != 2 {
+		t.Errorf("Total con 2x1 esperado 2, obtuve %g", preview.ImporteTotal)
+	}
+	for _, esperado := range []string{"<descuento>2</descuento>", "<totalDescuento>2</totalDescuento>", "2x1 Cola"} {
+		if !strings.Contains(preview.XML, esperado) {
+			t.Errorf("El XML no contiene %s", esperado)
+		}
+	}
+
+	// El reporte toma las líneas facturadas con promoción
+	database.Create(&db.Factura{ClaveAcceso: "CLAVE_PROMO", Secuencial: "000000001", FechaEmision: time.Now(), EstadoSRI: "AUTORIZADO", Total: 2})
+	database.Create(&db.Factura{ClaveAcceso: "CLAVE_ANULADA", Secuencial: "000000002", FechaEmision: time.Now(), EstadoSRI: "ANULADO", Total: 2})
+	items := itemsFactura("CLAVE_PROMO", &dto)
+	items = append(items, itemsFactura("CLAVE_ANULADA", &dto)...)
+	database.Create(&items)
+
+	impacto, err := svc.GetImpacto(time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(impacto) != 1 || impacto[0].Facturas != 1 || impacto[0].Descuento != 2 || impacto[0].Venta != 2 {
+		t.Errorf("Impacto incorrecto: %+v", impacto)
+	}
+	if _, err := svc.GenerarReporteImpactoExcel(time.Now().AddDate(0, 0, -1), time.Now()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/crypto"
+	"kushkiv2/pkg/util"
+	"kushkiv2/pkg/xml"
+)
+
+// Estados de la firma de un comprobante importado.
+const (
+	FirmaValida   = "VALIDA"
+	FirmaInvalida = "INVALIDA"
+	FirmaAusente  = "SIN FIRMA"
+)
+
+// OpcionesImportacionXML controla qué hace el importador con cada comprobante.
+type OpcionesImportacionXML struct {
+	ActualizarInventario bool   `json:"actualizarInventario"` // Ingresar stock y recalcular costos
+	CrearProductos       bool   `json:"crearProductos"`       // Crear en el catálogo los códigos desconocidos
+	AceptarSinFirma      bool   `json:"aceptarSinFirma"`      // Importar aunque la firma falte o no se pueda verificar
+	Bodega               string `json:"bodega"`               // Bodega de ingreso ("" = principal)
+}
+
+// ComprobanteImportado es el resultado de importar un XML (un ZIP produce varios).
+type ComprobanteImportado struct {
+	Archivo       string  `json:"archivo"`
+	ClaveAcceso   string  `json:"claveAcceso"`
+	Proveedor     string  `json:"proveedor"`
+	NumeroFactura string  `json:"numeroFactura"`
+	Total         float64 `json:"total"`
+	Firma         string  `json:"firma"`
+	Estado        string  `json:"estado"` // IMPORTADA, DUPLICADA, OMITIDA, ERROR
+	Mensaje       string  `json:"mensaje"`
+	CompraID      uint    `json:"compraId"`
+}
+
+// ResultadoImportacionXML agrupa los resultados de una importación.
+type ResultadoImportacionXML struct {
+	Comprobantes []ComprobanteImportado `json:"comprobantes"`
+	Importadas   int                    `json:"importadas"`
+	Duplicadas   int                    `json:"duplicadas"`
+	Omitidas     int                    `json:"omitidas"`
+	Errores      int                    `json:"errores"`
+}
+
+func (r *ResultadoImportacionXML) agregar(c ComprobanteImportado) {
+	switch c.Estado {
+	case "IMPORTADA":
+		r.Importadas++
+	case "DUPLICADA":
+		r.Duplicadas++
+	case "OMITIDA":
+		r.Omitidas++
+	default:
+		r.Errores++
+	}
+	r.Comprobantes = append(r.Comprobantes, c)
+}
+
+// ImportarComprobantesXML registra como compras las facturas recibidas en un archivo XML
+// (comprobante firmado o envoltorio <autorizacion>) o en un ZIP con varios XML.
+// Los resultados se agregan a resultado para poder importar varios archivos en una sola corrida.
+func (s *PurchaseService) ImportarComprobantesXML(resultado *ResultadoImportacionXML, nombre string, data []byte, opciones OpcionesImportacionXML) {
+	if strings.EqualFold(filepath.Ext(nombre), ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			resultado.agregar(ComprobanteImportado{Archivo: filepath.Base(nombre), Estado: "ERROR", Mensaje: fmt.Sprintf("ZIP inválido: %v", err)})
+			return
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || !strings.EqualFold(filepath.Ext(f.Name), ".xml") {
+				continue
+			}
+			archivo := filepath.Base(nombre) + "/" + f.Name
+			rc, err := f.Open()
+			if err != nil {
+				resultado.agregar(ComprobanteImportado{Archivo: archivo, Estado: "ERROR", Mensaje: err.Error()})
+				continue
+			}
+			contenido, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				resultado.agregar(ComprobanteImportado{Archivo: archivo, Estado: "ERROR", Mensaje: err.Error()})
+				continue
+			}
+			resultado.agregar(s.importarComprobante(archivo, contenido, opciones))
+		}
+		return
+	}
+	resultado.agregar(s.importarComprobante(filepath.Base(nombre), data, opciones))
+}
+
+// importarComprobante procesa un único XML.
+func (s *PurchaseService) importarComprobante(archivo string, data []byte, opciones OpcionesImportacionXML) ComprobanteImportado {
+	res := ComprobanteImportado{Archivo: archivo, Estado: "ERROR"}
+
+	comprobante, aut, err := xml.ExtraerComprobante(data)
+	if err != nil {
+		res.Mensaje = err.Error()
+		return res
+	}
+	if aut != nil && !strings.EqualFold(strings.TrimSpace(aut.Estado), "AUTORIZADO") {
+		res.Mensaje = fmt.Sprintf("comprobante no autorizado (estado: %s)", aut.Estado)
+		return res
+	}
+
+	factura, err := xml.ParseFacturaXML(comprobante)
+	if err != nil {
+		res.Estado = "OMITIDA"
+		res.Mensaje = "solo se importan facturas (codDoc 01)"
+		return res
+	}
+	info := factura.InfoTributaria
+	res.ClaveAcceso = strings.TrimSpace(info.ClaveAcceso)
+	res.Proveedor = info.RazonSocial
+	res.NumeroFactura = fmt.Sprintf("%s-%s-%s", info.Estab, info.PtoEmi, info.Secuencial)
+	res.Total = factura.InfoFactura.ImporteTotal
+
+	// 1. Firma
+	if _, err := crypto.VerifyXMLSignature(comprobante); err != nil {
+		res.Firma = FirmaInvalida
+		if errors.Is(err, crypto.ErrSinFirma) {
+			res.Firma = FirmaAusente
+		}
+		if !opciones.AceptarSinFirma {
+			res.Mensaje = fmt.Sprintf("firma no verificada: %v", err)
+			return res
+		}
+		res.Mensaje = fmt.Sprintf("Importada sin firma verificada (%v). ", err)
+	} else {
+		res.Firma = FirmaValida
+	}
+
+	// 2. Validaciones del documento
+	if info.CodDoc != "" && info.CodDoc != "01" {
+		res.Estado = "OMITIDA"
+		res.Mensaje = fmt.Sprintf("tipo de comprobante %s no soportado, solo facturas", info.CodDoc)
+		return res
+	}
+	if len(res.ClaveAcceso) != 49 {
+		res.Mensaje = "clave de acceso inválida"
+		return res
+	}
+	var config db.EmisorConfig
+	if db.GetDB().Limit(1).Find(&config); config.RUC != "" {
+		comprador := strings.TrimSpace(factura.InfoFactura.IdentificacionComprador)
+		if comprador != config.RUC && comprador != config.RUC[:min(10, len(config.RUC))] {
+			res.Mensaje = fmt.Sprintf("la factura está emitida a %s, no a este contribuyente", comprador)
+			return res
+		}
+	}
+
+	// 3. Duplicados por clave de acceso o por número de documento
+	var existentes []db.Purchase
+	db.GetDB().Where("autorizacion = ? OR (supplier_ruc = ? AND numero_factura = ?)", res.ClaveAcceso, info.Ruc, res.NumeroFactura).
+		Limit(1).Find(&existentes)
+	if len(existentes) > 0 {
+		res.Estado = "DUPLICADA"
+		res.CompraID = existentes[0].ID
+		res.Mensaje = "la compra ya estaba registrada"
+		return res
+	}
+
+	// 4. Proveedor
+	if err := asegurarProveedor(factura); err != nil {
+		res.Mensaje = err.Error()
+		return res
+	}
+
+	// 5. Ítems y productos
+	dto := db.PurchaseDTO{
+		SupplierRUC:   info.Ruc,
+		NumeroFactura: res.NumeroFactura,
+		Autorizacion:  res.ClaveAcceso,
+		Observacion:   "Importada desde XML",
+		Bodega:        opciones.Bodega,
+	}
+	if aut != nil && len(strings.TrimSpace(aut.NumeroAutorizacion)) == 49 {
+		dto.Autorizacion = strings.TrimSpace(aut.NumeroAutorizacion)
+	}
+	fecha, err := time.Parse("02/01/2006", strings.TrimSpace(factura.InfoFactura.FechaEmision))
+	if err != nil {
+		res.Mensaje = fmt.Sprintf("fecha de emisión inválida: %s", factura.InfoFactura.FechaEmision)
+		return res
+	}
+	dto.FechaEmision = fecha.Format("2006-01-02")
+
+	creados := 0
+	for _, det := range factura.Detalles {
+		if det.Cantidad <= 0 {
+			continue
+		}
+		porcentaje := 0.0
+		codigoIVA := 0
+		for _, imp := range det.Impuestos {
+			if imp.Codigo == "2" {
+				porcentaje = imp.Tarifa
+				codigoIVA, _ = strconv.Atoi(imp.CodigoPorcentaje)
+			}
+		}
+
+		sku, creado, err := buscarOCrearProducto(det, codigoIVA, porcentaje, opciones.CrearProductos)
+		if err != nil {
+			res.Mensaje = err.Error()
+			return res
+		}
+		if creado {
+			creados++
+		}
+		item := db.PurchaseItemDTO{
+			ProductoSKU:   sku,
+			Nombre:        det.Descripcion,
+			Cantidad:      det.Cantidad,
+			CostoUnitario: util.Round(det.PrecioTotalSinImpuesto/det.Cantidad, 6),
+			PorcentajeIVA: porcentaje,
+		}
+		if lote, vence := loteDeDetalle(det); lote != "" {
+			item.Lote = lote
+			if vence != nil {
+				item.FechaVencimiento = vence.Format("2006-01-02")
+			}
+		}
+		dto.Items = append(dto.Items, item)
+	}
+
+	compra, err := s.registrarCompra(dto, opciones.ActualizarInventario)
+	if errors.Is(err, errCompraDuplicada) {
+		// Registrada por otro proceso después de la verificación del paso 3
+		res.Estado = "DUPLICADA"
+		res.Mensaje = "la compra ya estaba registrada"
+		return res
+	}
+	if err != nil {
+		res.Mensaje = err.Error()
+		return res
+	}
+
+	res.Estado = "IMPORTADA"
+	res.CompraID = compra.ID
+	if creados > 0 {
+		res.Mensaje += fmt.Sprintf("%d productos creados sin precio de venta. ", creados)
+	}
+	if diff := math.Abs(compra.Total - factura.InfoFactura.ImporteTotal); diff > 0.01 {
+		res.Mensaje += fmt.Sprintf("El total calculado (%.2f) difiere del comprobante (%.2f). ", compra.Total, factura.InfoFactura.ImporteTotal)
+	}
+	res.Mensaje = strings.TrimSpace(res.Mensaje)
+	return res
+}
+
+// asegurarProveedor crea el proveedor del comprobante si no existe. No se valida el RUC:
+// el comprobante ya fue autorizado por el SRI.
+func asegurarProveedor(factura *xml.FacturaXML) error {
+	info := factura.InfoTributaria
+	var existentes []db.Supplier
+	db.GetDB().Where("ruc = ?", info.Ruc).Limit(1).Find(&existentes)
+	if len(existentes) > 0 {
+		return nil
+	}
+	supplier := db.Supplier{
+		RUC:             info.Ruc,
+		RazonSocial:     info.RazonSocial,
+		NombreComercial: info.NombreComercial,
+		Direccion:       info.DirMatriz,
+	}
+	if err := db.GetDB().Create(&supplier).Error; err != nil {
+		return fmt.Errorf("error creando proveedor: %v", err)
+	}
+	return nil
+}
+
+// buscarOCrearProducto relaciona un detalle con el catálogo por SKU o código de barras
+// (código principal o auxiliar). Si no existe y crear es true, lo da de alta con el código
+// del proveedor; si no, el ítem queda como gasto sin producto.
+func buscarOCrearProducto(det xml.Detalle, codigoIVA int, porcentaje float64, crear bool) (string, bool, error) {
+	codigos := []string{strings.TrimSpace(det.CodigoPrincipal)}
+	if aux := strings.TrimSpace(det.CodigoAuxiliar); aux != "" {
+		codigos = append(codigos, aux)
+	}
+
+	var productos []db.Product
+	db.GetDB().Where("sku IN ? OR barcode IN ?", codigos, codigos).Limit(1).Find(&productos)
+	if len(productos) > 0 {
+		return productos[0].SKU, false, nil
+	}
+	if !crear || codigos[0] == "" {
+		return codigos[0], false, nil
+	}
+
+	// El código de barras es único: usar el auxiliar (suele ser el EAN) si está libre
+	barcode := codigos[0]
+	if len(codigos) > 1 {
+		var usados int64
+		db.GetDB().Model(&db.Product{}).Where("barcode = ?", codigos[1]).Count(&usados)
+		if usados == 0 {
+			barcode = codigos[1]
+		}
+	}
+	product := db.Product{
+		SKU:           codigos[0],
+		Name:          det.Descripcion,
+		Barcode:       barcode,
+		TaxCode:       codigoIVA,
+		TaxPercentage: int(math.Round(porcentaje)),
+	}
+	if err := db.GetDB().Create(&product).Error; err != nil {
+		return "", false, fmt.Errorf("error creando producto %s: %v", product.SKU, err)
+	}
+	return product.SKU, true, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/xml"
+)
+
+func facturaProveedorXML(t *testing.T, secuencial string) []byte {
+	factura := &xml.FacturaXML{
+		ID:      "comprobante",
+		Version: "1.0.0",
+		InfoTributaria: xml.InfoTributaria{
+			Ambiente: "1", TipoEmision: "1", RazonSocial: "Distribuidora SA", Ruc: "1790016919001",
+			ClaveAcceso: "0101202601179001691900110010010" + secuencial[:9] + "123456781",
+			CodDoc:      "01", Estab: "001", PtoEmi: "001", Secuencial: secuencial, DirMatriz: "Quito",
+		},
+		InfoFactura: xml.InfoFactura{
+			FechaEmision:            "01/01/2026",
+			IdentificacionComprador: "1790011223001",
+			ImporteTotal:            28,
+		},
+		Detalles: []xml.Detalle{
+			{CodigoPrincipal: "P1", Descripcion: "Producto 1", Cantidad: 5, PrecioUnitario: 4, PrecioTotalSinImpuesto: 20,
+				Impuestos: []xml.Impuesto{{Codigo: "2", CodigoPorcentaje: "4", Tarifa: 15, BaseImponible: 20, Valor: 3}}},
+			{CodigoPrincipal: "PROV-9", CodigoAuxiliar: "7861234567890", Descripcion: "Nuevo", Cantidad: 2, PrecioUnitario: 2.5, PrecioTotalSinImpuesto: 5,
+				Impuestos: []xml.Impuesto{{Codigo: "2", CodigoPorcentaje: "0", Tarifa: 0, BaseImponible: 5}}},
+		},
+	}
+	data, err := xml.GenerateXML(factura)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func envolverAutorizacion(comprobante []byte, estado string) []byte {
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<autorizacion><estado>%s</estado><numeroAutorizacion></numeroAutorizacion><fechaAutorizacion>2026-01-01T10:00:00</fechaAutorizacion><ambiente>PRUEBAS</ambiente><comprobante><![CDATA[%s]]></comprobante></autorizacion>`, estado, comprobante))
+}
+
+func TestImportarComprobantesXML(t *testing.T) {
+	database := setupTestDB()
+	svc := NewPurchaseService()
+	database.Create(&db.EmisorConfig{RUC: "1790011223001"})
+	database.Create(&db.Product{SKU: "P1", Name: "Producto 1", Barcode: "P1", Stock: 5, Cost: 2})
+
+	opciones := OpcionesImportacionXML{ActualizarInventario: true, CrearProductos: true}
+
+	t.Run("Firma requerida", func(t *testing.T) {
+		res := &ResultadoImportacionXML{}
+		svc.ImportarComprobantesXML(res, "f1.xml", facturaProveedorXML(t, "000000001"), opciones)
+		if res.Errores != 1 || res.Comprobantes[0].Firma != FirmaAusente {
+			t.Fatalf("Debería rechazar un comprobante sin firma: %+v", res.Comprobantes)
+		}
+	})
+
+	opciones.AceptarSinFirma = true
+
+	t.Run("Autorizacion", func(t *testing.T) {
+		res := &ResultadoImportacionXML{}
+		svc.ImportarComprobantesXML(res, "f1.xml", envolverAutorizacion(facturaProveedorXML(t, "000000001"), "AUTORIZADO"), opciones)
+		if res.Importadas != 1 {
+			t.Fatalf("No se importó el comprobante: %+v", res.Comprobantes)
+		}
+		c := res.Comprobantes[0]
+		if c.NumeroFactura != "001-001-000000001" || len(c.ClaveAcceso) != 49 || !strings.Contains(c.Mensaje, "1 productos creados") {
+			t.Errorf("Resultado incorrecto: %+v", c)
+		}
+
+		var supplier db.Supplier
+		if err := database.First(&supplier, "ruc = ?", "1790016919001").Error; err != nil || supplier.RazonSocial != "Distribuidora SA" {
+			t.Errorf("Proveedor no creado: %+v", supplier)
+		}
+		var p1, nuevo db.Product
+		database.First(&p1, "sku = ?", "P1")
+		database.First(&nuevo, "sku = ?", "PROV-9")
+		if p1.Stock != 10 || p1.Cost != 3 {
+			t.Errorf("Inventario de P1 incorrecto: stock %g costo %.2f", p1.Stock, p1.Cost)
+		}
+		if nuevo.Stock != 2 || nuevo.Barcode != "7861234567890" || nuevo.Cost != 2.5 {
+			t.Errorf("Producto creado incorrecto: %+v", nuevo)
+		}
+
+		compra, _ := svc.GetCompra(c.CompraID)
+		if compra.Total != 28 || compra.Autorizacion != c.ClaveAcceso {
+			t.Errorf("Compra incorrecta: %+v", compra)
+		}
+	})
+
+	t.Run("ZIP y duplicados", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for i, contenido := range [][]byte{facturaProveedorXML(t, "000000001"), facturaProveedorXML(t, "000000002"), envolverAutorizacion(facturaProveedorXML(t, "000000003"), "NO AUTORIZADO")} {
+			w, _ := zw.Create(fmt.Sprintf("f%d.xml", i+1))
+			w.Write(contenido)
+		}
+		zw.Close()
+
+		opciones.ActualizarInventario = false
+		res := &ResultadoImportacionXML{}
+		svc.ImportarComprobantesXML(res, "recibidos.zip", buf.Bytes(), opciones)
+		if res.Duplicadas != 1 || res.Importadas != 1 || res.Errores != 1 {
+			t.Fatalf("Resumen incorrecto: %+v", res)
+		}
+		var p1 db.Product
+		database.First(&p1, "sku = ?", "P1")
+		if p1.Stock != 10 {
+			t.Errorf("Sin actualizar inventario no debería mover stock: %g", p1.Stock)
+		}
+	})
+
+	t.Run("Comprador distinto", func(t *testing.T) {
+		otra := bytes.Replace(facturaProveedorXML(t, "000000004"), []byte("1790011223001"), []byte("0999999999001"), 1)
+		res := &ResultadoImportacionXML{}
+		svc.ImportarComprobantesXML(res, "otra.xml", otra, opciones)
+		if res.Errores != 1 {
+			t.Errorf("Debería rechazar facturas emitidas a otro contribuyente: %+v", res.Comprobantes)
+		}
+	})
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/util"
+
+	"gorm.io/gorm"
+)
+
+// Formato del número de comprobante del proveedor: establecimiento-punto de emisión-secuencial.
+var numeroComprobanteRe = regexp.MustCompile(`^\d{3}-\d{3}-\d{9}$`)
+
+var soloDigitosRe = regexp.MustCompile(`^\d+$`)
+
+// errCompraDuplicada indica que la factura del proveedor ya está registrada.
+var errCompraDuplicada = errors.New("la factura de este proveedor ya fue registrada")
+
+// compraRegistrada indica si ya existe una compra con ese número de factura del proveedor.
+func compraRegistrada(tx *gorm.DB, supplierRUC, numeroFactura string) bool {
+	var existe int64
+	tx.Model(&db.Purchase{}).Where("supplier_ruc = ? AND numero_factura = ?", supplierRUC, numeroFactura).Count(&existe)
+	return existe > 0
+}
+
+type PurchaseService struct{}
+
+func NewPurchaseService() *PurchaseService {
+	return &PurchaseService{}
+}
+
+// --- PROVEEDORES ---
+
+// GuardarProveedor crea o actualiza un proveedor validando su RUC.
+func (s *PurchaseService) GuardarProveedor(dto db.SupplierDTO) error {
+	dto.RUC = strings.TrimSpace(dto.RUC)
+	if err := util.ValidarRUC(dto.RUC); err != nil {
+		return err
+	}
+	if strings.TrimSpace(dto.RazonSocial) == "" {
+		return fmt.Errorf("la razón social es obligatoria")
+	}
+	if dto.PlazoPago < 0 {
+		return fmt.Errorf("el plazo de pago no puede ser negativo")
+	}
+	if dto.DiasEntrega < 0 {
+		return fmt.Errorf("el tiempo de entrega no puede ser negativo")
+	}
+
+	supplier := db.Supplier{
+		RUC:             dto.RUC,
+		RazonSocial:     strings.TrimSpace(dto.RazonSocial),
+		NombreComercial: dto.NombreComercial,
+		Direccion:       dto.Direccion,
+		Email:           dto.Email,
+		Telefono:        dto.Telefono,
+		Contacto:        dto.Contacto,
+		PlazoPago:       dto.PlazoPago,
+		DiasEntrega:     dto.DiasEntrega,
+		Notas:           dto.Notas,
+	}
+	if err := db.GetDB().Save(&supplier).Error; err != nil {
+		return fmt.Errorf("error guardando proveedor: %v", err)
+	}
+	return nil
+}
+
+// ListarProveedores devuelve los proveedores, filtrando por RUC o nombre si se indica query.
+func (s *PurchaseService) ListarProveedores(query string) ([]db.SupplierDTO, error) {
+	q := db.GetDB().Order("razon_social asc")
+	if query = strings.TrimSpace(query); query != "" {
+		like := "%" + query + "%"
+		q = q.Where("ruc LIKE ? OR razon_social LIKE ? OR nombre_comercial LIKE ?", like, like, like)
+	}
+
+	var suppliers []db.Supplier
+	if err := q.Find(&suppliers).Error; err != nil {
+		return nil, fmt.Errorf("error listando proveedores: %v", err)
+	}
+
+	result := make([]db.SupplierDTO, 0, len(suppliers))
+	for _, p := range suppliers {
+		result = append(result, db.SupplierDTO{
+			RUC:             p.RUC,
+			RazonSocial:     p.RazonSocial,
+			NombreComercial: p.NombreComercial,
+			Direccion:       p.Direccion,
+			Email:           p.Email,
+			Telefono:        p.Telefono,
+			Contacto:        p.Contacto,
+			PlazoPago:       p.PlazoPago,
+			DiasEntrega:     p.DiasEntrega,
+			Notas:           p.Notas,
+		})
+	}
+	return result, nil
+}
+
+// EliminarProveedor borra un proveedor sin compras registradas.
+func (s *PurchaseService) EliminarProveedor(ruc string) error {
+	var compras int64
+	db.GetDB().Model(&db.Purchase{}).Where("supplier_ruc = ?", ruc).Count(&compras)
+	if compras > 0 {
+		return fmt.Errorf("el proveedor tiene %d compras registradas", compras)
+	}
+	res := db.GetDB().Delete(&db.Supplier{}, "ruc = ?", ruc)
+	if res.Error != nil {
+		return fmt.Errorf("error eliminando proveedor: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("proveedor no encontrado")
+	}
+	return nil
+}
+
+// --- COMPRAS ---
+
+// RegistrarCompra guarda una factura de proveedor y da entrada al inventario (al costo de compra)
+// de los ítems que existen en el catálogo. Los ítems sin producto (gastos, servicios) solo suman
+// a los totales tributarios.
+func (s *PurchaseService) RegistrarCompra(dto db.PurchaseDTO) (*db.PurchaseDTO, error) {
+	return s.registrarCompra(dto, true)
+}
+
+// registrarCompra permite omitir el ingreso a inventario (compras importadas solo para el IVA).
+func (s *PurchaseService) registrarCompra(dto db.PurchaseDTO, moverInventario bool) (*db.PurchaseDTO, error) {
+	var supplier db.Supplier
+	if err := db.GetDB().First(&supplier, "ruc = ?", dto.SupplierRUC).Error; err != nil {
+		return nil, fmt.Errorf("proveedor no registrado: %s", dto.SupplierRUC)
+	}
+	if !numeroComprobanteRe.MatchString(dto.NumeroFactura) {
+		return nil, fmt.Errorf("número de factura inválido (formato 001-001-000000123)")
+	}
+	if l := len(dto.Autorizacion); (l != 10 && l != 37 && l != 49) || !soloDigitosRe.MatchString(dto.Autorizacion) {
+		return nil, fmt.Errorf("número de autorización inválido: debe tener 10, 37 o 49 dígitos")
+	}
+	fecha, err := time.ParseInLocation("2006-01-02", dto.FechaEmision, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("fecha de emisión inválida: %v", err)
+	}
+	if len(dto.Items) == 0 {
+		return nil, fmt.Errorf("la compra debe tener al menos un ítem")
+	}
+
+	compra := db.Purchase{
+		SupplierRUC:   dto.SupplierRUC,
+		NumeroFactura: dto.NumeroFactura,
+		Autorizacion:  dto.Autorizacion,
+		Bodega:        dto.Bodega,
+		FechaEmision:  fecha,
+		Estado:        "REGISTRADA",
+		Observacion:   dto.Observacion,
+	}
+	items := make([]db.PurchaseItem, 0, len(dto.Items))
+	for i, it := range dto.Items {
+		if it.Cantidad <= 0 || it.CostoUnitario < 0 {
+			return nil, fmt.Errorf("ítem %d: cantidad o costo inválido", i+1)
+		}
+		vence, err := parseFechaVencimiento(it.FechaVencimiento)
+		if err != nil {
+			return nil, fmt.Errorf("ítem %d: %v", i+1, err)
+		}
+		subtotal := util.Round(it.Cantidad*it.CostoUnitario, 2)
+		// Cada tarifa en su base: el resumen de IVA no debe sumar el 5% u 8% a la tarifa general
+		switch {
+		case it.PorcentajeIVA == 5:
+			compra.Subtotal5 += subtotal
+		case it.PorcentajeIVA == 8:
+			compra.Subtotal8 += subtotal
+		case it.PorcentajeIVA > 0:
+			compra.Subtotal15 += subtotal
+		default:
+			compra.Subtotal0 += subtotal
+		}
+		compra.IVA += util.Round(subtotal*it.PorcentajeIVA/100, 2)
+		items = append(items, db.PurchaseItem{
+			ProductoSKU:      it.ProductoSKU,
+			Nombre:           it.Nombre,
+			Cantidad:         it.Cantidad,
+			CostoUnitario:    it.CostoUnitario,
+			Subtotal:         subtotal,
+			PorcentajeIVA:    it.PorcentajeIVA,
+			Lote:             strings.TrimSpace(it.Lote),
+			FechaVencimiento: vence,
+		})
+	}
+	compra.Subtotal15 = util.Round(compra.Subtotal15, 2)
+	compra.Subtotal5 = util.Round(compra.Subtotal5, 2)
+	compra.Subtotal8 = util.Round(compra.Subtotal8, 2)
+	compra.Subtotal0 = util.Round(compra.Subtotal0, 2)
+	compra.IVA = util.Round(compra.IVA, 2)
+	compra.Total = util.Round(compra.Subtotal15+compra.Subtotal5+compra.Subtotal8+compra.Subtotal0+compra.IVA, 2)
+
+	// El duplicado se verifica dentro de la transacción; el índice único idx_purchase_doc cubre dos
+	// registros simultáneos (importación y registro manual) para no duplicar el crédito tributario.
+	err = db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if compraRegistrada(tx, compra.SupplierRUC, compra.NumeroFactura) {
+			return fmt.Errorf("%s: %w", compra.NumeroFactura, errCompraDuplicada)
+		}
+		bodega, err := resolverBodega(tx, compra.Bodega)
+		if err != nil {
+			return err
+		}
+		compra.Bodega = bodega
+		if err := tx.Create(&compra).Error; err != nil {
+			if compraRegistrada(tx, compra.SupplierRUC, compra.NumeroFactura) {
+				return fmt.Errorf("%s: %w", compra.NumeroFactura, errCompraDuplicada)
+			}
+			return fmt.Errorf("error guardando compra: %v", err)
+		}
+		documento := documentoCompra(&compra)
+		for i := range items {
+			items[i].PurchaseID = compra.ID
+			if err := tx.Create(&items[i]).Error; err != nil {
+				return fmt.Errorf("error guardando ítems de compra: %v", err)
+			}
+			if !moverInventario {
+				continue
+			}
+
+			// Los servicios comprados (fletes, mano de obra) no ingresan al inventario
+			var count int64
+			tx.Model(&db.Product{}).Where("sku = ? AND es_servicio = ?", items[i].ProductoSKU, false).Count(&count)
+			cantidad := redondearCantidad(items[i].Cantidad)
+			if count == 0 || cantidad == 0 {
+				continue
+			}
+			mov := &db.StockMovement{
+				ProductSKU:    items[i].ProductoSKU,
+				Tipo:          MovCompra,
+				Cantidad:      cantidad,
+				CostoUnitario: items[i].CostoUnitario,
+				Documento:     documento,
+				Nota:          supplier.RazonSocial,
+				Bodega:        bodega,
+			}
+			if err := registrarMovimiento(tx, mov); err != nil {
+				return err
+			}
+			if items[i].Lote != "" {
+				if _, err := ingresarLote(tx, documento, items[i].ProductoSKU, bodega, items[i].Lote, items[i].FechaVencimiento, cantidad); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCompra(compra.ID)
+}
+
+// AnularCompra marca la compra como ANULADA, retira del inventario lo ingresado y la excluye del IVA.
+func (s *PurchaseService) AnularCompra(id uint) error {
+	var compra db.Purchase
+	if err := db.GetDB().First(&compra, id).Error; err != nil {
+		return fmt.Errorf("compra no encontrada: %v", err)
+	}
+	if compra.Estado == "ANULADA" {
+		return fmt.Errorf("la compra ya está anulada")
+	}
+
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&compra).Update("estado", "ANULADA").Error; err != nil {
+			return fmt.Errorf("error anulando compra: %v", err)
+		}
+		return revertirMovimientos(tx, documentoCompra(&compra), MovAjuste, "Anulación de compra")
+	})
+}
+
+// GetCompra devuelve una compra con sus ítems.
+func (s *PurchaseService) GetCompra(id uint) (*db.PurchaseDTO, error) {
+	var compra db.Purchase
+	if err := db.GetDB().First(&compra, id).Error; err != nil {
+		return nil, fmt.Errorf("compra no encontrada: %v", err)
+	}
+	var items []db.PurchaseItem
+	db.GetDB().Where("purchase_id = ?", id).Find(&items)
+
+	var supplier db.Supplier
+	db.GetDB().Where("ruc = ?", compra.SupplierRUC).Limit(1).Find(&supplier)
+
+	dto := mapPurchaseToDTO(compra, supplier.RazonSocial)
+	for _, it := range items {
+		item := db.PurchaseItemDTO{
+			ProductoSKU:   it.ProductoSKU,
+			Nombre:        it.Nombre,
+			Cantidad:      it.Cantidad,
+			CostoUnitario: it.CostoUnitario,
+			PorcentajeIVA: it.PorcentajeIVA,
+			Subtotal:      it.Subtotal,
+			Lote:          it.Lote,
+		}
+		if it.FechaVencimiento != nil {
+			item.FechaVencimiento = it.FechaVencimiento.Format("2006-01-02")
+		}
+		dto.Items = append(dto.Items, item)
+	}
+	return &dto, nil
+}
+
+// ListarCompras devuelve las compras del rango (sin ítems), de la más reciente a la más antigua.
+func (s *PurchaseService) ListarCompras(startDate, endDate time.Time) ([]db.PurchaseDTO, error) {
+	var compras []db.Purchase
+	if err := db.GetDB().Where("fecha_emision >= ? AND fecha_emision <= ?", startDate, endDate).
+		Order("fecha_emision desc, id desc").Find(&compras).Error; err != nil {
+		return nil, fmt.Errorf("error listando compras: %v", err)
+	}
+
+	nombres := map[string]string{}
+	var suppliers []db.Supplier
+	db.GetDB().Select("ruc", "razon_social").Find(&suppliers)
+	for _, p := range suppliers {
+		nombres[p.RUC] = p.RazonSocial
+	}
+
+	result := make([]db.PurchaseDTO, 0, len(compras))
+	for _, c := range compras {
+		result = append(result, mapPurchaseToDTO(c, nombres[c.SupplierRUC]))
+	}
+	return result, nil
+}
+
+// documentoCompra es la referencia de la compra en el kardex.
+func documentoCompra(c *db.Purchase) string {
+	return fmt.Sprintf("COMPRA %s %s", c.SupplierRUC, c.NumeroFactura)
+}
+
+func mapPurchaseToDTO(c db.Purchase, proveedor string) db.PurchaseDTO {
+	return db.PurchaseDTO{
+		ID:             c.ID,
+		SupplierRUC:    c.SupplierRUC,
+		SupplierNombre: proveedor,
+		NumeroFactura:  c.NumeroFactura,
+		Autorizacion:   c.Autorizacion,
+		FechaEmision:   c.FechaEmision.Format("2006-01-02"),
+		Bodega:         c.Bodega,
+		Items:          []db.PurchaseItemDTO{},
+		Subtotal15:     c.Subtotal15,
+		Subtotal5:      c.Subtotal5,
+		Subtotal8:      c.Subtotal8,
+		Subtotal0:      c.Subtotal0,
+		IVA:            c.IVA,
+		Total:          c.Total,
+		Estado:         c.Estado,
+		Observacion:    c.Observacion,
+	}
+}
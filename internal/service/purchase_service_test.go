@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+)
+
+func TestPurchaseService(t *testing.T) {
+	database := setupTestDB()
+	svc := NewPurchaseService()
+	database.Create(&db.Product{SKU: "P1", Name: "Producto 1", Barcode: "P1", Stock: 10, Cost: 2})
+
+	t.Run("Proveedores", func(t *testing.T) {
+		if err := svc.GuardarProveedor(db.SupplierDTO{RUC: "1790011223001", RazonSocial: "Falso"}); err == nil {
+			t.Error("Debería rechazar un RUC con dígito verificador inválido")
+		}
+		if err := svc.GuardarProveedor(db.SupplierDTO{RUC: "1790016919001", RazonSocial: "Distribuidora SA", PlazoPago: 30}); err != nil {
+			t.Fatalf("Error guardando proveedor: %v", err)
+		}
+		list, _ := svc.ListarProveedores("distri")
+		if len(list) != 1 || list[0].PlazoPago != 30 {
+			t.Errorf("Búsqueda de proveedores incorrecta: %+v", list)
+		}
+	})
+
+	compra := db.PurchaseDTO{
+		SupplierRUC:   "1790016919001",
+		NumeroFactura: "001-002-000000123",
+		Autorizacion:  "1234567890",
+		FechaEmision:  time.Now().Format("2006-01-02"),
+		Items: []db.PurchaseItemDTO{
+			{ProductoSKU: "P1", Nombre: "Producto 1", Cantidad: 10, CostoUnitario: 4, PorcentajeIVA: 15},
+			{ProductoSKU: "FLETE", Nombre: "Transporte", Cantidad: 1, CostoUnitario: 5},
+		},
+	}
+
+	var id uint
+	t.Run("Registrar Compra", func(t *testing.T) {
+		res, err := svc.RegistrarCompra(compra)
+		if err != nil {
+			t.Fatalf("Error registrando compra: %v", err)
+		}
+		id = res.ID
+		if res.Subtotal15 != 40 || res.Subtotal0 != 5 || res.IVA != 6 || res.Total != 51 || len(res.Items) != 2 {
+			t.Errorf("Totales de compra incorrectos: %+v", res)
+		}
+
+		var p db.Product
+		database.First(&p, "sku = ?", "P1")
+		if p.Stock != 20 || p.Cost != 3 || p.LastCost != 4 {
+			t.Errorf("Entrada de inventario incorrecta: stock %g, costo %.2f, último %.2f", p.Stock, p.Cost, p.LastCost)
+		}
+
+		if _, err := svc.RegistrarCompra(compra); !errors.Is(err, errCompraDuplicada) {
+			t.Errorf("Debería rechazar una factura de proveedor duplicada: %v", err)
+		}
+		// El índice único respalda la verificación ante registros simultáneos
+		if err := database.Create(&db.Purchase{SupplierRUC: compra.SupplierRUC, NumeroFactura: compra.NumeroFactura}).Error; err == nil {
+			t.Error("La base debe impedir dos compras con el mismo número de factura del proveedor")
+		}
+		invalida := compra
+		invalida.NumeroFactura = "123"
+		if _, err := svc.RegistrarCompra(invalida); err == nil {
+			t.Error("Debería rechazar un número de factura mal formado")
+		}
+	})
+
+	t.Run("Crédito Tributario", func(t *testing.T) {
+		database.Create(&db.Factura{ClaveAcceso: "V1", FechaEmision: time.Now(), Total: 115, Subtotal15: 100, IVA: 15, EstadoSRI: "AUTORIZADO"})
+		summary := NewTaxService().GetVATSummary(time.Now().Add(-24*time.Hour), time.Now().Add(24*time.Hour))
+		if summary.Compras15 != 40 || summary.Compras0 != 5 || summary.IvaCompras != 6 {
+			t.Errorf("Compras no reflejadas en el resumen: %+v", summary)
+		}
+		if summary.CreditoTributario != 6 || summary.ImpuestoSugerido != 9 {
+			t.Errorf("El IVA en compras debe reducir el impuesto: %+v", summary)
+		}
+	})
+
+	t.Run("Anular Compra", func(t *testing.T) {
+		if err := svc.AnularCompra(id); err != nil {
+			t.Fatal(err)
+		}
+		var p db.Product
+		database.First(&p, "sku = ?", "P1")
+		if p.Stock != 10 {
+			t.Errorf("La anulación debió retirar el stock: %g", p.Stock)
+		}
+		summary := NewTaxService().GetVATSummary(time.Now().Add(-24*time.Hour), time.Now().Add(24*time.Hour))
+		if summary.IvaCompras != 0 {
+			t.Errorf("Una compra anulada no genera crédito: %+v", summary)
+		}
+		if err := svc.EliminarProveedor("1790016919001"); err == nil {
+			t.Error("No se debe eliminar un proveedor con compras")
+		}
+	})
+
+	t.Run("Bases por Tarifa", func(t *testing.T) {
+		reducida := compra
+		reducida.NumeroFactura = "001-002-000000124"
+		reducida.Items = []db.PurchaseItemDTO{
+			{ProductoSKU: "P1", Nombre: "Producto 1", Cantidad: 5, CostoUnitario: 4, PorcentajeIVA: 15},
+			{ProductoSKU: "BLOQUE", Nombre: "Bloques", Cantidad: 10, CostoUnitario: 10, PorcentajeIVA: 5},
+		}
+		res, err := svc.RegistrarCompra(reducida)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Subtotal15 != 20 || res.Subtotal5 != 100 || res.IVA != 8 || res.Total != 128 {
+			t.Errorf("Bases por tarifa incorrectas: %+v", res)
+		}
+		summary := NewTaxService().GetVATSummary(time.Now().Add(-24*time.Hour), time.Now().Add(24*time.Hour))
+		if summary.Compras15 != 20 || summary.Compras5 != 100 || summary.IvaCompras != 8 {
+			t.Errorf("El 5%% no debe sumarse a la base del 15%%: %+v", summary)
+		}
+	})
+}
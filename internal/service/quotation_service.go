@@ -43,8 +43,19 @@ func (s *QuotationService) CreateQuotation(dto *db.QuotationDTO) error {
 	var subtotal15, subtotal0, totalIVA float64
 	var itemsDB []db.QuotationItem
 
-	for _, item := range dto.Items {
-		base := util.Round(item.Cantidad*item.Precio, 2)
+	// Lista de precios del cliente y promociones vigentes (los precios fijados a mano se respetan)
+	carrito := make([]db.InvoiceItem, len(dto.Items))
+	for i, item := range dto.Items {
+		carrito[i] = db.InvoiceItem{Codigo: item.Codigo, Cantidad: item.Cantidad, Precio: item.Precio, PrecioManual: item.PrecioManual, Descuento: item.Descuento, PromocionID: item.PromocionID}
+	}
+	aplicarListaPrecios(db.GetDB(), dto.ClienteID, carrito, time.Now())
+	aplicarPromociones(db.GetDB(), dto.ClienteID, carrito, time.Now())
+
+	for i := range dto.Items {
+		item := &dto.Items[i]
+		item.Precio = carrito[i].Precio
+		item.Descuento, item.PromocionID, item.Promocion = carrito[i].Descuento, carrito[i].PromocionID, carrito[i].Promocion
+		base := util.Round(item.Cantidad*item.Precio, 2) - util.Round(item.Descuento, 2)
 		impuesto := util.Round(base*(item.PorcentajeIVA/100), 2)
 		
 		if item.PorcentajeIVA > 0 {
@@ -59,6 +70,9 @@ func (s *QuotationService) CreateQuotation(dto *db.QuotationDTO) error {
 			Nombre:         item.Nombre,
 			Cantidad:       item.Cantidad,
 			PrecioUnitario: item.Precio,
+			Descuento:      item.Descuento,
+			PromocionID:    item.PromocionID,
+			Promocion:      item.Promocion,
 			Subtotal:       base,
 			PorcentajeIVA:  item.PorcentajeIVA,
 		})
@@ -88,7 +102,16 @@ func (s *QuotationService) CreateQuotation(dto *db.QuotationDTO) error {
 	if err := db.GetDB().First(&config).Error; err != nil {
 		logger.Error("Error obteniendo configuración para PDF cotización: %v", err)
 	} else {
-		pdfBytes, errPDF := pdf.GenerarCotizacionPDF(*quotationDB, itemsDB, config)
+		// Fotos opcionales: se usan las miniaturas para no inflar el PDF
+		var imagenes map[string]string
+		if dto.MostrarImagenes {
+			skus := make([]string, len(itemsDB))
+			for i, it := range itemsDB {
+				skus[i] = it.ProductoSKU
+			}
+			imagenes = miniaturasProductos(skus)
+		}
+		pdfBytes, errPDF := pdf.GenerarCotizacionPDF(*quotationDB, itemsDB, config, imagenes)
 		if errPDF != nil {
 			logger.Error("Error generando PDF cotización: %v", errPDF)
 		} else {
@@ -188,6 +211,10 @@ func (s *QuotationService) ConvertToInvoice(id uint) (*db.FacturaDTO, error) {
 			Cantidad:      item.Cantidad,
 			Precio:        item.PrecioUnitario,
 			PorcentajeIVA: item.PorcentajeIVA,
+			PrecioManual:  true, // Se factura al precio y descuento cotizados
+			Descuento:     item.Descuento,
+			PromocionID:   item.PromocionID,
+			Promocion:     item.Promocion,
 			// Inferir código IVA simple
 			CodigoIVA:     "2", // Default simple, idealmente guardar el código exacto
 		})
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/logger"
+	"kushkiv2/pkg/sri"
+	xmlsri "kushkiv2/pkg/xml"
+)
+
+// Reintentos máximos de descarga de un comprobante recibido antes de dejarlo en ERROR definitivo.
+const maxIntentosDescarga = 5
+
+// Estados de la cola de comprobantes recibidos.
+const (
+	RecibidoPendiente  = "PENDIENTE"
+	RecibidoRegistrado = "REGISTRADO"
+	RecibidoOmitido    = "OMITIDO"
+	RecibidoError      = "ERROR"
+)
+
+// columnasRecibidos mapea los encabezados del reporte del SRI (normalizados) al campo interno.
+// El portal ha cambiado el orden de columnas entre versiones, por eso se ubican por nombre.
+var columnasRecibidos = map[string]string{
+	"comprobante":         "tipo",
+	"tipo_comprobante":    "tipo",
+	"serie_comprobante":   "serie",
+	"numero_comprobante":  "serie",
+	"ruc_emisor":          "ruc",
+	"razon_social_emisor": "razon_social",
+	"fecha_emision":       "fecha_emision",
+	"clave_acceso":        "clave",
+	"numero_autorizacion": "autorizacion",
+	"importe_total":       "total",
+	"valor_total":         "total",
+}
+
+// RecibidoSRI es una línea del reporte de comprobantes recibidos.
+type RecibidoSRI struct {
+	Tipo         string  `json:"tipo"`
+	Serie        string  `json:"serie"`
+	RucEmisor    string  `json:"rucEmisor"`
+	RazonSocial  string  `json:"razonSocial"`
+	FechaEmision string  `json:"fechaEmision"` // Format: 2006-01-02
+	ClaveAcceso  string  `json:"claveAcceso"`
+	Total        float64 `json:"total"`
+}
+
+// ConciliacionItem es el resultado de cruzar una línea del reporte con las compras registradas.
+type ConciliacionItem struct {
+	RecibidoSRI
+	Estado          string  `json:"estado"` // REGISTRADA, DIFERENCIA, FALTANTE, NO_SOPORTADO
+	CompraID        uint    `json:"compraId"`
+	TotalRegistrado float64 `json:"totalRegistrado"`
+	Mensaje         string  `json:"mensaje"`
+}
+
+// ConciliacionRecibidos resume el cruce del reporte del SRI contra las compras.
+type ConciliacionRecibidos struct {
+	Desde        string             `json:"desde"`
+	Hasta        string             `json:"hasta"`
+	Items        []ConciliacionItem `json:"items"`
+	NoEnSRI      []db.PurchaseDTO   `json:"noEnSri"` // Compras del período que el SRI no lista
+	Registradas  int                `json:"registradas"`
+	Diferencias  int                `json:"diferencias"`
+	Faltantes    int                `json:"faltantes"`
+	NoSoportados int                `json:"noSoportados"`
+	Encolados    int                `json:"encolados"`
+}
+
+type ReceivedService struct {
+	purchaseService *PurchaseService
+	sriClient       *sri.SRIClient
+	mu              sync.Mutex // Evita procesar la cola dos veces en paralelo (worker y botón)
+}
+
+func NewReceivedService(purchaseService *PurchaseService) *ReceivedService {
+	return &ReceivedService{
+		purchaseService: purchaseService,
+		sriClient:       sri.NewSRIClient(),
+	}
+}
+
+// ParsearReporteRecibidos lee el TXT (separado por tabulaciones) que descarga el portal del SRI
+// en "Comprobantes electrónicos recibidos". Acepta UTF-8 o Latin-1 y busca la fila de encabezados.
+func (s *ReceivedService) ParsearReporteRecibidos(data []byte) ([]RecibidoSRI, error) {
+	texto := string(data)
+	if !utf8.Valid(data) {
+		// El portal exporta en ISO-8859-1: cada byte es el code point
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		texto = string(runes)
+	}
+	lineas := strings.Split(strings.ReplaceAll(texto, "\r\n", "\n"), "\n")
+
+	indices := map[string]int{}
+	inicio := -1
+	for i, linea := range lineas {
+		campos := strings.Split(linea, "\t")
+		for j, h := range campos {
+			if campo, ok := columnasRecibidos[normalizarEncabezado(h)]; ok {
+				if _, repetido := indices[campo]; !repetido {
+					indices[campo] = j
+				}
+			}
+		}
+		if _, ok := indices["clave"]; ok {
+			inicio = i + 1
+			break
+		}
+		indices = map[string]int{}
+	}
+	if inicio < 0 {
+		return nil, fmt.Errorf("el archivo no parece un reporte de comprobantes recibidos del SRI (falta la columna CLAVE_ACCESO)")
+	}
+
+	var result []RecibidoSRI
+	for n, linea := range lineas[inicio:] {
+		campos := strings.Split(linea, "\t")
+		valor := func(campo string) string {
+			if i, ok := indices[campo]; ok && i < len(campos) {
+				return strings.TrimSpace(campos[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(linea) == "" {
+			continue
+		}
+
+		r := RecibidoSRI{
+			Tipo:        valor("tipo"),
+			Serie:       valor("serie"),
+			RucEmisor:   valor("ruc"),
+			RazonSocial: valor("razon_social"),
+			ClaveAcceso: valor("clave"),
+		}
+		if len(r.ClaveAcceso) != 49 && len(valor("autorizacion")) == 49 {
+			r.ClaveAcceso = valor("autorizacion")
+		}
+		if len(r.ClaveAcceso) != 49 || !soloDigitosRe.MatchString(r.ClaveAcceso) {
+			return nil, fmt.Errorf("línea %d: clave de acceso inválida '%s'", inicio+n+1, r.ClaveAcceso)
+		}
+		// La clave de acceso contiene el RUC y la serie: completar si el reporte no los trae
+		if r.RucEmisor == "" {
+			r.RucEmisor = r.ClaveAcceso[10:23]
+		}
+		if r.Serie == "" {
+			r.Serie = fmt.Sprintf("%s-%s-%s", r.ClaveAcceso[24:27], r.ClaveAcceso[27:30], r.ClaveAcceso[30:39])
+		}
+		if f := valor("fecha_emision"); len(f) >= 10 {
+			if fecha, err := time.Parse("02/01/2006", f[:10]); err == nil {
+				r.FechaEmision = fecha.Format("2006-01-02")
+			}
+		}
+		if r.FechaEmision == "" {
+			fecha, err := time.Parse("02012006", r.ClaveAcceso[:8])
+			if err != nil {
+				return nil, fmt.Errorf("línea %d: fecha de emisión inválida", inicio+n+1)
+			}
+			r.FechaEmision = fecha.Format("2006-01-02")
+		}
+		if t := valor("total"); t != "" {
+			total, err := parsearDecimal(t)
+			if err != nil {
+				return nil, fmt.Errorf("línea %d: importe inválido '%s'", inicio+n+1, t)
+			}
+			r.Total = total
+		}
+		result = append(result, r)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("el reporte no contiene comprobantes")
+	}
+	return result, nil
+}
+
+// ConciliarRecibidos cruza el reporte del SRI con las compras registradas y encola para descarga
+// las facturas faltantes con las opciones de importación indicadas.
+func (s *ReceivedService) ConciliarRecibidos(data []byte, opciones OpcionesImportacionXML) (*ConciliacionRecibidos, error) {
+	filas, err := s.ParsearReporteRecibidos(data)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ConciliacionRecibidos{Items: []ConciliacionItem{}, NoEnSRI: []db.PurchaseDTO{}}
+	vistas := map[uint]bool{}
+	for _, f := range filas {
+		if res.Desde == "" || f.FechaEmision < res.Desde {
+			res.Desde = f.FechaEmision
+		}
+		if f.FechaEmision > res.Hasta {
+			res.Hasta = f.FechaEmision
+		}
+
+		item := ConciliacionItem{RecibidoSRI: f}
+		if !esFacturaRecibida(f) {
+			item.Estado = "NO_SOPORTADO"
+			item.Mensaje = "Solo se registran facturas como compras"
+			res.NoSoportados++
+			res.Items = append(res.Items, item)
+			continue
+		}
+
+		var compras []db.Purchase
+		db.GetDB().Where("autorizacion = ? OR (supplier_ruc = ? AND numero_factura = ?)", f.ClaveAcceso, f.RucEmisor, f.Serie).
+			Limit(1).Find(&compras)
+		if len(compras) > 0 {
+			c := compras[0]
+			vistas[c.ID] = true
+			item.CompraID = c.ID
+			item.TotalRegistrado = c.Total
+			item.Estado = "REGISTRADA"
+			if c.Estado == "ANULADA" {
+				item.Mensaje = "La compra está anulada en el sistema"
+			}
+			if f.Total > 0 && math.Abs(c.Total-f.Total) > 0.01 {
+				item.Estado = "DIFERENCIA"
+				item.Mensaje = fmt.Sprintf("Total SRI %.2f, registrado %.2f", f.Total, c.Total)
+				res.Diferencias++
+			} else {
+				res.Registradas++
+			}
+			res.Items = append(res.Items, item)
+			continue
+		}
+
+		item.Estado = "FALTANTE"
+		res.Faltantes++
+		encolado, err := encolarRecibido(f, opciones)
+		if err != nil {
+			return nil, err
+		}
+		if encolado {
+			res.Encolados++
+			item.Mensaje = "En cola de descarga"
+		} else {
+			item.Mensaje = "Ya está en la cola de descarga"
+		}
+		res.Items = append(res.Items, item)
+	}
+
+	// Compras del mismo período que el SRI no reporta (posibles errores de digitación)
+	desde, _ := time.ParseInLocation("2006-01-02", res.Desde, time.Local)
+	hasta, _ := time.ParseInLocation("2006-01-02", res.Hasta, time.Local)
+	var compras []db.Purchase
+	db.GetDB().Where("estado = ? AND fecha_emision >= ? AND fecha_emision < ?", "REGISTRADA", desde, hasta.AddDate(0, 0, 1)).
+		Order("fecha_emision asc").Find(&compras)
+	for _, c := range compras {
+		if !vistas[c.ID] {
+			res.NoEnSRI = append(res.NoEnSRI, mapPurchaseToDTO(c, ""))
+		}
+	}
+	return res, nil
+}
+
+// encolarRecibido agrega una clave a la cola de descarga. Si ya estaba (con error u omitida),
+// vuelve a quedar pendiente con las nuevas opciones. Devuelve false si ya estaba pendiente.
+func encolarRecibido(f RecibidoSRI, opciones OpcionesImportacionXML) (bool, error) {
+	fecha, _ := time.ParseInLocation("2006-01-02", f.FechaEmision, time.Local)
+	var existentes []db.ComprobanteRecibido
+	db.GetDB().Where("clave_acceso = ?", f.ClaveAcceso).Limit(1).Find(&existentes)
+	if len(existentes) > 0 && existentes[0].Estado == RecibidoPendiente {
+		return false, nil
+	}
+
+	rec := db.ComprobanteRecibido{
+		ClaveAcceso:          f.ClaveAcceso,
+		Tipo:                 f.Tipo,
+		RucEmisor:            f.RucEmisor,
+		RazonSocialEmisor:    f.RazonSocial,
+		Serie:                f.Serie,
+		FechaEmision:         fecha,
+		ImporteTotal:         f.Total,
+		Estado:               RecibidoPendiente,
+		ActualizarInventario: opciones.ActualizarInventario,
+		CrearProductos:       opciones.CrearProductos,
+		AceptarSinFirma:      opciones.AceptarSinFirma,
+		Bodega:               opciones.Bodega,
+	}
+	if len(existentes) > 0 {
+		rec.CreatedAt = existentes[0].CreatedAt
+	}
+	if err := db.GetDB().Save(&rec).Error; err != nil {
+		return false, fmt.Errorf("error encolando %s: %v", f.ClaveAcceso, err)
+	}
+	return true, nil
+}
+
+// ListarColaRecibidos devuelve la cola de descarga, opcionalmente filtrada por estado.
+func (s *ReceivedService) ListarColaRecibidos(estado string) ([]db.ComprobanteRecibidoDTO, error) {
+	q := db.GetDB().Order("fecha_emision asc")
+	if estado != "" {
+		q = q.Where("estado = ?", estado)
+	}
+	var recs []db.ComprobanteRecibido
+	if err := q.Find(&recs).Error; err != nil {
+		return nil, fmt.Errorf("error listando cola: %v", err)
+	}
+	result := make([]db.ComprobanteRecibidoDTO, 0, len(recs))
+	for _, r := range recs {
+		result = append(result, db.ComprobanteRecibidoDTO{
+			ClaveAcceso:       r.ClaveAcceso,
+			Tipo:              r.Tipo,
+			RucEmisor:         r.RucEmisor,
+			RazonSocialEmisor: r.RazonSocialEmisor,
+			Serie:             r.Serie,
+			FechaEmision:      r.FechaEmision.Format("2006-01-02"),
+			ImporteTotal:      r.ImporteTotal,
+			Estado:            r.Estado,
+			Intentos:          r.Intentos,
+			Mensaje:           r.Mensaje,
+			PurchaseID:        r.PurchaseID,
+		})
+	}
+	return result, nil
+}
+
+// ProcesarColaRecibidos descarga del SRI hasta limite comprobantes pendientes y los registra como compras.
+// Si el SRI no responde se detiene sin consumir intentos.
+func (s *ReceivedService) ProcesarColaRecibidos(limite int) (*ResultadoImportacionXML, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pendientes []db.ComprobanteRecibido
+	if err := db.GetDB().Where("estado IN ? AND intentos < ?", []string{RecibidoPendiente, RecibidoError}, maxIntentosDescarga).
+		Order("fecha_emision asc").Limit(limite).Find(&pendientes).Error; err != nil {
+		return nil, fmt.Errorf("error consultando cola: %v", err)
+	}
+
+	resultado := &ResultadoImportacionXML{Comprobantes: []ComprobanteImportado{}}
+	for i := range pendientes {
+		rec := &pendientes[i]
+		res, err := s.descargarRecibido(rec)
+		if _, isNetErr := err.(*sri.NetworkError); isNetErr {
+			return resultado, err
+		}
+		resultado.agregar(res)
+
+		updates := map[string]interface{}{"mensaje": res.Mensaje, "purchase_id": res.CompraID}
+		switch res.Estado {
+		case "IMPORTADA", "DUPLICADA":
+			updates["estado"] = RecibidoRegistrado
+		case "OMITIDA":
+			updates["estado"] = RecibidoOmitido
+		default:
+			updates["estado"] = RecibidoError
+			updates["intentos"] = rec.Intentos + 1
+		}
+		if err := db.GetDB().Model(rec).Updates(updates).Error; err != nil {
+			logger.Error("Error actualizando cola de recibidos %s: %v", rec.ClaveAcceso, err)
+		}
+	}
+	return resultado, nil
+}
+
+// descargarRecibido obtiene el XML autorizado de una clave y lo importa.
+func (s *ReceivedService) descargarRecibido(rec *db.ComprobanteRecibido) (ComprobanteImportado, error) {
+	res := ComprobanteImportado{Archivo: rec.ClaveAcceso, ClaveAcceso: rec.ClaveAcceso, Proveedor: rec.RazonSocialEmisor,
+		NumeroFactura: rec.Serie, Total: rec.ImporteTotal, Estado: "ERROR"}
+
+	resp, err := s.sriClient.AutorizarComprobante(rec.ClaveAcceso)
+	if err != nil {
+		res.Mensaje = fmt.Sprintf("error consultando SRI: %v", err)
+		return res, err
+	}
+	auts := resp.Autorizaciones.Autorizacion
+	if len(auts) == 0 {
+		res.Mensaje = "el SRI no devolvió el comprobante"
+		return res, nil
+	}
+	// Puede haber intentos previos no autorizados: tomar el autorizado
+	aut := auts[0]
+	for _, a := range auts {
+		if a.Estado == "AUTORIZADO" {
+			aut = a
+			break
+		}
+	}
+
+	envoltorio, err := xml.Marshal(xmlsri.AutorizacionXML{
+		Estado:             aut.Estado,
+		NumeroAutorizacion: aut.NumeroAutorizacion,
+		FechaAutorizacion:  aut.FechaAutorizacion,
+		Ambiente:           aut.Ambiente,
+		Comprobante:        aut.Comprobante,
+	})
+	if err != nil {
+		res.Mensaje = fmt.Sprintf("error armando autorización: %v", err)
+		return res, nil
+	}
+
+	opciones := OpcionesImportacionXML{
+		ActualizarInventario: rec.ActualizarInventario,
+		CrearProductos:       rec.CrearProductos,
+		AceptarSinFirma:      rec.AceptarSinFirma,
+		Bodega:               rec.Bodega,
+	}
+	return s.purchaseService.importarComprobante(rec.ClaveAcceso+".xml", envoltorio, opciones), nil
+}
+
+// StartQueueWorker procesa la cola de recibidos cada 30 minutos.
+func (s *ReceivedService) StartQueueWorker() {
+	go func() {
+		for {
+			time.Sleep(30 * time.Minute)
+			res, err := s.ProcesarColaRecibidos(20)
+			if err != nil {
+				logger.Error("Cola de recibidos: %v", err)
+			}
+			if res != nil && res.Importadas > 0 {
+				logger.Info("Compras registradas desde el SRI: %d", res.Importadas)
+			}
+		}
+	}()
+}
+
+// esFacturaRecibida distingue facturas de notas de crédito, retenciones, etc.
+// Usa el tipo del reporte y, si no viene, el código de documento de la clave de acceso.
+func esFacturaRecibida(f RecibidoSRI) bool {
+	if tipo := normalizarEncabezado(f.Tipo); tipo != "" {
+		return strings.HasPrefix(tipo, "factura")
+	}
+	return f.ClaveAcceso[8:10] == "01"
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"kushkiv2/internal/db"
+)
+
+// facturaRecibidaXML es la factura de proveedor con una clave de acceso bien formada (fecha, tipo,
+// RUC, ambiente, serie y secuencial en su posición), como las que lista el reporte del SRI.
+func facturaRecibidaXML(t *testing.T, secuencial string) []byte {
+	clave := "01012026" + "01" + "1790016919001" + "1" + "001001" + secuencial + "12345678" + "1" + "7"
+	data := facturaProveedorXML(t, secuencial)
+	original := []byte("0101202601179001691900110010010" + secuencial + "123456781")
+	if bytes.Count(data, original) != 1 {
+		t.Fatal("la factura de proveedor cambió de clave de acceso")
+	}
+	return bytes.Replace(data, original, []byte(clave), 1)
+}
+
+type sriTransportFunc func(*http.Request) (*http.Response, error)
+
+func (f sriTransportFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func respuestaAutorizacionSOAP(comprobante []byte) string {
+	var escapado bytes.Buffer
+	xml.EscapeText(&escapado, comprobante)
+	return `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><ns2:autorizacionComprobanteResponse xmlns:ns2="http://ec.gob.sri.ws.autorizacion">` +
+		`<RespuestaAutorizacionComprobante><claveAccesoConsultada>x</claveAccesoConsultada><numeroComprobantes>1</numeroComprobantes><autorizaciones><autorizacion>` +
+		`<estado>AUTORIZADO</estado><numeroAutorizacion></numeroAutorizacion><fechaAutorizacion>2026-01-01T10:00:00</fechaAutorizacion><ambiente>PRUEBAS</ambiente>` +
+		`<comprobante>` + escapado.String() + `</comprobante></autorizacion></autorizaciones></RespuestaAutorizacionComprobante>` +
+		`</ns2:autorizacionComprobanteResponse></soap:Body></soap:Envelope>`
+}
+
+func TestConciliarRecibidos(t *testing.T) {
+	database := setupTestDB()
+	purchases := NewPurchaseService()
+	svc := NewReceivedService(purchases)
+	database.Create(&db.EmisorConfig{RUC: "1790011223001"})
+	database.Create(&db.Product{SKU: "P1", Name: "Producto 1", Barcode: "P1", Stock: 0})
+
+	opciones := OpcionesImportacionXML{ActualizarInventario: true, CrearProductos: true, AceptarSinFirma: true}
+	previa := &ResultadoImportacionXML{}
+	purchases.ImportarComprobantesXML(previa, "f1.xml", facturaRecibidaXML(t, "000000001"), opciones)
+	if previa.Importadas != 1 {
+		t.Fatalf("No se pudo preparar la compra: %+v", previa.Comprobantes)
+	}
+	clave1 := previa.Comprobantes[0].ClaveAcceso
+	clave2 := strings.Replace(clave1, "000000001", "000000002", 1)
+	retencion := "0101202607" + clave1[10:]
+
+	// Compra digitada a mano que el SRI no reporta
+	if _, err := purchases.RegistrarCompra(db.PurchaseDTO{SupplierRUC: "1790016919001", NumeroFactura: "001-001-000000099", Autorizacion: "1234567890",
+		FechaEmision: "2026-01-01", Items: []db.PurchaseItemDTO{{Nombre: "Servicio", Cantidad: 1, CostoUnitario: 10}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	reporte := "RUC_EMISOR\tRAZON_SOCIAL_EMISOR\tCOMPROBANTE\tSERIE_COMPROBANTE\tCLAVE_ACCESO\tFECHA_AUTORIZACION\tFECHA_EMISION\tIDENTIFICACION_RECEPTOR\tIMPORTE_TOTAL\r\n" +
+		"1790016919001\tDistribuidora SA\tFactura\t001-001-000000001\t" + clave1 + "\t01/01/2026 10:00:00\t01/01/2026\t1790011223001\t28.00\r\n" +
+		"1790016919001\tDistribuidora SA\tFactura\t001-001-000000002\t" + clave2 + "\t01/01/2026 10:00:00\t01/01/2026\t1790011223001\t28.00\r\n" +
+		"1790016919001\tDistribuidora SA\tComprobante de Retenci\xf3n\t001-001-000000001\t" + retencion + "\t01/01/2026 10:00:00\t01/01/2026\t1790011223001\t\r\n"
+
+	filas, err := svc.ParsearReporteRecibidos([]byte(reporte))
+	if err != nil {
+		t.Fatalf("Error parseando reporte: %v", err)
+	}
+	if len(filas) != 3 || filas[2].Tipo != "Comprobante de Retención" || filas[1].Total != 28 || filas[0].FechaEmision != "2026-01-01" {
+		t.Fatalf("Reporte mal interpretado: %+v", filas)
+	}
+
+	res, err := svc.ConciliarRecibidos([]byte(reporte), opciones)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Registradas != 1 || res.Faltantes != 1 || res.Encolados != 1 || res.NoSoportados != 1 || len(res.NoEnSRI) != 1 {
+		t.Fatalf("Conciliación incorrecta: %+v", res)
+	}
+	if res, _ := svc.ConciliarRecibidos([]byte(reporte), opciones); res.Encolados != 0 {
+		t.Error("Una clave ya pendiente no debería encolarse de nuevo")
+	}
+
+	t.Run("SRI sin conexión", func(t *testing.T) {
+		svc.sriClient.Client.Transport = sriTransportFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("sin red")
+		})
+		if _, err := svc.ProcesarColaRecibidos(10); err == nil {
+			t.Error("Debería reportar el error de red")
+		}
+		cola, _ := svc.ListarColaRecibidos(RecibidoPendiente)
+		if len(cola) != 1 || cola[0].Intentos != 0 {
+			t.Errorf("Un error de red no debería consumir intentos: %+v", cola)
+		}
+	})
+
+	t.Run("Descarga y registro", func(t *testing.T) {
+		var consultada string
+		svc.sriClient.Client.Transport = sriTransportFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(r.Body)
+			consultada = string(body)
+			resp := respuestaAutorizacionSOAP(facturaRecibidaXML(t, "000000002"))
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(resp)), Header: http.Header{}}, nil
+		})
+
+		out, err := svc.ProcesarColaRecibidos(10)
+		if err != nil || out.Importadas != 1 {
+			t.Fatalf("No se registró el comprobante descargado: %v %+v", err, out)
+		}
+		if !strings.Contains(consultada, clave2) {
+			t.Error("No se consultó la clave faltante")
+		}
+		cola, _ := svc.ListarColaRecibidos("")
+		if len(cola) != 1 || cola[0].Estado != RecibidoRegistrado || cola[0].PurchaseID == 0 {
+			t.Errorf("Cola no actualizada: %+v", cola)
+		}
+		var p db.Product
+		database.First(&p, "sku = ?", "P1")
+		if p.Stock != 10 {
+			t.Errorf("Stock esperado 10 tras ambas compras, obtuve %g", p.Stock)
+		}
+	})
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/logger"
+)
+
+// mesesPorPeriodicidad define cuántos meses avanza cada tipo de plantilla recurrente.
+var mesesPorPeriodicidad = map[string]int{
+	"MENSUAL":    1,
+	"BIMESTRAL":  2,
+	"TRIMESTRAL": 3,
+	"SEMESTRAL":  6,
+	"ANUAL":      12,
+}
+
+// RecurringPreview describe una factura que se emitirá en una próxima ejecución.
+type RecurringPreview struct {
+	PlantillaID uint    `json:"plantillaId"`
+	Nombre      string  `json:"nombre"`
+	Cliente     string  `json:"cliente"`
+	Fecha       string  `json:"fecha"`
+	Total       float64 `json:"total"`
+}
+
+// FacturaEmitidaFunc se invoca tras cada emisión exitosa para guardar archivos y enviar correo.
+// email llega vacío si la plantilla no tiene habilitado el envío automático.
+type FacturaEmitidaFunc func(factura db.Factura, email string)
+
+type RecurringService struct {
+	invoiceService *InvoiceService
+	mu             sync.Mutex // Evita ejecuciones simultáneas (scheduler + disparo manual)
+}
+
+func NewRecurringService(invoiceService *InvoiceService) *RecurringService {
+	return &RecurringService{invoiceService: invoiceService}
+}
+
+// GuardarPlantilla crea o actualiza una plantilla y recalcula su próxima emisión.
+func (s *RecurringService) GuardarPlantilla(dto db.RecurringInvoiceDTO) (*db.RecurringInvoiceDTO, error) {
+	meses, ok := mesesPorPeriodicidad[dto.Periodicidad]
+	if !ok {
+		return nil, fmt.Errorf("periodicidad inválida: %s", dto.Periodicidad)
+	}
+	if dto.DiaMes < 1 || dto.DiaMes > 31 {
+		return nil, fmt.Errorf("el día de facturación debe estar entre 1 y 31")
+	}
+	if dto.Factura.ClienteID == "" {
+		return nil, fmt.Errorf("la plantilla debe tener un cliente")
+	}
+	if len(dto.Factura.Items) == 0 {
+		return nil, fmt.Errorf("la plantilla debe tener al menos un ítem")
+	}
+
+	inicio, err := time.ParseInLocation("2006-01-02", dto.FechaInicio, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("fecha de inicio inválida: %v", err)
+	}
+	var fin *time.Time
+	if dto.FechaFin != "" {
+		f, err := time.ParseInLocation("2006-01-02", dto.FechaFin, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("fecha de fin inválida: %v", err)
+		}
+		if f.Before(inicio) {
+			return nil, fmt.Errorf("la fecha de fin es anterior a la de inicio")
+		}
+		fin = &f
+	}
+
+	data, err := json.Marshal(dto.Factura)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando plantilla: %v", err)
+	}
+
+	plantilla := db.RecurringInvoice{}
+	if dto.ID != 0 {
+		if err := db.GetDB().First(&plantilla, dto.ID).Error; err != nil {
+			return nil, fmt.Errorf("plantilla no encontrada: %v", err)
+		}
+	}
+
+	cambioCalendario := dto.ID == 0 || plantilla.Periodicidad != dto.Periodicidad ||
+		plantilla.DiaMes != dto.DiaMes || !plantilla.FechaInicio.Equal(inicio)
+
+	plantilla.Nombre = dto.Nombre
+	plantilla.ClienteID = dto.Factura.ClienteID
+	plantilla.ClienteNombre = dto.Factura.ClienteNombre
+	plantilla.Data = string(data)
+	plantilla.Periodicidad = dto.Periodicidad
+	plantilla.DiaMes = dto.DiaMes
+	plantilla.FechaInicio = inicio
+	plantilla.FechaFin = fin
+	plantilla.EnviarCorreo = dto.EnviarCorreo
+	plantilla.Activa = dto.Activa
+
+	if cambioCalendario {
+		// Si ya se facturó, la siguiente emisión parte del último periodo emitido
+		if plantilla.UltimaEmision != nil {
+			plantilla.ProximaEmision = siguienteEmision(*plantilla.UltimaEmision, meses, dto.DiaMes)
+		} else {
+			plantilla.ProximaEmision = primeraEmision(inicio, dto.DiaMes)
+		}
+	}
+
+	if err := db.GetDB().Save(&plantilla).Error; err != nil {
+		return nil, fmt.Errorf("error guardando plantilla: %v", err)
+	}
+	return mapRecurringToDTO(plantilla)
+}
+
+// ListarPlantillas devuelve todas las plantillas recurrentes ordenadas por próxima emisión.
+func (s *RecurringService) ListarPlantillas() ([]db.RecurringInvoiceDTO, error) {
+	var plantillas []db.RecurringInvoice
+	if err := db.GetDB().Order("activa desc, proxima_emision asc").Find(&plantillas).Error; err != nil {
+		return nil, fmt.Errorf("error listando plantillas: %v", err)
+	}
+	result := make([]db.RecurringInvoiceDTO, 0, len(plantillas))
+	for _, p := range plantillas {
+		dto, err := mapRecurringToDTO(p)
+		if err != nil {
+			logger.Error("Plantilla recurrente %d corrupta: %v", p.ID, err)
+			continue
+		}
+		result = append(result, *dto)
+	}
+	return result, nil
+}
+
+// EliminarPlantilla borra la plantilla. El historial de ejecuciones se conserva.
+func (s *RecurringService) EliminarPlantilla(id uint) error {
+	res := db.GetDB().Delete(&db.RecurringInvoice{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("error eliminando plantilla: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("plantilla no encontrada")
+	}
+	return nil
+}
+
+// PrevisualizarEjecucion lista las facturas que se emitirán hasta la fecha indicada, sin emitir nada.
+func (s *RecurringService) PrevisualizarEjecucion(hasta time.Time) ([]RecurringPreview, error) {
+	var plantillas []db.RecurringInvoice
+	if err := db.GetDB().Where("activa = ? AND proxima_emision <= ?", true, hasta).
+		Order("proxima_emision asc").Find(&plantillas).Error; err != nil {
+		return nil, fmt.Errorf("error consultando plantillas: %v", err)
+	}
+
+	result := []RecurringPreview{}
+	for _, p := range plantillas {
+		var dto db.FacturaDTO
+		if err := json.Unmarshal([]byte(p.Data), &dto); err != nil {
+			logger.Error("Plantilla recurrente %d corrupta: %v", p.ID, err)
+			continue
+		}
+		total := calcularFactura(&dto).ImporteTotal
+		meses := mesesPorPeriodicidad[p.Periodicidad]
+
+		for fecha := p.ProximaEmision; !fecha.After(hasta) && vigente(p, fecha); fecha = siguienteEmision(fecha, meses, p.DiaMes) {
+			result = append(result, RecurringPreview{
+				PlantillaID: p.ID,
+				Nombre:      p.Nombre,
+				Cliente:     p.ClienteNombre,
+				Fecha:       fecha.Format("2006-01-02"),
+				Total:       total,
+			})
+		}
+	}
+	return result, nil
+}
+
+// EjecutarPendientes emite todas las facturas vencidas a la fecha `ahora`.
+// Los periodos atrasados (app cerrada varios días) se emiten uno por uno.
+// Si una emisión falla o el SRI la rechaza, la plantilla no avanza y se reintenta en la siguiente ejecución.
+func (s *RecurringService) EjecutarPendientes(ahora time.Time, onEmitida FacturaEmitidaFunc) ([]db.RecurringRunDTO, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var plantillas []db.RecurringInvoice
+	if err := db.GetDB().Where("activa = ? AND proxima_emision <= ?", true, ahora).Find(&plantillas).Error; err != nil {
+		return nil, fmt.Errorf("error consultando plantillas: %v", err)
+	}
+
+	runs := []db.RecurringRunDTO{}
+	for i := range plantillas {
+		p := &plantillas[i]
+		meses := mesesPorPeriodicidad[p.Periodicidad]
+
+		for !p.ProximaEmision.After(ahora) {
+			if !vigente(*p, p.ProximaEmision) {
+				// Contrato terminado
+				p.Activa = false
+				db.GetDB().Save(p)
+				break
+			}
+
+			run := s.emitirPeriodo(p, onEmitida)
+			runs = append(runs, mapRunToDTO(run, p.Nombre))
+			if run.Estado != "EXITO" {
+				break
+			}
+
+			periodo := p.ProximaEmision
+			p.UltimaEmision = &periodo
+			p.ProximaEmision = siguienteEmision(periodo, meses, p.DiaMes)
+			if err := db.GetDB().Save(p).Error; err != nil {
+				logger.Error("Error actualizando plantilla recurrente %d: %v", p.ID, err)
+				break
+			}
+		}
+	}
+	return runs, nil
+}
+
+// emitirPeriodo emite la factura de un periodo y registra el resultado en el log de ejecuciones.
+func (s *RecurringService) emitirPeriodo(p *db.RecurringInvoice, onEmitida FacturaEmitidaFunc) db.RecurringRun {
+	run := db.RecurringRun{RecurringID: p.ID, Periodo: p.ProximaEmision}
+
+	var dto db.FacturaDTO
+	if err := json.Unmarshal([]byte(p.Data), &dto); err != nil {
+		run.Estado = "ERROR"
+		run.Mensaje = fmt.Sprintf("plantilla corrupta: %v", err)
+	} else {
+		if dto.Observacion == "" {
+			dto.Observacion = fmt.Sprintf("%s - Periodo %s", p.Nombre, p.ProximaEmision.Format("01/2006"))
+		}
+		run.Total = calcularFactura(&dto).ImporteTotal
+
+		if err := s.invoiceService.EmitirFactura(&dto); err != nil {
+			run.Estado = "ERROR"
+			run.Mensaje = err.Error()
+		} else {
+			run.Estado = "EXITO"
+			run.ClaveAcceso = dto.ClaveAcceso
+			run.Secuencial = dto.Secuencial
+
+			var factura db.Factura
+			if err := db.GetDB().First(&factura, "clave_acceso = ?", dto.ClaveAcceso).Error; err == nil {
+				run.Mensaje = factura.EstadoSRI
+				if !estadosVenta[factura.EstadoSRI] {
+					// Rechazada por el SRI: el periodo no se da por emitido y se reintenta
+					run.Estado = "ERROR"
+					run.Mensaje = strings.TrimSpace(fmt.Sprintf("Factura %s %s: %s", factura.Secuencial, factura.EstadoSRI, factura.MensajeError))
+				} else if onEmitida != nil {
+					email := ""
+					if p.EnviarCorreo {
+						email = dto.ClienteEmail
+					}
+					onEmitida(factura, email)
+				}
+			}
+		}
+	}
+
+	if run.Estado == "ERROR" {
+		logger.Error("Facturación recurrente '%s' falló: %s", p.Nombre, run.Mensaje)
+	}
+	if err := db.GetDB().Create(&run).Error; err != nil {
+		logger.Error("Error guardando log de facturación recurrente: %v", err)
+	}
+	return run
+}
+
+// GetEjecuciones devuelve el log de ejecuciones, opcionalmente filtrado por plantilla (0 = todas).
+func (s *RecurringService) GetEjecuciones(plantillaID uint, limit int) ([]db.RecurringRunDTO, error) {
+	query := db.GetDB().Order("created_at desc")
+	if plantillaID != 0 {
+		query = query.Where("recurring_id = ?", plantillaID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var runs []db.RecurringRun
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("error consultando ejecuciones: %v", err)
+	}
+
+	nombres := map[uint]string{}
+	var plantillas []db.RecurringInvoice
+	db.GetDB().Select("id", "nombre").Find(&plantillas)
+	for _, p := range plantillas {
+		nombres[p.ID] = p.Nombre
+	}
+
+	result := make([]db.RecurringRunDTO, 0, len(runs))
+	for _, r := range runs {
+		result = append(result, mapRunToDTO(r, nombres[r.RecurringID]))
+	}
+	return result, nil
+}
+
+// StartScheduler revisa cada hora si hay facturas recurrentes vencidas y las emite.
+func (s *RecurringService) StartScheduler(onEmitida FacturaEmitidaFunc) {
+	go func() {
+		for {
+			time.Sleep(1 * time.Minute) // Dar tiempo a que la app termine de iniciar
+			if _, err := s.EjecutarPendientes(time.Now(), onEmitida); err != nil {
+				logger.Error("%v", err)
+			}
+			time.Sleep(59 * time.Minute)
+		}
+	}()
+}
+
+// vigente indica si la fecha cae dentro del contrato de la plantilla.
+func vigente(p db.RecurringInvoice, fecha time.Time) bool {
+	return p.FechaFin == nil || !fecha.After(*p.FechaFin)
+}
+
+// fechaConDia construye la fecha del mes indicado ajustando el día al último del mes si no existe (ej. 31 en febrero).
+func fechaConDia(anio int, mes time.Month, dia int, loc *time.Location) time.Time {
+	ultimo := time.Date(anio, mes+1, 0, 0, 0, 0, 0, loc).Day()
+	if dia > ultimo {
+		dia = ultimo
+	}
+	return time.Date(anio, mes, dia, 0, 0, 0, 0, loc)
+}
+
+// primeraEmision es la primera fecha con el día de facturación igual o posterior al inicio del contrato.
+func primeraEmision(inicio time.Time, dia int) time.Time {
+	fecha := fechaConDia(inicio.Year(), inicio.Month(), dia, inicio.Location())
+	if fecha.Before(inicio) {
+		fecha = fechaConDia(inicio.Year(), inicio.Month()+1, dia, inicio.Location())
+	}
+	return fecha
+}
+
+// siguienteEmision avanza `meses` desde el periodo anterior respetando el día de facturación.
+func siguienteEmision(anterior time.Time, meses, dia int) time.Time {
+	return fechaConDia(anterior.Year(), anterior.Month()+time.Month(meses), dia, anterior.Location())
+}
+
+func mapRecurringToDTO(p db.RecurringInvoice) (*db.RecurringInvoiceDTO, error) {
+	var factura db.FacturaDTO
+	if p.Data != "" {
+		if err := json.Unmarshal([]byte(p.Data), &factura); err != nil {
+			return nil, fmt.Errorf("error leyendo plantilla: %v", err)
+		}
+	}
+	dto := &db.RecurringInvoiceDTO{
+		ID:             p.ID,
+		Nombre:         p.Nombre,
+		Factura:        factura,
+		Periodicidad:   p.Periodicidad,
+		DiaMes:         p.DiaMes,
+		FechaInicio:    p.FechaInicio.Format("2006-01-02"),
+		EnviarCorreo:   p.EnviarCorreo,
+		Activa:         p.Activa,
+		ProximaEmision: p.ProximaEmision.Format("2006-01-02"),
+	}
+	if p.FechaFin != nil {
+		dto.FechaFin = p.FechaFin.Format("2006-01-02")
+	}
+	if p.UltimaEmision != nil {
+		dto.UltimaEmision = p.UltimaEmision.Format("2006-01-02")
+	}
+	return dto, nil
+}
+
+func mapRunToDTO(r db.RecurringRun, plantilla string) db.RecurringRunDTO {
+	return db.RecurringRunDTO{
+		ID:          r.ID,
+		RecurringID: r.RecurringID,
+		Plantilla:   plantilla,
+		Periodo:     r.Periodo.Format("2006-01-02"),
+		Fecha:       r.CreatedAt.Format("02/01/2006 15:04"),
+		Estado:      r.Estado,
+		ClaveAcceso: r.ClaveAcceso,
+		Secuencial:  r.Secuencial,
+		Total:       r.Total,
+		Mensaje:     r.Mensaje,
+	}
+}
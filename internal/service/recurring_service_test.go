@@ -0,0 +1,123 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+)
+
+func TestCalendarioRecurrente(t *testing.T) {
+	ini := time.Date(2026, 1, 15, 0, 0, 0, 0, time.Local)
+
+	if got := primeraEmision(ini, 20).Format("2006-01-02"); got != "2026-01-20" {
+		t.Errorf("Primera emisión esperada 2026-01-20, obtuve %s", got)
+	}
+	if got := primeraEmision(ini, 5).Format("2006-01-02"); got != "2026-02-05" {
+		t.Errorf("Primera emisión esperada 2026-02-05, obtuve %s", got)
+	}
+
+	// Día 31 se ajusta al último día de meses cortos, sin perder el día original
+	enero := time.Date(2026, 1, 31, 0, 0, 0, 0, time.Local)
+	feb := siguienteEmision(enero, 1, 31)
+	if got := feb.Format("2006-01-02"); got != "2026-02-28" {
+		t.Errorf("Esperaba 2026-02-28, obtuve %s", got)
+	}
+	if got := siguienteEmision(feb, 1, 31).Format("2006-01-02"); got != "2026-03-31" {
+		t.Errorf("Esperaba 2026-03-31, obtuve %s", got)
+	}
+	if got := siguienteEmision(enero, 3, 31).Format("2006-01-02"); got != "2026-04-30" {
+		t.Errorf("Trimestral: esperaba 2026-04-30, obtuve %s", got)
+	}
+}
+
+func TestRecurringService(t *testing.T) {
+	setupTestDB()
+	s := NewRecurringService(NewInvoiceService())
+
+	plantilla := db.RecurringInvoiceDTO{
+		Nombre: "Mantenimiento Mensual",
+		Factura: db.FacturaDTO{
+			ClienteID:     "0102030405",
+			ClienteNombre: "Cliente Contrato",
+			Items: []db.InvoiceItem{
+				{Codigo: "MANT", Nombre: "Mantenimiento", Cantidad: 1, Precio: 100, CodigoIVA: "4", PorcentajeIVA: 15},
+			},
+		},
+		Periodicidad: "MENSUAL",
+		DiaMes:       10,
+		FechaInicio:  "2026-01-01",
+		FechaFin:     "2026-03-31",
+		Activa:       true,
+	}
+
+	t.Run("Validaciones", func(t *testing.T) {
+		invalida := plantilla
+		invalida.Periodicidad = "SEMANAL"
+		if _, err := s.GuardarPlantilla(invalida); err == nil {
+			t.Error("Debería rechazar periodicidad desconocida")
+		}
+		invalida = plantilla
+		invalida.FechaFin = "2025-12-01"
+		if _, err := s.GuardarPlantilla(invalida); err == nil {
+			t.Error("Debería rechazar fecha de fin anterior al inicio")
+		}
+	})
+
+	saved, err := s.GuardarPlantilla(plantilla)
+	if err != nil {
+		t.Fatalf("Error guardando plantilla: %v", err)
+	}
+	if saved.ProximaEmision != "2026-01-10" {
+		t.Errorf("Próxima emisión esperada 2026-01-10, obtuve %s", saved.ProximaEmision)
+	}
+
+	t.Run("Previsualizar", func(t *testing.T) {
+		preview, err := s.PrevisualizarEjecucion(time.Date(2026, 12, 31, 0, 0, 0, 0, time.Local))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Solo 3 periodos dentro del contrato (ene, feb, mar)
+		if len(preview) != 3 {
+			t.Fatalf("Esperaba 3 emisiones previstas, obtuve %d", len(preview))
+		}
+		if preview[0].Total != 115 || preview[2].Fecha != "2026-03-10" {
+			t.Errorf("Previsualización inesperada: %+v", preview)
+		}
+	})
+
+	t.Run("Falla Registrada Sin Avanzar", func(t *testing.T) {
+		// Sin configuración de emisor la emisión falla
+		runs, err := s.EjecutarPendientes(time.Date(2026, 2, 15, 0, 0, 0, 0, time.Local), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 1 || runs[0].Estado != "ERROR" || runs[0].Mensaje == "" {
+			t.Fatalf("Esperaba una ejecución fallida registrada: %+v", runs)
+		}
+
+		list, _ := s.ListarPlantillas()
+		if list[0].ProximaEmision != "2026-01-10" {
+			t.Errorf("La plantilla no debió avanzar tras un fallo: %s", list[0].ProximaEmision)
+		}
+
+		log, _ := s.GetEjecuciones(saved.ID, 0)
+		if len(log) != 1 || log[0].Plantilla != "Mantenimiento Mensual" {
+			t.Errorf("Log de ejecuciones inesperado: %+v", log)
+		}
+	})
+
+	t.Run("Contrato Vencido Se Desactiva", func(t *testing.T) {
+		db.GetDB().Model(&db.RecurringInvoice{}).Where("id = ?", saved.ID).
+			UpdateColumn("proxima_emision", time.Date(2026, 4, 10, 0, 0, 0, 0, time.Local))
+
+		runs, _ := s.EjecutarPendientes(time.Date(2026, 5, 1, 0, 0, 0, 0, time.Local), nil)
+		if len(runs) != 0 {
+			t.Errorf("No debió emitir fuera del contrato: %+v", runs)
+		}
+		list, _ := s.ListarPlantillas()
+		if list[0].Activa {
+			t.Error("La plantilla vencida debió desactivarse")
+		}
+	})
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/logger"
+	"kushkiv2/pkg/util"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Valores por defecto de los parámetros de reposición.
+const (
+	ReposicionDiasHistorial = 30
+	ReposicionDiasCobertura = 15
+	ReposicionDiasEntrega   = 7
+)
+
+type ReorderService struct {
+	notificaciones *NotificationService
+}
+
+func NewReorderService(notificaciones *NotificationService) *ReorderService {
+	return &ReorderService{notificaciones: notificaciones}
+}
+
+// RevisarStockMinimo crea una notificación por cada producto con stock en o bajo su mínimo
+// (mismo criterio que el satélite) y resuelve las de los productos que ya se repusieron.
+// Devuelve solo las notificaciones nuevas.
+func (s *ReorderService) RevisarStockMinimo() ([]db.Notification, error) {
+	var products []db.Product
+	if err := db.GetDB().Where("min_stock > 0 AND es_kit = ? AND es_servicio = ?", false, false).Order("name").Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("error revisando stock mínimo: %v", err)
+	}
+	var pendientes []db.Notification
+	db.GetDB().Where("tipo = ? AND resuelta = ?", NotifStockBajo, false).Find(&pendientes)
+	conAviso := map[string]bool{}
+	for _, n := range pendientes {
+		conAviso[n.Referencia] = true
+	}
+
+	var nuevas []db.Notification
+	for _, p := range products {
+		if p.Stock > p.MinStock {
+			if conAviso[p.SKU] {
+				if err := s.notificaciones.Resolver(NotifStockBajo, p.SKU); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if conAviso[p.SKU] {
+			continue
+		}
+		mensaje := fmt.Sprintf("Stock bajo: %s (%s) tiene %g unidades, mínimo %g", p.Name, p.SKU, p.Stock, p.MinStock)
+		n, err := s.notificaciones.Notificar(NotifStockBajo, p.SKU, mensaje)
+		if err != nil {
+			return nil, err
+		}
+		if n != nil {
+			nuevas = append(nuevas, *n)
+		}
+	}
+	return nuevas, nil
+}
+
+// StartStockWatcher revisa el stock mínimo cada hora y avisa con notificar cuando hay alertas nuevas.
+func (s *ReorderService) StartStockWatcher(notificar func(mensaje string)) {
+	go func() {
+		for {
+			time.Sleep(1 * time.Minute) // Dar tiempo a que la app termine de iniciar
+			nuevas, err := s.RevisarStockMinimo()
+			if err != nil {
+				logger.Error("%v", err)
+			} else if len(nuevas) == 1 {
+				notificar(nuevas[0].Mensaje)
+			} else if len(nuevas) > 1 {
+				notificar(fmt.Sprintf("%d productos llegaron a su stock mínimo", len(nuevas)))
+			}
+			time.Sleep(59 * time.Minute)
+		}
+	}()
+}
+
+// GetSugerenciasReposicion calcula cuánto pedir de cada producto para cubrir el tiempo de entrega
+// del proveedor más los días de cobertura, según la venta diaria promedio del historial, y
+// terminar sobre el stock mínimo:
+//
+//	sugerido = venta diaria × (días de entrega + días de cobertura) + stock mínimo − stock actual
+//
+// El proveedor de cada producto es el de su última compra registrada.
+func (s *ReorderService) GetSugerenciasReposicion(params db.ReorderParamsDTO) ([]db.ReorderSuggestionDTO, error) {
+	params = normalizarParametrosReposicion(params)
+
+	desde := inicioDelDia(time.Now()).AddDate(0, 0, -params.DiasHistorial)
+	type venta struct {
+		ProductoSKU string
+		Cantidad    float64
+	}
+	var ventas []venta
+	err := db.GetDB().Table("factura_items").
+		Select("factura_items.producto_sku, SUM(factura_items.cantidad * factura_items.factor) as cantidad").
+		Joins("JOIN facturas ON facturas.clave_acceso = factura_items.factura_clave").
+		Where("facturas.fecha_emision >= ? AND facturas.estado_sri <> ?", desde, "ANULADO").
+		Group("factura_items.producto_sku").
+		Scan(&ventas).Error
+	if err != nil {
+		return nil, fmt.Errorf("error calculando ventas: %v", err)
+	}
+	vendido := map[string]float64{}
+	for _, v := range ventas {
+		vendido[v.ProductoSKU] += v.Cantidad
+		// Lo vendido en kits es demanda de sus componentes
+		for _, c := range componentesKit(db.GetDB(), v.ProductoSKU) {
+			vendido[c.ComponentSKU] += v.Cantidad * c.Cantidad
+		}
+	}
+
+	proveedorDe, err := ultimoProveedorPorProducto()
+	if err != nil {
+		return nil, err
+	}
+	var suppliers []db.Supplier
+	db.GetDB().Find(&suppliers)
+	proveedores := map[string]db.Supplier{}
+	for _, sup := range suppliers {
+		proveedores[sup.RUC] = sup
+	}
+
+	var products []db.Product
+	if err := db.GetDB().Where("es_kit = ? AND es_servicio = ?", false, false).Order("name").Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("error consultando productos: %v", err)
+	}
+
+	result := []db.ReorderSuggestionDTO{}
+	for _, p := range products {
+		ruc := proveedorDe[p.SKU]
+		if params.SupplierRUC != "" && ruc != params.SupplierRUC {
+			continue
+		}
+		if params.SoloBajoMinimo && p.Stock > p.MinStock {
+			continue
+		}
+
+		ventaDiaria := vendido[p.SKU] / float64(params.DiasHistorial)
+		entrega := proveedores[ruc].DiasEntrega
+		if entrega <= 0 {
+			entrega = params.DiasEntregaDefecto
+		}
+		necesidad := ventaDiaria*float64(entrega+params.DiasCobertura) + p.MinStock
+		sugerido := math.Ceil(redondearCantidad(necesidad - p.Stock))
+		if sugerido <= 0 {
+			continue
+		}
+
+		costo := p.LastCost
+		if costo == 0 {
+			costo = p.Cost
+		}
+		restantes := -1.0
+		if ventaDiaria > 0 {
+			restantes = util.Round(math.Max(p.Stock, 0)/ventaDiaria, 1)
+		}
+		result = append(result, db.ReorderSuggestionDTO{
+			SKU:            p.SKU,
+			Nombre:         p.Name,
+			Stock:          p.Stock,
+			MinStock:       p.MinStock,
+			VentaDiaria:    util.Round(ventaDiaria, 2),
+			DiasRestantes:  restantes,
+			DiasEntrega:    entrega,
+			Sugerido:       sugerido,
+			SupplierRUC:    ruc,
+			SupplierNombre: proveedores[ruc].RazonSocial,
+			UltimoCosto:    costo,
+			CostoEstimado:  util.Round(sugerido*costo, 2),
+		})
+	}
+
+	// Agrupado por proveedor y, dentro de cada uno, lo más urgente primero
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].SupplierNombre != result[j].SupplierNombre {
+			return result[i].SupplierNombre < result[j].SupplierNombre
+		}
+		if result[i].SupplierRUC != result[j].SupplierRUC {
+			return result[i].SupplierRUC < result[j].SupplierRUC
+		}
+		return urgencia(result[i]) < urgencia(result[j])
+	})
+	return result, nil
+}
+
+// GenerarOrdenCompraExcel exporta las sugerencias como borradores de orden de compra, una hoja por proveedor.
+func (s *ReorderService) GenerarOrdenCompraExcel(params db.ReorderParamsDTO) ([]byte, error) {
+	sugerencias, err := s.GetSugerenciasReposicion(params)
+	if err != nil {
+		return nil, err
+	}
+	params = normalizarParametrosReposicion(params)
+
+	f := excelize.NewFile()
+	defer f.Close()
+	bold, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+
+	var config db.EmisorConfig
+	db.GetDB().Limit(1).Find(&config)
+
+	if len(sugerencias) == 0 {
+		f.SetSheetName("Sheet1", "Orden de Compra")
+		f.SetCellValue("Orden de Compra", "A1", "No hay productos que reponer con los parámetros indicados")
+	}
+
+	primera := true
+	for i := 0; i < len(sugerencias); {
+		ruc := sugerencias[i].SupplierRUC
+		j := i
+		for j < len(sugerencias) && sugerencias[j].SupplierRUC == ruc {
+			j++
+		}
+		grupo := sugerencias[i:j]
+		i = j
+
+		sheet := ruc
+		proveedor := grupo[0].SupplierNombre
+		if ruc == "" {
+			sheet, proveedor = "Sin proveedor", "Sin proveedor asignado"
+		}
+		if primera {
+			f.SetSheetName("Sheet1", sheet)
+			primera = false
+		} else {
+			f.NewSheet(sheet)
+		}
+
+		f.SetCellValue(sheet, "A1", "ORDEN DE COMPRA (BORRADOR)")
+		f.SetCellStyle(sheet, "A1", "A1", bold)
+		f.SetCellValue(sheet, "A2", "Comprador: "+config.RazonSocial+" - RUC "+config.RUC)
+		f.SetCellValue(sheet, "A3", "Proveedor: "+proveedor)
+		if ruc != "" {
+			f.SetCellValue(sheet, "D3", "RUC: "+ruc)
+		}
+		f.SetCellValue(sheet, "A4", fmt.Sprintf("Fecha: %s - Cobertura: %d días - Historial: %d días",
+			time.Now().Format("2006-01-02"), params.DiasCobertura, params.DiasHistorial))
+
+		headers := []string{"SKU", "Producto", "Stock", "Mínimo", "Venta diaria", "Días restantes", "Cantidad", "Costo unit.", "Subtotal"}
+		for c, h := range headers {
+			cell, _ := excelize.CoordinatesToCellName(c+1, 6)
+			f.SetCellValue(sheet, cell, h)
+		}
+		f.SetRowStyle(sheet, 6, 6, bold)
+
+		row := 7
+		total := 0.0
+		for _, sg := range grupo {
+			f.SetCellValue(sheet, fmt.Sprintf("A%d", row), sg.SKU)
+			f.SetCellValue(sheet, fmt.Sprintf("B%d", row), sg.Nombre)
+			f.SetCellValue(sheet, fmt.Sprintf("C%d", row), sg.Stock)
+			f.SetCellValue(sheet, fmt.Sprintf("D%d", row), sg.MinStock)
+			f.SetCellValue(sheet, fmt.Sprintf("E%d", row), sg.VentaDiaria)
+			if sg.DiasRestantes >= 0 {
+				f.SetCellValue(sheet, fmt.Sprintf("F%d", row), sg.DiasRestantes)
+			}
+			f.SetCellValue(sheet, fmt.Sprintf("G%d", row), sg.Sugerido)
+			f.SetCellValue(sheet, fmt.Sprintf("H%d", row), sg.UltimoCosto)
+			f.SetCellValue(sheet, fmt.Sprintf("I%d", row), sg.CostoEstimado)
+			total += sg.CostoEstimado
+			row++
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), "Total (sin IVA)")
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), util.Round(total, 2))
+		f.SetRowStyle(sheet, row, row, bold)
+		f.SetColWidth(sheet, "A", "A", 16)
+		f.SetColWidth(sheet, "B", "B", 40)
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error generando orden de compra: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func normalizarParametrosReposicion(params db.ReorderParamsDTO) db.ReorderParamsDTO {
+	if params.DiasHistorial <= 0 {
+		params.DiasHistorial = ReposicionDiasHistorial
+	}
+	if params.DiasCobertura <= 0 {
+		params.DiasCobertura = ReposicionDiasCobertura
+	}
+	if params.DiasEntregaDefecto <= 0 {
+		params.DiasEntregaDefecto = ReposicionDiasEntrega
+	}
+	return params
+}
+
+// urgencia ordena por días restantes; los productos sin ventas (-1) van al final.
+func urgencia(s db.ReorderSuggestionDTO) float64 {
+	if s.DiasRestantes < 0 {
+		return math.MaxFloat64
+	}
+	return s.DiasRestantes
+}
+
+// ultimoProveedorPorProducto relaciona cada producto con el proveedor de su última compra vigente.
+func ultimoProveedorPorProducto() (map[string]string, error) {
+	type fila struct {
+		ProductoSKU string
+		SupplierRUC string
+	}
+	var filas []fila
+	err := db.GetDB().Table("purchase_items").
+		Select("purchase_items.producto_sku, purchases.supplier_ruc").
+		Joins("JOIN purchases ON purchases.id = purchase_items.purchase_id").
+		Where("purchases.estado <> ?", "ANULADA").
+		Order("purchases.fecha_emision asc, purchases.id asc").
+		Scan(&filas).Error
+	if err != nil {
+		return nil, fmt.Errorf("error consultando proveedores: %v", err)
+	}
+	result := map[string]string{}
+	for _, f := range filas {
+		result[f.ProductoSKU] = f.SupplierRUC
+	}
+	return result, nil
+}
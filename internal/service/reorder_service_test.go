@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+)
+
+func TestReorderService_StockMinimo(t *testing.T) {
+	database := setupTestDB()
+	notif := NewNotificationService()
+	svc := NewReorderService(notif)
+	database.Create(&db.Product{SKU: "A", Name: "Producto A", Barcode: "A", Stock: 3, MinStock: 5})
+	database.Create(&db.Product{SKU: "B", Name: "Producto B", Barcode: "B", Stock: 100})
+
+	nuevas, err := svc.RevisarStockMinimo()
+	if err != nil || len(nuevas) != 1 || nuevas[0].Referencia != "A" {
+		t.Fatalf("Se esperaba una alerta para A: %v %+v", err, nuevas)
+	}
+	if nuevas, _ := svc.RevisarStockMinimo(); len(nuevas) != 0 {
+		t.Error("Una alerta pendiente no debe repetirse")
+	}
+	if notif.ContarNoLeidas() != 1 {
+		t.Error("La alerta debería quedar en la lista de notificaciones")
+	}
+
+	// Al reponer se resuelve; si vuelve a bajar se avisa de nuevo
+	database.Model(&db.Product{}).Where("sku = ?", "A").Update("stock", 10)
+	svc.RevisarStockMinimo()
+	database.Model(&db.Product{}).Where("sku = ?", "A").Update("stock", 4)
+	if nuevas, _ := svc.RevisarStockMinimo(); len(nuevas) != 1 {
+		t.Error("Debería avisar otra vez tras reponer y volver a bajar")
+	}
+
+	if err := notif.MarcarLeida(0); err != nil || notif.ContarNoLeidas() != 0 {
+		t.Errorf("No se marcaron como leídas: %v", err)
+	}
+}
+
+func TestReorderService_Sugerencias(t *testing.T) {
+	database := setupTestDB()
+	svc := NewReorderService(NewNotificationService())
+	database.Create(&db.Product{SKU: "A", Name: "Producto A", Barcode: "A", Stock: 3, MinStock: 5})
+	database.Create(&db.Product{SKU: "C", Name: "Producto C", Barcode: "C", Stock: 10, MinStock: 2, LastCost: 1.5})
+	database.Create(&db.Supplier{RUC: "1790016919001", RazonSocial: "Distribuidora SA", DiasEntrega: 5})
+	compra := db.Purchase{SupplierRUC: "1790016919001", NumeroFactura: "001-001-000000001", Estado: "REGISTRADA", FechaEmision: time.Now()}
+	database.Create(&compra)
+	database.Create(&db.PurchaseItem{PurchaseID: compra.ID, ProductoSKU: "C", Cantidad: 10})
+
+	// 30 unidades vendidas en la ventana de 30 días: 1 por día
+	database.Create(&db.Factura{ClaveAcceso: "V1", FechaEmision: time.Now().AddDate(0, 0, -3), EstadoSRI: "AUTORIZADO"})
+	database.Create(&db.FacturaItem{FacturaClave: "V1", ProductoSKU: "C", Cantidad: 30})
+	database.Create(&db.Factura{ClaveAcceso: "V2", FechaEmision: time.Now().AddDate(0, 0, -2), EstadoSRI: "ANULADO"})
+	database.Create(&db.FacturaItem{FacturaClave: "V2", ProductoSKU: "C", Cantidad: 100})
+
+	list, err := svc.GetSugerenciasReposicion(db.ReorderParamsDTO{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Se esperaban 2 sugerencias, obtuve %+v", list)
+	}
+	porSKU := map[string]db.ReorderSuggestionDTO{}
+	for _, s := range list {
+		porSKU[s.SKU] = s
+	}
+	// C: 1/día × (5 entrega + 15 cobertura) + 2 mínimo − 10 en stock = 12
+	if c := porSKU["C"]; c.Sugerido != 12 || c.DiasEntrega != 5 || c.SupplierNombre != "Distribuidora SA" || c.CostoEstimado != 18 {
+		t.Errorf("Sugerencia de C incorrecta: %+v", c)
+	}
+	// A: sin ventas, solo completa el mínimo
+	if a := porSKU["A"]; a.Sugerido != 2 || a.DiasEntrega != ReposicionDiasEntrega || a.DiasRestantes != -1 {
+		t.Errorf("Sugerencia de A incorrecta: %+v", a)
+	}
+
+	if list, _ := svc.GetSugerenciasReposicion(db.ReorderParamsDTO{SupplierRUC: "1790016919001"}); len(list) != 1 {
+		t.Errorf("El filtro por proveedor debería dejar solo C: %+v", list)
+	}
+	if _, err := svc.GenerarOrdenCompraExcel(db.ReorderParamsDTO{}); err != nil {
+		t.Error(err)
+	}
+}
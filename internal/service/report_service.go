@@ -3,6 +3,8 @@ package service
 import (
 	"fmt"
 	"kushkiv2/internal/db"
+	"kushkiv2/pkg/util"
+	"sort"
 	"time"
 
 	"github.com/xuri/excelize/v2"
@@ -42,13 +44,22 @@ func (s *ReportService) GenerateSalesExcel(startDate, endDate time.Time) ([]byte
 	})
 
 	// Encabezados
-	headers := []string{"Fecha", "Secuencial", "Clave de Acceso", "Cliente ID", "Subtotal 15%", "Subtotal 0%", "IVA", "Total", "Estado"}
+	headers := []string{"Fecha", "Secuencial", "Clave de Acceso", "Cliente ID", "Subtotal 15%", "Subtotal 0%", "IVA", "Total", "Estado", "Costo", "Margen", "Margen %", "Venta Bienes", "Venta Servicios"}
 	for i, h := range headers {
 		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		f.SetCellValue(sheet, cell, h)
 		f.SetCellStyle(sheet, cell, cell, headerStyle)
 	}
 
+	margenes, err := s.GetMargins(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	margenPorFactura := map[string]MargenFactura{}
+	for _, m := range margenes.Facturas {
+		margenPorFactura[m.ClaveAcceso] = m
+	}
+
 	// Datos
 	for i, fact := range facturas {
 		row := i + 2
@@ -61,7 +72,45 @@ func (s *ReportService) GenerateSalesExcel(startDate, endDate time.Time) ([]byte
 		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), fact.IVA)
 		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), fact.Total)
 		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), fact.EstadoSRI)
+		if m, ok := margenPorFactura[fact.ClaveAcceso]; ok {
+			f.SetCellValue(sheet, fmt.Sprintf("J%d", row), m.Costo)
+			f.SetCellValue(sheet, fmt.Sprintf("K%d", row), m.Margen)
+			f.SetCellValue(sheet, fmt.Sprintf("L%d", row), m.MargenPct)
+			f.SetCellValue(sheet, fmt.Sprintf("M%d", row), m.Bienes)
+			f.SetCellValue(sheet, fmt.Sprintf("N%d", row), m.Servicios)
+		}
+	}
+
+	// Hoja de márgenes por producto
+	sheetMargen := "Margen por Producto"
+	f.NewSheet(sheetMargen)
+	headersM := []string{"SKU", "Producto", "Cantidad", "Venta", "Costo", "Margen", "Margen %"}
+	for i, h := range headersM {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheetMargen, cell, h)
+		f.SetCellStyle(sheetMargen, cell, cell, headerStyle)
+	}
+	for i, m := range margenes.Productos {
+		row := i + 2
+		f.SetCellValue(sheetMargen, fmt.Sprintf("A%d", row), m.SKU)
+		f.SetCellValue(sheetMargen, fmt.Sprintf("B%d", row), m.Nombre)
+		f.SetCellValue(sheetMargen, fmt.Sprintf("C%d", row), m.Cantidad)
+		f.SetCellValue(sheetMargen, fmt.Sprintf("D%d", row), m.Venta)
+		f.SetCellValue(sheetMargen, fmt.Sprintf("E%d", row), m.Costo)
+		f.SetCellValue(sheetMargen, fmt.Sprintf("F%d", row), m.Margen)
+		f.SetCellValue(sheetMargen, fmt.Sprintf("G%d", row), m.MargenPct)
 	}
+	totalRow := len(margenes.Productos) + 2
+	f.SetCellValue(sheetMargen, fmt.Sprintf("A%d", totalRow), "TOTAL")
+	f.SetCellValue(sheetMargen, fmt.Sprintf("D%d", totalRow), margenes.Venta)
+	f.SetCellValue(sheetMargen, fmt.Sprintf("E%d", totalRow), margenes.Costo)
+	f.SetCellValue(sheetMargen, fmt.Sprintf("F%d", totalRow), margenes.Margen)
+	f.SetCellValue(sheetMargen, fmt.Sprintf("G%d", totalRow), margenes.MargenPct)
+
+	// Hojas de márgenes por tipo (bienes y servicios), categoría y marca
+	escribirMargenGrupos(f, "Ventas por Tipo", "Tipo", margenes.Tipos, headerStyle)
+	escribirMargenGrupos(f, "Margen por Categoría", "Categoría", margenes.Categorias, headerStyle)
+	escribirMargenGrupos(f, "Margen por Marca", "Marca", margenes.Marcas, headerStyle)
 
 	f.SetActiveSheet(index)
 	
@@ -74,6 +123,25 @@ func (s *ReportService) GenerateSalesExcel(startDate, endDate time.Time) ([]byte
 	return buf.Bytes(), nil
 }
 
+func escribirMargenGrupos(f *excelize.File, sheet, titulo string, grupos []MargenGrupo, headerStyle int) {
+	f.NewSheet(sheet)
+	headers := []string{titulo, "Cantidad", "Venta", "Costo", "Margen", "Margen %"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+	for i, m := range grupos {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), m.Grupo)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), m.Cantidad)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), m.Venta)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), m.Costo)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), m.Margen)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), m.MargenPct)
+	}
+}
+
 // GenerateMasterReportExcel genera un reporte consolidado de todo el sistema.
 func (s *ReportService) GenerateMasterReportExcel() ([]byte, error) {
 	f := excelize.NewFile()
@@ -113,6 +181,13 @@ func (s *ReportService) GenerateMasterReportExcel() ([]byte, error) {
 	f.SetCellValue(sheetRes, "A6", "Fecha de Generación")
 	f.SetCellValue(sheetRes, "B6", time.Now().Format("02/01/2006 15:04"))
 
+	if margenes, err := s.GetMargins(time.Time{}, time.Now()); err == nil {
+		f.SetCellValue(sheetRes, "A7", "Margen Bruto Histórico")
+		f.SetCellValue(sheetRes, "B7", margenes.Margen)
+		f.SetCellValue(sheetRes, "A8", "Margen Bruto %")
+		f.SetCellValue(sheetRes, "B8", margenes.MargenPct)
+	}
+
 	// 1. HOJA DE VENTAS (HISTORIAL)
 	sheetVentas := "Historial Ventas"
 	f.NewSheet(sheetVentas)
@@ -160,7 +235,7 @@ func (s *ReportService) GenerateMasterReportExcel() ([]byte, error) {
 	// 3. HOJA DE PRODUCTOS (INVENTARIO)
 	sheetProductos := "Inventario"
 	f.NewSheet(sheetProductos)
-	headersP := []string{"SKU", "Nombre", "Precio", "Stock", "% IVA"}
+	headersP := []string{"SKU", "Nombre", "Precio", "Stock", "% IVA", "Costo Promedio", "Último Costo", "Valor Inventario", "Categoría", "Marca"}
 	for i, h := range headersP {
 		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		f.SetCellValue(sheetProductos, cell, h)
@@ -168,7 +243,8 @@ func (s *ReportService) GenerateMasterReportExcel() ([]byte, error) {
 	}
 
 	var products []db.Product
-	db.GetDB().Find(&products)
+	db.GetDB().Where("es_servicio = ?", false).Find(&products)
+	rutas, marcas := rutasCategorias(db.GetDB()), nombresMarcas(db.GetDB())
 	for i, p := range products {
 		row := i + 2
 		f.SetCellValue(sheetProductos, fmt.Sprintf("A%d", row), p.SKU)
@@ -176,6 +252,37 @@ func (s *ReportService) GenerateMasterReportExcel() ([]byte, error) {
 		f.SetCellValue(sheetProductos, fmt.Sprintf("C%d", row), p.Price)
 		f.SetCellValue(sheetProductos, fmt.Sprintf("D%d", row), p.Stock)
 		f.SetCellValue(sheetProductos, fmt.Sprintf("E%d", row), p.TaxPercentage)
+		f.SetCellValue(sheetProductos, fmt.Sprintf("F%d", row), p.Cost)
+		f.SetCellValue(sheetProductos, fmt.Sprintf("G%d", row), p.LastCost)
+		f.SetCellValue(sheetProductos, fmt.Sprintf("H%d", row), util.Round(float64(p.Stock)*p.Cost, 2))
+		f.SetCellValue(sheetProductos, fmt.Sprintf("I%d", row), nombreGrupo(rutas, p.CategoryID, SinCategoria))
+		f.SetCellValue(sheetProductos, fmt.Sprintf("J%d", row), nombreGrupo(marcas, p.BrandID, SinMarca))
+	}
+
+	// 4. HOJA DE INVENTARIO POR CATEGORÍA Y MARCA
+	if stock, err := s.GetStockByClassification(); err == nil {
+		sheetClasif := "Inventario por Clasificación"
+		f.NewSheet(sheetClasif)
+		headersS := []string{"Tipo", "Grupo", "Productos", "Unidades", "Valor Inventario"}
+		for i, h := range headersS {
+			cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+			f.SetCellValue(sheetClasif, cell, h)
+			f.SetCellStyle(sheetClasif, cell, cell, headerStyle)
+		}
+		row := 2
+		for _, bloque := range []struct {
+			tipo   string
+			grupos []StockGrupo
+		}{{"Categoría", stock.Categorias}, {"Marca", stock.Marcas}} {
+			for _, g := range bloque.grupos {
+				f.SetCellValue(sheetClasif, fmt.Sprintf("A%d", row), bloque.tipo)
+				f.SetCellValue(sheetClasif, fmt.Sprintf("B%d", row), g.Grupo)
+				f.SetCellValue(sheetClasif, fmt.Sprintf("C%d", row), g.Productos)
+				f.SetCellValue(sheetClasif, fmt.Sprintf("D%d", row), g.Unidades)
+				f.SetCellValue(sheetClasif, fmt.Sprintf("E%d", row), g.Valor)
+				row++
+			}
+		}
 	}
 
 	buf, err := f.WriteToBuffer()
@@ -208,3 +315,314 @@ func (s *ReportService) GetTopProducts(limit int) ([]TopProduct, error) {
 
 	return results, err
 }
+
+// TopGrupo son las ventas acumuladas de una categoría o marca.
+type TopGrupo struct {
+	Grupo    string  `json:"grupo"`
+	Quantity float64 `json:"quantity"`
+	Total    float64 `json:"total"`
+}
+
+// GetTopCategories obtiene las categorías más vendidas según la clasificación actual de cada producto.
+func (s *ReportService) GetTopCategories(limit int) ([]TopGrupo, error) {
+	return s.topPorClasificacion("category_id", rutasCategorias(db.GetDB()), SinCategoria, limit)
+}
+
+// GetTopBrands obtiene las marcas más vendidas.
+func (s *ReportService) GetTopBrands(limit int) ([]TopGrupo, error) {
+	return s.topPorClasificacion("brand_id", nombresMarcas(db.GetDB()), SinMarca, limit)
+}
+
+func (s *ReportService) topPorClasificacion(columna string, nombres map[uint]string, sinGrupo string, limit int) ([]TopGrupo, error) {
+	type fila struct {
+		ID       uint
+		Quantity float64
+		Total    float64
+	}
+	var filas []fila
+	err := db.GetDB().Table("factura_items").
+		Select("COALESCE(products." + columna + ", 0) as id, SUM(factura_items.cantidad) as quantity, SUM(factura_items.subtotal) as total").
+		Joins("JOIN facturas ON facturas.clave_acceso = factura_items.factura_clave").
+		Joins("LEFT JOIN products ON products.sku = factura_items.producto_sku").
+		Where("facturas.total > 0").
+		Group("id").
+		Order("total DESC").
+		Limit(limit).
+		Scan(&filas).Error
+	if err != nil {
+		return nil, err
+	}
+	results := make([]TopGrupo, 0, len(filas))
+	for _, f := range filas {
+		results = append(results, TopGrupo{Grupo: nombreGrupo(nombres, f.ID, sinGrupo), Quantity: f.Quantity, Total: util.Round(f.Total, 2)})
+	}
+	return results, nil
+}
+
+// StockGrupo es el inventario valorizado (stock x costo promedio) de una categoría o marca.
+type StockGrupo struct {
+	Grupo     string  `json:"grupo"`
+	Productos int     `json:"productos"`
+	Unidades  float64 `json:"unidades"`
+	Valor     float64 `json:"valor"`
+}
+
+// ReporteStockClasificacion agrupa el inventario actual por categoría y por marca.
+type ReporteStockClasificacion struct {
+	Categorias []StockGrupo `json:"categorias"`
+	Marcas     []StockGrupo `json:"marcas"`
+	Valor      float64      `json:"valor"`
+}
+
+// GetStockByClassification valoriza el stock actual por categoría y marca, de mayor a menor valor.
+// Los servicios no forman parte del inventario.
+func (s *ReportService) GetStockByClassification() (*ReporteStockClasificacion, error) {
+	var products []db.Product
+	if err := db.GetDB().Where("es_servicio = ?", false).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("error cargando inventario: %v", err)
+	}
+	rutas, marcas := rutasCategorias(db.GetDB()), nombresMarcas(db.GetDB())
+	reporte := &ReporteStockClasificacion{Categorias: []StockGrupo{}, Marcas: []StockGrupo{}}
+	idxCategoria, idxMarca := map[string]int{}, map[string]int{}
+	acumular := func(lista *[]StockGrupo, idx map[string]int, grupo string, p db.Product) {
+		i, ok := idx[grupo]
+		if !ok {
+			*lista = append(*lista, StockGrupo{Grupo: grupo})
+			i = len(*lista) - 1
+			idx[grupo] = i
+		}
+		(*lista)[i].Productos++
+		(*lista)[i].Unidades += p.Stock
+		(*lista)[i].Valor += p.Stock * p.Cost
+	}
+	for _, p := range products {
+		acumular(&reporte.Categorias, idxCategoria, nombreGrupo(rutas, p.CategoryID, SinCategoria), p)
+		acumular(&reporte.Marcas, idxMarca, nombreGrupo(marcas, p.BrandID, SinMarca), p)
+		reporte.Valor += p.Stock * p.Cost
+	}
+	for _, lista := range [][]StockGrupo{reporte.Categorias, reporte.Marcas} {
+		for i := range lista {
+			lista[i].Unidades = redondearCantidad(lista[i].Unidades)
+			lista[i].Valor = util.Round(lista[i].Valor, 2)
+		}
+		sort.Slice(lista, func(a, b int) bool { return lista[a].Valor > lista[b].Valor })
+	}
+	reporte.Valor = util.Round(reporte.Valor, 2)
+	return reporte, nil
+}
+
+func nombreGrupo(nombres map[uint]string, id uint, sinGrupo string) string {
+	if nombre, ok := nombres[id]; ok {
+		return nombre
+	}
+	return sinGrupo
+}
+
+// MargenFactura es la utilidad bruta de una factura (ventas sin IVA menos costo de lo vendido).
+type MargenFactura struct {
+	ClaveAcceso string  `json:"claveAcceso"`
+	Secuencial  string  `json:"secuencial"`
+	Fecha       string  `json:"fecha"`
+	ClienteID   string  `json:"clienteId"`
+	Venta       float64 `json:"venta"`
+	Costo       float64 `json:"costo"`
+	Margen      float64 `json:"margen"`
+	MargenPct   float64 `json:"margenPct"`
+	Bienes      float64 `json:"bienes"`    // Venta de productos con inventario
+	Servicios   float64 `json:"servicios"` // Venta de servicios y códigos fuera del catálogo
+}
+
+// MargenProducto es la utilidad bruta acumulada de un producto en el periodo.
+type MargenProducto struct {
+	SKU       string  `json:"sku"`
+	Nombre    string  `json:"nombre"`
+	Cantidad  float64 `json:"cantidad"`
+	Venta     float64 `json:"venta"`
+	Costo     float64 `json:"costo"`
+	Margen    float64 `json:"margen"`
+	MargenPct float64 `json:"margenPct"`
+}
+
+// MargenPeriodo es la utilidad bruta de un mes (YYYY-MM).
+type MargenPeriodo struct {
+	Periodo   string  `json:"periodo"`
+	Venta     float64 `json:"venta"`
+	Costo     float64 `json:"costo"`
+	Margen    float64 `json:"margen"`
+	MargenPct float64 `json:"margenPct"`
+}
+
+// Grupos del reporte de ventas por tipo de ítem
+const (
+	GrupoBienes    = "Bienes"
+	GrupoServicios = "Servicios"
+)
+
+// MargenGrupo es la utilidad bruta de un tipo de ítem, categoría o marca.
+type MargenGrupo struct {
+	Grupo     string  `json:"grupo"`
+	Cantidad  float64 `json:"cantidad"`
+	Venta     float64 `json:"venta"`
+	Costo     float64 `json:"costo"`
+	Margen    float64 `json:"margen"`
+	MargenPct float64 `json:"margenPct"`
+}
+
+// ReporteMargenes agrupa la utilidad bruta por factura, producto, tipo, categoría, marca y mes.
+type ReporteMargenes struct {
+	Facturas   []MargenFactura  `json:"facturas"`
+	Productos  []MargenProducto `json:"productos"`
+	Tipos      []MargenGrupo    `json:"tipos"` // Bienes y servicios por separado
+	Categorias []MargenGrupo    `json:"categorias"`
+	Marcas     []MargenGrupo    `json:"marcas"`
+	Periodos   []MargenPeriodo  `json:"periodos"`
+	Venta      float64          `json:"venta"`
+	Costo      float64          `json:"costo"`
+	Margen     float64          `json:"margen"`
+	MargenPct  float64          `json:"margenPct"`
+}
+
+// GetMargins calcula la utilidad bruta del rango con el costo guardado en cada ítem al vender.
+// Las facturas anuladas no se consideran. Categoría, marca y tipo son los actuales del producto; los
+// códigos que no están en el catálogo se cuentan como servicios.
+func (s *ReportService) GetMargins(startDate, endDate time.Time) (*ReporteMargenes, error) {
+	type fila struct {
+		ClaveAcceso  string
+		Secuencial   string
+		FechaEmision time.Time
+		ClienteID    string
+		ProductoSKU  string
+		Nombre       string
+		Cantidad     float64
+		Subtotal     float64
+		Costo        float64
+		CategoryID   uint
+		BrandID      uint
+		EsServicio   bool
+	}
+	var filas []fila
+	err := db.GetDB().Table("factura_items").
+		Select("facturas.clave_acceso, facturas.secuencial, facturas.fecha_emision, facturas.cliente_id, "+
+			"factura_items.producto_sku, factura_items.nombre, factura_items.cantidad, factura_items.subtotal, "+
+			"factura_items.cantidad * factura_items.costo_unitario as costo, "+
+			"COALESCE(products.category_id, 0) as category_id, COALESCE(products.brand_id, 0) as brand_id, "+
+			"COALESCE(products.es_servicio, 1) as es_servicio").
+		Joins("JOIN facturas ON facturas.clave_acceso = factura_items.factura_clave").
+		Joins("LEFT JOIN products ON products.sku = factura_items.producto_sku").
+		Where("facturas.fecha_emision BETWEEN ? AND ? AND facturas.estado_sri <> ?", startDate, endDate, "ANULADO").
+		Order("facturas.fecha_emision asc").
+		Scan(&filas).Error
+	if err != nil {
+		return nil, fmt.Errorf("error calculando márgenes: %v", err)
+	}
+
+	reporte := &ReporteMargenes{Facturas: []MargenFactura{}, Productos: []MargenProducto{}, Tipos: []MargenGrupo{}, Categorias: []MargenGrupo{}, Marcas: []MargenGrupo{}, Periodos: []MargenPeriodo{}}
+	idxFactura := map[string]int{}
+	idxProducto := map[string]int{}
+	idxPeriodo := map[string]int{}
+	idxTipo, idxCategoria, idxMarca := map[string]int{}, map[string]int{}, map[string]int{}
+	rutas, marcas := rutasCategorias(db.GetDB()), nombresMarcas(db.GetDB())
+	acumular := func(lista *[]MargenGrupo, idx map[string]int, grupo string, cantidad, venta, costo float64) {
+		i, ok := idx[grupo]
+		if !ok {
+			*lista = append(*lista, MargenGrupo{Grupo: grupo})
+			i = len(*lista) - 1
+			idx[grupo] = i
+		}
+		(*lista)[i].Cantidad += cantidad
+		(*lista)[i].Venta += venta
+		(*lista)[i].Costo += costo
+	}
+
+	for _, f := range filas {
+		i, ok := idxFactura[f.ClaveAcceso]
+		if !ok {
+			reporte.Facturas = append(reporte.Facturas, MargenFactura{
+				ClaveAcceso: f.ClaveAcceso,
+				Secuencial:  f.Secuencial,
+				Fecha:       f.FechaEmision.Format("2006-01-02"),
+				ClienteID:   f.ClienteID,
+			})
+			i = len(reporte.Facturas) - 1
+			idxFactura[f.ClaveAcceso] = i
+		}
+		reporte.Facturas[i].Venta += f.Subtotal
+		reporte.Facturas[i].Costo += f.Costo
+		tipo := GrupoBienes
+		if f.EsServicio {
+			tipo = GrupoServicios
+			reporte.Facturas[i].Servicios += f.Subtotal
+		} else {
+			reporte.Facturas[i].Bienes += f.Subtotal
+		}
+
+		j, ok := idxProducto[f.ProductoSKU]
+		if !ok {
+			reporte.Productos = append(reporte.Productos, MargenProducto{SKU: f.ProductoSKU, Nombre: f.Nombre})
+			j = len(reporte.Productos) - 1
+			idxProducto[f.ProductoSKU] = j
+		}
+		reporte.Productos[j].Cantidad += f.Cantidad
+		reporte.Productos[j].Venta += f.Subtotal
+		reporte.Productos[j].Costo += f.Costo
+
+		acumular(&reporte.Tipos, idxTipo, tipo, f.Cantidad, f.Subtotal, f.Costo)
+		acumular(&reporte.Categorias, idxCategoria, nombreGrupo(rutas, f.CategoryID, SinCategoria), f.Cantidad, f.Subtotal, f.Costo)
+		acumular(&reporte.Marcas, idxMarca, nombreGrupo(marcas, f.BrandID, SinMarca), f.Cantidad, f.Subtotal, f.Costo)
+
+		periodo := f.FechaEmision.Format("2006-01")
+		k, ok := idxPeriodo[periodo]
+		if !ok {
+			reporte.Periodos = append(reporte.Periodos, MargenPeriodo{Periodo: periodo})
+			k = len(reporte.Periodos) - 1
+			idxPeriodo[periodo] = k
+		}
+		reporte.Periodos[k].Venta += f.Subtotal
+		reporte.Periodos[k].Costo += f.Costo
+
+		reporte.Venta += f.Subtotal
+		reporte.Costo += f.Costo
+	}
+
+	for i := range reporte.Facturas {
+		m := &reporte.Facturas[i]
+		m.Venta, m.Costo = util.Round(m.Venta, 2), util.Round(m.Costo, 2)
+		m.Margen, m.MargenPct = margen(m.Venta, m.Costo)
+		m.Bienes, m.Servicios = util.Round(m.Bienes, 2), util.Round(m.Servicios, 2)
+	}
+	for i := range reporte.Productos {
+		m := &reporte.Productos[i]
+		m.Venta, m.Costo = util.Round(m.Venta, 2), util.Round(m.Costo, 2)
+		m.Margen, m.MargenPct = margen(m.Venta, m.Costo)
+	}
+	for i := range reporte.Periodos {
+		m := &reporte.Periodos[i]
+		m.Venta, m.Costo = util.Round(m.Venta, 2), util.Round(m.Costo, 2)
+		m.Margen, m.MargenPct = margen(m.Venta, m.Costo)
+	}
+	for _, lista := range [][]MargenGrupo{reporte.Tipos, reporte.Categorias, reporte.Marcas} {
+		for i := range lista {
+			m := &lista[i]
+			m.Cantidad = redondearCantidad(m.Cantidad)
+			m.Venta, m.Costo = util.Round(m.Venta, 2), util.Round(m.Costo, 2)
+			m.Margen, m.MargenPct = margen(m.Venta, m.Costo)
+		}
+		sort.Slice(lista, func(a, b int) bool { return lista[a].Venta > lista[b].Venta })
+	}
+	sort.Slice(reporte.Productos, func(a, b int) bool {
+		return reporte.Productos[a].Margen > reporte.Productos[b].Margen
+	})
+
+	reporte.Venta, reporte.Costo = util.Round(reporte.Venta, 2), util.Round(reporte.Costo, 2)
+	reporte.Margen, reporte.MargenPct = margen(reporte.Venta, reporte.Costo)
+	return reporte, nil
+}
+
+// margen devuelve la utilidad bruta y su porcentaje sobre la venta.
+func margen(venta, costo float64) (float64, float64) {
+	m := util.Round(venta-costo, 2)
+	if venta == 0 {
+		return m, 0
+	}
+	return m, util.Round(m/venta*100, 2)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+)
+
+func TestGetMargins(t *testing.T) {
+	database := setupTestDB()
+	svc := NewReportService()
+	now := time.Now()
+
+	database.Create(&db.Factura{ClaveAcceso: "F1", Secuencial: "000000001", FechaEmision: now, EstadoSRI: "AUTORIZADO", Total: 115})
+	database.Create(&db.Factura{ClaveAcceso: "F2", Secuencial: "000000002", FechaEmision: now, EstadoSRI: "ANULADO", Total: 50})
+	database.Create(&db.FacturaItem{FacturaClave: "F1", ProductoSKU: "A", Nombre: "Prod A", Cantidad: 2, Subtotal: 60, CostoUnitario: 20})
+	database.Create(&db.FacturaItem{FacturaClave: "F1", ProductoSKU: "B", Nombre: "Prod B", Cantidad: 1, Subtotal: 40, CostoUnitario: 10})
+	database.Create(&db.FacturaItem{FacturaClave: "F2", ProductoSKU: "A", Nombre: "Prod A", Cantidad: 1, Subtotal: 30, CostoUnitario: 20})
+
+	reporte, err := svc.GetMargins(now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reporte.Venta != 100 || reporte.Costo != 50 || reporte.Margen != 50 || reporte.MargenPct != 50 {
+		t.Errorf("Totales incorrectos (la anulada no cuenta): %+v", reporte)
+	}
+	if len(reporte.Facturas) != 1 || len(reporte.Periodos) != 1 {
+		t.Errorf("Agrupación incorrecta: %+v", reporte)
+	}
+	// Ordenado por margen: B (30) antes que A (20)
+	if len(reporte.Productos) != 2 || reporte.Productos[0].SKU != "B" || reporte.Productos[0].MargenPct != 75 {
+		t.Errorf("Margen por producto incorrecto: %+v", reporte.Productos)
+	}
+
+	if _, err := svc.GenerateSalesExcel(now.Add(-time.Hour), now.Add(time.Hour)); err != nil {
+		t.Errorf("Error generando Excel con márgenes: %v", err)
+	}
+}
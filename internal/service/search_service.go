@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 	"kushkiv2/internal/db"
+	"strings"
 
 	"github.com/sahilm/fuzzy"
 )
@@ -169,19 +170,35 @@ type ProductSource []ProductSearchItem
 func (s ProductSource) String(i int) string { return s[i].SearchContent }
 func (s ProductSource) Len() int           { return len(s) }
 
-func (s *SearchService) FuzzySearchProducts(query string) ([]db.ProductDTO, error) {
+// FuzzySearchProducts busca productos por texto dentro de la categoría, marca y etiquetas del filtro.
+func (s *SearchService) FuzzySearchProducts(query string, filtro db.ProductFilterDTO) ([]db.ProductDTO, error) {
 	var products []db.Product
-	err := db.GetDB().Limit(2000).Find(&products).Error
+	q := db.GetDB().Model(&db.Product{})
+	if filtro.CategoryID != 0 {
+		q = q.Where("category_id IN ?", subcategorias(db.GetDB(), filtro.CategoryID))
+	}
+	if filtro.BrandID != 0 {
+		q = q.Where("brand_id = ?", filtro.BrandID)
+	}
+	if tags := normalizarTags(filtro.Tags); len(tags) > 0 {
+		conTags := db.GetDB().Model(&db.ProductTag{}).Select("product_sku").
+			Where("tag IN ?", tags).Group("product_sku").Having("COUNT(DISTINCT tag) = ?", len(tags))
+		q = q.Where("sku IN (?)", conTags)
+	}
+	err := q.Limit(2000).Find(&products).Error
 	if err != nil {
 		return nil, err
 	}
 
+	rutas, marcas := rutasCategorias(db.GetDB()), nombresMarcas(db.GetDB())
+	tags := NewCatalogService().TagsPorProducto()
 	source := make(ProductSource, len(products))
 	productMap := make(map[string]db.Product)
 
 	for i, p := range products {
-		// Búsqueda por Nombre, SKU, Precio, Código de Barras y Código Auxiliar
-		searchStr := fmt.Sprintf("%s %s %s %s %.2f", p.Name, p.SKU, p.Barcode, p.AuxiliaryCode, p.Price)
+		// Búsqueda por Nombre, SKU, Precio, Código de Barras, Código Auxiliar y clasificación
+		searchStr := fmt.Sprintf("%s %s %s %s %.2f %s %s %s", p.Name, p.SKU, p.Barcode, p.AuxiliaryCode, p.Price,
+			rutas[p.CategoryID], marcas[p.BrandID], strings.Join(tags[p.SKU], " "))
 		source[i] = ProductSearchItem{
 			SKU:           p.SKU,
 			SearchContent: searchStr,
@@ -221,9 +238,25 @@ func (s *SearchService) mapProductsToDTO(products []db.Product) []db.ProductDTO
 			Barcode:       p.Barcode,
 			AuxiliaryCode: p.AuxiliaryCode,
 			MinStock:      p.MinStock,
+			Cost:          p.Cost,
+			LastCost:      p.LastCost,
 			ExpiryDate:    expiryStr,
 			Location:      p.Location,
+			UnidadMedida:  p.UnidadMedida,
+			CategoryID:    p.CategoryID,
+			BrandID:       p.BrandID,
+			EsKit:         p.EsKit,
+			DetallarKit:   p.DetallarKit,
+			EsServicio:    p.EsServicio,
+			TieneImagen:   p.Imagen != "",
 		})
 	}
+	NewCatalogService().Clasificar(dtos)
+	disponibles := NewKitService().DisponibilidadKits()
+	for i := range dtos {
+		if dtos[i].EsKit {
+			dtos[i].Stock = disponibles[dtos[i].SKU]
+		}
+	}
 	return dtos
 }
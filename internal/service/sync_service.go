@@ -171,4 +171,5 @@ func (s *SyncService) processSingleInvoice(f *db.Factura) {
 
 	// Guardar nuevo estado (GORM es thread-safe con pool configurado)
 	db.GetDB().Save(f)
+	revertirVentaRechazada(f)
 }
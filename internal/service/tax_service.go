@@ -11,6 +11,12 @@ type TaxSummary struct {
 	Ventas15          float64 `json:"ventas15"`          // Campo 401
 	Ventas0           float64 `json:"ventas0"`           // Campo 403
 	IvaGenerado       float64 `json:"ivaGenerado"`       // Campo 411
+	Compras15         float64 `json:"compras15"`         // Campo 500
+	Compras5          float64 `json:"compras5"`          // Tarifa 5%
+	Compras8          float64 `json:"compras8"`          // Tarifa 8%
+	Compras0          float64 `json:"compras0"`          // Campo 507
+	IvaCompras        float64 `json:"ivaCompras"`        // Campo 520
+	CreditoTributario float64 `json:"creditoTributario"` // Campo 564
 	RetencionesIva    float64 `json:"retencionesIva"`    // Campo 609
 	FactorProporcion  float64 `json:"factorProporcion"`  // Campo 702
 	ImpuestoSugerido  float64 `json:"impuestoSugerido"`
@@ -48,14 +54,32 @@ func (s *TaxService) GetVATSummary(startDate, endDate time.Time) TaxSummary {
 		summary.FactorProporcion = 1.0
 	}
 
-	// 3. Obtener Retenciones Recibidas
+	// 3. Compras del periodo (IVA en compras, casilleros 500)
+	var compras []db.Purchase
+	db.GetDB().Where("fecha_emision >= ? AND fecha_emision <= ? AND estado <> ?", startDate, endDate, "ANULADA").Find(&compras)
+	for _, c := range compras {
+		summary.Compras15 += c.Subtotal15
+		summary.Compras5 += c.Subtotal5
+		summary.Compras8 += c.Subtotal8
+		summary.Compras0 += c.Subtotal0
+		summary.IvaCompras += c.IVA
+	}
+	summary.Compras15 = util.Round(summary.Compras15, 2)
+	summary.Compras5 = util.Round(summary.Compras5, 2)
+	summary.Compras8 = util.Round(summary.Compras8, 2)
+	summary.Compras0 = util.Round(summary.Compras0, 2)
+	summary.IvaCompras = util.Round(summary.IvaCompras, 2)
+
+	// Crédito tributario aplicable: IVA en compras por el factor de proporcionalidad
+	summary.CreditoTributario = util.Round(summary.IvaCompras*summary.FactorProporcion, 2)
+
+	// 4. Obtener Retenciones Recibidas
 	db.GetDB().Model(&db.RetencionRecibida{}).
 		Where("fecha_emision >= ? AND fecha_emision <= ? AND tipo = ?", startDate, endDate, "IVA").
 		Select("COALESCE(SUM(valor_retenido), 0)").Scan(&summary.RetencionesIva)
 
-	// 4. Calcular Impuesto sugerido (IVA Cobrado - Retenciones)
-	// Nota: No tenemos el IVA de compras (casilleros 500) aún, así que restamos retenciones del generado.
-	res := summary.IvaGenerado - summary.RetencionesIva
+	// 5. Calcular Impuesto sugerido (IVA Cobrado - Crédito Tributario - Retenciones)
+	res := summary.IvaGenerado - summary.CreditoTributario - summary.RetencionesIva
 	if res < 0 { res = 0 }
 	summary.ImpuestoSugerido = util.Round(res, 2)
 
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"kushkiv2/internal/db"
+
+	"gorm.io/gorm"
+)
+
+type UnitService struct{}
+
+func NewUnitService() *UnitService {
+	return &UnitService{}
+}
+
+// ListarUnidades devuelve las presentaciones alternativas de un producto.
+func (s *UnitService) ListarUnidades(sku string) ([]db.ProductUnitDTO, error) {
+	var unidades []db.ProductUnit
+	if err := db.GetDB().Where("product_sku = ?", sku).Order("factor").Find(&unidades).Error; err != nil {
+		return nil, fmt.Errorf("error listando unidades: %v", err)
+	}
+	return mapProductUnits(unidades), nil
+}
+
+// GuardarUnidades reemplaza las presentaciones alternativas de un producto. Cada una necesita un
+// nombre distinto de la unidad base y un factor mayor que cero; su código de barras no puede
+// repetirse con el de otro producto o presentación.
+func (s *UnitService) GuardarUnidades(sku string, unidades []db.ProductUnitDTO) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var product db.Product
+		if err := tx.First(&product, "sku = ?", sku).Error; err != nil {
+			return fmt.Errorf("producto no encontrado: %s", sku)
+		}
+
+		nombres := map[string]bool{normalizarUnidad(product.UnidadMedida): true}
+		codigos := map[string]bool{}
+		nuevas := make([]db.ProductUnit, 0, len(unidades))
+		for _, u := range unidades {
+			nombre := normalizarUnidad(u.Nombre)
+			if nombre == "" {
+				return fmt.Errorf("la unidad necesita un nombre")
+			}
+			if nombres[nombre] {
+				return fmt.Errorf("la unidad %s está repetida o es la unidad base", nombre)
+			}
+			if u.Factor <= 0 {
+				return fmt.Errorf("el factor de la unidad %s debe ser mayor que cero", nombre)
+			}
+			nombres[nombre] = true
+
+			barcode := strings.TrimSpace(u.Barcode)
+			if barcode != "" {
+				if codigos[barcode] || barcode == product.Barcode || barcode == product.SKU {
+					return fmt.Errorf("el código de barras %s está repetido", barcode)
+				}
+				var usados int64
+				tx.Model(&db.Product{}).Where("sku = ? OR barcode = ?", barcode, barcode).Count(&usados)
+				if usados == 0 {
+					tx.Model(&db.ProductUnit{}).Where("barcode = ? AND product_sku <> ?", barcode, sku).Count(&usados)
+				}
+				if usados > 0 {
+					return fmt.Errorf("el código de barras %s ya pertenece a otro producto", barcode)
+				}
+				codigos[barcode] = true
+			}
+			nuevas = append(nuevas, db.ProductUnit{
+				ProductSKU: sku,
+				Nombre:     nombre,
+				Factor:     redondearCantidad(u.Factor),
+				Barcode:    barcode,
+				Price:      u.Price,
+			})
+		}
+
+		if err := tx.Where("product_sku = ?", sku).Delete(&db.ProductUnit{}).Error; err != nil {
+			return fmt.Errorf("error actualizando unidades: %v", err)
+		}
+		for i := range nuevas {
+			if err := tx.Create(&nuevas[i]).Error; err != nil {
+				return fmt.Errorf("error guardando unidad %s: %v", nuevas[i].Nombre, err)
+			}
+		}
+		return nil
+	})
+}
+
+// BuscarPorCodigo encuentra un producto por SKU, código de barras o código de una presentación.
+// Para una presentación devuelve su unidad, factor y precio (sin precio propio, el base por el
+// factor); si no, los de la unidad base.
+func (s *UnitService) BuscarPorCodigo(codigo string) (*db.ScanResultDTO, error) {
+	product, unidad, err := buscarPorCodigo(db.GetDB(), codigo)
+	if err != nil {
+		return nil, err
+	}
+	result := &db.ScanResultDTO{
+		SKU:     product.SKU,
+		Nombre:  product.Name,
+		Unidad:  product.UnidadMedida,
+		Factor:  1,
+		Precio:  product.Price,
+		Stock:   product.Stock,
+		Product: *product,
+	}
+	if product.EsKit {
+		if disp, err := disponibilidadKit(db.GetDB(), product.SKU, ""); err == nil {
+			result.Stock = disp.Disponible
+		}
+	}
+	if unidad != nil {
+		result.Unidad = unidad.Nombre
+		result.Factor = unidad.Factor
+		result.Precio = precioBase(db.GetDB(), product, unidad.Nombre)
+	}
+	return result, nil
+}
+
+// buscarPorCodigo resuelve un código leído (SKU, código de barras del producto o de una
+// presentación). unidad es nil cuando el código es el de la unidad base.
+func buscarPorCodigo(tx *gorm.DB, codigo string) (*db.Product, *db.ProductUnit, error) {
+	codigo = strings.TrimSpace(codigo)
+	if codigo == "" {
+		return nil, nil, fmt.Errorf("código vacío")
+	}
+	var products []db.Product
+	tx.Where("sku = ? OR barcode = ?", codigo, codigo).Limit(1).Find(&products)
+	if len(products) > 0 {
+		return &products[0], nil, nil
+	}
+
+	var unidades []db.ProductUnit
+	tx.Where("barcode = ?", codigo).Limit(1).Find(&unidades)
+	if len(unidades) > 0 {
+		var product db.Product
+		if err := tx.First(&product, "sku = ?", unidades[0].ProductSKU).Error; err == nil {
+			return &product, &unidades[0], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("producto no encontrado: %s", codigo)
+}
+
+// factorUnidad devuelve cuántas unidades base tiene la unidad de venta de un producto.
+// La unidad base, una unidad vacía o desconocida valen 1.
+func factorUnidad(tx *gorm.DB, sku, unidad string) float64 {
+	unidad = normalizarUnidad(unidad)
+	if unidad == "" {
+		return 1
+	}
+	var unidades []db.ProductUnit
+	tx.Where("product_sku = ? AND nombre = ?", sku, unidad).Limit(1).Find(&unidades)
+	if len(unidades) == 0 {
+		return 1
+	}
+	return unidades[0].Factor
+}
+
+// resolverUnidades completa la unidad y el factor de cada ítem de factura según el catálogo: sin
+// unidad se vende en la unidad base del producto; una presentación debe existir. Los códigos
+// que no son productos conservan la unidad indicada con factor 1.
+func resolverUnidades(tx *gorm.DB, items []db.InvoiceItem) error {
+	for i := range items {
+		item := &items[i]
+		item.Unidad = normalizarUnidad(item.Unidad)
+		item.Factor = 1
+
+		var products []db.Product
+		tx.Where("sku = ?", item.Codigo).Limit(1).Find(&products)
+		if len(products) == 0 {
+			continue
+		}
+		base := normalizarUnidad(products[0].UnidadMedida)
+		if item.Unidad == "" || item.Unidad == base {
+			item.Unidad = base
+			continue
+		}
+		var unidades []db.ProductUnit
+		tx.Where("product_sku = ? AND nombre = ?", item.Codigo, item.Unidad).Limit(1).Find(&unidades)
+		if len(unidades) == 0 {
+			return fmt.Errorf("la unidad %s no está definida para el producto %s", item.Unidad, item.Codigo)
+		}
+		item.Factor = unidades[0].Factor
+	}
+	return nil
+}
+
+// normalizarUnidad unifica los nombres de unidades ("kg " = "KG").
+func normalizarUnidad(unidad string) string {
+	return strings.ToUpper(strings.TrimSpace(unidad))
+}
+
+// UnidadesPorProducto agrupa por SKU las presentaciones de todos los productos, para listados.
+func (s *UnitService) UnidadesPorProducto() map[string][]db.ProductUnitDTO {
+	var unidades []db.ProductUnit
+	db.GetDB().Order("product_sku, factor").Find(&unidades)
+	result := map[string][]db.ProductUnitDTO{}
+	for _, u := range unidades {
+		result[u.ProductSKU] = append(result[u.ProductSKU], db.ProductUnitDTO{
+			ID:      u.ID,
+			Nombre:  u.Nombre,
+			Factor:  u.Factor,
+			Barcode: u.Barcode,
+			Price:   u.Price,
+		})
+	}
+	return result
+}
+
+func mapProductUnits(unidades []db.ProductUnit) []db.ProductUnitDTO {
+	result := make([]db.ProductUnitDTO, 0, len(unidades))
+	for _, u := range unidades {
+		result = append(result, db.ProductUnitDTO{
+			ID:      u.ID,
+			Nombre:  u.Nombre,
+			Factor:  u.Factor,
+			Barcode: u.Barcode,
+			Price:   u.Price,
+		})
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"testing"
+
+	"kushkiv2/internal/db"
+
+	"gorm.io/gorm"
+)
+
+func TestUnitService_Presentaciones(t *testing.T) {
+	database := setupTestDB()
+	svc := NewUnitService()
+	database.Create(&db.Product{SKU: "AGUA", Name: "Agua 500ml", Barcode: "7861001", Stock: 48, Cost: 0.25, Price: 0.5, UnidadMedida: "UND"})
+	database.Create(&db.Product{SKU: "OTRO", Name: "Otro", Barcode: "7869999"})
+
+	if err := svc.GuardarUnidades("AGUA", []db.ProductUnitDTO{{Nombre: "CAJA", Factor: 0}}); err == nil {
+		t.Error("Debe rechazar factores menores o iguales a cero")
+	}
+	if err := svc.GuardarUnidades("AGUA", []db.ProductUnitDTO{{Nombre: "und", Factor: 2}}); err == nil {
+		t.Error("Debe rechazar una presentación igual a la unidad base")
+	}
+	if err := svc.GuardarUnidades("AGUA", []db.ProductUnitDTO{{Nombre: "CAJA", Factor: 12, Barcode: "7869999"}}); err == nil {
+		t.Error("Debe rechazar códigos de barras de otro producto")
+	}
+	if err := svc.GuardarUnidades("AGUA", []db.ProductUnitDTO{{Nombre: " caja", Factor: 12, Barcode: "7861012", Price: 5.5}}); err != nil {
+		t.Fatal(err)
+	}
+
+	scan, err := svc.BuscarPorCodigo("7861012")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scan.SKU != "AGUA" || scan.Unidad != "CAJA" || scan.Factor != 12 || scan.Precio != 5.5 {
+		t.Errorf("Lectura de la presentación incorrecta: %+v", scan)
+	}
+	// Una presentación sin precio propio se vende al precio base por el factor
+	if err := svc.GuardarUnidades("AGUA", []db.ProductUnitDTO{{Nombre: "CAJA", Factor: 12, Barcode: "7861012", Price: 5.5}, {Nombre: "PACK", Factor: 6, Barcode: "7861006"}}); err != nil {
+		t.Fatal(err)
+	}
+	if scan, err := svc.BuscarPorCodigo("7861006"); err != nil || scan.Precio != 3 {
+		t.Errorf("Precio del pack sin precio propio incorrecto: %+v %v", scan, err)
+	}
+
+	items := []db.InvoiceItem{{Codigo: "AGUA", Unidad: "Caja", Cantidad: 2}, {Codigo: "AGUA", Cantidad: 1}}
+	if err := resolverUnidades(database, items); err != nil {
+		t.Fatal(err)
+	}
+	if items[0].Factor != 12 || items[1].Unidad != "UND" || items[1].Factor != 1 {
+		t.Errorf("Unidades mal resueltas: %+v", items)
+	}
+	if err := resolverUnidades(database, []db.InvoiceItem{{Codigo: "AGUA", Unidad: "PALLET"}}); err == nil {
+		t.Error("Debe rechazar unidades no definidas")
+	}
+
+	// La venta en cajas descuenta unidades base y escala el costo
+	venta := []db.FacturaItem{{ProductoSKU: "AGUA", Cantidad: 2, Unidad: "CAJA", Factor: 12}}
+	database.Transaction(func(tx *gorm.DB) error {
+		return descontarVenta(tx, "CLAVE_CAJA", "", venta)
+	})
+	if got := stockDe(t, "AGUA"); got != 24 {
+		t.Errorf("Stock esperado 24, obtuve %g", got)
+	}
+	if venta[0].CostoUnitario != 3 {
+		t.Errorf("Costo por caja esperado 3, obtuve %g", venta[0].CostoUnitario)
+	}
+}
+
+func TestInventoryService_CantidadesFraccionarias(t *testing.T) {
+	database := setupTestDB()
+	database.Create(&db.Product{SKU: "QUESO", Name: "Queso", Barcode: "QUESO", Stock: 10, Cost: 4, UnidadMedida: "KG"})
+
+	database.Transaction(func(tx *gorm.DB) error {
+		return descontarVenta(tx, "CLAVE_KG", "", []db.FacturaItem{{ProductoSKU: "QUESO", Cantidad: 1.5}})
+	})
+	database.Transaction(func(tx *gorm.DB) error {
+		return descontarVenta(tx, "CLAVE_KG2", "", []db.FacturaItem{{ProductoSKU: "QUESO", Cantidad: 0.1}})
+	})
+	if got := stockDe(t, "QUESO"); got != 8.4 {
+		t.Errorf("Stock esperado 8.4, obtuve %g", got)
+	}
+
+	var movs []db.StockMovement
+	database.Where("product_sku = ?", "QUESO").Order("id").Find(&movs)
+	if len(movs) != 2 || movs[1].Saldo != 8.4 {
+		t.Errorf("Kardex fraccionario incorrecto: %+v", movs)
+	}
+}
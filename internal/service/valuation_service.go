@@ -0,0 +1,341 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"kushkiv2/internal/db"
+	"kushkiv2/pkg/util"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Rangos de antigüedad del inventario según los días desde el último movimiento.
+var rangosAntiguedad = []struct {
+	hasta  int
+	nombre string
+}{
+	{30, "0-30 días"},
+	{60, "31-60 días"},
+	{90, "61-90 días"},
+	{180, "91-180 días"},
+	{365, "181-365 días"},
+}
+
+const (
+	RangoMasDeUnAnio    = "Más de 365 días"
+	RangoSinMovimientos = "Sin movimientos"
+)
+
+// ValorizacionProducto es la existencia de un producto a la fecha de corte, valorada al costo
+// promedio vigente en esa fecha.
+type ValorizacionProducto struct {
+	SKU               string  `json:"sku"`
+	Nombre            string  `json:"nombre"`
+	Categoria         string  `json:"categoria"`
+	Cantidad          float64 `json:"cantidad"`
+	CostoUnitario     float64 `json:"costoUnitario"`
+	Valor             float64 `json:"valor"`
+	UltimoMovimiento  string  `json:"ultimoMovimiento"` // Vacío si no tuvo movimientos hasta el corte
+	DiasSinMovimiento int     `json:"diasSinMovimiento"`
+	Antiguedad        string  `json:"antiguedad"`
+}
+
+// ExistenciaBodega es la existencia de un producto en una bodega a la fecha de corte.
+type ExistenciaBodega struct {
+	Bodega   string  `json:"bodega"`
+	SKU      string  `json:"sku"`
+	Nombre   string  `json:"nombre"`
+	Cantidad float64 `json:"cantidad"`
+	Valor    float64 `json:"valor"`
+}
+
+// ReporteValorizacion es el inventario valorizado a una fecha de corte, por producto, categoría y
+// bodega, con la antigüedad según el último movimiento para detectar stock sin rotación.
+type ReporteValorizacion struct {
+	Fecha       string                 `json:"fecha"`
+	Productos   []ValorizacionProducto `json:"productos"`
+	Categorias  []StockGrupo           `json:"categorias"`
+	Bodegas     []StockGrupo           `json:"bodegas"`
+	Antiguedad  []StockGrupo           `json:"antiguedad"`
+	Existencias []ExistenciaBodega     `json:"existencias"`
+	Valor       float64                `json:"valor"`
+}
+
+// GetInventoryValuation reconstruye el inventario a la fecha de corte desde el kardex: la cantidad
+// es el stock actual menos lo movido después del corte, y el costo es el promedio ponderado que
+// resulta de recorrer los movimientos hasta esa fecha. Los productos sin movimientos hasta el corte
+// se valoran a su costo actual. No incluye servicios ni kits.
+func (s *ReportService) GetInventoryValuation(fecha time.Time) (*ReporteValorizacion, error) {
+	tx := db.GetDB()
+	var products []db.Product
+	if err := tx.Where("es_servicio = ? AND es_kit = ?", false, false).Order("name").Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("error cargando inventario: %v", err)
+	}
+	principal, err := bodegaPrincipal(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Existencias por bodega a la fecha: stock actual menos el neto de los movimientos posteriores
+	cantidades := map[string]map[string]float64{}
+	sumar := func(sku, bodega string, cantidad float64) {
+		if bodega == "" {
+			bodega = principal
+		}
+		if cantidades[sku] == nil {
+			cantidades[sku] = map[string]float64{}
+		}
+		cantidades[sku][bodega] += cantidad
+	}
+	var stocks []db.ProductStock
+	if err := tx.Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("error cargando stock por bodega: %v", err)
+	}
+	conDesglose := map[string]bool{}
+	for _, ps := range stocks {
+		sumar(ps.ProductSKU, ps.Bodega, ps.Stock)
+		conDesglose[ps.ProductSKU] = true
+	}
+	for _, p := range products {
+		// Stock anterior a las bodegas: está en la principal
+		if !conDesglose[p.SKU] && p.Stock != 0 {
+			sumar(p.SKU, principal, p.Stock)
+		}
+	}
+	var posteriores []struct {
+		ProductSKU string
+		Bodega     string
+		Total      float64
+	}
+	if err := tx.Model(&db.StockMovement{}).
+		Select("product_sku, bodega, SUM(cantidad) as total").
+		Where("created_at > ?", fecha).
+		Group("product_sku, bodega").
+		Scan(&posteriores).Error; err != nil {
+		return nil, fmt.Errorf("error cargando movimientos: %v", err)
+	}
+	for _, m := range posteriores {
+		sumar(m.ProductSKU, m.Bodega, -m.Total)
+	}
+
+	// Costo promedio y último movimiento a la fecha, con la misma regla que registrarMovimiento
+	var movs []db.StockMovement
+	if err := tx.Select("product_sku, cantidad, saldo, costo_unitario, created_at").
+		Where("created_at <= ?", fecha).
+		Order("product_sku, id").
+		Find(&movs).Error; err != nil {
+		return nil, fmt.Errorf("error cargando movimientos: %v", err)
+	}
+	costos, ultimos := map[string]float64{}, map[string]time.Time{}
+	for _, m := range movs {
+		costo, ok := costos[m.ProductSKU]
+		switch {
+		case !ok:
+			costo = m.CostoUnitario
+		case m.Cantidad > 0 && m.CostoUnitario > 0:
+			costo = costoPromedio(m.Saldo-m.Cantidad, costo, m.Cantidad, m.CostoUnitario)
+		case m.Cantidad < 0:
+			costo = m.CostoUnitario
+		}
+		costos[m.ProductSKU] = costo
+		ultimos[m.ProductSKU] = m.CreatedAt
+	}
+
+	var bodegas []db.Warehouse
+	tx.Find(&bodegas)
+	nombresBodega := make(map[string]string, len(bodegas))
+	for _, b := range bodegas {
+		nombresBodega[b.Codigo] = b.Nombre
+	}
+	rutas := rutasCategorias(tx)
+
+	reporte := &ReporteValorizacion{
+		Fecha:       fecha.Format("2006-01-02"),
+		Productos:   []ValorizacionProducto{},
+		Categorias:  []StockGrupo{},
+		Bodegas:     []StockGrupo{},
+		Antiguedad:  []StockGrupo{},
+		Existencias: []ExistenciaBodega{},
+	}
+	idxCategoria, idxBodega, idxAntiguedad := map[string]int{}, map[string]int{}, map[string]int{}
+	acumular := func(lista *[]StockGrupo, idx map[string]int, grupo string, unidades, valor float64) {
+		i, ok := idx[grupo]
+		if !ok {
+			*lista = append(*lista, StockGrupo{Grupo: grupo})
+			i = len(*lista) - 1
+			idx[grupo] = i
+		}
+		(*lista)[i].Productos++
+		(*lista)[i].Unidades += unidades
+		(*lista)[i].Valor += valor
+	}
+	// Los rangos de antigüedad se muestran siempre y en orden
+	for _, r := range rangosAntiguedad {
+		idxAntiguedad[r.nombre] = len(reporte.Antiguedad)
+		reporte.Antiguedad = append(reporte.Antiguedad, StockGrupo{Grupo: r.nombre})
+	}
+	for _, nombre := range []string{RangoMasDeUnAnio, RangoSinMovimientos} {
+		idxAntiguedad[nombre] = len(reporte.Antiguedad)
+		reporte.Antiguedad = append(reporte.Antiguedad, StockGrupo{Grupo: nombre})
+	}
+
+	for _, p := range products {
+		if p.CreatedAt.After(fecha) {
+			continue
+		}
+		porBodega := cantidades[p.SKU]
+		cantidad := 0.0
+		for _, q := range porBodega {
+			cantidad += q
+		}
+		cantidad = redondearCantidad(cantidad)
+		if cantidad == 0 {
+			continue
+		}
+		costo, ok := costos[p.SKU]
+		if !ok {
+			costo = p.Cost
+		}
+		v := ValorizacionProducto{
+			SKU:           p.SKU,
+			Nombre:        p.Name,
+			Categoria:     nombreGrupo(rutas, p.CategoryID, SinCategoria),
+			Cantidad:      cantidad,
+			CostoUnitario: costo,
+			Valor:         util.Round(cantidad*costo, 2),
+			Antiguedad:    RangoSinMovimientos,
+		}
+		if ultimo, ok := ultimos[p.SKU]; ok {
+			v.UltimoMovimiento = ultimo.Format("2006-01-02")
+			v.DiasSinMovimiento = int(fecha.Sub(ultimo).Hours() / 24)
+			v.Antiguedad = rangoAntiguedad(v.DiasSinMovimiento)
+		}
+		reporte.Productos = append(reporte.Productos, v)
+		reporte.Valor += v.Valor
+		acumular(&reporte.Categorias, idxCategoria, v.Categoria, v.Cantidad, v.Valor)
+		acumular(&reporte.Antiguedad, idxAntiguedad, v.Antiguedad, v.Cantidad, v.Valor)
+
+		codigos := make([]string, 0, len(porBodega))
+		for codigo := range porBodega {
+			codigos = append(codigos, codigo)
+		}
+		sort.Strings(codigos)
+		for _, codigo := range codigos {
+			q := redondearCantidad(porBodega[codigo])
+			if q == 0 {
+				continue
+			}
+			valor := util.Round(q*costo, 2)
+			reporte.Existencias = append(reporte.Existencias, ExistenciaBodega{Bodega: codigo, SKU: p.SKU, Nombre: p.Name, Cantidad: q, Valor: valor})
+			nombre := nombresBodega[codigo]
+			if nombre == "" {
+				nombre = codigo
+			}
+			acumular(&reporte.Bodegas, idxBodega, nombre, q, valor)
+		}
+	}
+
+	for _, lista := range [][]StockGrupo{reporte.Categorias, reporte.Bodegas, reporte.Antiguedad} {
+		for i := range lista {
+			lista[i].Unidades = redondearCantidad(lista[i].Unidades)
+			lista[i].Valor = util.Round(lista[i].Valor, 2)
+		}
+	}
+	for _, lista := range [][]StockGrupo{reporte.Categorias, reporte.Bodegas} {
+		sort.Slice(lista, func(a, b int) bool { return lista[a].Valor > lista[b].Valor })
+	}
+	reporte.Valor = util.Round(reporte.Valor, 2)
+	return reporte, nil
+}
+
+func rangoAntiguedad(dias int) string {
+	for _, r := range rangosAntiguedad {
+		if dias <= r.hasta {
+			return r.nombre
+		}
+	}
+	return RangoMasDeUnAnio
+}
+
+// GenerateInventoryValuationExcel exporta la valorización del inventario a la fecha de corte.
+func (s *ReportService) GenerateInventoryValuationExcel(fecha time.Time) ([]byte, error) {
+	reporte, err := s.GetInventoryValuation(fecha)
+	if err != nil {
+		return nil, err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"34D399"}, Pattern: 1},
+	})
+	encabezados := func(sheet string, headers []string) {
+		for i, h := range headers {
+			cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+			f.SetCellValue(sheet, cell, h)
+			f.SetCellStyle(sheet, cell, cell, headerStyle)
+		}
+	}
+
+	sheet := "Valorización"
+	f.SetSheetName("Sheet1", sheet)
+	encabezados(sheet, []string{"SKU", "Producto", "Categoría", "Cantidad", "Costo Unitario", "Valor", "Último Movimiento", "Días sin Movimiento", "Antigüedad"})
+	for i, p := range reporte.Productos {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), p.SKU)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), p.Nombre)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), p.Categoria)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), p.Cantidad)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), p.CostoUnitario)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), p.Valor)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), p.UltimoMovimiento)
+		if p.UltimoMovimiento != "" {
+			f.SetCellValue(sheet, fmt.Sprintf("H%d", row), p.DiasSinMovimiento)
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), p.Antiguedad)
+	}
+	total := len(reporte.Productos) + 2
+	f.SetCellValue(sheet, fmt.Sprintf("E%d", total), "Total al "+reporte.Fecha)
+	f.SetCellValue(sheet, fmt.Sprintf("F%d", total), reporte.Valor)
+
+	grupos := []struct {
+		sheet, titulo string
+		lista         []StockGrupo
+	}{
+		{"Por Categoría", "Categoría", reporte.Categorias},
+		{"Por Bodega", "Bodega", reporte.Bodegas},
+		{"Antigüedad", "Antigüedad", reporte.Antiguedad},
+	}
+	for _, g := range grupos {
+		f.NewSheet(g.sheet)
+		encabezados(g.sheet, []string{g.titulo, "Productos", "Unidades", "Valor"})
+		for i, grupo := range g.lista {
+			row := i + 2
+			f.SetCellValue(g.sheet, fmt.Sprintf("A%d", row), grupo.Grupo)
+			f.SetCellValue(g.sheet, fmt.Sprintf("B%d", row), grupo.Productos)
+			f.SetCellValue(g.sheet, fmt.Sprintf("C%d", row), grupo.Unidades)
+			f.SetCellValue(g.sheet, fmt.Sprintf("D%d", row), grupo.Valor)
+		}
+	}
+
+	sheet = "Existencias por Bodega"
+	f.NewSheet(sheet)
+	encabezados(sheet, []string{"Bodega", "SKU", "Producto", "Cantidad", "Valor"})
+	for i, e := range reporte.Existencias {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), e.Bodega)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), e.SKU)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), e.Nombre)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), e.Cantidad)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), e.Valor)
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+)
+
+func TestGetInventoryValuation(t *testing.T) {
+	database := setupTestDB()
+	svc := NewReportService()
+	corte := time.Now().AddDate(0, 0, -20)
+	dias := func(n int) time.Time { return corte.AddDate(0, 0, -n) }
+
+	database.Create(&db.Warehouse{Codigo: "SUC2", Nombre: "Sucursal Norte", Activa: true})
+	database.Create(&db.Product{SKU: "P1", Name: "Arroz", Barcode: "P1", CreatedAt: dias(200)})
+	database.Create(&db.Product{SKU: "P2", Name: "Legado", Barcode: "P2", Stock: 8, Cost: 1.5, CreatedAt: dias(500)})
+	database.Create(&db.Product{SKU: "P3", Name: "Clavos", Barcode: "P3", CreatedAt: dias(500)})
+	database.Create(&db.Product{SKU: "MO", Name: "Mano de obra", Barcode: "MO", Cost: 5, EsServicio: true})
+
+	movs := []db.StockMovement{
+		{ProductSKU: "P1", Tipo: MovCompra, Cantidad: 10, CostoUnitario: 2, CreatedAt: dias(100)},
+		{ProductSKU: "P1", Tipo: MovCompra, Cantidad: 10, CostoUnitario: 4, CreatedAt: dias(40)},
+		{ProductSKU: "P1", Tipo: MovTransferencia, Cantidad: -4, CreatedAt: dias(10)},
+		{ProductSKU: "P1", Tipo: MovTransferencia, Cantidad: 4, Bodega: "SUC2", CreatedAt: dias(10)},
+		{ProductSKU: "P3", Tipo: MovCompra, Cantidad: 2, CostoUnitario: 5, CreatedAt: dias(400)},
+		// Posteriores al corte: no cambian la valorización
+		{ProductSKU: "P1", Tipo: MovVenta, Cantidad: -5, CreatedAt: dias(-5)},
+		{ProductSKU: "P1", Tipo: MovCompra, Cantidad: 5, CostoUnitario: 9, CreatedAt: dias(-10)},
+	}
+	for i := range movs {
+		if err := registrarMovimiento(database, &movs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Creado después del corte
+	database.Create(&db.Product{SKU: "P4", Name: "Nuevo", Barcode: "P4", Stock: 3, Cost: 1})
+
+	reporte, err := svc.GetInventoryValuation(corte)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reporte.Productos) != 3 || reporte.Valor != 82 {
+		t.Fatalf("Valorización incorrecta: %+v", reporte)
+	}
+	porSKU := map[string]ValorizacionProducto{}
+	for _, p := range reporte.Productos {
+		porSKU[p.SKU] = p
+	}
+	if p1 := porSKU["P1"]; p1.Cantidad != 20 || p1.CostoUnitario != 3 || p1.Valor != 60 || p1.DiasSinMovimiento != 10 || p1.Antiguedad != "0-30 días" {
+		t.Errorf("P1 al corte: %+v", p1)
+	}
+	if p2 := porSKU["P2"]; p2.Valor != 12 || p2.Antiguedad != RangoSinMovimientos {
+		t.Errorf("El stock sin kardex se valora al costo actual: %+v", p2)
+	}
+	if p3 := porSKU["P3"]; p3.Valor != 10 || p3.Antiguedad != RangoMasDeUnAnio {
+		t.Errorf("P3 debería estar sin rotación: %+v", p3)
+	}
+
+	bodegas := map[string]float64{}
+	for _, b := range reporte.Bodegas {
+		bodegas[b.Grupo] = b.Valor
+	}
+	if bodegas["Bodega Principal"] != 70 || bodegas["Sucursal Norte"] != 12 {
+		t.Errorf("Valor por bodega incorrecto: %+v", reporte.Bodegas)
+	}
+	if len(reporte.Antiguedad) != 7 || reporte.Antiguedad[0].Valor != 60 || reporte.Antiguedad[6].Valor != 12 {
+		t.Errorf("Rangos de antigüedad incorrectos: %+v", reporte.Antiguedad)
+	}
+
+	if data, err := svc.GenerateInventoryValuationExcel(corte); err != nil || len(data) == 0 {
+		t.Errorf("Excel no generado: %v", err)
+	}
+}
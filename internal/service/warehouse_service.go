@@ -0,0 +1,469 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"kushkiv2/internal/db"
+
+	"gorm.io/gorm"
+)
+
+var codigoSerieRe = regexp.MustCompile(`^\d{3}$`)
+
+type WarehouseService struct{}
+
+func NewWarehouseService() *WarehouseService {
+	return &WarehouseService{}
+}
+
+// GuardarBodega crea o actualiza una bodega. Solo puede haber una principal y esta no se desactiva.
+func (s *WarehouseService) GuardarBodega(dto db.WarehouseDTO) error {
+	dto.Codigo = strings.ToUpper(strings.TrimSpace(dto.Codigo))
+	if dto.Codigo == "" || strings.TrimSpace(dto.Nombre) == "" {
+		return fmt.Errorf("código y nombre son obligatorios")
+	}
+	if dto.Estab != "" && !codigoSerieRe.MatchString(dto.Estab) {
+		return fmt.Errorf("establecimiento inválido (3 dígitos)")
+	}
+	if dto.PtoEmi != "" && (dto.Estab == "" || !codigoSerieRe.MatchString(dto.PtoEmi)) {
+		return fmt.Errorf("punto de emisión inválido: requiere establecimiento y 3 dígitos")
+	}
+	if dto.Principal && !dto.Activa {
+		return fmt.Errorf("la bodega principal debe estar activa")
+	}
+
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var actual []db.Warehouse
+		tx.Where("codigo = ?", dto.Codigo).Limit(1).Find(&actual)
+		if len(actual) > 0 && actual[0].Principal && !dto.Principal {
+			return fmt.Errorf("asigne otra bodega como principal antes de cambiar esta")
+		}
+
+		if dto.Estab != "" {
+			var duplicada int64
+			tx.Model(&db.Warehouse{}).Where("codigo <> ? AND estab = ? AND pto_emi = ? AND activa = ?", dto.Codigo, dto.Estab, dto.PtoEmi, true).Count(&duplicada)
+			if duplicada > 0 && dto.Activa {
+				return fmt.Errorf("otra bodega activa ya atiende las ventas de %s-%s", dto.Estab, dto.PtoEmi)
+			}
+		}
+
+		if dto.Principal {
+			if err := tx.Model(&db.Warehouse{}).Where("codigo <> ?", dto.Codigo).Update("principal", false).Error; err != nil {
+				return fmt.Errorf("error actualizando bodega principal: %v", err)
+			}
+		}
+
+		bodega := db.Warehouse{
+			Codigo:    dto.Codigo,
+			Nombre:    strings.TrimSpace(dto.Nombre),
+			Direccion: dto.Direccion,
+			Estab:     dto.Estab,
+			PtoEmi:    dto.PtoEmi,
+			Principal: dto.Principal,
+			Activa:    dto.Activa,
+		}
+		if len(actual) > 0 {
+			bodega.CreatedAt = actual[0].CreatedAt
+		}
+		if err := tx.Save(&bodega).Error; err != nil {
+			return fmt.Errorf("error guardando bodega: %v", err)
+		}
+		return nil
+	})
+}
+
+// ListarBodegas devuelve las bodegas, la principal primero.
+func (s *WarehouseService) ListarBodegas(soloActivas bool) ([]db.WarehouseDTO, error) {
+	q := db.GetDB().Order("principal desc, nombre asc")
+	if soloActivas {
+		q = q.Where("activa = ?", true)
+	}
+	var bodegas []db.Warehouse
+	if err := q.Find(&bodegas).Error; err != nil {
+		return nil, fmt.Errorf("error listando bodegas: %v", err)
+	}
+	result := make([]db.WarehouseDTO, 0, len(bodegas))
+	for _, b := range bodegas {
+		result = append(result, db.WarehouseDTO{
+			Codigo:    b.Codigo,
+			Nombre:    b.Nombre,
+			Direccion: b.Direccion,
+			Estab:     b.Estab,
+			PtoEmi:    b.PtoEmi,
+			Principal: b.Principal,
+			Activa:    b.Activa,
+		})
+	}
+	return result, nil
+}
+
+// EliminarBodega borra una bodega sin stock ni movimientos. Si tuvo movimientos, se debe desactivar.
+func (s *WarehouseService) EliminarBodega(codigo string) error {
+	var bodega db.Warehouse
+	if err := db.GetDB().First(&bodega, "codigo = ?", codigo).Error; err != nil {
+		return fmt.Errorf("bodega no encontrada")
+	}
+	if bodega.Principal {
+		return fmt.Errorf("no se puede eliminar la bodega principal")
+	}
+	var movimientos int64
+	db.GetDB().Model(&db.StockMovement{}).Where("bodega = ?", codigo).Count(&movimientos)
+	if movimientos > 0 {
+		return fmt.Errorf("la bodega tiene %d movimientos: desactívela en lugar de eliminarla", movimientos)
+	}
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&db.ProductStock{}, "bodega = ?", codigo).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&bodega).Error
+	})
+}
+
+// GetStockPorBodega devuelve el stock de un producto en cada bodega activa (incluye las que están en cero).
+func (s *WarehouseService) GetStockPorBodega(sku string) ([]db.ProductStockDTO, error) {
+	var product db.Product
+	if err := db.GetDB().First(&product, "sku = ?", sku).Error; err != nil {
+		return nil, fmt.Errorf("producto no encontrado: %s", sku)
+	}
+	if err := inicializarStockBodegas(db.GetDB(), &product); err != nil {
+		return nil, err
+	}
+
+	var bodegas []db.Warehouse
+	db.GetDB().Where("activa = ?", true).Order("principal desc, nombre asc").Find(&bodegas)
+	var stocks []db.ProductStock
+	db.GetDB().Where("product_sku = ?", sku).Find(&stocks)
+	porBodega := map[string]db.ProductStock{}
+	for _, ps := range stocks {
+		porBodega[ps.Bodega] = ps
+	}
+
+	result := make([]db.ProductStockDTO, 0, len(bodegas))
+	for _, b := range bodegas {
+		ps := porBodega[b.Codigo]
+		result = append(result, db.ProductStockDTO{
+			SKU:          sku,
+			Nombre:       product.Name,
+			Bodega:       b.Codigo,
+			BodegaNombre: b.Nombre,
+			Stock:        ps.Stock,
+			Ubicacion:    ps.Ubicacion,
+		})
+	}
+	return result, nil
+}
+
+// GetInventarioBodega devuelve el stock y la ubicación de todos los productos en una bodega.
+func (s *WarehouseService) GetInventarioBodega(bodega string) (map[string]db.ProductStock, error) {
+	codigo, err := resolverBodega(db.GetDB(), bodega)
+	if err != nil {
+		return nil, err
+	}
+	principal, _ := bodegaPrincipal(db.GetDB())
+
+	result := map[string]db.ProductStock{}
+	// Productos que aún no tienen desglose: todo su stock está en la principal
+	if codigo == principal {
+		var products []db.Product
+		db.GetDB().Where("sku NOT IN (?)", db.GetDB().Model(&db.ProductStock{}).Select("product_sku")).Find(&products)
+		for _, p := range products {
+			result[p.SKU] = db.ProductStock{ProductSKU: p.SKU, Bodega: codigo, Stock: p.Stock, Ubicacion: p.Location}
+		}
+	}
+	var stocks []db.ProductStock
+	if err := db.GetDB().Where("bodega = ?", codigo).Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("error consultando stock de la bodega: %v", err)
+	}
+	for _, ps := range stocks {
+		result[ps.ProductSKU] = ps
+	}
+	return result, nil
+}
+
+// AsignarUbicacion guarda la percha o casillero de un producto dentro de una bodega.
+func (s *WarehouseService) AsignarUbicacion(sku, bodega, ubicacion string) error {
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var product db.Product
+		if err := tx.First(&product, "sku = ?", sku).Error; err != nil {
+			return fmt.Errorf("producto no encontrado: %s", sku)
+		}
+		codigo, err := resolverBodega(tx, bodega)
+		if err != nil {
+			return err
+		}
+		if err := inicializarStockBodegas(tx, &product); err != nil {
+			return err
+		}
+		ps := db.ProductStock{ProductSKU: sku, Bodega: codigo}
+		if err := tx.Where(ps).Attrs(db.ProductStock{Stock: 0}).FirstOrCreate(&ps).Error; err != nil {
+			return fmt.Errorf("error guardando ubicación: %v", err)
+		}
+		return tx.Model(&ps).Update("ubicacion", strings.TrimSpace(ubicacion)).Error
+	})
+}
+
+// --- TRASLADOS ---
+
+// Transferir mueve mercadería entre dos bodegas activas. No se permite dejar stock negativo en el origen.
+// El costo promedio no cambia: el traslado solo redistribuye existencias.
+func (s *WarehouseService) Transferir(dto db.TransferDTO, usuario string) (*db.TransferDTO, error) {
+	if dto.Origen == dto.Destino {
+		return nil, fmt.Errorf("la bodega de origen y destino deben ser distintas")
+	}
+	if len(dto.Items) == 0 {
+		return nil, fmt.Errorf("el traslado debe tener al menos un producto")
+	}
+	if dto.GuiaRemision != "" && !numeroComprobanteRe.MatchString(dto.GuiaRemision) {
+		return nil, fmt.Errorf("número de guía de remisión inválido (formato 001-001-000000123)")
+	}
+
+	traslado := db.Transfer{
+		Origen:       dto.Origen,
+		Destino:      dto.Destino,
+		GuiaRemision: dto.GuiaRemision,
+		Estado:       "REALIZADA",
+		Usuario:      usuario,
+		Nota:         dto.Nota,
+	}
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		for _, codigo := range []string{dto.Origen, dto.Destino} {
+			var bodega db.Warehouse
+			if err := tx.First(&bodega, "codigo = ?", codigo).Error; err != nil {
+				return fmt.Errorf("bodega no encontrada: %s", codigo)
+			}
+			if !bodega.Activa {
+				return fmt.Errorf("la bodega %s está inactiva", codigo)
+			}
+		}
+
+		if err := tx.Create(&traslado).Error; err != nil {
+			return fmt.Errorf("error guardando traslado: %v", err)
+		}
+		documento := documentoTraslado(traslado.ID)
+
+		for i, it := range dto.Items {
+			if it.Cantidad <= 0 {
+				return fmt.Errorf("ítem %d: cantidad inválida", i+1)
+			}
+			var product db.Product
+			if err := tx.First(&product, "sku = ?", it.SKU).Error; err != nil {
+				return fmt.Errorf("producto no encontrado: %s", it.SKU)
+			}
+			disponible, err := stockEnBodega(tx, &product, dto.Origen)
+			if err != nil {
+				return err
+			}
+			if disponible < it.Cantidad {
+				return fmt.Errorf("stock insuficiente de %s en %s: disponible %g", it.SKU, dto.Origen, disponible)
+			}
+
+			if err := tx.Create(&db.TransferItem{TransferID: traslado.ID, ProductSKU: it.SKU, Cantidad: it.Cantidad}).Error; err != nil {
+				return fmt.Errorf("error guardando ítems del traslado: %v", err)
+			}
+			salida := &db.StockMovement{ProductSKU: it.SKU, Tipo: MovTransferencia, Cantidad: -it.Cantidad,
+				Documento: documento, Usuario: usuario, Nota: "Hacia " + dto.Destino, Bodega: dto.Origen}
+			if err := registrarMovimiento(tx, salida); err != nil {
+				return err
+			}
+			entrada := &db.StockMovement{ProductSKU: it.SKU, Tipo: MovTransferencia, Cantidad: it.Cantidad,
+				Documento: documento, Usuario: usuario, Nota: "Desde " + dto.Origen, Bodega: dto.Destino}
+			if err := registrarMovimiento(tx, entrada); err != nil {
+				return err
+			}
+			if err := trasladarLotes(tx, documento, it.SKU, dto.Origen, dto.Destino, it.Cantidad); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTraslado(traslado.ID)
+}
+
+// AnularTraslado devuelve la mercadería a su bodega de origen. Falla si el destino ya no
+// tiene el stock recibido (por ejemplo, porque se vendió).
+func (s *WarehouseService) AnularTraslado(id uint, usuario string) error {
+	var traslado db.Transfer
+	if err := db.GetDB().First(&traslado, id).Error; err != nil {
+		return fmt.Errorf("traslado no encontrado")
+	}
+	if traslado.Estado == "ANULADA" {
+		return fmt.Errorf("el traslado ya está anulado")
+	}
+	return db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var items []db.TransferItem
+		tx.Where("transfer_id = ?", id).Find(&items)
+		for _, it := range items {
+			var product db.Product
+			if err := tx.First(&product, "sku = ?", it.ProductSKU).Error; err != nil {
+				continue
+			}
+			disponible, err := stockEnBodega(tx, &product, traslado.Destino)
+			if err != nil {
+				return err
+			}
+			if disponible < it.Cantidad {
+				return fmt.Errorf("no se puede anular: solo quedan %g de %s en %s", disponible, it.ProductSKU, traslado.Destino)
+			}
+		}
+		if err := tx.Model(&traslado).Update("estado", "ANULADA").Error; err != nil {
+			return fmt.Errorf("error anulando traslado: %v", err)
+		}
+		return revertirMovimientos(tx, documentoTraslado(id), MovTransferencia, "Anulación de traslado por "+usuario)
+	})
+}
+
+// GetTraslado devuelve un traslado con sus ítems.
+func (s *WarehouseService) GetTraslado(id uint) (*db.TransferDTO, error) {
+	var traslado db.Transfer
+	if err := db.GetDB().First(&traslado, id).Error; err != nil {
+		return nil, fmt.Errorf("traslado no encontrado")
+	}
+	var items []db.TransferItem
+	db.GetDB().Where("transfer_id = ?", id).Find(&items)
+
+	dto := mapTransferToDTO(traslado)
+	for _, it := range items {
+		var nombres []string
+		db.GetDB().Model(&db.Product{}).Where("sku = ?", it.ProductSKU).Limit(1).Pluck("name", &nombres)
+		item := db.TransferItemDTO{SKU: it.ProductSKU, Cantidad: it.Cantidad}
+		if len(nombres) > 0 {
+			item.Nombre = nombres[0]
+		}
+		dto.Items = append(dto.Items, item)
+	}
+	return &dto, nil
+}
+
+// ListarTraslados devuelve los traslados del rango (sin ítems), del más reciente al más antiguo.
+func (s *WarehouseService) ListarTraslados(desde, hasta time.Time) ([]db.TransferDTO, error) {
+	var traslados []db.Transfer
+	if err := db.GetDB().Where("created_at >= ? AND created_at <= ?", desde, hasta).
+		Order("created_at desc, id desc").Find(&traslados).Error; err != nil {
+		return nil, fmt.Errorf("error listando traslados: %v", err)
+	}
+	result := make([]db.TransferDTO, 0, len(traslados))
+	for _, t := range traslados {
+		result = append(result, mapTransferToDTO(t))
+	}
+	return result, nil
+}
+
+func mapTransferToDTO(t db.Transfer) db.TransferDTO {
+	return db.TransferDTO{
+		ID:           t.ID,
+		Origen:       t.Origen,
+		Destino:      t.Destino,
+		GuiaRemision: t.GuiaRemision,
+		Estado:       t.Estado,
+		Usuario:      t.Usuario,
+		Nota:         t.Nota,
+		Fecha:        t.CreatedAt.Format("02/01/2006 15:04"),
+		Items:        []db.TransferItemDTO{},
+	}
+}
+
+// documentoTraslado es la referencia del traslado en el kardex.
+func documentoTraslado(id uint) string {
+	return fmt.Sprintf("TRASLADO %d", id)
+}
+
+// --- Resolución de bodegas (compartido con el kardex) ---
+
+// bodegaPrincipal devuelve el código de la bodega por defecto.
+func bodegaPrincipal(tx *gorm.DB) (string, error) {
+	var bodegas []db.Warehouse
+	tx.Where("principal = ?", true).Limit(1).Find(&bodegas)
+	if len(bodegas) == 0 {
+		return "", fmt.Errorf("no hay una bodega principal configurada")
+	}
+	return bodegas[0].Codigo, nil
+}
+
+// resolverBodega valida el código de bodega; vacío equivale a la principal.
+func resolverBodega(tx *gorm.DB, codigo string) (string, error) {
+	if codigo == "" {
+		return bodegaPrincipal(tx)
+	}
+	var count int64
+	tx.Model(&db.Warehouse{}).Where("codigo = ?", codigo).Count(&count)
+	if count == 0 {
+		return "", fmt.Errorf("bodega no encontrada: %s", codigo)
+	}
+	return codigo, nil
+}
+
+// bodegaVenta devuelve la bodega de la que descuentan las ventas de un punto de emisión:
+// primero la asignada al punto exacto, luego la del establecimiento y por último la principal.
+func bodegaVenta(tx *gorm.DB, estab, ptoEmi string) string {
+	var bodegas []db.Warehouse
+	tx.Where("activa = ? AND estab = ? AND (pto_emi = ? OR pto_emi = '')", true, estab, ptoEmi).
+		Order("pto_emi desc").Limit(1).Find(&bodegas)
+	if len(bodegas) > 0 {
+		return bodegas[0].Codigo
+	}
+	return ""
+}
+
+// bodegaVentaClave obtiene la bodega de venta a partir de la serie contenida en la clave de acceso.
+func bodegaVentaClave(tx *gorm.DB, claveAcceso string) string {
+	if len(claveAcceso) != 49 {
+		return ""
+	}
+	return bodegaVenta(tx, claveAcceso[24:27], claveAcceso[27:30])
+}
+
+// inicializarStockBodegas asigna a la bodega principal el stock de productos que aún no tienen
+// desglose por bodega (creados antes de multibodega o directamente con stock).
+func inicializarStockBodegas(tx *gorm.DB, product *db.Product) error {
+	if product.Stock == 0 {
+		return nil
+	}
+	var count int64
+	tx.Model(&db.ProductStock{}).Where("product_sku = ?", product.SKU).Count(&count)
+	if count > 0 {
+		return nil
+	}
+	principal, err := bodegaPrincipal(tx)
+	if err != nil {
+		return err
+	}
+	ps := db.ProductStock{ProductSKU: product.SKU, Bodega: principal, Stock: product.Stock, Ubicacion: product.Location}
+	if err := tx.Create(&ps).Error; err != nil {
+		return fmt.Errorf("error inicializando stock por bodega de %s: %v", product.SKU, err)
+	}
+	return nil
+}
+
+// stockEnBodega devuelve el stock de un producto en una bodega.
+func stockEnBodega(tx *gorm.DB, product *db.Product, bodega string) (float64, error) {
+	if err := inicializarStockBodegas(tx, product); err != nil {
+		return 0, err
+	}
+	var stocks []db.ProductStock
+	tx.Where("product_sku = ? AND bodega = ?", product.SKU, bodega).Limit(1).Find(&stocks)
+	if len(stocks) == 0 {
+		return 0, nil
+	}
+	return stocks[0].Stock, nil
+}
+
+// sumarStockBodega aplica una variación al stock de la bodega, creando el registro si no existe.
+func sumarStockBodega(tx *gorm.DB, sku, bodega string, cantidad float64) error {
+	res := tx.Model(&db.ProductStock{}).Where("product_sku = ? AND bodega = ?", sku, bodega).
+		UpdateColumn("stock", gorm.Expr("ROUND(stock + ?, 6)", cantidad))
+	if res.Error != nil {
+		return fmt.Errorf("error actualizando stock de %s en %s: %v", sku, bodega, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		if err := tx.Create(&db.ProductStock{ProductSKU: sku, Bodega: bodega, Stock: cantidad}).Error; err != nil {
+			return fmt.Errorf("error creando stock de %s en %s: %v", sku, bodega, err)
+		}
+	}
+	return nil
+}
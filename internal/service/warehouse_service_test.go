@@ -0,0 +1,130 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"kushkiv2/internal/db"
+
+	"gorm.io/gorm"
+)
+
+func stockBodega(t *testing.T, sku, bodega string) float64 {
+	t.Helper()
+	var stocks []db.ProductStock
+	db.GetDB().Where("product_sku = ? AND bodega = ?", sku, bodega).Limit(1).Find(&stocks)
+	if len(stocks) == 0 {
+		return 0
+	}
+	return stocks[0].Stock
+}
+
+func TestWarehouseService_Traslados(t *testing.T) {
+	database := setupTestDB()
+	svc := NewWarehouseService()
+	inv := NewInventoryService()
+	database.Create(&db.Product{SKU: "P1", Name: "Producto 1", Barcode: "P1", Stock: 10})
+
+	if err := svc.GuardarBodega(db.WarehouseDTO{Codigo: "SUC2", Nombre: "Sucursal Norte", Estab: "002", Activa: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.GuardarBodega(db.WarehouseDTO{Codigo: "SUC3", Nombre: "Duplicada", Estab: "002", Activa: true}); err == nil {
+		t.Error("Dos bodegas activas no deben compartir establecimiento y punto de emisión")
+	}
+
+	t.Run("Inicialización en la principal", func(t *testing.T) {
+		if _, err := inv.AjustarStock("P1", "", 2, false, "tester", "Ingreso"); err != nil {
+			t.Fatal(err)
+		}
+		if got := stockBodega(t, "P1", "PRINCIPAL"); got != 12 {
+			t.Errorf("La principal debería tener 12, tiene %g", got)
+		}
+	})
+
+	var trasladoID uint
+	t.Run("Traslado", func(t *testing.T) {
+		traslado, err := svc.Transferir(db.TransferDTO{
+			Origen:       "PRINCIPAL",
+			Destino:      "SUC2",
+			GuiaRemision: "001-001-000000045",
+			Items:        []db.TransferItemDTO{{SKU: "P1", Cantidad: 5}},
+		}, "tester")
+		if err != nil {
+			t.Fatal(err)
+		}
+		trasladoID = traslado.ID
+		if len(traslado.Items) != 1 || traslado.Estado != "REALIZADA" {
+			t.Errorf("Traslado mal guardado: %+v", traslado)
+		}
+		if got := stockBodega(t, "P1", "PRINCIPAL"); got != 7 {
+			t.Errorf("Origen esperado 7, obtuve %g", got)
+		}
+		if got := stockBodega(t, "P1", "SUC2"); got != 5 {
+			t.Errorf("Destino esperado 5, obtuve %g", got)
+		}
+		if got := stockDe(t, "P1"); got != 12 {
+			t.Errorf("El stock total no debe cambiar con un traslado, obtuve %g", got)
+		}
+
+		_, err = svc.Transferir(db.TransferDTO{Origen: "SUC2", Destino: "PRINCIPAL", Items: []db.TransferItemDTO{{SKU: "P1", Cantidad: 6}}}, "tester")
+		if err == nil {
+			t.Error("Debería rechazar un traslado mayor al stock del origen")
+		}
+		_, err = svc.Transferir(db.TransferDTO{Origen: "SUC2", Destino: "SUC2", Items: []db.TransferItemDTO{{SKU: "P1", Cantidad: 1}}}, "tester")
+		if err == nil {
+			t.Error("Debería rechazar origen y destino iguales")
+		}
+	})
+
+	t.Run("Venta por establecimiento", func(t *testing.T) {
+		// Clave con establecimiento 002 en las posiciones 25-27
+		clave := "0101202601" + "1790011223001" + "1" + "002001" + "000000001" + "12345678" + "1" + "0"
+		err := database.Transaction(func(tx *gorm.DB) error {
+			return descontarVenta(tx, clave, bodegaVentaClave(tx, clave), []db.FacturaItem{{ProductoSKU: "P1", Cantidad: 2}})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := stockBodega(t, "P1", "SUC2"); got != 3 {
+			t.Errorf("La venta debió descontar de SUC2, quedan %g", got)
+		}
+		if got := stockBodega(t, "P1", "PRINCIPAL"); got != 7 {
+			t.Errorf("La principal no debió cambiar, tiene %g", got)
+		}
+	})
+
+	t.Run("Kardex por bodega", func(t *testing.T) {
+		kardex, err := inv.GetKardex("P1", "SUC2", time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(kardex.Movimientos) != 2 || kardex.SaldoFinal != 3 {
+			t.Errorf("Kardex de SUC2 incorrecto: %d movimientos, saldo %g", len(kardex.Movimientos), kardex.SaldoFinal)
+		}
+	})
+
+	t.Run("Anulación", func(t *testing.T) {
+		if err := svc.EliminarBodega("SUC2"); err == nil {
+			t.Error("No se debe eliminar una bodega con movimientos")
+		}
+		if err := svc.AnularTraslado(trasladoID, "tester"); err == nil {
+			t.Fatal("No se debe anular si el destino ya vendió parte de lo recibido")
+		}
+		_, err := svc.Transferir(db.TransferDTO{Origen: "PRINCIPAL", Destino: "SUC2", Items: []db.TransferItemDTO{{SKU: "P1", Cantidad: 2}}}, "tester")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.AnularTraslado(trasladoID, "tester"); err != nil {
+			t.Fatal(err)
+		}
+		if got := stockBodega(t, "P1", "PRINCIPAL"); got != 10 {
+			t.Errorf("La anulación debió devolver el stock al origen, tiene %g", got)
+		}
+		if got := stockBodega(t, "P1", "SUC2"); got != 0 {
+			t.Errorf("El destino debió quedar en 0, tiene %g", got)
+		}
+		if err := svc.AnularTraslado(trasladoID, "tester"); err == nil {
+			t.Error("No se debe anular dos veces")
+		}
+	})
+}
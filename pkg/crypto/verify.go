@@ -0,0 +1,346 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	nsDSig       = "http://www.w3.org/2000/09/xmldsig#"
+	algEnveloped = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algSHA1      = "http://www.w3.org/2000/09/xmldsig#sha1"
+	algSHA256    = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algRSASHA1   = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	algRSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+)
+
+// ErrSinFirma indica que el XML no contiene un bloque ds:Signature.
+var ErrSinFirma = errors.New("el documento no está firmado")
+
+type dsigSignature struct {
+	SignedInfo struct {
+		SignatureMethod struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"http://www.w3.org/2000/09/xmldsig# SignatureMethod"`
+		References []struct {
+			URI        string `xml:"URI,attr"`
+			Transforms []struct {
+				Algorithm string `xml:"Algorithm,attr"`
+			} `xml:"http://www.w3.org/2000/09/xmldsig# Transforms>Transform"`
+			DigestMethod struct {
+				Algorithm string `xml:"Algorithm,attr"`
+			} `xml:"http://www.w3.org/2000/09/xmldsig# DigestMethod"`
+			DigestValue string `xml:"http://www.w3.org/2000/09/xmldsig# DigestValue"`
+		} `xml:"http://www.w3.org/2000/09/xmldsig# Reference"`
+	} `xml:"http://www.w3.org/2000/09/xmldsig# SignedInfo"`
+	SignatureValue  string `xml:"http://www.w3.org/2000/09/xmldsig# SignatureValue"`
+	X509Certificate string `xml:"http://www.w3.org/2000/09/xmldsig# KeyInfo>X509Data>X509Certificate"`
+}
+
+// VerifyXMLSignature verifica la firma XMLDSig/XAdES de un comprobante: los digests de cada
+// referencia y la firma RSA de SignedInfo con el certificado incluido. Devuelve el certificado firmante.
+// No valida la cadena de confianza del certificado.
+func VerifyXMLSignature(data []byte) (*x509.Certificate, error) {
+	var doc struct {
+		Signature *dsigSignature `xml:"http://www.w3.org/2000/09/xmldsig# Signature"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("XML inválido: %v", err)
+	}
+	sig := doc.Signature
+	if sig == nil {
+		return nil, ErrSinFirma
+	}
+
+	// 1. Digests de las referencias
+	for _, ref := range sig.SignedInfo.References {
+		enveloped := false
+		for _, t := range ref.Transforms {
+			if t.Algorithm == algEnveloped {
+				enveloped = true
+			}
+		}
+
+		var match func(uri, local string, attrs []xml.Attr) bool
+		if ref.URI == "" {
+			match = func(string, string, []xml.Attr) bool { return true }
+		} else if strings.HasPrefix(ref.URI, "#") {
+			id := ref.URI[1:]
+			match = func(_, _ string, attrs []xml.Attr) bool {
+				for _, a := range attrs {
+					if (a.Name.Local == "Id" || a.Name.Local == "id" || a.Name.Local == "ID") && a.Name.Space == "" && a.Value == id {
+						return true
+					}
+				}
+				return false
+			}
+		} else {
+			return nil, fmt.Errorf("referencia externa no soportada: %s", ref.URI)
+		}
+
+		canon, err := canonicalizar(data, match, enveloped)
+		if err != nil {
+			return nil, fmt.Errorf("referencia %s: %v", ref.URI, err)
+		}
+		digest, err := calcularDigest(ref.DigestMethod.Algorithm, canon)
+		if err != nil {
+			return nil, err
+		}
+		if digest != limpiarBase64(ref.DigestValue) {
+			return nil, fmt.Errorf("el contenido de %s fue modificado después de firmar", refNombre(ref.URI))
+		}
+	}
+
+	// 2. Firma RSA sobre SignedInfo canonicalizado
+	signedInfo, err := canonicalizar(data, func(uri, local string, _ []xml.Attr) bool {
+		return uri == nsDSig && local == "SignedInfo"
+	}, false)
+	if err != nil {
+		return nil, fmt.Errorf("SignedInfo: %v", err)
+	}
+
+	certDER, err := base64.StdEncoding.DecodeString(limpiarBase64(sig.X509Certificate))
+	if err != nil {
+		return nil, fmt.Errorf("certificado ilegible: %v", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("certificado inválido: %v", err)
+	}
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("el certificado no contiene una llave RSA")
+	}
+
+	sigBytes, err := base64.StdEncoding.DecodeString(limpiarBase64(sig.SignatureValue))
+	if err != nil {
+		return nil, fmt.Errorf("valor de firma ilegible: %v", err)
+	}
+
+	var hash crypto.Hash
+	var hashed []byte
+	switch sig.SignedInfo.SignatureMethod.Algorithm {
+	case algRSASHA1:
+		h := sha1.Sum(signedInfo)
+		hash, hashed = crypto.SHA1, h[:]
+	case algRSASHA256:
+		h := sha256.Sum256(signedInfo)
+		hash, hashed = crypto.SHA256, h[:]
+	default:
+		return nil, fmt.Errorf("algoritmo de firma no soportado: %s", sig.SignedInfo.SignatureMethod.Algorithm)
+	}
+	if err := rsa.VerifyPKCS1v15(pub, hash, hashed, sigBytes); err != nil {
+		return nil, fmt.Errorf("la firma no corresponde al certificado: %v", err)
+	}
+	return cert, nil
+}
+
+func refNombre(uri string) string {
+	if uri == "" {
+		return "el documento"
+	}
+	return uri
+}
+
+func calcularDigest(alg string, data []byte) (string, error) {
+	switch alg {
+	case algSHA1:
+		h := sha1.Sum(data)
+		return base64.StdEncoding.EncodeToString(h[:]), nil
+	case algSHA256:
+		h := sha256.Sum256(data)
+		return base64.StdEncoding.EncodeToString(h[:]), nil
+	}
+	return "", fmt.Errorf("algoritmo de digest no soportado: %s", alg)
+}
+
+func limpiarBase64(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+// canonicalizar serializa en C14N inclusiva (sin comentarios) el primer elemento que cumple match.
+// Con enveloped, omite el subárbol ds:Signature (transformación enveloped-signature).
+func canonicalizar(data []byte, match func(uri, local string, attrs []xml.Attr) bool, enveloped bool) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = true
+
+	var out bytes.Buffer
+	// scopes: declaraciones de namespaces en alcance (prefijo -> URI) por nivel
+	scopes := []map[string]string{{"xml": "http://www.w3.org/XML/1998/namespace"}}
+	// renderizados: namespaces ya escritos en la salida por nivel dentro del subárbol
+	var renderizados []map[string]string
+	capturando := false
+	saltando := 0
+
+	resolver := func(prefix string) string {
+		for i := len(scopes) - 1; i >= 0; i-- {
+			if uri, ok := scopes[i][prefix]; ok {
+				return uri
+			}
+		}
+		return ""
+	}
+
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			decl := map[string]string{}
+			var attrs []xml.Attr
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					decl[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					decl[""] = a.Value
+				default:
+					attrs = append(attrs, a)
+				}
+			}
+			scopes = append(scopes, decl)
+			uri := resolver(t.Name.Space)
+
+			if saltando > 0 {
+				saltando++
+				continue
+			}
+			if !capturando {
+				if !match(uri, t.Name.Local, attrs) {
+					continue
+				}
+				capturando = true
+			} else if enveloped && uri == nsDSig && t.Name.Local == "Signature" {
+				saltando = 1
+				continue
+			}
+
+			// Namespaces a escribir: en el ápice todos los que están en alcance; luego solo los que cambian
+			aEscribir := map[string]string{}
+			if len(renderizados) == 0 {
+				for _, sc := range scopes {
+					for p, u := range sc {
+						aEscribir[p] = u
+					}
+				}
+				delete(aEscribir, "xml")
+				if aEscribir[""] == "" {
+					delete(aEscribir, "")
+				}
+			} else {
+				padre := renderizados[len(renderizados)-1]
+				for p, u := range decl {
+					if padre[p] != u && !(p == "" && u == "" && padre[""] == "") {
+						aEscribir[p] = u
+					}
+				}
+			}
+			actual := map[string]string{}
+			if len(renderizados) > 0 {
+				for p, u := range renderizados[len(renderizados)-1] {
+					actual[p] = u
+				}
+			}
+			for p, u := range aEscribir {
+				actual[p] = u
+			}
+			renderizados = append(renderizados, actual)
+
+			out.WriteString("<" + qname(t.Name))
+			prefijos := make([]string, 0, len(aEscribir))
+			for p := range aEscribir {
+				prefijos = append(prefijos, p)
+			}
+			sort.Strings(prefijos)
+			for _, p := range prefijos {
+				if p == "" {
+					out.WriteString(` xmlns="` + escaparAtributo(aEscribir[p]) + `"`)
+				} else {
+					out.WriteString(" xmlns:" + p + `="` + escaparAtributo(aEscribir[p]) + `"`)
+				}
+			}
+			sort.SliceStable(attrs, func(i, j int) bool {
+				ui, uj := "", ""
+				if attrs[i].Name.Space != "" {
+					ui = resolver(attrs[i].Name.Space)
+				}
+				if attrs[j].Name.Space != "" {
+					uj = resolver(attrs[j].Name.Space)
+				}
+				if ui != uj {
+					return ui < uj
+				}
+				return attrs[i].Name.Local < attrs[j].Name.Local
+			})
+			for _, a := range attrs {
+				out.WriteString(" " + qname(a.Name) + `="` + escaparAtributo(a.Value) + `"`)
+			}
+			out.WriteString(">")
+
+		case xml.EndElement:
+			scopes = scopes[:len(scopes)-1]
+			if saltando > 0 {
+				saltando--
+				continue
+			}
+			if !capturando {
+				continue
+			}
+			out.WriteString("</" + qname(t.Name) + ">")
+			renderizados = renderizados[:len(renderizados)-1]
+			if len(renderizados) == 0 {
+				return out.Bytes(), nil
+			}
+
+		case xml.CharData:
+			if capturando && saltando == 0 {
+				out.WriteString(escaparTexto(string(t)))
+			}
+
+		case xml.ProcInst:
+			if capturando && saltando == 0 {
+				out.WriteString("<?" + t.Target)
+				if len(t.Inst) > 0 {
+					out.WriteString(" " + string(t.Inst))
+				}
+				out.WriteString("?>")
+			}
+		}
+	}
+
+	if capturando {
+		return nil, fmt.Errorf("elemento incompleto")
+	}
+	return nil, fmt.Errorf("elemento referenciado no encontrado")
+}
+
+func qname(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+var escTexto = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+
+var escAtributo = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+
+func escaparTexto(s string) string    { return escTexto.Replace(s) }
+func escaparAtributo(s string) string { return escAtributo.Replace(s) }
@@ -0,0 +1,169 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// firmarTest arma un comprobante firmado con C14N estándar, como lo hacen los sistemas de los proveedores.
+func firmarTest(t *testing.T, contenido string) string {
+	priv, cert := createTestCredentials(t)
+
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<factura id="comprobante" version="1.0.0">` + contenido + `<ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#" Id="Signature1"><ds:SignedInfo>` +
+		`<ds:CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/>` +
+		`<ds:SignatureMethod Algorithm="http://www.w3.org/2000/09/xmldsig#rsa-sha1"/>` +
+		`<ds:Reference URI="#comprobante"><ds:Transforms><ds:Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"/></ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="http://www.w3.org/2000/09/xmldsig#sha1"/><ds:DigestValue>DIGEST</ds:DigestValue></ds:Reference>` +
+		`</ds:SignedInfo><ds:SignatureValue>FIRMA</ds:SignatureValue><ds:KeyInfo><ds:X509Data><ds:X509Certificate>CERT</ds:X509Certificate></ds:X509Data></ds:KeyInfo></ds:Signature></factura>`
+
+	porId := func(_, _ string, attrs []xml.Attr) bool {
+		for _, a := range attrs {
+			if a.Name.Local == "id" && a.Value == "comprobante" {
+				return true
+			}
+		}
+		return false
+	}
+	canon, err := canonicalizar([]byte(doc), porId, true)
+	if err != nil {
+		t.Fatalf("canonicalizar documento: %v", err)
+	}
+	digest := sha1.Sum(canon)
+	doc = strings.Replace(doc, "DIGEST", base64.StdEncoding.EncodeToString(digest[:]), 1)
+	doc = strings.Replace(doc, "CERT", base64.StdEncoding.EncodeToString(cert.Raw), 1)
+
+	signedInfo, err := canonicalizar([]byte(doc), func(uri, local string, _ []xml.Attr) bool {
+		return uri == nsDSig && local == "SignedInfo"
+	}, false)
+	if err != nil {
+		t.Fatalf("canonicalizar SignedInfo: %v", err)
+	}
+	if !strings.HasPrefix(string(signedInfo), `<ds:SignedInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">`) {
+		t.Errorf("SignedInfo debe heredar el namespace ds: %s", signedInfo[:60])
+	}
+	hashed := sha1.Sum(signedInfo)
+	firma, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA1, hashed[:])
+	if err != nil {
+		t.Fatalf("firmar: %v", err)
+	}
+	return strings.Replace(doc, "FIRMA", base64.StdEncoding.EncodeToString(firma), 1)
+}
+
+func TestVerifyXMLSignature(t *testing.T) {
+	firmado := firmarTest(t, `<infoTributaria><ruc>1790016919001</ruc><razonSocial>Proveedor &amp; Cía</razonSocial></infoTributaria><infoFactura><importeTotal>11.50</importeTotal></infoFactura>`)
+
+	if _, err := VerifyXMLSignature([]byte(firmado)); err != nil {
+		t.Fatalf("firma válida rechazada: %v", err)
+	}
+
+	alterado := strings.Replace(firmado, "<importeTotal>11.50", "<importeTotal>1.50", 1)
+	if _, err := VerifyXMLSignature([]byte(alterado)); err == nil {
+		t.Error("se aceptó un comprobante alterado")
+	}
+
+	sinFirma := `<factura id="comprobante"><infoTributaria/></factura>`
+	if _, err := VerifyXMLSignature([]byte(sinFirma)); !errors.Is(err, ErrSinFirma) {
+		t.Errorf("esperaba ErrSinFirma, obtuve %v", err)
+	}
+}
+
+func TestCanonicalizar(t *testing.T) {
+	doc := `<raiz xmlns:a="urn:a"><a:hijo z="1" b="2" xmlns:a="urn:a">x &gt; y</a:hijo><vacio/></raiz>`
+	canon, err := canonicalizar([]byte(doc), func(_, local string, _ []xml.Attr) bool { return local == "raiz" }, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	esperado := `<raiz xmlns:a="urn:a"><a:hijo b="2" z="1">x &gt; y</a:hijo><vacio></vacio></raiz>`
+	if string(canon) != esperado {
+		t.Errorf("C14N incorrecta:\n%s\n%s", canon, esperado)
+	}
+}
+
+// Ejemplos de la recomendación W3C "Canonical XML 1.0" (secciones 3.3 y 3.4, sin DTD): la salida
+// esperada es la publicada en la norma, no la de nuestro firmador. El último caso es el subconjunto
+// que firman los emisores XAdES: SignedProperties hereda los namespaces declarados en ds:Signature.
+func TestCanonicalizar_EjemplosW3C(t *testing.T) {
+	casos := []struct {
+		nombre, elemento, doc, esperado string
+	}{
+		{
+			nombre:   "Etiquetas y namespaces",
+			elemento: "doc",
+			doc: `<doc>
+   <e1   />
+   <e2   ></e2>
+   <e3   name = "elem3"   id="elem3"   />
+   <e4   name="elem4"   id="elem4"   ></e4>
+   <e5 a:attr="out" b:attr="sorted" attr2="all" attr="I'm"
+      xmlns:b="http://www.ietf.org"
+      xmlns:a="http://www.w3.org"
+      xmlns="http://example.org"/>
+   <e6 xmlns="" xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="" xmlns:a="http://www.w3.org">
+            <e9 xmlns="" xmlns:a="http://www.ietf.org"/>
+         </e8>
+      </e7>
+   </e6>
+</doc>`,
+			esperado: `<doc>
+   <e1></e1>
+   <e2></e2>
+   <e3 id="elem3" name="elem3"></e3>
+   <e4 id="elem4" name="elem4"></e4>
+   <e5 xmlns="http://example.org" xmlns:a="http://www.w3.org" xmlns:b="http://www.ietf.org" attr="I'm" attr2="all" b:attr="sorted" a:attr="out"></e5>
+   <e6 xmlns:a="http://www.w3.org">
+      <e7 xmlns="http://www.ietf.org">
+         <e8 xmlns="">
+            <e9 xmlns:a="http://www.ietf.org"></e9>
+         </e8>
+      </e7>
+   </e6>
+</doc>`,
+		},
+		{
+			nombre:   "Caracteres y referencias",
+			elemento: "doc",
+			doc: `<doc>
+   <text>First line&#x0d;&#10;Second line</text>
+   <value>&#x32;</value>
+   <compute><![CDATA[value>"0" && value<"10" ?"valid":"error"]]></compute>
+   <compute expr='value>"0" &amp;&amp; value&lt;"10" ?"valid":"error"'>valid</compute>
+   <norm attr=' &apos;   &#x20;&#13;&#xa;&#9;   &apos; '/>
+</doc>`,
+			esperado: `<doc>
+   <text>First line&#xD;
+Second line</text>
+   <value>2</value>
+   <compute>value&gt;"0" &amp;&amp; value&lt;"10" ?"valid":"error"</compute>
+   <compute expr="value>&quot;0&quot; &amp;&amp; value&lt;&quot;10&quot; ?&quot;valid&quot;:&quot;error&quot;">valid</compute>
+   <norm attr=" '    &#xD;&#xA;&#x9;   ' "></norm>
+</doc>`,
+		},
+		{
+			nombre:   "Propiedades XAdES",
+			elemento: "SignedProperties",
+			doc:      `<factura id="comprobante"><ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#" xmlns:etsi="http://uri.etsi.org/01903/v1.3.2#" Id="S1"><ds:Object><etsi:QualifyingProperties Target="#S1"><etsi:SignedProperties Id="S1-SP"><etsi:SigningTime>2025-01-02T10:00:00-05:00</etsi:SigningTime><ds:DigestValue>abc=</ds:DigestValue></etsi:SignedProperties></etsi:QualifyingProperties></ds:Object></ds:Signature></factura>`,
+			esperado: `<etsi:SignedProperties xmlns:ds="http://www.w3.org/2000/09/xmldsig#" xmlns:etsi="http://uri.etsi.org/01903/v1.3.2#" Id="S1-SP"><etsi:SigningTime>2025-01-02T10:00:00-05:00</etsi:SigningTime><ds:DigestValue>abc=</ds:DigestValue></etsi:SignedProperties>`,
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			canon, err := canonicalizar([]byte(c.doc), func(_, local string, _ []xml.Attr) bool { return local == c.elemento }, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(canon) != c.esperado {
+				t.Errorf("C14N incorrecta:\n%s\nesperado:\n%s", canon, c.esperado)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/johnfercher/maroto/v2"
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
+	"github.com/johnfercher/maroto/v2/pkg/components/image"
+	"github.com/johnfercher/maroto/v2/pkg/components/line"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/config"
+	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/consts/pagesize"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+
+	"kushkiv2/internal/db"
+)
+
+// GenerarCierreCajaPDF crea el cierre Z de una caja: ventas por forma de pago, movimientos de
+// efectivo, arqueo por denominación y la diferencia con lo esperado.
+func GenerarCierreCajaPDF(caja db.CashSessionDTO, configEmisor db.EmisorConfig) ([]byte, error) {
+	cfg := config.NewBuilder().
+		WithPageSize(pagesize.A4).
+		WithLeftMargin(15).
+		WithTopMargin(15).
+		WithRightMargin(15).
+		WithBottomMargin(15).
+		Build()
+
+	m := maroto.New(cfg)
+
+	// =========================================================================
+	// 1. CABECERA
+	// =========================================================================
+
+	colLogo := col.New(4)
+	if configEmisor.LogoPath != "" {
+		if _, err := os.Stat(configEmisor.LogoPath); err == nil {
+			colLogo.Add(image.NewFromFile(configEmisor.LogoPath, props.Rect{Center: false, Percent: 100, Left: 0}))
+		}
+	} else {
+		colLogo.Add(text.New("KUSHKI APP", props.Text{Style: fontstyle.Bold, Color: colorEmeraldPrimary, Size: 16}))
+	}
+
+	m.AddRow(20,
+		colLogo,
+		col.New(8).Add(
+			text.New("CIERRE DE CAJA (Z)", props.Text{Size: 14, Style: fontstyle.Bold, Align: align.Right, Color: colorEmeraldPrimary, Top: 0}),
+			text.New(fmt.Sprintf("No. %d - Punto %s-%s", caja.ID, caja.Estab, caja.PtoEmi), props.Text{Size: 11, Align: align.Right, Top: 8, Style: fontstyle.Bold}),
+		),
+	)
+
+	m.AddRow(5, col.New(12))
+
+	m.AddRow(18,
+		col.New(6).Add(
+			text.New(configEmisor.RazonSocial, props.Text{Size: 11, Style: fontstyle.Bold, Color: colorDarkGray, Top: 0}),
+			text.New("RUC: "+configEmisor.RUC, props.Text{Size: 9, Color: colorGray, Top: 6}),
+			text.New("Cajero: "+caja.Usuario, props.Text{Size: 8, Color: colorGray, Top: 11}),
+		),
+		col.New(6).Add(
+			text.New("APERTURA: "+caja.Apertura, props.Text{Size: 9, Align: align.Right, Top: 0, Style: fontstyle.Bold}),
+			text.New("CIERRE: "+caja.Cierre, props.Text{Size: 8, Align: align.Right, Top: 6, Color: colorGray}),
+			text.New("Cerrada por: "+caja.UsuarioCierre, props.Text{Size: 8, Align: align.Right, Top: 11, Color: colorGray}),
+		),
+	)
+
+	m.AddRow(5, col.New(12).Add(line.New(props.Line{Color: colorEmeraldPrimary, Thickness: 0.5})))
+
+	// =========================================================================
+	// 2. VENTAS POR FORMA DE PAGO
+	// =========================================================================
+
+	encabezadoCierre(m, []columnaCierre{{2, "CÓDIGO", align.Left}, {6, "FORMA DE PAGO", align.Left}, {2, "FACTURAS", align.Center}, {2, "TOTAL", align.Right}})
+	for _, v := range caja.Ventas {
+		filaCierre(m, []columnaCierre{{2, v.FormaPago, align.Left}, {6, v.Descripcion, align.Left}, {2, fmt.Sprintf("%d", v.Facturas), align.Center}, {2, fmtMoney(v.Total), align.Right}})
+	}
+	if len(caja.Ventas) == 0 {
+		filaCierre(m, []columnaCierre{{12, "Sin ventas en el turno", align.Left}})
+	}
+	filaCierre(m, []columnaCierre{{8, "TOTAL VENTAS", align.Right}, {2, fmt.Sprintf("%d", caja.Facturas), align.Center}, {2, fmtMoney(caja.TotalVentas), align.Right}})
+
+	m.AddRow(8, col.New(12))
+
+	// =========================================================================
+	// 3. MOVIMIENTOS DE EFECTIVO
+	// =========================================================================
+
+	if len(caja.Movimientos) > 0 {
+		encabezadoCierre(m, []columnaCierre{{3, "FECHA", align.Left}, {2, "TIPO", align.Left}, {4, "MOTIVO", align.Left}, {1, "USUARIO", align.Left}, {2, "MONTO", align.Right}})
+		for _, mov := range caja.Movimientos {
+			filaCierre(m, []columnaCierre{{3, mov.Fecha, align.Left}, {2, mov.Tipo, align.Left}, {4, mov.Motivo, align.Left}, {1, mov.Usuario, align.Left}, {2, fmtMoney(mov.Monto), align.Right}})
+		}
+		m.AddRow(8, col.New(12))
+	}
+
+	// =========================================================================
+	// 4. ARQUEO Y RESUMEN
+	// =========================================================================
+
+	encabezadoCierre(m, []columnaCierre{{2, "DENOMINACIÓN", align.Left}, {2, "CANTIDAD", align.Center}, {2, "SUBTOTAL", align.Right}, {6, "", align.Left}})
+
+	resumen := []struct {
+		label string
+		val   string
+	}{
+		{"Fondo inicial", fmtMoney(caja.FondoInicial)},
+		{"Ventas en efectivo", fmtMoney(caja.VentasEfectivo)},
+		{"Ingresos", fmtMoney(caja.Ingresos)},
+		{"Egresos", "-" + fmtMoney(caja.Egresos)},
+		{"Efectivo esperado", fmtMoney(caja.EfectivoEsperado)},
+		{"Efectivo contado", fmtMoney(caja.EfectivoContado)},
+	}
+	filas := len(resumen)
+	if len(caja.Conteo) > filas {
+		filas = len(caja.Conteo)
+	}
+	for i := 0; i < filas; i++ {
+		cols := []core.Col{col.New(2), col.New(2), col.New(2)}
+		if i < len(caja.Conteo) {
+			c := caja.Conteo[i]
+			cols = []core.Col{
+				text.NewCol(2, "$ "+fmtMoney(c.Denominacion), props.Text{Size: 8, Align: align.Left, Top: 1.5, Left: 2}),
+				text.NewCol(2, fmt.Sprintf("%d", c.Cantidad), props.Text{Size: 8, Align: align.Center, Top: 1.5}),
+				text.NewCol(2, fmtMoney(c.Subtotal), props.Text{Size: 8, Align: align.Right, Top: 1.5, Right: 2}),
+			}
+		}
+		if i < len(resumen) {
+			cols = append(cols,
+				col.New(1),
+				text.NewCol(3, resumen[i].label, props.Text{Size: 8, Align: align.Right, Color: colorGray, Top: 1.5, Right: 2}),
+				text.NewCol(2, resumen[i].val, props.Text{Size: 8, Align: align.Right, Color: colorDarkGray, Top: 1.5}),
+			)
+		} else {
+			cols = append(cols, col.New(6))
+		}
+		m.AddRow(6, cols...)
+	}
+
+	m.AddRow(5, col.New(12))
+
+	etiqueta := "SIN DIFERENCIA"
+	if caja.Diferencia > 0 {
+		etiqueta = "SOBRANTE"
+	} else if caja.Diferencia < 0 {
+		etiqueta = "FALTANTE"
+	}
+	colObs := col.New(7)
+	if caja.Observacion != "" {
+		colObs.Add(text.New("Observaciones:", props.Text{Style: fontstyle.Bold, Size: 8}))
+		colObs.Add(text.New(caja.Observacion, props.Text{Size: 8, Top: 5, Color: colorGray}))
+	}
+	m.AddRow(12,
+		colObs,
+		col.New(5).WithStyle(&props.Cell{BackgroundColor: colorEmeraldPrimary}).Add(
+			text.New(etiqueta, props.Text{Size: 9, Style: fontstyle.Bold, Color: colorWhite, Align: align.Left, Left: 4, Top: 3.5}),
+			text.New("$ "+fmtMoney(caja.Diferencia), props.Text{Size: 12, Style: fontstyle.Bold, Color: colorWhite, Align: align.Right, Right: 4, Top: 3}),
+		),
+	)
+
+	// =========================================================================
+	// 5. FIRMAS
+	// =========================================================================
+	m.AddRow(25, col.New(12))
+	m.AddRow(10,
+		col.New(5).Add(
+			line.New(props.Line{Color: colorGray, Thickness: 0.3}),
+			text.New("Cajero", props.Text{Size: 8, Align: align.Center, Top: 2, Color: colorGray}),
+		),
+		col.New(2),
+		col.New(5).Add(
+			line.New(props.Line{Color: colorGray, Thickness: 0.3}),
+			text.New("Supervisor", props.Text{Size: 8, Align: align.Center, Top: 2, Color: colorGray}),
+		),
+	)
+
+	document, err := m.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	return document.GetBytes(), nil
+}
+
+type columnaCierre struct {
+	ancho  int
+	texto  string
+	alinea align.Type
+}
+
+func encabezadoCierre(m core.Maroto, columnas []columnaCierre) {
+	cols := make([]core.Col, len(columnas))
+	for i, c := range columnas {
+		cols[i] = text.NewCol(c.ancho, c.texto, props.Text{Style: fontstyle.Bold, Size: 8, Align: c.alinea, Color: colorWhite, Top: 1.5, Left: 2, Right: 2})
+	}
+	m.AddRow(8, cols...).WithStyle(&props.Cell{BackgroundColor: colorEmeraldPrimary})
+}
+
+func filaCierre(m core.Maroto, columnas []columnaCierre) {
+	cols := make([]core.Col, len(columnas))
+	for i, c := range columnas {
+		cols[i] = text.NewCol(c.ancho, c.texto, props.Text{Size: 8, Align: c.alinea, Top: 1.5, Left: 2, Right: 2})
+	}
+	m.AddRow(6, cols...)
+	m.AddRow(1, col.New(12).Add(line.New(props.Line{Color: &props.Color{Red: 240, Green: 240, Blue: 240}})))
+}
@@ -0,0 +1,185 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/johnfercher/maroto/v2"
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
+	"github.com/johnfercher/maroto/v2/pkg/components/image"
+	"github.com/johnfercher/maroto/v2/pkg/components/line"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/config"
+	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/consts/pagesize"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+
+	"kushkiv2/internal/db"
+)
+
+// GenerarReporteConteoPDF crea el reporte de diferencias de una toma física
+func GenerarReporteConteoPDF(conteo db.CountSessionDTO, configEmisor db.EmisorConfig) ([]byte, error) {
+	cfg := config.NewBuilder().
+		WithPageSize(pagesize.A4).
+		WithLeftMargin(15).
+		WithTopMargin(15).
+		WithRightMargin(15).
+		WithBottomMargin(15).
+		Build()
+
+	m := maroto.New(cfg)
+
+	// =========================================================================
+	// 1. CABECERA
+	// =========================================================================
+
+	colLogo := col.New(4)
+	if configEmisor.LogoPath != "" {
+		if _, err := os.Stat(configEmisor.LogoPath); err == nil {
+			colLogo.Add(image.NewFromFile(configEmisor.LogoPath, props.Rect{Center: false, Percent: 100, Left: 0}))
+		}
+	} else {
+		colLogo.Add(text.New("KUSHKI APP", props.Text{Style: fontstyle.Bold, Color: colorEmeraldPrimary, Size: 16}))
+	}
+
+	m.AddRow(20,
+		colLogo,
+		col.New(8).Add(
+			text.New("TOMA FÍSICA DE INVENTARIO", props.Text{Size: 14, Style: fontstyle.Bold, Align: align.Right, Color: colorEmeraldPrimary, Top: 0}),
+			text.New(fmt.Sprintf("No. %d - %s", conteo.ID, conteo.Estado), props.Text{Size: 11, Align: align.Right, Top: 8, Style: fontstyle.Bold}),
+		),
+	)
+
+	m.AddRow(5, col.New(12))
+
+	bodega := conteo.BodegaNombre
+	if bodega == "" {
+		bodega = conteo.Bodega
+	}
+	seccion := conteo.Seccion
+	if seccion == "" {
+		seccion = "Toda la bodega"
+	}
+	cierre := conteo.FechaCierre
+	if cierre == "" {
+		cierre = "-"
+	}
+
+	m.AddRow(18,
+		col.New(6).Add(
+			text.New(configEmisor.RazonSocial, props.Text{Size: 11, Style: fontstyle.Bold, Color: colorDarkGray, Top: 0}),
+			text.New("Bodega: "+bodega, props.Text{Size: 9, Color: colorGray, Top: 6}),
+			text.New("Sección: "+seccion, props.Text{Size: 8, Color: colorGray, Top: 11}),
+		),
+		col.New(6).Add(
+			text.New("APERTURA: "+conteo.Fecha, props.Text{Size: 9, Align: align.Right, Top: 0, Style: fontstyle.Bold}),
+			text.New("CIERRE: "+cierre, props.Text{Size: 8, Align: align.Right, Top: 6, Color: colorGray}),
+			text.New("Responsable: "+conteo.Usuario, props.Text{Size: 8, Align: align.Right, Top: 11, Color: colorGray}),
+		),
+	)
+
+	m.AddRow(5, col.New(12).Add(line.New(props.Line{Color: colorEmeraldPrimary, Thickness: 0.5})))
+
+	// =========================================================================
+	// 2. DETALLE DE DIFERENCIAS
+	// =========================================================================
+
+	m.AddRow(9,
+		text.NewCol(2, "CÓDIGO", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Left, Color: colorWhite, Top: 1.5, Left: 2}),
+		text.NewCol(4, "DESCRIPCIÓN", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Left, Color: colorWhite, Top: 1.5, Left: 2}),
+		text.NewCol(1, "SISTEMA", props.Text{Style: fontstyle.Bold, Size: 7, Align: align.Center, Color: colorWhite, Top: 1.5}),
+		text.NewCol(1, "CONTADO", props.Text{Style: fontstyle.Bold, Size: 7, Align: align.Center, Color: colorWhite, Top: 1.5}),
+		text.NewCol(1, "DIF.", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Center, Color: colorWhite, Top: 1.5}),
+		text.NewCol(1, "COSTO", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Right, Color: colorWhite, Top: 1.5, Right: 2}),
+		text.NewCol(2, "VALOR", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Right, Color: colorWhite, Top: 1.5, Right: 2}),
+	).WithStyle(&props.Cell{BackgroundColor: colorEmeraldPrimary})
+
+	for _, l := range conteo.Lineas {
+		contado := fmt.Sprintf("%g", l.Contado)
+		if l.NoContado {
+			contado = "-"
+		}
+		descripcion := l.Nombre
+		if l.Ubicacion != "" {
+			descripcion += " (" + l.Ubicacion + ")"
+		}
+		estilo := fontstyle.Normal
+		if l.Diferencia != 0 {
+			estilo = fontstyle.Bold
+		}
+		m.AddRow(8,
+			text.NewCol(2, l.SKU, props.Text{Size: 8, Align: align.Left, Top: 2, Color: colorGray, Left: 2}),
+			text.NewCol(4, descripcion, props.Text{Size: 8, Align: align.Left, Top: 2, Left: 2}),
+			text.NewCol(1, fmt.Sprintf("%g", l.Sistema), props.Text{Size: 8, Align: align.Center, Top: 2}),
+			text.NewCol(1, contado, props.Text{Size: 8, Align: align.Center, Top: 2}),
+			text.NewCol(1, fmt.Sprintf("%+g", l.Diferencia), props.Text{Size: 8, Align: align.Center, Top: 2, Style: estilo}),
+			text.NewCol(1, fmtMoney(l.CostoUnitario), props.Text{Size: 8, Align: align.Right, Top: 2, Right: 2}),
+			text.NewCol(2, fmtMoney(l.ValorDiferencia), props.Text{Size: 8, Align: align.Right, Top: 2, Style: estilo, Right: 2}),
+		)
+		m.AddRow(1, col.New(12).Add(line.New(props.Line{Color: &props.Color{Red: 240, Green: 240, Blue: 240}})))
+	}
+
+	m.AddRow(10, col.New(12))
+
+	// =========================================================================
+	// 3. RESUMEN
+	// =========================================================================
+
+	colIzq := col.New(7)
+	if conteo.Nota != "" {
+		colIzq.Add(text.New("Observaciones:", props.Text{Style: fontstyle.Bold, Size: 8}))
+		colIzq.Add(text.New(conteo.Nota, props.Text{Size: 8, Top: 5, Color: colorGray}))
+	}
+
+	resumen := []struct {
+		label string
+		val   string
+	}{
+		{"Productos contados", fmt.Sprintf("%d", conteo.Contados)},
+		{fmt.Sprintf("Sobrantes (%d)", conteo.Sobrantes), fmtMoney(conteo.ValorSobrante)},
+		{fmt.Sprintf("Faltantes (%d)", conteo.Faltantes), fmtMoney(conteo.ValorFaltante)},
+	}
+	for i, r := range resumen {
+		colDerLbl := col.New(3).Add(text.New(r.label, props.Text{Size: 8, Align: align.Right, Color: colorGray, Right: 2}))
+		colDerVal := col.New(2).Add(text.New(r.val, props.Text{Size: 8, Align: align.Right, Color: colorDarkGray}))
+		if i == 0 {
+			m.AddRow(5, colIzq, colDerLbl, colDerVal)
+		} else {
+			m.AddRow(5, col.New(7), colDerLbl, colDerVal)
+		}
+	}
+
+	m.AddRow(5, col.New(12))
+
+	m.AddRow(12,
+		col.New(7),
+		col.New(5).WithStyle(&props.Cell{BackgroundColor: colorEmeraldPrimary}).Add(
+			text.New("DIFERENCIA NETA", props.Text{Size: 9, Style: fontstyle.Bold, Color: colorWhite, Align: align.Left, Left: 4, Top: 3.5}),
+			text.New("$ "+fmtMoney(conteo.ValorSobrante-conteo.ValorFaltante), props.Text{Size: 12, Style: fontstyle.Bold, Color: colorWhite, Align: align.Right, Right: 4, Top: 3}),
+		),
+	)
+
+	// =========================================================================
+	// 4. FIRMAS
+	// =========================================================================
+	m.AddRow(25, col.New(12))
+	m.AddRow(10,
+		col.New(5).Add(
+			line.New(props.Line{Color: colorGray, Thickness: 0.3}),
+			text.New("Responsable del conteo", props.Text{Size: 8, Align: align.Center, Top: 2, Color: colorGray}),
+		),
+		col.New(2),
+		col.New(5).Add(
+			line.New(props.Line{Color: colorGray, Thickness: 0.3}),
+			text.New("Supervisor", props.Text{Size: 8, Align: align.Center, Top: 2, Color: colorGray}),
+		),
+	)
+
+	document, err := m.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	return document.GetBytes(), nil
+}
@@ -0,0 +1,194 @@
+package pdf
+
+import (
+	"fmt"
+
+	"github.com/johnfercher/maroto/v2"
+	"github.com/johnfercher/maroto/v2/pkg/components/code"
+	"github.com/johnfercher/maroto/v2/pkg/components/col"
+	"github.com/johnfercher/maroto/v2/pkg/components/page"
+	"github.com/johnfercher/maroto/v2/pkg/components/row"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
+	"github.com/johnfercher/maroto/v2/pkg/config"
+	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/barcode"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
+	"github.com/johnfercher/maroto/v2/pkg/consts/pagesize"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/johnfercher/maroto/v2/pkg/props"
+
+	"kushkiv2/internal/db"
+)
+
+// Formatos de etiqueta
+const (
+	EtiquetaA4      = "A4"      // Hoja A4 de 3 columnas x 8 filas
+	EtiquetaTermica = "TERMICA" // Rollo térmico de 50 x 25 mm, una etiqueta por página
+)
+
+// Simbologías de código
+const (
+	SimbologiaEAN13   = "EAN13"
+	SimbologiaCode128 = "CODE128"
+	SimbologiaQR      = "QR"
+)
+
+const (
+	etiquetasPorFila   = 3
+	filasEtiquetasA4   = 8
+	gridEtiquetasA4    = 24 // 8 columnas por etiqueta: 3 para el QR y 5 para el texto
+	altoEtiquetaA4     = 33
+	separacionFilasA4  = 3
+	margenEtiquetaTerm = 1.5
+)
+
+// GenerarEtiquetasPDF crea las etiquetas de productos (nombre, código, precio y ubicación) en
+// hoja A4 o rollo térmico. Cada etiqueta se repite según sus Copias.
+func GenerarEtiquetasPDF(etiquetas []db.LabelDTO, opciones db.LabelOptionsDTO) ([]byte, error) {
+	expandidas := []db.LabelDTO{}
+	for _, e := range etiquetas {
+		copias := e.Copias
+		if copias < 1 {
+			copias = 1
+		}
+		for i := 0; i < copias; i++ {
+			expandidas = append(expandidas, e)
+		}
+	}
+	if len(expandidas) == 0 {
+		return nil, fmt.Errorf("no hay etiquetas para imprimir")
+	}
+
+	var m core.Maroto
+	if opciones.Formato == EtiquetaTermica {
+		cfg := config.NewBuilder().
+			WithDimensions(50, 25).
+			WithLeftMargin(margenEtiquetaTerm).
+			WithTopMargin(margenEtiquetaTerm).
+			WithRightMargin(margenEtiquetaTerm).
+			WithBottomMargin(0).
+			Build()
+		m = maroto.New(cfg)
+		for _, e := range expandidas {
+			m.AddPages(page.New().Add(filasEtiquetaTermica(e, opciones)...))
+		}
+	} else {
+		cfg := config.NewBuilder().
+			WithPageSize(pagesize.A4).
+			WithLeftMargin(5).
+			WithTopMargin(10).
+			WithRightMargin(5).
+			WithBottomMargin(0).
+			WithMaxGridSize(gridEtiquetasA4).
+			Build()
+		m = maroto.New(cfg)
+		porPagina := etiquetasPorFila * filasEtiquetasA4
+		for inicio := 0; inicio < len(expandidas); inicio += porPagina {
+			fin := inicio + porPagina
+			if fin > len(expandidas) {
+				fin = len(expandidas)
+			}
+			p := page.New()
+			for fila := inicio; fila < fin; fila += etiquetasPorFila {
+				hasta := fila + etiquetasPorFila
+				if hasta > fin {
+					hasta = fin
+				}
+				p.Add(filasEtiquetaA4(expandidas[fila:hasta], opciones)...)
+				p.Add(row.New(separacionFilasA4))
+			}
+			m.AddPages(p)
+		}
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return nil, err
+	}
+	return document.GetBytes(), nil
+}
+
+// filasEtiquetaTermica arma una etiqueta de 47 x 23.5 mm útiles.
+func filasEtiquetaTermica(e db.LabelDTO, opciones db.LabelOptionsDTO) []core.Row {
+	pie := piesEtiqueta(e, opciones)
+	if e.Simbologia == SimbologiaQR {
+		textos := col.New(8).Add(text.New(e.Nombre, props.Text{Size: 6, Style: fontstyle.Bold, Left: 1}))
+		for i, t := range pie {
+			textos.Add(text.New(t.texto, props.Text{Size: t.size, Style: t.estilo, Left: 1, Top: 9 + float64(i)*4}))
+		}
+		return []core.Row{row.New(22).Add(col.New(4).Add(code.NewQr(e.Codigo, props.Rect{Center: true, Percent: 95})), textos)}
+	}
+	return []core.Row{
+		row.New(4).Add(text.NewCol(12, e.Nombre, props.Text{Size: 6, Style: fontstyle.Bold, Align: align.Center})),
+		row.New(12).Add(col.New(12).Add(code.NewBar(e.Codigo, propsBarra(e)))),
+		row.New(5).Add(colsPie(pie, 6, 6)...),
+	}
+}
+
+// filasEtiquetaA4 arma una fila de hasta tres etiquetas de la hoja.
+func filasEtiquetaA4(fila []db.LabelDTO, opciones db.LabelOptionsDTO) []core.Row {
+	ancho := gridEtiquetasA4 / etiquetasPorFila
+	if len(fila) > 0 && fila[0].Simbologia == SimbologiaQR {
+		r := row.New(altoEtiquetaA4)
+		for _, e := range fila {
+			textos := col.New(ancho - 3).Add(text.New(e.Nombre, props.Text{Size: 8, Style: fontstyle.Bold, Left: 1, Top: 2}))
+			for i, t := range piesEtiqueta(e, opciones) {
+				textos.Add(text.New(t.texto, props.Text{Size: t.size + 1, Style: t.estilo, Left: 1, Top: 14 + float64(i)*5}))
+			}
+			r.Add(col.New(3).Add(code.NewQr(e.Codigo, props.Rect{Center: true, Percent: 90})), textos)
+		}
+		return []core.Row{r}
+	}
+
+	nombres := row.New(7)
+	barras := row.New(17)
+	pies := row.New(altoEtiquetaA4 - 24)
+	for _, e := range fila {
+		nombres.Add(text.NewCol(ancho, e.Nombre, props.Text{Size: 8, Style: fontstyle.Bold, Align: align.Center, Top: 1}))
+		barras.Add(col.New(ancho).Add(code.NewBar(e.Codigo, propsBarra(e))))
+		pies.Add(colsPie(piesEtiqueta(e, opciones), ancho/2, ancho-ancho/2)...)
+	}
+	return []core.Row{nombres, barras, pies}
+}
+
+type textoEtiqueta struct {
+	texto  string
+	size   float64
+	estilo fontstyle.Type
+}
+
+// piesEtiqueta devuelve el código legible, la ubicación y el precio según las opciones.
+func piesEtiqueta(e db.LabelDTO, opciones db.LabelOptionsDTO) []textoEtiqueta {
+	pie := []textoEtiqueta{{texto: e.Codigo, size: 5, estilo: fontstyle.Normal}}
+	if opciones.MostrarUbicacion && e.Ubicacion != "" {
+		pie = append(pie, textoEtiqueta{texto: e.Ubicacion, size: 5, estilo: fontstyle.Normal})
+	}
+	if opciones.MostrarPrecio {
+		pie = append(pie, textoEtiqueta{texto: "$ " + fmtMoney(e.Precio), size: 8, estilo: fontstyle.Bold})
+	}
+	return pie
+}
+
+// colsPie pone el código y la ubicación a la izquierda y el precio a la derecha.
+func colsPie(pie []textoEtiqueta, izq, der int) []core.Col {
+	colIzq := col.New(izq)
+	colDer := col.New(der)
+	top := 0.0
+	for _, t := range pie {
+		if t.size >= 8 {
+			colDer.Add(text.New(t.texto, props.Text{Size: t.size, Style: t.estilo, Align: align.Right, Right: 1}))
+			continue
+		}
+		colIzq.Add(text.New(t.texto, props.Text{Size: t.size, Style: t.estilo, Left: 1, Top: top}))
+		top += 2.5
+	}
+	return []core.Col{colIzq, colDer}
+}
+
+func propsBarra(e db.LabelDTO) props.Barcode {
+	tipo := barcode.Code128
+	if e.Simbologia == SimbologiaEAN13 {
+		tipo = barcode.EAN
+	}
+	return props.Barcode{Center: true, Percent: 90, Type: tipo}
+}
@@ -2,7 +2,11 @@ package pdf
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+
 	"github.com/johnfercher/maroto/v2/pkg/props"
+	srixml "kushkiv2/pkg/xml"
 )
 
 // Colores Globales
@@ -18,6 +22,9 @@ var (
 	colorLightGray  = &props.Color{Red: 200, Green: 200, Blue: 200}
 	colorWhite      = &props.Color{Red: 255, Green: 255, Blue: 255}
 	colorBackground = &props.Color{Red: 245, Green: 245, Blue: 245}
+
+	// Marca de agua para vistas previas (Borrador)
+	colorDraft = &props.Color{Red: 239, Green: 68, Blue: 68}
 )
 
 // Utilidades compartidas
@@ -25,6 +32,26 @@ func fmtMoney(val float64) string {
 	return fmt.Sprintf("%.2f", val)
 }
 
+// fmtCantidad muestra la cantidad sin ceros sobrantes (hasta 6 decimales) y su unidad de medida.
+func fmtCantidad(item srixml.Detalle) string {
+	cantidad := strconv.FormatFloat(math.Round(item.Cantidad*1e6)/1e6, 'f', -1, 64)
+	if item.UnidadMedida != "" {
+		cantidad += " " + item.UnidadMedida
+	}
+	return cantidad
+}
+
+// descripcionDetalle agrega a la descripción los detalles adicionales del ítem (lote, vencimiento).
+func descripcionDetalle(item srixml.Detalle) string {
+	desc := item.Descripcion
+	if item.DetallesAdicionales != nil {
+		for _, d := range item.DetallesAdicionales.DetAdicional {
+			desc += fmt.Sprintf(" | %s: %s", d.Nombre, d.Valor)
+		}
+	}
+	return desc
+}
+
 func getAmbienteText(codigo string) string {
 	if codigo == "2" {
 		return "PRODUCCIÓN"
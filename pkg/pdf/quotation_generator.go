@@ -1,6 +1,7 @@
 package pdf
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/johnfercher/maroto/v2"
@@ -12,13 +13,15 @@ import (
 	"github.com/johnfercher/maroto/v2/pkg/consts/align"
 	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
 	"github.com/johnfercher/maroto/v2/pkg/consts/pagesize"
+	"github.com/johnfercher/maroto/v2/pkg/core"
 	"github.com/johnfercher/maroto/v2/pkg/props"
 
 	"kushkiv2/internal/db"
 )
 
-// GenerarCotizacionPDF crea el PDF de una cotización
-func GenerarCotizacionPDF(cotizacion db.Quotation, items []db.QuotationItem, configEmisor db.EmisorConfig) ([]byte, error) {
+// GenerarCotizacionPDF crea el PDF de una cotización. Con imagenes (SKU -> ruta de la miniatura)
+// distinto de nil, la tabla lleva una columna con la foto de cada producto.
+func GenerarCotizacionPDF(cotizacion db.Quotation, items []db.QuotationItem, configEmisor db.EmisorConfig, imagenes map[string]string) ([]byte, error) {
 	cfg := config.NewBuilder().
 		WithPageSize(pagesize.A4).
 		WithLeftMargin(15).
@@ -95,22 +98,51 @@ func GenerarCotizacionPDF(cotizacion db.Quotation, items []db.QuotationItem, con
 	// 3. TABLA DETALLES
 	// =========================================================================
 
-	m.AddRow(9,
+	// Con fotos, la descripción cede una columna a la imagen y las filas son más altas
+	conImagenes := imagenes != nil
+	anchoDescripcion, altoFila, margenFila := 5, 8.0, 2.0
+	if conImagenes {
+		anchoDescripcion, altoFila, margenFila = 4, 14, 5
+	}
+
+	encabezado := []core.Col{
 		text.NewCol(2, "CÓDIGO", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Left, Color: colorWhite, Top: 1.5, Left: 2}),
 		text.NewCol(1, "CANT.", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Center, Color: colorWhite, Top: 1.5}),
-		text.NewCol(5, "DESCRIPCIÓN", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Left, Color: colorWhite, Top: 1.5, Left: 2}),
+		text.NewCol(anchoDescripcion, "DESCRIPCIÓN", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Left, Color: colorWhite, Top: 1.5, Left: 2}),
 		text.NewCol(2, "P. UNIT", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Right, Color: colorWhite, Top: 1.5, Right: 2}),
 		text.NewCol(2, "TOTAL", props.Text{Style: fontstyle.Bold, Size: 8, Align: align.Right, Color: colorWhite, Top: 1.5, Right: 2}),
-	).WithStyle(&props.Cell{BackgroundColor: colorEmeraldPrimary})
+	}
+	if conImagenes {
+		encabezado = append([]core.Col{col.New(1)}, encabezado...)
+	}
+	m.AddRow(9, encabezado...).WithStyle(&props.Cell{BackgroundColor: colorEmeraldPrimary})
 
+	var descuento float64
 	for _, item := range items {
-		m.AddRow(8,
-			text.NewCol(2, item.ProductoSKU, props.Text{Size: 8, Align: align.Left, Top: 2, Color: colorGray, Left: 2}),
-			text.NewCol(1, fmtMoney(item.Cantidad), props.Text{Size: 8, Align: align.Center, Top: 2}),
-			text.NewCol(5, item.Nombre, props.Text{Size: 8, Align: align.Left, Top: 2, Left: 2}),
-			text.NewCol(2, fmtMoney(item.PrecioUnitario), props.Text{Size: 8, Align: align.Right, Top: 2, Right: 2}),
-			text.NewCol(2, fmtMoney(item.Subtotal), props.Text{Size: 8, Align: align.Right, Top: 2, Style: fontstyle.Bold, Right: 2}),
-		)
+		descripcion := item.Nombre
+		if item.Descuento > 0 {
+			etiqueta := item.Promocion
+			if etiqueta == "" {
+				etiqueta = "Descuento"
+			}
+			descripcion += fmt.Sprintf(" | %s: -%s", etiqueta, fmtMoney(item.Descuento))
+			descuento += item.Descuento
+		}
+		fila := []core.Col{
+			text.NewCol(2, item.ProductoSKU, props.Text{Size: 8, Align: align.Left, Top: margenFila, Color: colorGray, Left: 2}),
+			text.NewCol(1, fmtMoney(item.Cantidad), props.Text{Size: 8, Align: align.Center, Top: margenFila}),
+			text.NewCol(anchoDescripcion, descripcion, props.Text{Size: 8, Align: align.Left, Top: margenFila, Left: 2}),
+			text.NewCol(2, fmtMoney(item.PrecioUnitario), props.Text{Size: 8, Align: align.Right, Top: margenFila, Right: 2}),
+			text.NewCol(2, fmtMoney(item.Subtotal), props.Text{Size: 8, Align: align.Right, Top: margenFila, Style: fontstyle.Bold, Right: 2}),
+		}
+		if conImagenes {
+			colFoto := col.New(1)
+			if ruta, ok := imagenes[item.ProductoSKU]; ok {
+				colFoto.Add(image.NewFromFile(ruta, props.Rect{Center: true, Percent: 90}))
+			}
+			fila = append([]core.Col{colFoto}, fila...)
+		}
+		m.AddRow(altoFila, fila...)
 		m.AddRow(1, col.New(12).Add(line.New(props.Line{Color: &props.Color{Red: 240, Green: 240, Blue: 240}})))
 	}
 
@@ -133,6 +165,9 @@ func GenerarCotizacionPDF(cotizacion db.Quotation, items []db.QuotationItem, con
 		{"Subtotal", fmtMoney(subtotal)},
 		{"IVA", fmtMoney(iva)},
 	}
+	if descuento > 0 {
+		totals = append([]totalRow{{"Descuentos", fmtMoney(descuento)}}, totals...)
+	}
 
 	// Observación
 	colIzq := col.New(7)
@@ -4,8 +4,14 @@ import (
 	"fmt"
 
 	"github.com/johnfercher/maroto/v2"
+	"github.com/johnfercher/maroto/v2/pkg/components/row"
+	"github.com/johnfercher/maroto/v2/pkg/components/text"
 	"github.com/johnfercher/maroto/v2/pkg/config"
+	"github.com/johnfercher/maroto/v2/pkg/consts/align"
+	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
 	"github.com/johnfercher/maroto/v2/pkg/consts/pagesize"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/johnfercher/maroto/v2/pkg/props"
 	
 	srixml "kushkiv2/pkg/xml"
 )
@@ -13,6 +19,16 @@ import (
 // GenerarRIDE crea el PDF de la factura usando el tema seleccionado.
 // themeName puede ser: "modern" (default), "minimal", "corporate".
 func GenerarRIDE(factura srixml.FacturaXML, logoPath string, themeName string) ([]byte, error) {
+	return generarRIDE(factura, logoPath, themeName, false)
+}
+
+// GenerarRIDEBorrador crea una vista previa del RIDE con la marca "BORRADOR" en cada página.
+// Se usa para la previsualización antes de emitir; no tiene validez tributaria.
+func GenerarRIDEBorrador(factura srixml.FacturaXML, logoPath string, themeName string) ([]byte, error) {
+	return generarRIDE(factura, logoPath, themeName, true)
+}
+
+func generarRIDE(factura srixml.FacturaXML, logoPath string, themeName string, borrador bool) ([]byte, error) {
 	// 1. Configuración Base de Maroto
 	cfg := config.NewBuilder().
 		WithPageSize(pagesize.A4).
@@ -24,6 +40,12 @@ func GenerarRIDE(factura srixml.FacturaXML, logoPath string, themeName string) (
 
 	m := maroto.New(cfg)
 
+	if borrador {
+		if err := m.RegisterHeader(borradorRow()); err != nil {
+			return nil, fmt.Errorf("error agregando marca de borrador: %w", err)
+		}
+	}
+
 	// 2. Selección de Estrategia (Factory)
 	var theme InvoiceTheme
 
@@ -49,6 +71,19 @@ func GenerarRIDE(factura srixml.FacturaXML, logoPath string, themeName string) (
 	return document.GetBytes(), nil
 }
 
+// borradorRow genera la franja de marca de agua usada en las vistas previas.
+func borradorRow() core.Row {
+	return row.New(12).Add(
+		text.NewCol(12, "BORRADOR - SIN VALIDEZ TRIBUTARIA", props.Text{
+			Size:  16,
+			Style: fontstyle.Bold,
+			Align: align.Center,
+			Color: colorDraft,
+			Top:   2,
+		}),
+	)
+}
+
 // StrPtr Helper para punteros de string (usado en tests o utilidades externas)
 func StrPtr(s string) *string {
 	return &s
@@ -74,4 +74,14 @@ func TestGenerarRIDE(t *testing.T) {
 			t.Error("Debería generar PDF (fallback a modern)")
 		}
 	})
+
+	t.Run("Borrador", func(t *testing.T) {
+		bytes, err := pdf.GenerarRIDEBorrador(factura, "", "modern")
+		if err != nil {
+			t.Fatalf("Error generando RIDE borrador: %v", err)
+		}
+		if len(bytes) == 0 || string(bytes[0:4]) != "%PDF" {
+			t.Error("El RIDE borrador no es un PDF válido")
+		}
+	})
 }
@@ -109,8 +109,8 @@ func (t *ModernTheme) Build(m core.Maroto, f srixml.FacturaXML, logoPath string)
 	for _, item := range f.Detalles {
 		m.AddRow(8,
 			text.NewCol(2, item.CodigoPrincipal, props.Text{Size: 8, Top: 2, Left: 2}),
-			text.NewCol(1, fmtMoney(item.Cantidad), props.Text{Size: 8, Align: align.Center, Top: 2}),
-			text.NewCol(5, item.Descripcion, props.Text{Size: 8, Top: 2, Left: 2}),
+			text.NewCol(1, fmtCantidad(item), props.Text{Size: 8, Align: align.Center, Top: 2}),
+			text.NewCol(5, descripcionDetalle(item), props.Text{Size: 8, Top: 2, Left: 2}),
 			text.NewCol(2, fmtMoney(item.PrecioUnitario), props.Text{Size: 8, Align: align.Right, Top: 2, Right: 2}),
 			text.NewCol(2, fmtMoney(item.PrecioTotalSinImpuesto), props.Text{Size: 8, Align: align.Right, Top: 2, Right: 2, Style: fontstyle.Bold}),
 		)
@@ -175,8 +175,8 @@ func (t *MinimalTheme) Build(m core.Maroto, f srixml.FacturaXML, logoPath string
 	for _, item := range f.Detalles {
 		m.AddRow(6,
 			text.NewCol(2, item.CodigoPrincipal, props.Text{Size: 8}),
-			text.NewCol(6, item.Descripcion, props.Text{Size: 8}),
-			text.NewCol(2, fmtMoney(item.Cantidad), props.Text{Size: 8, Align: align.Center}),
+			text.NewCol(6, descripcionDetalle(item), props.Text{Size: 8}),
+			text.NewCol(2, fmtCantidad(item), props.Text{Size: 8, Align: align.Center}),
 			text.NewCol(2, fmtMoney(item.PrecioTotalSinImpuesto), props.Text{Size: 8, Align: align.Right}),
 		)
 	}
@@ -245,8 +245,8 @@ func (t *CorporateTheme) Build(m core.Maroto, f srixml.FacturaXML, logoPath stri
 		}
 		m.AddRow(7,
 			text.NewCol(2, item.CodigoPrincipal, props.Text{Size: 8, Align: align.Center, Top: 1.5}),
-			text.NewCol(1, fmtMoney(item.Cantidad), props.Text{Size: 8, Align: align.Center, Top: 1.5}),
-			text.NewCol(5, item.Descripcion, props.Text{Size: 8, Top: 1.5}),
+			text.NewCol(1, fmtCantidad(item), props.Text{Size: 8, Align: align.Center, Top: 1.5}),
+			text.NewCol(5, descripcionDetalle(item), props.Text{Size: 8, Top: 1.5}),
 			text.NewCol(2, fmtMoney(item.PrecioUnitario), props.Text{Size: 8, Align: align.Right, Top: 1.5, Right: 2}),
 			text.NewCol(2, fmtMoney(item.PrecioTotalSinImpuesto), props.Text{Size: 8, Align: align.Right, Top: 1.5, Right: 2}),
 		).WithStyle(&props.Cell{BackgroundColor: bg})
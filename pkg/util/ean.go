@@ -0,0 +1,22 @@
+package util
+
+// DigitoEAN13 calcula el dígito de control de los 12 primeros dígitos de un EAN-13.
+func DigitoEAN13(base string) int {
+	suma := 0
+	for i := 0; i < 12 && i < len(base); i++ {
+		digito := int(base[i] - '0')
+		if i%2 == 1 {
+			digito *= 3
+		}
+		suma += digito
+	}
+	return (10 - suma%10) % 10
+}
+
+// EsEAN13 indica si el código tiene 13 dígitos y un dígito de control válido.
+func EsEAN13(codigo string) bool {
+	if len(codigo) != 13 || !soloDigitos(codigo) {
+		return false
+	}
+	return DigitoEAN13(codigo) == int(codigo[12]-'0')
+}
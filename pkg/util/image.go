@@ -1,8 +1,10 @@
 package util
 
 import (
+	"bytes"
+	"fmt"
 	"image"
-	_ "image/jpeg"
+	"image/jpeg"
 	"image/png"
 	"os"
 	"path/filepath"
@@ -82,3 +84,78 @@ func IsImage(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
 	return ext == ".png" || ext == ".jpg" || ext == ".jpeg"
 }
+
+// Tamaños de las fotos de productos (lado mayor, en píxeles)
+const (
+	ProductImageMaxSize = 800
+	ProductThumbSize    = 160
+)
+
+// ProcessAndSaveProductImage normaliza la foto de un producto (PNG o JPG): la redimensiona a
+// ProductImageMaxSize de lado mayor sobre fondo blanco y la guarda como JPEG junto con su
+// miniatura (ver ThumbnailPath). Devuelve la ruta de la imagen.
+func ProcessAndSaveProductImage(data []byte, outputDir, name string) (string, error) {
+	// Se revisan las dimensiones antes de decodificar para no reservar memoria de más
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if cfg.Width > 10000 || cfg.Height > 10000 {
+		return "", fmt.Errorf("imagen demasiado grande (%dx%d)", cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", err
+	}
+
+	outputPath := filepath.Join(outputDir, name+".jpg")
+	if err := saveJPEG(fitImage(img, ProductImageMaxSize), outputPath); err != nil {
+		return "", err
+	}
+	if err := saveJPEG(fitImage(img, ProductThumbSize), ThumbnailPath(outputPath)); err != nil {
+		os.Remove(outputPath)
+		return "", err
+	}
+	return outputPath, nil
+}
+
+// ThumbnailPath devuelve la ruta de la miniatura de una foto de producto.
+func ThumbnailPath(imagePath string) string {
+	ext := filepath.Ext(imagePath)
+	return strings.TrimSuffix(imagePath, ext) + "_thumb" + ext
+}
+
+// fitImage reduce la imagen (manteniendo el aspecto) para que su lado mayor no pase de maxSize y
+// la pinta sobre fondo blanco, ya que JPEG no admite transparencia.
+func fitImage(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSize || height > maxSize {
+		if width >= height {
+			height = max(1, height*maxSize/width)
+			width = maxSize
+		} else {
+			width = max(1, width*maxSize/height)
+			height = maxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+func saveJPEG(img image.Image, path string) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	return jpeg.Encode(outFile, img, &jpeg.Options{Quality: 85})
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ValidarCedula verifica una cédula ecuatoriana (10 dígitos, módulo 10).
+func ValidarCedula(cedula string) error {
+	if len(cedula) != 10 || !soloDigitos(cedula) {
+		return fmt.Errorf("la cédula debe tener 10 dígitos")
+	}
+	if err := validarProvincia(cedula); err != nil {
+		return err
+	}
+	if cedula[2] >= '6' {
+		return fmt.Errorf("tercer dígito inválido para cédula")
+	}
+
+	suma := 0
+	for i := 0; i < 9; i++ {
+		d := int(cedula[i] - '0')
+		if i%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		suma += d
+	}
+	verificador := (10 - suma%10) % 10
+	if verificador != int(cedula[9]-'0') {
+		return fmt.Errorf("dígito verificador de cédula inválido")
+	}
+	return nil
+}
+
+// ValidarRUC verifica un RUC ecuatoriano de persona natural, sociedad privada o entidad pública.
+func ValidarRUC(ruc string) error {
+	if len(ruc) != 13 || !soloDigitos(ruc) {
+		return fmt.Errorf("el RUC debe tener 13 dígitos")
+	}
+	if err := validarProvincia(ruc); err != nil {
+		return err
+	}
+
+	switch tercero := ruc[2]; {
+	case tercero < '6': // Persona natural: cédula + establecimiento
+		if err := ValidarCedula(ruc[:10]); err != nil {
+			return fmt.Errorf("RUC inválido: %v", err)
+		}
+		if ruc[10:] == "000" {
+			return fmt.Errorf("RUC inválido: establecimiento 000")
+		}
+	case tercero == '6': // Entidad pública
+		if !modulo11(ruc[:8], []int{3, 2, 7, 6, 5, 4, 3, 2}, int(ruc[8]-'0')) || ruc[9:] == "0000" {
+			return fmt.Errorf("RUC de entidad pública inválido")
+		}
+	case tercero == '9': // Sociedad privada
+		if !modulo11(ruc[:9], []int{4, 3, 2, 7, 6, 5, 4, 3, 2}, int(ruc[9]-'0')) || ruc[10:] == "000" {
+			return fmt.Errorf("RUC de sociedad inválido")
+		}
+	default:
+		return fmt.Errorf("tercer dígito del RUC inválido")
+	}
+	return nil
+}
+
+func modulo11(digitos string, coeficientes []int, verificador int) bool {
+	suma := 0
+	for i, c := range coeficientes {
+		suma += int(digitos[i]-'0') * c
+	}
+	esperado := 11 - suma%11
+	if esperado == 11 {
+		esperado = 0
+	}
+	return esperado == verificador
+}
+
+func validarProvincia(id string) error {
+	provincia, _ := strconv.Atoi(id[:2])
+	if (provincia < 1 || provincia > 24) && provincia != 30 {
+		return fmt.Errorf("código de provincia inválido: %s", id[:2])
+	}
+	return nil
+}
+
+func soloDigitos(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,8 +1,10 @@
 package xml
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
+	"io"
 )
 
 // GenerateXML convierte el struct FacturaXML en un arreglo de bytes XML.
@@ -15,3 +17,57 @@ func GenerateXML(factura *FacturaXML) ([]byte, error) {
 	header := []byte(xml.Header)
 	return append(header, output...), nil
 }
+
+// ParseFacturaXML interpreta un XML de factura (firmado o no) y devuelve su estructura.
+func ParseFacturaXML(data []byte) (*FacturaXML, error) {
+	var factura FacturaXML
+	if err := xml.Unmarshal(data, &factura); err != nil {
+		return nil, fmt.Errorf("error al interpretar XML: %v", err)
+	}
+	return &factura, nil
+}
+
+// ExtraerComprobante devuelve el XML del comprobante contenido en data. Acepta el comprobante
+// directo (<factura>...) o envuelto en <autorizacion>, incluso dentro de la respuesta completa
+// del web service (<RespuestaAutorizacionComprobante>). aut es nil si no había envoltorio.
+func ExtraerComprobante(data []byte) (comprobante []byte, aut *AutorizacionXML, err error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	enRespuesta := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, nil, fmt.Errorf("el archivo no contiene un comprobante")
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error al interpretar XML: %v", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch se.Name.Local {
+		case "autorizacion":
+			var a AutorizacionXML
+			if err := dec.DecodeElement(&a, &se); err != nil {
+				return nil, nil, fmt.Errorf("error al interpretar autorización: %v", err)
+			}
+			if len(bytes.TrimSpace([]byte(a.Comprobante))) == 0 {
+				return nil, nil, fmt.Errorf("la autorización no incluye el comprobante")
+			}
+			return []byte(a.Comprobante), &a, nil
+		case "Envelope", "Body", "autorizacionComprobanteResponse", "RespuestaAutorizacionComprobante", "autorizaciones":
+			// Contenedores de la respuesta del SRI: seguir buscando la primera <autorizacion>
+			enRespuesta = true
+		default:
+			if enRespuesta {
+				// claveAccesoConsultada, numeroComprobantes, etc.
+				if err := dec.Skip(); err != nil {
+					return nil, nil, fmt.Errorf("error al interpretar XML: %v", err)
+				}
+				continue
+			}
+			// El primer elemento es el comprobante mismo
+			return data, nil, nil
+		}
+	}
+}
@@ -98,3 +98,14 @@ type CampoAdicional struct {
 	Nombre string `xml:"nombre,attr"`
 	Value  string `xml:",chardata"`
 }
+
+// AutorizacionXML es el envoltorio que devuelve el SRI (y que envían los proveedores)
+// con el comprobante firmado dentro de <comprobante>, normalmente como CDATA.
+type AutorizacionXML struct {
+	XMLName            xml.Name `xml:"autorizacion"`
+	Estado             string   `xml:"estado"`
+	NumeroAutorizacion string   `xml:"numeroAutorizacion"`
+	FechaAutorizacion  string   `xml:"fechaAutorizacion"`
+	Ambiente           string   `xml:"ambiente"`
+	Comprobante        string   `xml:"comprobante"`
+}